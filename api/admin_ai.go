@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"gorm.io/gorm"
 
 	"sealchat/pm"
+	"sealchat/service"
 	aiService "sealchat/service/ai"
 	"sealchat/utils"
 )
@@ -309,3 +311,30 @@ func AdminAIQuotaDelete(c *fiber.Ctx) error {
 	}
 	return c.JSON(fiber.Map{"message": "AI 用户配额覆盖已删除"})
 }
+
+func AdminSemanticIndexStatus(c *fiber.Ctx) error {
+	if !CanWithSystemRole(c, pm.PermModAdmin) {
+		return c.SendStatus(fiber.StatusForbidden)
+	}
+	return c.JSON(service.GetSemanticIndexStatus())
+}
+
+// AdminSemanticIndexBackfill 后台重建单个频道的向量索引，进度见状态接口的 backfilling
+func AdminSemanticIndexBackfill(c *fiber.Ctx) error {
+	if !CanWithSystemRole(c, pm.PermModAdmin) {
+		return c.SendStatus(fiber.StatusForbidden)
+	}
+	if !service.SemanticSearchEnabled() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": service.ErrSemanticSearchUnavailable.Error()})
+	}
+	channelID := strings.TrimSpace(c.Params("channelId"))
+	if channelID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "缺少频道ID"})
+	}
+	go func() {
+		if err := service.SemanticIndexBackfillChannel(context.Background(), channelID); err != nil {
+			log.Printf("semantic-index: 频道 %s 回填失败: %v", channelID, err)
+		}
+	}()
+	return c.JSON(fiber.Map{"message": "已开始回填"})
+}
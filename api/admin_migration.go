@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
@@ -54,6 +55,49 @@ func ImageMigrationExecute(c *fiber.Ctx) error {
 	})
 }
 
+// ImageSanitizePreview returns the number of existing images whose metadata has not been stripped
+func ImageSanitizePreview(c *fiber.Ctx) error {
+	stats, err := service.GetImageSanitizePreview()
+	if err != nil {
+		return wrapErrorStatus(c, http.StatusInternalServerError, err, "获取元数据清理预览失败")
+	}
+	return c.JSON(fiber.Map{
+		"stats":   stats,
+		"enabled": service.ImageMetadataStripEnabled(),
+	})
+}
+
+type ImageSanitizeExecuteRequest struct {
+	BatchSize int  `json:"batchSize"`
+	DryRun    bool `json:"dryRun"`
+}
+
+// ImageSanitizeExecute strips EXIF/XMP metadata from a batch of existing images
+func ImageSanitizeExecute(c *fiber.Ctx) error {
+	var req ImageSanitizeExecuteRequest
+	if err := c.BodyParser(&req); err != nil {
+		req.BatchSize = 100
+		req.DryRun = false
+	}
+	if req.BatchSize <= 0 {
+		req.BatchSize = 100
+	}
+	if req.BatchSize > 1000 {
+		req.BatchSize = 1000
+	}
+
+	stats, results, err := service.SanitizeExistingImages(req.BatchSize, req.DryRun)
+	if err != nil {
+		return wrapErrorStatus(c, http.StatusInternalServerError, err, "执行元数据清理失败")
+	}
+
+	return c.JSON(fiber.Map{
+		"stats":   stats,
+		"results": results,
+		"dryRun":  req.DryRun,
+	})
+}
+
 // AudioFolderMigrationPreview returns statistics about audio folder migration
 func AudioFolderMigrationPreview(c *fiber.Ctx) error {
 	stats, err := service.GetAudioFolderMigrationPreview()
@@ -87,3 +131,54 @@ func AudioFolderMigrationExecute(c *fiber.Ctx) error {
 		"dryRun":  req.DryRun,
 	})
 }
+
+// AudioAnalysisBatchStatus returns progress of the audio loudness re-analysis job
+func AudioAnalysisBatchStatus(c *fiber.Ctx) error {
+	status, err := service.GetAudioAnalysisBatchStatus()
+	if err != nil {
+		return wrapErrorStatus(c, http.StatusInternalServerError, err, "获取响度分析进度失败")
+	}
+	return c.JSON(fiber.Map{
+		"status": status,
+	})
+}
+
+type AudioAnalysisBatchExecuteRequest struct {
+	Force     bool `json:"force"`
+	BatchSize int  `json:"batchSize"`
+	DryRun    bool `json:"dryRun"`
+}
+
+// AudioAnalysisBatchExecute starts loudness/waveform re-analysis for existing audio assets
+func AudioAnalysisBatchExecute(c *fiber.Ctx) error {
+	var req AudioAnalysisBatchExecuteRequest
+	if err := c.BodyParser(&req); err != nil {
+		req = AudioAnalysisBatchExecuteRequest{}
+	}
+	if req.BatchSize <= 0 {
+		req.BatchSize = 500
+	}
+	if req.BatchSize > 5000 {
+		req.BatchSize = 5000
+	}
+
+	status, err := service.StartAudioAnalysisBatch(service.AudioAnalysisBatchRequest{
+		Force:     req.Force,
+		BatchSize: req.BatchSize,
+		DryRun:    req.DryRun,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrAudioAnalysisBusy) {
+			return wrapErrorStatus(c, http.StatusConflict, err, "已有响度分析任务正在运行")
+		}
+		if errors.Is(err, service.ErrAudioAnalysisUnavailable) {
+			return wrapErrorStatus(c, http.StatusServiceUnavailable, err, "响度分析不可用")
+		}
+		return wrapErrorStatus(c, http.StatusInternalServerError, err, "启动响度分析失败")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": status,
+		"dryRun": req.DryRun,
+	})
+}
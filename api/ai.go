@@ -65,35 +65,7 @@ func AITaskRun(ctx *fiber.Ctx) error {
 		Runner:     runner,
 	})
 	if err != nil {
-		if errors.Is(err, aiService.ErrInputTooLong) {
-			maxInputChars := 0
-			if appConfig != nil {
-				maxInputChars = utils.NormalizeAIConfig(appConfig.AI).Features[featureKey].Params.MaxInputChars
-			}
-			err = aiService.FormatInputTooLongError(featureKey, len([]rune(strings.TrimSpace(body.Input))), maxInputChars)
-		}
-		status := fiber.StatusBadRequest
-		switch err.(type) {
-		case *aiService.AIQuotaExceededError:
-			status = fiber.StatusForbidden
-		default:
-			if errors.Is(err, aiService.ErrUserCustomProviderRequired) {
-				status = fiber.StatusForbidden
-			} else if strings.Contains(err.Error(), "no ai provider available") {
-				status = fiber.StatusServiceUnavailable
-			} else if strings.Contains(err.Error(), "unavailable") {
-				status = fiber.StatusForbidden
-			} else if strings.Contains(err.Error(), "pricing") {
-				status = fiber.StatusServiceUnavailable
-			} else if strings.Contains(err.Error(), "ai usage unavailable") {
-				status = fiber.StatusBadGateway
-			} else if strings.Contains(err.Error(), "quota reservation missing") {
-				status = fiber.StatusInternalServerError
-			}
-		}
-		if strings.Contains(err.Error(), "no ai provider available") {
-			status = fiber.StatusServiceUnavailable
-		}
+		status, err := resolveAITaskError(err, featureKey, body.Input)
 		return ctx.Status(status).JSON(fiber.Map{"message": err.Error()})
 	}
 	result := output.Result
@@ -104,3 +76,36 @@ func AITaskRun(ctx *fiber.Ctx) error {
 		"providerId": result.ProviderID,
 	})
 }
+
+func resolveAITaskError(err error, featureKey string, input string) (int, error) {
+	if errors.Is(err, aiService.ErrInputTooLong) {
+		maxInputChars := 0
+		if appConfig != nil {
+			maxInputChars = utils.NormalizeAIConfig(appConfig.AI).Features[featureKey].Params.MaxInputChars
+		}
+		err = aiService.FormatInputTooLongError(featureKey, len([]rune(strings.TrimSpace(input))), maxInputChars)
+	}
+	status := fiber.StatusBadRequest
+	switch err.(type) {
+	case *aiService.AIQuotaExceededError:
+		status = fiber.StatusForbidden
+	default:
+		if errors.Is(err, aiService.ErrUserCustomProviderRequired) {
+			status = fiber.StatusForbidden
+		} else if strings.Contains(err.Error(), "no ai provider available") {
+			status = fiber.StatusServiceUnavailable
+		} else if strings.Contains(err.Error(), "unavailable") {
+			status = fiber.StatusForbidden
+		} else if strings.Contains(err.Error(), "pricing") {
+			status = fiber.StatusServiceUnavailable
+		} else if strings.Contains(err.Error(), "ai usage unavailable") {
+			status = fiber.StatusBadGateway
+		} else if strings.Contains(err.Error(), "quota reservation missing") {
+			status = fiber.StatusInternalServerError
+		}
+	}
+	if strings.Contains(err.Error(), "no ai provider available") {
+		status = fiber.StatusServiceUnavailable
+	}
+	return status, err
+}
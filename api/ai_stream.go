@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	aiService "sealchat/service/ai"
+	"sealchat/utils"
+)
+
+const aiStreamHeartbeat = 15 * time.Second
+
+type aiStreamRegistration struct {
+	userID string
+	cancel context.CancelFunc
+}
+
+var aiStreamRegistry = struct {
+	sync.Mutex
+	streams map[string]aiStreamRegistration
+}{streams: map[string]aiStreamRegistration{}}
+
+func registerAIStream(streamID, userID string, cancel context.CancelFunc) func() {
+	aiStreamRegistry.Lock()
+	aiStreamRegistry.streams[streamID] = aiStreamRegistration{userID: userID, cancel: cancel}
+	aiStreamRegistry.Unlock()
+	return func() {
+		aiStreamRegistry.Lock()
+		delete(aiStreamRegistry.streams, streamID)
+		aiStreamRegistry.Unlock()
+	}
+}
+
+// aiStreamWriter 串行化 SSE 写入，心跳与增量来自不同 goroutine
+type aiStreamWriter struct {
+	mu     sync.Mutex
+	writer *bufio.Writer
+	failed bool
+}
+
+func (w *aiStreamWriter) event(name string, payload any) bool {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false
+	}
+	return w.write(fmt.Sprintf("event: %s\ndata: %s\n\n", name, data))
+}
+
+func (w *aiStreamWriter) write(frame string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failed {
+		return false
+	}
+	if _, err := w.writer.WriteString(frame); err != nil || w.writer.Flush() != nil {
+		w.failed = true
+		return false
+	}
+	return true
+}
+
+// AITaskStream 以 SSE 推送 AI 任务结果：ready → delta* → done|error。
+// 客户端断开或调用取消接口都会中止上游请求，平台额度按已产生的用量结算。
+func AITaskStream(ctx *fiber.Ctx) error {
+	user := getCurUser(ctx)
+	if user == nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "未登录"})
+	}
+	var body struct {
+		WorldID   string `json:"worldId"`
+		ChannelID string `json:"channelId"`
+		Input     string `json:"input"`
+		Source    string `json:"source"`
+	}
+	if err := ctx.BodyParser(&body); err != nil {
+		return err
+	}
+	featureKey := strings.TrimSpace(ctx.Params("featureKey"))
+	runner := aiRunnerFactory(func() *utils.AppConfig { return appConfig })
+	cfg := utils.AIConfig{}
+	if appConfig != nil {
+		cfg = appConfig.AI
+	}
+
+	streamID := utils.NewID()
+	runCtx, cancel := context.WithCancel(context.Background())
+	unregister := registerAIStream(streamID, user.ID, cancel)
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream; charset=utf-8")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache, no-transform")
+	ctx.Set("X-Accel-Buffering", "no")
+	ctx.Context().SetBodyStreamWriter(func(writer *bufio.Writer) {
+		defer unregister()
+		defer cancel()
+		out := &aiStreamWriter{writer: writer}
+		if !out.event("ready", fiber.Map{"streamId": streamID, "featureKey": featureKey}) {
+			return
+		}
+
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(aiStreamHeartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if !out.write(": heartbeat\n\n") {
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+
+		output, err := aiService.RunTaskWithBilling(runCtx, aiService.BilledRunInput{
+			Config:     cfg,
+			User:       user,
+			FeatureKey: featureKey,
+			WorldID:    body.WorldID,
+			Input:      body.Input,
+			Source:     strings.TrimSpace(body.Source),
+			Runner:     runner,
+			OnDelta: func(delta string) error {
+				if !out.event("delta", fiber.Map{"text": delta}) {
+					cancel()
+					return context.Canceled
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			status, err := resolveAITaskError(err, featureKey, body.Input)
+			canceled := errors.Is(err, context.Canceled) || runCtx.Err() != nil
+			out.event("error", fiber.Map{
+				"message":  err.Error(),
+				"status":   status,
+				"canceled": canceled,
+				"partial":  output.Result.Result,
+				"billed":   output.Billed,
+			})
+			return
+		}
+		result := output.Result
+		out.event("done", fiber.Map{
+			"featureKey": result.FeatureKey,
+			"result":     result.Result,
+			"model":      result.Model,
+			"providerId": result.ProviderID,
+		})
+	})
+	return nil
+}
+
+// AITaskStreamCancel 主动中止进行中的流式任务，仅限发起者本人
+func AITaskStreamCancel(ctx *fiber.Ctx) error {
+	user := getCurUser(ctx)
+	if user == nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "未登录"})
+	}
+	streamID := strings.TrimSpace(ctx.Params("streamId"))
+	aiStreamRegistry.Lock()
+	registration, ok := aiStreamRegistry.streams[streamID]
+	aiStreamRegistry.Unlock()
+	if !ok || registration.userID != user.ID {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "任务不存在或已结束"})
+	}
+	registration.cancel()
+	return ctx.JSON(fiber.Map{"success": true})
+}
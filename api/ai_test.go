@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
@@ -335,3 +338,212 @@ func TestAdminAIConfigUpdatePersistsIncomingProviderAPIKey(t *testing.T) {
 		t.Fatalf("config.yaml missing apiKey, got:\n%s", string(rawConfig))
 	}
 }
+
+func newAIStreamTestConfig(baseURL string) *utils.AppConfig {
+	cfg := utils.ReadConfig()
+	cfg.DSN = "file::memory:?cache=shared"
+	model.DBInit(cfg)
+	cfg.AI = utils.NormalizeAIConfig(utils.AIConfig{
+		Enabled: true,
+		Providers: []utils.AIProviderConfig{{
+			ID:      "stream-provider",
+			Name:    "Stream",
+			Enabled: true,
+			BaseURL: baseURL,
+			APIKey:  "secret",
+			Models:  []string{"stream-model"},
+			Weight:  1,
+		}},
+		Pricing: []utils.AIModelPricingConfig{{
+			ProviderID:                 "stream-provider",
+			Model:                      "stream-model",
+			PromptPricePer1MTokens:     1,
+			CompletionPricePer1MTokens: 2,
+		}},
+		Features: map[string]utils.AIFeatureConfig{
+			"polish": {
+				Enabled:       true,
+				DefaultPrompt: "prompt",
+				DefaultModel:  "stream-model",
+				Access:        utils.AIFeatureAccessConfig{Mode: utils.AIFeatureAccessAll},
+			},
+		},
+	})
+	return cfg
+}
+
+func TestAITaskStreamSendsDeltasAndSettlesFromFinalUsage(t *testing.T) {
+	originalConfig := appConfig
+	originalFactory := aiRunnerFactory
+	defer func() {
+		appConfig = originalConfig
+		aiRunnerFactory = originalFactory
+	}()
+
+	var upstream map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&upstream)
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id":"c1","object":"chat.completion.chunk","model":"stream-model","choices":[{"index":0,"delta":{"content":"润色"}}]}`,
+			`{"id":"c1","object":"chat.completion.chunk","model":"stream-model","choices":[{"index":0,"delta":{"content":"完成"}}]}`,
+			`{"id":"c1","object":"chat.completion.chunk","model":"stream-model","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":34,"total_tokens":46}}`,
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	appConfig = newAIStreamTestConfig(server.URL)
+	aiRunnerFactory = originalFactory
+	userID := "ai-stream-" + utils.NewIDWithLength(6)
+
+	app := fiber.New()
+	app.Post("/ai/tasks/:featureKey/stream", func(c *fiber.Ctx) error {
+		c.Locals("user", &model.UserModel{StringPKBaseModel: model.StringPKBaseModel{ID: userID}})
+		return AITaskStream(c)
+	})
+	body, _ := json.Marshal(map[string]any{"input": "需要润色的文字", "source": "platform"})
+	req := httptest.NewRequest("POST", "/ai/tasks/polish/stream", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test error: %v", err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	stream := string(raw)
+
+	if upstream["stream"] != true {
+		t.Fatalf("upstream request should enable stream: %#v", upstream)
+	}
+	for _, want := range []string{"event: ready", `event: delta` + "\n" + `data: {"text":"润色"}`, "event: done", `"result":"润色完成"`} {
+		if !strings.Contains(stream, want) {
+			t.Fatalf("stream missing %q:\n%s", want, stream)
+		}
+	}
+
+	var ledger model.AIUsageLedgerModel
+	if err := model.GetDB().Where("user_id = ?", userID).Limit(1).Find(&ledger).Error; err != nil || ledger.ID == "" {
+		t.Fatalf("ledger not written: %v", err)
+	}
+	if ledger.PromptTokens != 12 || ledger.CompletionTokens != 34 {
+		t.Fatalf("ledger usage = %d/%d, want 12/34", ledger.PromptTokens, ledger.CompletionTokens)
+	}
+	var reservation model.AIQuotaReservationModel
+	model.GetDB().Where("user_id = ?", userID).Limit(1).Find(&reservation)
+	if reservation.Status != aiService.AIQuotaReservationStatusSettled {
+		t.Fatalf("reservation status = %q, want settled", reservation.Status)
+	}
+}
+
+func TestAITaskStreamEstimatesUsageWhenProviderOmitsIt(t *testing.T) {
+	originalConfig := appConfig
+	originalFactory := aiRunnerFactory
+	defer func() {
+		appConfig = originalConfig
+		aiRunnerFactory = originalFactory
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"c1","object":"chat.completion.chunk","model":"stream-model","choices":[{"index":0,"delta":{"content":"润色完成"}}]}`+"\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	appConfig = newAIStreamTestConfig(server.URL)
+	aiRunnerFactory = originalFactory
+	userID := "ai-nousage-" + utils.NewIDWithLength(6)
+
+	app := fiber.New()
+	app.Post("/ai/tasks/:featureKey/stream", func(c *fiber.Ctx) error {
+		c.Locals("user", &model.UserModel{StringPKBaseModel: model.StringPKBaseModel{ID: userID}})
+		return AITaskStream(c)
+	})
+	body, _ := json.Marshal(map[string]any{"input": "需要润色的文字", "source": "platform"})
+	req := httptest.NewRequest("POST", "/ai/tasks/polish/stream", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test error: %v", err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	stream := string(raw)
+	if !strings.Contains(stream, "event: done") || strings.Contains(stream, "event: error") {
+		t.Fatalf("complete stream without usage should finish normally:\n%s", stream)
+	}
+
+	var ledger model.AIUsageLedgerModel
+	if err := model.GetDB().Where("user_id = ?", userID).Limit(1).Find(&ledger).Error; err != nil || ledger.ID == "" {
+		t.Fatalf("ledger not written: %v", err)
+	}
+	if ledger.PromptTokens <= 0 || ledger.CompletionTokens != 4 {
+		t.Fatalf("ledger usage = %d/%d, want estimated usage", ledger.PromptTokens, ledger.CompletionTokens)
+	}
+	var reservation model.AIQuotaReservationModel
+	model.GetDB().Where("user_id = ?", userID).Limit(1).Find(&reservation)
+	if reservation.Status != aiService.AIQuotaReservationStatusSettled {
+		t.Fatalf("reservation status = %q, want settled", reservation.Status)
+	}
+}
+
+type aiStreamingRunnerFunc func(ctx context.Context, req aiService.RunRequest, onDelta aiService.StreamDeltaFunc) (aiService.RunResult, error)
+
+func (fn aiStreamingRunnerFunc) Run(ctx context.Context, req aiService.RunRequest) (aiService.RunResult, error) {
+	return fn(ctx, req, nil)
+}
+
+func (fn aiStreamingRunnerFunc) RunStream(ctx context.Context, req aiService.RunRequest, onDelta aiService.StreamDeltaFunc) (aiService.RunResult, error) {
+	return fn(ctx, req, onDelta)
+}
+
+func TestRunTaskWithBillingSettlesCanceledStream(t *testing.T) {
+	cfg := newAIStreamTestConfig("http://127.0.0.1:0")
+	userID := "ai-cancel-" + utils.NewIDWithLength(6)
+	runCtx, cancel := context.WithCancel(context.Background())
+
+	runner := aiStreamingRunnerFunc(func(ctx context.Context, req aiService.RunRequest, onDelta aiService.StreamDeltaFunc) (aiService.RunResult, error) {
+		_ = onDelta("半截")
+		cancel()
+		return aiService.RunResult{
+			FeatureKey: req.FeatureKey,
+			Result:     "半截",
+			Model:      "stream-model",
+			ProviderID: "stream-provider",
+			Usage:      aiService.RunUsage{PromptTokens: 8, CompletionTokens: 2},
+		}, ctx.Err()
+	})
+	var deltas []string
+	output, err := aiService.RunTaskWithBilling(runCtx, aiService.BilledRunInput{
+		Config:     cfg.AI,
+		User:       &model.UserModel{StringPKBaseModel: model.StringPKBaseModel{ID: userID}},
+		FeatureKey: "polish",
+		Input:      "需要润色的文字",
+		Source:     "platform",
+		Runner:     runner,
+		OnDelta: func(delta string) error {
+			deltas = append(deltas, delta)
+			return nil
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if !output.Billed || len(deltas) != 1 {
+		t.Fatalf("canceled stream should be billed for partial output: %#v deltas=%v", output, deltas)
+	}
+	var log model.AIUsageLogModel
+	model.GetDB().Where("user_id = ?", userID).Limit(1).Find(&log)
+	if log.Status != "canceled" || log.CompletionTokens != 2 {
+		t.Fatalf("unexpected usage log: %#v", log)
+	}
+	var reservation model.AIQuotaReservationModel
+	model.GetDB().Where("user_id = ?", userID).Limit(1).Find(&reservation)
+	if reservation.Status != aiService.AIQuotaReservationStatusSettled {
+		t.Fatalf("reservation status = %q, want settled", reservation.Status)
+	}
+}
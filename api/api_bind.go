@@ -33,6 +33,7 @@ var appConfig *utils.AppConfig
 var appFs afero.Fs
 var serveAppWithOptionalCertificateForInit = serveAppWithOptionalCertificate
 var startOneBotReverseRuntimeForInit = startOneBotReverseRuntime
+var startQQBridgeRuntimeForInit = startQQBridgeRuntime
 
 // SyncConfigToDB 将配置同步到数据库
 func SyncConfigToDB(config *utils.AppConfig, source string) {
@@ -404,6 +405,9 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 		if strings.Contains(requestURI, "/api/v1/worlds/") && strings.Contains(requestURI, "/theater/packages/import") {
 			return fasthttp.RequestConfig{MaxRequestBodySize: service.TheaterPackageRequestBodyLimit()}
 		}
+		if strings.Contains(requestURI, "/api/v1/worlds/package/import") {
+			return fasthttp.RequestConfig{MaxRequestBodySize: service.WorldPackageRequestBodyLimit()}
+		}
 		return fasthttp.RequestConfig{MaxRequestBodySize: bodyLimit}
 	}
 	app.Use(certificateHTTPRedirectMiddleware(config))
@@ -442,6 +446,10 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	v1.Get("/public/ob/channels/:channelId/sticky-note-folders", ObserverStickyNoteFolderList)
 	v1.Get("/public/ob/channels/:channelId/battle-reports/:reportId", ObserverBattleReportGet)
 	v1.Get("/public/ob/channels/:channelId/iforms", ObserverChannelIFormList)
+	v1.Get("/public/ob/:slug/feed.atom", ObserverFeedAtom)
+	v1.Get("/public/ob/:slug/feed.json", ObserverFeedJSON)
+	v1.Get("/public/ob/:slug/channels/:channelId/feed.atom", ObserverFeedAtom)
+	v1.Get("/public/ob/:slug/channels/:channelId/feed.json", ObserverFeedJSON)
 	BindTheaterObserverRoutes(v1)
 	v1.Get("/public/worlds/:worldId/keywords", WorldKeywordPublicListHandler)
 	v1.Get("/public/worlds/:worldId/keywords/effective", EffectiveWorldKeywordPublicListHandler)
@@ -461,6 +469,7 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	v1.Get("/webhook/worlds/:worldId/digests", WebhookWorldDigestList)
 	v1.Get("/webhook/worlds/:worldId/digests/latest", WebhookWorldDigestLatest)
 	v1.Post("/webhook/channels/:channelId/messages", WebhookAuthMiddleware, WebhookMessages)
+	v1.Post("/qq-bridges/:bridgeId/events", QQBridgeEvents)
 	// 必须在 v1Auth.Use(SignCheckMiddleware) 之前注册。
 	// Fiber 同前缀 group middleware 会按注册顺序吞掉后续路由；若放在后面，playToken 请求会先被 SignCheckMiddleware 拦成 401。
 	v1.Get("/audio/stream/:id", OptionalSignCheckMiddleware, AudioAssetStream)
@@ -491,6 +500,15 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	// User preferences
 	v1Auth.Get("/user/preferences", UserPreferencesGet)
 	v1Auth.Post("/user/preferences", UserPreferencesUpsert)
+	v1Auth.Get("/user/blocks", UserBlockListHandler)
+	v1Auth.Post("/user/blocks", UserBlockCreateHandler)
+	v1Auth.Delete("/user/blocks/:userId", UserBlockDeleteHandler)
+	v1Auth.Get("/user/privacy", UserPrivacyGetHandler)
+	v1Auth.Put("/user/privacy", UserPrivacyUpdateHandler)
+	v1Auth.Post("/reports", ReportCreateHandler)
+	v1Auth.Get("/reports/mine", ReportMineHandler)
+	v1Auth.Get("/reports/:reportId", ReportGetHandler)
+	v1Auth.Post("/reports/:reportId/resolve", ReportResolveHandler)
 	v1Auth.Get("/app-notification/settings", AppNotificationSettingsGet)
 	v1Auth.Put("/app-notification/settings", AppNotificationSettingsPut)
 	v1Auth.Post("/app-notification/server-chan/test", AppNotificationServerChanTest)
@@ -506,6 +524,9 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	v1Auth.Get("/user/input-stats/by-channel", UserInputStatsByChannel)
 	v1Auth.Get("/user/input-stats/timeline", UserInputStatsTimeline)
 	v1Auth.Get("/user/input-stats/sessions", UserInputStatsSessions)
+	v1Auth.Get("/user/dice-stats", UserDiceStats)
+	v1Auth.Get("/channels/:channelId/dice-stats", ChannelDiceStats)
+	v1Auth.Get("/worlds/:worldId/dice-stats", WorldDiceStats)
 
 	v1Auth.Get("/gallery/collections", GalleryCollectionsList)
 	v1Auth.Post("/gallery/collections", GalleryCollectionCreate)
@@ -539,6 +560,8 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	v1Auth.Post("/attachment-confirm", AttachmentSetConfirm)
 	v1Auth.Post("/attachments-delete", AttachmentDelete)
 	v1Auth.Get("/attachment/:id/meta", AttachmentMeta)
+	v1Auth.Get("/attachment/:id/video", AttachmentVideoInfo)
+	v1Auth.Post("/attachment/:id/video/retry", AttachmentVideoRetry)
 
 	v1Auth.Get("/channel-identities", ChannelIdentityList)
 	v1Auth.Get("/channel-identities/:id", ChannelIdentityGet)
@@ -562,6 +585,8 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	v1Auth.Put("/channels/:channelId/identity-mode-config", ChannelIdentityModeConfigUpsert)
 	v1Auth.Get("/ai/capabilities", AICapabilitiesGet)
 	v1Auth.Post("/ai/tasks/:featureKey", AITaskRun)
+	v1Auth.Post("/ai/tasks/:featureKey/stream", AITaskStream)
+	v1Auth.Post("/ai/tasks/streams/:streamId/cancel", AITaskStreamCancel)
 	v1Auth.Post("/channel-identities/:id/bind-character-card", ChannelIdentityBindCharacterCard)
 	v1Auth.Post("/channel-identities/:id/unbind-character-card", ChannelIdentityUnbindCharacterCard)
 
@@ -623,6 +648,7 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	webhookIntegrations.Post("/", WebhookIntegrationCreate)
 	webhookIntegrations.Post("/:id/rotate", WebhookIntegrationRotate)
 	webhookIntegrations.Post("/:id/revoke", WebhookIntegrationRevoke)
+	v1Auth.Get("/channels/:channelId/qq-bridge", QQBridgeGet)
 
 	// Digest push settings (reuse original UI entry position, replace capability semantics)
 	v1Auth.Get("/channels/:channelId/digest-push", DigestPushSettingsGet)
@@ -659,6 +685,7 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	audio := v1Auth.Group("/audio")
 	audio.Get("/assets", AudioAssetList)
 	audio.Get("/assets/:id", AudioAssetGet)
+	audio.Get("/assets/:id/analysis", AudioAssetAnalysisGet)
 	audio.Post("/assets/:id/play-token", AudioAssetPlayToken)
 	audio.Get("/folders", AudioFolderList)
 	audio.Get("/scenes", AudioSceneList)
@@ -676,6 +703,7 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	audioAdmin.Get("/assets/import/jobs/:jobId", AudioAssetImportJobStatus)
 	audioAdmin.Post("/assets/reorder", AudioAssetReorder)
 	audioAdmin.Patch("/assets/:id", AudioAssetUpdate)
+	audioAdmin.Post("/assets/:id/analyze", AudioAssetAnalyze)
 	audioAdmin.Delete("/assets/:id", AudioAssetDelete)
 	audioAdmin.Post("/folders", AudioFolderCreate)
 	audioAdmin.Patch("/folders/:id", AudioFolderUpdate)
@@ -684,6 +712,7 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	audioAdmin.Patch("/scenes/:id", AudioSceneUpdate)
 	audioAdmin.Delete("/scenes/:id", AudioSceneDelete)
 	audioAdmin.Post("/state", AudioPlaybackStateSet)
+	audioAdmin.Post("/state/transition", AudioPlaybackTransitionSet)
 
 	v1Auth.Get("/channel-role-list", ChannelRoles)
 	v1Auth.Get("/channel-member-list", ChannelMembers)
@@ -711,6 +740,10 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	worldGroup.Get("", WorldList)
 	worldGroup.Post("/", WorldCreateHandler)
 	worldGroup.Post("", WorldCreateHandler)
+	worldGroup.Post("/package/import", WorldPackageImportCreate)
+	worldGroup.Get("/package/jobs/:jobId", WorldPackageJobGet)
+	worldGroup.Get("/package/jobs/:jobId/download", WorldPackageDownload)
+	worldGroup.Delete("/package/jobs/:jobId", WorldPackageJobDelete)
 	worldGroup.Get("/:worldId", WorldDetail)
 	worldGroup.Get("/:worldId/observer-link", WorldObserverLinkGetHandler)
 	worldGroup.Put("/:worldId/observer-link", WorldObserverLinkUpdateHandler)
@@ -721,7 +754,22 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	worldGroup.Put("/:worldId/dice3d/profile", WorldDice3DProfilePut)
 	worldGroup.Put("/:worldId/theater-presentation-template", WorldTheaterPresentationTemplateSet)
 	worldGroup.Delete("/:worldId", WorldDeleteHandler)
+	worldGroup.Post("/:worldId/package/export", WorldPackageExportCreate)
 	worldGroup.Post("/:worldId/join", WorldJoinHandler)
+	worldGroup.Get("/join-requests/mine", WorldJoinRequestMineHandler)
+	worldGroup.Get("/:worldId/join-application", WorldJoinApplicationHandler)
+	worldGroup.Get("/:worldId/join-requests", WorldJoinRequestListHandler)
+	worldGroup.Post("/:worldId/join-requests", WorldJoinRequestSubmitHandler)
+	worldGroup.Post("/:worldId/join-requests/:requestId/review", WorldJoinRequestReviewHandler)
+	worldGroup.Post("/:worldId/join-requests/:requestId/withdraw", WorldJoinRequestWithdrawHandler)
+	worldGroup.Get("/:worldId/bans", WorldBanListHandler)
+	worldGroup.Delete("/:worldId/bans/:userId", WorldUnbanHandler)
+	worldGroup.Get("/ownership-transfers/incoming", WorldOwnershipTransferIncomingHandler)
+	worldGroup.Post("/ownership-transfers/:transferId/accept", WorldOwnershipTransferAcceptHandler)
+	worldGroup.Post("/ownership-transfers/:transferId/decline", WorldOwnershipTransferDeclineHandler)
+	worldGroup.Post("/ownership-transfers/:transferId/cancel", WorldOwnershipTransferCancelHandler)
+	worldGroup.Get("/:worldId/ownership-transfers", WorldOwnershipTransferListHandler)
+	worldGroup.Post("/:worldId/ownership-transfers", WorldOwnershipTransferCreateHandler)
 	worldGroup.Post("/:worldId/leave", WorldLeaveHandler)
 	worldGroup.Post("/:worldId/archive", WorldArchiveSetHandler)
 	worldGroup.Delete("/:worldId/archive", WorldArchiveSetHandler)
@@ -741,6 +789,17 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	worldGroup.Get("/:worldId/members", WorldMemberListHandler)
 	worldGroup.Delete("/:worldId/members/:userId", WorldMemberRemoveHandler)
 	worldGroup.Post("/:worldId/members/:userId/role", WorldMemberRoleHandler)
+	worldGroup.Post("/:worldId/members/:userId/mute", WorldMemberMuteHandler)
+	worldGroup.Get("/:worldId/reports", WorldReportListHandler)
+	worldGroup.Get("/:worldId/content-filter/rules", ContentFilterRuleListHandler)
+	worldGroup.Post("/:worldId/content-filter/rules", ContentFilterRuleCreateHandler)
+	worldGroup.Post("/:worldId/content-filter/rules/import", ContentFilterRuleImportHandler)
+	worldGroup.Patch("/:worldId/content-filter/rules/:ruleId", ContentFilterRuleUpdateHandler)
+	worldGroup.Delete("/:worldId/content-filter/rules/:ruleId", ContentFilterRuleDeleteHandler)
+	worldGroup.Post("/:worldId/content-filter/test", ContentFilterTestHandler)
+	worldGroup.Get("/:worldId/content-filter/stats", ContentFilterStatsHandler)
+	worldGroup.Get("/:worldId/content-filter/pending", ContentFilterPendingListHandler)
+	worldGroup.Post("/:worldId/content-filter/pending/:pendingId/review", ContentFilterPendingReviewHandler)
 	worldGroup.Get("/:worldId/keywords", WorldKeywordListHandler)
 	worldGroup.Get("/:worldId/keywords/effective", EffectiveWorldKeywordListHandler)
 	worldGroup.Get("/:worldId/keywords/categories", WorldKeywordCategoriesHandler)
@@ -758,22 +817,40 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	worldGroup.Post("/:worldId/keywords/reorder", WorldKeywordReorderHandler)
 	worldGroup.Post("/:worldId/keywords/import", WorldKeywordImportHandler)
 	worldGroup.Get("/:worldId/keywords/export", WorldKeywordExportHandler)
+	worldGroup.Post("/:worldId/keywords/extract", WorldKeywordExtractHandler)
+	worldGroup.Post("/:worldId/keywords/extract/accept", WorldKeywordExtractAcceptHandler)
 	worldGroup.Get("/:worldId/external-glossaries", WorldExternalGlossaryListHandler)
 	worldGroup.Post("/:worldId/external-glossaries/:libraryId/enable", WorldExternalGlossaryEnableHandler)
 	worldGroup.Post("/:worldId/external-glossaries/:libraryId/disable", WorldExternalGlossaryDisableHandler)
 	worldGroup.Post("/:worldId/external-glossaries/bulk-enable", WorldExternalGlossaryBulkEnableHandler)
 	worldGroup.Post("/:worldId/external-glossaries/bulk-disable", WorldExternalGlossaryBulkDisableHandler)
+	worldGroup.Get("/:worldId/sticker-packs", WorldStickerPackListHandler)
+	worldGroup.Post("/:worldId/sticker-packs", WorldStickerPackCreateHandler)
+	worldGroup.Post("/:worldId/sticker-packs/reorder", WorldStickerPackReorderHandler)
+	worldGroup.Post("/:worldId/sticker-packs/import", WorldStickerPackImportHandler)
+	worldGroup.Patch("/:worldId/sticker-packs/:packId", WorldStickerPackUpdateHandler)
+	worldGroup.Delete("/:worldId/sticker-packs/:packId", WorldStickerPackDeleteHandler)
+	worldGroup.Get("/:worldId/sticker-packs/:packId/export", WorldStickerPackExportHandler)
+	worldGroup.Post("/:worldId/sticker-packs/:packId/stickers", WorldStickerAddHandler)
+	worldGroup.Post("/:worldId/sticker-packs/:packId/stickers/reorder", WorldStickerReorderHandler)
+	worldGroup.Patch("/:worldId/stickers/:stickerId", WorldStickerUpdateHandler)
+	worldGroup.Delete("/:worldId/stickers/:stickerId", WorldStickerDeleteHandler)
+	worldGroup.Get("/:worldId/sticker-packs/:packId/shares", WorldStickerPackShareListHandler)
+	worldGroup.Post("/:worldId/sticker-packs/:packId/shares", WorldStickerPackShareHandler)
+	worldGroup.Delete("/:worldId/sticker-packs/:packId/shares/:targetWorldId", WorldStickerPackUnshareHandler)
 	worldGroup.Get("/:worldId/archived-channels", ArchivedChannelList)
 	v1Auth.Post("/worlds/invites/:slug/consume", WorldInviteConsumeHandler)
 	v1Auth.Post("/channels/archive", ChannelArchive)
 	v1Auth.Post("/channels/unarchive", ChannelUnarchive)
 	v1Auth.Delete("/channels/archived", ChannelPermanentDelete)
 	v1Auth.Get("/channel-presence", ChannelPresence)
+	v1Auth.Get("/voice/ice-servers", VoiceICEConfig)
 	v1Auth.Get("/channels/:channelId/message-active-days", ChannelMessageActiveDaysHandler)
 	v1Auth.Get("/channels/:channelId/battle-reports", BattleReportList)
 	v1Auth.Post("/channels/:channelId/battle-reports", BattleReportCreate)
 	v1Auth.Post("/channels/:channelId/battle-reports/summarize-input", BattleReportSummarizeInput)
 	v1Auth.Post("/channels/:channelId/battle-reports/summarize", BattleReportSummarize)
+	v1Auth.Post("/channels/:channelId/battle-reports/summarize-estimate", BattleReportSummarizeEstimate)
 	v1Auth.Post("/channels/:channelId/battle-reports/reorder", BattleReportReorder)
 	v1Auth.Get("/channels/:channelId/battle-report-display", BattleReportDisplayGet)
 	v1Auth.Post("/channels/:channelId/battle-report-display", BattleReportDisplayEnsure)
@@ -783,6 +860,7 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	v1Auth.Get("/battle-reports/:reportId", BattleReportGet)
 	v1Auth.Patch("/battle-reports/:reportId", BattleReportUpdate)
 	v1Auth.Delete("/battle-reports/:reportId", BattleReportDelete)
+	v1Auth.Post("/battle-reports/:reportId/summarize-resume", BattleReportSummarizeResume)
 	v1Auth.Post("/chat/export", ChatExportCreate)
 	v1Auth.Post("/chat/export/batch", ChatExportBatchCreate)
 	v1Auth.Get("/chat/export", ChatExportList)
@@ -792,6 +870,12 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	v1Auth.Post("/chat/export/test", ChatExportTest)
 	v1Auth.Post("/chat/export/:taskId/upload", ChatExportUpload)
 	v1Auth.Post("/chat/export/:taskId/upload-batch", ChatExportBatchUpload)
+	v1Auth.Get("/chat/export-schedules", ChatExportScheduleList)
+	v1Auth.Post("/chat/export-schedules", ChatExportScheduleCreate)
+	v1Auth.Put("/chat/export-schedules/:scheduleId", ChatExportScheduleUpdate)
+	v1Auth.Delete("/chat/export-schedules/:scheduleId", ChatExportScheduleDelete)
+	v1Auth.Post("/chat/export-schedules/:scheduleId/run", ChatExportScheduleRun)
+	v1Auth.Get("/chat/export-schedules/:scheduleId/runs", ChatExportScheduleRuns)
 	// 聊天记录导入
 	chatImport := v1Auth.Group("/channels/:channelId/import")
 	chatImport.Get("/templates", ChatImportTemplates)
@@ -820,11 +904,25 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	v1AuthAdmin.Post("/admin/bot-token-update", BotTokenUpdate)
 	v1AuthAdmin.Post("/admin/bot-token-delete", BotTokenDelete)
 	v1AuthAdmin.Post("/admin/bot-token-batch-delete", BotTokenBatchDelete)
+	v1AuthAdmin.Put("/channels/:channelId/qq-bridge", QQBridgeUpsert)
+	v1AuthAdmin.Delete("/channels/:channelId/qq-bridge", QQBridgeDelete)
 	v1AuthAdmin.Post("/admin/system-bots/cleanup-orphaned", CleanupOrphanSystemBots)
 	v1AuthAdmin.Get("/admin/user-list", AdminUserList)
 	v1AuthAdmin.Post("/admin/user-disable", AdminUserDisable)
 	v1AuthAdmin.Post("/admin/user-enable", AdminUserEnable)
 	v1AuthAdmin.Post("/admin/user-delete", AdminUserDelete)
+	v1AuthAdmin.Get("/admin/worlds/abandoned", AdminAbandonedWorldList)
+	v1AuthAdmin.Post("/admin/worlds/:worldId/force-transfer", AdminWorldForceTransfer)
+	v1AuthAdmin.Get("/admin/reports", AdminReportListHandler)
+	v1AuthAdmin.Get("/admin/content-filter/rules", ContentFilterRuleListHandler)
+	v1AuthAdmin.Post("/admin/content-filter/rules", ContentFilterRuleCreateHandler)
+	v1AuthAdmin.Post("/admin/content-filter/rules/import", ContentFilterRuleImportHandler)
+	v1AuthAdmin.Patch("/admin/content-filter/rules/:ruleId", ContentFilterRuleUpdateHandler)
+	v1AuthAdmin.Delete("/admin/content-filter/rules/:ruleId", ContentFilterRuleDeleteHandler)
+	v1AuthAdmin.Post("/admin/content-filter/test", ContentFilterTestHandler)
+	v1AuthAdmin.Get("/admin/content-filter/stats", ContentFilterStatsHandler)
+	v1AuthAdmin.Get("/admin/content-filter/pending", ContentFilterPendingListHandler)
+	v1AuthAdmin.Post("/admin/content-filter/pending/:pendingId/review", ContentFilterPendingReviewHandler)
 	v1AuthAdmin.Post("/admin/user-password-reset", AdminUserResetPassword)
 	v1AuthAdmin.Post("/admin/user-role-link-by-user-id", AdminUserRoleLinkByUserId)
 	v1AuthAdmin.Post("/admin/user-role-unlink-by-user-id", AdminUserRoleUnlinkByUserId)
@@ -842,6 +940,8 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	v1AuthAdmin.Post("/admin/ai/models", AdminAIProviderModelsDiscover)
 	v1AuthAdmin.Get("/admin/ai/usage-logs", AdminAIUsageLogs)
 	v1AuthAdmin.Post("/admin/ai/usage-logs/cleanup", AdminAIUsageLogsCleanup)
+	v1AuthAdmin.Get("/admin/ai/semantic-index", AdminSemanticIndexStatus)
+	v1AuthAdmin.Post("/admin/ai/semantic-index/channels/:channelId/backfill", AdminSemanticIndexBackfill)
 	v1AuthAdmin.Get("/admin/ai-quotas", AdminAIQuotaList)
 	v1AuthAdmin.Get("/admin/ai-quotas/:userId", AdminAIQuotaGet)
 	v1AuthAdmin.Put("/admin/ai-quotas/:userId", AdminAIQuotaUpsert)
@@ -909,10 +1009,14 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 	// Image migration routes
 	v1AuthAdmin.Get("/admin/image-migration/preview", ImageMigrationPreview)
 	v1AuthAdmin.Post("/admin/image-migration/execute", ImageMigrationExecute)
+	v1AuthAdmin.Get("/admin/image-sanitize/preview", ImageSanitizePreview)
+	v1AuthAdmin.Post("/admin/image-sanitize/execute", ImageSanitizeExecute)
 	v1AuthAdmin.Get("/admin/s3-migration/preview", S3MigrationPreview)
 	v1AuthAdmin.Post("/admin/s3-migration/execute", S3MigrationExecute)
 	v1AuthAdmin.Get("/admin/audio-folder-migration/preview", AudioFolderMigrationPreview)
 	v1AuthAdmin.Post("/admin/audio-folder-migration/execute", AudioFolderMigrationExecute)
+	v1AuthAdmin.Get("/admin/audio-analysis/status", AudioAnalysisBatchStatus)
+	v1AuthAdmin.Post("/admin/audio-analysis/execute", AudioAnalysisBatchExecute)
 
 	// Email notification admin test
 	v1AuthAdmin.Post("/admin/email-test", AdminEmailTestSend)
@@ -989,7 +1093,9 @@ func Init(config *utils.AppConfig, uiStatic fs.FS) error {
 
 	websocketWorks(app, config.WebUrl)
 	oneBotWSWorks(app, config.WebUrl)
+	satoriWorks(app, config.WebUrl)
 	startOneBotReverseRuntimeForInit()
+	startQQBridgeRuntimeForInit()
 
 	return serveAppWithOptionalCertificateForInit(app, config)
 }
@@ -43,20 +43,25 @@ func UploadQuick(c *fiber.Ctx) error {
 	}
 
 	tx, newItem := model.AttachmentCreate(&model.AttachmentModel{
-		Filename:    item.Filename,
-		Size:        item.Size,
-		Hash:        hashBytes,
-		MimeType:    item.MimeType,
-		IsAnimated:  item.IsAnimated,
-		ChannelID:   body.ChannelID,
-		UserID:      getCurUser(c).ID,
-		StorageType: item.StorageType,
-		ObjectKey:   item.ObjectKey,
-		ExternalURL: item.ExternalURL,
+		Filename:         item.Filename,
+		Size:             item.Size,
+		Hash:             hashBytes,
+		MimeType:         item.MimeType,
+		IsAnimated:       item.IsAnimated,
+		MetadataStripped: item.MetadataStripped,
+		Width:            item.Width,
+		Height:           item.Height,
+		ThumbHash:        item.ThumbHash,
+		ChannelID:        body.ChannelID,
+		UserID:           getCurUser(c).ID,
+		StorageType:      item.StorageType,
+		ObjectKey:        item.ObjectKey,
+		ExternalURL:      item.ExternalURL,
 	})
 	if tx.Error != nil {
 		return wrapError(c, tx.Error, "上传失败，请重试")
 	}
+	service.EnqueueAttachmentVideo(newItem)
 
 	// 特殊值处理
 	if body.ChannelID == "user-avatar" {
@@ -117,20 +122,25 @@ func Upload(c *fiber.Ctx) error {
 		}
 
 		tx, newItem := model.AttachmentCreate(&model.AttachmentModel{
-			Filename:    file.Filename,
-			Size:        saveResult.Size,
-			Hash:        saveResult.Hash,
-			MimeType:    saveResult.MimeType,
-			IsAnimated:  saveResult.IsAnimated,
-			ChannelID:   channelId,
-			UserID:      getCurUser(c).ID,
-			StorageType: location.StorageType,
-			ObjectKey:   location.ObjectKey,
-			ExternalURL: location.ExternalURL,
+			Filename:         file.Filename,
+			Size:             saveResult.Size,
+			Hash:             saveResult.Hash,
+			MimeType:         saveResult.MimeType,
+			IsAnimated:       saveResult.IsAnimated,
+			MetadataStripped: saveResult.MetadataStripped,
+			Width:            saveResult.Placeholder.Width,
+			Height:           saveResult.Placeholder.Height,
+			ThumbHash:        saveResult.Placeholder.ThumbHash,
+			ChannelID:        channelId,
+			UserID:           getCurUser(c).ID,
+			StorageType:      location.StorageType,
+			ObjectKey:        location.ObjectKey,
+			ExternalURL:      location.ExternalURL,
 		})
 		if tx.Error != nil {
 			return wrapError(c, tx.Error, "上传失败，请重试")
 		}
+		service.EnqueueAttachmentVideo(newItem)
 
 		filenames = append(filenames, fn)
 		ids = append(ids, newItem.ID)
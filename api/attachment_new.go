@@ -73,15 +73,19 @@ func uploadFiles(
 		}
 
 		attachment := &model.AttachmentModel{
-			Filename:    file.Filename,
-			Size:        saveResult.Size,
-			Hash:        saveResult.Hash,
-			MimeType:    saveResult.MimeType,
-			IsAnimated:  saveResult.IsAnimated,
-			UserID:      uid,
-			StorageType: location.StorageType,
-			ObjectKey:   location.ObjectKey,
-			ExternalURL: location.ExternalURL,
+			Filename:         file.Filename,
+			Size:             saveResult.Size,
+			Hash:             saveResult.Hash,
+			MimeType:         saveResult.MimeType,
+			IsAnimated:       saveResult.IsAnimated,
+			MetadataStripped: saveResult.MetadataStripped,
+			Width:            saveResult.Placeholder.Width,
+			Height:           saveResult.Placeholder.Height,
+			ThumbHash:        saveResult.Placeholder.ThumbHash,
+			UserID:           uid,
+			StorageType:      location.StorageType,
+			ObjectKey:        location.ObjectKey,
+			ExternalURL:      location.ExternalURL,
 		}
 
 		attachment.ID = utils.NewID()
@@ -89,6 +93,7 @@ func uploadFiles(
 			modelSolve(attachment)
 		}
 		model.AttachmentCreate(attachment)
+		service.EnqueueAttachmentVideo(attachment)
 
 		filenames = append(filenames, fn)
 		ids = append(ids, attachment.ID)
@@ -244,14 +249,18 @@ func AttachmentUploadQuick(c *fiber.Ctx) error {
 	}
 
 	_, newItem := model.AttachmentCreate(&model.AttachmentModel{
-		Filename:    item.Filename,
-		Size:        item.Size,
-		Hash:        hashBytes,
-		MimeType:    item.MimeType,
-		IsAnimated:  item.IsAnimated,
-		StorageType: item.StorageType,
-		ObjectKey:   item.ObjectKey,
-		ExternalURL: item.ExternalURL,
+		Filename:         item.Filename,
+		Size:             item.Size,
+		Hash:             hashBytes,
+		MimeType:         item.MimeType,
+		IsAnimated:       item.IsAnimated,
+		MetadataStripped: item.MetadataStripped,
+		Width:            item.Width,
+		Height:           item.Height,
+		ThumbHash:        item.ThumbHash,
+		StorageType:      item.StorageType,
+		ObjectKey:        item.ObjectKey,
+		ExternalURL:      item.ExternalURL,
 
 		ParentID:     body.ParentId,
 		ParentIDType: body.ParentIdType,
@@ -266,6 +275,7 @@ func AttachmentUploadQuick(c *fiber.Ctx) error {
 		CreatorName:   ui.Nickname,
 		CreatorAvatar: ui.Avatar,
 	})
+	service.EnqueueAttachmentVideo(newItem)
 
 	return c.JSON(fiber.Map{
 		"message": "上传成功",
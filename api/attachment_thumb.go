@@ -84,6 +84,11 @@ func AttachmentThumb(c *fiber.Ctx) error {
 		return AttachmentGet(c)
 	}
 
+	// 早于占位功能上传、尚未回填的图片顺带补全占位信息
+	if att.ThumbHash == "" {
+		fillAttachmentPlaceholder(&att, originalPath)
+	}
+
 	// Generate thumbnail
 	if err := generateThumbnail(originalPath, thumbPath, size); err != nil {
 		// Generation failed, serve original
@@ -224,3 +229,21 @@ func setThumbCacheHeaders(c *fiber.Ctx) {
 	c.Set("Cache-Control", "public, max-age=31536000, immutable")
 	c.Set("Content-Type", "image/webp")
 }
+
+// fillAttachmentPlaceholder 从原图计算占位信息并写回附件记录
+func fillAttachmentPlaceholder(att *model.AttachmentModel, originalPath string) {
+	data, err := os.ReadFile(originalPath)
+	if err != nil {
+		return
+	}
+	// 缩略图接口只服务图片，旧记录可能没有 MIME 类型，这里不再按类型过滤
+	placeholder, err := utils.ComputeImagePlaceholder(data)
+	if err != nil || placeholder.ThumbHash == "" {
+		return
+	}
+	_ = model.GetDB().Model(&model.AttachmentModel{}).Where("id = ?", att.ID).Updates(map[string]any{
+		"width":      placeholder.Width,
+		"height":     placeholder.Height,
+		"thumb_hash": placeholder.ThumbHash,
+	}).Error
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
+)
+
+// AttachmentVideoInfo 返回视频附件的处理状态、封面与转码版本
+// GET /api/v1/attachment/:id/video
+func AttachmentVideoInfo(c *fiber.Ctx) error {
+	attachmentID := strings.TrimSpace(c.Params("id"))
+	if attachmentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "无效的附件ID",
+		})
+	}
+	video, err := model.AttachmentVideoGet(attachmentID)
+	if err != nil {
+		return wrapError(c, err, "读取视频信息失败")
+	}
+	if video == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "该附件没有视频处理记录",
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message": "ok",
+		"item":    video.ToProtocolType(),
+	})
+}
+
+// AttachmentVideoRetry 上传者重新处理失败的视频附件
+// POST /api/v1/attachment/:id/video/retry
+func AttachmentVideoRetry(c *fiber.Ctx) error {
+	attachmentID := strings.TrimSpace(c.Params("id"))
+	video, err := model.AttachmentVideoGet(attachmentID)
+	if err != nil {
+		return wrapError(c, err, "读取视频信息失败")
+	}
+	if video == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "该附件没有视频处理记录",
+		})
+	}
+	if video.UserID != getCurUser(c).ID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "只有上传者可以重试",
+		})
+	}
+	video, err = service.RetryAttachmentVideo(attachmentID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message": "ok",
+		"item":    video.ToProtocolType(),
+	})
+}
+
+// broadcastAttachmentVideoState 推送视频处理进度；已关联频道时广播到频道，否则只推送给上传者
+func broadcastAttachmentVideoState(video *model.AttachmentVideoModel) {
+	if video == nil || userId2ConnInfoGlobal == nil {
+		return
+	}
+	eventType := protocol.EventAttachmentVideoProcessing
+	switch video.Status {
+	case model.AttachmentVideoStatusReady:
+		eventType = protocol.EventAttachmentVideoReady
+	case model.AttachmentVideoStatusFailed:
+		eventType = protocol.EventAttachmentVideoFailed
+	}
+	event := &protocol.Event{
+		Type:            eventType,
+		AttachmentVideo: video.ToProtocolType(),
+	}
+	channelID := strings.TrimSpace(video.ChannelID)
+	if channelID != "" && channelID != "user-avatar" {
+		if channel, err := model.ChannelGet(channelID); err == nil && channel != nil && channel.ID != "" {
+			ctx := &ChatContext{
+				ChannelUsersMap: getChannelUsersMap(),
+				UserId2ConnInfo: getUserConnInfoMap(),
+			}
+			event.Channel = &protocol.Channel{ID: channel.ID, WorldID: channel.WorldID}
+			ctx.BroadcastEventInChannel(channelID, event)
+			return
+		}
+	}
+	broadcastEventToUsers([]string{video.UserID}, event)
+}
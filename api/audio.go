@@ -2,6 +2,7 @@ package api
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -428,6 +429,7 @@ func AudioAssetUpdate(c *fiber.Ctx) error {
 		FolderID    *string                     `json:"folderId"`
 		Scope       *model.AudioAssetScope      `json:"scope"`
 		WorldID     *string                     `json:"worldId"`
+		CuePoints   *[]model.AudioCuePoint      `json:"cuePoints"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return wrapErrorStatus(c, fiber.StatusBadRequest, err, "请求体格式错误")
@@ -467,6 +469,7 @@ func AudioAssetUpdate(c *fiber.Ctx) error {
 		Scope:       normalizedScope,
 		WorldID:     normalizedWorldID,
 		UpdatedBy:   user.ID,
+		CuePoints:   req.CuePoints,
 	}
 	updated, err := service.AudioUpdateAsset(id, input)
 	if err != nil {
@@ -475,6 +478,68 @@ func AudioAssetUpdate(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"item": updated})
 }
 
+func AudioAssetAnalysisGet(c *fiber.Ctx) error {
+	id := strings.TrimSpace(c.Params("id"))
+	if id == "" {
+		return wrapErrorStatus(c, fiber.StatusBadRequest, nil, "缺少资源ID")
+	}
+	user := getCurUser(c)
+	if user == nil {
+		return wrapErrorStatus(c, fiber.StatusUnauthorized, nil, "未登录")
+	}
+	asset, err := service.AudioGetAsset(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return wrapErrorStatus(c, fiber.StatusNotFound, err, "素材不存在")
+		}
+		return wrapErrorStatus(c, fiber.StatusInternalServerError, err, "读取素材失败")
+	}
+	if err := ensureAudioStreamAllowed(user, asset); err != nil {
+		return wrapErrorStatus(c, fiber.StatusForbidden, nil, err.Error())
+	}
+	analysis, err := service.AudioGetAssetAnalysis(id)
+	if err != nil {
+		return wrapErrorStatus(c, fiber.StatusInternalServerError, err, "读取响度分析失败")
+	}
+	return c.JSON(analysis)
+}
+
+func AudioAssetAnalyze(c *fiber.Ctx) error {
+	id := strings.TrimSpace(c.Params("id"))
+	if id == "" {
+		return wrapErrorStatus(c, fiber.StatusBadRequest, nil, "缺少资源ID")
+	}
+	asset, err := service.AudioGetAsset(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return wrapErrorStatus(c, fiber.StatusNotFound, err, "素材不存在")
+		}
+		return wrapErrorStatus(c, fiber.StatusInternalServerError, err, "读取素材失败")
+	}
+	user := getCurUser(c)
+	isSystemAdmin := pm.CanWithSystemRole(user.ID, pm.PermModAdmin)
+	if asset.Scope == model.AudioScopeCommon && !isSystemAdmin {
+		return wrapErrorStatus(c, fiber.StatusForbidden, nil, "仅平台管理员可分析通用素材")
+	}
+	if asset.Scope == model.AudioScopeWorld {
+		worldID := strings.TrimSpace(normalizeOptionalString(asset.WorldID))
+		if worldID == "" {
+			return wrapErrorStatus(c, fiber.StatusForbidden, nil, "素材缺少世界归属信息")
+		}
+		if !isSystemAdmin && !service.IsWorldAdmin(worldID, user.ID) {
+			return wrapErrorStatus(c, fiber.StatusForbidden, nil, "仅该世界管理员可分析此素材")
+		}
+	}
+	updated, err := service.AudioRequestAssetAnalysis(id)
+	if err != nil {
+		if errors.Is(err, service.ErrAudioAnalysisUnavailable) {
+			return wrapErrorStatus(c, fiber.StatusServiceUnavailable, err, "响度分析不可用")
+		}
+		return wrapErrorStatus(c, fiber.StatusBadRequest, err, "提交响度分析失败")
+	}
+	return c.JSON(fiber.Map{"item": updated})
+}
+
 func AudioAssetReorder(c *fiber.Ctx) error {
 	var req struct {
 		IDs      []string `json:"ids"`
@@ -1063,6 +1128,67 @@ func AudioPlaybackStateSet(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"state": buildAudioPlaybackResponse(state)})
 }
 
+type audioPlaybackTransitionRequest struct {
+	ChannelID            string `json:"channelId"`
+	SceneID              string `json:"sceneId"`
+	CrossfadeMs          int    `json:"crossfadeMs"`
+	WorldPlaybackEnabled bool   `json:"worldPlaybackEnabled"`
+	BaseRevision         int64  `json:"baseRevision"`
+	Persist              bool   `json:"persist"`
+}
+
+// AudioPlaybackTransitionSet 切换到指定场景，可选交叉淡化
+func AudioPlaybackTransitionSet(c *fiber.Ctx) error {
+	var req audioPlaybackTransitionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return wrapErrorStatus(c, fiber.StatusBadRequest, err, "请求体解析失败")
+	}
+	req.ChannelID = strings.TrimSpace(req.ChannelID)
+	req.SceneID = strings.TrimSpace(req.SceneID)
+	if req.ChannelID == "" {
+		return wrapErrorStatus(c, fiber.StatusBadRequest, nil, "缺少频道ID")
+	}
+	if req.SceneID == "" {
+		return wrapErrorStatus(c, fiber.StatusBadRequest, nil, "缺少场景ID")
+	}
+	user := getCurUser(c)
+	if user == nil {
+		return wrapErrorStatus(c, fiber.StatusUnauthorized, nil, "未登录")
+	}
+	if err := ensureChannelMembership(user.ID, req.ChannelID); err != nil {
+		return wrapErrorStatus(c, fiber.StatusForbidden, err, "仅频道成员可更新播放状态")
+	}
+	state, err := service.AudioTransitionToScene(service.AudioSceneTransitionInput{
+		ChannelID:            req.ChannelID,
+		SceneID:              req.SceneID,
+		CrossfadeMs:          req.CrossfadeMs,
+		WorldPlaybackEnabled: req.WorldPlaybackEnabled,
+		BaseRevision:         req.BaseRevision,
+		ActorID:              user.ID,
+		Persist:              req.Persist,
+	})
+	if err != nil {
+		var conflictErr *service.AudioPlaybackRevisionConflictError
+		if errors.As(err, &conflictErr) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"message": "播放状态版本冲突",
+				"state":   buildAudioPlaybackResponse(conflictErr.CurrentState),
+			})
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return wrapErrorStatus(c, fiber.StatusNotFound, err, "场景不存在")
+		}
+		if errors.Is(err, service.ErrAudioSceneWorldMismatch) {
+			return wrapErrorStatus(c, fiber.StatusForbidden, err, err.Error())
+		}
+		return wrapErrorStatus(c, fiber.StatusInternalServerError, err, "切换场景失败")
+	}
+	if state != nil {
+		broadcastAudioPlaybackState(user, state)
+	}
+	return c.JSON(fiber.Map{"state": buildAudioPlaybackResponse(state)})
+}
+
 func AdminAudioAssetList(c *fiber.Ctx) error {
 	result, err := service.AdminAudioListAssets(buildAdminAudioAssetFiltersFromQuery(c))
 	if err != nil {
@@ -1345,6 +1471,8 @@ type audioSceneRequest struct {
 	ChannelScope *string                 `json:"channelScope"`
 	Scope        string                  `json:"scope"`
 	WorldID      *string                 `json:"worldId"`
+	// Automation 为空表示不修改；nextSceneId 为空字符串表示清除
+	Automation *model.AudioSceneAutomation `json:"automation"`
 }
 
 func (r audioSceneRequest) toInput(actor string) service.AudioSceneInput {
@@ -1362,6 +1490,7 @@ func (r audioSceneRequest) toInput(actor string) service.AudioSceneInput {
 		ActorID:      actor,
 		Scope:        scope,
 		WorldID:      r.WorldID,
+		Automation:   r.Automation,
 	}
 }
 
@@ -1661,11 +1790,14 @@ func buildAudioPlaybackResponse(state *service.AudioPlaybackStateSnapshot) inter
 		"updatedAt":            state.UpdatedAt.UnixMilli(),
 		"scopeType":            state.ScopeType,
 		"scopeId":              state.ScopeID,
+		"transition":           convertPlaybackTransition(state.Transition),
+		"automation":           convertScheduledAutomation(state.Automation),
+		"syncReason":           state.SyncReason,
 	}
 }
 
 func broadcastAudioPlaybackState(operator *model.UserModel, state *service.AudioPlaybackStateSnapshot) {
-	if operator == nil || state == nil {
+	if state == nil {
 		return
 	}
 	if channelUsersMapGlobal == nil || userId2ConnInfoGlobal == nil {
@@ -1687,19 +1819,25 @@ func broadcastAudioPlaybackState(operator *model.UserModel, state *service.Audio
 		UpdatedAt:            state.UpdatedAt.UnixMilli(),
 		ScopeType:            state.ScopeType,
 		ScopeID:              state.ScopeID,
+		Transition:           convertPlaybackTransition(state.Transition),
+		Automation:           convertScheduledAutomation(state.Automation),
+		SyncReason:           state.SyncReason,
 	}
 	event := &protocol.Event{
 		Type: protocol.EventAudioStateUpdated,
 		Channel: &protocol.Channel{
 			ID: state.ChannelID,
 		},
-		User: &protocol.User{
+		AudioState: payload,
+	}
+	// 服务端自动化触发时操作者可能已不存在，此时不携带 user
+	if operator != nil {
+		event.User = &protocol.User{
 			ID:     operator.ID,
 			Nick:   operator.Nickname,
 			Name:   operator.Username,
 			Avatar: operator.Avatar,
-		},
-		AudioState: payload,
+		}
 	}
 	ctx := &ChatContext{
 		User:            operator,
@@ -1719,26 +1857,69 @@ func convertTrackStates(list []service.AudioTrackState) []protocol.AudioTrackSta
 	result := make([]protocol.AudioTrackState, 0, len(list))
 	for _, item := range list {
 		result = append(result, protocol.AudioTrackState{
-			Type:             item.Type,
-			AssetID:          item.AssetID,
-			Volume:           item.Volume,
-			Muted:            item.Muted,
-			Solo:             item.Solo,
-			FadeIn:           item.FadeIn,
-			FadeOut:          item.FadeOut,
-			IsPlaying:        item.IsPlaying,
-			Position:         item.Position,
-			LoopEnabled:      item.LoopEnabled,
-			PlaybackRate:     item.PlaybackRate,
-			PlaylistFolderID: item.PlaylistFolderID,
-			PlaylistMode:     item.PlaylistMode,
-			PlaylistAssetIDs: append([]string(nil), item.PlaylistAssetIDs...),
-			PlaylistIndex:    item.PlaylistIndex,
+			Type:              item.Type,
+			AssetID:           item.AssetID,
+			Volume:            item.Volume,
+			Muted:             item.Muted,
+			Solo:              item.Solo,
+			FadeIn:            item.FadeIn,
+			FadeOut:           item.FadeOut,
+			IsPlaying:         item.IsPlaying,
+			Position:          item.Position,
+			LoopEnabled:       item.LoopEnabled,
+			PlaybackRate:      item.PlaybackRate,
+			PlaylistFolderID:  item.PlaylistFolderID,
+			PlaylistMode:      item.PlaylistMode,
+			PlaylistAssetIDs:  append([]string(nil), item.PlaylistAssetIDs...),
+			PlaylistIndex:     item.PlaylistIndex,
+			NormalizeLoudness: item.NormalizeLoudness,
+			GainDB:            item.GainDB,
+			LoopCueID:         item.LoopCueID,
 		})
 	}
 	return result
 }
 
+func convertPlaybackTransition(src *model.AudioPlaybackTransition) *protocol.AudioPlaybackTransition {
+	if src == nil {
+		return nil
+	}
+	return &protocol.AudioPlaybackTransition{
+		Type:        src.Type,
+		FromSceneID: src.FromSceneID,
+		ToSceneID:   src.ToSceneID,
+		FromTracks:  convertTrackStates(src.FromTracks),
+		DurationMs:  src.DurationMs,
+		StartedAtMs: src.StartedAtMs,
+	}
+}
+
+func convertScheduledAutomation(src *model.AudioScheduledAutomation) *protocol.AudioScheduledAutomation {
+	if src == nil {
+		return nil
+	}
+	return &protocol.AudioScheduledAutomation{
+		TargetSceneID: src.TargetSceneID,
+		AtPositionSec: src.AtPositionSec,
+		CrossfadeMs:   src.CrossfadeMs,
+	}
+}
+
+// LocalAudioPlaybackEventPublisher 将服务端自动化产生的播放状态广播给本机连接
+type LocalAudioPlaybackEventPublisher struct{}
+
+func (LocalAudioPlaybackEventPublisher) PublishAudioPlaybackState(_ context.Context, state *service.AudioPlaybackStateSnapshot) error {
+	if state == nil {
+		return nil
+	}
+	var operator *model.UserModel
+	if state.UpdatedBy != "" {
+		operator = model.UserGet(state.UpdatedBy)
+	}
+	broadcastAudioPlaybackState(operator, state)
+	return nil
+}
+
 func guessContentType(objectKey string) string {
 	switch strings.ToLower(filepath.Ext(objectKey)) {
 	case ".ogg", ".opus":
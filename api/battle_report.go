@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"time"
@@ -24,6 +25,7 @@ type battleReportRequest struct {
 	AIProviderID       string   `json:"aiProviderId"`
 	AIModel            string   `json:"aiModel"`
 	AIFeatureKey       string   `json:"aiFeatureKey"`
+	IncludeDiceStats   bool     `json:"includeDiceStats"`
 }
 
 type battleReportReorderRequest struct {
@@ -36,26 +38,34 @@ type battleReportDisplayRequest struct {
 }
 
 type battleReportResponse struct {
-	ID                 string `json:"id"`
-	ChannelID          string `json:"channelId"`
-	WorldID            string `json:"worldId"`
-	Title              string `json:"title"`
-	Content            string `json:"content,omitempty"`
-	ContentPreview     string `json:"contentPreview"`
-	PeriodStart        int64  `json:"periodStart"`
-	PeriodEnd          int64  `json:"periodEnd"`
-	ContextReportCount int    `json:"contextReportCount"`
-	SortOrder          int    `json:"sortOrder"`
-	Status             string `json:"status"`
-	ErrorMessage       string `json:"errorMessage,omitempty"`
-	CreatorID          string `json:"creatorId"`
-	UpdaterID          string `json:"updaterId"`
-	AISource           string `json:"aiSource,omitempty"`
-	AIProviderID       string `json:"aiProviderId,omitempty"`
-	AIModel            string `json:"aiModel,omitempty"`
-	AIFeatureKey       string `json:"aiFeatureKey,omitempty"`
-	CreatedAt          int64  `json:"createdAt"`
-	UpdatedAt          int64  `json:"updatedAt"`
+	ID                 string  `json:"id"`
+	ChannelID          string  `json:"channelId"`
+	WorldID            string  `json:"worldId"`
+	Title              string  `json:"title"`
+	Content            string  `json:"content,omitempty"`
+	ContentPreview     string  `json:"contentPreview"`
+	PeriodStart        int64   `json:"periodStart"`
+	PeriodEnd          int64   `json:"periodEnd"`
+	ContextReportCount int     `json:"contextReportCount"`
+	SortOrder          int     `json:"sortOrder"`
+	Status             string  `json:"status"`
+	ErrorMessage       string  `json:"errorMessage,omitempty"`
+	CreatorID          string  `json:"creatorId"`
+	UpdaterID          string  `json:"updaterId"`
+	AISource           string  `json:"aiSource,omitempty"`
+	AIProviderID       string  `json:"aiProviderId,omitempty"`
+	AIModel            string  `json:"aiModel,omitempty"`
+	AIFeatureKey       string  `json:"aiFeatureKey,omitempty"`
+	IncludeDiceStats   bool    `json:"includeDiceStats"`
+	SummaryStage       string  `json:"summaryStage,omitempty"`
+	SummaryLevel       int     `json:"summaryLevel,omitempty"`
+	SummaryDone        int     `json:"summaryDone,omitempty"`
+	SummaryTotal       int     `json:"summaryTotal,omitempty"`
+	EstimatedCost      float64 `json:"estimatedCost,omitempty"`
+	CreatedAt          int64   `json:"createdAt"`
+	UpdatedAt          int64   `json:"updatedAt"`
+
+	DiceStats *service.DiceStatsReport `json:"diceStats,omitempty"`
 }
 
 type battleReportDisplayResponse struct {
@@ -110,7 +120,14 @@ func BattleReportGet(c *fiber.Ctx) error {
 	if err != nil {
 		return battleReportError(c, err)
 	}
-	return c.JSON(fiber.Map{"item": battleReportToResponse(item, true)})
+	resp := battleReportToResponse(item, true)
+	if item.IncludeDiceStats {
+		// 与旁观页一致，只统计战报所属频道
+		if resp.DiceStats, err = service.BattleReportDiceStats(item, []string{item.ChannelID}); err != nil {
+			return battleReportError(c, err)
+		}
+	}
+	return c.JSON(fiber.Map{"item": resp})
 }
 
 func BattleReportUpdate(c *fiber.Ctx) error {
@@ -291,6 +308,60 @@ func BattleReportSummarize(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"item": battleReportToResponse(item, true)})
 }
 
+func BattleReportSummarizeEstimate(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "未登录"})
+	}
+	var req battleReportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "请求体解析失败"})
+	}
+	cfg := utils.AIConfig{}
+	if appConfig != nil {
+		cfg = appConfig.AI
+	}
+	estimate, err := service.EstimateBattleReportSummary(c.Params("channelId"), user.ID, req.Source, service.BattleReportSummaryPromptInput{
+		Title:              req.Title,
+		PeriodStart:        unixMilliToTime(req.PeriodStart),
+		PeriodEnd:          unixMilliToTime(req.PeriodEnd),
+		ContextReportCount: req.ContextReportCount,
+		SourceChannelIDs:   req.SourceChannelIDs,
+		AIConfig:           cfg,
+	})
+	if err != nil {
+		return battleReportError(c, err)
+	}
+	return c.JSON(fiber.Map{"estimate": estimate})
+}
+
+func BattleReportSummarizeResume(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "未登录"})
+	}
+	var req battleReportRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "请求体解析失败"})
+		}
+	}
+	cfg := utils.AIConfig{}
+	if appConfig != nil {
+		cfg = appConfig.AI
+	}
+	runner := aiRunnerFactory(func() *utils.AppConfig { return appConfig })
+	item, err := service.ResumeBattleReportSummary(context.Background(), c.Params("reportId"), user.ID, service.BattleReportSummaryInput{
+		Source:   req.Source,
+		AIConfig: cfg,
+		Runner:   runner,
+	})
+	if err != nil {
+		return battleReportError(c, err)
+	}
+	return c.JSON(fiber.Map{"item": battleReportToResponse(item, true)})
+}
+
 func battleReportDisplayToResponse(item *model.BattleReportDisplayChannelModel) battleReportDisplayResponse {
 	if item == nil {
 		return battleReportDisplayResponse{}
@@ -319,6 +390,7 @@ func battleReportInputFromRequest(req battleReportRequest) service.BattleReportI
 		AIProviderID:       req.AIProviderID,
 		AIModel:            req.AIModel,
 		AIFeatureKey:       req.AIFeatureKey,
+		IncludeDiceStats:   req.IncludeDiceStats,
 	}
 }
 
@@ -344,6 +416,12 @@ func battleReportToResponse(item *model.BattleReportModel, includeContent bool)
 		AIProviderID:       item.AIProviderID,
 		AIModel:            item.AIModel,
 		AIFeatureKey:       item.AIFeatureKey,
+		IncludeDiceStats:   item.IncludeDiceStats,
+		SummaryStage:       item.SummaryStage,
+		SummaryLevel:       item.SummaryLevel,
+		SummaryDone:        item.SummaryDone,
+		SummaryTotal:       item.SummaryTotal,
+		EstimatedCost:      item.EstimatedCost,
 		CreatedAt:          timeToUnixMilli(item.CreatedAt),
 		UpdatedAt:          timeToUnixMilli(item.UpdatedAt),
 	}
@@ -362,6 +440,9 @@ func battleReportError(c *fiber.Ctx, err error) error {
 	case errors.Is(err, gorm.ErrRecordNotFound):
 		status = fiber.StatusNotFound
 		message = "战报不存在"
+	case errors.Is(err, service.ErrBattleReportSummaryBusy):
+		status = fiber.StatusConflict
+		message = err.Error()
 	case strings.Contains(err.Error(), "仅频道成员"), strings.Contains(err.Error(), "仅世界成员"):
 		status = fiber.StatusForbidden
 		message = err.Error()
@@ -117,13 +117,15 @@ func apiBotInfoSetName(ctx *ChatContext, msg []byte) {
 	ctx.User.Brief = data.Data.Brief
 	ctx.User.SaveInfo()
 	for _, i := range ctx.Members {
+		oldNick := i.Nickname
 		i.Nickname = name
 		i.SaveInfo()
 		// 广播事件，名字更新了
 		ctx.BroadcastEventInChannel(i.ChannelID, &protocol.Event{
-			Type:   "channel-member-updated",
+			Type:   protocol.EventChannelMemberUpdated,
 			Member: i.ToProtocolType(),
 		})
+		go notifyOneBotMemberCardChanged(i.ChannelID, i, oldNick)
 	}
 
 	ret := struct {
@@ -169,14 +171,16 @@ func apiBotChannelMemberSetName(ctx *ChatContext, msg []byte) {
 
 	member, err := model.MemberGetByUserIDAndChannelIDBase(data.Data.UserId, data.Data.ChannelId, data.Data.Name, false)
 	if member != nil {
+		oldNick := member.Nickname
 		member.Nickname = data.Data.Name
 		member.SaveInfo()
 
 		// 广播事件，名字更新了
 		ctx.BroadcastEventInChannel(data.Data.ChannelId, &protocol.Event{
-			Type:   "channel-member-updated",
+			Type:   protocol.EventChannelMemberUpdated,
 			Member: member.ToProtocolType(),
 		})
+		go notifyOneBotMemberCardChanged(data.Data.ChannelId, member, oldNick)
 	}
 
 	ret := struct {
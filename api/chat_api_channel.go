@@ -85,6 +85,22 @@ func apiChannelPrivateCreate(ctx *ChatContext, data *struct {
 		}{Code: http.StatusBadRequest, Msg: "不能和自己进行私聊"}, nil
 	}
 
+	// 已有私聊频道仅检查屏蔽关系，新建私聊还需符合对方的隐私设置
+	privacyErr := error(nil)
+	if existing, _ := model.ChannelPrivateGet(ctx.User.ID, data.UserId); existing != nil && existing.ID != "" {
+		if service.UsersBlockedEitherWay(ctx.User.ID, data.UserId) {
+			privacyErr = service.ErrUserBlocked
+		}
+	} else {
+		privacyErr = service.CheckDirectMessageAllowed(ctx.User.ID, data.UserId)
+	}
+	if privacyErr != nil {
+		return &struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}{Code: http.StatusForbidden, Msg: privacyErr.Error()}, nil
+	}
+
 	ch, isNew := model.ChannelPrivateNew(ctx.User.ID, data.UserId) // 创建私聊频道
 	if ch == nil {
 		return &struct {
@@ -319,6 +335,8 @@ func apiChannelEnter(ctx *ChatContext, data *struct {
 		ctx.BroadcastChannelPresence(oldChannelId)
 		ctx.ConnInfo.WorldId = ""
 	}
+	// 切换频道后不再保留其他频道的语音会话
+	voiceLeaveConn(ctx, ctx.Conn, channelId)
 
 	member, err := model.MemberGetByUserIDAndChannelID(ctx.User.ID, channelId, ctx.User.Nickname)
 	if err != nil {
@@ -470,13 +488,14 @@ func apiChannelFeatureUpdate(ctx *ChatContext, data *struct {
 	ChannelID          string    `json:"channel_id"`
 	BuiltInDiceEnabled *bool     `json:"built_in_dice_enabled"`
 	BotFeatureEnabled  *bool     `json:"bot_feature_enabled"`
+	VoiceEnabled       *bool     `json:"voice_enabled"`
 	PrimaryBotID       *string   `json:"primary_bot_id"`
 	EventBotIDs        *[]string `json:"event_bot_ids"`
 }) (any, error) {
 	if data.ChannelID == "" {
 		return nil, fmt.Errorf("频道ID不能为空")
 	}
-	if data.BuiltInDiceEnabled == nil && data.BotFeatureEnabled == nil && data.PrimaryBotID == nil && data.EventBotIDs == nil {
+	if data.BuiltInDiceEnabled == nil && data.BotFeatureEnabled == nil && data.VoiceEnabled == nil && data.PrimaryBotID == nil && data.EventBotIDs == nil {
 		return nil, fmt.Errorf("没有可更新的字段")
 	}
 	if !pm.CanWithChannelRole(ctx.User.ID, data.ChannelID, pm.PermFuncChannelManageInfo, pm.PermFuncChannelRoleLink) {
@@ -500,6 +519,13 @@ func apiChannelFeatureUpdate(ctx *ChatContext, data *struct {
 		channel.BotFeatureEnabled = *data.BotFeatureEnabled
 		updates["bot_feature_enabled"] = channel.BotFeatureEnabled
 	}
+	if data.VoiceEnabled != nil {
+		if channel.IsPrivate && *data.VoiceEnabled {
+			return nil, fmt.Errorf("私聊频道不支持语音")
+		}
+		channel.VoiceEnabled = *data.VoiceEnabled
+		updates["voice_enabled"] = channel.VoiceEnabled
+	}
 	if data.PrimaryBotID != nil {
 		channel.PrimaryBotID = strings.TrimSpace(*data.PrimaryBotID)
 		updates["primary_bot_id"] = channel.PrimaryBotID
@@ -625,11 +651,15 @@ func apiChannelFeatureUpdate(ctx *ChatContext, data *struct {
 	}
 	ctx.BroadcastEventInChannel(channel.ID, ev)
 	ctx.BroadcastEventInChannelForBot(channel.ID, ev)
+	if data.VoiceEnabled != nil && !channel.VoiceEnabled {
+		voiceRoomClose(ctx, channel.ID)
+	}
 
 	return &struct {
 		ChannelID           string   `json:"channel_id"`
 		BuiltInDiceEnabled  bool     `json:"built_in_dice_enabled"`
 		BotFeatureEnabled   bool     `json:"bot_feature_enabled"`
+		VoiceEnabled        bool     `json:"voice_enabled"`
 		PrimaryBotID        string   `json:"primary_bot_id"`
 		EventBotIDs         []string `json:"event_bot_ids"`
 		CharacterAPIEnabled bool     `json:"character_api_enabled"`
@@ -638,6 +668,7 @@ func apiChannelFeatureUpdate(ctx *ChatContext, data *struct {
 		ChannelID:           channel.ID,
 		BuiltInDiceEnabled:  channel.BuiltInDiceEnabled,
 		BotFeatureEnabled:   channel.BotFeatureEnabled,
+		VoiceEnabled:        channel.VoiceEnabled,
 		PrimaryBotID:        channel.PrimaryBotID,
 		EventBotIDs:         channel.GetEventBotIDs(),
 		CharacterAPIEnabled: characterEnabled,
@@ -72,6 +72,17 @@ func apiFriendRequestCreate(ctx *ChatContext, data *struct {
 	ReceiverID string `json:"receiverId"` // 接收者
 	Note       string `json:"note"`       // 申请理由
 }) (any, error) {
+	// 发送者以当前连接用户为准
+	data.SenderID = ctx.User.ID
+	if err := service.CheckFriendRequestAllowed(data.SenderID, data.ReceiverID); err != nil {
+		return &struct {
+			Message string `json:"message"`
+			Status  int    `json:"status"`
+		}{
+			Message: err.Error(),
+			Status:  -1,
+		}, nil
+	}
 	invite := &model.FriendRequestModel{
 		SenderID:   data.SenderID,
 		ReceiverID: data.ReceiverID,
@@ -82,7 +93,10 @@ func apiFriendRequestCreate(ctx *ChatContext, data *struct {
 	if err != nil {
 		status = -1
 	} else {
-		autoFriendRequestApproveIfReceiverBot(invite)
+		go notifyOneBotFriendRequest(invite)
+		if autoFriendRequestApproveIfReceiverBot(invite) {
+			go notifyOneBotFriendAdded(invite.SenderID, invite.ReceiverID)
+		}
 	}
 	return &struct {
 		Message string `json:"message"`
@@ -117,6 +131,9 @@ func apiFriendRequestApprove(ctx *ChatContext, data *struct {
 				if ch.ID == "" {
 					model.ChannelPrivateNew(req.SenderID, req.ReceiverID)
 				}
+				if data.Approve {
+					go notifyOneBotFriendAdded(req.SenderID, req.ReceiverID)
+				}
 			}
 			return ok, nil
 		}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
+)
+
+type groupDMErrorResult struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func groupDMError(err error) (any, error) {
+	switch {
+	case errors.Is(err, service.ErrGroupDMNotFound):
+		return &groupDMErrorResult{Code: http.StatusNotFound, Msg: err.Error()}, nil
+	case errors.Is(err, service.ErrGroupDMPermission),
+		errors.Is(err, service.ErrUserBlocked),
+		errors.Is(err, service.ErrUserDMNotAllowed):
+		return &groupDMErrorResult{Code: http.StatusForbidden, Msg: err.Error()}, nil
+	case errors.Is(err, service.ErrGroupDMTooFewMembers),
+		errors.Is(err, service.ErrGroupDMTooManyMembers),
+		errors.Is(err, service.ErrGroupDMMemberInvalid),
+		errors.Is(err, service.ErrGroupDMNameTooLong):
+		return &groupDMErrorResult{Code: http.StatusBadRequest, Msg: err.Error()}, nil
+	}
+	return nil, err
+}
+
+// broadcastGroupDMUpdated 通知成员（含被移出者）刷新多人私聊
+func broadcastGroupDMUpdated(ch *model.ChannelModel, action string, extraUserIDs ...string) {
+	if ch == nil {
+		return
+	}
+	userIDs := append(service.GroupDMMemberIDs(ch.ID), extraUserIDs...)
+	broadcastEventToUsers(userIDs, &protocol.Event{
+		Type: protocol.EventGroupDMUpdated,
+		Argv: &protocol.Argv{Options: map[string]interface{}{
+			"channelId": ch.ID,
+			"action":    action,
+			"channel":   ch,
+		}},
+	})
+}
+
+func apiGroupDMCreate(ctx *ChatContext, data *struct {
+	UserIDs []string `json:"user_ids"`
+	Name    string   `json:"name"`
+}) (any, error) {
+	ch, err := service.GroupDMCreate(ctx.User.ID, data.UserIDs, data.Name)
+	if err != nil {
+		return groupDMError(err)
+	}
+	broadcastGroupDMUpdated(ch, "created")
+	return &struct {
+		Channel *model.ChannelModel `json:"channel"`
+	}{Channel: ch}, nil
+}
+
+func apiGroupDMList(ctx *ChatContext, data *struct{}) (any, error) {
+	items, err := service.GroupDMList(ctx.User.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &struct {
+		Data []*model.ChannelModel `json:"data"`
+	}{Data: items}, nil
+}
+
+func apiGroupDMMembers(ctx *ChatContext, data *struct {
+	ChannelID string `json:"channel_id"`
+}) (any, error) {
+	items, err := service.GroupDMMembers(data.ChannelID, ctx.User.ID)
+	if err != nil {
+		return groupDMError(err)
+	}
+	return &struct {
+		Data []*service.GroupDMMember `json:"data"`
+	}{Data: items}, nil
+}
+
+func apiGroupDMMemberAdd(ctx *ChatContext, data *struct {
+	ChannelID string   `json:"channel_id"`
+	UserIDs   []string `json:"user_ids"`
+}) (any, error) {
+	added, err := service.GroupDMAddMembers(data.ChannelID, ctx.User.ID, data.UserIDs)
+	if err != nil {
+		return groupDMError(err)
+	}
+	ch, _ := service.GroupDMGet(data.ChannelID)
+	broadcastGroupDMUpdated(ch, "member_added")
+	return &struct {
+		Added []string `json:"added"`
+	}{Added: added}, nil
+}
+
+func apiGroupDMMemberRemove(ctx *ChatContext, data *struct {
+	ChannelID string `json:"channel_id"`
+	UserID    string `json:"user_id"`
+}) (any, error) {
+	if err := service.GroupDMRemoveMember(data.ChannelID, ctx.User.ID, data.UserID); err != nil {
+		return groupDMError(err)
+	}
+	ch, _ := service.GroupDMGet(data.ChannelID)
+	broadcastGroupDMUpdated(ch, "member_removed", data.UserID)
+	return &struct {
+		Success bool `json:"success"`
+	}{Success: true}, nil
+}
+
+func apiGroupDMLeave(ctx *ChatContext, data *struct {
+	ChannelID string `json:"channel_id"`
+}) (any, error) {
+	ch, err := service.GroupDMLeave(data.ChannelID, ctx.User.ID)
+	if err != nil {
+		return groupDMError(err)
+	}
+	broadcastGroupDMUpdated(ch, "member_left", ctx.User.ID)
+	return &struct {
+		Success bool `json:"success"`
+	}{Success: true}, nil
+}
+
+func apiGroupDMUpdate(ctx *ChatContext, data *struct {
+	ChannelID string  `json:"channel_id"`
+	Name      *string `json:"name"`
+	Avatar    *string `json:"avatar"`
+}) (any, error) {
+	ch, err := service.GroupDMUpdate(data.ChannelID, ctx.User.ID, data.Name, data.Avatar)
+	if err != nil {
+		return groupDMError(err)
+	}
+	broadcastGroupDMUpdated(ch, "updated")
+	return &struct {
+		Channel *model.ChannelModel `json:"channel"`
+	}{Channel: ch}, nil
+}
+
+func apiGroupDMOwnerTransfer(ctx *ChatContext, data *struct {
+	ChannelID string `json:"channel_id"`
+	UserID    string `json:"user_id"`
+}) (any, error) {
+	ch, err := service.GroupDMTransferOwner(data.ChannelID, ctx.User.ID, data.UserID)
+	if err != nil {
+		return groupDMError(err)
+	}
+	broadcastGroupDMUpdated(ch, "owner_transferred")
+	return &struct {
+		Channel *model.ChannelModel `json:"channel"`
+	}{Channel: ch}, nil
+}
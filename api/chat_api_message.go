@@ -439,10 +439,11 @@ func apiMessageGet(ctx *ChatContext, data *struct {
 	}
 
 	return map[string]any{
-		"id":            item.ID,
-		"channel_id":    item.ChannelID,
-		"created_at":    item.CreatedAt.UnixMilli(),
-		"display_order": item.DisplayOrder,
+		"id":             item.ID,
+		"channel_id":     item.ChannelID,
+		"created_at":     item.CreatedAt.UnixMilli(),
+		"display_order":  item.DisplayOrder,
+		"sender_blocked": service.UserHasBlocked(ctx.User.ID, item.UserID),
 	}, nil
 }
 
@@ -712,6 +713,7 @@ func apiMessageContext(ctx *ChatContext, data *struct {
 		}
 		item.EnsureWhisperMeta()
 	}
+	fillMessageImagePlaceholders(items)
 
 	if ctx.User != nil && len(items) > 0 {
 		ids := make([]string, 0, len(items))
@@ -734,6 +736,7 @@ func apiMessageContext(ctx *ChatContext, data *struct {
 				}
 			}
 		}
+		markMessagesFromBlockedUsers(items, ctx.User.ID)
 	}
 
 	beforeCursor := ""
@@ -799,13 +802,17 @@ func apiMessageDelete(ctx *ChatContext, data *messageDeletePayload) (any, error)
 		}
 		channelData := channel.ToProtocolType()
 
-		ctx.BroadcastEventInChannel(data.ChannelID, &protocol.Event{
+		ev := &protocol.Event{
 			// 协议规定: 事件中必须含有 channel，message，user
 			Type:    protocol.EventMessageDeleted,
 			Message: item.ToProtocolType2(channelData),
 			Channel: channelData,
 			User:    ctx.User.ToProtocolType(),
-		})
+		}
+		ctx.BroadcastEventInChannel(data.ChannelID, ev)
+		if !item.IsWhisper {
+			ctx.BroadcastEventInChannelForBot(data.ChannelID, ev)
+		}
 
 		_ = model.WebhookEventLogAppendForMessage(data.ChannelID, "message-deleted", item.ID)
 
@@ -1074,6 +1081,44 @@ func hydrateMessagesForBroadcast(messages []*model.MessageModel) {
 			item.Quote.EnsureWhisperMeta()
 		}
 	}
+	fillMessageImagePlaceholders(messages)
+}
+
+// fillMessageImagePlaceholders 为消息内的图片附件补全尺寸与 ThumbHash，前端据此在图片加载前占位
+func fillMessageImagePlaceholders(messages []*model.MessageModel) {
+	idsByMessage := make(map[*model.MessageModel][]string, len(messages))
+	var allIDs []string
+	for _, item := range messages {
+		if item == nil || item.Content == "" {
+			continue
+		}
+		ids := model.ExtractMessageImageAttachmentIDs(item.Content)
+		if len(ids) == 0 {
+			continue
+		}
+		idsByMessage[item] = ids
+		allIDs = append(allIDs, ids...)
+	}
+	if len(allIDs) == 0 {
+		return
+	}
+	placeholders, err := model.AttachmentImagePlaceholdersByIDs(model.GetDB(), lo.Uniq(allIDs))
+	if err != nil {
+		log.Printf("加载图片占位信息失败: %v", err)
+		return
+	}
+	for item, ids := range idsByMessage {
+		for _, id := range ids {
+			placeholder := placeholders[id]
+			if placeholder == nil {
+				continue
+			}
+			if item.ImagePlaceholders == nil {
+				item.ImagePlaceholders = make(map[string]*protocol.ImagePlaceholder, len(ids))
+			}
+			item.ImagePlaceholders[id] = placeholder
+		}
+	}
 }
 
 func setUserNickFromMember(user *model.UserModel, member *model.MemberModel) {
@@ -1654,6 +1699,7 @@ func apiMessagePinList(ctx *ChatContext, data *struct {
 	}
 
 	hydrateMessagesForBroadcast(items)
+	markMessagesFromBlockedUsers(items, ctx.User.ID)
 
 	return &struct {
 		Data []*model.MessageModel `json:"data"`
@@ -1939,6 +1985,9 @@ func apiMessageCreate(ctx *ChatContext, data *struct {
 		if !pm.CanWithChannelRole(ctx.User.ID, channelId, pm.PermFuncChannelTextSend, pm.PermFuncChannelTextSendAll) {
 			return nil, nil
 		}
+		if err := service.CheckChannelSendMuted(channelId, ctx.User.ID); err != nil {
+			return nil, err
+		}
 	} else {
 		// 好友/陌生人
 		fr, _ := model.FriendRelationGetByID(channelId)
@@ -1950,6 +1999,9 @@ func apiMessageCreate(ctx *ChatContext, data *struct {
 		if fr.UserID1 == ctx.User.ID {
 			privateOtherUser = fr.UserID2
 		}
+		if service.UsersBlockedEitherWay(ctx.User.ID, privateOtherUser) {
+			return nil, service.ErrUserBlocked
+		}
 	}
 
 	content := data.Content
@@ -2118,6 +2170,24 @@ func apiMessageCreate(ctx *ChatContext, data *struct {
 			return existingMessageData, nil
 		}
 	}
+
+	// 内容过滤：拦截与待审核在入库前处理，掩码直接替换正文
+	var contentFilterFlagRuleIDs []string
+	if !ctx.SkipContentFilter {
+		filterResult := service.ContentFilterCheck(channel.WorldID, model.ContentFilterScopeMessage, content)
+		if filterResult.Blocked() || (filterResult.NeedsApproval() && ctx.User.IsBot) {
+			return nil, service.ErrContentFilterBlocked
+		}
+		if filterResult.NeedsApproval() {
+			return holdMessageForContentApproval(ctx, channel, data.Content, filterResult.RuleIDs, data)
+		}
+		content = filterResult.Text
+		contentFilterFlagRuleIDs = filterResult.FlagRuleIDs
+	}
+	// 世界表情短码 :shortcode: 展开为图片
+	if !ctx.User.IsBot {
+		content = service.ExpandWorldStickerShortcodes(channel.WorldID, ctx.User.ID, content)
+	}
 	var renderResult *service.DiceRenderResult
 	var isHiddenDice bool
 	if effectiveBuiltInDiceEnabled {
@@ -2209,6 +2279,12 @@ func apiMessageCreate(ctx *ChatContext, data *struct {
 	if len(whisperRecipientIDs) > 10 {
 		return nil, fmt.Errorf("悄悄话收件人数量不能超过10人")
 	}
+	if !ctx.User.IsBot && !hiddenWhisperToSelf {
+		targets := append([]string{whisperTo}, whisperRecipientIDs...)
+		if len(service.UserIDsBlockingSender(ctx.User.ID, targets)) > 0 {
+			return nil, fmt.Errorf("悄悄话对象已屏蔽你")
+		}
+	}
 	if len(whisperRecipientIDs) > 0 && whisperTo == "" {
 		whisperTo = whisperRecipientIDs[0]
 	}
@@ -2443,6 +2519,7 @@ func apiMessageCreate(ctx *ChatContext, data *struct {
 
 		userData := ctx.User.ToProtocolType()
 
+		fillMessageImagePlaceholders([]*model.MessageModel{&m})
 		messageData := m.ToProtocolType2(channelData)
 		messageData.Content = content
 		messageData.User = userData
@@ -2512,6 +2589,9 @@ func apiMessageCreate(ctx *ChatContext, data *struct {
 
 		_ = model.WebhookEventLogAppendForMessage(data.ChannelID, "message-created", m.ID)
 		notifyAppMessageCreated(m.ID)
+		if len(contentFilterFlagRuleIDs) > 0 {
+			go flagMessageByContentFilter(m.ID, contentFilterFlagRuleIDs)
+		}
 		go func(channelID string, message model.MessageModel) {
 			if err := service.RecordDigestWindowMessage(channelID, &message); err != nil {
 				log.Printf("digest-push: 记录消息摘要窗口失败 channel=%s message=%s err=%v", channelID, message.ID, err)
@@ -2568,6 +2648,15 @@ func apiMessageCreate(ctx *ChatContext, data *struct {
 				_ = model.ChannelReadInit(data.ChannelID, privateOtherUser)
 				ctx.BroadcastToUserJSON(privateOtherUser, buildMessageCreatedNoticePayload(data.ChannelID, content, privateOtherUser))
 			}
+		} else if channel.IsGroupDM() {
+			// 多人私聊只通知成员
+			for _, uid := range service.GroupDMMemberIDs(data.ChannelID) {
+				if uid == "" || uid == ctx.User.ID {
+					continue
+				}
+				_ = model.ChannelReadInit(data.ChannelID, uid)
+				ctx.BroadcastToUserJSON(uid, buildMessageCreatedNoticePayload(data.ChannelID, content, uid))
+			}
 		} else {
 			// 给当前在线人都通知一遍
 			var uids []string
@@ -2921,6 +3010,7 @@ func apiMessageList(ctx *ChatContext, data *struct {
 			i.Quote.EnsureWhisperMeta()
 		}
 	}
+	fillMessageImagePlaceholders(items)
 
 	if ctx.User != nil && len(items) > 0 {
 		ids := make([]string, 0, len(items))
@@ -2943,6 +3033,7 @@ func apiMessageList(ctx *ChatContext, data *struct {
 				}
 			}
 		}
+		markMessagesFromBlockedUsers(items, ctx.User.ID)
 	}
 
 	return &struct {
@@ -3011,6 +3102,10 @@ func apiMessageUpdate(ctx *ChatContext, data *struct {
 	if !isAuthor && !isAdminEdit {
 		return nil, nil
 	}
+	// 禁言期间同样不能通过编辑改写内容
+	if err := service.CheckChannelSendMuted(data.ChannelID, ctx.User.ID); err != nil {
+		return nil, err
+	}
 	channelData := channel.ToProtocolType()
 	effectiveBotFeatureEnabled := service.IsBotFeatureEffectivelyEnabled(channel)
 	effectiveBuiltInDiceEnabled := service.IsBuiltInDiceEffectivelyEnabled(channel)
@@ -3203,6 +3298,15 @@ func apiMessageUpdate(ctx *ChatContext, data *struct {
 		newContent = fillBotMentionNames(data.ChannelID, newContent)
 		newContent = protocol.EscapeSatoriText(newContent)
 	}
+	// 编辑无法进入审核队列，需审核规则视同拦截
+	filterResult := service.ContentFilterCheck(channel.WorldID, model.ContentFilterScopeMessage, newContent)
+	if filterResult.Blocked() || filterResult.NeedsApproval() {
+		return nil, service.ErrContentFilterBlocked
+	}
+	newContent = filterResult.Text
+	if !ctx.User.IsBot {
+		newContent = service.ExpandWorldStickerShortcodes(channel.WorldID, ctx.User.ID, newContent)
+	}
 	existingDiceRolls, err := model.MessageDiceRollListByMessageID(msg.ID)
 	if err != nil {
 		return nil, err
@@ -3225,6 +3329,7 @@ func apiMessageUpdate(ctx *ChatContext, data *struct {
 	}
 
 	buildMessage := func() *protocol.Message {
+		fillMessageImagePlaceholders([]*model.MessageModel{&msg})
 		messageData := msg.ToProtocolType2(channelData)
 		messageData.Content = msg.Content
 		if authorUser != nil {
@@ -3377,6 +3482,9 @@ func apiMessageUpdate(ctx *ChatContext, data *struct {
 	}
 
 	_ = model.WebhookEventLogAppendForMessage(data.ChannelID, "message-updated", msg.ID)
+	if len(filterResult.FlagRuleIDs) > 0 {
+		go flagMessageByContentFilter(msg.ID, filterResult.FlagRuleIDs)
+	}
 
 	return &struct {
 		Message *protocol.Message `json:"message"`
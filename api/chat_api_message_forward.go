@@ -175,6 +175,9 @@ func apiMessageForwardBatch(ctx *ChatContext, data *messageForwardBatchRequest)
 		if !pm.CanWithChannelRole(ctx.User.ID, channel.ID, pm.PermFuncChannelTextSend, pm.PermFuncChannelTextSendAll) {
 			return nil, fmt.Errorf("无权限在目标频道发言：%s", channel.Name)
 		}
+		if err := service.CheckChannelSendMuted(channel.ID, ctx.User.ID); err != nil {
+			return nil, err
+		}
 		member, memberErr := model.MemberGetByUserIDAndChannelID(ctx.User.ID, channel.ID, ctx.User.Nickname)
 		if memberErr != nil {
 			return nil, memberErr
@@ -28,6 +28,8 @@ type ChatContext struct {
 	Echo            string
 	ConnInfo        *ConnInfo
 	OneBotSessionID string
+	// SkipContentFilter 审核通过后重放发送时跳过内容过滤
+	SkipContentFilter bool
 
 	ChannelUsersMap *utils.SyncMap[string, *utils.SyncSet[string]]
 	UserId2ConnInfo *utils.SyncMap[string, *utils.SyncMap[*WsSyncConn, *ConnInfo]]
@@ -220,7 +222,9 @@ func (ctx *ChatContext) BroadcastEvent(data *protocol.Event) {
 
 func (ctx *ChatContext) BroadcastEventInChannel(channelId string, data *protocol.Event) {
 	data.Timestamp = time.Now().Unix()
-	ctx.rangeChannelConnMaps(channelId, func(_ string, connMap *utils.SyncMap[*WsSyncConn, *ConnInfo], indexed bool) bool {
+	marker := newBlockedSenderMarker(data)
+	ctx.rangeChannelConnMaps(channelId, func(userId string, connMap *utils.SyncMap[*WsSyncConn, *ConnInfo], indexed bool) bool {
+		ev := marker.eventFor(userId, data)
 		connMap.Range(func(conn *WsSyncConn, info *ConnInfo) bool {
 			if info != nil && ((indexed && info.ChannelId == "") || info.ChannelId == channelId) {
 				writeConnJSONAndPrune(connMap, conn, struct {
@@ -228,7 +232,7 @@ func (ctx *ChatContext) BroadcastEventInChannel(channelId string, data *protocol
 					Op protocol.Opcode `json:"op"`
 				}{
 					// 协议规定: 事件中必须含有 channel，message，user
-					Event: *data,
+					Event: *ev,
 					Op:    protocol.OpEvent,
 				})
 			}
@@ -283,6 +287,7 @@ func (ctx *ChatContext) BroadcastEventInChannelForBot(channelId string, data *pr
 			}
 		}
 		getOneBotRuntime().publishProtocolEvent(botID, data, ctx.OneBotSessionID)
+		getSatoriRuntime().publishProtocolEvent(botID, data)
 	}
 }
 
@@ -570,18 +575,20 @@ func (ctx *ChatContext) BroadcastEventInChannelToUsers(channelId string, userIds
 		}
 	}
 	data.Timestamp = time.Now().Unix()
+	marker := newBlockedSenderMarker(data)
 	for userId := range targets {
 		value, ok := ctx.UserId2ConnInfo.Load(userId)
 		if !ok || value == nil {
 			continue
 		}
+		ev := marker.eventFor(userId, data)
 		value.Range(func(conn *WsSyncConn, info *ConnInfo) bool {
 			if info != nil && (info.ChannelId == "" || info.ChannelId == channelId) {
 				writeConnJSONAndPrune(value, conn, struct {
 					protocol.Event
 					Op protocol.Opcode `json:"op"`
 				}{
-					Event: *data,
+					Event: *ev,
 					Op:    protocol.OpEvent,
 				})
 			}
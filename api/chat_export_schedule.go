@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/service"
+)
+
+type chatExportScheduleRequest struct {
+	ScopeType        string                         `json:"scope_type"`
+	ScopeID          string                         `json:"scope_id"`
+	Name             string                         `json:"name"`
+	Format           string                         `json:"format"`
+	DisplayName      string                         `json:"display_name"`
+	IncludeOOC       *bool                          `json:"include_ooc"`
+	IncludeArchived  *bool                          `json:"include_archived"`
+	IncludeImages    *bool                          `json:"include_images"`
+	WithoutTimestamp *bool                          `json:"without_timestamp"`
+	MergeMessages    *bool                          `json:"merge_messages"`
+	Frequency        string                         `json:"frequency"`
+	CronExpr         string                         `json:"cron_expr"`
+	TimeOfDay        string                         `json:"time_of_day"`
+	Weekday          int                            `json:"weekday"`
+	Targets          []service.ExportDeliveryTarget `json:"targets"`
+	AlertEmail       string                         `json:"alert_email"`
+	Incremental      *bool                          `json:"incremental"`
+	Enabled          *bool                          `json:"enabled"`
+}
+
+type chatExportScheduleItem struct {
+	*model.MessageExportScheduleModel
+	Targets []service.ExportDeliveryTarget `json:"targets"`
+}
+
+type chatExportScheduleRunItem struct {
+	*model.MessageExportScheduleRunModel
+	Deliveries []service.ExportDeliveryResult `json:"deliveries"`
+}
+
+func (req *chatExportScheduleRequest) toInput() service.ExportScheduleInput {
+	format := strings.TrimSpace(req.Format)
+	if format == "" {
+		format = "txt"
+	}
+	return service.ExportScheduleInput{
+		ScopeType:        strings.TrimSpace(req.ScopeType),
+		ScopeID:          strings.TrimSpace(req.ScopeID),
+		Name:             req.Name,
+		Format:           format,
+		DisplayName:      req.DisplayName,
+		IncludeOOC:       req.IncludeOOC == nil || *req.IncludeOOC,
+		IncludeArchived:  req.IncludeArchived != nil && *req.IncludeArchived,
+		IncludeImages:    req.IncludeImages == nil || *req.IncludeImages,
+		WithoutTimestamp: req.WithoutTimestamp != nil && *req.WithoutTimestamp,
+		MergeMessages:    req.MergeMessages == nil || *req.MergeMessages,
+		Frequency:        req.Frequency,
+		CronExpr:         req.CronExpr,
+		TimeOfDay:        req.TimeOfDay,
+		Weekday:          req.Weekday,
+		Targets:          req.Targets,
+		AlertEmail:       req.AlertEmail,
+		Incremental:      req.Incremental == nil || *req.Incremental,
+		Enabled:          req.Enabled == nil || *req.Enabled,
+	}
+}
+
+func buildChatExportScheduleItem(schedule *model.MessageExportScheduleModel) chatExportScheduleItem {
+	return chatExportScheduleItem{
+		MessageExportScheduleModel: schedule,
+		Targets:                    service.ParseExportScheduleTargets(schedule.Targets),
+	}
+}
+
+func ChatExportScheduleList(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "未认证"})
+	}
+	items, err := service.ListExportSchedules(user.ID, strings.TrimSpace(c.Query("scope_type")), c.Query("scope_id"))
+	if err != nil {
+		return c.Status(mapExportError(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	result := make([]chatExportScheduleItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, buildChatExportScheduleItem(item))
+	}
+	return c.JSON(fiber.Map{"items": result})
+}
+
+func ChatExportScheduleCreate(c *fiber.Ctx) error {
+	var req chatExportScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "请求体解析失败"})
+	}
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "未认证"})
+	}
+	schedule, err := service.CreateExportSchedule(user.ID, req.toInput())
+	if err != nil {
+		return c.Status(mapExportError(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"item": buildChatExportScheduleItem(schedule)})
+}
+
+func ChatExportScheduleUpdate(c *fiber.Ctx) error {
+	var req chatExportScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "请求体解析失败"})
+	}
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "未认证"})
+	}
+	schedule, err := service.UpdateExportSchedule(user.ID, c.Params("scheduleId"), req.toInput())
+	if err != nil {
+		return c.Status(mapExportError(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"item": buildChatExportScheduleItem(schedule)})
+}
+
+func ChatExportScheduleDelete(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "未认证"})
+	}
+	if err := service.DeleteExportSchedule(user.ID, c.Params("scheduleId")); err != nil {
+		return c.Status(mapExportError(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// ChatExportScheduleRun 立即执行一次，由后台调度在下一轮拾取
+func ChatExportScheduleRun(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "未认证"})
+	}
+	schedule, err := service.TriggerExportSchedule(user.ID, c.Params("scheduleId"))
+	if err != nil {
+		return c.Status(mapExportError(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"item": buildChatExportScheduleItem(schedule)})
+}
+
+func ChatExportScheduleRuns(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "未认证"})
+	}
+	runs, err := service.ListExportScheduleRuns(user.ID, c.Params("scheduleId"), parsePositiveInt(c.Query("limit"), 20))
+	if err != nil {
+		return c.Status(mapExportError(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	items := make([]chatExportScheduleRunItem, 0, len(runs))
+	for _, run := range runs {
+		item := chatExportScheduleRunItem{MessageExportScheduleRunModel: run, Deliveries: []service.ExportDeliveryResult{}}
+		if strings.TrimSpace(run.Deliveries) != "" {
+			_ = json.Unmarshal([]byte(run.Deliveries), &item.Deliveries)
+		}
+		items = append(items, item)
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
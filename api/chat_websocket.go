@@ -354,6 +354,12 @@ func websocketWorks(app *fiber.App, webUrl string) {
 	channelUsersMapGlobal = channelUsersMap
 	userId2ConnInfoGlobal = userId2ConnInfo
 	service.AppNotificationUserSuppressingExternal = isUserSuppressingExternalNotification
+	service.ReportMessageRemover = removeMessageForReport
+	service.AttachmentVideoStateNotifier = broadcastAttachmentVideoState
+	service.EmailReplyMessageCreator = createMessageForEmailReply
+	service.WorldMemberChangeNotifier = func(worldID, userID, operatorID string, joined bool) {
+		go notifyOneBotWorldMemberChange(worldID, userID, operatorID, joined)
+	}
 
 	// 在线态兜底广播：事件驱动为主，周期性全量广播用于状态收敛。
 	go func() {
@@ -1029,6 +1035,22 @@ func websocketWorks(app *fiber.App, webUrl string) {
 					case "sticky-note.push":
 						apiWrap(ctx, msg, apiStickyNotePushWs)
 						solved = true
+					// Voice APIs
+					case "voice.join":
+						apiWrap(ctx, msg, apiVoiceJoin)
+						solved = true
+					case "voice.leave":
+						apiWrap(ctx, msg, apiVoiceLeave)
+						solved = true
+					case "voice.signal":
+						apiWrap(ctx, msg, apiVoiceSignal)
+						solved = true
+					case "voice.state.update":
+						apiWrap(ctx, msg, apiVoiceStateUpdate)
+						solved = true
+					case "voice.moderate":
+						apiWrap(ctx, msg, apiVoiceModerate)
+						solved = true
 
 					case "channel.create":
 						apiWrap(ctx, msg, apiChannelCreate)
@@ -1037,6 +1059,30 @@ func websocketWorks(app *fiber.App, webUrl string) {
 						// 私聊
 						apiWrap(ctx, msg, apiChannelPrivateCreate)
 						solved = true
+					case "channel.group.create": // 自设API：多人私聊
+						apiWrap(ctx, msg, apiGroupDMCreate)
+						solved = true
+					case "channel.group.list":
+						apiWrap(ctx, msg, apiGroupDMList)
+						solved = true
+					case "channel.group.members":
+						apiWrap(ctx, msg, apiGroupDMMembers)
+						solved = true
+					case "channel.group.member.add":
+						apiWrap(ctx, msg, apiGroupDMMemberAdd)
+						solved = true
+					case "channel.group.member.remove":
+						apiWrap(ctx, msg, apiGroupDMMemberRemove)
+						solved = true
+					case "channel.group.leave":
+						apiWrap(ctx, msg, apiGroupDMLeave)
+						solved = true
+					case "channel.group.update":
+						apiWrap(ctx, msg, apiGroupDMUpdate)
+						solved = true
+					case "channel.group.owner.transfer":
+						apiWrap(ctx, msg, apiGroupDMOwnerTransfer)
+						solved = true
 					case "channel.list":
 						apiWrap(ctx, msg, apiChannelList)
 						solved = true
@@ -1349,6 +1395,7 @@ func websocketWorks(app *fiber.App, webUrl string) {
 			ChannelUsersMap: channelUsersMap,
 			UserId2ConnInfo: userId2ConnInfo,
 		}
+		voiceLeaveConn(ctx, c, "")
 		for chId := range affectedChannelIDs {
 			value, ok := channelUsersMap.Load(chId)
 			if !ok || value == nil {
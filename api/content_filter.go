@@ -0,0 +1,272 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
+)
+
+func contentFilterErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrContentFilterPermission):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrContentFilterRuleNotFound), errors.Is(err, service.ErrContentFilterPendingNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrContentFilterPendingReviewed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrContentFilterRuleInvalid):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "操作失败"})
+}
+
+// 世界路由与管理员路由共用处理函数，管理员路由没有 worldId，即操作全站规则
+
+func ContentFilterRuleListHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	items, err := service.ContentFilterRuleList(c.Params("worldId"), user.ID)
+	if err != nil {
+		return contentFilterErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func ContentFilterRuleCreateHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body service.ContentFilterRuleInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	rule, err := service.ContentFilterRuleCreate(c.Params("worldId"), user.ID, body)
+	if err != nil {
+		return contentFilterErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"rule": rule})
+}
+
+func ContentFilterRuleImportHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		Words []string `json:"words"`
+		Text  string   `json:"text"`
+		service.ContentFilterRuleInput
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	words := body.Words
+	for _, line := range strings.Split(body.Text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			words = append(words, line)
+		}
+	}
+	count, err := service.ContentFilterRuleImport(c.Params("worldId"), user.ID, words, body.ContentFilterRuleInput)
+	if err != nil {
+		return contentFilterErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"imported": count})
+}
+
+func ContentFilterRuleUpdateHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body service.ContentFilterRuleInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	rule, err := service.ContentFilterRuleUpdate(c.Params("ruleId"), user.ID, body)
+	if err != nil {
+		return contentFilterErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"rule": rule})
+}
+
+func ContentFilterRuleDeleteHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if err := service.ContentFilterRuleDelete(c.Params("ruleId"), user.ID); err != nil {
+		return contentFilterErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func ContentFilterTestHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		Scope string                          `json:"scope"`
+		Text  string                          `json:"text"`
+		Rule  *service.ContentFilterRuleInput `json:"rule"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	result, err := service.ContentFilterTest(c.Params("worldId"), user.ID, body.Scope, body.Text, body.Rule)
+	if err != nil {
+		return contentFilterErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"result": result})
+}
+
+func ContentFilterStatsHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	stats, err := service.ContentFilterStatsGet(c.Params("worldId"), user.ID)
+	if err != nil {
+		return contentFilterErrorResponse(c, err)
+	}
+	return c.JSON(stats)
+}
+
+func ContentFilterPendingListHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	items, err := service.ContentFilterPendingList(c.Params("worldId"), user.ID, c.Query("status"))
+	if err != nil {
+		return contentFilterErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func ContentFilterPendingReviewHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		Approve bool `json:"approve"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	item, err := service.ContentFilterPendingReview(c.Params("pendingId"), user.ID, body.Approve)
+	if err != nil {
+		return contentFilterErrorResponse(c, err)
+	}
+	if body.Approve {
+		messageID, err := publishApprovedContent(item)
+		if err != nil {
+			log.Printf("content-filter: 审核通过后发送失败 pending=%s err=%v", item.ID, err)
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": "审核已通过，但消息发送失败：" + err.Error(), "item": item})
+		}
+		item.MessageID = messageID
+	}
+	notifyContentFilterReviewed(item)
+	return c.JSON(fiber.Map{"item": item})
+}
+
+// holdMessageForContentApproval 暂存命中“需审核”规则的消息并提醒管理员
+func holdMessageForContentApproval(ctx *ChatContext, channel *model.ChannelModel, content string, ruleIDs []string, payload any) (any, error) {
+	item, err := service.ContentFilterPendingCreate(channel.WorldID, channel.ID, ctx.User.ID, content, ruleIDs, payload)
+	if err != nil {
+		return nil, err
+	}
+	reviewerIDs := service.ContentFilterReviewerIDs(channel.WorldID)
+	if len(reviewerIDs) > 0 {
+		broadcastEventToUsers(reviewerIDs, &protocol.Event{
+			Type: protocol.EventContentFilterPending,
+			Argv: &protocol.Argv{Options: map[string]interface{}{"worldId": item.WorldID, "pendingId": item.ID, "channelId": item.ChannelID}},
+		})
+		notice := service.AppNotificationNotice{
+			EventType: "content-filter.pending",
+			Title:     channel.Name,
+			Body:      "有消息等待审核",
+			DedupeKey: "content-filter.pending:" + item.ID,
+			WorldID:   item.WorldID,
+		}
+		if item.WorldID != "" {
+			notice.OpenPath = item.WorldID + "/content-filter"
+		}
+		webURL := currentAppWebURL()
+		go func() {
+			_ = service.EnqueueAppNotificationNotice(reviewerIDs, notice, webURL)
+		}()
+	}
+	return &struct {
+		Pending   bool   `json:"pending"`
+		PendingID string `json:"pendingId"`
+		Message   string `json:"message"`
+	}{
+		Pending:   true,
+		PendingID: item.ID,
+		Message:   service.ErrContentFilterNeedsApproval.Error(),
+	}, nil
+}
+
+// publishApprovedContent 以作者身份按原始参数重放发送，跳过内容过滤
+func publishApprovedContent(item *model.ContentFilterPendingModel) (string, error) {
+	author := model.UserGet(item.UserID)
+	if author == nil || author.ID == "" {
+		return "", errors.New("消息作者不存在")
+	}
+	data := &struct {
+		ChannelID         string   `json:"channel_id"`
+		QuoteID           string   `json:"quote_id"`
+		Content           string   `json:"content"`
+		WhisperTo         string   `json:"whisper_to"`
+		WhisperToIds      []string `json:"whisper_to_ids"`
+		ClientID          string   `json:"client_id"`
+		IdentityID        string   `json:"identity_id"`
+		IdentityVariantID string   `json:"identity_variant_id"`
+		ICMode            string   `json:"ic_mode"`
+		BeforeID          string   `json:"before_id"`
+		AfterID           string   `json:"after_id"`
+		DisplayOrder      *float64 `json:"display_order"`
+		TypingDurationMs  *int64   `json:"typing_duration_ms"`
+	}{}
+	if err := json.Unmarshal([]byte(item.PayloadJSON), data); err != nil {
+		return "", err
+	}
+	// 审核期间其他消息已继续发送，不再沿用原排序位置
+	data.BeforeID = ""
+	data.AfterID = ""
+	data.DisplayOrder = nil
+	ctx := &ChatContext{
+		User:              author,
+		ChannelUsersMap:   channelUsersMapGlobal,
+		UserId2ConnInfo:   userId2ConnInfoGlobal,
+		SkipContentFilter: true,
+	}
+	resp, err := apiMessageCreate(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	message, _ := resp.(*protocol.Message)
+	if message == nil || message.ID == "" {
+		return "", errors.New("消息发送失败")
+	}
+	_ = service.ContentFilterPendingSetMessage(item.ID, message.ID)
+	return message.ID, nil
+}
+
+func notifyContentFilterReviewed(item *model.ContentFilterPendingModel) {
+	body := "你的消息已通过审核并发布"
+	if item.Status == model.ContentFilterPendingStatusRejected {
+		body = "你的消息未通过审核"
+	}
+	broadcastEventToUsers([]string{item.UserID}, &protocol.Event{
+		Type: protocol.EventContentFilterPending,
+		Argv: &protocol.Argv{Options: map[string]interface{}{"pendingId": item.ID, "status": item.Status, "messageId": item.MessageID}},
+	})
+	notice := service.AppNotificationNotice{
+		EventType: "content-filter.reviewed",
+		Title:     "消息审核结果",
+		Body:      body,
+		DedupeKey: "content-filter.reviewed:" + item.ID,
+		WorldID:   item.WorldID,
+	}
+	webURL := currentAppWebURL()
+	go func() {
+		_ = service.EnqueueAppNotificationNotice([]string{item.UserID}, notice, webURL)
+	}()
+}
+
+// flagMessageByContentFilter 命中“标记”规则的消息自动生成举报
+func flagMessageByContentFilter(messageID string, ruleIDs []string) {
+	report, err := service.ContentFilterFlagMessage(messageID, ruleIDs)
+	if err != nil {
+		log.Printf("content-filter: 自动标记消息失败 message=%s err=%v", messageID, err)
+		return
+	}
+	notifyReportCreated(report)
+}
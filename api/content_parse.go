@@ -2,15 +2,27 @@ package api
 
 import (
 	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
 	"sealchat/utils"
 )
 
 func (ctx *ChatContext) TagCheck(ChannelID, msgId, text string) {
 	db := model.GetDB()
-	for receiverID := range collectMentionTargetIDsFromContent(text) {
+	targets := collectMentionTargetIDsFromContent(text)
+	candidates := make([]string, 0, len(targets))
+	for receiverID := range targets {
+		candidates = append(candidates, receiverID)
+	}
+	// 屏蔽了发送者的用户不会收到提及
+	blocking := service.UserIDsBlockingSender(ctx.User.ID, candidates)
+	for receiverID := range targets {
 		if receiverID == "" {
 			continue
 		}
+		if _, blocked := blocking[receiverID]; blocked {
+			continue
+		}
 		mention := model.MentionModel{
 			StringPKBaseModel: model.StringPKBaseModel{
 				ID: utils.NewID(),
@@ -25,3 +37,70 @@ func (ctx *ChatContext) TagCheck(ChannelID, msgId, text string) {
 		db.Create(&mention)
 	}
 }
+
+// markMessagesFromBlockedUsers 标记当前用户已屏蔽的发送者，消息仍下发以保持时间线连续
+func markMessagesFromBlockedUsers(items []*model.MessageModel, userID string) {
+	blocked := service.UserBlockedIDSet(userID)
+	if len(blocked) == 0 {
+		return
+	}
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if _, ok := blocked[item.UserID]; ok {
+			item.SenderBlocked = true
+		}
+		if item.Quote != nil {
+			if _, ok := blocked[item.Quote.UserID]; ok {
+				item.Quote.SenderBlocked = true
+			}
+		}
+	}
+}
+
+// blockedSenderMarker 实时广播时按接收者标记已屏蔽的发送者，与列表接口的 sender_blocked 一致
+type blockedSenderMarker struct {
+	senderBlockers map[string]struct{}
+	quoteBlockers  map[string]struct{}
+}
+
+// newBlockedSenderMarker 事件不含消息或无人屏蔽相关发送者时返回 nil
+func newBlockedSenderMarker(data *protocol.Event) *blockedSenderMarker {
+	if data == nil || data.Message == nil {
+		return nil
+	}
+	marker := &blockedSenderMarker{}
+	if data.Message.User != nil {
+		marker.senderBlockers = service.UserBlockerIDSet(data.Message.User.ID)
+	}
+	if quote := data.Message.Quote; quote != nil && quote.User != nil {
+		marker.quoteBlockers = service.UserBlockerIDSet(quote.User.ID)
+	}
+	if len(marker.senderBlockers) == 0 && len(marker.quoteBlockers) == 0 {
+		return nil
+	}
+	return marker
+}
+
+// eventFor 接收者屏蔽了发送者时返回带标记的副本，否则原样返回
+func (m *blockedSenderMarker) eventFor(userID string, data *protocol.Event) *protocol.Event {
+	if m == nil {
+		return data
+	}
+	_, senderBlocked := m.senderBlockers[userID]
+	_, quoteBlocked := m.quoteBlockers[userID]
+	if !senderBlocked && !quoteBlocked {
+		return data
+	}
+	ev := *data
+	msg := *data.Message
+	msg.SenderBlocked = senderBlocked
+	if quoteBlocked {
+		quote := *msg.Quote
+		quote.SenderBlocked = true
+		msg.Quote = &quote
+	}
+	ev.Message = &msg
+	return &ev
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/pm"
+	"sealchat/service"
+)
+
+// parseDiceStatsFilter 复用输入统计的时间与场内外筛选参数
+func parseDiceStatsFilter(c *fiber.Ctx) model.DiceStatsFilter {
+	base := parseStatsFilterParams(c)
+	return model.DiceStatsFilter{
+		IdentityID: strings.TrimSpace(c.Query("identityId")),
+		StartTime:  base.StartTime,
+		EndTime:    base.EndTime,
+		ICMode:     base.ICMode,
+	}
+}
+
+// UserDiceStats 当前用户的掷骰统计，可按世界/频道/角色缩小范围
+func UserDiceStats(c *fiber.Ctx) error {
+	u := getCurUser(c)
+	if u == nil {
+		return fiber.NewError(http.StatusUnauthorized, "未登录")
+	}
+
+	f := parseDiceStatsFilter(c)
+	f.UserID = u.ID
+	f.WorldID = strings.TrimSpace(c.Query("worldId"))
+	f.IncludeWhisper = true
+	if channelID := strings.TrimSpace(c.Query("channelId")); channelID != "" {
+		f.ChannelIDs = []string{channelID}
+	}
+
+	report, err := service.ComputeDiceStats(f, service.DiceStatsOptions{GroupByIdentity: true})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.Status(http.StatusOK).JSON(report)
+}
+
+// ChannelDiceStats 频道内的掷骰统计，按成员与角色分组，不包含悄悄话
+func ChannelDiceStats(c *fiber.Ctx) error {
+	u := getCurUser(c)
+	if u == nil {
+		return fiber.NewError(http.StatusUnauthorized, "未登录")
+	}
+
+	channelID := strings.TrimSpace(c.Params("channelId"))
+	if channelID == "" {
+		return fiber.NewError(http.StatusBadRequest, "channelId 不能为空")
+	}
+	if len(channelID) < 30 {
+		if !pm.CanWithChannelRole(u.ID, channelID, pm.PermFuncChannelRead, pm.PermFuncChannelReadAll) {
+			return fiber.NewError(http.StatusForbidden, "无权查看该频道")
+		}
+	} else {
+		fr, _ := model.FriendRelationGetByID(channelID)
+		if fr.ID == "" || (fr.UserID1 != u.ID && fr.UserID2 != u.ID) {
+			return fiber.NewError(http.StatusForbidden, "无权查看该频道")
+		}
+	}
+
+	f := parseDiceStatsFilter(c)
+	f.UserID = strings.TrimSpace(c.Query("userId"))
+	f.ChannelIDs = []string{channelID}
+
+	report, err := service.ComputeDiceStats(f, service.DiceStatsOptions{GroupByUser: true, GroupByIdentity: true})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.Status(http.StatusOK).JSON(report)
+}
+
+// WorldDiceStats 世界内的掷骰统计，仅统计当前用户可见的频道
+func WorldDiceStats(c *fiber.Ctx) error {
+	u := getCurUser(c)
+	if u == nil {
+		return fiber.NewError(http.StatusUnauthorized, "未登录")
+	}
+
+	worldID := strings.TrimSpace(c.Params("worldId"))
+	if worldID == "" {
+		return fiber.NewError(http.StatusBadRequest, "worldId 不能为空")
+	}
+	if !service.IsWorldMember(worldID, u.ID) && !canViewAllInputStatsMeta(u.ID) {
+		return fiber.NewError(http.StatusForbidden, "尚未加入该世界")
+	}
+
+	visibleChannelIDs, err := service.ChannelIdListByWorld(u.ID, worldID, false)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if channelID := strings.TrimSpace(c.Query("channelId")); channelID != "" {
+		found := false
+		for _, id := range visibleChannelIDs {
+			if id == channelID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fiber.NewError(http.StatusForbidden, "无权查看该频道")
+		}
+		visibleChannelIDs = []string{channelID}
+	}
+	if len(visibleChannelIDs) == 0 {
+		return c.Status(http.StatusOK).JSON(&service.DiceStatsReport{Overall: &service.DiceStatsResult{Dice: []*service.DiceFaceStats{}}})
+	}
+
+	f := parseDiceStatsFilter(c)
+	f.UserID = strings.TrimSpace(c.Query("userId"))
+	f.WorldID = worldID
+	f.ChannelIDs = visibleChannelIDs
+
+	report, err := service.ComputeDiceStats(f, service.DiceStatsOptions{GroupByUser: true, GroupByIdentity: true})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.Status(http.StatusOK).JSON(report)
+}
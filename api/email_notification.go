@@ -8,6 +8,7 @@ import (
 
 	"sealchat/model"
 	"sealchat/pm"
+	"sealchat/protocol"
 	"sealchat/service"
 	"sealchat/utils"
 )
@@ -316,3 +317,42 @@ func AdminEmailTestSend(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"success": true, "message": "测试邮件已发送至 " + email})
 }
+
+// createMessageForEmailReply 将通知邮件的回复以用户身份发送到频道，沿用常规消息发送的权限校验与广播
+func createMessageForEmailReply(userID, channelID, content string) error {
+	user := model.UserGet(userID)
+	if user == nil || user.ID == "" || user.Disabled {
+		return service.ErrEmailReplyForbidden
+	}
+	ctx := &ChatContext{
+		User:            user,
+		ChannelUsersMap: channelUsersMapGlobal,
+		UserId2ConnInfo: userId2ConnInfoGlobal,
+	}
+	resp, err := apiMessageCreate(ctx, &struct {
+		ChannelID         string   `json:"channel_id"`
+		QuoteID           string   `json:"quote_id"`
+		Content           string   `json:"content"`
+		WhisperTo         string   `json:"whisper_to"`
+		WhisperToIds      []string `json:"whisper_to_ids"`
+		ClientID          string   `json:"client_id"`
+		IdentityID        string   `json:"identity_id"`
+		IdentityVariantID string   `json:"identity_variant_id"`
+		ICMode            string   `json:"ic_mode"`
+		BeforeID          string   `json:"before_id"`
+		AfterID           string   `json:"after_id"`
+		DisplayOrder      *float64 `json:"display_order"`
+		TypingDurationMs  *int64   `json:"typing_duration_ms"`
+	}{
+		ChannelID: channelID,
+		Content:   content,
+	})
+	if err != nil {
+		return err
+	}
+	// 无发言权限时 apiMessageCreate 返回空结果
+	if message, _ := resp.(*protocol.Message); message == nil || message.ID == "" {
+		return service.ErrEmailReplyForbidden
+	}
+	return nil
+}
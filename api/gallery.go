@@ -175,7 +175,11 @@ func GalleryItemsList(c *fiber.Ctx) error {
 	}
 	keyword := strings.TrimSpace(c.Query("keyword"))
 	return utils.APIPaginatedList(c, func(page, pageSize int) ([]*model.GalleryItem, int64, error) {
-		return model.ListGalleryItems(collectionID, keyword, page, pageSize)
+		items, total, err := model.ListGalleryItems(collectionID, keyword, page, pageSize)
+		if err == nil {
+			model.FillGalleryItemPlaceholders(items)
+		}
+		return items, total, err
 	})
 }
 
@@ -434,6 +438,7 @@ func GallerySearch(c *fiber.Ctx) error {
 	if err != nil {
 		return wrapError(c, err, "搜索表情失败")
 	}
+	model.FillGalleryItemPlaceholders(items)
 	results = append(results, items...)
 
 	if len(results) > 0 {
@@ -39,6 +39,10 @@ func MessageReactionAdd(c *fiber.Ctx) error {
 	if emoji == "" {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": "emoji 不能为空"})
 	}
+	emoji, err = service.ResolveWorldStickerReactionEmoji(channel.WorldID, user.ID, emoji)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
 
 	identityID := strings.TrimSpace(req.IdentityID)
 	identity, err := service.ChannelIdentityValidateMessageIdentity(user.ID, channel.ID, identityID)
@@ -90,6 +94,10 @@ func MessageReactionRemove(c *fiber.Ctx) error {
 	if emoji == "" {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": "emoji 不能为空"})
 	}
+	emoji, err = service.ResolveWorldStickerReactionEmoji(channel.WorldID, user.ID, emoji)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
 
 	summary, err := service.RemoveMessageReaction(msg.ID, user.ID, emoji)
 	if err != nil {
@@ -59,6 +59,7 @@ type messageSearchItem struct {
 	WhisperToUserID string                 `json:"whisper_to_user_id,omitempty"`
 	HighlightRanges [][2]int               `json:"highlight_ranges,omitempty"`
 	WhisperLabel    string                 `json:"whisper_label,omitempty"`
+	SenderBlocked   bool                   `json:"sender_blocked,omitempty"`
 }
 
 type messageSearchUser struct {
@@ -348,6 +349,7 @@ func executeChannelMessageSearch(c *fiber.Ctx, channelID, viewerUserID string, c
 	archivedFilter, icMode, includeOutside, timeStart, timeEnd, speakerIDs := parseMessageSearchFilters(c, "")
 
 	sortMode := strings.ToLower(strings.TrimSpace(c.Query("sort", "time_desc")))
+	searchMode := parseMessageSearchMode(c.Query("search_mode"))
 
 	db := model.GetDB()
 	buildBaseQuery := func() *gorm.DB {
@@ -434,6 +436,31 @@ func executeChannelMessageSearch(c *fiber.Ctx, channelID, viewerUserID string, c
 		}
 	}
 
+	filters := map[string]any{
+		"archived":        archivedFilter,
+		"ic_mode":         icMode,
+		"include_outside": includeOutside,
+		"time_start":      timeStart,
+		"time_end":        timeEnd,
+		"speaker_ids":     speakerIDs,
+		"sort":            sortMode,
+		"search_mode":     searchMode,
+	}
+	if searchMode != messageSearchModeKeyword {
+		return executeSemanticMessageSearch(c, semanticMessageSearchRequest{
+			channelID:    channelID,
+			keyword:      keyword,
+			mode:         searchMode,
+			page:         page,
+			pageSize:     pageSize,
+			baseQuery:    buildBaseQuery,
+			keywordQuery: query,
+			channelRef:   channelRef,
+			filters:      filters,
+			viewerUserID: viewerUserID,
+		})
+	}
+
 	dataQuery := query.Session(&gorm.Session{})
 	switch sortMode {
 	case "relevance":
@@ -465,6 +492,7 @@ func executeChannelMessageSearch(c *fiber.Ctx, channelID, viewerUserID string, c
 		})
 	}
 
+	markMessagesFromBlockedUsers(messages, viewerUserID)
 	items := lo.Map(messages, func(msg *model.MessageModel, _ int) messageSearchItem {
 		return buildMessageSearchItem(msg)
 	})
@@ -484,15 +512,7 @@ func executeChannelMessageSearch(c *fiber.Ctx, channelID, viewerUserID string, c
 			ID:   channelRef.ID,
 			Name: channelRef.Name,
 		},
-		Filters: map[string]any{
-			"archived":        archivedFilter,
-			"ic_mode":         icMode,
-			"include_outside": includeOutside,
-			"time_start":      timeStart,
-			"time_end":        timeEnd,
-			"speaker_ids":     speakerIDs,
-			"sort":            sortMode,
-		},
+		Filters: filters,
 		Metadata: map[string]any{
 			"search_backend": backendName,
 			"sqlite": map[string]any{
@@ -664,6 +684,7 @@ func executeChannelMessageSearchRefine(c *fiber.Ctx, channelID, viewerUserID str
 		})
 	}
 
+	markMessagesFromBlockedUsers(messages, viewerUserID)
 	items := lo.Map(messages, func(msg *model.MessageModel, _ int) messageSearchItem {
 		return buildMessageSearchItem(msg)
 	})
@@ -1168,9 +1189,10 @@ func buildMessageSearchItem(msg *model.MessageModel) messageSearchItem {
 			}
 			return msg.ArchivedAt.UnixMilli()
 		}(),
-		CreatedAt:    msg.CreatedAt.UnixMilli(),
-		DisplayOrder: msg.DisplayOrder,
-		IsWhisper:    msg.IsWhisper,
+		CreatedAt:     msg.CreatedAt.UnixMilli(),
+		DisplayOrder:  msg.DisplayOrder,
+		IsWhisper:     msg.IsWhisper,
+		SenderBlocked: msg.SenderBlocked,
 	}
 	if msg.WhisperTo != "" {
 		item.WhisperToUserID = msg.WhisperTo
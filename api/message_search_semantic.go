@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/service"
+	"sealchat/service/vectorindex"
+)
+
+const (
+	messageSearchModeKeyword  = "keyword"
+	messageSearchModeSemantic = "semantic"
+	messageSearchModeHybrid   = "hybrid"
+
+	semanticSearchCandidateLimit = 200
+	// hybridSearchRRFK 倒数排名融合的平滑常数，数值越大越弱化头部名次的优势
+	hybridSearchRRFK = 60
+)
+
+type semanticMessageSearchRequest struct {
+	channelID    string
+	keyword      string
+	mode         string
+	page         int
+	pageSize     int
+	baseQuery    func() *gorm.DB
+	keywordQuery *gorm.DB
+	channelRef   *messageSearchChannelRef
+	filters      map[string]any
+	viewerUserID string
+}
+
+func parseMessageSearchMode(raw string) string {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case messageSearchModeSemantic:
+		return messageSearchModeSemantic
+	case messageSearchModeHybrid:
+		return messageSearchModeHybrid
+	default:
+		return messageSearchModeKeyword
+	}
+}
+
+// executeSemanticMessageSearch 向量候选先经过与关键词搜索相同的基础条件（悄悄话可见性、归档、场内外、时间），
+// 混合模式再与关键词结果按倒数排名融合，分页在融合后的列表上进行。
+func executeSemanticMessageSearch(c *fiber.Ctx, req semanticMessageSearchRequest) error {
+	hits, err := service.SemanticSearchChannel(c.Context(), req.channelID, req.keyword, semanticSearchCandidateLimit)
+	if err != nil {
+		if errors.Is(err, service.ErrSemanticSearchUnavailable) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+		}
+		log.Printf("语义搜索失败 channel=%s: %v", req.channelID, err)
+		return c.Status(http.StatusBadGateway).JSON(fiber.Map{"message": "语义搜索失败"})
+	}
+	hitIDs := lo.Map(hits, func(hit vectorindex.Hit, _ int) string { return hit.ID })
+
+	var visibleIDs []string
+	if len(hitIDs) > 0 {
+		if err := req.baseQuery().Where("id IN ?", hitIDs).Pluck("id", &visibleIDs).Error; err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "查询失败"})
+		}
+	}
+	visible := lo.SliceToMap(visibleIDs, func(id string) (string, struct{}) { return id, struct{}{} })
+	rankings := [][]string{lo.Filter(hitIDs, func(id string, _ int) bool {
+		_, ok := visible[id]
+		return ok
+	})}
+	if req.mode == messageSearchModeHybrid && req.keywordQuery != nil {
+		var keywordIDs []string
+		if err := req.keywordQuery.Session(&gorm.Session{}).
+			Order("display_order desc").Order("created_at desc").
+			Limit(semanticSearchCandidateLimit).
+			Pluck("id", &keywordIDs).Error; err != nil {
+			log.Printf("混合搜索关键词部分失败，仅使用语义结果: %v", err)
+		} else {
+			rankings = append(rankings, keywordIDs)
+		}
+	}
+	fused := fuseMessageSearchRankings(rankings...)
+
+	total := len(fused)
+	start := (req.page - 1) * req.pageSize
+	if start < 0 || start > total {
+		start = total
+	}
+	end := min(start+req.pageSize, total)
+	pageIDs := fused[start:end]
+
+	var messages []*model.MessageModel
+	if len(pageIDs) > 0 {
+		if err := model.GetDB().Model(&model.MessageModel{}).
+			Where("id IN ?", pageIDs).
+			Preload("User", func(tx *gorm.DB) *gorm.DB {
+				return tx.Select("id, username, nickname, avatar, is_bot")
+			}).
+			Preload("Member", func(tx *gorm.DB) *gorm.DB {
+				return tx.Select("id, nickname, channel_id, user_id")
+			}).
+			Find(&messages).Error; err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "查询失败"})
+		}
+	}
+	markMessagesFromBlockedUsers(messages, req.viewerUserID)
+	byID := lo.KeyBy(messages, func(msg *model.MessageModel) string { return msg.ID })
+	items := make([]messageSearchItem, 0, len(pageIDs))
+	for _, id := range pageIDs {
+		if msg, ok := byID[id]; ok {
+			items = append(items, buildMessageSearchItem(msg))
+		}
+	}
+
+	return c.JSON(messageSearchResponse{
+		Page:     req.page,
+		PageSize: req.pageSize,
+		Total:    int64(total),
+		HasMore:  end < total,
+		Items:    items,
+		Keyword:  req.keyword,
+		Match:    req.mode,
+		Channel: &messageSearchChannelRef{
+			ID:   req.channelRef.ID,
+			Name: req.channelRef.Name,
+		},
+		Filters: req.filters,
+		Metadata: map[string]any{
+			"search_backend":      req.mode,
+			"search_mode":         req.mode,
+			"semantic_candidates": len(hitIDs),
+			"semantic_index":      service.GetSemanticIndexStatus(),
+		},
+	})
+}
+
+// fuseMessageSearchRankings 倒数排名融合：每个列表贡献 1/(k+名次)，同分时保留先出现的顺序
+func fuseMessageSearchRankings(rankings ...[]string) []string {
+	scores := map[string]float64{}
+	order := make([]string, 0)
+	for _, ranking := range rankings {
+		for rank, id := range ranking {
+			if _, seen := scores[id]; !seen {
+				order = append(order, id)
+			}
+			scores[id] += 1 / float64(hybridSearchRRFK+rank+1)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	return order
+}
@@ -105,3 +105,16 @@ func TestBuildSnippetTruncatesByRuneLength(t *testing.T) {
 		t.Fatalf("buildSnippet() = %q, want %q", got, want)
 	}
 }
+
+func TestFuseMessageSearchRankingsPrefersItemsInBothLists(t *testing.T) {
+	fused := fuseMessageSearchRankings(
+		[]string{"semantic-only", "both", "semantic-tail"},
+		[]string{"keyword-only", "both"},
+	)
+	if len(fused) != 4 || fused[0] != "both" {
+		t.Fatalf("item ranked by both lists should come first: %v", fused)
+	}
+	if fused[1] != "semantic-only" || fused[2] != "keyword-only" {
+		t.Fatalf("ties should keep first-seen order: %v", fused)
+	}
+}
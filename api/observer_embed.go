@@ -84,7 +84,13 @@ func ObserverBattleReportGet(c *fiber.Ctx) error {
 		}
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "没有访问该战报的权限"})
 	}
-	return c.JSON(fiber.Map{"item": battleReportToResponse(item, true)})
+	resp := battleReportToResponse(item, true)
+	if item.IncludeDiceStats {
+		if resp.DiceStats, err = service.BattleReportDiceStats(item, []string{item.ChannelID}); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	return c.JSON(fiber.Map{"item": resp})
 }
 
 func ObserverChannelIFormList(c *fiber.Ctx) error {
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/service"
+)
+
+const (
+	observerFeedFormatAtom = "atom"
+	observerFeedFormatJSON = "json"
+)
+
+func ObserverFeedAtom(c *fiber.Ctx) error {
+	return observerFeedHandler(c, observerFeedFormatAtom)
+}
+
+func ObserverFeedJSON(c *fiber.Ctx) error {
+	return observerFeedHandler(c, observerFeedFormatJSON)
+}
+
+// observerFeedHandler 输出 OB 世界或单个频道的场内消息订阅源，支持 before 分页与条件请求
+func observerFeedHandler(c *fiber.Ctx, format string) error {
+	slug := strings.TrimSpace(c.Params("slug"))
+	if slug == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "缺少OB链接标识"})
+	}
+	world, _, err := service.ResolveWorldObserverLink(slug)
+	if err != nil || world == nil {
+		if err == nil || errors.Is(err, service.ErrWorldObserverLinkInvalid) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "旁观链接无效或已关闭"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "解析旁观链接失败"})
+	}
+
+	var channel *model.ChannelModel
+	if channelID := strings.TrimSpace(c.Params("channelId")); channelID != "" {
+		channel, err = service.CanObserverAccessChannel(channelID, world.ID)
+		if err != nil {
+			if strings.Contains(err.Error(), "不存在") {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "频道不存在"})
+			}
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "没有访问该频道的权限"})
+		}
+	}
+
+	limit, _ := strconv.Atoi(strings.TrimSpace(c.Query("limit")))
+	feed, err := service.LoadObserverFeed(world, channel, service.ObserverFeedQuery{
+		Limit:  limit,
+		Before: strings.TrimSpace(c.Query("before")),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrObserverFeedCursorInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "读取订阅源失败"})
+	}
+
+	base := strings.TrimRight(c.BaseURL(), "/")
+	feed.Links = service.ObserverFeedLinks{
+		Self: base + c.OriginalURL(),
+		Home: base + observerFeedHomePath(slug),
+		NextURL: func(cursor string) string {
+			query := url.Values{}
+			query.Set("before", cursor)
+			if limit > 0 {
+				query.Set("limit", strconv.Itoa(limit))
+			}
+			return base + c.Path() + "?" + query.Encode()
+		},
+	}
+
+	etag := service.ObserverFeedETag(feed, format)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, feed.Updated.UTC().Format(http.TimeFormat))
+	c.Set(fiber.HeaderCacheControl, "public, max-age=60")
+	if observerFeedNotModified(c.Get(fiber.HeaderIfNoneMatch), c.Get(fiber.HeaderIfModifiedSince), etag, feed.Updated) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	var body []byte
+	switch format {
+	case observerFeedFormatJSON:
+		body, err = service.RenderObserverFeedJSON(feed)
+		c.Set(fiber.HeaderContentType, "application/feed+json; charset=utf-8")
+	default:
+		body, err = service.RenderObserverFeedAtom(feed)
+		c.Set(fiber.HeaderContentType, "application/atom+xml; charset=utf-8")
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "生成订阅源失败"})
+	}
+	return c.Status(http.StatusOK).Send(body)
+}
+
+func observerFeedHomePath(slug string) string {
+	webURL := ""
+	if appConfig != nil {
+		webURL = appConfig.WebUrl
+	}
+	return strings.TrimRight(joinWebPath(webURL), "/") + "/#/ob/" + url.PathEscape(slug)
+}
+
+// observerFeedNotModified 按 RFC 9110 处理条件请求：存在 If-None-Match 时忽略 If-Modified-Since
+func observerFeedNotModified(ifNoneMatch, ifModifiedSince, etag string, updated time.Time) bool {
+	if ifNoneMatch = strings.TrimSpace(ifNoneMatch); ifNoneMatch != "" {
+		if ifNoneMatch == "*" {
+			return true
+		}
+		target := strings.TrimPrefix(etag, "W/")
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == target {
+				return true
+			}
+		}
+		return false
+	}
+	if ifModifiedSince = strings.TrimSpace(ifModifiedSince); ifModifiedSince != "" {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		return !updated.Truncate(time.Second).After(since)
+	}
+	return false
+}
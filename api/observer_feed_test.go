@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestObserverFeedNotModified(t *testing.T) {
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	etag := `W/"abc"`
+
+	if !observerFeedNotModified(`"abc"`, "", etag, updated) {
+		t.Fatalf("expected weak etag comparison to match")
+	}
+	if !observerFeedNotModified(`W/"x", W/"abc"`, "", etag, updated) {
+		t.Fatalf("expected etag list to match")
+	}
+	// If-None-Match 不匹配时不再参考 If-Modified-Since
+	if observerFeedNotModified(`W/"other"`, updated.Format(http.TimeFormat), etag, updated) {
+		t.Fatalf("expected mismatched etag to return full response")
+	}
+	if !observerFeedNotModified("", updated.Format(http.TimeFormat), etag, updated) {
+		t.Fatalf("expected same second to be not modified")
+	}
+	if observerFeedNotModified("", updated.Add(-time.Minute).Format(http.TimeFormat), etag, updated) {
+		t.Fatalf("expected newer content to be modified")
+	}
+	if observerFeedNotModified("", "", etag, updated) {
+		t.Fatalf("expected unconditional request to be modified")
+	}
+}
@@ -191,6 +191,20 @@ func dispatchOneBotAction(session *oneBotSession, req *oneBotActionRequest) *one
 		data, err = oneBotActionGetGroupMemberInfo(session, req.Params)
 	case "get_group_member_list":
 		data, err = oneBotActionGetGroupMemberList(session, req.Params)
+	case "set_group_card":
+		data, err = oneBotActionSetGroupCard(session, req.Params)
+	case "set_group_ban":
+		data, err = oneBotActionSetGroupBan(session, req.Params)
+	case "get_group_msg_history":
+		data, err = oneBotActionGetGroupMsgHistory(session, req.Params)
+	case "send_group_forward_msg":
+		data, err = oneBotActionSendGroupForwardMessage(session, req.Params)
+	case "upload_group_file":
+		data, err = oneBotActionUploadGroupFile(session, req.Params)
+	case "set_friend_add_request":
+		data, err = oneBotActionSetFriendAddRequest(session, req.Params)
+	case "set_group_add_request":
+		data, err = oneBotActionSetGroupAddRequest(session, req.Params)
 	case "can_send_image":
 		data, err = map[string]any{"yes": true}, nil
 	case "get_status":
@@ -224,6 +238,13 @@ func isOneBotSupportedAction(action string) bool {
 		"get_group_list",
 		"get_group_member_info",
 		"get_group_member_list",
+		"set_group_card",
+		"set_group_ban",
+		"get_group_msg_history",
+		"send_group_forward_msg",
+		"upload_group_file",
+		"set_friend_add_request",
+		"set_group_add_request",
 		"can_send_image",
 		"get_status",
 		"get_version_info":
@@ -447,6 +468,10 @@ func oneBotActionSendIntoChannel(session *oneBotSession, channel *model.ChannelM
 	if err != nil {
 		return nil, oneBotBadRequest(err.Error())
 	}
+	return oneBotSendContentIntoChannel(session, channel, decoded)
+}
+
+func oneBotSendContentIntoChannel(session *oneBotSession, channel *model.ChannelModel, decoded *service.OneBotDecodedMessage) (any, error) {
 	if shouldSuppressBotNicknameSyncAck(session, channel.ID, decoded.Content) {
 		messageID, err := service.GetOrCreateOneBotID(service.OneBotEntityMessage, "suppressed-bot-nickname-sync:"+utils.NewID())
 		if err != nil {
@@ -881,10 +906,13 @@ func buildOneBotMessageResponseFromModel(channel *model.ChannelModel, msg *model
 }
 
 func projectProtocolEventToOneBot(session *oneBotSession, event *protocol.Event) (map[string]any, bool) {
-	if session == nil || event == nil || event.Message == nil || event.Channel == nil {
+	if session == nil || event == nil {
 		return nil, false
 	}
 	if event.Type != protocol.EventMessageCreated {
+		return projectOneBotNoticeEvent(session, event)
+	}
+	if event.Message == nil || event.Channel == nil {
 		return nil, false
 	}
 	isPrivate := event.Channel.Type == protocol.DirectChannelType
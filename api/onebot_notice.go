@@ -0,0 +1,726 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
+)
+
+const (
+	oneBotGroupMsgHistoryDefaultCount = 20
+	oneBotGroupMsgHistoryMaxCount     = 100
+	oneBotForwardMaxNodes             = 100
+	oneBotGroupCardMaxRunes           = 32
+)
+
+// projectOneBotNoticeEvent 将成员变动、撤回、好友与申请事件转换为 OneBot v11 notice/request 上报
+func projectOneBotNoticeEvent(session *oneBotSession, event *protocol.Event) (map[string]any, bool) {
+	switch event.Type {
+	case protocol.EventMessageDeleted, protocol.EventMessageRemoved:
+		return projectOneBotRecallNotice(session, event)
+	case protocol.EventGuildMemberAdded, protocol.EventGuildMemberRemoved:
+		return projectOneBotGroupMemberNotice(session, event)
+	case protocol.EventChannelMemberUpdated:
+		return projectOneBotGroupCardNotice(session, event)
+	case protocol.EventFriendAdded:
+		if event.User == nil || event.User.ID == "" {
+			return nil, false
+		}
+		userID, err := service.GetOrCreateOneBotID(service.OneBotEntityUser, event.User.ID)
+		if err != nil {
+			return nil, false
+		}
+		payload := buildOneBotPostBase(session, event, "notice")
+		payload["notice_type"] = "friend_add"
+		payload["user_id"] = userID
+		return payload, true
+	case protocol.EventFriendRequest, protocol.EventGuildMemberRequest:
+		return projectOneBotRequestEvent(session, event)
+	}
+	return nil, false
+}
+
+func buildOneBotPostBase(session *oneBotSession, event *protocol.Event, postType string) map[string]any {
+	timestamp := event.Timestamp
+	if timestamp <= 0 {
+		timestamp = time.Now().Unix()
+	}
+	return map[string]any{
+		"time":      timestamp,
+		"self_id":   session.SelfID,
+		"post_type": postType,
+	}
+}
+
+func oneBotSessionBotUserID(session *oneBotSession) string {
+	if session.BotUser != nil && session.BotUser.ID != "" {
+		return session.BotUser.ID
+	}
+	if session.SelfID <= 0 {
+		return ""
+	}
+	botUserID, _ := service.ResolveInternalID(service.OneBotEntityBotUser, session.SelfID)
+	return botUserID
+}
+
+func projectOneBotRecallNotice(session *oneBotSession, event *protocol.Event) (map[string]any, bool) {
+	if event.Message == nil || event.Channel == nil || event.User == nil {
+		return nil, false
+	}
+	isPrivate := event.Channel.Type == protocol.DirectChannelType
+	if event.Message.IsWhisper && !isPrivate {
+		return nil, false
+	}
+	authorID := event.User.ID
+	if event.Message.User != nil && event.Message.User.ID != "" {
+		authorID = event.Message.User.ID
+	}
+	messageID, err := service.GetOrCreateOneBotID(service.OneBotEntityMessage, event.Message.ID)
+	if err != nil {
+		return nil, false
+	}
+	userID, err := service.GetOrCreateOneBotID(service.OneBotEntityUser, authorID)
+	if err != nil {
+		return nil, false
+	}
+	payload := buildOneBotPostBase(session, event, "notice")
+	payload["user_id"] = userID
+	payload["message_id"] = messageID
+	if isPrivate {
+		payload["notice_type"] = "friend_recall"
+		return payload, true
+	}
+	groupID, err := service.GetOrCreateOneBotID(service.OneBotEntityChannel, event.Channel.ID)
+	if err != nil {
+		return nil, false
+	}
+	operatorID, err := service.GetOrCreateOneBotID(service.OneBotEntityUser, event.User.ID)
+	if err != nil {
+		return nil, false
+	}
+	payload["notice_type"] = "group_recall"
+	payload["group_id"] = groupID
+	payload["operator_id"] = operatorID
+	return payload, true
+}
+
+func projectOneBotGroupMemberNotice(session *oneBotSession, event *protocol.Event) (map[string]any, bool) {
+	if event.Channel == nil || event.User == nil || event.User.ID == "" {
+		return nil, false
+	}
+	groupID, err := service.GetOrCreateOneBotID(service.OneBotEntityChannel, event.Channel.ID)
+	if err != nil {
+		return nil, false
+	}
+	userID, err := service.GetOrCreateOneBotID(service.OneBotEntityUser, event.User.ID)
+	if err != nil {
+		return nil, false
+	}
+	operatorSource := event.User.ID
+	if event.Operator != nil && event.Operator.ID != "" {
+		operatorSource = event.Operator.ID
+	}
+	operatorID, err := service.GetOrCreateOneBotID(service.OneBotEntityUser, operatorSource)
+	if err != nil {
+		return nil, false
+	}
+	payload := buildOneBotPostBase(session, event, "notice")
+	payload["group_id"] = groupID
+	payload["user_id"] = userID
+	payload["operator_id"] = operatorID
+	if event.Type == protocol.EventGuildMemberAdded {
+		payload["notice_type"] = "group_increase"
+		payload["sub_type"] = "approve"
+		if operatorSource != event.User.ID {
+			payload["sub_type"] = "invite"
+		}
+		return payload, true
+	}
+	payload["notice_type"] = "group_decrease"
+	switch {
+	case event.User.ID == oneBotSessionBotUserID(session):
+		payload["sub_type"] = "kick_me"
+	case operatorSource == event.User.ID:
+		payload["sub_type"] = "leave"
+	default:
+		payload["sub_type"] = "kick"
+	}
+	return payload, true
+}
+
+func projectOneBotGroupCardNotice(session *oneBotSession, event *protocol.Event) (map[string]any, bool) {
+	if event.Channel == nil || event.Member == nil || event.Member.User == nil || event.Member.User.ID == "" {
+		return nil, false
+	}
+	if event.Channel.Type == protocol.DirectChannelType {
+		return nil, false
+	}
+	groupID, err := service.GetOrCreateOneBotID(service.OneBotEntityChannel, event.Channel.ID)
+	if err != nil {
+		return nil, false
+	}
+	userID, err := service.GetOrCreateOneBotID(service.OneBotEntityUser, event.Member.User.ID)
+	if err != nil {
+		return nil, false
+	}
+	payload := buildOneBotPostBase(session, event, "notice")
+	payload["notice_type"] = "group_card"
+	payload["group_id"] = groupID
+	payload["user_id"] = userID
+	payload["card_new"] = event.Member.Nick
+	payload["card_old"] = oneBotEventStringOption(event, "oldNick")
+	return payload, true
+}
+
+func projectOneBotRequestEvent(session *oneBotSession, event *protocol.Event) (map[string]any, bool) {
+	if event.User == nil || event.User.ID == "" {
+		return nil, false
+	}
+	flag := oneBotEventStringOption(event, "requestId")
+	if flag == "" {
+		return nil, false
+	}
+	userID, err := service.GetOrCreateOneBotID(service.OneBotEntityUser, event.User.ID)
+	if err != nil {
+		return nil, false
+	}
+	payload := buildOneBotPostBase(session, event, "request")
+	payload["user_id"] = userID
+	payload["comment"] = oneBotEventStringOption(event, "comment")
+	payload["flag"] = flag
+	if event.Type == protocol.EventFriendRequest {
+		payload["request_type"] = "friend"
+		return payload, true
+	}
+	if event.Channel == nil {
+		return nil, false
+	}
+	groupID, err := service.GetOrCreateOneBotID(service.OneBotEntityChannel, event.Channel.ID)
+	if err != nil {
+		return nil, false
+	}
+	payload["request_type"] = "group"
+	payload["sub_type"] = "add"
+	payload["group_id"] = groupID
+	return payload, true
+}
+
+func oneBotEventStringOption(event *protocol.Event, key string) string {
+	if event.Argv == nil || event.Argv.Options == nil {
+		return ""
+	}
+	value, _ := event.Argv.Options[key].(string)
+	return value
+}
+
+// publishOneBotChannelEvent 仅向频道绑定的 OneBot/Satori 连接推送事件，不写入原生机器人连接
+func publishOneBotChannelEvent(channelID string, event *protocol.Event) {
+	botIDs, err := service.EventBotIDsByChannelId(channelID)
+	if err != nil {
+		return
+	}
+	event.Timestamp = time.Now().Unix()
+	for _, botID := range botIDs {
+		getOneBotRuntime().publishProtocolEvent(botID, event, "")
+		getSatoriRuntime().publishProtocolEvent(botID, event)
+	}
+}
+
+// publishOneBotUserEvent 向指定机器人推送与频道无关的事件，例如好友相关通知
+func publishOneBotUserEvent(botUserID string, event *protocol.Event) {
+	user := model.UserGet(botUserID)
+	if user == nil || user.ID == "" || !user.IsBot {
+		return
+	}
+	event.Timestamp = time.Now().Unix()
+	getOneBotRuntime().publishProtocolEvent(botUserID, event, "")
+	getSatoriRuntime().publishProtocolEvent(botUserID, event)
+}
+
+// notifyOneBotWorldMemberChange 将世界成员加入或离开映射为世界内各群的 group_increase/group_decrease
+func notifyOneBotWorldMemberChange(worldID, userID, operatorID string, joined bool) {
+	user := model.UserGet(userID)
+	if user == nil || user.ID == "" {
+		return
+	}
+	var channels []*model.ChannelModel
+	if err := model.GetDB().
+		Where("world_id = ? AND is_private = ? AND status = ?", worldID, false, model.ChannelStatusActive).
+		Find(&channels).Error; err != nil {
+		log.Printf("[onebot] 查询世界频道失败 world=%s err=%v", worldID, err)
+		return
+	}
+	eventType := protocol.EventGuildMemberRemoved
+	if joined {
+		eventType = protocol.EventGuildMemberAdded
+	}
+	var operator *protocol.User
+	if operatorID != "" && operatorID != userID {
+		if item := model.UserGet(operatorID); item != nil && item.ID != "" {
+			operator = item.ToProtocolType()
+		}
+	}
+	for _, channel := range channels {
+		if strings.EqualFold(channel.PermType, "private") {
+			continue
+		}
+		publishOneBotChannelEvent(channel.ID, &protocol.Event{
+			Type:     eventType,
+			Channel:  channel.ToProtocolType(),
+			User:     user.ToProtocolType(),
+			Operator: operator,
+		})
+	}
+}
+
+// notifyOneBotMemberCardChanged 频道成员昵称变更后推送 group_card
+func notifyOneBotMemberCardChanged(channelID string, member *model.MemberModel, oldNick string) {
+	if member == nil || member.Nickname == oldNick {
+		return
+	}
+	channel, err := model.ChannelGet(channelID)
+	if err != nil || channel == nil || channel.ID == "" || channel.IsPrivate {
+		return
+	}
+	publishOneBotChannelEvent(channelID, &protocol.Event{
+		Type:    protocol.EventChannelMemberUpdated,
+		Channel: channel.ToProtocolType(),
+		Member:  member.ToProtocolType(),
+		Argv:    &protocol.Argv{Options: map[string]interface{}{"oldNick": oldNick}},
+	})
+}
+
+// notifyOneBotFriendRequest 好友申请发往机器人时推送 request 事件
+func notifyOneBotFriendRequest(invite *model.FriendRequestModel) {
+	sender := model.UserGet(invite.SenderID)
+	if sender == nil || sender.ID == "" {
+		return
+	}
+	publishOneBotUserEvent(invite.ReceiverID, &protocol.Event{
+		Type: protocol.EventFriendRequest,
+		User: sender.ToProtocolType(),
+		Argv: &protocol.Argv{Options: map[string]interface{}{"requestId": invite.ID, "comment": invite.Note}},
+	})
+}
+
+// notifyOneBotFriendAdded 双方成为好友后，分别向其中的机器人推送 friend_add
+func notifyOneBotFriendAdded(userID1, userID2 string) {
+	pairs := [][2]string{{userID1, userID2}, {userID2, userID1}}
+	for _, pair := range pairs {
+		friend := model.UserGet(pair[1])
+		if friend == nil || friend.ID == "" {
+			continue
+		}
+		publishOneBotUserEvent(pair[0], &protocol.Event{
+			Type: protocol.EventFriendAdded,
+			User: friend.ToProtocolType(),
+		})
+	}
+}
+
+// notifyOneBotWorldJoinRequest 向具备审核权限的机器人推送加群申请，群取世界默认频道或机器人绑定的首个频道
+func notifyOneBotWorldJoinRequest(world *model.WorldModel, applicant *model.UserModel, request *model.WorldJoinRequestModel) {
+	for _, reviewerID := range service.WorldJoinReviewerIDs(world.ID) {
+		reviewer := model.UserGet(reviewerID)
+		if reviewer == nil || !reviewer.IsBot {
+			continue
+		}
+		channel := resolveOneBotWorldGroupChannel(reviewerID, world)
+		if channel == nil {
+			continue
+		}
+		publishOneBotUserEvent(reviewerID, &protocol.Event{
+			Type:    protocol.EventGuildMemberRequest,
+			Channel: channel.ToProtocolType(),
+			User:    applicant.ToProtocolType(),
+			Argv:    &protocol.Argv{Options: map[string]interface{}{"requestId": request.ID, "comment": request.Message}},
+		})
+	}
+}
+
+func resolveOneBotWorldGroupChannel(botUserID string, world *model.WorldModel) *model.ChannelModel {
+	if world.DefaultChannelID != "" {
+		if channel, err := ensureOneBotGroupChannel(botUserID, world.DefaultChannelID); err == nil {
+			return channel
+		}
+	}
+	channels, err := listOneBotGroupChannels(botUserID)
+	if err != nil {
+		return nil
+	}
+	for _, channel := range channels {
+		if channel.WorldID == world.ID {
+			return channel
+		}
+	}
+	return nil
+}
+
+func resolveOneBotGroupParam(session *oneBotSession, groupID int64) (*model.ChannelModel, error) {
+	if groupID <= 0 {
+		return nil, oneBotBadRequest("group_id missing")
+	}
+	channelID, err := service.ResolveInternalID(service.OneBotEntityChannel, groupID)
+	if err != nil {
+		return nil, oneBotNotFound("group not found")
+	}
+	return ensureOneBotGroupChannel(session.BotUser.ID, channelID)
+}
+
+func oneBotActionSetGroupCard(session *oneBotSession, raw json.RawMessage) (any, error) {
+	var params struct {
+		GroupID oneBotInt64Param `json:"group_id"`
+		UserID  oneBotInt64Param `json:"user_id"`
+		Card    string           `json:"card"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, oneBotBadRequest("invalid params")
+	}
+	channel, err := resolveOneBotGroupParam(session, params.GroupID.Int64())
+	if err != nil {
+		return nil, err
+	}
+	targetUserID, err := service.ResolveInternalID(service.OneBotEntityUser, params.UserID.Int64())
+	if err != nil {
+		return nil, oneBotNotFound("user not found")
+	}
+	botUserID := session.BotUser.ID
+	if targetUserID != botUserID {
+		if getChannelMemberRoleRank(channel, channel.ID, botUserID) < channelMemberRoleRankAdmin ||
+			!canModerateTargetByRank(channel, channel.ID, botUserID, targetUserID) {
+			return nil, oneBotForbidden("permission denied")
+		}
+	}
+	card := strings.TrimSpace(params.Card)
+	if utf8.RuneCountInString(card) > oneBotGroupCardMaxRunes {
+		return nil, oneBotBadRequest("card too long")
+	}
+	if card != "" {
+		if card, err = service.ContentFilterApplyText(channel.WorldID, model.ContentFilterScopeNickname, card); err != nil {
+			return nil, oneBotBadRequest(err.Error())
+		}
+	}
+	member, _ := model.MemberGetByUserIDAndChannelIDBase(targetUserID, channel.ID, "", false)
+	if member == nil || member.ID == "" {
+		return nil, oneBotNotFound("member not found")
+	}
+	if card == "" {
+		if user := model.UserGet(targetUserID); user != nil {
+			card = user.Nickname
+		}
+	}
+	oldNick := member.Nickname
+	member.Nickname = card
+	member.SaveInfo()
+	oneBotChatContext(session).BroadcastEventInChannel(channel.ID, &protocol.Event{
+		Type:   protocol.EventChannelMemberUpdated,
+		Member: member.ToProtocolType(),
+	})
+	go notifyOneBotMemberCardChanged(channel.ID, member, oldNick)
+	return nil, nil
+}
+
+func oneBotActionSetGroupBan(session *oneBotSession, raw json.RawMessage) (any, error) {
+	var params struct {
+		GroupID  oneBotInt64Param  `json:"group_id"`
+		UserID   oneBotInt64Param  `json:"user_id"`
+		Duration *oneBotInt64Param `json:"duration"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, oneBotBadRequest("invalid params")
+	}
+	channel, err := resolveOneBotGroupParam(session, params.GroupID.Int64())
+	if err != nil {
+		return nil, err
+	}
+	if channel.WorldID == "" {
+		return nil, oneBotBadRequest("group has no world")
+	}
+	targetUserID, err := service.ResolveInternalID(service.OneBotEntityUser, params.UserID.Int64())
+	if err != nil {
+		return nil, oneBotNotFound("user not found")
+	}
+	// OneBot 默认禁言 30 分钟，0 表示解除
+	duration := 30 * time.Minute
+	if params.Duration != nil {
+		duration = time.Duration(params.Duration.Int64()) * time.Second
+	}
+	if _, err := service.WorldMuteMember(channel.WorldID, session.BotUser.ID, targetUserID, duration); err != nil {
+		if errors.Is(err, service.ErrWorldPermission) || errors.Is(err, service.ErrWorldOwnerImmutable) {
+			return nil, oneBotForbidden(err.Error())
+		}
+		return nil, oneBotBadRequest(err.Error())
+	}
+	return nil, nil
+}
+
+func oneBotActionGetGroupMsgHistory(session *oneBotSession, raw json.RawMessage) (any, error) {
+	var params struct {
+		GroupID    oneBotInt64Param `json:"group_id"`
+		MessageSeq oneBotInt64Param `json:"message_seq"`
+		MessageID  oneBotInt64Param `json:"message_id"`
+		Count      int              `json:"count"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, oneBotBadRequest("invalid params")
+	}
+	channel, err := resolveOneBotGroupParam(session, params.GroupID.Int64())
+	if err != nil {
+		return nil, err
+	}
+	count := params.Count
+	if count <= 0 {
+		count = oneBotGroupMsgHistoryDefaultCount
+	}
+	if count > oneBotGroupMsgHistoryMaxCount {
+		count = oneBotGroupMsgHistoryMaxCount
+	}
+	query := model.GetDB().
+		Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, username, nickname, avatar, is_bot")
+		}).
+		Preload("Member", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, nickname, user_id, channel_id")
+		}).
+		Where("channel_id = ? AND is_deleted = ? AND is_revoked = ? AND is_whisper = ?", channel.ID, false, false, false)
+	seq := params.MessageSeq.Int64()
+	if seq <= 0 {
+		seq = params.MessageID.Int64()
+	}
+	if seq > 0 {
+		anchor, err := loadOneBotMessageModel(seq)
+		if err != nil {
+			return nil, err
+		}
+		if anchor.ChannelID != channel.ID {
+			return nil, oneBotNotFound("message not found")
+		}
+		query = query.Where("display_order < ?", anchor.DisplayOrder)
+	}
+	var items []*model.MessageModel
+	if err := query.Order("display_order DESC").Limit(count).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	messages := make([]map[string]any, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		item, err := buildOneBotMessageResponseFromModel(channel, items[i])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, item)
+	}
+	return map[string]any{"messages": messages}, nil
+}
+
+type oneBotForwardNode struct {
+	Type string `json:"type"`
+	Data struct {
+		ID       oneBotInt64Param `json:"id"`
+		Name     string           `json:"name"`
+		Nickname string           `json:"nickname"`
+		Content  json.RawMessage  `json:"content"`
+	} `json:"data"`
+}
+
+// oneBotActionSendGroupForwardMessage 没有合并转发消息类型，按“名称：内容”逐行合成为一条消息发送
+func oneBotActionSendGroupForwardMessage(session *oneBotSession, raw json.RawMessage) (any, error) {
+	var params struct {
+		GroupID  oneBotInt64Param    `json:"group_id"`
+		Messages []oneBotForwardNode `json:"messages"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, oneBotBadRequest("invalid params")
+	}
+	channel, err := resolveOneBotGroupParam(session, params.GroupID.Int64())
+	if err != nil {
+		return nil, err
+	}
+	if len(params.Messages) == 0 {
+		return nil, oneBotBadRequest("messages missing")
+	}
+	if len(params.Messages) > oneBotForwardMaxNodes {
+		return nil, oneBotBadRequest("too many messages")
+	}
+	lines := make([]string, 0, len(params.Messages))
+	for _, node := range params.Messages {
+		if node.Type != "" && node.Type != "node" {
+			return nil, oneBotBadRequest("invalid forward node")
+		}
+		name, content, err := resolveOneBotForwardNode(session, &node)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, protocol.EscapeSatoriText(name)+"："+content)
+	}
+	resp, err := oneBotSendContentIntoChannel(session, channel, &service.OneBotDecodedMessage{Content: strings.Join(lines, "\n")})
+	if err != nil {
+		return nil, err
+	}
+	if data, ok := resp.(map[string]any); ok {
+		data["forward_id"] = fmt.Sprint(data["message_id"])
+	}
+	return resp, nil
+}
+
+func resolveOneBotForwardNode(session *oneBotSession, node *oneBotForwardNode) (string, string, error) {
+	if node.Data.ID.Int64() > 0 {
+		msg, err := loadOneBotMessageModel(node.Data.ID.Int64())
+		if err != nil {
+			return "", "", err
+		}
+		if msg.IsWhisper {
+			return "", "", oneBotNotFound("message not found")
+		}
+		if bound, _ := service.IsBotBoundToChannel(session.BotUser.ID, msg.ChannelID); !bound {
+			return "", "", oneBotForbidden("bot not bound to group")
+		}
+		name := msg.SenderMemberName
+		if name == "" && msg.User != nil {
+			name = msg.User.Nickname
+		}
+		return name, msg.Content, nil
+	}
+	name := strings.TrimSpace(node.Data.Name)
+	if name == "" {
+		name = strings.TrimSpace(node.Data.Nickname)
+	}
+	decoded, err := decodeOneBotMessageParam(node.Data.Content, false)
+	if err != nil {
+		return "", "", oneBotBadRequest(err.Error())
+	}
+	return name, decoded.Content, nil
+}
+
+// oneBotActionUploadGroupFile 仅接受 http(s) 与 base64:// 来源，不读取服务端本地路径
+func oneBotActionUploadGroupFile(session *oneBotSession, raw json.RawMessage) (any, error) {
+	var params struct {
+		GroupID oneBotInt64Param `json:"group_id"`
+		File    string           `json:"file"`
+		Name    string           `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, oneBotBadRequest("invalid params")
+	}
+	channel, err := resolveOneBotGroupParam(session, params.GroupID.Int64())
+	if err != nil {
+		return nil, err
+	}
+	input := service.RemoteAttachmentImportInput{
+		URL:       strings.TrimSpace(params.File),
+		Filename:  strings.TrimSpace(params.Name),
+		UserID:    session.BotUser.ID,
+		ChannelID: channel.ID,
+	}
+	if appConfig != nil && appConfig.ImageSizeLimit > 0 {
+		input.MaxSizeBytes = appConfig.ImageSizeLimit * 1024
+	}
+	var item *model.AttachmentModel
+	switch {
+	case strings.HasPrefix(input.URL, "base64://"):
+		data, decodeErr := base64.StdEncoding.DecodeString(strings.TrimPrefix(input.URL, "base64://"))
+		if decodeErr != nil {
+			return nil, oneBotBadRequest("invalid base64 file")
+		}
+		item, err = service.ImportAttachmentFromReader(bytes.NewReader(data), input)
+	case strings.HasPrefix(input.URL, "http://"), strings.HasPrefix(input.URL, "https://"):
+		item, err = service.ImportAttachmentFromURL(input)
+	default:
+		return nil, oneBotBadRequest("unsupported file source")
+	}
+	if err != nil {
+		return nil, oneBotBadRequest(err.Error())
+	}
+	if item == nil || item.ID == "" {
+		return nil, oneBotBadRequest("file upload failed")
+	}
+	content := fmt.Sprintf(`<file src="id:%s" name="%s"/>`, item.ID, html.EscapeString(item.Filename))
+	if strings.HasPrefix(item.MimeType, "image/") {
+		content = fmt.Sprintf(`<img src="id:%s"/>`, item.ID)
+	}
+	resp, err := oneBotSendContentIntoChannel(session, channel, &service.OneBotDecodedMessage{Content: content})
+	if err != nil {
+		return nil, err
+	}
+	if data, ok := resp.(map[string]any); ok {
+		data["file_id"] = item.ID
+	}
+	return resp, nil
+}
+
+func oneBotActionSetFriendAddRequest(session *oneBotSession, raw json.RawMessage) (any, error) {
+	var params struct {
+		Flag    string `json:"flag"`
+		Approve *bool  `json:"approve"`
+		Remark  string `json:"remark"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, oneBotBadRequest("invalid params")
+	}
+	approve := params.Approve == nil || *params.Approve
+	req, _ := model.FriendRequestGetByID(strings.TrimSpace(params.Flag))
+	if req == nil || req.ID == "" || req.ReceiverID != session.BotUser.ID {
+		return nil, oneBotNotFound("request not found")
+	}
+	if req.Status != "pending" {
+		// 外部机器人收到的申请会被自动通过，此时同意视为成功
+		if approve && req.Status == "accept" {
+			return nil, nil
+		}
+		return nil, oneBotBadRequest("request already handled")
+	}
+	ok, err := apiFriendRequestApprove(oneBotChatContext(session), &struct {
+		MessageId string `json:"message_id"`
+		Approve   bool   `json:"approve"`
+		Comment   string `json:"comment"`
+	}{MessageId: req.ID, Approve: approve, Comment: params.Remark})
+	if err != nil {
+		return nil, err
+	}
+	if success, _ := ok.(bool); !success && approve {
+		return nil, oneBotBadRequest("request handle failed")
+	}
+	return nil, nil
+}
+
+func oneBotActionSetGroupAddRequest(session *oneBotSession, raw json.RawMessage) (any, error) {
+	var params struct {
+		Flag    string `json:"flag"`
+		Approve *bool  `json:"approve"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, oneBotBadRequest("invalid params")
+	}
+	request, err := service.GetWorldJoinRequest(params.Flag)
+	if err != nil {
+		return nil, oneBotNotFound("request not found")
+	}
+	decision := service.WorldJoinRequestDecisionApprove
+	if params.Approve != nil && !*params.Approve {
+		decision = service.WorldJoinRequestDecisionReject
+	}
+	result, err := service.WorldJoinRequestReview(request.WorldID, request.ID, session.BotUser.ID, decision, params.Reason)
+	if err != nil {
+		if errors.Is(err, service.ErrWorldPermission) {
+			return nil, oneBotForbidden(err.Error())
+		}
+		return nil, oneBotBadRequest(err.Error())
+	}
+	if world, err := service.GetWorldByID(request.WorldID); err == nil {
+		notifyWorldJoinRequestReviewed(world, result)
+	}
+	return nil, nil
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
+	"sealchat/utils"
+)
+
+func TestProjectOneBotNoticeEvents(t *testing.T) {
+	initOneBotAPITestEnv(t)
+
+	botUser, _ := createOneBotTestBot(t, "noticebot", model.BotKindManual)
+	session := createOneBotTestSession(t, botUser)
+	channel := &protocol.Channel{ID: "group-" + utils.NewIDWithLength(8), Type: protocol.TextChannelType}
+	author := &protocol.User{ID: "author-" + utils.NewIDWithLength(8)}
+	admin := &protocol.User{ID: "admin-" + utils.NewIDWithLength(8)}
+
+	recall, ok := projectProtocolEventToOneBot(session, &protocol.Event{
+		Type:    protocol.EventMessageRemoved,
+		Channel: channel,
+		User:    admin,
+		Message: &protocol.Message{ID: "msg-" + utils.NewIDWithLength(8), User: author},
+	})
+	if !ok || recall["post_type"] != "notice" || recall["notice_type"] != "group_recall" {
+		t.Fatalf("unexpected recall payload: %#v", recall)
+	}
+	authorID, _ := service.GetOrCreateOneBotID(service.OneBotEntityUser, author.ID)
+	adminID, _ := service.GetOrCreateOneBotID(service.OneBotEntityUser, admin.ID)
+	if recall["user_id"] != authorID || recall["operator_id"] != adminID {
+		t.Fatalf("recall should distinguish author and operator: %#v", recall)
+	}
+	if _, ok := projectProtocolEventToOneBot(session, &protocol.Event{
+		Type:    protocol.EventMessageDeleted,
+		Channel: channel,
+		User:    author,
+		Message: &protocol.Message{ID: "msg-" + utils.NewIDWithLength(8), IsWhisper: true},
+	}); ok {
+		t.Fatal("whisper recall should not be projected")
+	}
+
+	cases := []struct {
+		user     *protocol.User
+		operator *protocol.User
+		subType  string
+	}{
+		{user: author, subType: "leave"},
+		{user: author, operator: admin, subType: "kick"},
+		{user: &protocol.User{ID: botUser.ID}, operator: admin, subType: "kick_me"},
+	}
+	for _, item := range cases {
+		payload, ok := projectProtocolEventToOneBot(session, &protocol.Event{
+			Type: protocol.EventGuildMemberRemoved, Channel: channel, User: item.user, Operator: item.operator,
+		})
+		if !ok || payload["notice_type"] != "group_decrease" || payload["sub_type"] != item.subType {
+			t.Fatalf("unexpected group_decrease payload for %s: %#v", item.subType, payload)
+		}
+	}
+
+	card, ok := projectProtocolEventToOneBot(session, &protocol.Event{
+		Type:    protocol.EventChannelMemberUpdated,
+		Channel: channel,
+		Member:  &protocol.GuildMember{User: author, Nick: "新名片"},
+		Argv:    &protocol.Argv{Options: map[string]interface{}{"oldNick": "旧名片"}},
+	})
+	if !ok || card["notice_type"] != "group_card" || card["card_new"] != "新名片" || card["card_old"] != "旧名片" {
+		t.Fatalf("unexpected group_card payload: %#v", card)
+	}
+
+	request, ok := projectProtocolEventToOneBot(session, &protocol.Event{
+		Type:    protocol.EventGuildMemberRequest,
+		Channel: channel,
+		User:    author,
+		Argv:    &protocol.Argv{Options: map[string]interface{}{"requestId": "req-1", "comment": "想加入"}},
+	})
+	if !ok || request["post_type"] != "request" || request["request_type"] != "group" ||
+		request["flag"] != "req-1" || request["comment"] != "想加入" {
+		t.Fatalf("unexpected group request payload: %#v", request)
+	}
+}
+
+func TestOneBotActionGroupCardAndHistory(t *testing.T) {
+	initOneBotAPITestEnv(t)
+
+	botUser, _ := createOneBotTestBot(t, "historybot", model.BotKindManual)
+	world, channel := createOneBotTestWorldAndChannel(t, botUser.ID)
+	session := createOneBotTestSession(t, botUser)
+	groupID, err := service.GetOrCreateOneBotID(service.OneBotEntityChannel, channel.ID)
+	if err != nil {
+		t.Fatalf("create group mapping failed: %v", err)
+	}
+
+	messageIDs := make([]int64, 0, 3)
+	for i := 0; i < 3; i++ {
+		resp := dispatchOneBotAction(session, &oneBotActionRequest{
+			Action: "send_group_msg",
+			Params: json.RawMessage(fmt.Sprintf(`{"group_id":%d,"message":"history %d"}`, groupID, i)),
+		})
+		messageIDs = append(messageIDs, mustOneBotMessageID(t, resp))
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	resp := dispatchOneBotAction(session, &oneBotActionRequest{
+		Action: "get_group_msg_history",
+		Params: json.RawMessage(fmt.Sprintf(`{"group_id":%d,"message_seq":%d}`, groupID, messageIDs[2])),
+	})
+	if resp.Status != "ok" {
+		t.Fatalf("get_group_msg_history response = %#v", resp)
+	}
+	messages, _ := resp.Data.(map[string]any)["messages"].([]map[string]any)
+	if len(messages) != 2 || messages[0]["message_id"] != messageIDs[0] || messages[1]["message_id"] != messageIDs[1] {
+		t.Fatalf("unexpected history: %#v", messages)
+	}
+
+	botNumericID, err := service.GetOrCreateOneBotID(service.OneBotEntityUser, botUser.ID)
+	if err != nil {
+		t.Fatalf("create user mapping failed: %v", err)
+	}
+	resp = dispatchOneBotAction(session, &oneBotActionRequest{
+		Action: "set_group_card",
+		Params: json.RawMessage(fmt.Sprintf(`{"group_id":%d,"user_id":%d,"card":"骰娘"}`, groupID, botNumericID)),
+	})
+	if resp.Status != "ok" {
+		t.Fatalf("set_group_card response = %#v", resp)
+	}
+	member, _ := model.MemberGetByUserIDAndChannelIDBase(botUser.ID, channel.ID, "", false)
+	if member == nil || member.Nickname != "骰娘" {
+		t.Fatalf("member card not updated: %#v", member)
+	}
+
+	ownerNumericID, err := service.GetOrCreateOneBotID(service.OneBotEntityUser, world.OwnerID)
+	if err != nil {
+		t.Fatalf("create owner mapping failed: %v", err)
+	}
+	resp = dispatchOneBotAction(session, &oneBotActionRequest{
+		Action: "set_group_card",
+		Params: json.RawMessage(fmt.Sprintf(`{"group_id":%d,"user_id":%d,"card":"x"}`, groupID, ownerNumericID)),
+	})
+	if resp.Status == "ok" {
+		t.Fatal("bot without admin role should not change others' card")
+	}
+	resp = dispatchOneBotAction(session, &oneBotActionRequest{
+		Action: "set_group_ban",
+		Params: json.RawMessage(fmt.Sprintf(`{"group_id":%d,"user_id":%d,"duration":60}`, groupID, ownerNumericID)),
+	})
+	if resp.Status == "ok" {
+		t.Fatal("bot without world admin should not ban members")
+	}
+}
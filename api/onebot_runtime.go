@@ -447,6 +447,9 @@ func (rt *oneBotRuntime) applyHTTPPostQuickOperation(botUserID string, event *pr
 	if rt == nil || strings.TrimSpace(botUserID) == "" || event == nil || event.Channel == nil {
 		return nil
 	}
+	if event.Type != protocol.EventMessageCreated {
+		return nil // 通知与请求事件的快速操作暂不支持
+	}
 
 	op, err := parseOneBotHTTPQuickOperation(body)
 	if err != nil {
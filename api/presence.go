@@ -60,6 +60,7 @@ func buildChannelPresenceSnapshot(channelID string, channelUsersMap *utils.SyncM
 			Latency:  latency,
 			Focused:  active.Focused,
 			LastSeen: active.LastPingTime,
+			Voice:    voiceStateForUser(channelID, userID),
 		})
 		return true
 	})
@@ -0,0 +1,515 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/pm"
+	"sealchat/service"
+)
+
+const qqBridgeOutboundBatch = 50
+
+var qqBridgeCapabilities = []string{"read_changes", "write_create", "write_delete_own", "identity_upsert"}
+
+var qqBridgeRemoteImageRegexp = regexp.MustCompile(`<img src="(https?://[^"]+)"`)
+
+var errQQBridgeUnmapped = errors.New("无对应 QQ 账号")
+
+// qqBridgeLink 一次同步所需的桥接上下文，消息写入沿用频道 webhook 授权
+type qqBridgeLink struct {
+	bridge      *model.QQBridgeModel
+	integration *model.ChannelWebhookIntegrationModel
+	botUser     *model.UserModel
+	channel     *model.ChannelModel
+	client      qqBridgeClient
+}
+
+// loadQQBridgeLink 桥接被禁用、授权被撤销或频道不存在时返回 nil
+func loadQQBridgeLink(bridgeID string, client qqBridgeClient) (*qqBridgeLink, error) {
+	bridge, err := model.QQBridgeGet(bridgeID)
+	if err != nil || bridge == nil || !bridge.Enabled {
+		return nil, err
+	}
+	integration, err := model.ChannelWebhookIntegrationGetByID(bridge.ChannelID, bridge.IntegrationID)
+	if err != nil || integration == nil || integration.Status != model.WebhookIntegrationStatusActive {
+		return nil, err
+	}
+	botUser := model.UserGet(integration.BotUserID)
+	if botUser == nil || botUser.ID == "" {
+		return nil, nil
+	}
+	channel, err := model.ChannelGet(bridge.ChannelID)
+	if err != nil || channel == nil || channel.ID == "" {
+		return nil, err
+	}
+	return &qqBridgeLink{bridge: bridge, integration: integration, botUser: botUser, channel: channel, client: client}, nil
+}
+
+type qqBridgeEvent struct {
+	PostType    string           `json:"post_type"`
+	MessageType string           `json:"message_type"`
+	NoticeType  string           `json:"notice_type"`
+	SelfID      oneBotInt64Param `json:"self_id"`
+	GroupID     oneBotInt64Param `json:"group_id"`
+	UserID      oneBotInt64Param `json:"user_id"`
+	MessageID   oneBotInt64Param `json:"message_id"`
+	Message     any              `json:"message"`
+	Sender      struct {
+		Nickname string `json:"nickname"`
+		Card     string `json:"card"`
+	} `json:"sender"`
+}
+
+func (l *qqBridgeLink) handleEvent(body []byte) error {
+	var ev qqBridgeEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return err
+	}
+	if ev.GroupID.Int64() != l.bridge.GroupID {
+		return nil
+	}
+	switch {
+	case ev.PostType == "message" && ev.MessageType == "group":
+		// 自身发出的消息即出站转发结果，忽略以免回环
+		if ev.UserID.Int64() == ev.SelfID.Int64() {
+			return nil
+		}
+		return l.relayInboundMessage(&ev)
+	case ev.PostType == "notice" && ev.NoticeType == "group_recall":
+		return l.relayInboundRecall(&ev)
+	}
+	return nil
+}
+
+func (l *qqBridgeLink) relayInboundMessage(ev *qqBridgeEvent) error {
+	externalID := qqBridgeFormatID(ev.MessageID.Int64())
+	if existing, err := model.MessageExternalRefGet(l.channel.ID, model.QQBridgeSourceInbound, externalID); err != nil || existing != nil {
+		return err
+	}
+	decoded, err := l.decodeInbound(ev.Message)
+	if err != nil {
+		return err
+	}
+	content := l.localizeInboundImages(decoded.Content)
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+
+	actorID := qqBridgeFormatID(ev.UserID.Int64())
+	displayName := strings.TrimSpace(ev.Sender.Card)
+	if displayName == "" {
+		displayName = strings.TrimSpace(ev.Sender.Nickname)
+	}
+	if displayName == "" {
+		displayName = actorID
+	}
+	_, err = webhookCreateMessage(l.integration, l.botUser, l.channel, &webhookWriteRequest{
+		Op:          "message.create",
+		ExternalRef: &webhookExternalRef{Source: model.QQBridgeSourceInbound, ExternalID: externalID},
+		Identity:    &webhookIdentityPayload{ExternalActorID: actorID, DisplayName: displayName},
+		Message:     &webhookMessagePayload{Content: content, QuoteMessageID: decoded.QuoteID},
+	})
+	return err
+}
+
+// decodeInbound 引用的 QQ 消息不在桥接记录中时，去掉引用后重新解码
+func (l *qqBridgeLink) decodeInbound(message any) (*service.OneBotDecodedMessage, error) {
+	message = qqBridgeFlattenAtSegments(message)
+	decoded, err := service.DecodeOneBotMessageValue(message, false, service.OneBotMessageCodecHooks{
+		ResolveMessageID: l.resolveQuotedMessageID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if decoded == nil {
+		decoded, err = service.DecodeOneBotMessageValue(message, false, service.OneBotMessageCodecHooks{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return decoded, nil
+}
+
+func (l *qqBridgeLink) resolveQuotedMessageID(numericID int64) (string, error) {
+	externalID := qqBridgeFormatID(numericID)
+	for _, source := range []string{model.QQBridgeSourceInbound, model.QQBridgeSourceOutbound} {
+		ref, err := model.MessageExternalRefGet(l.channel.ID, source, externalID)
+		if err != nil {
+			return "", err
+		}
+		if ref != nil {
+			return ref.MessageID, nil
+		}
+	}
+	return "", nil
+}
+
+// qqBridgeFlattenAtSegments QQ 用户在 SealChat 中没有账号，@ 段转为纯文本
+func qqBridgeFlattenAtSegments(message any) any {
+	segments, ok := message.([]any)
+	if !ok {
+		return message
+	}
+	out := make([]any, 0, len(segments))
+	for _, item := range segments {
+		segment, ok := item.(map[string]any)
+		if !ok || segment["type"] != "at" {
+			out = append(out, item)
+			continue
+		}
+		data, _ := segment["data"].(map[string]any)
+		name, _ := data["name"].(string)
+		if name = strings.TrimSpace(name); name == "" {
+			name = strings.TrimSpace(fmt.Sprint(data["qq"]))
+		}
+		if name == "all" {
+			name = "全体成员"
+		}
+		out = append(out, map[string]any{"type": "text", "data": map[string]any{"text": "@" + name + " "}})
+	}
+	return out
+}
+
+// localizeInboundImages 将 QQ 图片转存为附件，失败时保留原链接
+func (l *qqBridgeLink) localizeInboundImages(content string) string {
+	return qqBridgeRemoteImageRegexp.ReplaceAllStringFunc(content, func(match string) string {
+		url := html.UnescapeString(qqBridgeRemoteImageRegexp.FindStringSubmatch(match)[1])
+		input := service.RemoteAttachmentImportInput{URL: url, UserID: l.botUser.ID, ChannelID: l.channel.ID}
+		if appConfig != nil && appConfig.ImageSizeLimit > 0 {
+			input.MaxSizeBytes = appConfig.ImageSizeLimit * 1024
+		}
+		item, err := service.ImportAttachmentFromURL(input)
+		if err != nil || item == nil || item.ID == "" {
+			return match
+		}
+		return fmt.Sprintf(`<img src="id:%s"`, item.ID)
+	})
+}
+
+func (l *qqBridgeLink) relayInboundRecall(ev *qqBridgeEvent) error {
+	externalID := qqBridgeFormatID(ev.MessageID.Int64())
+	ref, err := model.MessageExternalRefGet(l.channel.ID, model.QQBridgeSourceInbound, externalID)
+	if err != nil || ref == nil {
+		return err
+	}
+	_, err = webhookDeleteMessage(l.integration, l.botUser, l.channel, &webhookWriteRequest{
+		Op:          "message.delete",
+		ExternalRef: &webhookExternalRef{Source: model.QQBridgeSourceInbound, ExternalID: externalID},
+	})
+	return err
+}
+
+// syncOutbound 按变更流转发频道内新消息与撤回，QQ 来源的事件通过 excludeSource 排除
+func (l *qqBridgeLink) syncOutbound() error {
+	logs, err := model.WebhookEventLogListAfter(l.channel.ID, l.bridge.LastEventSeq, qqBridgeOutboundBatch, model.QQBridgeSourceInbound)
+	if err != nil {
+		return err
+	}
+	for _, item := range logs {
+		var err error
+		switch item.Type {
+		case "message-created":
+			err = l.relayOutboundMessage(item.MessageID)
+		case "message-removed", "message-deleted":
+			err = l.relayOutboundRecall(item.MessageID)
+		}
+		if errors.Is(err, errQQBridgeNotConnected) || errors.Is(err, errQQBridgeTimeout) {
+			// 连接类错误保留游标，下次重试
+			return err
+		}
+		if err != nil {
+			log.Printf("[qq-bridge] 转发失败 bridge=%s seq=%d err=%v", l.bridge.ID, item.Seq, err)
+			_ = model.QQBridgeRecordError(l.bridge.ID, err.Error())
+		}
+		if err := model.QQBridgeUpdateCursor(l.bridge.ID, item.Seq); err != nil {
+			return err
+		}
+		l.bridge.LastEventSeq = item.Seq
+	}
+	return nil
+}
+
+func (l *qqBridgeLink) relayOutboundMessage(messageID string) error {
+	if existing, err := model.MessageExternalRefGetByMessageID(messageID, model.QQBridgeSourceOutbound); err != nil || existing != nil {
+		return err
+	}
+	var msg model.MessageModel
+	if err := model.GetDB().Where("id = ? AND channel_id = ?", messageID, l.channel.ID).Limit(1).Find(&msg).Error; err != nil {
+		return err
+	}
+	if msg.ID == "" || msg.IsWhisper || msg.IsDeleted || msg.IsRevoked || msg.UserID == l.botUser.ID {
+		return nil
+	}
+
+	hooks := service.OneBotMessageCodecHooks{
+		ResolveUserOneBotID: func(string) (int64, error) {
+			return 0, errQQBridgeUnmapped
+		},
+		ResolveMessageOneBotID: l.resolveQQMessageID,
+		ResolveAttachmentURL:   resolveOneBotAttachmentURL,
+	}
+	quoteID := ""
+	if msg.QuoteID != "" {
+		if _, err := l.resolveQQMessageID(msg.QuoteID); err == nil {
+			quoteID = msg.QuoteID
+		}
+	}
+	content, err := service.EncodeOneBotMessage(msg.Content, quoteID, hooks)
+	if err != nil {
+		return err
+	}
+	senderName := strings.TrimSpace(msg.SenderMemberName)
+	if senderName == "" {
+		if user := model.UserGet(msg.UserID); user != nil {
+			senderName = user.Nickname
+		}
+	}
+	// 引用段必须位于消息开头
+	prefix := ""
+	if strings.HasPrefix(content, "[CQ:reply,") {
+		end := strings.Index(content, "]") + 1
+		prefix, content = content[:end], content[end:]
+	}
+	data, err := l.client.callAction("send_group_msg", map[string]any{
+		"group_id":    l.bridge.GroupID,
+		"message":     prefix + senderName + ": " + content,
+		"auto_escape": false,
+	})
+	if err != nil {
+		return err
+	}
+	var result struct {
+		MessageID oneBotInt64Param `json:"message_id"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil || result.MessageID.Int64() == 0 {
+		return nil
+	}
+	_, err = model.MessageExternalRefUpsert(l.channel.ID, model.QQBridgeSourceOutbound, qqBridgeFormatID(result.MessageID.Int64()), msg.ID, l.integration.ID, "")
+	return err
+}
+
+// resolveQQMessageID 查找 SealChat 消息在 QQ 群中的对应消息号
+func (l *qqBridgeLink) resolveQQMessageID(messageID string) (int64, error) {
+	for _, source := range []string{model.QQBridgeSourceInbound, model.QQBridgeSourceOutbound} {
+		ref, err := model.MessageExternalRefGetByMessageID(messageID, source)
+		if err != nil {
+			return 0, err
+		}
+		if ref != nil && ref.ChannelID == l.channel.ID {
+			return strconv.ParseInt(ref.ExternalID, 10, 64)
+		}
+	}
+	return 0, errQQBridgeUnmapped
+}
+
+func (l *qqBridgeLink) relayOutboundRecall(messageID string) error {
+	ref, err := model.MessageExternalRefGetByMessageID(messageID, model.QQBridgeSourceOutbound)
+	if err != nil || ref == nil {
+		return err
+	}
+	numericID, err := strconv.ParseInt(ref.ExternalID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	_, err = l.client.callAction("delete_msg", map[string]any{"message_id": numericID})
+	return err
+}
+
+func buildQQBridgeDTO(item *model.QQBridgeModel) fiber.Map {
+	if item == nil {
+		return nil
+	}
+	return fiber.Map{
+		"id":             item.ID,
+		"channelId":      item.ChannelID,
+		"integrationId":  item.IntegrationID,
+		"groupId":        item.GroupID,
+		"transportType":  item.TransportType,
+		"endpoint":       item.Endpoint,
+		"hasAccessToken": item.AccessToken != "",
+		"hasSecret":      item.Secret != "",
+		"enabled":        item.Enabled,
+		"eventPath":      "/api/v1/qq-bridges/" + item.ID + "/events",
+		"lastError":      item.LastError,
+		"lastErrorAt":    item.LastErrorAt,
+		"createdAt":      item.CreatedAt,
+		"updatedAt":      item.UpdatedAt,
+	}
+}
+
+// newQQBridge 为桥接创建专用 webhook 授权，并让机器人以成员身份加入频道以便创建发言身份
+func newQQBridge(channelID, createdBy string) (*model.QQBridgeModel, error) {
+	integration, _, err := createChannelWebhookIntegration(channelID, "QQ 群桥接", model.QQBridgeSourceInbound, createdBy, qqBridgeCapabilities, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := model.UserRoleMappingCreate(&model.UserRoleMappingModel{
+		UserID:   integration.BotUserID,
+		RoleID:   fmt.Sprintf("ch-%s-member", channelID),
+		RoleType: "channel",
+	}); err != nil {
+		return nil, webhookOpWrap(err, "授予频道成员身份失败")
+	}
+	// 只转发启用之后的新消息
+	maxSeq, _ := model.WebhookEventLogMaxSeq(channelID)
+	return &model.QQBridgeModel{
+		ChannelID:     channelID,
+		IntegrationID: integration.ID,
+		BotUserID:     integration.BotUserID,
+		CreatedBy:     createdBy,
+		Enabled:       true,
+		LastEventSeq:  maxSeq,
+	}, nil
+}
+
+func QQBridgeGet(c *fiber.Ctx) error {
+	channelID := strings.TrimSpace(c.Params("channelId"))
+	if !CanWithChannelRole(c, channelID, pm.PermFuncChannelManageInfo) {
+		return nil
+	}
+	item, err := model.QQBridgeGetByChannelID(channelID)
+	if err != nil {
+		return wrapError(c, err, "读取 QQ 桥接失败")
+	}
+	return c.JSON(fiber.Map{"item": buildQQBridgeDTO(item)})
+}
+
+// QQBridgeUpsert 仅系统管理员可配置，服务端会主动连接该地址
+func QQBridgeUpsert(c *fiber.Ctx) error {
+	channelID := strings.TrimSpace(c.Params("channelId"))
+	var body struct {
+		GroupID       oneBotInt64Param `json:"groupId"`
+		TransportType string           `json:"transportType"`
+		Endpoint      string           `json:"endpoint"`
+		AccessToken   *string          `json:"accessToken"`
+		Secret        *string          `json:"secret"`
+		Enabled       *bool            `json:"enabled"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return wrapError(c, err, "参数错误")
+	}
+	if body.GroupID.Int64() <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": "QQ 群号无效"})
+	}
+	endpoint := strings.TrimSpace(body.Endpoint)
+	if endpoint == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": "连接地址不能为空"})
+	}
+
+	channel, err := model.ChannelGet(channelID)
+	if err != nil {
+		return wrapError(c, err, "读取频道失败")
+	}
+	if channel == nil || channel.ID == "" {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"message": "频道不存在"})
+	}
+	if strings.EqualFold(channel.PermType, "private") {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": "私聊频道不支持 QQ 桥接"})
+	}
+
+	item, err := model.QQBridgeGetByChannelID(channelID)
+	if err != nil {
+		return wrapError(c, err, "读取 QQ 桥接失败")
+	}
+	isNew := item == nil
+	if isNew {
+		item = &model.QQBridgeModel{}
+	}
+	item.GroupID = body.GroupID.Int64()
+	item.TransportType = body.TransportType
+	item.Endpoint = endpoint
+	if body.AccessToken != nil {
+		item.AccessToken = *body.AccessToken
+	}
+	if body.Secret != nil {
+		item.Secret = *body.Secret
+	}
+	model.NormalizeQQBridge(item)
+	if err := validateQQBridgeEndpoint(item.TransportType, item.Endpoint); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+	// HTTP 模式的事件上报入口是公开路由，必须依靠签名鉴别来源
+	if item.TransportType == model.OneBotTransportHTTP && item.Secret == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": "HTTP 模式必须设置上报签名密钥"})
+	}
+	if isNew {
+		created, err := newQQBridge(channelID, getCurUser(c).ID)
+		if err != nil {
+			return respondWebhookOpError(c, err)
+		}
+		created.GroupID, created.TransportType, created.Endpoint = item.GroupID, item.TransportType, item.Endpoint
+		created.AccessToken, created.Secret = item.AccessToken, item.Secret
+		item = created
+	}
+	if body.Enabled != nil {
+		item.Enabled = *body.Enabled
+	}
+	item.LastError = ""
+	item.LastErrorAt = 0
+	if err := model.QQBridgeSave(item); err != nil {
+		return wrapError(c, err, "保存 QQ 桥接失败")
+	}
+	reloadQQBridgeForChannel(channelID)
+	return c.JSON(fiber.Map{"item": buildQQBridgeDTO(item)})
+}
+
+func QQBridgeDelete(c *fiber.Ctx) error {
+	channelID := strings.TrimSpace(c.Params("channelId"))
+	item, err := model.QQBridgeGetByChannelID(channelID)
+	if err != nil {
+		return wrapError(c, err, "读取 QQ 桥接失败")
+	}
+	if item == nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"message": "QQ 桥接不存在"})
+	}
+	qqBridgeRuntimeGlobal.stop(channelID)
+	integration, err := model.ChannelWebhookIntegrationGetByID(channelID, item.IntegrationID)
+	if err != nil {
+		return wrapError(c, err, "读取授权失败")
+	}
+	if integration != nil && integration.Status == model.WebhookIntegrationStatusActive {
+		if err := revokeChannelWebhookIntegration(integration); err != nil {
+			return wrapError(c, err, "撤销授权失败")
+		}
+	}
+	if err := model.QQBridgeDelete(item.ID); err != nil {
+		return wrapError(c, err, "删除 QQ 桥接失败")
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// QQBridgeEvents 接收 HTTP 模式下外部实现的事件上报
+func QQBridgeEvents(c *fiber.Ctx) error {
+	bridge, err := model.QQBridgeGet(c.Params("bridgeId"))
+	if err != nil {
+		return wrapError(c, err, "读取 QQ 桥接失败")
+	}
+	if bridge == nil || !bridge.Enabled || bridge.TransportType != model.OneBotTransportHTTP {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"message": "QQ 桥接不存在"})
+	}
+	if !verifyQQBridgeSignature(bridge.Secret, c.Body(), c.Get("X-Signature")) {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"message": "签名校验失败"})
+	}
+	link, err := loadQQBridgeLink(bridge.ID, newQQBridgeHTTPClient(bridge))
+	if err != nil {
+		return wrapError(c, err, "加载 QQ 桥接失败")
+	}
+	if link == nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"message": "QQ 桥接不存在"})
+	}
+	if err := link.handleEvent(c.Body()); err != nil {
+		log.Printf("[qq-bridge] 处理事件失败 bridge=%s err=%v", bridge.ID, err)
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
@@ -0,0 +1,482 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	fastws "github.com/fasthttp/websocket"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+const (
+	qqBridgeActionTimeout     = 10 * time.Second
+	qqBridgeReconnectInterval = 5 * time.Second
+	qqBridgePollInterval      = time.Second
+)
+
+var (
+	errQQBridgeNotConnected      = errors.New("QQ 桥接未连接")
+	errQQBridgeTimeout           = errors.New("QQ 桥接调用超时")
+	errQQBridgeEndpointForbidden = errors.New("连接地址指向本机或内网，需在配置 qqBridge.allowedPrivateHosts 中放行")
+)
+
+// qqBridgeHostAllowed 配置中显式放行的主机可以指向本机或内网
+func qqBridgeHostAllowed(host string) bool {
+	if appConfig == nil {
+		return false
+	}
+	host = strings.Trim(strings.TrimSpace(host), "[]")
+	for _, item := range appConfig.QQBridge.AllowedPrivateHosts {
+		if strings.EqualFold(strings.Trim(strings.TrimSpace(item), "[]"), host) {
+			return true
+		}
+	}
+	return false
+}
+
+func qqBridgeBlockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// validateQQBridgeEndpoint 校验连接地址协议与传输方式匹配，并拒绝解析到回环、链路本地或内网的主机
+func validateQQBridgeEndpoint(transportType, endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Hostname() == "" {
+		return errors.New("连接地址格式错误")
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	if transportType == model.OneBotTransportHTTP {
+		if scheme != "http" && scheme != "https" {
+			return errors.New("HTTP 模式的连接地址需以 http:// 或 https:// 开头")
+		}
+	} else if scheme != "ws" && scheme != "wss" {
+		return errors.New("WebSocket 模式的连接地址需以 ws:// 或 wss:// 开头")
+	}
+	host := parsed.Hostname()
+	if qqBridgeHostAllowed(host) {
+		return nil
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return fmt.Errorf("无法解析连接地址: %v", err)
+	}
+	for _, ip := range ips {
+		if qqBridgeBlockedIP(ip) {
+			return errQQBridgeEndpointForbidden
+		}
+	}
+	return nil
+}
+
+// qqBridgeDialContext 连接时按实际解析出的 IP 再校验一次，避免 DNS 重绑定绕过保存时的检查
+func qqBridgeDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: qqBridgeActionTimeout}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !qqBridgeHostAllowed(host) {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			ipText, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(ipText); ip == nil || qqBridgeBlockedIP(ip) {
+				return errQQBridgeEndpointForbidden
+			}
+			return nil
+		}
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// qqBridgeActionError 外部实现返回的失败响应，重试无意义
+type qqBridgeActionError struct {
+	action  string
+	retcode int
+	message string
+}
+
+func (e *qqBridgeActionError) Error() string {
+	return fmt.Sprintf("%s 调用失败 retcode=%d %s", e.action, e.retcode, e.message)
+}
+
+type qqBridgeActionResponse struct {
+	Status  string          `json:"status"`
+	RetCode int             `json:"retcode"`
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+	Wording string          `json:"wording"`
+	Echo    json.RawMessage `json:"echo"`
+}
+
+func (r *qqBridgeActionResponse) result(action string) (json.RawMessage, error) {
+	if r.RetCode != 0 || (r.Status != "" && r.Status != "ok" && r.Status != "async") {
+		message := r.Wording
+		if message == "" {
+			message = r.Message
+		}
+		return nil, &qqBridgeActionError{action: action, retcode: r.RetCode, message: message}
+	}
+	return r.Data, nil
+}
+
+// qqBridgeClient 向外部 OneBot 实现调用动作
+type qqBridgeClient interface {
+	callAction(action string, params any) (json.RawMessage, error)
+}
+
+type qqBridgeHTTPClient struct {
+	endpoint    string
+	accessToken string
+	client      *http.Client
+}
+
+func newQQBridgeHTTPClient(bridge *model.QQBridgeModel) *qqBridgeHTTPClient {
+	return &qqBridgeHTTPClient{
+		endpoint:    strings.TrimRight(bridge.Endpoint, "/"),
+		accessToken: bridge.AccessToken,
+		client: &http.Client{
+			Timeout:   qqBridgeActionTimeout,
+			Transport: &http.Transport{DialContext: qqBridgeDialContext},
+		},
+	}
+}
+
+func (c *qqBridgeHTTPClient) callAction(action string, params any) (json.RawMessage, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.endpoint+"/"+action, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errQQBridgeNotConnected, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &qqBridgeActionError{action: action, retcode: resp.StatusCode, message: oneBotDebugSnippet(raw)}
+	}
+	var out qqBridgeActionResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out.result(action)
+}
+
+// qqBridgeWSClient 正向 WS（universal）连接，动作响应按 echo 分发，其余帧视为事件
+type qqBridgeWSClient struct {
+	mu      sync.Mutex
+	conn    *fastws.Conn
+	pending map[string]chan *qqBridgeActionResponse
+}
+
+func newQQBridgeWSClient() *qqBridgeWSClient {
+	return &qqBridgeWSClient{pending: map[string]chan *qqBridgeActionResponse{}}
+}
+
+func (c *qqBridgeWSClient) callAction(action string, params any) (json.RawMessage, error) {
+	echo := utils.NewID()
+	ch := make(chan *qqBridgeActionResponse, 1)
+
+	c.mu.Lock()
+	if c.conn == nil {
+		c.mu.Unlock()
+		return nil, errQQBridgeNotConnected
+	}
+	c.pending[echo] = ch
+	_ = c.conn.SetWriteDeadline(time.Now().Add(qqBridgeActionTimeout))
+	err := c.conn.WriteJSON(map[string]any{"action": action, "params": params, "echo": echo})
+	c.mu.Unlock()
+	if err != nil {
+		c.dropPending(echo)
+		return nil, fmt.Errorf("%w: %v", errQQBridgeNotConnected, err)
+	}
+
+	select {
+	case resp := <-ch:
+		if resp == nil {
+			return nil, errQQBridgeNotConnected
+		}
+		return resp.result(action)
+	case <-time.After(qqBridgeActionTimeout):
+		c.dropPending(echo)
+		return nil, errQQBridgeTimeout
+	}
+}
+
+func (c *qqBridgeWSClient) dropPending(echo string) {
+	c.mu.Lock()
+	delete(c.pending, echo)
+	c.mu.Unlock()
+}
+
+func (c *qqBridgeWSClient) attach(conn *fastws.Conn) {
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+}
+
+// detach 断线后让所有等待中的调用立即失败
+func (c *qqBridgeWSClient) detach() {
+	c.mu.Lock()
+	c.conn = nil
+	pending := c.pending
+	c.pending = map[string]chan *qqBridgeActionResponse{}
+	c.mu.Unlock()
+	for _, ch := range pending {
+		ch <- nil
+	}
+}
+
+// dispatchFrame 返回 true 表示该帧是动作响应
+func (c *qqBridgeWSClient) dispatchFrame(body []byte) bool {
+	var resp qqBridgeActionResponse
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Echo) == 0 {
+		return false
+	}
+	echo := strings.Trim(string(resp.Echo), `"`)
+	c.mu.Lock()
+	ch := c.pending[echo]
+	delete(c.pending, echo)
+	c.mu.Unlock()
+	if ch == nil {
+		return true
+	}
+	ch <- &resp
+	return true
+}
+
+func (c *qqBridgeWSClient) runDialLoop(bridge *model.QQBridgeModel, stop <-chan struct{}, onEvent func(body []byte)) {
+	headers := http.Header{}
+	if bridge.AccessToken != "" {
+		headers.Set("Authorization", "Bearer "+bridge.AccessToken)
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		dialer := &fastws.Dialer{NetDialContext: qqBridgeDialContext, HandshakeTimeout: qqBridgeActionTimeout}
+		conn, _, err := dialer.Dial(bridge.Endpoint, headers)
+		if err != nil {
+			log.Printf("[qq-bridge] 连接失败 bridge=%s url=%s err=%v", bridge.ID, bridge.Endpoint, err)
+			_ = model.QQBridgeRecordError(bridge.ID, "连接失败: "+err.Error())
+			select {
+			case <-time.After(qqBridgeReconnectInterval):
+				continue
+			case <-stop:
+				return
+			}
+		}
+		_ = model.QQBridgeRecordError(bridge.ID, "")
+		c.attach(conn)
+
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-stop:
+				_ = conn.Close()
+			case <-done:
+			}
+		}()
+		for {
+			_, body, err := conn.ReadMessage()
+			if err != nil {
+				break
+			}
+			if c.dispatchFrame(body) {
+				continue
+			}
+			go onEvent(body)
+		}
+		close(done)
+		c.detach()
+		_ = conn.Close()
+
+		select {
+		case <-time.After(qqBridgeReconnectInterval):
+		case <-stop:
+			return
+		}
+	}
+}
+
+// verifyQQBridgeSignature 校验 OneBot HTTP POST 上报的 X-Signature（HMAC-SHA1），未配置密钥时一律拒绝
+func verifyQQBridgeSignature(secret string, body []byte, signature string) bool {
+	if secret == "" {
+		return false
+	}
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha1=")
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+type qqBridgeController struct {
+	bridgeID string
+	stop     chan struct{}
+}
+
+func (c *qqBridgeController) cancel() {
+	if c == nil {
+		return
+	}
+	select {
+	case <-c.stop:
+	default:
+		close(c.stop)
+	}
+}
+
+type qqBridgeRuntime struct {
+	mu          sync.Mutex
+	started     bool
+	controllers map[string]*qqBridgeController
+}
+
+var qqBridgeRuntimeGlobal = &qqBridgeRuntime{controllers: map[string]*qqBridgeController{}}
+
+func startQQBridgeRuntime() {
+	rt := qqBridgeRuntimeGlobal
+	rt.mu.Lock()
+	if rt.started {
+		rt.mu.Unlock()
+		return
+	}
+	rt.started = true
+	rt.mu.Unlock()
+
+	items, err := model.QQBridgeListEnabled()
+	if err != nil {
+		log.Printf("[qq-bridge] 加载桥接配置失败: %v", err)
+		return
+	}
+	for _, item := range items {
+		rt.reload(item.ChannelID)
+	}
+}
+
+func reloadQQBridgeForChannel(channelID string) {
+	qqBridgeRuntimeGlobal.reload(channelID)
+}
+
+func (rt *qqBridgeRuntime) reload(channelID string) {
+	channelID = strings.TrimSpace(channelID)
+	if channelID == "" {
+		return
+	}
+	rt.mu.Lock()
+	if existing := rt.controllers[channelID]; existing != nil {
+		existing.cancel()
+		delete(rt.controllers, channelID)
+	}
+	started := rt.started
+	rt.mu.Unlock()
+	if !started {
+		return
+	}
+
+	bridge, err := model.QQBridgeGetByChannelID(channelID)
+	if err != nil || bridge == nil || !bridge.Enabled {
+		if err != nil {
+			log.Printf("[qq-bridge] 读取桥接配置失败 channel=%s err=%v", channelID, err)
+		}
+		return
+	}
+	controller := &qqBridgeController{bridgeID: bridge.ID, stop: make(chan struct{})}
+	rt.mu.Lock()
+	rt.controllers[channelID] = controller
+	rt.mu.Unlock()
+
+	go rt.run(controller, bridge)
+}
+
+func (rt *qqBridgeRuntime) run(controller *qqBridgeController, bridge *model.QQBridgeModel) {
+	var client qqBridgeClient
+	if bridge.TransportType == model.OneBotTransportHTTP {
+		client = newQQBridgeHTTPClient(bridge)
+	} else {
+		wsClient := newQQBridgeWSClient()
+		client = wsClient
+		go wsClient.runDialLoop(bridge, controller.stop, func(body []byte) {
+			link, err := loadQQBridgeLink(bridge.ID, client)
+			if err != nil || link == nil {
+				return
+			}
+			if err := link.handleEvent(body); err != nil {
+				log.Printf("[qq-bridge] 处理事件失败 bridge=%s err=%v", bridge.ID, err)
+			}
+		})
+	}
+
+	ticker := time.NewTicker(qqBridgePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-controller.stop:
+			return
+		case <-ticker.C:
+		}
+		link, err := loadQQBridgeLink(bridge.ID, client)
+		if err != nil {
+			log.Printf("[qq-bridge] 加载桥接失败 bridge=%s err=%v", bridge.ID, err)
+			continue
+		}
+		if link == nil {
+			return
+		}
+		if err := link.syncOutbound(); err != nil && !errors.Is(err, errQQBridgeNotConnected) {
+			log.Printf("[qq-bridge] 出站同步失败 bridge=%s err=%v", bridge.ID, err)
+		}
+	}
+}
+
+func (rt *qqBridgeRuntime) stop(channelID string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if existing := rt.controllers[channelID]; existing != nil {
+		existing.cancel()
+		delete(rt.controllers, channelID)
+	}
+}
+
+func qqBridgeFormatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
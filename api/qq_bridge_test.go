@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+type qqBridgeStandInCall struct {
+	action string
+	params map[string]any
+}
+
+// newQQBridgeStandIn 模拟外部 OneBot 实现的 HTTP API，记录收到的动作
+func newQQBridgeStandIn(t *testing.T) (*httptest.Server, func() []qqBridgeStandInCall) {
+	t.Helper()
+	var mu sync.Mutex
+	var calls []qqBridgeStandInCall
+	nextID := int64(7000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer qq-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var params map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		mu.Lock()
+		calls = append(calls, qqBridgeStandInCall{action: strings.TrimPrefix(r.URL.Path, "/"), params: params})
+		nextID++
+		id := nextID
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": "ok", "retcode": 0, "data": map[string]any{"message_id": id}})
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []qqBridgeStandInCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]qqBridgeStandInCall(nil), calls...)
+	}
+}
+
+func TestQQBridgeMirrorsMessagesBothWays(t *testing.T) {
+	initOneBotAPITestEnv(t)
+
+	botUser, _ := createOneBotTestBot(t, "qqbridgebot", model.BotKindManual)
+	world, channel := createOneBotTestWorldAndChannel(t, botUser.ID)
+	server, calls := newQQBridgeStandIn(t)
+	prevHosts := appConfig.QQBridge.AllowedPrivateHosts
+	appConfig.QQBridge.AllowedPrivateHosts = []string{"127.0.0.1"}
+	t.Cleanup(func() { appConfig.QQBridge.AllowedPrivateHosts = prevHosts })
+
+	bridge, err := newQQBridge(channel.ID, world.OwnerID)
+	if err != nil {
+		t.Fatalf("create bridge failed: %v", err)
+	}
+	bridge.GroupID = 10001
+	bridge.TransportType = model.OneBotTransportHTTP
+	bridge.Endpoint = server.URL
+	bridge.AccessToken = "qq-token"
+	if err := model.QQBridgeSave(bridge); err != nil {
+		t.Fatalf("save bridge failed: %v", err)
+	}
+	link, err := loadQQBridgeLink(bridge.ID, newQQBridgeHTTPClient(bridge))
+	if err != nil || link == nil {
+		t.Fatalf("load bridge failed: %v", err)
+	}
+	integration := link.integration
+
+	inbound := `{"post_type":"message","message_type":"group","self_id":42,"group_id":10001,"user_id":123,"message_id":9001,
+		"message":[{"type":"text","data":{"text":"来自 QQ 的消息"}}],"sender":{"nickname":"qq-nick","card":"阿青"}}`
+	if err := link.handleEvent([]byte(inbound)); err != nil {
+		t.Fatalf("inbound message failed: %v", err)
+	}
+	ref, _ := model.MessageExternalRefGet(channel.ID, model.QQBridgeSourceInbound, "9001")
+	if ref == nil {
+		t.Fatal("inbound message should be recorded with qq external ref")
+	}
+	var inboundMsg model.MessageModel
+	model.GetDB().Where("id = ?", ref.MessageID).Limit(1).Find(&inboundMsg)
+	if inboundMsg.SenderIdentityName != "阿青" || !strings.Contains(inboundMsg.Content, "来自 QQ 的消息") {
+		t.Fatalf("unexpected inbound message: %#v", inboundMsg)
+	}
+	binding, _ := model.WebhookIdentityBindingGet(integration.ID, model.QQBridgeSourceInbound, "123")
+	if binding == nil || binding.IdentityID != inboundMsg.SenderIdentityID {
+		t.Fatalf("qq sender should map to webhook identity: %#v", binding)
+	}
+
+	selfEcho := `{"post_type":"message","message_type":"group","self_id":42,"group_id":10001,"user_id":42,"message_id":9002,"message":"回声"}`
+	if err := link.handleEvent([]byte(selfEcho)); err != nil {
+		t.Fatalf("self message failed: %v", err)
+	}
+	if ref, _ := model.MessageExternalRefGet(channel.ID, model.QQBridgeSourceInbound, "9002"); ref != nil {
+		t.Fatal("messages sent by the bridge account should not be mirrored back")
+	}
+
+	if err := link.syncOutbound(); err != nil {
+		t.Fatalf("sync outbound failed: %v", err)
+	}
+	if got := calls(); len(got) != 0 {
+		t.Fatalf("qq-originated messages should not echo back: %#v", got)
+	}
+
+	member, _ := model.MemberGetByUserIDAndChannelIDBase(world.OwnerID, channel.ID, "店长", true)
+	local := &model.MessageModel{
+		StringPKBaseModel: model.StringPKBaseModel{ID: "msg-" + utils.NewIDWithLength(8)},
+		ChannelID:         channel.ID,
+		UserID:            world.OwnerID,
+		MemberID:          member.ID,
+		Content:           "hello",
+		SenderMemberName:  "店长",
+	}
+	if err := model.GetDB().Create(local).Error; err != nil {
+		t.Fatalf("create local message failed: %v", err)
+	}
+	_ = model.WebhookEventLogAppendForMessage(channel.ID, "message-created", local.ID)
+	if err := link.syncOutbound(); err != nil {
+		t.Fatalf("sync outbound failed: %v", err)
+	}
+	got := calls()
+	if len(got) != 1 || got[0].action != "send_group_msg" || got[0].params["message"] != "店长: hello" || got[0].params["group_id"] != float64(10001) {
+		t.Fatalf("unexpected outbound calls: %#v", got)
+	}
+	outRef, _ := model.MessageExternalRefGetByMessageID(local.ID, model.QQBridgeSourceOutbound)
+	if outRef == nil || outRef.ExternalID != "7001" {
+		t.Fatalf("outbound ref not recorded: %#v", outRef)
+	}
+
+	_ = model.WebhookEventLogAppendForMessage(channel.ID, "message-removed", local.ID)
+	if err := link.syncOutbound(); err != nil {
+		t.Fatalf("sync outbound recall failed: %v", err)
+	}
+	got = calls()
+	if len(got) != 2 || got[1].action != "delete_msg" || got[1].params["message_id"] != float64(7001) {
+		t.Fatalf("unexpected recall calls: %#v", got)
+	}
+
+	recall := `{"post_type":"notice","notice_type":"group_recall","self_id":42,"group_id":10001,"user_id":123,"operator_id":123,"message_id":9001}`
+	if err := link.handleEvent([]byte(recall)); err != nil {
+		t.Fatalf("inbound recall failed: %v", err)
+	}
+	model.GetDB().Where("id = ?", ref.MessageID).Limit(1).Find(&inboundMsg)
+	if !inboundMsg.IsDeleted && !inboundMsg.IsRevoked {
+		t.Fatal("qq recall should remove the mirrored message")
+	}
+	if err := link.syncOutbound(); err != nil {
+		t.Fatalf("sync outbound failed: %v", err)
+	}
+	if got := calls(); len(got) != 2 {
+		t.Fatalf("qq recall should not echo back: %#v", got)
+	}
+}
+
+func TestVerifyQQBridgeSignature(t *testing.T) {
+	body := []byte(`{"post_type":"message"}`)
+	if verifyQQBridgeSignature("", body, "") {
+		t.Fatal("bridges without a secret should reject events")
+	}
+	mac := hmac.New(sha1.New, []byte("secret"))
+	mac.Write(body)
+	if !verifyQQBridgeSignature("secret", body, "sha1="+hex.EncodeToString(mac.Sum(nil))) {
+		t.Fatal("valid signature should pass")
+	}
+	if verifyQQBridgeSignature("secret", body, "sha1=0000") {
+		t.Fatal("invalid signature should be rejected")
+	}
+}
+
+func TestValidateQQBridgeEndpoint(t *testing.T) {
+	initOneBotAPITestEnv(t)
+	prevHosts := appConfig.QQBridge.AllowedPrivateHosts
+	appConfig.QQBridge.AllowedPrivateHosts = nil
+	t.Cleanup(func() { appConfig.QQBridge.AllowedPrivateHosts = prevHosts })
+
+	for _, endpoint := range []string{"http://127.0.0.1:3000", "http://10.0.0.8", "http://169.254.169.254/latest", "http://[::1]:3000", "http://localhost:3000"} {
+		if err := validateQQBridgeEndpoint(model.OneBotTransportHTTP, endpoint); err == nil {
+			t.Fatalf("internal endpoint %s should be rejected", endpoint)
+		}
+	}
+	if err := validateQQBridgeEndpoint(model.OneBotTransportForwardWS, "http://8.8.8.8"); err == nil {
+		t.Fatal("ws transport should require ws:// or wss://")
+	}
+	if err := validateQQBridgeEndpoint(model.OneBotTransportForwardWS, "wss://8.8.8.8/onebot"); err != nil {
+		t.Fatalf("public endpoint should pass: %v", err)
+	}
+
+	appConfig.QQBridge.AllowedPrivateHosts = []string{"127.0.0.1"}
+	if err := validateQQBridgeEndpoint(model.OneBotTransportHTTP, "http://127.0.0.1:3000"); err != nil {
+		t.Fatalf("allowlisted host should pass: %v", err)
+	}
+	if _, err := qqBridgeDialContext(context.Background(), "tcp", "10.255.255.1:80"); !errors.Is(err, errQQBridgeEndpointForbidden) {
+		t.Fatalf("dialing a private address should be refused, got %v", err)
+	}
+}
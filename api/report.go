@@ -0,0 +1,197 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
+)
+
+func reportErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrReportNotFound), errors.Is(err, service.ErrWorldNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrReportPermission), errors.Is(err, service.ErrWorldPermission):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "无权处理该举报"})
+	case errors.Is(err, service.ErrReportDuplicate), errors.Is(err, service.ErrReportResolved):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrReportTooMany):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrReportTargetInvalid),
+		errors.Is(err, service.ErrReportSelf),
+		errors.Is(err, service.ErrReportCategoryInvalid),
+		errors.Is(err, service.ErrReportReasonTooLong),
+		errors.Is(err, service.ErrReportActionInvalid),
+		errors.Is(err, service.ErrWorldMuteDurationRange):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrWorldOwnerImmutable):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "无法对世界拥有者执行该操作"})
+	case errors.Is(err, service.ErrWorldMemberInvalid):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "成员不存在"})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "操作失败"})
+}
+
+func ReportCreateHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body service.ReportCreateInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	report, err := service.ReportCreate(user.ID, body)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	notifyReportCreated(report)
+	return c.JSON(fiber.Map{"id": report.ID, "status": report.Status})
+}
+
+func ReportMineHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	items, err := service.ReportListMine(user.ID)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func WorldReportListHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize", "20"))
+	items, total, err := service.ReportListByWorld(c.Params("worldId"), user.ID, c.Query("status"), page, pageSize)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items, "total": total, "page": page, "pageSize": pageSize})
+}
+
+func AdminReportListHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize", "20"))
+	items, total, err := service.ReportListGlobal(user.ID, c.Query("worldId"), c.Query("status"), page, pageSize)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items, "total": total, "page": page, "pageSize": pageSize})
+}
+
+func ReportGetHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	report, err := service.ReportGet(c.Params("reportId"), user.ID)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"report": report})
+}
+
+func ReportResolveHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body service.ReportResolveInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	items, err := service.ReportResolve(c.Params("reportId"), user.ID, body)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	notifyReportsResolved(items)
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func WorldMemberMuteHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		Minutes int `json:"minutes"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	until, err := service.WorldMuteMember(c.Params("worldId"), user.ID, c.Params("userId"), time.Duration(body.Minutes)*time.Minute)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"mutedUntil": until})
+}
+
+// removeMessageForReport 供举报处理删除消息，沿用频道消息删除的权限校验与广播
+func removeMessageForReport(operatorID, channelID, messageID string) error {
+	operator := model.UserGet(operatorID)
+	if operator == nil || operator.ID == "" {
+		return service.ErrReportPermission
+	}
+	ctx := &ChatContext{
+		User:            operator,
+		ChannelUsersMap: channelUsersMapGlobal,
+		UserId2ConnInfo: userId2ConnInfoGlobal,
+	}
+	_, err := apiMessageRemove(ctx, &messageRemovePayload{ChannelID: channelID, MessageID: messageID})
+	return err
+}
+
+// notifyReportCreated 提醒世界管理员有新的举报待处理
+func notifyReportCreated(report *model.ReportModel) {
+	reviewerIDs := service.ReportReviewerIDs(report)
+	if len(reviewerIDs) == 0 {
+		return
+	}
+	broadcastEventToUsers(reviewerIDs, &protocol.Event{
+		Type: protocol.EventReportUpdated,
+		Argv: &protocol.Argv{Options: map[string]interface{}{"worldId": report.WorldID, "reportId": report.ID, "status": report.Status}},
+	})
+	worldName := ""
+	if world, err := service.GetWorldByID(report.WorldID); err == nil {
+		worldName = world.Name
+	}
+	notice := service.AppNotificationNotice{
+		EventType: "report.created",
+		Title:     worldName,
+		Body:      "收到新的举报，请及时处理",
+		DedupeKey: "report.created:" + report.ID,
+		WorldID:   report.WorldID,
+		WorldName: worldName,
+	}
+	webURL := currentAppWebURL()
+	go func() {
+		_ = service.EnqueueAppNotificationNotice(reviewerIDs, notice, webURL)
+	}()
+}
+
+// notifyReportsResolved 向举报人反馈处理结果，不透露处理人
+func notifyReportsResolved(items []*model.ReportModel) {
+	webURL := currentAppWebURL()
+	for _, item := range items {
+		if item.ReporterID == "" {
+			continue // 内容过滤自动标记，无举报人
+		}
+		broadcastEventToUsers([]string{item.ReporterID}, &protocol.Event{
+			Type: protocol.EventReportUpdated,
+			Argv: &protocol.Argv{Options: map[string]interface{}{"reportId": item.ID, "status": item.Status, "feedback": item.Feedback}},
+		})
+		body := "你的举报已处理"
+		if item.Status == model.ReportStatusDismissed {
+			body = "你的举报经审核未发现违规"
+		}
+		if item.Feedback != "" {
+			body += "：" + item.Feedback
+		}
+		notice := service.AppNotificationNotice{
+			EventType: "report.resolved",
+			Title:     "举报处理结果",
+			Body:      body,
+			DedupeKey: "report.resolved:" + item.ID,
+			WorldID:   item.WorldID,
+		}
+		recipient := item.ReporterID
+		go func() {
+			_ = service.EnqueueAppNotificationNotice([]string{recipient}, notice, webURL)
+		}()
+	}
+}
@@ -0,0 +1,928 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/pm"
+	"sealchat/protocol"
+	"sealchat/service"
+)
+
+const (
+	satoriListPageSize        = 100
+	satoriMessageListDefault  = 50
+	satoriReactionUserPerPage = 50
+)
+
+type satoriList struct {
+	Data any    `json:"data"`
+	Next string `json:"next,omitempty"`
+}
+
+type satoriBidiList struct {
+	Data any    `json:"data"`
+	Prev string `json:"prev,omitempty"`
+	Next string `json:"next,omitempty"`
+}
+
+// satoriResourceContext 资源调用复用 OneBot 的机器人会话，以沿用消息发送时的机器人上下文缓存
+type satoriResourceContext struct {
+	botUser *model.UserModel
+	session *oneBotSession
+}
+
+func (ctx *satoriResourceContext) chat() *ChatContext {
+	return oneBotChatContext(ctx.session)
+}
+
+// SatoriResourceCall 处理 POST /satori/v1/{resource}.{method}
+func SatoriResourceCall(c *fiber.Ctx) error {
+	token := resolveOneBotAccessToken(c.Get("Authorization"))
+	if token == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "缺少 token"})
+	}
+	botUser, err := resolveSatoriBotFromToken(token)
+	if err != nil {
+		return c.Status(satoriErrorStatus(err)).JSON(fiber.Map{"message": err.Error()})
+	}
+	platform := firstNonEmptySatoriValue(c.Get("Satori-Platform"), c.Get("X-Platform"))
+	if platform != "" && platform != satoriPlatform {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "platform 不匹配"})
+	}
+	selfID := firstNonEmptySatoriValue(c.Get("Satori-User-ID"), c.Get("X-Self-ID"))
+	if selfID != "" && selfID != botUser.ID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "self_id 与 token 不匹配"})
+	}
+
+	body := c.Body()
+	if len(strings.TrimSpace(string(body))) == 0 {
+		body = []byte("{}")
+	}
+	ctx := &satoriResourceContext{
+		botUser: botUser,
+		session: newOneBotSession(botUser, oneBotSessionRoleAPI, oneBotSessionSourceHTTP, nil),
+	}
+	data, err := dispatchSatoriResource(ctx, c.Params("method"), body)
+	if err != nil {
+		return c.Status(satoriErrorStatus(err)).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.JSON(data)
+}
+
+func dispatchSatoriResource(ctx *satoriResourceContext, method string, raw json.RawMessage) (any, error) {
+	switch strings.TrimSpace(method) {
+	case "message.create":
+		return satoriMessageCreate(ctx, raw)
+	case "message.get":
+		return satoriMessageGet(ctx, raw)
+	case "message.delete":
+		return satoriMessageDelete(ctx, raw)
+	case "message.update":
+		return satoriMessageUpdate(ctx, raw)
+	case "message.list":
+		return satoriMessageList(ctx, raw)
+	case "channel.get":
+		return satoriChannelGet(ctx, raw)
+	case "channel.list":
+		return satoriChannelList(ctx, raw)
+	case "channel.create":
+		return satoriChannelCreate(ctx, raw)
+	case "channel.update":
+		return satoriChannelUpdate(ctx, raw)
+	case "channel.delete":
+		return satoriChannelDelete(ctx, raw)
+	case "user.channel.create":
+		return satoriUserChannelCreate(ctx, raw)
+	case "guild.get":
+		return satoriGuildGet(ctx, raw)
+	case "guild.list":
+		return satoriGuildList(ctx)
+	case "guild.member.get":
+		return satoriGuildMemberGet(ctx, raw)
+	case "guild.member.list":
+		return satoriGuildMemberList(ctx, raw)
+	case "guild.member.kick":
+		return satoriGuildMemberKick(ctx, raw)
+	case "guild.member.mute":
+		return satoriGuildMemberMute(ctx, raw)
+	case "guild.member.approve":
+		return satoriGuildMemberApprove(ctx, raw)
+	case "reaction.create":
+		return satoriReactionCreate(ctx, raw)
+	case "reaction.delete":
+		return satoriReactionDelete(ctx, raw)
+	case "reaction.list":
+		return satoriReactionList(ctx, raw)
+	case "user.get":
+		return satoriUserGet(raw)
+	case "friend.list":
+		return satoriFriendList(ctx)
+	case "friend.approve":
+		return satoriFriendApprove(ctx, raw)
+	case "login.get":
+		return buildSatoriLogin(ctx.botUser), nil
+	case "channel.mute", "guild.approve", "reaction.clear",
+		"guild.member.role.set", "guild.member.role.unset",
+		"guild.role.list", "guild.role.create", "guild.role.update", "guild.role.delete":
+		return nil, satoriMethodNotAllowed("method not implemented")
+	}
+	return nil, satoriNotFound("unknown method")
+}
+
+func decodeSatoriParams(raw json.RawMessage, params any) error {
+	if err := json.Unmarshal(raw, params); err != nil {
+		return satoriBadRequest("invalid params")
+	}
+	return nil
+}
+
+func satoriParseOffset(next string) int {
+	offset, err := strconv.Atoi(strings.TrimSpace(next))
+	if err != nil || offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+// satoriResolveChannel 私聊频道要求机器人是其中一方，其余频道要求机器人已绑定
+func satoriResolveChannel(botUserID, channelID string) (*model.ChannelModel, error) {
+	channelID = strings.TrimSpace(channelID)
+	if channelID == "" {
+		return nil, satoriBadRequest("channel_id missing")
+	}
+	channel, err := model.ChannelGet(channelID)
+	if err != nil || channel == nil || channel.ID == "" {
+		return nil, satoriNotFound("channel not found")
+	}
+	if channel.IsPrivate || strings.EqualFold(strings.TrimSpace(channel.PermType), "private") {
+		for _, part := range strings.Split(channel.ID, ":") {
+			if part == botUserID {
+				return channel, nil
+			}
+		}
+		return nil, satoriNotFound("channel not found")
+	}
+	return ensureOneBotGroupChannel(botUserID, channel.ID)
+}
+
+func satoriResolveGuild(botUserID, guildID string) (*model.WorldModel, error) {
+	guildID = strings.TrimSpace(guildID)
+	if guildID == "" {
+		return nil, satoriBadRequest("guild_id missing")
+	}
+	world, err := service.GetWorldByID(guildID)
+	if err != nil || world == nil || world.ID == "" {
+		return nil, satoriNotFound("guild not found")
+	}
+	if resolveOneBotWorldGroupChannel(botUserID, world) == nil {
+		return nil, satoriForbidden("bot not bound to guild")
+	}
+	return world, nil
+}
+
+func satoriWorldError(err error) error {
+	if errors.Is(err, service.ErrWorldPermission) || errors.Is(err, service.ErrWorldOwnerImmutable) {
+		return satoriForbidden(err.Error())
+	}
+	return satoriBadRequest(err.Error())
+}
+
+var satoriQuotePattern = regexp.MustCompile(`<quote\s+id="([^"]+)"\s*/>|<quote\s+id="([^"]+)"\s*>\s*</quote>`)
+
+// satoriExtractQuote 取出 <quote id/> 作为引用消息，SealChat 以 quote_id 单独存储
+func satoriExtractQuote(content string) (string, string) {
+	match := satoriQuotePattern.FindStringSubmatch(content)
+	if match == nil {
+		return content, ""
+	}
+	quoteID := firstNonEmptySatoriValue(match[1], match[2])
+	return strings.TrimSpace(strings.Replace(content, match[0], "", 1)), quoteID
+}
+
+func satoriMessageCreate(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		ChannelID string `json:"channel_id"`
+		Content   string `json:"content"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	channel, err := satoriResolveChannel(ctx.botUser.ID, params.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	content, quoteID := satoriExtractQuote(params.Content)
+	if strings.TrimSpace(content) == "" {
+		return nil, satoriBadRequest("content missing")
+	}
+	resp, err := apiMessageCreate(ctx.chat(), &struct {
+		ChannelID         string   `json:"channel_id"`
+		QuoteID           string   `json:"quote_id"`
+		Content           string   `json:"content"`
+		WhisperTo         string   `json:"whisper_to"`
+		WhisperToIds      []string `json:"whisper_to_ids"`
+		ClientID          string   `json:"client_id"`
+		IdentityID        string   `json:"identity_id"`
+		IdentityVariantID string   `json:"identity_variant_id"`
+		ICMode            string   `json:"ic_mode"`
+		BeforeID          string   `json:"before_id"`
+		AfterID           string   `json:"after_id"`
+		DisplayOrder      *float64 `json:"display_order"`
+		TypingDurationMs  *int64   `json:"typing_duration_ms"`
+	}{
+		ChannelID: channel.ID,
+		QuoteID:   quoteID,
+		Content:   content,
+		ICMode:    resolveExternalBotIncomingICMode("", content),
+	})
+	if err != nil {
+		return nil, err
+	}
+	message, _ := resp.(*protocol.Message)
+	if message == nil || message.ID == "" {
+		return nil, satoriForbidden("message create failed")
+	}
+	out := satoriMessageFromProtocol(message)
+	out.Channel = satoriChannelFromModel(channel)
+	out.Guild = satoriGuildByWorldID(channel.WorldID)
+	return []*satoriMessage{out}, nil
+}
+
+func satoriMessageQuery(botUserID, channelID string) *gorm.DB {
+	q := model.GetDB().
+		Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, username, nickname, avatar, is_bot")
+		}).
+		Preload("Member", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, nickname, user_id, channel_id")
+		}).
+		Where("channel_id = ? AND is_deleted = ? AND is_revoked = ?", channelID, false, false)
+	return applyWhisperVisibilityFilterWithReadAll(q, botUserID, canUserReadAllWhispersInChannel(botUserID, channelID))
+}
+
+func satoriLoadMessage(botUserID string, channel *model.ChannelModel, messageID string) (*model.MessageModel, error) {
+	messageID = strings.TrimSpace(messageID)
+	if messageID == "" {
+		return nil, satoriBadRequest("message_id missing")
+	}
+	var msg model.MessageModel
+	if err := satoriMessageQuery(botUserID, channel.ID).Where("id = ?", messageID).Limit(1).Find(&msg).Error; err != nil {
+		return nil, err
+	}
+	if msg.ID == "" {
+		return nil, satoriNotFound("message not found")
+	}
+	return &msg, nil
+}
+
+func satoriMessageGet(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		ChannelID string `json:"channel_id"`
+		MessageID string `json:"message_id"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	channel, err := satoriResolveChannel(ctx.botUser.ID, params.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := satoriLoadMessage(ctx.botUser.ID, channel, params.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	return satoriMessageFromModel(channel, msg), nil
+}
+
+func satoriMessageDelete(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		ChannelID string `json:"channel_id"`
+		MessageID string `json:"message_id"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	channel, err := satoriResolveChannel(ctx.botUser.ID, params.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := satoriLoadMessage(ctx.botUser.ID, channel, params.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := apiMessageDelete(ctx.chat(), &messageDeletePayload{ChannelID: channel.ID, MessageID: msg.ID}); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func satoriMessageUpdate(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		ChannelID string `json:"channel_id"`
+		MessageID string `json:"message_id"`
+		Content   string `json:"content"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	channel, err := satoriResolveChannel(ctx.botUser.ID, params.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := satoriLoadMessage(ctx.botUser.ID, channel, params.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	if msg.UserID != ctx.botUser.ID {
+		return nil, satoriForbidden("only own message can be updated")
+	}
+	if _, err := apiMessageUpdate(ctx.chat(), &struct {
+		ChannelID         string   `json:"channel_id"`
+		MessageID         string   `json:"message_id"`
+		Content           string   `json:"content"`
+		WhisperToIds      []string `json:"whisper_to_ids"`
+		ICMode            string   `json:"ic_mode"`
+		IdentityID        *string  `json:"identity_id"`
+		IdentityVariantID *string  `json:"identity_variant_id"`
+	}{
+		ChannelID: channel.ID,
+		MessageID: msg.ID,
+		Content:   params.Content,
+	}); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// satoriMessageList 以消息 ID 作为翻页游标，按 display_order 向前或向后取
+func satoriMessageList(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		ChannelID string `json:"channel_id"`
+		Next      string `json:"next"`
+		Direction string `json:"direction"`
+		Limit     int    `json:"limit"`
+		Order     string `json:"order"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	channel, err := satoriResolveChannel(ctx.botUser.ID, params.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	direction := strings.ToLower(strings.TrimSpace(params.Direction))
+	if direction == "" {
+		direction = "before"
+	}
+	if direction != "before" && direction != "after" {
+		return nil, satoriBadRequest("direction not supported")
+	}
+	limit := params.Limit
+	if limit <= 0 || limit > satoriListPageSize {
+		limit = satoriMessageListDefault
+	}
+
+	query := satoriMessageQuery(ctx.botUser.ID, channel.ID)
+	anchored := strings.TrimSpace(params.Next) != ""
+	if anchored {
+		anchor, err := satoriLoadMessage(ctx.botUser.ID, channel, params.Next)
+		if err != nil {
+			return nil, err
+		}
+		if direction == "before" {
+			query = query.Where("display_order < ?", anchor.DisplayOrder)
+		} else {
+			query = query.Where("display_order > ?", anchor.DisplayOrder)
+		}
+	}
+	order := "display_order DESC"
+	if direction == "after" {
+		order = "display_order ASC"
+	}
+	var items []*model.MessageModel
+	if err := query.Order(order).Limit(limit).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if direction == "before" {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	result := &satoriBidiList{}
+	if len(items) > 0 {
+		oldest, newest := items[0].ID, items[len(items)-1].ID
+		full := len(items) == limit
+		if (direction == "before" && full) || (direction == "after" && anchored) {
+			result.Prev = oldest
+		}
+		if (direction == "after" && full) || (direction == "before" && anchored) {
+			result.Next = newest
+		}
+	}
+	data := make([]*satoriMessage, 0, len(items))
+	for _, item := range items {
+		data = append(data, satoriMessageFromModel(channel, item))
+	}
+	if strings.EqualFold(strings.TrimSpace(params.Order), "desc") {
+		for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+	result.Data = data
+	return result, nil
+}
+
+func satoriChannelGet(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		ChannelID string `json:"channel_id"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	channel, err := satoriResolveChannel(ctx.botUser.ID, params.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	return satoriChannelFromModel(channel), nil
+}
+
+func satoriChannelList(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		GuildID string `json:"guild_id"`
+		Next    string `json:"next"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	world, err := satoriResolveGuild(ctx.botUser.ID, params.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	channels, err := listOneBotGroupChannels(ctx.botUser.ID)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]*satoriChannel, 0, len(channels))
+	for _, channel := range channels {
+		if channel.WorldID == world.ID {
+			data = append(data, satoriChannelFromModel(channel))
+		}
+	}
+	return &satoriList{Data: data}, nil
+}
+
+func satoriChannelCreate(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		GuildID string `json:"guild_id"`
+		Data    struct {
+			Name     string `json:"name"`
+			Type     int    `json:"type"`
+			ParentID string `json:"parent_id"`
+		} `json:"data"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	world, err := satoriResolveGuild(ctx.botUser.ID, params.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	if params.Data.Type != 0 {
+		return nil, satoriBadRequest("only text channel supported")
+	}
+	if strings.TrimSpace(params.Data.Name) == "" {
+		return nil, satoriBadRequest("channel name missing")
+	}
+	resp, err := apiChannelCreate(ctx.chat(), &protocol.Channel{
+		Name:     strings.TrimSpace(params.Data.Name),
+		WorldID:  world.ID,
+		ParentID: strings.TrimSpace(params.Data.ParentID),
+		PermType: "public",
+	})
+	if err != nil {
+		return nil, satoriForbidden(err.Error())
+	}
+	created, _ := resp.(*struct {
+		Channel *protocol.Channel `json:"channel"`
+	})
+	if created == nil || created.Channel == nil || created.Channel.ID == "" {
+		return nil, satoriForbidden("channel create failed")
+	}
+	channel, err := model.ChannelGet(created.Channel.ID)
+	if err != nil || channel == nil || channel.ID == "" {
+		return nil, satoriNotFound("channel not found")
+	}
+	return satoriChannelFromModel(channel), nil
+}
+
+func satoriChannelUpdate(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		ChannelID string `json:"channel_id"`
+		Data      struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	channel, err := satoriResolveChannel(ctx.botUser.ID, params.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel.IsPrivate {
+		return nil, satoriBadRequest("direct channel cannot be updated")
+	}
+	if !pm.CanWithChannelRole(ctx.botUser.ID, channel.ID, pm.PermFuncChannelManageInfo, pm.PermFuncChannelRoleLink) {
+		return nil, satoriForbidden("permission denied")
+	}
+	name := strings.TrimSpace(params.Data.Name)
+	if name == "" {
+		return nil, satoriBadRequest("channel name missing")
+	}
+	// ChannelInfoEdit 按固定字段整体写入，需在原记录上修改
+	channel.Name = name
+	if err := model.ChannelInfoEdit(channel.ID, channel); err != nil {
+		return nil, err
+	}
+	broadcastChannelTreeInvalidated(ctx.botUser, channel, "info-edit")
+	return nil, nil
+}
+
+func satoriChannelDelete(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		ChannelID string `json:"channel_id"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	channel, err := satoriResolveChannel(ctx.botUser.ID, params.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if !pm.CanWithChannelRole(ctx.botUser.ID, channel.ID, pm.PermFuncChannelManageInfo, pm.PermFuncChannelManageRoleRoot) {
+		return nil, satoriForbidden("permission denied")
+	}
+	if err := service.ChannelDissolve(channel.ID, ctx.botUser.ID); err != nil {
+		return nil, satoriBadRequest(err.Error())
+	}
+	broadcastChannelTreeInvalidated(ctx.botUser, channel, "dissolve")
+	return nil, nil
+}
+
+func satoriUserChannelCreate(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		UserID string `json:"user_id"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	target := model.UserGet(strings.TrimSpace(params.UserID))
+	if target == nil || target.ID == "" {
+		return nil, satoriNotFound("user not found")
+	}
+	if target.ID == ctx.botUser.ID {
+		return nil, satoriBadRequest("cannot create direct channel with self")
+	}
+	channel, err := ensureOneBotPrivateChannel(ctx.botUser.ID, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	return satoriChannelFromModel(channel), nil
+}
+
+func satoriGuildGet(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		GuildID string `json:"guild_id"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	world, err := satoriResolveGuild(ctx.botUser.ID, params.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	return satoriGuildFromWorld(world), nil
+}
+
+// satoriGuildList 机器人所在群组即其绑定频道所属的世界
+func satoriGuildList(ctx *satoriResourceContext) (any, error) {
+	channels, err := listOneBotGroupChannels(ctx.botUser.ID)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]struct{}{}
+	data := make([]*satoriGuild, 0)
+	for _, channel := range channels {
+		if channel.WorldID == "" {
+			continue
+		}
+		if _, ok := seen[channel.WorldID]; ok {
+			continue
+		}
+		seen[channel.WorldID] = struct{}{}
+		if guild := satoriGuildByWorldID(channel.WorldID); guild != nil {
+			data = append(data, guild)
+		}
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].ID < data[j].ID })
+	return &satoriList{Data: data}, nil
+}
+
+func satoriGuildMemberFromWorldMember(member *model.WorldMemberModel) *satoriGuildMember {
+	user := model.UserGet(member.UserID)
+	if user == nil || user.ID == "" {
+		return nil
+	}
+	return &satoriGuildMember{
+		User:     satoriUserFromModel(user),
+		Nick:     user.Nickname,
+		JoinedAt: member.JoinedAt.UnixMilli(),
+	}
+}
+
+func satoriGuildMemberGet(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		GuildID string `json:"guild_id"`
+		UserID  string `json:"user_id"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	world, err := satoriResolveGuild(ctx.botUser.ID, params.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	var member model.WorldMemberModel
+	if err := model.GetDB().Where("world_id = ? AND user_id = ?", world.ID, strings.TrimSpace(params.UserID)).
+		Limit(1).Find(&member).Error; err != nil {
+		return nil, err
+	}
+	if member.ID == "" {
+		return nil, satoriNotFound("member not found")
+	}
+	data := satoriGuildMemberFromWorldMember(&member)
+	if data == nil {
+		return nil, satoriNotFound("user not found")
+	}
+	return data, nil
+}
+
+func satoriGuildMemberList(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		GuildID string `json:"guild_id"`
+		Next    string `json:"next"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	world, err := satoriResolveGuild(ctx.botUser.ID, params.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	offset := satoriParseOffset(params.Next)
+	var members []*model.WorldMemberModel
+	if err := model.GetDB().Where("world_id = ?", world.ID).
+		Order("joined_at asc").Offset(offset).Limit(satoriListPageSize).
+		Find(&members).Error; err != nil {
+		return nil, err
+	}
+	data := make([]*satoriGuildMember, 0, len(members))
+	for _, member := range members {
+		if item := satoriGuildMemberFromWorldMember(member); item != nil {
+			data = append(data, item)
+		}
+	}
+	result := &satoriList{Data: data}
+	if len(members) == satoriListPageSize {
+		result.Next = strconv.Itoa(offset + len(members))
+	}
+	return result, nil
+}
+
+func satoriGuildMemberKick(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		GuildID string `json:"guild_id"`
+		UserID  string `json:"user_id"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	world, err := satoriResolveGuild(ctx.botUser.ID, params.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.WorldRemoveMember(world.ID, ctx.botUser.ID, strings.TrimSpace(params.UserID)); err != nil {
+		return nil, satoriWorldError(err)
+	}
+	return nil, nil
+}
+
+// satoriGuildMemberMute duration 单位为毫秒，0 表示解除禁言
+func satoriGuildMemberMute(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params struct {
+		GuildID  string `json:"guild_id"`
+		UserID   string `json:"user_id"`
+		Duration int64  `json:"duration"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	world, err := satoriResolveGuild(ctx.botUser.ID, params.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	if params.Duration < 0 {
+		return nil, satoriBadRequest("duration invalid")
+	}
+	duration := time.Duration(params.Duration) * time.Millisecond
+	if _, err := service.WorldMuteMember(world.ID, ctx.botUser.ID, strings.TrimSpace(params.UserID), duration); err != nil {
+		return nil, satoriWorldError(err)
+	}
+	return nil, nil
+}
+
+type satoriApproveParams struct {
+	MessageID string `json:"message_id"`
+	Approve   bool   `json:"approve"`
+	Comment   string `json:"comment"`
+}
+
+// satoriGuildMemberApprove 申请事件中的 message.id 即加入申请 ID，审核沿用 OneBot 的实现
+func satoriGuildMemberApprove(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params satoriApproveParams
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	forwarded, _ := json.Marshal(map[string]any{
+		"flag":    params.MessageID,
+		"approve": params.Approve,
+		"reason":  params.Comment,
+	})
+	return oneBotActionSetGroupAddRequest(ctx.session, forwarded)
+}
+
+func satoriFriendApprove(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	var params satoriApproveParams
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	forwarded, _ := json.Marshal(map[string]any{
+		"flag":    params.MessageID,
+		"approve": params.Approve,
+		"remark":  params.Comment,
+	})
+	return oneBotActionSetFriendAddRequest(ctx.session, forwarded)
+}
+
+type satoriReactionParams struct {
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+	Emoji     string `json:"emoji"`
+	UserID    string `json:"user_id"`
+	Next      string `json:"next"`
+}
+
+func satoriResolveReaction(ctx *satoriResourceContext, raw json.RawMessage) (*satoriReactionParams, *model.MessageModel, *model.ChannelModel, error) {
+	var params satoriReactionParams
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err := satoriResolveChannel(ctx.botUser.ID, params.ChannelID); err != nil {
+		return nil, nil, nil, err
+	}
+	params.Emoji = strings.TrimSpace(params.Emoji)
+	if params.Emoji == "" {
+		return nil, nil, nil, satoriBadRequest("emoji missing")
+	}
+	msg, channel, status, errMsg, err := resolveReactionMessage(ctx.botUser, params.MessageID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if status != 0 {
+		return nil, nil, nil, &satoriError{Status: status, Message: errMsg}
+	}
+	if channel.ID != strings.TrimSpace(params.ChannelID) {
+		return nil, nil, nil, satoriNotFound("message not found")
+	}
+	return &params, msg, channel, nil
+}
+
+func satoriReactionCreate(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	params, msg, channel, err := satoriResolveReaction(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	identityID := ""
+	if identity, _ := service.EnsureHiddenDefaultIdentity(ctx.botUser.ID, channel.ID); identity != nil {
+		identityID = identity.ID
+	}
+	emoji, err := service.ResolveWorldStickerReactionEmoji(channel.WorldID, ctx.botUser.ID, params.Emoji)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := service.AddMessageReaction(msg.ID, ctx.botUser.ID, emoji, identityID)
+	if err != nil {
+		return nil, err
+	}
+	broadcastMessageReaction(channel, msg, ctx.botUser, summary, "add")
+	return nil, nil
+}
+
+func satoriReactionDelete(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	params, msg, channel, err := satoriResolveReaction(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	if userID := strings.TrimSpace(params.UserID); userID != "" && userID != ctx.botUser.ID {
+		return nil, satoriMethodNotAllowed("only own reaction can be deleted")
+	}
+	emoji, err := service.ResolveWorldStickerReactionEmoji(channel.WorldID, ctx.botUser.ID, params.Emoji)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := service.RemoveMessageReaction(msg.ID, ctx.botUser.ID, emoji)
+	if err != nil {
+		return nil, err
+	}
+	broadcastMessageReaction(channel, msg, ctx.botUser, summary, "remove")
+	return nil, nil
+}
+
+func satoriReactionList(ctx *satoriResourceContext, raw json.RawMessage) (any, error) {
+	params, msg, channel, err := satoriResolveReaction(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	offset := satoriParseOffset(params.Next)
+	items, total, err := service.ListMessageReactionUsers(msg.ID, channel.ID, params.Emoji, satoriReactionUserPerPage, offset)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]*satoriUser, 0, len(items))
+	for _, item := range items {
+		user := satoriUserFromModel(model.UserGet(item.UserID))
+		if user == nil {
+			continue
+		}
+		if item.DisplayName != "" {
+			user.Nick = item.DisplayName
+		}
+		data = append(data, user)
+	}
+	result := &satoriList{Data: data}
+	if offset+len(items) < total {
+		result.Next = strconv.Itoa(offset + len(items))
+	}
+	return result, nil
+}
+
+func satoriUserGet(raw json.RawMessage) (any, error) {
+	var params struct {
+		UserID string `json:"user_id"`
+	}
+	if err := decodeSatoriParams(raw, &params); err != nil {
+		return nil, err
+	}
+	user := satoriUserFromModel(model.UserGet(strings.TrimSpace(params.UserID)))
+	if user == nil {
+		return nil, satoriNotFound("user not found")
+	}
+	return user, nil
+}
+
+func satoriFriendList(ctx *satoriResourceContext) (any, error) {
+	items, err := model.FriendList(ctx.botUser.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]*satoriUser, 0, len(items))
+	for _, item := range items {
+		if item == nil || item.UserInfo == nil {
+			continue
+		}
+		if user := satoriUserFromModel(item.UserInfo); user != nil {
+			data = append(data, user)
+		}
+	}
+	return &satoriList{Data: data}, nil
+}
+
+func firstNonEmptySatoriValue(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,643 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
+	"sealchat/utils"
+)
+
+const (
+	satoriPlatform        = "sealchat"
+	satoriEventBufferSize = 1000
+	satoriIdentifyTimeout = 10 * time.Second
+	satoriReadTimeout     = 60 * time.Second
+)
+
+// satoriOpcode Satori 信令，与 SealChat 自有网关的 protocol.Opcode 编号并不完全一致
+type satoriOpcode int
+
+const (
+	satoriOpEvent satoriOpcode = iota
+	satoriOpPing
+	satoriOpPong
+	satoriOpIdentify
+	satoriOpReady
+	satoriOpMeta
+)
+
+type satoriSignal struct {
+	Op   satoriOpcode    `json:"op"`
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+type satoriSignalPayload struct {
+	Op   satoriOpcode `json:"op"`
+	Body any          `json:"body,omitempty"`
+}
+
+type satoriUser struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Nick   string `json:"nick,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+	IsBot  bool   `json:"is_bot,omitempty"`
+}
+
+type satoriChannel struct {
+	ID       string `json:"id"`
+	Type     int    `json:"type"`
+	Name     string `json:"name,omitempty"`
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+type satoriGuild struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+type satoriGuildMember struct {
+	User     *satoriUser `json:"user,omitempty"`
+	Nick     string      `json:"nick,omitempty"`
+	Avatar   string      `json:"avatar,omitempty"`
+	JoinedAt int64       `json:"joined_at,omitempty"`
+}
+
+type satoriMessage struct {
+	ID        string             `json:"id"`
+	Content   string             `json:"content,omitempty"`
+	Channel   *satoriChannel     `json:"channel,omitempty"`
+	Guild     *satoriGuild       `json:"guild,omitempty"`
+	Member    *satoriGuildMember `json:"member,omitempty"`
+	User      *satoriUser        `json:"user,omitempty"`
+	Quote     *satoriMessage     `json:"quote,omitempty"`
+	CreatedAt int64              `json:"created_at,omitempty"`
+	UpdatedAt int64              `json:"updated_at,omitempty"`
+}
+
+type satoriEmoji struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type satoriLogin struct {
+	User     *satoriUser `json:"user"`
+	SelfID   string      `json:"self_id"`
+	Platform string      `json:"platform"`
+	Status   int         `json:"status"`
+	Adapter  string      `json:"adapter"`
+	Features []string    `json:"features"`
+}
+
+type satoriEvent struct {
+	SN        int64              `json:"sn"`
+	ID        int64              `json:"id"` // 兼容仍按 id 断线续传的 Satori 1.0 客户端
+	Type      string             `json:"type"`
+	Timestamp int64              `json:"timestamp"`
+	Platform  string             `json:"platform"`
+	SelfID    string             `json:"self_id"`
+	Login     *satoriLogin       `json:"login,omitempty"`
+	Channel   *satoriChannel     `json:"channel,omitempty"`
+	Guild     *satoriGuild       `json:"guild,omitempty"`
+	Member    *satoriGuildMember `json:"member,omitempty"`
+	Message   *satoriMessage     `json:"message,omitempty"`
+	Operator  *satoriUser        `json:"operator,omitempty"`
+	User      *satoriUser        `json:"user,omitempty"`
+	Emoji     *satoriEmoji       `json:"emoji,omitempty"`
+}
+
+var satoriLoginFeatures = []string{
+	"message.delete",
+	"message.update",
+	"message.list",
+	"reaction.create",
+	"reaction.delete",
+	"reaction.list",
+	"guild.member.kick",
+	"guild.member.mute",
+	"user.channel.create",
+}
+
+type satoriSession struct {
+	ID      string
+	BotUser *model.UserModel
+	Conn    oneBotJSONConn
+}
+
+func (s *satoriSession) send(op satoriOpcode, body any) error {
+	if s == nil || s.Conn == nil {
+		return errors.New("satori session unavailable")
+	}
+	payload := satoriSignalPayload{Op: op, Body: body}
+	if timedConn, ok := s.Conn.(oneBotTimedJSONConn); ok {
+		return timedConn.WriteJSONWithTimeout(payload, oneBotWriteTimeout)
+	}
+	return s.Conn.WriteJSON(payload)
+}
+
+// satoriBotState 每个机器人一份事件缓冲，sn 单调递增，断线重连时按 sn 补发
+type satoriBotState struct {
+	mu       sync.Mutex
+	botUser  *model.UserModel
+	sn       int64
+	events   []*satoriEvent
+	sessions map[string]*satoriSession
+}
+
+type satoriRuntime struct {
+	mu   sync.Mutex
+	bots map[string]*satoriBotState
+}
+
+var satoriRuntimeGlobal = &satoriRuntime{bots: map[string]*satoriBotState{}}
+
+func getSatoriRuntime() *satoriRuntime {
+	return satoriRuntimeGlobal
+}
+
+func (rt *satoriRuntime) botState(botUserID string, create bool) *satoriBotState {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	state := rt.bots[botUserID]
+	if state == nil && create {
+		state = &satoriBotState{sessions: map[string]*satoriSession{}}
+		rt.bots[botUserID] = state
+	}
+	return state
+}
+
+// attach 发送 READY 并补发 sn 之后的事件；全程持有机器人锁，保证补发与实时事件不乱序
+func (rt *satoriRuntime) attach(session *satoriSession, sn int64, ready any) error {
+	state := rt.botState(session.BotUser.ID, true)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.botUser = session.BotUser
+	if err := session.send(satoriOpReady, ready); err != nil {
+		return err
+	}
+	if sn > 0 {
+		for _, event := range state.events {
+			if event.SN <= sn {
+				continue
+			}
+			if err := session.send(satoriOpEvent, event); err != nil {
+				return err
+			}
+		}
+	}
+	state.sessions[session.ID] = session
+	return nil
+}
+
+func (rt *satoriRuntime) detach(session *satoriSession) {
+	if session == nil || session.BotUser == nil {
+		return
+	}
+	state := rt.botState(session.BotUser.ID, false)
+	if state == nil {
+		return
+	}
+	state.mu.Lock()
+	delete(state.sessions, session.ID)
+	state.mu.Unlock()
+}
+
+// publishProtocolEvent 仅为连接过 Satori 网关的机器人缓存与推送事件
+func (rt *satoriRuntime) publishProtocolEvent(botUserID string, event *protocol.Event) {
+	if rt == nil || botUserID == "" || event == nil {
+		return
+	}
+	state := rt.botState(botUserID, false)
+	if state == nil {
+		return
+	}
+	state.mu.Lock()
+	botUser := state.botUser
+	state.mu.Unlock()
+
+	projected, ok := projectProtocolEventToSatori(botUser, event)
+	if !ok {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.sn++
+	projected.SN = state.sn
+	projected.ID = state.sn
+	state.events = append(state.events, projected)
+	if overflow := len(state.events) - satoriEventBufferSize; overflow > 0 {
+		state.events = append([]*satoriEvent(nil), state.events[overflow:]...)
+	}
+	for id, session := range state.sessions {
+		if err := session.send(satoriOpEvent, projected); err != nil {
+			log.Printf("[satori] 推送事件失败 session=%s bot=%s err=%v", id, botUserID, err)
+			delete(state.sessions, id)
+			_ = session.Conn.Close()
+		}
+	}
+}
+
+func satoriWorks(app *fiber.App, webUrl string) {
+	wsPath := joinWebPath(webUrl, "satori/v1/events")
+	app.Use(wsPath, func(c *fiber.Ctx) error {
+		if websocket.IsWebSocketUpgrade(c) {
+			return c.Next()
+		}
+		return fiber.ErrUpgradeRequired
+	})
+	app.Get(wsPath, websocket.New(satoriEventsWSHandler))
+	app.Post(joinWebPath(webUrl, "satori/v1/:method"), SatoriResourceCall)
+}
+
+func satoriEventsWSHandler(rawConn *websocket.Conn) {
+	conn := &WsSyncConn{Conn: rawConn, Mux: sync.RWMutex{}}
+	var session *satoriSession
+	defer func() {
+		getSatoriRuntime().detach(session)
+		_ = rawConn.Close()
+	}()
+
+	_ = rawConn.SetReadDeadline(time.Now().Add(satoriIdentifyTimeout))
+	for {
+		_, body, err := rawConn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var signal satoriSignal
+		if err := json.Unmarshal(body, &signal); err != nil {
+			continue
+		}
+		switch signal.Op {
+		case satoriOpPing:
+			if err := conn.WriteJSON(satoriSignalPayload{Op: satoriOpPong, Body: map[string]any{}}); err != nil {
+				return
+			}
+		case satoriOpIdentify:
+			if session != nil {
+				continue
+			}
+			var identify struct {
+				Token string `json:"token"`
+				SN    int64  `json:"sn"`
+			}
+			_ = json.Unmarshal(signal.Body, &identify)
+			token := identify.Token
+			if strings.TrimSpace(token) == "" {
+				token = rawConn.Headers("Authorization")
+			}
+			botUser, err := resolveSatoriBotFromToken(token)
+			if err != nil {
+				log.Printf("[satori] 鉴权失败: %v", err)
+				return
+			}
+			candidate := &satoriSession{ID: utils.NewID(), BotUser: botUser, Conn: conn}
+			ready := map[string]any{
+				"logins":     []*satoriLogin{buildSatoriLogin(botUser)},
+				"proxy_urls": []string{},
+			}
+			if err := getSatoriRuntime().attach(candidate, identify.SN, ready); err != nil {
+				log.Printf("[satori] 建立会话失败 bot=%s err=%v", botUser.ID, err)
+				return
+			}
+			session = candidate
+		}
+		if session != nil {
+			_ = rawConn.SetReadDeadline(time.Now().Add(satoriReadTimeout))
+		}
+	}
+}
+
+type satoriError struct {
+	Status  int
+	Message string
+}
+
+func (e *satoriError) Error() string {
+	return e.Message
+}
+
+func satoriBadRequest(message string) error {
+	return &satoriError{Status: http.StatusBadRequest, Message: message}
+}
+
+func satoriUnauthorized(message string) error {
+	return &satoriError{Status: http.StatusUnauthorized, Message: message}
+}
+
+func satoriForbidden(message string) error {
+	return &satoriError{Status: http.StatusForbidden, Message: message}
+}
+
+func satoriNotFound(message string) error {
+	return &satoriError{Status: http.StatusNotFound, Message: message}
+}
+
+func satoriMethodNotAllowed(message string) error {
+	return &satoriError{Status: http.StatusMethodNotAllowed, Message: message}
+}
+
+// satoriErrorStatus 复用的 OneBot 辅助函数返回 oneBotActionError，按 retcode 映射为 HTTP 状态
+func satoriErrorStatus(err error) int {
+	var satoriErr *satoriError
+	if errors.As(err, &satoriErr) {
+		return satoriErr.Status
+	}
+	var oneBotErr *oneBotActionError
+	if errors.As(err, &oneBotErr) {
+		switch oneBotErr.RetCode {
+		case 1403:
+			return http.StatusForbidden
+		case 1404:
+			return http.StatusNotFound
+		}
+	}
+	return http.StatusBadRequest
+}
+
+func resolveSatoriBotFromToken(token string) (*model.UserModel, error) {
+	token = resolveOneBotAccessToken(token)
+	if len(token) != 32 {
+		return nil, satoriUnauthorized("token invalid")
+	}
+	user, err := model.BotVerifyAccessToken(token)
+	if err != nil || user == nil || !user.IsBot {
+		return nil, satoriUnauthorized("token invalid")
+	}
+	if strings.TrimSpace(user.BotKind) != model.BotKindManual {
+		return nil, satoriForbidden("only manual bot supports satori")
+	}
+	return user, nil
+}
+
+func buildSatoriLogin(botUser *model.UserModel) *satoriLogin {
+	return &satoriLogin{
+		User:     satoriUserFromModel(botUser),
+		SelfID:   botUser.ID,
+		Platform: satoriPlatform,
+		Status:   int(protocol.StatusOnline),
+		Adapter:  satoriPlatform,
+		Features: satoriLoginFeatures,
+	}
+}
+
+func satoriAvatarURL(avatar string) string {
+	avatar = strings.TrimSpace(avatar)
+	if !strings.HasPrefix(avatar, "id:") {
+		return avatar
+	}
+	resolved, err := resolveOneBotAttachmentURL(strings.TrimPrefix(avatar, "id:"))
+	if err != nil {
+		return ""
+	}
+	return resolved
+}
+
+func satoriUserFromModel(user *model.UserModel) *satoriUser {
+	if user == nil {
+		return nil
+	}
+	return satoriUserFromProtocol(user.ToProtocolType())
+}
+
+func satoriUserFromProtocol(user *protocol.User) *satoriUser {
+	if user == nil || user.ID == "" {
+		return nil
+	}
+	return &satoriUser{
+		ID:     user.ID,
+		Name:   user.Name,
+		Nick:   user.Nick,
+		Avatar: satoriAvatarURL(user.Avatar),
+		IsBot:  user.IsBot,
+	}
+}
+
+// satoriChannelType Satori 频道类型：0 文字、1 私聊、2 分类、3 语音
+func satoriChannelType(channelType protocol.ChannelType) int {
+	switch channelType {
+	case protocol.DirectChannelType:
+		return 1
+	case protocol.CategoryChannelType:
+		return 2
+	case protocol.VoiceChannelType:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func satoriChannelFromProtocol(channel *protocol.Channel) *satoriChannel {
+	if channel == nil || channel.ID == "" {
+		return nil
+	}
+	name := channel.Name
+	if channel.Type == protocol.DirectChannelType {
+		name = ""
+	}
+	return &satoriChannel{
+		ID:       channel.ID,
+		Type:     satoriChannelType(channel.Type),
+		Name:     name,
+		ParentID: channel.ParentID,
+	}
+}
+
+func satoriChannelFromModel(channel *model.ChannelModel) *satoriChannel {
+	if channel == nil {
+		return nil
+	}
+	data := satoriChannelFromProtocol(channel.ToProtocolType())
+	if data != nil {
+		data.ParentID = channel.ParentID
+	}
+	return data
+}
+
+func satoriGuildFromWorld(world *model.WorldModel) *satoriGuild {
+	if world == nil || world.ID == "" {
+		return nil
+	}
+	return &satoriGuild{ID: world.ID, Name: world.Name, Avatar: satoriAvatarURL(world.Avatar)}
+}
+
+// satoriGuildByWorldID 世界映射为 Satori 群组，私聊等无世界的频道返回 nil
+func satoriGuildByWorldID(worldID string) *satoriGuild {
+	if strings.TrimSpace(worldID) == "" {
+		return nil
+	}
+	world, err := service.GetWorldByID(worldID)
+	if err != nil {
+		return nil
+	}
+	return satoriGuildFromWorld(world)
+}
+
+func satoriMemberFromProtocol(member *protocol.GuildMember) *satoriGuildMember {
+	if member == nil {
+		return nil
+	}
+	return &satoriGuildMember{
+		User:     satoriUserFromProtocol(member.User),
+		Nick:     member.Nick,
+		Avatar:   satoriAvatarURL(member.Avatar),
+		JoinedAt: member.JoinedAt,
+	}
+}
+
+var satoriAttachmentSrcPattern = regexp.MustCompile(`\ssrc="id:([^"]+)"`)
+
+// satoriExportContent 将内部附件引用 id:xxx 替换为可下载地址
+func satoriExportContent(content string) string {
+	return satoriAttachmentSrcPattern.ReplaceAllStringFunc(content, func(match string) string {
+		token := satoriAttachmentSrcPattern.FindStringSubmatch(match)[1]
+		resolved, err := resolveOneBotAttachmentURL(token)
+		if err != nil || resolved == "" {
+			return match
+		}
+		return ` src="` + resolved + `"`
+	})
+}
+
+func satoriMessageFromProtocol(msg *protocol.Message) *satoriMessage {
+	if msg == nil || msg.ID == "" {
+		return nil
+	}
+	out := &satoriMessage{
+		ID:        msg.ID,
+		Content:   satoriExportContent(msg.Content),
+		User:      satoriUserFromProtocol(msg.User),
+		Member:    satoriMemberFromProtocol(msg.Member),
+		CreatedAt: msg.CreatedAt,
+		UpdatedAt: msg.UpdatedAt,
+	}
+	if msg.Quote != nil && msg.Quote.ID != "" {
+		out.Quote = &satoriMessage{ID: msg.Quote.ID}
+	}
+	return out
+}
+
+func satoriMessageFromModel(channel *model.ChannelModel, msg *model.MessageModel) *satoriMessage {
+	channelData := channel.ToProtocolType()
+	out := satoriMessageFromProtocol(buildProtocolMessage(msg, channelData))
+	if out == nil {
+		return nil
+	}
+	if out.Quote == nil && strings.TrimSpace(msg.QuoteID) != "" {
+		out.Quote = &satoriMessage{ID: msg.QuoteID}
+	}
+	out.Channel = satoriChannelFromModel(channel)
+	out.Guild = satoriGuildByWorldID(channel.WorldID)
+	return out
+}
+
+func projectProtocolEventToSatori(botUser *model.UserModel, event *protocol.Event) (*satoriEvent, bool) {
+	if botUser == nil || event == nil {
+		return nil, false
+	}
+	out := &satoriEvent{
+		Timestamp: event.Timestamp * 1000,
+		Platform:  satoriPlatform,
+		SelfID:    botUser.ID,
+		Login:     buildSatoriLogin(botUser),
+		Channel:   satoriChannelFromProtocol(event.Channel),
+		User:      satoriUserFromProtocol(event.User),
+		Operator:  satoriUserFromProtocol(event.Operator),
+		Member:    satoriMemberFromProtocol(event.Member),
+	}
+	if out.Timestamp <= 0 {
+		out.Timestamp = time.Now().UnixMilli()
+	}
+	isDirect := event.Channel != nil && event.Channel.Type == protocol.DirectChannelType
+	if event.Channel != nil && !isDirect {
+		out.Guild = satoriGuildByWorldID(event.Channel.WorldID)
+	}
+
+	switch event.Type {
+	case protocol.EventMessageCreated, protocol.EventMessageUpdated,
+		protocol.EventMessageDeleted, protocol.EventMessageRemoved:
+		if event.Message == nil || event.Channel == nil {
+			return nil, false
+		}
+		if event.Message.IsWhisper && !isDirect {
+			return nil, false
+		}
+		out.Type = string(event.Type)
+		if event.Type == protocol.EventMessageRemoved {
+			out.Type = string(protocol.EventMessageDeleted)
+		}
+		out.Message = satoriMessageFromProtocol(event.Message)
+		if out.Message == nil {
+			return nil, false
+		}
+		if out.Member == nil && !isDirect {
+			out.Member = out.Message.Member
+		}
+		if event.Type == protocol.EventMessageCreated || event.Type == protocol.EventMessageUpdated {
+			if author := satoriUserFromProtocol(event.Message.User); author != nil {
+				out.User = author
+			}
+		}
+	case protocol.EventMessageReaction:
+		reaction := event.MessageReaction
+		if reaction == nil || event.Channel == nil {
+			return nil, false
+		}
+		out.Type = "reaction-added"
+		if reaction.Action == "remove" {
+			out.Type = "reaction-removed"
+		}
+		out.Message = &satoriMessage{ID: reaction.MessageID}
+		out.Emoji = &satoriEmoji{ID: reaction.Emoji, Name: reaction.Emoji}
+	case protocol.EventGuildMemberAdded, protocol.EventGuildMemberRemoved:
+		// 世界成员变动会按频道逐个广播，只在机器人所选的代表频道上投递一次
+		if out.Guild == nil || out.User == nil {
+			return nil, false
+		}
+		world, err := service.GetWorldByID(out.Guild.ID)
+		if err != nil {
+			return nil, false
+		}
+		if channel := resolveOneBotWorldGroupChannel(botUser.ID, world); channel == nil || channel.ID != event.Channel.ID {
+			return nil, false
+		}
+		out.Type = string(event.Type)
+		out.Channel = nil
+	case protocol.EventChannelMemberUpdated:
+		if out.Guild == nil || out.Member == nil {
+			return nil, false
+		}
+		out.Type = "guild-member-updated"
+		if out.User == nil {
+			out.User = out.Member.User
+		}
+	case protocol.EventGuildMemberRequest, protocol.EventFriendRequest:
+		requestID := oneBotEventStringOption(event, "requestId")
+		if requestID == "" || out.User == nil {
+			return nil, false
+		}
+		out.Type = string(event.Type)
+		out.Message = &satoriMessage{ID: requestID, Content: oneBotEventStringOption(event, "comment")}
+	case protocol.EventChannelUpdated:
+		if out.Channel == nil {
+			return nil, false
+		}
+		out.Type = string(event.Type)
+	default:
+		return nil, false
+	}
+	return out, true
+}
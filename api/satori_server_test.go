@@ -0,0 +1,216 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/utils"
+)
+
+func newSatoriHTTPTestApp() *fiber.App {
+	app := fiber.New()
+	app.Post("/satori/v1/:method", SatoriResourceCall)
+	return app
+}
+
+func callSatoriTestResource(t *testing.T, app *fiber.App, token, method, body string) (int, json.RawMessage) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/satori/v1/"+method, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var raw json.RawMessage
+	_ = json.NewDecoder(resp.Body).Decode(&raw)
+	return resp.StatusCode, raw
+}
+
+func TestSatoriResourceRequiresToken(t *testing.T) {
+	initOneBotAPITestEnv(t)
+
+	app := newSatoriHTTPTestApp()
+	status, _ := callSatoriTestResource(t, app, "", "login.get", "{}")
+	if status != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", status, http.StatusUnauthorized)
+	}
+	status, _ = callSatoriTestResource(t, app, strings.Repeat("x", 32), "login.get", "{}")
+	if status != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
+
+func TestSatoriResourceMessageCreateAndGet(t *testing.T) {
+	initOneBotAPITestEnv(t)
+
+	botUser, token := createOneBotTestBot(t, "satori-msg", model.BotKindManual)
+	world, channel := createOneBotTestWorldAndChannel(t, botUser.ID)
+	app := newSatoriHTTPTestApp()
+
+	status, raw := callSatoriTestResource(t, app, token.Token, "message.create",
+		`{"channel_id":"`+channel.ID+`","content":"hello satori"}`)
+	if status != http.StatusOK {
+		t.Fatalf("message.create status = %d body=%s", status, raw)
+	}
+	var created []satoriMessage
+	if err := json.Unmarshal(raw, &created); err != nil || len(created) != 1 || created[0].ID == "" {
+		t.Fatalf("unexpected message.create result: %s", raw)
+	}
+	if created[0].Guild == nil || created[0].Guild.ID != world.ID {
+		t.Fatalf("created message should carry guild: %s", raw)
+	}
+
+	status, raw = callSatoriTestResource(t, app, token.Token, "message.get",
+		`{"channel_id":"`+channel.ID+`","message_id":"`+created[0].ID+`"}`)
+	if status != http.StatusOK {
+		t.Fatalf("message.get status = %d body=%s", status, raw)
+	}
+	var fetched satoriMessage
+	if err := json.Unmarshal(raw, &fetched); err != nil || !strings.Contains(fetched.Content, "hello satori") {
+		t.Fatalf("unexpected message.get result: %s", raw)
+	}
+	if fetched.User == nil || fetched.User.ID != botUser.ID {
+		t.Fatalf("message author should be the bot: %s", raw)
+	}
+
+	status, raw = callSatoriTestResource(t, app, token.Token, "message.list", `{"channel_id":"`+channel.ID+`"}`)
+	if status != http.StatusOK {
+		t.Fatalf("message.list status = %d body=%s", status, raw)
+	}
+	var listed struct {
+		Data []satoriMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &listed); err != nil || len(listed.Data) != 1 || listed.Data[0].ID != created[0].ID {
+		t.Fatalf("unexpected message.list result: %s", raw)
+	}
+
+	status, _ = callSatoriTestResource(t, app, token.Token, "message.get",
+		`{"channel_id":"grp-missing","message_id":"`+created[0].ID+`"}`)
+	if status != http.StatusNotFound {
+		t.Fatalf("unknown channel status = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestSatoriResourceGuildAndChannelList(t *testing.T) {
+	initOneBotAPITestEnv(t)
+
+	botUser, token := createOneBotTestBot(t, "satori-guild", model.BotKindManual)
+	world, channel := createOneBotTestWorldAndChannel(t, botUser.ID)
+	app := newSatoriHTTPTestApp()
+
+	status, raw := callSatoriTestResource(t, app, token.Token, "guild.list", "")
+	if status != http.StatusOK {
+		t.Fatalf("guild.list status = %d body=%s", status, raw)
+	}
+	var guilds struct {
+		Data []satoriGuild `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &guilds); err != nil || len(guilds.Data) != 1 || guilds.Data[0].ID != world.ID {
+		t.Fatalf("unexpected guild.list result: %s", raw)
+	}
+
+	status, raw = callSatoriTestResource(t, app, token.Token, "channel.list", `{"guild_id":"`+world.ID+`"}`)
+	if status != http.StatusOK {
+		t.Fatalf("channel.list status = %d body=%s", status, raw)
+	}
+	var channels struct {
+		Data []satoriChannel `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &channels); err != nil || len(channels.Data) != 1 || channels.Data[0].ID != channel.ID {
+		t.Fatalf("unexpected channel.list result: %s", raw)
+	}
+
+	status, raw = callSatoriTestResource(t, app, token.Token, "login.get", "{}")
+	if status != http.StatusOK || !strings.Contains(string(raw), botUser.ID) {
+		t.Fatalf("unexpected login.get result: %d %s", status, raw)
+	}
+
+	status, _ = callSatoriTestResource(t, app, token.Token, "guild.role.list", `{"guild_id":"`+world.ID+`"}`)
+	if status != http.StatusMethodNotAllowed {
+		t.Fatalf("unsupported method status = %d, want %d", status, http.StatusMethodNotAllowed)
+	}
+	status, _ = callSatoriTestResource(t, app, token.Token, "nope.nope", "{}")
+	if status != http.StatusNotFound {
+		t.Fatalf("unknown method status = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func satoriTestEventSNs(t *testing.T, conn *oneBotTestJSONConn) []int64 {
+	t.Helper()
+	var out []int64
+	for _, payload := range conn.payloads {
+		signal, ok := payload.(satoriSignalPayload)
+		if !ok || signal.Op != satoriOpEvent {
+			continue
+		}
+		event, ok := signal.Body.(*satoriEvent)
+		if !ok {
+			t.Fatalf("unexpected event body: %T", signal.Body)
+		}
+		out = append(out, event.SN)
+	}
+	return out
+}
+
+func TestSatoriRuntimeResumesFromSN(t *testing.T) {
+	initOneBotAPITestEnv(t)
+
+	botUser, _ := createOneBotTestBot(t, "satori-sn", model.BotKindManual)
+	sender := createOneBotTestUser(t, "satori-sender", false, "")
+	_, channel := createOneBotTestWorldAndChannel(t, botUser.ID)
+	rt := &satoriRuntime{bots: map[string]*satoriBotState{}}
+
+	newEvent := func(content string) *protocol.Event {
+		return &protocol.Event{
+			Type:      protocol.EventMessageCreated,
+			Timestamp: time.Now().Unix(),
+			Channel:   &protocol.Channel{ID: channel.ID, Name: channel.Name, Type: protocol.TextChannelType},
+			User:      &protocol.User{ID: sender.ID, Nick: sender.Nickname},
+			Message:   &protocol.Message{ID: "msg-" + utils.NewIDWithLength(8), Content: content},
+		}
+	}
+
+	rt.publishProtocolEvent(botUser.ID, newEvent("before identify"))
+	if rt.botState(botUser.ID, false) != nil {
+		t.Fatal("bots without satori sessions should not buffer events")
+	}
+
+	first := &oneBotTestJSONConn{}
+	session := &satoriSession{ID: "s1", BotUser: botUser, Conn: first}
+	if err := rt.attach(session, 0, map[string]any{}); err != nil {
+		t.Fatalf("attach failed: %v", err)
+	}
+	if signal, ok := first.payloads[0].(satoriSignalPayload); !ok || signal.Op != satoriOpReady {
+		t.Fatalf("first frame should be READY: %#v", first.payloads[0])
+	}
+	for _, content := range []string{"one", "two", "three"} {
+		rt.publishProtocolEvent(botUser.ID, newEvent(content))
+	}
+	whisper := newEvent("secret")
+	whisper.Message.IsWhisper = true
+	rt.publishProtocolEvent(botUser.ID, whisper)
+	if got := satoriTestEventSNs(t, first); len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Fatalf("unexpected live sn sequence: %v", got)
+	}
+	rt.detach(session)
+
+	resumed := &oneBotTestJSONConn{}
+	if err := rt.attach(&satoriSession{ID: "s2", BotUser: botUser, Conn: resumed}, 1, map[string]any{}); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if got := satoriTestEventSNs(t, resumed); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("resume should replay events after sn=1, got %v", got)
+	}
+}
@@ -394,6 +394,19 @@ func UserInfoUpdate(c *fiber.Ctx) error {
 		})
 	}
 
+	if data.Nickname, err = service.ContentFilterApplyText("", model.ContentFilterScopeNickname, data.Nickname); err != nil {
+		c.Status(http.StatusBadRequest)
+		return c.JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if data.Brief, err = service.ContentFilterApplyText("", model.ContentFilterScopeNickname, data.Brief); err != nil {
+		c.Status(http.StatusBadRequest)
+		return c.JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
 	u := getCurUser(c)
 	db := model.GetDB()
 	u2 := &model.UserModel{}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/service"
+)
+
+func userBlockErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrUserBlockSelf), errors.Is(err, service.ErrUserBlockLimit),
+		errors.Is(err, service.ErrUserPrivacyInvalid):
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrUserBlockTargetInvalid):
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	}
+	return wrapError(c, err, fallback)
+}
+
+func UserBlockListHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	items, err := service.UserBlockList(user.ID)
+	if err != nil {
+		return userBlockErrorResponse(c, err, "获取屏蔽列表失败")
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func UserBlockCreateHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		UserID string `json:"userId"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": "请求参数错误"})
+	}
+	item, err := service.UserBlock(user.ID, body.UserID)
+	if err != nil {
+		return userBlockErrorResponse(c, err, "屏蔽失败")
+	}
+	return c.JSON(fiber.Map{"item": item})
+}
+
+func UserBlockDeleteHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if err := service.UserUnblock(user.ID, c.Params("userId")); err != nil {
+		return userBlockErrorResponse(c, err, "取消屏蔽失败")
+	}
+	return c.JSON(fiber.Map{"message": "已取消屏蔽"})
+}
+
+func UserPrivacyGetHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	return c.JSON(fiber.Map{"privacy": service.GetUserPrivacySettings(user.ID)})
+}
+
+func UserPrivacyUpdateHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body service.UserPrivacySettings
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": "请求参数错误"})
+	}
+	settings, err := service.UpdateUserPrivacySettings(user.ID, body)
+	if err != nil {
+		return userBlockErrorResponse(c, err, "保存隐私设置失败")
+	}
+	return c.JSON(fiber.Map{"privacy": settings})
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"testing"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
+)
+
+func TestBlockedSenderMarkerMarksOnlyBlockingReceivers(t *testing.T) {
+	initOneBotAPITestEnv(t)
+	sender := createOneBotTestUser(t, "marker-sender", false, "")
+	blocker := createOneBotTestUser(t, "marker-blocker", false, "")
+	other := createOneBotTestUser(t, "marker-other", false, "")
+	if _, err := service.UserBlock(blocker.ID, sender.ID); err != nil {
+		t.Fatalf("block failed: %v", err)
+	}
+
+	ev := &protocol.Event{
+		Type: protocol.EventMessageCreated,
+		Message: &protocol.Message{
+			ID:    "marker-msg",
+			User:  &protocol.User{ID: sender.ID},
+			Quote: &protocol.Message{ID: "marker-quote", User: &protocol.User{ID: other.ID}},
+		},
+	}
+	marker := newBlockedSenderMarker(ev)
+	if got := marker.eventFor(other.ID, ev); got != ev || got.Message.SenderBlocked {
+		t.Fatalf("receivers who did not block should get the shared event")
+	}
+	got := marker.eventFor(blocker.ID, ev)
+	if !got.Message.SenderBlocked || got.Message.Quote.SenderBlocked {
+		t.Fatalf("blocker should see the sender marked: %+v", got.Message)
+	}
+	if ev.Message.SenderBlocked {
+		t.Fatal("marking must not mutate the shared event")
+	}
+
+	quoted := &protocol.Event{Message: &protocol.Message{User: &protocol.User{ID: other.ID}, Quote: &protocol.Message{User: &protocol.User{ID: sender.ID}}}}
+	got = newBlockedSenderMarker(quoted).eventFor(blocker.ID, quoted)
+	if got.Message.SenderBlocked || !got.Message.Quote.SenderBlocked || quoted.Message.Quote.SenderBlocked {
+		t.Fatalf("only the blocked quote should be marked: %+v", got.Message.Quote)
+	}
+
+	msgs := []*model.MessageModel{{UserID: sender.ID}, {UserID: other.ID}}
+	markMessagesFromBlockedUsers(msgs, blocker.ID)
+	if !msgs[0].SenderBlocked || msgs[1].SenderBlocked {
+		t.Fatalf("unexpected list marking: %+v %+v", msgs[0], msgs[1])
+	}
+}
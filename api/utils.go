@@ -12,6 +12,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"sealchat/pm/gen"
+	"sealchat/service"
 	"sealchat/utils"
 	"strings"
 	"sync"
@@ -43,12 +44,19 @@ func copyZeroAlloc(w io.Writer, r io.Reader) (int64, error) {
 // ErrFileTooLarge is returned when uploaded file exceeds size limit
 var ErrFileTooLarge = errors.New("文件大小超过限制")
 
+// ErrImageMetadataStripFailed 开启元数据清理时图片结构无法解析
+var ErrImageMetadataStripFailed = errors.New("图片元数据清理失败，请转换格式后重新上传")
+
 // SaveMultipartFileResult contains the result of saving a multipart file
 type SaveMultipartFileResult struct {
 	Hash       []byte
 	Size       int64
 	MimeType   string // Final MIME type after conversion (e.g., image/webp)
 	IsAnimated bool   // Whether the image is animated (e.g., animated WebP from GIF)
+	// 已清除 EXIF 等元数据
+	MetadataStripped bool
+	// 图片尺寸与 ThumbHash 占位图
+	Placeholder utils.ImagePlaceholder
 }
 
 func SaveMultipartFile(fh *multipart.FileHeader, fOut afero.File, limit int64) (result SaveMultipartFileResult, err error) {
@@ -98,16 +106,20 @@ func SaveMultipartFile(fh *multipart.FileHeader, fOut afero.File, limit int64) (
 			return SaveMultipartFileResult{Hash: hash, Size: size, MimeType: mimeType}, err
 		}
 
+		data, stripped, err := sanitizeUploadImage(data, mimeType)
+		if err != nil {
+			return SaveMultipartFileResult{}, err
+		}
 		compressed, finalMime, ok, isAnimated, compErr := tryCompressImage(data, mimeType, appConfig.ImageCompressQuality)
 		if compErr != nil {
 			return SaveMultipartFileResult{}, compErr
 		}
 		if ok && len(compressed) > 0 {
 			hash, size, err := copyWithHash(fOut, bytes.NewReader(compressed))
-			return SaveMultipartFileResult{Hash: hash, Size: size, MimeType: finalMime, IsAnimated: isAnimated}, err
+			return SaveMultipartFileResult{Hash: hash, Size: size, MimeType: finalMime, IsAnimated: isAnimated, MetadataStripped: stripped, Placeholder: service.ComputeImagePlaceholderData(compressed, finalMime)}, err
 		}
 		hash, size, err := copyWithHash(fOut, bytes.NewReader(data))
-		return SaveMultipartFileResult{Hash: hash, Size: size, MimeType: mimeType, IsAnimated: isAnimated}, err
+		return SaveMultipartFileResult{Hash: hash, Size: size, MimeType: mimeType, IsAnimated: isAnimated, MetadataStripped: stripped, Placeholder: service.ComputeImagePlaceholderData(data, mimeType)}, err
 	}
 
 	// For non-image files, also check size limit
@@ -119,8 +131,25 @@ func SaveMultipartFile(fh *multipart.FileHeader, fOut afero.File, limit int64) (
 	if int64(len(data)) > limit {
 		return SaveMultipartFileResult{}, ErrFileTooLarge
 	}
+	data, stripped, err := sanitizeUploadImage(data, mimeType)
+	if err != nil {
+		return SaveMultipartFileResult{}, err
+	}
 	hash, size, err := copyWithHash(fOut, bytes.NewReader(data))
-	return SaveMultipartFileResult{Hash: hash, Size: size, MimeType: mimeType}, err
+	return SaveMultipartFileResult{Hash: hash, Size: size, MimeType: mimeType, MetadataStripped: stripped, Placeholder: service.ComputeImagePlaceholderData(data, mimeType)}, err
+}
+
+// sanitizeUploadImage 按配置清除图片元数据；哈希在清理之后计算，去重以清理后的内容为准。
+// 开关开启时清理失败直接拒绝上传，避免原图带着元数据落盘
+func sanitizeUploadImage(data []byte, mimeType string) ([]byte, bool, error) {
+	if appConfig == nil || !appConfig.ImageStripMetadata || !utils.IsMetadataSanitizableImage(mimeType) {
+		return data, false, nil
+	}
+	result, err := utils.SanitizeImageMetadata(data)
+	if err != nil {
+		return nil, false, ErrImageMetadataStripFailed
+	}
+	return result.Data, true, nil
 }
 
 func copyWithHash(dst io.Writer, src io.Reader) ([]byte, int64, error) {
@@ -0,0 +1,589 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/pm"
+	"sealchat/protocol"
+	"sealchat/utils"
+)
+
+// 语音频道信令：服务端只负责会话登记与 offer/answer/ice 转发，媒体流由客户端之间直连，
+// 人数超过 mesh 上限时交由外部 SFU 处理（本服务仅签发接入令牌）。
+
+const (
+	voiceSignalMaxSDPBytes       = 64 * 1024
+	voiceSignalMaxCandidateBytes = 2 * 1024
+	voiceSFUTokenTTL             = 10 * time.Minute
+)
+
+var (
+	ErrVoiceDisabled          = errors.New("语音功能未开启")
+	ErrVoiceChannelDisabled   = errors.New("该频道未开启语音")
+	ErrVoiceNotInChannel      = errors.New("请先进入该频道")
+	ErrVoicePermissionDenied  = errors.New("您没有加入语音的权限")
+	ErrVoiceRoomFull          = errors.New("语音人数已满")
+	ErrVoiceNotJoined         = errors.New("尚未加入语音")
+	ErrVoiceSessionNotFound   = errors.New("目标语音会话不存在")
+	ErrVoiceSignalInvalid     = errors.New("无效的语音信令")
+	ErrVoiceManageDenied      = errors.New("您没有管理语音成员的权限")
+	ErrVoiceMeshLimitExceeded = errors.New("语音人数已达直连上限，且未配置 SFU")
+)
+
+type voiceParticipant struct {
+	User  *model.UserModel
+	Conn  *WsSyncConn
+	State protocol.VoiceParticipantState
+}
+
+type voiceRoom struct {
+	mu           sync.Mutex
+	participants map[string]*voiceParticipant // sessionID -> participant
+}
+
+var voiceRooms = &utils.SyncMap[string, *voiceRoom]{}
+
+func voiceConfig() utils.VoiceConfig {
+	if appConfig == nil {
+		return utils.VoiceConfig{}
+	}
+	return appConfig.Voice
+}
+
+func voiceRoomLoad(channelID string, create bool) *voiceRoom {
+	if create {
+		room, _ := voiceRooms.LoadOrStore(channelID, &voiceRoom{participants: map[string]*voiceParticipant{}})
+		return room
+	}
+	room, ok := voiceRooms.Load(channelID)
+	if !ok {
+		return nil
+	}
+	return room
+}
+
+// voiceTopology 按当前人数决定连接方式
+func voiceTopology(count int, cfg utils.VoiceConfig) string {
+	meshMax := cfg.MeshMaxParticipants
+	if meshMax <= 0 {
+		meshMax = 6
+	}
+	if cfg.SFU.Enabled && cfg.SFU.URL != "" && count > meshMax {
+		return protocol.VoiceTopologySFU
+	}
+	return protocol.VoiceTopologyMesh
+}
+
+// voiceICEServers 组装下发给客户端的 ICE 列表；配置了 TURN 共享密钥时，
+// 为未填写静态凭据的 turn/turns 地址按 TURN REST 约定签发临时凭据。
+func voiceICEServers(cfg utils.VoiceConfig, userID string, now time.Time) []protocol.VoiceICEServer {
+	servers := make([]protocol.VoiceICEServer, 0, len(cfg.ICEServers))
+	for _, item := range cfg.ICEServers {
+		server := protocol.VoiceICEServer{
+			URLs:       append([]string(nil), item.URLs...),
+			Username:   item.Username,
+			Credential: item.Credential,
+		}
+		if server.Username == "" && cfg.TURNSecret != "" && voiceHasTURNURL(item.URLs) {
+			ttl := cfg.TURNCredentialTTL
+			if ttl <= 0 {
+				ttl = 86400
+			}
+			server.Username, server.Credential = voiceTURNCredential(cfg.TURNSecret, userID, now.Add(time.Duration(ttl)*time.Second))
+		}
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+func voiceHasTURNURL(urls []string) bool {
+	for _, u := range urls {
+		lower := strings.ToLower(u)
+		if strings.HasPrefix(lower, "turn:") || strings.HasPrefix(lower, "turns:") {
+			return true
+		}
+	}
+	return false
+}
+
+func voiceTURNCredential(secret, userID string, expireAt time.Time) (string, string) {
+	username := strconv.FormatInt(expireAt.Unix(), 10)
+	if userID != "" {
+		username += ":" + userID
+	}
+	mac := hmac.New(sha1.New, []byte(secret))
+	_, _ = mac.Write([]byte(username))
+	return username, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type voiceSFUClaims struct {
+	ChannelID string `json:"channelId"`
+	SessionID string `json:"sessionId"`
+	UserID    string `json:"userId"`
+	ExpireAt  int64  `json:"exp"`
+}
+
+// voiceSFUToken 签发外部 SFU 接入令牌，格式为 base64url(claims).base64url(hmac-sha256)
+func voiceSFUToken(secret string, claims voiceSFUClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(encoded))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (room *voiceRoom) snapshotLocked() []*protocol.VoiceParticipant {
+	items := make([]*protocol.VoiceParticipant, 0, len(room.participants))
+	for _, p := range room.participants {
+		var user *protocol.User
+		if p.User != nil {
+			user = p.User.ToProtocolType()
+		}
+		items = append(items, &protocol.VoiceParticipant{
+			User:                  user,
+			VoiceParticipantState: p.State,
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].JoinedAt != items[j].JoinedAt {
+			return items[i].JoinedAt < items[j].JoinedAt
+		}
+		return items[i].SessionID < items[j].SessionID
+	})
+	return items
+}
+
+func (room *voiceRoom) findByConnLocked(conn *WsSyncConn) *voiceParticipant {
+	for _, p := range room.participants {
+		if p.Conn == conn {
+			return p
+		}
+	}
+	return nil
+}
+
+// voiceStateForUser 供在线列表使用，同一用户多端加入时取最早加入的会话
+func voiceStateForUser(channelID, userID string) *protocol.VoiceParticipantState {
+	room := voiceRoomLoad(channelID, false)
+	if room == nil {
+		return nil
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	var found *voiceParticipant
+	for _, p := range room.participants {
+		if p.User == nil || p.User.ID != userID {
+			continue
+		}
+		if found == nil || p.State.JoinedAt < found.State.JoinedAt {
+			found = p
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	state := found.State
+	return &state
+}
+
+func writeVoiceEvent(conn *WsSyncConn, event *protocol.Event) {
+	if conn == nil || event == nil {
+		return
+	}
+	event.Timestamp = time.Now().Unix()
+	_ = conn.WriteJSON(struct {
+		protocol.Event
+		Op protocol.Opcode `json:"op"`
+	}{
+		Event: *event,
+		Op:    protocol.OpEvent,
+	})
+}
+
+func (ctx *ChatContext) broadcastVoiceState(channelID, action string) {
+	if ctx == nil || channelID == "" {
+		return
+	}
+	participants := []*protocol.VoiceParticipant{}
+	if room := voiceRoomLoad(channelID, false); room != nil {
+		room.mu.Lock()
+		participants = room.snapshotLocked()
+		room.mu.Unlock()
+	}
+	ctx.BroadcastEventInChannel(channelID, &protocol.Event{
+		Type:    protocol.EventVoiceStateUpdated,
+		Channel: &protocol.Channel{ID: channelID},
+		Voice: &protocol.VoiceEventPayload{
+			ChannelID:    channelID,
+			Action:       action,
+			Topology:     voiceTopology(len(participants), voiceConfig()),
+			Participants: participants,
+		},
+	})
+	ctx.BroadcastChannelPresence(channelID)
+}
+
+// voiceLeaveConn 连接断开或切换频道时移除其语音会话
+func voiceLeaveConn(ctx *ChatContext, conn *WsSyncConn, exceptChannelID string) {
+	if conn == nil {
+		return
+	}
+	var affected []string
+	voiceRooms.Range(func(channelID string, room *voiceRoom) bool {
+		if channelID == exceptChannelID {
+			return true
+		}
+		room.mu.Lock()
+		if p := room.findByConnLocked(conn); p != nil {
+			delete(room.participants, p.State.SessionID)
+			affected = append(affected, channelID)
+		}
+		if len(room.participants) == 0 {
+			voiceRooms.Delete(channelID)
+		}
+		room.mu.Unlock()
+		return true
+	})
+	for _, channelID := range affected {
+		ctx.broadcastVoiceState(channelID, "leave")
+	}
+}
+
+// voiceRoomClose 频道关闭语音时清空会话
+func voiceRoomClose(ctx *ChatContext, channelID string) {
+	room, ok := voiceRooms.LoadAndDelete(channelID)
+	if !ok || room == nil {
+		return
+	}
+	room.mu.Lock()
+	count := len(room.participants)
+	room.participants = map[string]*voiceParticipant{}
+	room.mu.Unlock()
+	if count > 0 {
+		ctx.broadcastVoiceState(channelID, "close")
+	}
+}
+
+func voiceLoadChannel(channelID string) (*model.ChannelModel, error) {
+	channel, err := model.ChannelGet(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil || channel.ID == "" {
+		return nil, fmt.Errorf("频道不存在")
+	}
+	if channel.IsPrivate || !channel.VoiceEnabled {
+		return nil, ErrVoiceChannelDisabled
+	}
+	return channel, nil
+}
+
+func apiVoiceJoin(ctx *ChatContext, data *struct {
+	ChannelID string `json:"channel_id"`
+}) (any, error) {
+	cfg := voiceConfig()
+	if !cfg.Enabled {
+		return nil, ErrVoiceDisabled
+	}
+	channelID := strings.TrimSpace(data.ChannelID)
+	if channelID == "" {
+		return nil, fmt.Errorf("频道ID不能为空")
+	}
+	if ctx.ConnInfo == nil || ctx.ConnInfo.ChannelId != channelID {
+		return nil, ErrVoiceNotInChannel
+	}
+	if _, err := voiceLoadChannel(channelID); err != nil {
+		return nil, err
+	}
+	if !pm.CanWithChannelRole(ctx.User.ID, channelID, pm.PermFuncChannelVoiceJoin) {
+		return nil, ErrVoicePermissionDenied
+	}
+
+	// 同一连接同时只保留一个语音会话
+	voiceLeaveConn(ctx, ctx.Conn, channelID)
+
+	room := voiceRoomLoad(channelID, true)
+	room.mu.Lock()
+	participant := room.findByConnLocked(ctx.Conn)
+	if participant == nil {
+		count := len(room.participants) + 1
+		if cfg.MaxParticipants > 0 && count > cfg.MaxParticipants {
+			room.mu.Unlock()
+			return nil, ErrVoiceRoomFull
+		}
+		if !cfg.SFU.Enabled && count > cfg.MeshMaxParticipants {
+			room.mu.Unlock()
+			return nil, ErrVoiceMeshLimitExceeded
+		}
+		participant = &voiceParticipant{
+			User: ctx.User,
+			Conn: ctx.Conn,
+			State: protocol.VoiceParticipantState{
+				SessionID: utils.NewID(),
+				JoinedAt:  time.Now().UnixMilli(),
+			},
+		}
+		room.participants[participant.State.SessionID] = participant
+		// 房间可能在加锁前被清理，确保登记的是当前实例
+		voiceRooms.Store(channelID, room)
+	}
+	sessionID := participant.State.SessionID
+	participants := room.snapshotLocked()
+	room.mu.Unlock()
+
+	topology := voiceTopology(len(participants), cfg)
+	resp := &struct {
+		SessionID    string                       `json:"sessionId"`
+		Topology     string                       `json:"topology"`
+		ICEServers   []protocol.VoiceICEServer    `json:"iceServers"`
+		Participants []*protocol.VoiceParticipant `json:"participants"`
+		SFUURL       string                       `json:"sfuUrl,omitempty"`
+		SFUToken     string                       `json:"sfuToken,omitempty"`
+	}{
+		SessionID:    sessionID,
+		Topology:     topology,
+		ICEServers:   voiceICEServers(cfg, ctx.User.ID, time.Now()),
+		Participants: participants,
+	}
+	if topology == protocol.VoiceTopologySFU {
+		token, err := voiceSFUToken(cfg.SFU.Secret, voiceSFUClaims{
+			ChannelID: channelID,
+			SessionID: sessionID,
+			UserID:    ctx.User.ID,
+			ExpireAt:  time.Now().Add(voiceSFUTokenTTL).Unix(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		resp.SFUURL = cfg.SFU.URL
+		resp.SFUToken = token
+	}
+
+	ctx.broadcastVoiceState(channelID, "join")
+	return resp, nil
+}
+
+func apiVoiceLeave(ctx *ChatContext, data *struct {
+	ChannelID string `json:"channel_id"`
+}) (any, error) {
+	channelID := strings.TrimSpace(data.ChannelID)
+	room := voiceRoomLoad(channelID, false)
+	if room == nil {
+		return &struct {
+			Success bool `json:"success"`
+		}{Success: true}, nil
+	}
+	room.mu.Lock()
+	removed := false
+	if p := room.findByConnLocked(ctx.Conn); p != nil {
+		delete(room.participants, p.State.SessionID)
+		removed = true
+	}
+	if len(room.participants) == 0 {
+		voiceRooms.Delete(channelID)
+	}
+	room.mu.Unlock()
+	if removed {
+		ctx.broadcastVoiceState(channelID, "leave")
+	}
+	return &struct {
+		Success bool `json:"success"`
+	}{Success: true}, nil
+}
+
+func apiVoiceSignal(ctx *ChatContext, data *struct {
+	ChannelID   string                   `json:"channel_id"`
+	ToSessionID string                   `json:"to_session_id"`
+	Kind        string                   `json:"kind"`
+	SDP         string                   `json:"sdp"`
+	Candidate   *protocol.VoiceCandidate `json:"candidate"`
+}) (any, error) {
+	channelID := strings.TrimSpace(data.ChannelID)
+	switch data.Kind {
+	case protocol.VoiceSignalOffer, protocol.VoiceSignalAnswer:
+		if data.SDP == "" || len(data.SDP) > voiceSignalMaxSDPBytes {
+			return nil, ErrVoiceSignalInvalid
+		}
+	case protocol.VoiceSignalICE:
+		// candidate 为空表示 end-of-candidates
+		if data.Candidate != nil && len(data.Candidate.Candidate) > voiceSignalMaxCandidateBytes {
+			return nil, ErrVoiceSignalInvalid
+		}
+	default:
+		return nil, ErrVoiceSignalInvalid
+	}
+
+	room := voiceRoomLoad(channelID, false)
+	if room == nil {
+		return nil, ErrVoiceNotJoined
+	}
+	room.mu.Lock()
+	sender := room.findByConnLocked(ctx.Conn)
+	target := room.participants[data.ToSessionID]
+	var fromSessionID string
+	var targetConn *WsSyncConn
+	if sender != nil {
+		fromSessionID = sender.State.SessionID
+	}
+	if target != nil {
+		targetConn = target.Conn
+	}
+	room.mu.Unlock()
+	if sender == nil {
+		return nil, ErrVoiceNotJoined
+	}
+	if target == nil || target == sender {
+		return nil, ErrVoiceSessionNotFound
+	}
+
+	writeVoiceEvent(targetConn, &protocol.Event{
+		Type:    protocol.EventVoiceSignal,
+		Channel: &protocol.Channel{ID: channelID},
+		User:    ctx.User.ToProtocolType(),
+		Voice: &protocol.VoiceEventPayload{
+			ChannelID: channelID,
+			Signal: &protocol.VoiceSignal{
+				Kind:          data.Kind,
+				FromSessionID: fromSessionID,
+				FromUserID:    ctx.User.ID,
+				ToSessionID:   data.ToSessionID,
+				SDP:           data.SDP,
+				Candidate:     data.Candidate,
+			},
+		},
+	})
+	return &struct {
+		Success bool `json:"success"`
+	}{Success: true}, nil
+}
+
+func apiVoiceStateUpdate(ctx *ChatContext, data *struct {
+	ChannelID string `json:"channel_id"`
+	Muted     *bool  `json:"muted"`
+	Deafened  *bool  `json:"deafened"`
+	Speaking  *bool  `json:"speaking"`
+}) (any, error) {
+	channelID := strings.TrimSpace(data.ChannelID)
+	room := voiceRoomLoad(channelID, false)
+	if room == nil {
+		return nil, ErrVoiceNotJoined
+	}
+	room.mu.Lock()
+	p := room.findByConnLocked(ctx.Conn)
+	if p == nil {
+		room.mu.Unlock()
+		return nil, ErrVoiceNotJoined
+	}
+	prev := p.State
+	if data.Muted != nil {
+		p.State.Muted = *data.Muted
+	}
+	if data.Deafened != nil {
+		p.State.Deafened = *data.Deafened
+		// 闭听时一并闭麦
+		if p.State.Deafened {
+			p.State.Muted = true
+		}
+	}
+	if data.Speaking != nil {
+		p.State.Speaking = *data.Speaking
+	}
+	if p.State.Muted || p.State.ServerMuted {
+		p.State.Speaking = false
+	}
+	state := p.State
+	room.mu.Unlock()
+
+	if state != prev {
+		ctx.broadcastVoiceState(channelID, "update")
+	}
+	return &struct {
+		State protocol.VoiceParticipantState `json:"state"`
+	}{State: state}, nil
+}
+
+func apiVoiceModerate(ctx *ChatContext, data *struct {
+	ChannelID   string `json:"channel_id"`
+	SessionID   string `json:"session_id"`
+	ServerMuted *bool  `json:"server_muted"`
+	Remove      bool   `json:"remove"`
+}) (any, error) {
+	channelID := strings.TrimSpace(data.ChannelID)
+	if !pm.CanWithChannelRole(ctx.User.ID, channelID, pm.PermFuncChannelVoiceManage) {
+		return nil, ErrVoiceManageDenied
+	}
+	room := voiceRoomLoad(channelID, false)
+	if room == nil {
+		return nil, ErrVoiceSessionNotFound
+	}
+	room.mu.Lock()
+	p := room.participants[data.SessionID]
+	if p == nil {
+		room.mu.Unlock()
+		return nil, ErrVoiceSessionNotFound
+	}
+	targetConn := p.Conn
+	if data.Remove {
+		delete(room.participants, data.SessionID)
+		if len(room.participants) == 0 {
+			voiceRooms.Delete(channelID)
+		}
+	} else if data.ServerMuted != nil {
+		p.State.ServerMuted = *data.ServerMuted
+		if p.State.ServerMuted {
+			p.State.Speaking = false
+		}
+	}
+	room.mu.Unlock()
+
+	if data.Remove {
+		writeVoiceEvent(targetConn, &protocol.Event{
+			Type:     protocol.EventVoiceStateUpdated,
+			Channel:  &protocol.Channel{ID: channelID},
+			Operator: ctx.User.ToProtocolType(),
+			Voice: &protocol.VoiceEventPayload{
+				ChannelID: channelID,
+				Action:    "removed",
+			},
+		})
+	}
+	ctx.broadcastVoiceState(channelID, "moderate")
+	return &struct {
+		Success bool `json:"success"`
+	}{Success: true}, nil
+}
+
+// VoiceICEConfig 供客户端在加入前预取 ICE 配置
+func VoiceICEConfig(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "未认证"})
+	}
+	cfg := voiceConfig()
+	if !cfg.Enabled {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": ErrVoiceDisabled.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"iceServers":          voiceICEServers(cfg, user.ID, time.Now()),
+		"meshMaxParticipants": cfg.MeshMaxParticipants,
+		"maxParticipants":     cfg.MaxParticipants,
+		"sfuEnabled":          cfg.SFU.Enabled,
+	})
+}
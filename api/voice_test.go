@@ -0,0 +1,78 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"sealchat/protocol"
+	"sealchat/utils"
+)
+
+func TestVoiceTopology(t *testing.T) {
+	cfg := utils.VoiceConfig{MeshMaxParticipants: 4}
+	if got := voiceTopology(8, cfg); got != protocol.VoiceTopologyMesh {
+		t.Fatalf("expected mesh without sfu, got %s", got)
+	}
+	cfg.SFU = utils.VoiceSFUConfig{Enabled: true, URL: "wss://sfu.example.com"}
+	if got := voiceTopology(4, cfg); got != protocol.VoiceTopologyMesh {
+		t.Fatalf("expected mesh within limit, got %s", got)
+	}
+	if got := voiceTopology(5, cfg); got != protocol.VoiceTopologySFU {
+		t.Fatalf("expected sfu above mesh limit, got %s", got)
+	}
+}
+
+func TestVoiceICEServersTURNCredential(t *testing.T) {
+	cfg := utils.VoiceConfig{
+		TURNSecret:        "secret",
+		TURNCredentialTTL: 60,
+		ICEServers: []utils.VoiceICEServerConfig{
+			{URLs: []string{"stun:stun.example.com"}},
+			{URLs: []string{"turn:turn.example.com:3478"}},
+			{URLs: []string{"turns:turn.example.com"}, Username: "static", Credential: "pass"},
+		},
+	}
+	now := time.Unix(1700000000, 0)
+	servers := voiceICEServers(cfg, "u1", now)
+	if len(servers) != 3 {
+		t.Fatalf("expected 3 servers, got %d", len(servers))
+	}
+	if servers[0].Username != "" || servers[0].Credential != "" {
+		t.Fatalf("stun server should not carry credentials")
+	}
+	if servers[1].Username != "1700000060:u1" || servers[1].Credential == "" {
+		t.Fatalf("unexpected turn credential: %+v", servers[1])
+	}
+	if _, cred := voiceTURNCredential("secret", "u1", now.Add(time.Minute)); cred != servers[1].Credential {
+		t.Fatalf("turn credential should be deterministic")
+	}
+	if servers[2].Username != "static" || servers[2].Credential != "pass" {
+		t.Fatalf("static credential should be kept, got %+v", servers[2])
+	}
+}
+
+func TestVoiceSFUToken(t *testing.T) {
+	token, err := voiceSFUToken("secret", voiceSFUClaims{ChannelID: "c1", SessionID: "s1", UserID: "u1", ExpireAt: 100})
+	if err != nil {
+		t.Fatalf("sign token failed: %v", err)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		t.Fatalf("unexpected token format: %s", token)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	_, _ = mac.Write([]byte(parts[0]))
+	if base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) != parts[1] {
+		t.Fatalf("token signature mismatch")
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	var claims voiceSFUClaims
+	if err := json.Unmarshal(raw, &claims); err != nil || claims.SessionID != "s1" || claims.ExpireAt != 100 {
+		t.Fatalf("unexpected claims: %+v err=%v", claims, err)
+	}
+}
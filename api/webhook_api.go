@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -96,21 +97,14 @@ func WebhookChanges(c *fiber.Ctx) error {
 
 	excludeSource := strings.TrimSpace(c.Query("excludeSource"))
 
-	db := model.GetDB()
 	if !hasCursor {
-		var maxSeq int64
-		_ = db.Model(&model.WebhookEventLogModel{}).Where("channel_id = ?", channelID).Select("MAX(seq)").Scan(&maxSeq).Error
+		maxSeq, _ := model.WebhookEventLogMaxSeq(channelID)
 		if maxSeq > int64(limit) {
 			cursor = maxSeq - int64(limit)
 		}
 	}
-	q := db.Where("channel_id = ? AND seq > ?", channelID, cursor)
-	if excludeSource != "" {
-		q = q.Where("(source IS NULL OR source = '' OR source <> ?)", excludeSource)
-	}
-
-	var logs []model.WebhookEventLogModel
-	if err := q.Order("seq ASC").Limit(limit).Find(&logs).Error; err != nil {
+	logs, err := model.WebhookEventLogListAfter(channelID, cursor, limit, excludeSource)
+	if err != nil {
 		return wrapError(c, err, "读取变更流失败")
 	}
 
@@ -124,7 +118,7 @@ func WebhookChanges(c *fiber.Ctx) error {
 	msgByID := map[string]*model.MessageModel{}
 	if len(messageIDs) > 0 {
 		var messages []*model.MessageModel
-		if err := db.Preload("User", func(db *gorm.DB) *gorm.DB {
+		if err := model.GetDB().Preload("User", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, username, nickname, avatar, is_bot")
 		}).Preload("Member", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, nickname, channel_id, user_id")
@@ -371,12 +365,31 @@ func webhookMessageUpsert(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 }
 
 func webhookMessageCreate(c *fiber.Ctx, integration *model.ChannelWebhookIntegrationModel, botUser *model.UserModel, channel *model.ChannelModel, req *webhookWriteRequest) error {
+	messageID, err := webhookCreateMessage(integration, botUser, channel, req)
+	if err != nil {
+		return respondWebhookOpError(c, err)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"ok": true,
+		"result": fiber.Map{
+			"messageId": messageID,
+			"created":   true,
+			"updated":   false,
+		},
+	})
+}
+
+// webhookCreateMessage 以集成机器人身份写入消息，供 HTTP 接口与 QQ 桥接共用
+func webhookCreateMessage(integration *model.ChannelWebhookIntegrationModel, botUser *model.UserModel, channel *model.ChannelModel, req *webhookWriteRequest) (string, error) {
 	if req == nil || req.Message == nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"ok": false, "error": "bad_request", "message": "message 不能为空"})
+		return "", webhookOpFail(http.StatusBadRequest, "bad_request", "message 不能为空")
 	}
 	content := strings.TrimSpace(req.Message.Content)
 	if content == "" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"ok": false, "error": "bad_request", "message": "message.content 不能为空"})
+		return "", webhookOpFail(http.StatusBadRequest, "bad_request", "message.content 不能为空")
+	}
+	if err := service.CheckChannelSendMuted(channel.ID, botUser.ID); err != nil {
+		return "", webhookOpFail(http.StatusForbidden, "forbidden", err.Error())
 	}
 
 	// BOT 入站：CQ 码转换为 Satori XML
@@ -384,6 +397,12 @@ func webhookMessageCreate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 	content = fillBotMentionNames(channel.ID, content)
 	content = protocol.EscapeSatoriText(content)
 
+	filterResult := service.ContentFilterCheck(channel.WorldID, model.ContentFilterScopeMessage, content)
+	if filterResult.Blocked() || filterResult.NeedsApproval() {
+		return "", webhookOpFail(http.StatusForbidden, "forbidden", service.ErrContentFilterBlocked.Error())
+	}
+	content = filterResult.Text
+
 	icMode := strings.ToLower(strings.TrimSpace(req.Message.ICMode))
 	if isExternalBotIncomingUser(botUser) {
 		icMode = resolveExternalBotIncomingICMode(icMode, content)
@@ -392,7 +411,7 @@ func webhookMessageCreate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 		icMode = "ic"
 	}
 	if icMode != "ic" && icMode != "ooc" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"ok": false, "error": "bad_request", "message": "message.icMode 仅支持 ic/ooc"})
+		return "", webhookOpFail(http.StatusBadRequest, "bad_request", "message.icMode 仅支持 ic/ooc")
 	}
 
 	source, externalID := webhookResolveExternalRef(req)
@@ -403,15 +422,9 @@ func webhookMessageCreate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 		id, actorID, err := webhookResolveIdentityID(integration, botUser, channel.ID, req.Identity)
 		if err != nil {
 			if fe, ok := err.(*fiber.Error); ok {
-				errType := "bad_request"
-				if fe.Code == http.StatusForbidden {
-					errType = "forbidden"
-				} else if fe.Code == http.StatusNotFound {
-					errType = "not_found"
-				}
-				return c.Status(fe.Code).JSON(fiber.Map{"ok": false, "error": errType, "message": fe.Message})
+				return "", webhookOpFail(fe.Code, webhookErrorTypeByStatus(fe.Code), fe.Message)
 			}
-			return wrapError(c, err, "处理身份失败")
+			return "", webhookOpWrap(err, "处理身份失败")
 		}
 		identityID = id
 		externalActorID = actorID
@@ -419,12 +432,12 @@ func webhookMessageCreate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 
 	quoteID, err := webhookResolveQuoteID(channel.ID, source, req.Message.QuoteExternalID, req.Message.QuoteMessageID)
 	if err != nil {
-		return wrapError(c, err, "解析引用消息失败")
+		return "", webhookOpWrap(err, "解析引用消息失败")
 	}
 
 	member, err := model.MemberGetByUserIDAndChannelIDBase(botUser.ID, channel.ID, botUser.Nickname, true)
 	if err != nil {
-		return wrapError(c, err, "创建频道成员失败")
+		return "", webhookOpWrap(err, "创建频道成员失败")
 	}
 
 	now := time.Now()
@@ -437,7 +450,7 @@ func webhookMessageCreate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 	if service.IsBuiltInDiceEffectivelyEnabled(channel) {
 		renderResult, err = service.RenderDiceContent(content, channel.DefaultDiceExpr, nil)
 		if err != nil {
-			return wrapError(c, err, "渲染骰点失败")
+			return "", webhookOpWrap(err, "渲染骰点失败")
 		}
 		if renderResult != nil {
 			content = renderResult.Content
@@ -460,7 +473,7 @@ func webhookMessageCreate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 	if identityID != "" {
 		identity, err := service.ChannelIdentityValidateMessageIdentity(botUser.ID, channel.ID, identityID)
 		if err != nil {
-			return wrapError(c, err, "身份校验失败")
+			return "", webhookOpWrap(err, "身份校验失败")
 		}
 		if identity != nil {
 			msg.SenderRoleID = identity.ID
@@ -479,7 +492,7 @@ func webhookMessageCreate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 
 	db := model.GetDB()
 	if err := db.Create(msg).Error; err != nil {
-		return wrapError(c, err, "创建消息失败")
+		return "", webhookOpWrap(err, "创建消息失败")
 	}
 
 	if source != "" && externalID != "" {
@@ -487,6 +500,9 @@ func webhookMessageCreate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 	}
 	_ = model.WebhookEventLogAppendForMessage(channel.ID, "message-created", msg.ID)
 	notifyAppMessageCreated(msg.ID)
+	if len(filterResult.FlagRuleIDs) > 0 {
+		go flagMessageByContentFilter(msg.ID, filterResult.FlagRuleIDs)
+	}
 	go func(channelID string, message model.MessageModel) {
 		if err := service.RecordDigestWindowMessage(channelID, &message); err != nil {
 			log.Printf("digest-push: 记录 webhook 消息摘要窗口失败 channel=%s message=%s err=%v", channelID, message.ID, err)
@@ -533,14 +549,7 @@ func webhookMessageCreate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 	channel.UpdateRecentSent()
 	member.UpdateRecentSent()
 
-	return c.Status(http.StatusOK).JSON(fiber.Map{
-		"ok": true,
-		"result": fiber.Map{
-			"messageId": msg.ID,
-			"created":   true,
-			"updated":   false,
-		},
-	})
+	return msg.ID, nil
 }
 
 func webhookMessageUpdate(c *fiber.Ctx, integration *model.ChannelWebhookIntegrationModel, botUser *model.UserModel, channel *model.ChannelModel, req *webhookWriteRequest) error {
@@ -621,6 +630,9 @@ func webhookMessageUpdate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 		IdentityVariantID: nil,
 	}
 	_, err := apiMessageUpdate(ctx, data)
+	if errors.Is(err, service.ErrContentFilterBlocked) {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"ok": false, "error": "forbidden", "message": err.Error()})
+	}
 	if err != nil {
 		return wrapError(c, err, "更新消息失败")
 	}
@@ -636,6 +648,20 @@ func webhookMessageUpdate(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 }
 
 func webhookMessageDelete(c *fiber.Ctx, integration *model.ChannelWebhookIntegrationModel, botUser *model.UserModel, channel *model.ChannelModel, req *webhookWriteRequest) error {
+	messageID, err := webhookDeleteMessage(integration, botUser, channel, req)
+	if err != nil {
+		return respondWebhookOpError(c, err)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"ok": true,
+		"result": fiber.Map{
+			"messageId": messageID,
+			"deleted":   true,
+		},
+	})
+}
+
+func webhookDeleteMessage(integration *model.ChannelWebhookIntegrationModel, botUser *model.UserModel, channel *model.ChannelModel, req *webhookWriteRequest) (string, error) {
 	messageID := ""
 	if req != nil && req.Message != nil {
 		messageID = strings.TrimSpace(req.Message.MessageID)
@@ -644,29 +670,29 @@ func webhookMessageDelete(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 	if messageID == "" && source != "" && externalID != "" {
 		ref, err := model.MessageExternalRefGet(channel.ID, source, externalID)
 		if err != nil {
-			return wrapError(c, err, "读取 externalRef 失败")
+			return "", webhookOpWrap(err, "读取 externalRef 失败")
 		}
 		if ref == nil || strings.TrimSpace(ref.MessageID) == "" {
-			return c.Status(http.StatusNotFound).JSON(fiber.Map{"ok": false, "error": "not_found", "message": "消息不存在"})
+			return "", webhookOpFail(http.StatusNotFound, "not_found", "消息不存在")
 		}
 		if strings.TrimSpace(ref.IntegrationID) != "" && ref.IntegrationID != integration.ID {
-			return c.Status(http.StatusForbidden).JSON(fiber.Map{"ok": false, "error": "forbidden", "message": "externalRef 属于其他授权"})
+			return "", webhookOpFail(http.StatusForbidden, "forbidden", "externalRef 属于其他授权")
 		}
 		messageID = ref.MessageID
 	}
 	if messageID == "" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"ok": false, "error": "bad_request", "message": "messageId 或 externalRef 必填其一"})
+		return "", webhookOpFail(http.StatusBadRequest, "bad_request", "messageId 或 externalRef 必填其一")
 	}
 
 	var msg model.MessageModel
 	if err := model.GetDB().Select("id, user_id, is_deleted, is_revoked").Where("id = ? AND channel_id = ?", messageID, channel.ID).Limit(1).Find(&msg).Error; err != nil {
-		return wrapError(c, err, "读取消息失败")
+		return "", webhookOpWrap(err, "读取消息失败")
 	}
 	if msg.ID == "" || msg.IsDeleted {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{"ok": false, "error": "not_found", "message": "消息不存在或已删除"})
+		return "", webhookOpFail(http.StatusNotFound, "not_found", "消息不存在或已删除")
 	}
 	if msg.UserID != botUser.ID {
-		return c.Status(http.StatusForbidden).JSON(fiber.Map{"ok": false, "error": "forbidden", "message": "仅允许删除自身消息"})
+		return "", webhookOpFail(http.StatusForbidden, "forbidden", "仅允许删除自身消息")
 	}
 
 	ctx := &ChatContext{
@@ -680,14 +706,51 @@ func webhookMessageDelete(c *fiber.Ctx, integration *model.ChannelWebhookIntegra
 	}
 	_, err := apiMessageRemove(ctx, data)
 	if err != nil {
-		return wrapError(c, err, "删除消息失败")
+		return "", webhookOpWrap(err, "删除消息失败")
 	}
+	return messageID, nil
+}
 
-	return c.Status(http.StatusOK).JSON(fiber.Map{
-		"ok": true,
-		"result": fiber.Map{
-			"messageId": messageID,
-			"deleted":   true,
-		},
-	})
+// webhookOpError 记录写入失败时的 HTTP 状态，cause 非空时按 wrapError 的格式返回
+type webhookOpError struct {
+	status  int
+	errType string
+	message string
+	cause   error
+}
+
+func (e *webhookOpError) Error() string {
+	if e.cause != nil {
+		return e.message + ": " + e.cause.Error()
+	}
+	return e.message
+}
+
+func webhookOpFail(status int, errType, message string) error {
+	return &webhookOpError{status: status, errType: errType, message: message}
+}
+
+func webhookOpWrap(err error, message string) error {
+	return &webhookOpError{status: http.StatusBadRequest, message: message, cause: err}
+}
+
+func webhookErrorTypeByStatus(status int) string {
+	switch status {
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	}
+	return "bad_request"
+}
+
+func respondWebhookOpError(c *fiber.Ctx, err error) error {
+	var opErr *webhookOpError
+	if !errors.As(err, &opErr) {
+		return wrapError(c, err, "操作失败")
+	}
+	if opErr.cause != nil {
+		return wrapError(c, opErr.cause, opErr.message)
+	}
+	return c.Status(opErr.status).JSON(fiber.Map{"ok": false, "error": opErr.errType, "message": opErr.message})
 }
@@ -146,6 +146,19 @@ func WebhookIntegrationCreate(c *fiber.Ctx) error {
 		body.Capabilities = []string{"read_changes"}
 	}
 
+	integration, tokenValue, err := createChannelWebhookIntegration(channelID, name, source, getCurUser(c).ID, body.Capabilities, body.ExpiresInDays)
+	if err != nil {
+		return respondWebhookOpError(c, err)
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"item":  buildWebhookIntegrationDTO(integration),
+		"token": tokenValue, // 仅返回一次
+	})
+}
+
+// createChannelWebhookIntegration 创建频道 webhook 专用机器人、token 与授权记录，token 明文仅在此返回
+func createChannelWebhookIntegration(channelID, name, source, createdBy string, capabilities []string, expiresDays int) (*model.ChannelWebhookIntegrationModel, string, error) {
 	uid := utils.NewID()
 	nickColor := ""
 	user := &model.UserModel{
@@ -162,10 +175,9 @@ func WebhookIntegrationCreate(c *fiber.Ctx) error {
 
 	db := model.GetDB()
 	if err := db.Create(user).Error; err != nil {
-		return wrapError(c, err, "创建 bot 失败")
+		return nil, "", webhookOpWrap(err, "创建 bot 失败")
 	}
 
-	expiresDays := body.ExpiresInDays
 	if expiresDays <= 0 {
 		expiresDays = 365 * 3
 	}
@@ -179,7 +191,7 @@ func WebhookIntegrationCreate(c *fiber.Ctx) error {
 		ExpiresAt:         time.Now().UnixMilli() + int64(expiresDays)*24*60*60*1e3,
 	}
 	if err := db.Create(token).Error; err != nil {
-		return wrapError(c, err, "创建 token 失败")
+		return nil, "", webhookOpWrap(err, "创建 token 失败")
 	}
 	_ = service.SyncBotUserProfile(token)
 	_ = service.SyncBotMembers(token)
@@ -187,16 +199,12 @@ func WebhookIntegrationCreate(c *fiber.Ctx) error {
 	// 确保 bot 在频道内有成员记录，方便昵称与身份体系联动
 	_, _ = model.MemberGetByUserIDAndChannelIDBase(user.ID, channelID, name, true)
 
-	createdBy := getCurUser(c).ID
-	integration, err := model.ChannelWebhookIntegrationCreate(channelID, name, source, user.ID, createdBy, body.Capabilities)
+	integration, err := model.ChannelWebhookIntegrationCreate(channelID, name, source, user.ID, createdBy, capabilities)
 	if err != nil {
-		return wrapError(c, err, "创建 webhook 授权失败")
+		return nil, "", webhookOpWrap(err, "创建 webhook 授权失败")
 	}
 
-	return c.Status(http.StatusCreated).JSON(fiber.Map{
-		"item":  buildWebhookIntegrationDTO(integration),
-		"token": tokenValue, // 仅返回一次
-	})
+	return integration, tokenValue, nil
 }
 
 func WebhookIntegrationRotate(c *fiber.Ctx) error {
@@ -258,13 +266,22 @@ func WebhookIntegrationRevoke(c *fiber.Ctx) error {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{"message": "授权不存在"})
 	}
 
+	if err := revokeChannelWebhookIntegration(integration); err != nil {
+		return wrapError(c, err, "撤销授权失败")
+	}
+
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// revokeChannelWebhookIntegration 撤销授权并使 token 失效，清理不再被引用的专用机器人
+func revokeChannelWebhookIntegration(integration *model.ChannelWebhookIntegrationModel) error {
 	tx := model.GetDB().Begin()
 	if tx.Error != nil {
-		return wrapError(c, tx.Error, "撤销授权失败")
+		return tx.Error
 	}
 	rollback := func(err error) error {
 		tx.Rollback()
-		return wrapError(c, err, "撤销授权失败")
+		return err
 	}
 
 	if err := tx.Model(&model.ChannelWebhookIntegrationModel{}).
@@ -280,9 +297,5 @@ func WebhookIntegrationRevoke(c *fiber.Ctx) error {
 	if _, err := model.CleanupOrphanSystemBotByUserIDTx(tx, integration.BotUserID); err != nil {
 		return rollback(err)
 	}
-	if err := tx.Commit().Error; err != nil {
-		return wrapError(c, err, "撤销授权失败")
-	}
-
-	return c.JSON(fiber.Map{"success": true})
+	return tx.Commit().Error
 }
@@ -421,7 +421,8 @@ func WorldUpdateHandler(c *fiber.Ctx) error {
 			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": "选择 BOT 掷骰时必须指定默认 BOT"})
 		case errors.Is(err, service.ErrWorldDefaultDiceBotInvalid):
 			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": "默认 BOT 不存在或不是机器人"})
-		case errors.Is(err, service.ErrWorldCursorThemeInvalid):
+		case errors.Is(err, service.ErrWorldCursorThemeInvalid), errors.Is(err, service.ErrWorldJoinQuestionsInvalid),
+			errors.Is(err, service.ErrContentFilterBlocked):
 			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
 		default:
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "更新世界失败"})
@@ -481,9 +482,16 @@ func WorldJoinHandler(c *fiber.Ctx) error {
 		}
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "获取世界失败"})
 	}
-	if world.Visibility == model.WorldVisibilityPrivate && !service.IsWorldAdmin(worldID, user.ID) {
+	isAdmin := service.IsWorldAdmin(worldID, user.ID)
+	if world.RequiresJoinApproval() && !isAdmin && !service.IsWorldMember(worldID, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "该世界需提交加入申请并经审核", "requiresApproval": true})
+	}
+	if world.Visibility == model.WorldVisibilityPrivate && !isAdmin {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "该世界仅通过邀请加入"})
 	}
+	if service.IsWorldBanned(worldID, user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": service.ErrWorldJoinBanned.Error()})
+	}
 	member, err := service.WorldJoin(worldID, user.ID, model.WorldRoleMember)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "加入失败"})
@@ -573,11 +581,12 @@ func WorldInviteCreateHandler(c *fiber.Ctx) error {
 		MaxUse     int    `json:"maxUse"`
 		Memo       string `json:"memo"`
 		Role       string `json:"role"`
+		JoinMode   string `json:"joinMode"`
 	}
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
 	}
-	invite, err := service.WorldInviteCreate(worldID, user.ID, body.TTLMinutes, body.MaxUse, body.Memo, body.Role)
+	invite, err := service.WorldInviteCreate(worldID, user.ID, body.TTLMinutes, body.MaxUse, body.Memo, body.Role, body.JoinMode)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrWorldPermission):
@@ -597,22 +606,37 @@ func WorldInviteConsumeHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "未登录"})
 	}
 	slug := c.Params("slug")
-	invite, world, member, alreadyJoined, err := service.WorldInviteConsume(slug, user.ID)
+	var body service.WorldJoinApplicationInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+		}
+	}
+	result, err := service.WorldInviteConsume(slug, user.ID, body)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrWorldInviteInvalid):
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "邀请链接无效或已过期"})
 		case errors.Is(err, service.ErrWorldNotFound):
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "世界不存在"})
+		case errors.Is(err, service.ErrWorldJoinBanned):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": err.Error()})
+		case errors.Is(err, service.ErrWorldJoinAnswerInvalid):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
 		default:
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "加入失败"})
 		}
 	}
+	if result.Request != nil {
+		notifyWorldJoinRequestSubmitted(result.World, user, result.Request)
+	}
 	return c.JSON(fiber.Map{
-		"invite":         invite,
-		"world":          world,
-		"member":         member,
-		"already_joined": alreadyJoined,
+		"invite":         result.Invite,
+		"world":          result.World,
+		"member":         result.Member,
+		"already_joined": result.AlreadyJoined,
+		"pending":        result.Request != nil,
+		"joinRequest":    result.Request,
 	})
 }
 
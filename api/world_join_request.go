@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
+)
+
+func worldJoinRequestErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrWorldNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "世界不存在"})
+	case errors.Is(err, service.ErrWorldPermission):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "无权审核加入申请"})
+	case errors.Is(err, service.ErrWorldJoinBanned):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrWorldJoinRequestNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrWorldJoinRequestReviewed), errors.Is(err, service.ErrWorldJoinAlreadyMember):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrWorldJoinAnswerInvalid), errors.Is(err, service.ErrWorldJoinDecisionInvalid),
+		errors.Is(err, service.ErrWorldJoinNotApplicable):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "操作失败"})
+}
+
+// WorldJoinApplicationHandler 返回加入申请所需信息以及当前用户的待审核申请
+func WorldJoinApplicationHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	worldID := c.Params("worldId")
+	world, err := service.GetWorldByID(worldID)
+	if err != nil {
+		return worldJoinRequestErrorResponse(c, err)
+	}
+	var myRequest model.WorldJoinRequestModel
+	_ = model.GetDB().Where("world_id = ? AND user_id = ?", worldID, user.ID).
+		Order("created_at DESC").Limit(1).Find(&myRequest).Error
+	resp := fiber.Map{
+		"worldId":          world.ID,
+		"worldName":        world.Name,
+		"requiresApproval": world.RequiresJoinApproval(),
+		"questions":        world.GetJoinQuestions(),
+		"isMember":         service.IsWorldMember(worldID, user.ID),
+		"banned":           service.IsWorldBanned(worldID, user.ID),
+	}
+	if myRequest.ID != "" {
+		resp["myRequest"] = &myRequest
+	}
+	return c.JSON(resp)
+}
+
+func WorldJoinRequestSubmitHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body service.WorldJoinApplicationInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	request, err := service.WorldJoinRequestSubmit(c.Params("worldId"), user.ID, body)
+	if err != nil {
+		return worldJoinRequestErrorResponse(c, err)
+	}
+	if world, err := service.GetWorldByID(request.WorldID); err == nil {
+		notifyWorldJoinRequestSubmitted(world, user, request)
+	}
+	return c.JSON(fiber.Map{"request": request})
+}
+
+func WorldJoinRequestListHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	page := parseQueryIntDefault(c, "page", 1)
+	pageSize := parseQueryIntDefault(c, "pageSize", 20)
+	items, total, err := service.WorldJoinRequestList(c.Params("worldId"), user.ID, c.Query("status"), page, pageSize)
+	if err != nil {
+		return worldJoinRequestErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items, "total": total, "page": page, "pageSize": pageSize})
+}
+
+func WorldJoinRequestMineHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	items, err := service.WorldJoinRequestListMine(user.ID)
+	if err != nil {
+		return worldJoinRequestErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func WorldJoinRequestReviewHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		Decision string `json:"decision"`
+		Note     string `json:"note"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	worldID := c.Params("worldId")
+	request, err := service.WorldJoinRequestReview(worldID, c.Params("requestId"), user.ID, strings.TrimSpace(body.Decision), body.Note)
+	if err != nil {
+		return worldJoinRequestErrorResponse(c, err)
+	}
+	if world, err := service.GetWorldByID(worldID); err == nil {
+		notifyWorldJoinRequestReviewed(world, request)
+	}
+	return c.JSON(fiber.Map{"request": request})
+}
+
+func WorldJoinRequestWithdrawHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if err := service.WorldJoinRequestWithdraw(user.ID, c.Params("requestId")); err != nil {
+		return worldJoinRequestErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "已撤回"})
+}
+
+func WorldBanListHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	items, err := service.WorldBanList(c.Params("worldId"), user.ID)
+	if err != nil {
+		return worldJoinRequestErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func WorldUnbanHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if err := service.WorldUnban(c.Params("worldId"), c.Params("userId"), user.ID); err != nil {
+		return worldJoinRequestErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "已解除封禁"})
+}
+
+// notifyWorldJoinRequestSubmitted 通知世界管理员有新的加入申请
+func notifyWorldJoinRequestSubmitted(world *model.WorldModel, applicant *model.UserModel, request *model.WorldJoinRequestModel) {
+	if world == nil || request == nil {
+		return
+	}
+	reviewers := service.WorldJoinReviewerIDs(world.ID)
+	broadcastEventToUsers(reviewers, &protocol.Event{
+		Type: protocol.EventWorldJoinRequestUpdated,
+		Argv: &protocol.Argv{Options: map[string]interface{}{"worldId": world.ID, "request": request}},
+	})
+	name := applicant.Nickname
+	if strings.TrimSpace(name) == "" {
+		name = applicant.Username
+	}
+	notice := service.AppNotificationNotice{
+		EventType: "world.join_request.created",
+		Title:     world.Name,
+		Body:      fmt.Sprintf("%s 申请加入世界", name),
+		DedupeKey: "world-join-request:" + request.ID,
+		WorldID:   world.ID,
+		WorldName: world.Name,
+		OpenPath:  world.ID + "/join-requests",
+	}
+	webURL := currentAppWebURL()
+	go func() {
+		_ = service.EnqueueAppNotificationNotice(reviewers, notice, webURL)
+	}()
+	go notifyOneBotWorldJoinRequest(world, applicant, request)
+}
+
+// notifyWorldJoinRequestReviewed 将审核结果告知申请人
+func notifyWorldJoinRequestReviewed(world *model.WorldModel, request *model.WorldJoinRequestModel) {
+	if world == nil || request == nil {
+		return
+	}
+	broadcastEventToUsers([]string{request.UserID}, &protocol.Event{
+		Type: protocol.EventWorldJoinRequestUpdated,
+		Argv: &protocol.Argv{Options: map[string]interface{}{"worldId": world.ID, "request": request}},
+	})
+	broadcastEventToUsers(service.WorldJoinReviewerIDs(world.ID), &protocol.Event{
+		Type: protocol.EventWorldJoinRequestUpdated,
+		Argv: &protocol.Argv{Options: map[string]interface{}{"worldId": world.ID, "request": request}},
+	})
+	body := "你的加入申请未通过"
+	if request.Status == model.WorldJoinRequestStatusApproved {
+		body = "你的加入申请已通过"
+	}
+	if request.ReviewNote != "" {
+		body += "：" + request.ReviewNote
+	}
+	notice := service.AppNotificationNotice{
+		EventType: "world.join_request.reviewed",
+		Title:     world.Name,
+		Body:      body,
+		DedupeKey: "world-join-request-reviewed:" + request.ID,
+		WorldID:   world.ID,
+		WorldName: world.Name,
+	}
+	webURL := currentAppWebURL()
+	go func() {
+		_ = service.EnqueueAppNotificationNotice([]string{request.UserID}, notice, webURL)
+	}()
+}
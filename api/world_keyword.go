@@ -611,6 +611,30 @@ func broadcastWorldKeywordEvent(payload *worldKeywordEventPayload) {
 	go broadcastEventToWorld(payload.WorldID, event)
 }
 
+// broadcastEventToUsers 推送事件到指定用户的全部连接，不区分当前所在世界
+func broadcastEventToUsers(userIDs []string, event *protocol.Event) {
+	if userId2ConnInfoGlobal == nil {
+		return
+	}
+	event.Timestamp = time.Now().Unix()
+	for _, userID := range userIDs {
+		conns, ok := userId2ConnInfoGlobal.Load(userID)
+		if !ok || conns == nil {
+			continue
+		}
+		conns.Range(func(conn *WsSyncConn, _ *ConnInfo) bool {
+			_ = conn.WriteJSON(struct {
+				protocol.Event
+				Op protocol.Opcode `json:"op"`
+			}{
+				Event: *event,
+				Op:    protocol.OpEvent,
+			})
+			return true
+		})
+	}
+}
+
 func broadcastEventToWorld(worldID string, event *protocol.Event) {
 	if userId2ConnInfoGlobal == nil {
 		return
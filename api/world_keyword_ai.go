@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/service"
+	aiService "sealchat/service/ai"
+	"sealchat/utils"
+)
+
+func WorldKeywordExtractHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "未登录"})
+	}
+	var payload struct {
+		ChannelIDs  []string `json:"channelIds"`
+		PeriodStart int64    `json:"periodStart"`
+		PeriodEnd   int64    `json:"periodEnd"`
+		Source      string   `json:"source"`
+	}
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	cfg := utils.AIConfig{}
+	if appConfig != nil {
+		cfg = appConfig.AI
+	}
+	result, err := service.WorldKeywordExtract(c.Context(), c.Params("worldId"), user, service.WorldKeywordExtractInput{
+		ChannelIDs:  payload.ChannelIDs,
+		PeriodStart: unixMilliToTime(payload.PeriodStart),
+		PeriodEnd:   unixMilliToTime(payload.PeriodEnd),
+		Source:      payload.Source,
+		AIConfig:    cfg,
+		Runner:      aiRunnerFactory(func() *utils.AppConfig { return appConfig }),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrWorldPermission), errors.Is(err, service.ErrWorldKeywordExtractUnavailable):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": err.Error()})
+		}
+		status, err := resolveAITaskError(err, aiService.FeatureGlossaryExtract, "")
+		return c.Status(status).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.JSON(result)
+}
+
+// WorldKeywordExtractAcceptHandler 采纳勾选的提议词条，与导入共用同一广播
+func WorldKeywordExtractAcceptHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "未登录"})
+	}
+	worldID := c.Params("worldId")
+	var payload struct {
+		Items   []service.WorldKeywordSuggestion `json:"items"`
+		Replace bool                             `json:"replace"`
+	}
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	stats, err := service.WorldKeywordAcceptSuggestions(worldID, user.ID, payload.Items, payload.Replace)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if err == service.ErrWorldPermission {
+			status = fiber.StatusForbidden
+		}
+		return c.Status(status).JSON(fiber.Map{"message": err.Error()})
+	}
+	requestID := utils.NewID()
+	broadcastWorldKeywordEvent(&worldKeywordEventPayload{
+		WorldID:     worldID,
+		Operation:   "imported",
+		RequestID:   requestID,
+		ForceReload: true,
+	})
+	return c.JSON(fiber.Map{"stats": stats, "requestId": requestID})
+}
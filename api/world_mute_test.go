@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"sealchat/model"
+	"sealchat/service"
+	"sealchat/utils"
+)
+
+func TestAPIMessageUpdateRejectsMutedAuthor(t *testing.T) {
+	initMessageUpdateWhisperTestDB(t)
+
+	author := createMessageUpdateWhisperTestUser(t, "muted-author")
+	world := &model.WorldModel{
+		StringPKBaseModel: model.StringPKBaseModel{ID: "muted-world"},
+		Name:              "World",
+		Status:            "active",
+		OwnerID:           "muted-owner",
+	}
+	if err := model.GetDB().Create(world).Error; err != nil {
+		t.Fatal(err)
+	}
+	channel := &model.ChannelModel{
+		StringPKBaseModel: model.StringPKBaseModel{ID: "muted-channel"},
+		WorldID:           world.ID,
+		Name:              "Channel",
+		PermType:          "public",
+		Status:            "active",
+	}
+	if err := model.GetDB().Create(channel).Error; err != nil {
+		t.Fatal(err)
+	}
+	createMessageUpdateWhisperTestMember(t, channel.ID, author.ID)
+	mutedUntil := time.Now().Add(time.Hour)
+	if err := model.GetDB().Create(&model.WorldMemberModel{
+		StringPKBaseModel: model.StringPKBaseModel{ID: utils.NewID()},
+		WorldID:           world.ID,
+		UserID:            author.ID,
+		Role:              model.WorldRoleMember,
+		JoinedAt:          time.Now(),
+		MutedUntil:        &mutedUntil,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	message := &model.MessageModel{
+		StringPKBaseModel: model.StringPKBaseModel{ID: "muted-message"},
+		ChannelID:         channel.ID,
+		UserID:            author.ID,
+		MemberID:          "mem-" + author.ID,
+		Content:           "before",
+		ICMode:            "ic",
+	}
+	if err := model.GetDB().Create(message).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &ChatContext{
+		User:            author,
+		ChannelUsersMap: &utils.SyncMap[string, *utils.SyncSet[string]]{},
+		UserId2ConnInfo: &utils.SyncMap[string, *utils.SyncMap[*WsSyncConn, *ConnInfo]]{},
+	}
+	_, err := apiMessageUpdate(ctx, &struct {
+		ChannelID         string   `json:"channel_id"`
+		MessageID         string   `json:"message_id"`
+		Content           string   `json:"content"`
+		WhisperToIds      []string `json:"whisper_to_ids"`
+		ICMode            string   `json:"ic_mode"`
+		IdentityID        *string  `json:"identity_id"`
+		IdentityVariantID *string  `json:"identity_variant_id"`
+	}{
+		ChannelID: channel.ID,
+		MessageID: message.ID,
+		Content:   "after",
+		ICMode:    "ic",
+	})
+	if !errors.Is(err, service.ErrWorldMemberMuted) {
+		t.Fatalf("muted author should not edit, got %v", err)
+	}
+	var stored model.MessageModel
+	if err := model.GetDB().Where("id = ?", message.ID).First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Content != "before" {
+		t.Fatalf("message should stay unchanged, got %q", stored.Content)
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/protocol"
+	"sealchat/service"
+)
+
+func worldOwnershipErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrWorldNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "世界不存在"})
+	case errors.Is(err, service.ErrWorldPermission):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "仅世界拥有者可转移所有权"})
+	case errors.Is(err, service.ErrWorldOwnershipTransferNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrWorldOwnershipTransferResolved), errors.Is(err, service.ErrWorldOwnerStillActive):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrWorldOwnershipTargetInvalid), errors.Is(err, service.ErrWorldOwnershipTargetNotMember):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "操作失败"})
+}
+
+func WorldOwnershipTransferCreateHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		TargetUserID string `json:"targetUserId"`
+		Note         string `json:"note"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	transfer, err := service.WorldOwnershipTransferRequest(c.Params("worldId"), user.ID, body.TargetUserID, body.Note)
+	if err != nil {
+		return worldOwnershipErrorResponse(c, err)
+	}
+	notifyWorldOwnershipTransfer(transfer, transfer.ToUserID, "world.ownership_transfer.requested", "邀请你接管该世界的所有权")
+	return c.JSON(fiber.Map{"transfer": transfer})
+}
+
+func WorldOwnershipTransferListHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	items, err := service.WorldOwnershipTransferList(c.Params("worldId"), user.ID)
+	if err != nil {
+		return worldOwnershipErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func WorldOwnershipTransferIncomingHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	items, err := service.WorldOwnershipTransferListIncoming(user.ID)
+	if err != nil {
+		return worldOwnershipErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func WorldOwnershipTransferAcceptHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	transfer, world, err := service.WorldOwnershipTransferAccept(c.Params("transferId"), user.ID)
+	if err != nil {
+		return worldOwnershipErrorResponse(c, err)
+	}
+	broadcastWorldUpdated(world)
+	notifyWorldOwnershipTransfer(transfer, transfer.FromUserID, "world.ownership_transfer.accepted", "所有权转移已被接受")
+	return c.JSON(fiber.Map{"transfer": transfer, "world": world})
+}
+
+func WorldOwnershipTransferDeclineHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	transfer, err := service.WorldOwnershipTransferDecline(c.Params("transferId"), user.ID)
+	if err != nil {
+		return worldOwnershipErrorResponse(c, err)
+	}
+	notifyWorldOwnershipTransfer(transfer, transfer.FromUserID, "world.ownership_transfer.declined", "所有权转移已被拒绝")
+	return c.JSON(fiber.Map{"transfer": transfer})
+}
+
+func WorldOwnershipTransferCancelHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	transfer, err := service.WorldOwnershipTransferCancel(c.Params("transferId"), user.ID)
+	if err != nil {
+		return worldOwnershipErrorResponse(c, err)
+	}
+	notifyWorldOwnershipTransfer(transfer, transfer.ToUserID, "", "")
+	return c.JSON(fiber.Map{"transfer": transfer})
+}
+
+// AdminAbandonedWorldList 列出拥有者已停用或删除的世界
+func AdminAbandonedWorldList(c *fiber.Ctx) error {
+	items, err := service.AdminListAbandonedWorlds()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "获取世界失败"})
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func AdminWorldForceTransfer(c *fiber.Ctx) error {
+	operator := getCurUser(c)
+	var body struct {
+		TargetUserID string `json:"targetUserId"`
+		Note         string `json:"note"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	transfer, world, err := service.AdminWorldForceTransfer(c.Params("worldId"), operator.ID, body.TargetUserID, body.Note)
+	if err != nil {
+		return worldOwnershipErrorResponse(c, err)
+	}
+	broadcastWorldUpdated(world)
+	notifyWorldOwnershipTransfer(transfer, transfer.ToUserID, "world.ownership_transfer.forced", "平台管理员已将该世界转移给你")
+	return c.JSON(fiber.Map{"transfer": transfer, "world": world})
+}
+
+// notifyWorldOwnershipTransfer 推送转移状态；eventType 为空时只推送 WS 事件
+func notifyWorldOwnershipTransfer(transfer *model.WorldOwnershipTransferModel, recipientID, eventType, body string) {
+	if transfer == nil {
+		return
+	}
+	broadcastEventToUsers([]string{transfer.FromUserID, transfer.ToUserID}, &protocol.Event{
+		Type: protocol.EventWorldOwnershipTransferUpdated,
+		Argv: &protocol.Argv{Options: map[string]interface{}{"worldId": transfer.WorldID, "transfer": transfer}},
+	})
+	if eventType == "" || recipientID == "" {
+		return
+	}
+	worldName := ""
+	if world, err := service.GetWorldByID(transfer.WorldID); err == nil {
+		worldName = world.Name
+	}
+	notice := service.AppNotificationNotice{
+		EventType: eventType,
+		Title:     worldName,
+		Body:      body,
+		DedupeKey: eventType + ":" + transfer.ID,
+		WorldID:   transfer.WorldID,
+		WorldName: worldName,
+	}
+	webURL := currentAppWebURL()
+	go func() {
+		_ = service.EnqueueAppNotificationNotice([]string{recipientID}, notice, webURL)
+	}()
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/model"
+	"sealchat/service"
+)
+
+func worldPackageErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrWorldPermission), errors.Is(err, service.ErrWorldCreateForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrWorldNotFound), errors.Is(err, service.ErrWorldPackageJobNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrWorldPackageJobBusy):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrWorldPackageTooLarge), errors.Is(err, service.ErrWorldPackageInvalid):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+}
+
+// WorldPackageExportCreate 创建世界模板包导出任务
+func WorldPackageExportCreate(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		IncludeHistory bool `json:"includeHistory"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "请求参数错误"})
+		}
+	}
+	job, err := service.CreateWorldPackageExportJob(user.ID, c.Params("worldId"), body.IncludeHistory)
+	if err != nil {
+		return worldPackageErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"job": worldPackageJobView(job)})
+}
+
+// WorldPackageImportCreate 上传世界模板包，dryRun 时仅返回导入摘要而不创建世界
+func WorldPackageImportCreate(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	file, err := c.FormFile("file")
+	if err != nil || file == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "file 必填"})
+	}
+	input, err := file.Open()
+	if err != nil {
+		return worldPackageErrorResponse(c, err)
+	}
+	defer input.Close()
+	job, err := service.CreateWorldPackageImportJob(user.ID, file.Filename, input, file.Size, service.WorldPackageImportParams{
+		Name:           c.FormValue("name"),
+		IncludeHistory: parseFormBool(c.FormValue("includeHistory")),
+		DryRun:         parseFormBool(c.FormValue("dryRun")),
+	})
+	if err != nil {
+		return worldPackageErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"job": worldPackageJobView(job)})
+}
+
+func WorldPackageJobGet(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	job, err := service.GetWorldPackageJob(user.ID, c.Params("jobId"))
+	if err != nil {
+		return worldPackageErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"job": worldPackageJobView(job)})
+}
+
+func WorldPackageDownload(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	job, err := service.GetWorldPackageJob(user.ID, c.Params("jobId"))
+	if err != nil {
+		return worldPackageErrorResponse(c, err)
+	}
+	if job.Type != model.WorldPackageJobTypeExport || job.Status != model.WorldPackageJobStatusDone || strings.TrimSpace(job.OutputFilePath) == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "导出文件不存在"})
+	}
+	if _, err := os.Stat(job.OutputFilePath); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "导出文件已过期"})
+	}
+	c.Set(fiber.HeaderContentType, "application/zip")
+	return c.Download(job.OutputFilePath, job.OutputFileName)
+}
+
+func WorldPackageJobDelete(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if err := service.DeleteWorldPackageJob(user.ID, c.Params("jobId")); err != nil {
+		return worldPackageErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func worldPackageJobView(job *model.WorldPackageJobModel) fiber.Map {
+	result := fiber.Map{
+		"id": job.ID, "type": job.Type, "status": job.Status, "progress": job.Progress,
+		"sourceWorldId": job.SourceWorldID, "targetWorldId": job.TargetWorldID, "targetName": job.TargetName,
+		"includeHistory": job.IncludeHistory, "dryRun": job.DryRun, "originalName": job.OriginalName,
+		"outputFileName": job.OutputFileName, "outputFileSize": job.OutputFileSize,
+		"packageHash": job.PackageHash, "errorCode": job.ErrorCode, "errorMessage": job.ErrorMessage,
+		"createdAt": job.CreatedAt, "startedAt": job.StartedAt, "finishedAt": job.FinishedAt, "expiresAt": job.ExpiresAt,
+	}
+	if strings.TrimSpace(job.SummaryJSON) != "" {
+		var summary service.WorldPackageSummary
+		if json.Unmarshal([]byte(job.SummaryJSON), &summary) == nil {
+			result["summary"] = summary
+		}
+	}
+	return result
+}
+
+func parseFormBool(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
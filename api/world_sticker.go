@@ -0,0 +1,200 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sealchat/service"
+)
+
+const worldStickerImportMaxSize = 64 * 1024 * 1024
+
+func worldStickerErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrWorldPermission):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "无权管理表情包"})
+	case errors.Is(err, service.ErrWorldStickerPackNotFound), errors.Is(err, service.ErrWorldStickerNotFound), errors.Is(err, service.ErrWorldNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, service.ErrWorldStickerShortcodeConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+}
+
+func WorldStickerPackListHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	items, err := service.WorldStickerPackList(c.Params("worldId"), user.ID)
+	if err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func WorldStickerPackCreateHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body service.WorldStickerPackInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	pack, err := service.WorldStickerPackCreate(c.Params("worldId"), user.ID, body)
+	if err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"pack": pack})
+}
+
+func WorldStickerPackUpdateHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body service.WorldStickerPackInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	pack, err := service.WorldStickerPackUpdate(c.Params("worldId"), c.Params("packId"), user.ID, body)
+	if err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"pack": pack})
+}
+
+func WorldStickerPackDeleteHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if err := service.WorldStickerPackDelete(c.Params("worldId"), c.Params("packId"), user.ID); err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func WorldStickerPackReorderHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	if err := service.WorldStickerPackReorder(c.Params("worldId"), user.ID, body.IDs); err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func WorldStickerAddHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body service.WorldStickerInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	sticker, err := service.WorldStickerAdd(c.Params("worldId"), c.Params("packId"), user.ID, body)
+	if err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"sticker": sticker})
+}
+
+func WorldStickerUpdateHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body service.WorldStickerInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	sticker, err := service.WorldStickerUpdate(c.Params("worldId"), c.Params("stickerId"), user.ID, body)
+	if err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"sticker": sticker})
+}
+
+func WorldStickerDeleteHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if err := service.WorldStickerDelete(c.Params("worldId"), c.Params("stickerId"), user.ID); err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func WorldStickerReorderHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	if err := service.WorldStickerReorder(c.Params("worldId"), c.Params("packId"), user.ID, body.IDs); err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func WorldStickerPackExportHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	name, data, err := service.WorldStickerPackExport(c.Params("worldId"), c.Params("packId"), user.ID)
+	if err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"stickers.zip\"; filename*=UTF-8''%s", url.PathEscape(name)))
+	return c.Send(data)
+}
+
+func WorldStickerPackImportHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "请上传表情包 ZIP 文件"})
+	}
+	if file.Size > worldStickerImportMaxSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "文件大小不能超过64MB"})
+	}
+	f, err := file.Open()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"message": "无法打开文件"})
+	}
+	defer f.Close()
+	pack, skipped, err := service.WorldStickerPackImport(c.Params("worldId"), user.ID, f, file.Size)
+	if err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	if skipped == nil {
+		skipped = []string{}
+	}
+	return c.JSON(fiber.Map{"pack": pack, "skipped": skipped})
+}
+
+// 共享接口仅系统管理员可用，权限在服务层校验
+
+func WorldStickerPackShareListHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	items, err := service.WorldStickerPackShares(c.Params("packId"), user.ID)
+	if err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"items": items})
+}
+
+func WorldStickerPackShareHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	var body struct {
+		TargetWorldID string `json:"targetWorldId"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "参数错误"})
+	}
+	share, err := service.WorldStickerPackShare(c.Params("packId"), body.TargetWorldID, user.ID)
+	if err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"share": share})
+}
+
+func WorldStickerPackUnshareHandler(c *fiber.Ctx) error {
+	user := getCurUser(c)
+	if err := service.WorldStickerPackUnshare(c.Params("packId"), c.Params("targetWorldId"), user.ID); err != nil {
+		return worldStickerErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
@@ -227,6 +227,7 @@ func main() {
 		fatalWithStartupLock("初始化音频子系统失败: %v", err)
 	}
 	service.InitTheaterMediaService(config.TheaterMedia, service.ResolveMediaToolchain(&config.Audio))
+	service.InitAttachmentVideoService(config.AttachmentVideo, service.ResolveMediaToolchain(&config.Audio))
 
 	// 输出 FFmpeg 检测结果
 	if svc := service.GetAudioService(); svc != nil {
@@ -253,6 +254,8 @@ func main() {
 		HTMLMaxConcurrency:  config.Export.HTMLMaxConcurrency,
 	})
 	service.StartTheaterPackageWorker(ctx, config.Export.StorageDir)
+	service.StartWorldPackageWorker(ctx, config.Export.StorageDir)
+	service.StartExportScheduleWorker(ctx, config.Export.StorageDir)
 
 	// 未读提醒取代旧未读邮件提醒主链路；旧代码保留但不再默认启动。
 	service.StartDigestPushWorker()
@@ -332,6 +335,21 @@ func main() {
 	service.SetTheaterEventPublisher(api.LocalTheaterEventPublisher{})
 	service.SetTheaterChatSender(api.LocalTheaterChatSender{})
 	service.StartTheaterOutboxWorker(ctx)
+	service.SetAudioPlaybackEventPublisher(api.LocalAudioPlaybackEventPublisher{})
+	service.StartAudioAutomationWorker(ctx)
+	service.StartSemanticIndexWorker(ctx)
+	service.StartImagePlaceholderBackfillWorker(ctx)
+	if config.EmailNotification.Enabled {
+		if config.EmailNotification.DigestWorkerEnabled {
+			service.StartUnreadNotificationWorker(service.UnreadNotificationWorkerConfig{
+				CheckIntervalSec: config.EmailNotification.CheckIntervalSec,
+				MaxPerHour:       config.EmailNotification.MaxPerHour,
+				SiteURL:          config.Domain,
+				Reply:            config.EmailNotification.Reply,
+			}, config.EmailNotification.SMTP)
+		}
+		service.StartEmailReplyListener(ctx, config.EmailNotification.Reply)
+	}
 
 	service.SyncUpdateCurrentVersion(utils.BuildVersion)
 	if err := api.Init(config, embedDirStatic); err != nil {
@@ -190,6 +190,15 @@ func ListActiveAppNotificationDevicesByWorld(worldID string) ([]AppNotificationD
 	return devices, err
 }
 
+func ListActiveAppNotificationDevicesByUsers(userIDs []string) ([]AppNotificationDeviceModel, error) {
+	var devices []AppNotificationDeviceModel
+	if len(userIDs) == 0 {
+		return devices, nil
+	}
+	err := db.Where("user_id IN ? AND revoked_at IS NULL AND token_expires_at > ?", userIDs, time.Now()).Find(&devices).Error
+	return devices, err
+}
+
 func ListActiveAppNotificationDevices() ([]AppNotificationDeviceModel, error) {
 	var devices []AppNotificationDeviceModel
 	err := db.Where("revoked_at IS NULL AND token_expires_at > ?", time.Now()).Find(&devices).Error
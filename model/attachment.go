@@ -6,6 +6,7 @@ import (
 
 	"gorm.io/gorm"
 
+	"sealchat/protocol"
 	"sealchat/utils"
 )
 
@@ -39,6 +40,16 @@ type AttachmentModel struct {
 	IsTemp        bool   `json:"isTemp,omitempty" gorm:"index"` // 临时文件标记，先上传上来，无问题转正，有问题自动删除
 	CreatorName   string `json:"creatorName,omitempty"`         // 上传者的名字
 	CreatorAvatar string `json:"creatorAvatar,omitempty"`
+
+	MetadataStripped    bool `json:"metadataStripped,omitempty" gorm:"default:false;index"` // 已清除 EXIF 等图片元数据
+	MetadataStripFailed bool `json:"-" gorm:"default:false;index"`                          // 存量清理时文件缺失或无法解析，不再重试
+
+	// 图片尺寸与 ThumbHash 占位图，非图片或无法解码时为空
+	Width     int    `json:"width,omitempty" gorm:"default:0"`
+	Height    int    `json:"height,omitempty" gorm:"default:0"`
+	ThumbHash string `json:"thumbHash,omitempty" gorm:"size:64"`
+	// 存量回填已处理过，超出像素上限或无法解码的图片不再重复扫描
+	PlaceholderChecked bool `json:"-" gorm:"default:false;index"`
 }
 
 func (*AttachmentModel) TableName() string {
@@ -116,3 +127,27 @@ func AttachmentsSetDelete(attachmentIdList []string) int64 {
 	}
 	return 0
 }
+
+// AttachmentImagePlaceholdersByIDs 批量读取图片附件的尺寸与 ThumbHash，没有占位信息的附件不会出现在结果中
+func AttachmentImagePlaceholdersByIDs(conn *gorm.DB, ids []string) (map[string]*protocol.ImagePlaceholder, error) {
+	result := make(map[string]*protocol.ImagePlaceholder, len(ids))
+	if conn == nil || len(ids) == 0 {
+		return result, nil
+	}
+	var rows []AttachmentModel
+	if err := conn.Model(&AttachmentModel{}).
+		Select("id, width, height, thumb_hash").
+		Where("id IN ?", ids).
+		Where("thumb_hash <> '' OR width > 0").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.ID] = &protocol.ImagePlaceholder{
+			Width:     row.Width,
+			Height:    row.Height,
+			ThumbHash: row.ThumbHash,
+		}
+	}
+	return result, nil
+}
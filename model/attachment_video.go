@@ -0,0 +1,81 @@
+package model
+
+import (
+	"gorm.io/gorm"
+
+	"sealchat/protocol"
+)
+
+const (
+	AttachmentVideoStatusPending     = "pending"
+	AttachmentVideoStatusProbing     = "probing"
+	AttachmentVideoStatusTranscoding = "transcoding"
+	AttachmentVideoStatusReady       = "ready"
+	AttachmentVideoStatusFailed      = "failed"
+)
+
+// AttachmentVideoModel 聊天视频附件的处理状态与衍生文件
+type AttachmentVideoModel struct {
+	StringPKBaseModel
+	AttachmentID string  `json:"attachmentId" gorm:"size:100;uniqueIndex"`
+	UserID       string  `json:"userId" gorm:"size:100;index"`
+	ChannelID    string  `json:"channelId" gorm:"size:100"`
+	Status       string  `json:"status" gorm:"size:32;index"`
+	Progress     float64 `json:"progress"`
+	DurationMS   int64   `json:"durationMs"`
+	Width        int     `json:"width"`
+	Height       int     `json:"height"`
+	Container    string  `json:"container" gorm:"size:64"`
+	VideoCodec   string  `json:"videoCodec" gorm:"size:32"`
+	AudioCodec   string  `json:"audioCodec" gorm:"size:32"`
+
+	PosterAttachmentID    string `json:"posterAttachmentId" gorm:"size:100"`
+	RenditionAttachmentID string `json:"renditionAttachmentId" gorm:"size:100"`
+	RenditionMimeType     string `json:"renditionMimeType" gorm:"size:64"`
+	RenditionSize         int64  `json:"renditionSize"`
+
+	FailureCode    string `json:"failureCode,omitempty" gorm:"size:64"`
+	FailureMessage string `json:"-"`
+	Retryable      bool   `json:"retryable"`
+}
+
+func (*AttachmentVideoModel) TableName() string {
+	return "attachment_videos"
+}
+
+// ToProtocolType 转换为推送给客户端的处理状态
+func (m *AttachmentVideoModel) ToProtocolType() *protocol.AttachmentVideoEventPayload {
+	return &protocol.AttachmentVideoEventPayload{
+		AttachmentID:          m.AttachmentID,
+		Status:                m.Status,
+		Progress:              m.Progress,
+		DurationMS:            m.DurationMS,
+		Width:                 m.Width,
+		Height:                m.Height,
+		PosterAttachmentID:    m.PosterAttachmentID,
+		RenditionAttachmentID: m.RenditionAttachmentID,
+		RenditionMimeType:     m.RenditionMimeType,
+		ErrorCode:             m.FailureCode,
+		Retryable:             m.Retryable,
+	}
+}
+
+func AttachmentVideoGet(attachmentID string) (*AttachmentVideoModel, error) {
+	var item AttachmentVideoModel
+	if err := GetDB().Where("attachment_id = ?", attachmentID).Limit(1).Find(&item).Error; err != nil {
+		return nil, err
+	}
+	if item.ID == "" {
+		return nil, nil
+	}
+	return &item, nil
+}
+
+func AttachmentVideosByAttachmentIDs(conn *gorm.DB, ids []string) ([]*AttachmentVideoModel, error) {
+	var items []*AttachmentVideoModel
+	if conn == nil || len(ids) == 0 {
+		return items, nil
+	}
+	err := conn.Where("attachment_id IN ?", ids).Find(&items).Error
+	return items, err
+}
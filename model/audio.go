@@ -29,6 +29,15 @@ const (
 	AudioTranscodeFailed  AudioTranscodeStatus = "failed"
 )
 
+type AudioAnalysisStatus string
+
+const (
+	AudioAnalysisPending     AudioAnalysisStatus = "pending"
+	AudioAnalysisReady       AudioAnalysisStatus = "ready"
+	AudioAnalysisFailed      AudioAnalysisStatus = "failed"
+	AudioAnalysisUnavailable AudioAnalysisStatus = "unavailable"
+)
+
 type AudioAssetScope string
 
 const (
@@ -36,6 +45,27 @@ const (
 	AudioScopeWorld  AudioAssetScope = "world"
 )
 
+// 音频内提示点类型：intro/loop/outro 用于「前奏-循环段-尾奏」结构，marker 为普通标记
+const (
+	AudioCueKindIntro  = "intro"
+	AudioCueKindLoop   = "loop"
+	AudioCueKindOutro  = "outro"
+	AudioCueKindMarker = "marker"
+)
+
+type AudioCuePoint struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Kind  string   `json:"kind"`
+	Start float64  `json:"start"`
+	End   *float64 `json:"end,omitempty"`
+}
+
+// IsRegion 判断提示点是否为有效区间（End 大于 Start）
+func (c AudioCuePoint) IsRegion() bool {
+	return c.End != nil && *c.End > c.Start
+}
+
 type AudioAssetVariant struct {
 	Label       string            `json:"label"`
 	BitrateKbps int               `json:"bitrateKbps"`
@@ -117,6 +147,16 @@ type AudioAsset struct {
 	TranscodeStatus AudioTranscodeStatus        `json:"transcodeStatus" gorm:"type:varchar(16);default:'ready'"`
 	Scope           AudioAssetScope             `json:"scope" gorm:"type:varchar(16);index;default:'common'"`
 	WorldID         *string                     `json:"worldId" gorm:"index"`
+	// 响度分析结果（EBU R128），由 ffmpeg 分析后回写
+	AnalysisStatus   AudioAnalysisStatus     `json:"analysisStatus" gorm:"type:varchar(16);index"`
+	LoudnessLUFS     *float64                `json:"loudnessLufs"`
+	LoudnessRangeLU  *float64                `json:"loudnessRangeLu"`
+	TruePeakDBTP     *float64                `json:"truePeakDbtp"`
+	GainSuggestionDB *float64                `json:"gainSuggestionDb"`
+	WaveformPeaks    JSONList[int]           `json:"waveformPeaks,omitempty" gorm:"type:json"`
+	AnalyzedAt       *time.Time              `json:"analyzedAt"`
+	AnalysisError    string                  `json:"analysisError,omitempty" gorm:"type:text"`
+	CuePoints        JSONList[AudioCuePoint] `json:"cuePoints" gorm:"type:json"`
 }
 
 func (*AudioAsset) TableName() string { return "audio_assets" }
@@ -169,6 +209,15 @@ type AudioSceneTrack struct {
 	PlaylistMode     *string  `json:"playlistMode,omitempty"`
 	PlaylistAssetIDs []string `json:"playlistAssetIds,omitempty"`
 	PlaylistIndex    int      `json:"playlistIndex"`
+	LoopCueID        *string  `json:"loopCueId,omitempty"`
+}
+
+// AudioSceneAutomation 场景自动化：场景播放 AfterSeconds 秒后切换到 NextSceneID，
+// CrossfadeMs 大于 0 时以交叉淡化方式切换。
+type AudioSceneAutomation struct {
+	NextSceneID  string  `json:"nextSceneId"`
+	AfterSeconds float64 `json:"afterSeconds"`
+	CrossfadeMs  int     `json:"crossfadeMs"`
 }
 
 type AudioScene struct {
@@ -183,6 +232,7 @@ type AudioScene struct {
 	UpdatedBy    string                    `json:"updatedBy"`
 	Scope        AudioAssetScope           `json:"scope" gorm:"type:varchar(16);index;default:'common'"`
 	WorldID      *string                   `json:"worldId" gorm:"index"`
+	Automation   *AudioSceneAutomation     `json:"automation,omitempty" gorm:"serializer:json"`
 }
 
 func (*AudioScene) TableName() string { return "audio_scenes" }
@@ -203,6 +253,28 @@ type AudioTrackState struct {
 	PlaylistMode     *string  `json:"playlistMode,omitempty"`
 	PlaylistAssetIDs []string `json:"playlistAssetIds,omitempty"`
 	PlaylistIndex    int      `json:"playlistIndex"`
+	// NormalizeLoudness 为空时视为开启，GainDB 由服务端按素材响度建议值填充
+	NormalizeLoudness *bool   `json:"normalizeLoudness,omitempty"`
+	GainDB            float64 `json:"gainDb"`
+	// LoopCueID 指向素材内的 loop 区间，设置后该轨道在区间内循环，不参与播放列表推进
+	LoopCueID *string `json:"loopCueId,omitempty"`
+}
+
+// AudioPlaybackTransition 描述正在进行的场景切换，客户端据此对 FromTracks 淡出、对新轨道淡入。
+type AudioPlaybackTransition struct {
+	Type        string            `json:"type"`
+	FromSceneID *string           `json:"fromSceneId,omitempty"`
+	ToSceneID   *string           `json:"toSceneId,omitempty"`
+	FromTracks  []AudioTrackState `json:"fromTracks,omitempty"`
+	DurationMs  int               `json:"durationMs"`
+	StartedAtMs int64             `json:"startedAtMs"`
+}
+
+// AudioScheduledAutomation 由服务端执行的待触发场景切换，整体播放进度到达 AtPositionSec 时触发。
+type AudioScheduledAutomation struct {
+	TargetSceneID string  `json:"targetSceneId"`
+	AtPositionSec float64 `json:"atPositionSec"`
+	CrossfadeMs   int     `json:"crossfadeMs"`
 }
 
 type AudioPlaybackState struct {
@@ -216,6 +288,8 @@ type AudioPlaybackState struct {
 	WorldPlaybackEnabled bool                      `json:"worldPlaybackEnabled" gorm:"default:true"`
 	Revision             int64                     `json:"revision" gorm:"not null;default:0"`
 	CapturedAtMs         int64                     `json:"capturedAtMs" gorm:"not null;default:0"`
+	Transition           *AudioPlaybackTransition  `json:"transition,omitempty" gorm:"serializer:json"`
+	Automation           *AudioScheduledAutomation `json:"automation,omitempty" gorm:"serializer:json"`
 	UpdatedBy            string                    `json:"updatedBy"`
 	UpdatedAt            time.Time                 `json:"updatedAt"`
 	CreatedAt            time.Time                 `json:"createdAt"`
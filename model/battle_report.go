@@ -32,6 +32,13 @@ type BattleReportModel struct {
 	AIProviderID       string             `json:"aiProviderId" gorm:"column:ai_provider_id;size:100"`
 	AIModel            string             `json:"aiModel" gorm:"column:ai_model;size:120"`
 	AIFeatureKey       string             `json:"aiFeatureKey" gorm:"column:ai_feature_key;size:64"`
+	IncludeDiceStats   bool               `json:"includeDiceStats" gorm:"default:false"`             // 查看战报时附带时段内的掷骰统计
+	SourceChannelIDs   []string           `json:"sourceChannelIds" gorm:"serializer:json;type:text"` // AI 总结的来源频道，失败后续跑时沿用
+	SummaryStage       string             `json:"summaryStage" gorm:"size:32"`                       // 分段总结当前阶段：map/reduce/final
+	SummaryLevel       int                `json:"summaryLevel"`
+	SummaryDone        int                `json:"summaryDone"`
+	SummaryTotal       int                `json:"summaryTotal"`
+	EstimatedCost      float64            `json:"estimatedCost"`
 	IsDeleted          bool               `json:"isDeleted" gorm:"default:false;index"`
 	DeletedAt          *time.Time         `json:"deletedAt"`
 	DeletedBy          string             `json:"deletedBy" gorm:"size:100"`
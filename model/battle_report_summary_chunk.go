@@ -0,0 +1,50 @@
+package model
+
+import (
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// BattleReportSummaryChunkModel 分段总结的中间结果，任务失败后重试时按输入哈希复用
+type BattleReportSummaryChunkModel struct {
+	StringPKBaseModel
+	ReportID   string `json:"reportId" gorm:"size:100;uniqueIndex:idx_battle_report_chunk_pos,priority:1"`
+	Level      int    `json:"level" gorm:"uniqueIndex:idx_battle_report_chunk_pos,priority:2"`
+	ChunkIndex int    `json:"chunkIndex" gorm:"uniqueIndex:idx_battle_report_chunk_pos,priority:3"`
+	InputHash  string `json:"inputHash" gorm:"size:64"`
+	Summary    string `json:"summary" gorm:"type:text"`
+	ProviderID string `json:"providerId" gorm:"size:100"`
+	Model      string `json:"model" gorm:"size:120"`
+}
+
+func (*BattleReportSummaryChunkModel) TableName() string {
+	return "battle_report_summary_chunks"
+}
+
+func BattleReportSummaryChunkGet(reportID string, level int, index int) (*BattleReportSummaryChunkModel, error) {
+	var item BattleReportSummaryChunkModel
+	err := GetDB().
+		Where("report_id = ? AND level = ? AND chunk_index = ?", strings.TrimSpace(reportID), level, index).
+		Limit(1).
+		Find(&item).Error
+	if err != nil || item.ID == "" {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func BattleReportSummaryChunkSave(item *BattleReportSummaryChunkModel) error {
+	if item.ID == "" {
+		item.Init()
+	}
+	return GetDB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "report_id"}, {Name: "level"}, {Name: "chunk_index"}},
+		DoUpdates: clause.AssignmentColumns([]string{"input_hash", "summary", "provider_id", "model", "updated_at"}),
+	}).Create(item).Error
+}
+
+func BattleReportSummaryChunkClear(reportID string) error {
+	return GetDB().Where("report_id = ?", strings.TrimSpace(reportID)).
+		Delete(&BattleReportSummaryChunkModel{}).Error
+}
@@ -15,6 +15,8 @@ const (
 	ChannelStatusActive   = "active"   // 正常状态
 	ChannelStatusArchived = "archived" // 归档状态
 	ChannelStatusDeleted  = "deleted"  // 已解散/删除
+
+	ChannelPermTypeGroupDM = "group_dm" // 多人私聊，不属于任何世界
 )
 
 type ChannelModel struct {
@@ -31,6 +33,7 @@ type ChannelModel struct {
 	DefaultDiceExpr         string `json:"defaultDiceExpr" gorm:"size:32;not null;default:d20"`
 	BuiltInDiceEnabled      bool   `json:"builtInDiceEnabled" gorm:"default:true"`
 	BotFeatureEnabled       bool   `json:"botFeatureEnabled" gorm:"default:false"`
+	VoiceEnabled            bool   `json:"voiceEnabled" gorm:"default:false"` // 是否开启语音通话
 	PrimaryBotID            string `json:"primaryBotId" gorm:"size:100;index"`
 	EventBotIDsJSON         string `json:"-" gorm:"type:text"`
 	BotWhisperForwardConfig string `json:"botWhisperForwardConfig" gorm:"type:text"`
@@ -40,6 +43,7 @@ type ChannelModel struct {
 
 	BackgroundAttachmentId string `json:"backgroundAttachmentId" gorm:"size:100"` // 背景图附件ID
 	BackgroundSettings     string `json:"backgroundSettings" gorm:"type:text"`    // JSON: 背景常规设置
+	Avatar                 string `json:"avatar,omitempty" gorm:"size:255"`       // 多人私聊头像
 
 	FriendInfo   *FriendModel `json:"friendInfo,omitempty" gorm:"-"`
 	MembersCount int          `json:"membersCount" gorm:"-"`
@@ -181,10 +185,23 @@ func ChannelBackgroundEdit(channelId string, updates *ChannelBackgroundUpdate) e
 		Updates(updates).Error
 }
 
+func channelPermTypeEditable(permType string) bool {
+	return permType == "public" || permType == "non-public"
+}
+
 // ChannelInfoEdit 可修改内容: 名称，简介，公开或非公开，成员正在输入提示，优先级序号，背景图
+// 仅允许在公开与非公开之间切换，私聊与多人私聊的类型不可改写
 func ChannelInfoEdit(channelId string, updates *ChannelModel) error {
+	var current ChannelModel
+	if err := db.Model(&ChannelModel{}).Select("perm_type").Where("id = ?", channelId).Limit(1).Find(&current).Error; err != nil {
+		return err
+	}
+	fields := []string{"name", "note", "sort_order", "background_attachment_id", "background_settings"}
+	if (current.PermType == "" || channelPermTypeEditable(current.PermType)) && channelPermTypeEditable(updates.PermType) {
+		fields = append(fields, "perm_type")
+	}
 	if err := db.Model(&ChannelModel{}).
-		Where("id = ?", channelId).Select("name", "note", "perm_type", "sort_order", "background_attachment_id", "background_settings").
+		Where("id = ?", channelId).Select(fields).
 		Updates(updates).Error; err != nil {
 		return err
 	}
@@ -234,6 +251,8 @@ func (c *ChannelModel) ToProtocolType() *protocol.Channel {
 	channelType := protocol.TextChannelType
 	if c.IsPrivate {
 		channelType = protocol.DirectChannelType
+	} else if c.VoiceEnabled {
+		channelType = protocol.VoiceChannelType
 	}
 	return &protocol.Channel{
 		ID:                      c.ID,
@@ -244,6 +263,7 @@ func (c *ChannelModel) ToProtocolType() *protocol.Channel {
 		BotCommandPrefixes:      utils.GetConfiguredBotCommandPrefixes(),
 		BuiltInDiceEnabled:      c.BuiltInDiceEnabled,
 		BotFeatureEnabled:       c.BotFeatureEnabled,
+		VoiceEnabled:            c.VoiceEnabled,
 		PrimaryBotID:            c.PrimaryBotID,
 		EventBotIDs:             c.GetEventBotIDs(),
 		BotWhisperForwardConfig: c.BotWhisperForwardConfig,
@@ -252,6 +272,10 @@ func (c *ChannelModel) ToProtocolType() *protocol.Channel {
 	}
 }
 
+func (c *ChannelModel) IsGroupDM() bool {
+	return c != nil && c.PermType == ChannelPermTypeGroupDM
+}
+
 func ChannelPublicNew(channelID string, ch *ChannelModel, creatorId string) *ChannelModel {
 	ch.ID = channelID
 	ch.UserID = creatorId
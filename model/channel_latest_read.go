@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -197,6 +198,10 @@ func ChannelReadInit(channelId, userId string) error {
 }
 
 func ChannelReadInitInBatches(channelId string, userIds []string) error {
+	return ChannelReadInitInBatchesTx(db, channelId, userIds)
+}
+
+func ChannelReadInitInBatchesTx(tx *gorm.DB, channelId string, userIds []string) error {
 	models := make([]ChannelLatestReadModel, len(userIds))
 	for i, userId := range userIds {
 		models[i] = ChannelLatestReadModel{
@@ -206,7 +211,7 @@ func ChannelReadInitInBatches(channelId string, userIds []string) error {
 		}
 	}
 
-	return db.Clauses(clause.OnConflict{
+	return tx.Clauses(clause.OnConflict{
 		DoNothing: true, // 对应 INSERT OR IGNORE
 	}).CreateInBatches(models, 100).Error
 }
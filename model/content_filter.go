@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+const (
+	ContentFilterMatchWord  = "word"
+	ContentFilterMatchRegex = "regex"
+
+	ContentFilterActionBlock   = "block"
+	ContentFilterActionMask    = "mask"
+	ContentFilterActionFlag    = "flag"
+	ContentFilterActionApprove = "approve" // 需管理员审核后才发布
+
+	ContentFilterScopeMessage  = "message"
+	ContentFilterScopeNickname = "nickname"
+	ContentFilterScopeIdentity = "identity"
+	ContentFilterScopeWorld    = "world"
+
+	ContentFilterPendingStatusPending  = "pending"
+	ContentFilterPendingStatusApproved = "approved"
+	ContentFilterPendingStatusRejected = "rejected"
+)
+
+// ContentFilterRuleModel 内容过滤规则，WorldID 为空表示全站规则
+type ContentFilterRuleModel struct {
+	StringPKBaseModel
+	WorldID   string     `json:"worldId" gorm:"size:100;index"`
+	Pattern   string     `json:"pattern" gorm:"size:512"`
+	MatchType string     `json:"matchType" gorm:"size:16"`
+	Action    string     `json:"action" gorm:"size:16"`
+	Scopes    string     `json:"scopes" gorm:"size:128"` // 逗号分隔，为空表示全部范围
+	Enabled   bool       `json:"enabled" gorm:"default:true"`
+	Note      string     `json:"note" gorm:"size:255"`
+	CreatorID string     `json:"creatorId" gorm:"size:100"`
+	HitCount  int64      `json:"hitCount" gorm:"not null;default:0"`
+	LastHitAt *time.Time `json:"lastHitAt,omitempty"`
+}
+
+func (*ContentFilterRuleModel) TableName() string {
+	return "content_filter_rules"
+}
+
+// ContentFilterPendingModel 命中“需审核”规则而暂存的消息
+type ContentFilterPendingModel struct {
+	StringPKBaseModel
+	WorldID     string     `json:"worldId" gorm:"size:100;index"`
+	ChannelID   string     `json:"channelId" gorm:"size:100;index"`
+	UserID      string     `json:"userId" gorm:"size:100;index"`
+	RuleIDs     string     `json:"ruleIds" gorm:"size:512"`
+	Content     string     `json:"content" gorm:"type:text"`
+	PayloadJSON string     `json:"-" gorm:"type:text"` // 原始发送参数，审核通过后按原参数发布
+	Status      string     `json:"status" gorm:"size:16;index"`
+	ReviewerID  string     `json:"reviewerId" gorm:"size:100"`
+	ReviewedAt  *time.Time `json:"reviewedAt,omitempty"`
+	MessageID   string     `json:"messageId" gorm:"size:100"`
+}
+
+func (*ContentFilterPendingModel) TableName() string {
+	return "content_filter_pending"
+}
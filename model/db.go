@@ -139,6 +139,7 @@ func DBInit(cfg *utils.AppConfig) {
 	db.AutoMigrate(&AppNotificationInstanceModel{}, &AppNotificationDeviceModel{}, &AppNotificationPreferenceModel{})
 	db.AutoMigrate(&MemberModel{})
 	db.AutoMigrate(&AttachmentModel{})
+	db.AutoMigrate(&AttachmentVideoModel{})
 	if err := autoMigrateTheaterModels(db); err != nil {
 		panic(fmt.Sprintf("初始化 Theater 数据表失败: %v", err))
 	}
@@ -175,17 +176,25 @@ func DBInit(cfg *utils.AppConfig) {
 
 	db.AutoMigrate(&SystemRoleModel{}, &ChannelRoleModel{}, &RolePermissionModel{}, &UserRoleMappingModel{})
 	db.AutoMigrate(&FriendModel{}, &FriendRequestModel{})
-	db.AutoMigrate(&MessageExportJobModel{})
-	db.AutoMigrate(&BattleReportModel{}, &BattleReportDisplayChannelModel{}, &BattleReportDisplayEmbedModel{})
+	db.AutoMigrate(&MessageExportJobModel{}, &MessageExportScheduleModel{}, &MessageExportScheduleRunModel{})
+	db.AutoMigrate(&BattleReportModel{}, &BattleReportDisplayChannelModel{}, &BattleReportDisplayEmbedModel{}, &BattleReportSummaryChunkModel{})
 	db.AutoMigrate(&ChannelIFormModel{})
 	db.AutoMigrate(&WorldIFormBindingModel{})
 	db.AutoMigrate(&WorldModel{}, &WorldMemberModel{}, &WorldMemberDice3DProfileModel{}, &WorldInviteModel{}, &WorldFavoriteModel{}, &WorldArchiveModel{}, &WorldKeywordModel{}, &WorldKeywordCategoryModel{})
+	db.AutoMigrate(&WorldStickerPackModel{}, &WorldStickerModel{}, &WorldStickerPackShareModel{})
+	db.AutoMigrate(&WorldPackageJobModel{})
+	db.AutoMigrate(&WorldJoinRequestModel{}, &WorldBanModel{})
+	db.AutoMigrate(&WorldOwnershipTransferModel{})
+	db.AutoMigrate(&UserBlockModel{})
+	db.AutoMigrate(&ReportModel{})
+	db.AutoMigrate(&ContentFilterRuleModel{}, &ContentFilterPendingModel{})
 	db.AutoMigrate(&ExternalGlossaryLibraryModel{}, &ExternalGlossaryTermModel{}, &ExternalGlossaryCategoryModel{}, &WorldExternalGlossaryBindingModel{})
 	db.AutoMigrate(&AnnouncementModel{}, &AnnouncementUserStateModel{})
 	db.AutoMigrate(&ServiceMetricSample{})
 	db.AutoMigrate(&ChatImportJobModel{})
 	db.AutoMigrate(&ChannelWebhookIntegrationModel{}, &MessageExternalRefModel{}, &WebhookEventLogModel{}, &WebhookIdentityBindingModel{})
 	db.AutoMigrate(&DigestWebhookIntegrationModel{})
+	db.AutoMigrate(&QQBridgeModel{})
 	db.AutoMigrate(&DigestPushRuleModel{}, &DigestWindowVisitorModel{}, &DigestWindowSpeakerModel{}, &DigestRecordModel{}, &DigestDeliveryLogModel{})
 	db.AutoMigrate(&StickyNoteModel{}, &StickyNoteUserStateModel{}, &StickyNoteFolderModel{})
 	db.AutoMigrate(&EmailNotificationSettingsModel{}, &EmailNotificationLogModel{})
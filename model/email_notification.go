@@ -118,6 +118,19 @@ func (m *EmailNotificationSettingsModel) ToSMTPConfig() utils.SMTPConfig {
 	}
 }
 
+// EmailNotificationSettingsGetByID 按记录 ID 获取邮件通知设置，不存在时返回 nil
+func EmailNotificationSettingsGetByID(id string) (*EmailNotificationSettingsModel, error) {
+	var record EmailNotificationSettingsModel
+	err := db.Where("id = ?", id).Limit(1).Find(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	if record.ID == "" {
+		return nil, nil
+	}
+	return &record, nil
+}
+
 // EmailNotificationSettingsDelete 删除用户邮件通知设置
 func EmailNotificationSettingsDelete(userID, channelID string) error {
 	return db.Where("user_id = ? AND channel_id = ?", userID, channelID).Delete(&EmailNotificationSettingsModel{}).Error
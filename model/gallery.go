@@ -44,10 +44,39 @@ type GalleryItem struct {
 	Order        int    `json:"order"`
 	CreatedBy    string `json:"createdBy"`
 	Size         int64  `json:"size"`
+
+	// 来自附件的图片尺寸与 ThumbHash，仅用于列表展示
+	Width     int    `json:"width,omitempty" gorm:"-"`
+	Height    int    `json:"height,omitempty" gorm:"-"`
+	ThumbHash string `json:"thumbHash,omitempty" gorm:"-"`
 }
 
 func (*GalleryItem) TableName() string { return "gallery_items" }
 
+// FillGalleryItemPlaceholders 按附件补全图库条目的尺寸与 ThumbHash
+func FillGalleryItemPlaceholders(items []*GalleryItem) {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != nil && item.AttachmentID != "" {
+			ids = append(ids, item.AttachmentID)
+		}
+	}
+	placeholders, err := AttachmentImagePlaceholdersByIDs(db, ids)
+	if err != nil {
+		return
+	}
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if placeholder := placeholders[item.AttachmentID]; placeholder != nil {
+			item.Width = placeholder.Width
+			item.Height = placeholder.Height
+			item.ThumbHash = placeholder.ThumbHash
+		}
+	}
+}
+
 func CreateGalleryCollection(ownerType OwnerType, ownerID, name, createdBy string, order int) (*GalleryCollection, error) {
 	if ownerType != OwnerTypeUser && ownerType != OwnerTypeChannel {
 		return nil, errors.New("invalid owner type")
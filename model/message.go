@@ -79,6 +79,10 @@ type MessageModel struct {
 	WhisperTargetDisplayNames []string                  `json:"whisper_target_display_names,omitempty" gorm:"-"`
 	WhisperMeta               *protocol.WhisperMeta     `json:"whisper_meta,omitempty" gorm:"-"`
 	Reactions                 []MessageReactionListItem `json:"reactions" gorm:"-"`
+	// ImagePlaceholders 图片附件的尺寸与 ThumbHash，键为附件 ID
+	ImagePlaceholders map[string]*protocol.ImagePlaceholder `json:"image_placeholders,omitempty" gorm:"-"`
+	// SenderBlocked 发送者已被当前用户屏蔽，前端据此折叠消息
+	SenderBlocked bool `json:"sender_blocked,omitempty" gorm:"-"`
 }
 
 func (*MessageModel) TableName() string {
@@ -235,6 +239,9 @@ func (m *MessageModel) ToProtocolType2(channelData *protocol.Channel) *protocol.
 			msg.DiceVisual = &payload
 		}
 	}
+	if len(m.ImagePlaceholders) > 0 {
+		msg.ImagePlaceholders = m.ImagePlaceholders
+	}
 	if len(m.WhisperTargets) > 0 {
 		msg.WhisperToIds = make([]*protocol.User, 0, len(m.WhisperTargets))
 		for _, target := range m.WhisperTargets {
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// DiceStatsMaxRows 单次统计最多读取的掷骰记录数
+const DiceStatsMaxRows = 100000
+
+// DiceStatsFilter 掷骰统计筛选条件，各项为空表示不限
+type DiceStatsFilter struct {
+	UserID         string
+	IdentityID     string
+	WorldID        string
+	ChannelIDs     []string
+	StartTime      *time.Time
+	EndTime        *time.Time
+	ICMode         string // "ic", "ooc", or "" (all)
+	IncludeWhisper bool   // 是否包含悄悄话内的掷骰（仅统计本人时开启）
+}
+
+// DiceStatsRow 掷骰记录及其所属消息的元信息
+type DiceStatsRow struct {
+	MessageID       string    `gorm:"column:message_id"`
+	RollIndex       int       `gorm:"column:roll_index"`
+	Formula         string    `gorm:"column:formula"`
+	ResultDetail    string    `gorm:"column:result_detail"`
+	ResultValueText string    `gorm:"column:result_value_text"`
+	UserID          string    `gorm:"column:user_id"`
+	UserNickname    string    `gorm:"column:user_nickname"`
+	IdentityID      string    `gorm:"column:identity_id"`
+	IdentityName    string    `gorm:"column:identity_name"`
+	ChannelID       string    `gorm:"column:channel_id"`
+	CreatedAt       time.Time `gorm:"column:created_at"`
+}
+
+// DiceStatsRows 按时间顺序读取满足条件的掷骰记录，返回值 truncated 表示超出上限被截断
+func DiceStatsRows(f DiceStatsFilter) (rows []DiceStatsRow, truncated bool, err error) {
+	q := db.Table("message_dice_rolls AS r").
+		Select("r.message_id AS message_id, r.roll_index AS roll_index, r.formula AS formula, "+
+			"r.result_detail AS result_detail, r.result_value_text AS result_value_text, "+
+			"m.user_id AS user_id, u.nickname AS user_nickname, m.sender_identity_id AS identity_id, "+
+			"m.sender_identity_name AS identity_name, m.channel_id AS channel_id, m.created_at AS created_at").
+		Joins("JOIN messages m ON m.id = r.message_id").
+		Joins("LEFT JOIN users u ON u.id = m.user_id").
+		Where("r.is_error = ? AND m.is_deleted = ? AND m.is_revoked = ?", false, false, false)
+
+	if userID := strings.TrimSpace(f.UserID); userID != "" {
+		q = q.Where("m.user_id = ?", userID)
+	}
+	if identityID := strings.TrimSpace(f.IdentityID); identityID != "" {
+		q = q.Where("m.sender_identity_id = ?", identityID)
+	}
+	if len(f.ChannelIDs) > 0 {
+		q = q.Where("m.channel_id IN ?", f.ChannelIDs)
+	}
+	if worldID := strings.TrimSpace(f.WorldID); worldID != "" {
+		q = q.Joins("JOIN channels c ON c.id = m.channel_id").Where("c.world_id = ?", worldID)
+	}
+	if f.StartTime != nil {
+		q = q.Where("m.created_at >= ?", *f.StartTime)
+	}
+	if f.EndTime != nil {
+		q = q.Where("m.created_at <= ?", *f.EndTime)
+	}
+	switch f.ICMode {
+	case "ic", "ooc":
+		q = q.Where("m.ic_mode = ?", f.ICMode)
+	}
+	if !f.IncludeWhisper {
+		q = q.Where("(m.is_whisper = ? OR m.is_whisper IS NULL)", false)
+	}
+
+	if err = q.Order("m.created_at ASC, r.message_id ASC, r.roll_index ASC").
+		Limit(DiceStatsMaxRows + 1).
+		Find(&rows).Error; err != nil {
+		return nil, false, err
+	}
+	if len(rows) > DiceStatsMaxRows {
+		rows = rows[:DiceStatsMaxRows]
+		truncated = true
+	}
+	return rows, truncated, nil
+}
@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+const (
+	MessageExportScheduleScopeChannel = "channel"
+	MessageExportScheduleScopeWorld   = "world"
+
+	MessageExportScheduleFrequencyDaily  = "daily"
+	MessageExportScheduleFrequencyWeekly = "weekly"
+	MessageExportScheduleFrequencyCron   = "cron"
+
+	MessageExportScheduleRunRunning = "running"
+	MessageExportScheduleRunSuccess = "success"
+	MessageExportScheduleRunFailed  = "failed"
+	MessageExportScheduleRunSkipped = "skipped"
+)
+
+// MessageExportScheduleModel 周期导出计划；Targets 为投递目标的 JSON 数组。
+type MessageExportScheduleModel struct {
+	StringPKBaseModel
+
+	UserID    string `json:"user_id" gorm:"index;size:100"`
+	ScopeType string `json:"scope_type" gorm:"size:16"`
+	ScopeID   string `json:"scope_id" gorm:"index;size:100"`
+	WorldID   string `json:"world_id" gorm:"index;size:100"`
+	Name      string `json:"name" gorm:"size:255"`
+
+	Format           string `json:"format" gorm:"size:32"`
+	DisplayName      string `json:"display_name" gorm:"size:255"`
+	IncludeOOC       bool   `json:"include_ooc"`
+	IncludeArchived  bool   `json:"include_archived"`
+	IncludeImages    bool   `json:"include_images"`
+	WithoutTimestamp bool   `json:"without_timestamp"`
+	MergeMessages    bool   `json:"merge_messages"`
+
+	Frequency string `json:"frequency" gorm:"size:16"`
+	CronExpr  string `json:"cron_expr" gorm:"size:100"`
+	TimeOfDay string `json:"time_of_day" gorm:"size:8"`
+	Weekday   int    `json:"weekday"`
+
+	Targets     string `json:"targets" gorm:"type:text"`
+	AlertEmail  string `json:"alert_email" gorm:"size:254"`
+	Incremental bool   `json:"incremental"`
+	Enabled     bool   `json:"enabled" gorm:"index"`
+
+	NextRunAt    *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	LastRangeEnd *time.Time `json:"last_range_end"`
+	LastStatus   string     `json:"last_status" gorm:"size:24"`
+	LastError    string     `json:"last_error" gorm:"type:text"`
+	FailureCount int        `json:"failure_count"`
+}
+
+func (*MessageExportScheduleModel) TableName() string {
+	return "message_export_schedules"
+}
+
+// MessageExportScheduleRunModel 周期导出的单次执行记录；Deliveries 为各目标投递结果的 JSON 数组。
+type MessageExportScheduleRunModel struct {
+	StringPKBaseModel
+
+	ScheduleID string     `json:"schedule_id" gorm:"index;size:100"`
+	JobID      string     `json:"job_id" gorm:"index;size:100"`
+	RangeStart *time.Time `json:"range_start"`
+	RangeEnd   *time.Time `json:"range_end"`
+	Status     string     `json:"status" gorm:"index;size:24"`
+	Deliveries string     `json:"deliveries" gorm:"type:text"`
+	ErrorMsg   string     `json:"error_msg" gorm:"type:text"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+func (*MessageExportScheduleRunModel) TableName() string {
+	return "message_export_schedule_runs"
+}
@@ -52,6 +52,22 @@ func MessageExternalRefGetFirstByMessageID(messageID string) (*MessageExternalRe
 	return &item, nil
 }
 
+func MessageExternalRefGetByMessageID(messageID, source string) (*MessageExternalRefModel, error) {
+	messageID = strings.TrimSpace(messageID)
+	source = strings.TrimSpace(source)
+	if messageID == "" || source == "" {
+		return nil, nil
+	}
+	var item MessageExternalRefModel
+	if err := db.Where("message_id = ? AND source = ?", messageID, source).Order("created_at ASC").Limit(1).Find(&item).Error; err != nil {
+		return nil, err
+	}
+	if item.ID == "" {
+		return nil, nil
+	}
+	return &item, nil
+}
+
 func MessageExternalRefUpsert(channelID, source, externalID, messageID, integrationID, externalActorID string) (*MessageExternalRefModel, error) {
 	channelID = strings.TrimSpace(channelID)
 	source = strings.TrimSpace(source)
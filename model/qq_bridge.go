@@ -0,0 +1,130 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"sealchat/utils"
+)
+
+// QQBridgeSourceInbound / QQBridgeSourceOutbound 用作 MessageExternalRef 与变更流的来源标记，
+// 出站转发的消息使用单独来源，避免撤回同步被 excludeSource 过滤。
+const (
+	QQBridgeSourceInbound  = "qq"
+	QQBridgeSourceOutbound = "qq-outbound"
+)
+
+// QQBridgeModel 频道与外部 QQ 群的桥接配置，SealChat 作为 OneBot 客户端连接外部实现
+type QQBridgeModel struct {
+	StringPKBaseModel
+	ChannelID     string `json:"channelId" gorm:"size:100;uniqueIndex"`
+	IntegrationID string `json:"integrationId" gorm:"size:100;index"`
+	BotUserID     string `json:"botUserId" gorm:"size:100"`
+	GroupID       int64  `json:"groupId" gorm:"index"`
+	TransportType string `json:"transportType" gorm:"size:32"`
+	Endpoint      string `json:"endpoint" gorm:"size:512"`
+	AccessToken   string `json:"-" gorm:"size:256"`
+	Secret        string `json:"-" gorm:"size:256"`
+	Enabled       bool   `json:"enabled"`
+	CreatedBy     string `json:"createdBy" gorm:"size:100"`
+	LastEventSeq  int64  `json:"lastEventSeq"`
+	LastError     string `json:"lastError" gorm:"size:512"`
+	LastErrorAt   int64  `json:"lastErrorAt"`
+}
+
+func (*QQBridgeModel) TableName() string {
+	return "qq_bridges"
+}
+
+func NormalizeQQBridge(item *QQBridgeModel) {
+	if item == nil {
+		return
+	}
+	item.ChannelID = strings.TrimSpace(item.ChannelID)
+	item.Endpoint = strings.TrimRight(strings.TrimSpace(item.Endpoint), "/")
+	item.AccessToken = strings.TrimSpace(item.AccessToken)
+	item.Secret = strings.TrimSpace(item.Secret)
+	if strings.TrimSpace(strings.ToLower(item.TransportType)) == OneBotTransportHTTP {
+		item.TransportType = OneBotTransportHTTP
+	} else {
+		item.TransportType = OneBotTransportForwardWS
+	}
+}
+
+func QQBridgeGet(id string) (*QQBridgeModel, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, nil
+	}
+	var item QQBridgeModel
+	if err := db.Where("id = ?", id).Limit(1).Find(&item).Error; err != nil {
+		return nil, err
+	}
+	if item.ID == "" {
+		return nil, nil
+	}
+	return &item, nil
+}
+
+func QQBridgeGetByChannelID(channelID string) (*QQBridgeModel, error) {
+	channelID = strings.TrimSpace(channelID)
+	if channelID == "" {
+		return nil, nil
+	}
+	var item QQBridgeModel
+	if err := db.Where("channel_id = ?", channelID).Limit(1).Find(&item).Error; err != nil {
+		return nil, err
+	}
+	if item.ID == "" {
+		return nil, nil
+	}
+	return &item, nil
+}
+
+func QQBridgeSave(item *QQBridgeModel) error {
+	if item == nil {
+		return nil
+	}
+	NormalizeQQBridge(item)
+	if item.ID == "" {
+		item.ID = utils.NewID()
+		return db.Create(item).Error
+	}
+	return db.Save(item).Error
+}
+
+func QQBridgeDelete(id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil
+	}
+	return db.Where("id = ?", id).Delete(&QQBridgeModel{}).Error
+}
+
+func QQBridgeListEnabled() ([]*QQBridgeModel, error) {
+	var items []*QQBridgeModel
+	if err := db.Where("enabled = ?", true).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func QQBridgeUpdateCursor(id string, seq int64) error {
+	return db.Model(&QQBridgeModel{}).Where("id = ?", id).Update("last_event_seq", seq).Error
+}
+
+// QQBridgeRecordError 记录最近一次同步错误，message 为空表示恢复正常
+func QQBridgeRecordError(id, message string) error {
+	message = strings.TrimSpace(message)
+	if len(message) > 500 {
+		message = message[:500]
+	}
+	at := int64(0)
+	if message != "" {
+		at = time.Now().UnixMilli()
+	}
+	return db.Model(&QQBridgeModel{}).Where("id = ?", id).Updates(map[string]any{
+		"last_error":    message,
+		"last_error_at": at,
+	}).Error
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ReportTargetMessage  = "message"
+	ReportTargetUser     = "user"
+	ReportTargetIdentity = "identity"
+
+	ReportCategorySpam          = "spam"
+	ReportCategoryHarassment    = "harassment"
+	ReportCategoryNSFW          = "nsfw"
+	ReportCategoryIllegal       = "illegal"
+	ReportCategoryImpersonation = "impersonation"
+	ReportCategoryOther         = "other"
+
+	ReportStatusPending   = "pending"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+
+	ReportActionDismiss       = "dismiss"
+	ReportActionDeleteMessage = "delete_message"
+	ReportActionMute          = "mute"
+	ReportActionRemoveMember  = "remove_member"
+	ReportActionDisableUser   = "disable_user"
+)
+
+// ReportEvidence 举报时保存的内容快照，消息之后被编辑或删除也不影响
+type ReportEvidence struct {
+	MessageID        string    `json:"messageId,omitempty"`
+	ChannelID        string    `json:"channelId,omitempty"`
+	ChannelName      string    `json:"channelName,omitempty"`
+	Content          string    `json:"content,omitempty"`
+	ICMode           string    `json:"icMode,omitempty"`
+	IsWhisper        bool      `json:"isWhisper,omitempty"`
+	SenderUserID     string    `json:"senderUserId,omitempty"`
+	SenderUsername   string    `json:"senderUsername,omitempty"`
+	SenderNickname   string    `json:"senderNickname,omitempty"`
+	SenderMemberName string    `json:"senderMemberName,omitempty"`
+	IdentityID       string    `json:"identityId,omitempty"`
+	IdentityName     string    `json:"identityName,omitempty"`
+	IdentityAvatarID string    `json:"identityAvatarId,omitempty"`
+	UserAvatar       string    `json:"userAvatar,omitempty"`
+	UserBrief        string    `json:"userBrief,omitempty"`
+	CreatedAt        time.Time `json:"createdAt,omitempty"`
+	CapturedAt       time.Time `json:"capturedAt"`
+}
+
+// ReportModel 成员举报，WorldID 为空的举报只进入平台管理员的全局队列
+type ReportModel struct {
+	StringPKBaseModel
+	WorldID      string     `json:"worldId" gorm:"size:100;index"`
+	ReporterID   string     `json:"reporterId" gorm:"size:100;index"`
+	TargetType   string     `json:"targetType" gorm:"size:24;index:idx_report_target,priority:1"`
+	TargetID     string     `json:"targetId" gorm:"size:100;index:idx_report_target,priority:2"`
+	TargetUserID string     `json:"targetUserId" gorm:"size:100;index"`
+	Category     string     `json:"category" gorm:"size:32"`
+	Reason       string     `json:"reason" gorm:"size:1000"`
+	EvidenceJSON string     `json:"-" gorm:"type:text"`
+	Status       string     `json:"status" gorm:"size:24;index"`
+	Action       string     `json:"action" gorm:"size:32"`
+	HandlerID    string     `json:"handlerId" gorm:"size:100"`
+	HandlerNote  string     `json:"handlerNote" gorm:"size:1000"`
+	Feedback     string     `json:"feedback" gorm:"size:500"` // 反馈给举报人的说明
+	HandledAt    *time.Time `json:"handledAt,omitempty"`
+}
+
+func (*ReportModel) TableName() string {
+	return "reports"
+}
+
+func (m *ReportModel) GetEvidence() *ReportEvidence {
+	if m == nil || m.EvidenceJSON == "" {
+		return nil
+	}
+	var evidence ReportEvidence
+	if err := json.Unmarshal([]byte(m.EvidenceJSON), &evidence); err != nil {
+		return nil
+	}
+	return &evidence
+}
+
+func (m *ReportModel) SetEvidence(evidence *ReportEvidence) {
+	if evidence == nil {
+		m.EvidenceJSON = ""
+		return
+	}
+	data, _ := json.Marshal(evidence)
+	m.EvidenceJSON = string(data)
+}
+
+func (m ReportModel) MarshalJSON() ([]byte, error) {
+	type alias ReportModel
+	return json.Marshal(struct {
+		alias
+		Evidence *ReportEvidence `json:"evidence,omitempty"`
+	}{alias: alias(m), Evidence: m.GetEvidence()})
+}
@@ -179,6 +179,10 @@ func RolePermissionBatchDelete(roleId string, permissions []string) error {
 
 // RolePermissionBatchCreate 批量创建角色权限
 func RolePermissionBatchCreate(roleID string, permissionIDs []string) error {
+	return RolePermissionBatchCreateTx(db, roleID, permissionIDs)
+}
+
+func RolePermissionBatchCreateTx(tx *gorm.DB, roleID string, permissionIDs []string) error {
 	var rolePermissions []RolePermissionModel
 	for _, permissionID := range permissionIDs {
 		rolePermissions = append(rolePermissions, RolePermissionModel{
@@ -187,7 +191,7 @@ func RolePermissionBatchCreate(roleID string, permissionIDs []string) error {
 			PermissionID:      permissionID,
 		})
 	}
-	return db.Create(&rolePermissions).Error
+	return tx.Create(&rolePermissions).Error
 }
 
 // RolePermissionGet 获取角色权限
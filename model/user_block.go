@@ -0,0 +1,14 @@
+package model
+
+// UserBlockModel 用户屏蔽关系，UserID 屏蔽 BlockedUserID（单向）。
+type UserBlockModel struct {
+	StringPKBaseModel
+	UserID        string `json:"userId" gorm:"size:100;uniqueIndex:idx_user_block_pair,priority:1"`
+	BlockedUserID string `json:"blockedUserId" gorm:"size:100;uniqueIndex:idx_user_block_pair,priority:2;index"`
+
+	BlockedUser *UserModel `json:"blockedUser,omitempty" gorm:"-"`
+}
+
+func (*UserBlockModel) TableName() string {
+	return "user_blocks"
+}
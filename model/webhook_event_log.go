@@ -73,3 +73,27 @@ func WebhookEventLogCleanupBefore(cutoff time.Time) (int64, error) {
 	tx := db.Where("created_at < ?", cutoff).Delete(&WebhookEventLogModel{})
 	return tx.RowsAffected, tx.Error
 }
+
+func WebhookEventLogMaxSeq(channelID string) (int64, error) {
+	var maxSeq *int64
+	if err := db.Model(&WebhookEventLogModel{}).Where("channel_id = ?", strings.TrimSpace(channelID)).Select("MAX(seq)").Scan(&maxSeq).Error; err != nil {
+		return 0, err
+	}
+	if maxSeq == nil {
+		return 0, nil
+	}
+	return *maxSeq, nil
+}
+
+// WebhookEventLogListAfter 按 seq 升序读取 cursor 之后的变更，excludeSource 非空时跳过该来源产生的事件
+func WebhookEventLogListAfter(channelID string, cursor int64, limit int, excludeSource string) ([]WebhookEventLogModel, error) {
+	q := db.Where("channel_id = ? AND seq > ?", strings.TrimSpace(channelID), cursor)
+	if excludeSource = strings.TrimSpace(excludeSource); excludeSource != "" {
+		q = q.Where("(source IS NULL OR source = '' OR source <> ?)", excludeSource)
+	}
+	var logs []WebhookEventLogModel
+	if err := q.Order("seq ASC").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
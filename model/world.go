@@ -35,7 +35,8 @@ type WorldModel struct {
 	Visibility                            string  `json:"visibility" gorm:"size:24;default:public;index"`             // public/private/unlisted
 	ObserverSlug                          *string `json:"-" gorm:"size:64;uniqueIndex"`                               // 专属 OB 旁观 slug，空值使用 NULL 以避免唯一索引冲突
 	ObserverEnabled                       bool    `json:"-" gorm:"default:false"`                                     // 专属 OB 旁观链接启用状态
-	EnforceMembership                     bool    `json:"enforceMembership" gorm:"default:false"`                     // 预留未来严格控制
+	EnforceMembership                     bool    `json:"enforceMembership" gorm:"default:false"`                     // 私有/非公开世界需提交加入申请并经审核
+	JoinQuestionsJSON                     string  `json:"-" gorm:"type:text"`                                         // 加入申请问题
 	AllowAdminEditMessages                bool    `json:"allowAdminEditMessages" gorm:"default:false"`                // 允许管理员编辑成员发言
 	AllowManageOtherUserChannelIdentities bool    `json:"allowManageOtherUserChannelIdentities" gorm:"default:false"` // 允许管理其他用户频道角色
 	AllowMemberEditKeywords               bool    `json:"allowMemberEditKeywords" gorm:"default:false"`               // 允许成员编辑世界术语
@@ -124,6 +125,22 @@ func (m *WorldModel) GetDice3DConfig() protocol.Dice3DWorldConfig {
 	return value
 }
 
+func (m *WorldModel) GetJoinQuestions() []WorldJoinQuestion {
+	var value []WorldJoinQuestion
+	if strings.TrimSpace(m.JoinQuestionsJSON) != "" {
+		_ = json.Unmarshal([]byte(m.JoinQuestionsJSON), &value)
+	}
+	if value == nil {
+		value = []WorldJoinQuestion{}
+	}
+	return value
+}
+
+// RequiresJoinApproval 公开世界始终可直接加入，开启严格成员控制的私有/非公开世界需审核
+func (m *WorldModel) RequiresJoinApproval() bool {
+	return m.EnforceMembership && m.Visibility != WorldVisibilityPublic
+}
+
 func (m *WorldModel) GetCursorTheme() utils.CursorThemeConfig {
 	var value utils.CursorThemeConfig
 	if strings.TrimSpace(m.CursorThemeJSON) != "" {
@@ -142,6 +159,7 @@ func (m *WorldModel) MarshalJSON() ([]byte, error) {
 		StickyNoteDefaultAppearance *protocol.StickyNoteAppearance            `json:"stickyNoteDefaultAppearance,omitempty"`
 		CursorTheme                 utils.CursorThemeConfig                   `json:"cursorTheme"`
 		Dice3DConfig                protocol.Dice3DWorldConfig                `json:"dice3dConfig"`
+		JoinQuestions               []WorldJoinQuestion                       `json:"joinQuestions"`
 	}{
 		worldModelAlias:             (*worldModelAlias)(m),
 		ChannelDefaultBotIDs:        m.GetChannelDefaultBotIDs(),
@@ -150,6 +168,7 @@ func (m *WorldModel) MarshalJSON() ([]byte, error) {
 		StickyNoteDefaultAppearance: m.GetStickyNoteDefaultAppearance(),
 		CursorTheme:                 m.GetCursorTheme(),
 		Dice3DConfig:                m.GetDice3DConfig(),
+		JoinQuestions:               m.GetJoinQuestions(),
 	})
 }
 
@@ -181,6 +200,7 @@ type WorldMemberModel struct {
 	JoinedAt                    time.Time  `json:"joinedAt"`
 	EditNoticeAckedAt           *time.Time `json:"editNoticeAckedAt"`           // 确认管理员编辑提示的时间
 	ManageIdentityNoticeAckedAt *time.Time `json:"manageIdentityNoticeAckedAt"` // 确认频道角色代管提示的时间
+	MutedUntil                  *time.Time `json:"mutedUntil,omitempty"`        // 世界内禁言截止时间
 }
 
 func (*WorldMemberModel) TableName() string {
@@ -199,6 +219,7 @@ type WorldInviteModel struct {
 	UsedCount int        `json:"usedCount"`
 	Status    string     `json:"status" gorm:"size:24;default:active;index"`
 	Memo      string     `json:"memo" gorm:"size:255"`
+	JoinMode  string     `json:"joinMode" gorm:"size:16;default:auto"` // auto 直接加入 / apply 生成加入申请
 }
 
 func (*WorldInviteModel) TableName() string {
@@ -215,6 +236,9 @@ func (m *WorldInviteModel) BeforeCreate(tx *gorm.DB) error {
 	if strings.TrimSpace(m.Role) == "" {
 		m.Role = WorldRoleMember
 	}
+	if strings.TrimSpace(m.JoinMode) == "" {
+		m.JoinMode = WorldInviteJoinModeAuto
+	}
 	if strings.TrimSpace(m.Status) == "" {
 		m.Status = "active"
 	}
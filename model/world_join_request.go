@@ -0,0 +1,86 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	WorldJoinRequestStatusPending   = "pending"
+	WorldJoinRequestStatusApproved  = "approved"
+	WorldJoinRequestStatusRejected  = "rejected"
+	WorldJoinRequestStatusBanned    = "banned"
+	WorldJoinRequestStatusWithdrawn = "withdrawn"
+
+	WorldInviteJoinModeAuto  = "auto"  // 直接加入
+	WorldInviteJoinModeApply = "apply" // 生成加入申请
+)
+
+// WorldJoinQuestion 世界自定义的入群问题，保存在 WorldModel.JoinQuestionsJSON 中。
+type WorldJoinQuestion struct {
+	ID       string `json:"id"`
+	Prompt   string `json:"prompt"`
+	Required bool   `json:"required"`
+}
+
+// WorldJoinAnswer 申请人对单个问题的回答，Prompt 为提交时的问题快照。
+type WorldJoinAnswer struct {
+	QuestionID string `json:"questionId"`
+	Prompt     string `json:"prompt"`
+	Answer     string `json:"answer"`
+}
+
+// WorldJoinRequestModel 记录用户加入世界的申请与审核结果。
+type WorldJoinRequestModel struct {
+	StringPKBaseModel
+	WorldID     string     `json:"worldId" gorm:"size:100;index:idx_world_join_request_status,priority:1"`
+	UserID      string     `json:"userId" gorm:"size:100;index"`
+	Status      string     `json:"status" gorm:"size:24;index:idx_world_join_request_status,priority:2"`
+	Role        string     `json:"role" gorm:"size:24;default:member"`
+	InviteID    string     `json:"inviteId,omitempty" gorm:"size:100"`
+	Message     string     `json:"message" gorm:"size:500"`
+	AnswersJSON string     `json:"-" gorm:"type:text"`
+	ReviewerID  string     `json:"reviewerId,omitempty" gorm:"size:100"`
+	ReviewNote  string     `json:"reviewNote,omitempty" gorm:"size:500"`
+	ReviewedAt  *time.Time `json:"reviewedAt,omitempty"`
+}
+
+func (*WorldJoinRequestModel) TableName() string {
+	return "world_join_requests"
+}
+
+func (m *WorldJoinRequestModel) GetAnswers() []WorldJoinAnswer {
+	var answers []WorldJoinAnswer
+	if strings.TrimSpace(m.AnswersJSON) != "" {
+		_ = json.Unmarshal([]byte(m.AnswersJSON), &answers)
+	}
+	if answers == nil {
+		answers = []WorldJoinAnswer{}
+	}
+	return answers
+}
+
+func (m *WorldJoinRequestModel) MarshalJSON() ([]byte, error) {
+	type worldJoinRequestAlias WorldJoinRequestModel
+	return json.Marshal(&struct {
+		*worldJoinRequestAlias
+		Answers []WorldJoinAnswer `json:"answers"`
+	}{
+		worldJoinRequestAlias: (*worldJoinRequestAlias)(m),
+		Answers:               m.GetAnswers(),
+	})
+}
+
+// WorldBanModel 被世界封禁的用户，无法再提交申请或通过邀请加入。
+type WorldBanModel struct {
+	StringPKBaseModel
+	WorldID   string `json:"worldId" gorm:"size:100;uniqueIndex:idx_world_ban_user,priority:1"`
+	UserID    string `json:"userId" gorm:"size:100;uniqueIndex:idx_world_ban_user,priority:2;index"`
+	Reason    string `json:"reason" gorm:"size:500"`
+	CreatedBy string `json:"createdBy" gorm:"size:100"`
+}
+
+func (*WorldBanModel) TableName() string {
+	return "world_bans"
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	WorldOwnershipTransferStatusPending   = "pending"
+	WorldOwnershipTransferStatusAccepted  = "accepted"
+	WorldOwnershipTransferStatusDeclined  = "declined"
+	WorldOwnershipTransferStatusCancelled = "cancelled"
+	WorldOwnershipTransferStatusExpired   = "expired"
+	WorldOwnershipTransferStatusForced    = "forced" // 平台管理员强制转移
+)
+
+// WorldOwnershipTransferModel 世界所有权转移申请，同时作为转移的审计记录保留。
+type WorldOwnershipTransferModel struct {
+	StringPKBaseModel
+	WorldID    string     `json:"worldId" gorm:"size:100;index"`
+	FromUserID string     `json:"fromUserId" gorm:"size:100;index"`
+	ToUserID   string     `json:"toUserId" gorm:"size:100;index"`
+	ActorID    string     `json:"actorId" gorm:"size:100"` // 发起人，强制转移时为平台管理员
+	Status     string     `json:"status" gorm:"size:24;index"`
+	Note       string     `json:"note" gorm:"size:500"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+func (*WorldOwnershipTransferModel) TableName() string {
+	return "world_ownership_transfers"
+}
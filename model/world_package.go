@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+const (
+	WorldPackageJobTypeExport = "export"
+	WorldPackageJobTypeImport = "import"
+
+	WorldPackageJobStatusPending = "pending"
+	WorldPackageJobStatusRunning = "running"
+	WorldPackageJobStatusDone    = "done"
+	WorldPackageJobStatusFailed  = "failed"
+)
+
+// WorldPackageJobModel 记录整个世界的模板包导出/导入任务。
+type WorldPackageJobModel struct {
+	StringPKBaseModel
+	Type           string     `json:"type" gorm:"size:16;not null;index:idx_world_package_job_status_created,priority:2"`
+	Status         string     `json:"status" gorm:"size:24;not null;index:idx_world_package_job_status_created,priority:1"`
+	ActorUserID    string     `json:"actorUserId" gorm:"size:100;not null;index"`
+	SourceWorldID  string     `json:"sourceWorldId,omitempty" gorm:"size:100;index"`
+	TargetWorldID  string     `json:"targetWorldId,omitempty" gorm:"size:100;index"`
+	TargetName     string     `json:"targetName,omitempty" gorm:"size:100"`
+	IncludeHistory bool       `json:"includeHistory" gorm:"not null;default:false"`
+	DryRun         bool       `json:"dryRun" gorm:"not null;default:false"`
+	Progress       float64    `json:"progress" gorm:"not null;default:0"`
+	InputFilePath  string     `json:"-" gorm:"size:1024"`
+	OriginalName   string     `json:"originalName,omitempty" gorm:"size:255"`
+	OutputFilePath string     `json:"-" gorm:"size:1024"`
+	OutputFileName string     `json:"outputFileName,omitempty" gorm:"size:255"`
+	OutputFileSize int64      `json:"outputFileSize,omitempty"`
+	PackageHash    string     `json:"packageHash,omitempty" gorm:"size:64;index"`
+	SummaryJSON    string     `json:"summaryJson,omitempty" gorm:"type:text"`
+	ErrorCode      string     `json:"errorCode,omitempty" gorm:"size:64"`
+	ErrorMessage   string     `json:"errorMessage,omitempty" gorm:"type:text"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	FinishedAt     *time.Time `json:"finishedAt,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty" gorm:"index"`
+}
+
+func (*WorldPackageJobModel) TableName() string { return "world_package_jobs" }
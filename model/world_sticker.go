@@ -0,0 +1,43 @@
+package model
+
+// WorldStickerPackModel 世界表情包，由世界管理员维护；AllowedRoles 为空表示所有世界成员可用。
+type WorldStickerPackModel struct {
+	StringPKBaseModel
+	WorldID      string           `json:"worldId" gorm:"size:100;index:idx_world_sticker_pack_sort,priority:1"`
+	Name         string           `json:"name" gorm:"size:100"`
+	Description  string           `json:"description" gorm:"type:text"`
+	CoverID      string           `json:"coverId" gorm:"size:100"`
+	AllowedRoles JSONList[string] `json:"allowedRoles" gorm:"type:json"`
+	SortOrder    int              `json:"sortOrder" gorm:"default:0;index:idx_world_sticker_pack_sort,priority:2"`
+	IsEnabled    bool             `json:"isEnabled" gorm:"default:true"`
+	CreatedBy    string           `json:"createdBy" gorm:"size:100"`
+	UpdatedBy    string           `json:"updatedBy" gorm:"size:100"`
+	StickerCount int              `json:"stickerCount" gorm:"-"`
+	SharedFrom   string           `json:"sharedFrom,omitempty" gorm:"-"`
+}
+
+func (*WorldStickerPackModel) TableName() string { return "world_sticker_packs" }
+
+// WorldStickerModel 表情包内的单个表情；Shortcode 在所属世界内唯一，输入 :shortcode: 即可引用。
+type WorldStickerModel struct {
+	StringPKBaseModel
+	PackID       string           `json:"packId" gorm:"size:100;index:idx_world_sticker_pack,priority:1"`
+	WorldID      string           `json:"worldId" gorm:"size:100;index:idx_world_sticker_shortcode,priority:1"`
+	Shortcode    string           `json:"shortcode" gorm:"size:64;index:idx_world_sticker_shortcode,priority:2"`
+	Aliases      JSONList[string] `json:"aliases" gorm:"type:json"`
+	AttachmentID string           `json:"attachmentId" gorm:"size:100"`
+	SortOrder    int              `json:"sortOrder" gorm:"default:0;index:idx_world_sticker_pack,priority:2"`
+	CreatedBy    string           `json:"createdBy" gorm:"size:100"`
+}
+
+func (*WorldStickerModel) TableName() string { return "world_stickers" }
+
+// WorldStickerPackShareModel 系统管理员把某个世界的表情包共享给其他世界使用（只读）。
+type WorldStickerPackShareModel struct {
+	StringPKBaseModel
+	PackID        string `json:"packId" gorm:"size:100;uniqueIndex:idx_world_sticker_share,priority:1"`
+	TargetWorldID string `json:"targetWorldId" gorm:"size:100;uniqueIndex:idx_world_sticker_share,priority:2;index"`
+	CreatedBy     string `json:"createdBy" gorm:"size:100"`
+}
+
+func (*WorldStickerPackShareModel) TableName() string { return "world_sticker_pack_shares" }
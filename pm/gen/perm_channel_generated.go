@@ -35,6 +35,8 @@ var PermChannelMap = map[string]string{
     "func_channel_theater_resource_delete": "频道 - 小剧场 - 删除资源",
     "func_channel_theater_action_trigger": "频道 - 小剧场 - 触发动作",
     "func_channel_theater_admin_restore": "频道 - 小剧场 - 管理恢复",
+    "func_channel_voice_join": "频道 - 语音 - 加入语音",
+    "func_channel_voice_manage": "频道 - 语音 - 管理语音成员",
     "func_channel_read_all": "频道 - 特殊 - 查看所有子频道",
     "func_channel_text_send_all": "频道 - 特殊 - 在所有子频道发送文本",
 }
@@ -74,6 +76,8 @@ var PermChannelArray = []map[string]string{
 	{"key": "func_channel_theater_resource_delete", "desc": "频道 - 小剧场 - 删除资源"},
 	{"key": "func_channel_theater_action_trigger", "desc": "频道 - 小剧场 - 触发动作"},
 	{"key": "func_channel_theater_admin_restore", "desc": "频道 - 小剧场 - 管理恢复"},
+	{"key": "func_channel_voice_join", "desc": "频道 - 语音 - 加入语音"},
+	{"key": "func_channel_voice_manage", "desc": "频道 - 语音 - 管理语音成员"},
 	{"key": "func_channel_read_all", "desc": "频道 - 特殊 - 查看所有子频道"},
 	{"key": "func_channel_text_send_all", "desc": "频道 - 特殊 - 在所有子频道发送文本"},
 }
@@ -112,6 +112,7 @@ func Init() {
 	ensureChannelMessagePinPerms(chRoles)
 	ensureObserverWhisperReadPerms(chRoles)
 	ensureChannelTheaterPerms(chRoles)
+	ensureChannelVoicePerms(chRoles)
 
 	if num == 0 {
 		// 目前system roles表还未实用，每次创建是设计的一部分而不是bug
@@ -200,6 +201,22 @@ func ensureChannelTheaterPerms(chRoles []*model.ChannelRoleModel) {
 	}
 }
 
+func ensureChannelVoicePerms(chRoles []*model.ChannelRoleModel) {
+	all := VoiceChannelPermissions()
+	member := []gorbac.Permission{PermFuncChannelVoiceJoin}
+	for _, role := range chRoles {
+		if role == nil {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(role.ID, "-owner"), strings.HasSuffix(role.ID, "-admin"):
+			ensureRoleHasPermissions(role.ID, all)
+		case strings.HasSuffix(role.ID, "-member"):
+			ensureRoleHasPermissions(role.ID, member)
+		}
+	}
+}
+
 func ensureRoleHasPermissions(roleID string, perms []gorbac.Permission) {
 	if roleID == "" || len(perms) == 0 {
 		return
@@ -46,6 +46,9 @@ var (
 	PermFuncChannelTheaterActionTrigger       = gorbac.NewStdPermission("func_channel_theater_action_trigger")        // 频道 - 小剧场 - 触发动作
 	PermFuncChannelTheaterAdminRestore        = gorbac.NewStdPermission("func_channel_theater_admin_restore")         // 频道 - 小剧场 - 管理恢复
 
+	PermFuncChannelVoiceJoin   = gorbac.NewStdPermission("func_channel_voice_join")   // 频道 - 语音 - 加入语音
+	PermFuncChannelVoiceManage = gorbac.NewStdPermission("func_channel_voice_manage") // 频道 - 语音 - 管理语音成员
+
 	// 准备加一个at权限
 
 	PermFuncChannelReadAll     = gorbac.NewStdPermission("func_channel_read_all")      // 频道 - 特殊 - 查看所有子频道
@@ -65,3 +68,10 @@ func TheaterChannelPermissions() []gorbac.Permission {
 		PermFuncChannelTheaterAdminRestore,
 	}
 }
+
+func VoiceChannelPermissions() []gorbac.Permission {
+	return []gorbac.Permission{
+		PermFuncChannelVoiceJoin,
+		PermFuncChannelVoiceManage,
+	}
+}
@@ -16,6 +16,7 @@ type Channel struct {
 	BotCommandPrefixes      []string    `json:"botCommandPrefixes,omitempty"`
 	BuiltInDiceEnabled      bool        `json:"builtInDiceEnabled"`
 	BotFeatureEnabled       bool        `json:"botFeatureEnabled"`
+	VoiceEnabled            bool        `json:"voiceEnabled,omitempty"`
 	PrimaryBotID            string      `json:"primaryBotId,omitempty"`
 	EventBotIDs             []string    `json:"eventBotIds,omitempty"`
 	CharacterAPIEnabled     bool        `json:"characterApiEnabled"`
@@ -198,6 +199,17 @@ type Message struct {
 	ClientID         string             `json:"clientId,omitempty"`
 	WhisperMeta      *WhisperMeta       `json:"whisperMeta,omitempty"`
 	DiceVisual       *DiceVisualPayload `json:"diceVisual,omitempty"`
+	// ImagePlaceholders 消息内图片附件的尺寸与占位图，键为附件 ID
+	ImagePlaceholders map[string]*ImagePlaceholder `json:"imagePlaceholders,omitempty"`
+	// SenderBlocked 发送者已被接收方屏蔽，仅在按接收者下发的事件中设置
+	SenderBlocked bool `json:"senderBlocked,omitempty"`
+}
+
+// ImagePlaceholder 图片加载前用于占位的尺寸与 ThumbHash（base64）
+type ImagePlaceholder struct {
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	ThumbHash string `json:"thumbHash,omitempty"`
 }
 
 type MessageIdentity struct {
@@ -213,28 +225,47 @@ type MessageIdentity struct {
 }
 
 type ChannelPresence struct {
-	User     *User `json:"user"`
-	Latency  int64 `json:"latency"`
-	Focused  bool  `json:"focused"`
-	LastSeen int64 `json:"lastSeen"`
+	User     *User                  `json:"user"`
+	Latency  int64                  `json:"latency"`
+	Focused  bool                   `json:"focused"`
+	LastSeen int64                  `json:"lastSeen"`
+	Voice    *VoiceParticipantState `json:"voice,omitempty"`
 }
 
 type AudioTrackState struct {
-	Type             string   `json:"type"`
-	AssetID          *string  `json:"assetId"`
-	Volume           float64  `json:"volume"`
-	Muted            bool     `json:"muted"`
-	Solo             bool     `json:"solo"`
-	FadeIn           int      `json:"fadeIn"`
-	FadeOut          int      `json:"fadeOut"`
-	IsPlaying        bool     `json:"isPlaying"`
-	Position         float64  `json:"position"`
-	LoopEnabled      bool     `json:"loopEnabled"`
-	PlaybackRate     float64  `json:"playbackRate"`
-	PlaylistFolderID *string  `json:"playlistFolderId,omitempty"`
-	PlaylistMode     *string  `json:"playlistMode,omitempty"`
-	PlaylistAssetIDs []string `json:"playlistAssetIds,omitempty"`
-	PlaylistIndex    int      `json:"playlistIndex"`
+	Type              string   `json:"type"`
+	AssetID           *string  `json:"assetId"`
+	Volume            float64  `json:"volume"`
+	Muted             bool     `json:"muted"`
+	Solo              bool     `json:"solo"`
+	FadeIn            int      `json:"fadeIn"`
+	FadeOut           int      `json:"fadeOut"`
+	IsPlaying         bool     `json:"isPlaying"`
+	Position          float64  `json:"position"`
+	LoopEnabled       bool     `json:"loopEnabled"`
+	PlaybackRate      float64  `json:"playbackRate"`
+	PlaylistFolderID  *string  `json:"playlistFolderId,omitempty"`
+	PlaylistMode      *string  `json:"playlistMode,omitempty"`
+	PlaylistAssetIDs  []string `json:"playlistAssetIds,omitempty"`
+	PlaylistIndex     int      `json:"playlistIndex"`
+	NormalizeLoudness *bool    `json:"normalizeLoudness,omitempty"`
+	GainDB            float64  `json:"gainDb"`
+	LoopCueID         *string  `json:"loopCueId,omitempty"`
+}
+
+type AudioPlaybackTransition struct {
+	Type        string            `json:"type"`
+	FromSceneID *string           `json:"fromSceneId,omitempty"`
+	ToSceneID   *string           `json:"toSceneId,omitempty"`
+	FromTracks  []AudioTrackState `json:"fromTracks,omitempty"`
+	DurationMs  int               `json:"durationMs"`
+	StartedAtMs int64             `json:"startedAtMs"`
+}
+
+type AudioScheduledAutomation struct {
+	TargetSceneID string  `json:"targetSceneId"`
+	AtPositionSec float64 `json:"atPositionSec"`
+	CrossfadeMs   int     `json:"crossfadeMs"`
 }
 
 type AudioPlaybackStatePayload struct {
@@ -253,6 +284,10 @@ type AudioPlaybackStatePayload struct {
 	UpdatedAt            int64             `json:"updatedAt"`
 	ScopeType            string            `json:"scopeType"`
 	ScopeID              string            `json:"scopeId"`
+	// 以下字段由服务端自动化写入：场景切换过渡、待触发的场景自动化以及本次同步原因
+	Transition *AudioPlaybackTransition  `json:"transition,omitempty"`
+	Automation *AudioScheduledAutomation `json:"automation,omitempty"`
+	SyncReason string                    `json:"syncReason,omitempty"`
 }
 
 type ChannelIForm struct {
@@ -317,6 +352,21 @@ type ChannelImageLayoutItem struct {
 	UpdatedAt    int64  `json:"updatedAt,omitempty"`
 }
 
+// AttachmentVideoEventPayload 视频附件处理进度，rendition 为转码后的网页播放版本
+type AttachmentVideoEventPayload struct {
+	AttachmentID          string  `json:"attachmentId"`
+	Status                string  `json:"status"`
+	Progress              float64 `json:"progress"`
+	DurationMS            int64   `json:"durationMs,omitempty"`
+	Width                 int     `json:"width,omitempty"`
+	Height                int     `json:"height,omitempty"`
+	PosterAttachmentID    string  `json:"posterAttachmentId,omitempty"`
+	RenditionAttachmentID string  `json:"renditionAttachmentId,omitempty"`
+	RenditionMimeType     string  `json:"renditionMimeType,omitempty"`
+	ErrorCode             string  `json:"errorCode,omitempty"`
+	Retryable             bool    `json:"retryable,omitempty"`
+}
+
 type ChannelImageLayoutEventPayload struct {
 	ChannelID  string                   `json:"channelId"`
 	MessageID  string                   `json:"messageId"`
@@ -399,6 +449,10 @@ const (
 	EventFriendRequest                  EventName = "friend-request"
 	EventGuildRequest                   EventName = "guild-request"
 	EventGuildMemberRequest             EventName = "guild-member-request"
+	EventGuildMemberAdded               EventName = "guild-member-added"
+	EventGuildMemberRemoved             EventName = "guild-member-removed"
+	EventChannelMemberUpdated           EventName = "channel-member-updated"
+	EventFriendAdded                    EventName = "friend-added"
 	EventTypingPreview                  EventName = "typing-preview"
 	EventChannelPresenceUpdated         EventName = "channel-presence-updated"
 	EventChannelUpdated                 EventName = "channel-updated"
@@ -413,6 +467,11 @@ const (
 	EventWorldUpdated                   EventName = "world-updated"
 	EventWorldDice3DUpdated             EventName = "world-dice3d-updated"
 	EventWorldMemberDice3DUpdated       EventName = "world-member-dice3d-updated"
+	EventWorldJoinRequestUpdated        EventName = "world-join-request-updated"
+	EventWorldOwnershipTransferUpdated  EventName = "world-ownership-transfer-updated"
+	EventGroupDMUpdated                 EventName = "group-dm-updated"
+	EventReportUpdated                  EventName = "report-updated"
+	EventContentFilterPending           EventName = "content-filter-pending"
 	EventLobbyAnnouncementUpdated       EventName = "lobby-announcement-updated"
 	// Sticky Note Events
 	EventStickyNoteCreated EventName = "sticky-note-created"
@@ -442,17 +501,24 @@ const (
 	// Quick Login Events
 	EventQuickLoginRequested EventName = "quick-login-requested"
 	// Theater Events
-	EventTheaterSnapshot            EventName = "theater.snapshot"
-	EventTheaterMutationApplied     EventName = "theater.mutation.applied"
-	EventTheaterMutationRejected    EventName = "theater.mutation.rejected"
-	EventTheaterResourceProcessing  EventName = "theater.resource.processing"
-	EventTheaterResourceReady       EventName = "theater.resource.ready"
-	EventTheaterResourceFailed      EventName = "theater.resource.failed"
+	EventTheaterSnapshot           EventName = "theater.snapshot"
+	EventTheaterMutationApplied    EventName = "theater.mutation.applied"
+	EventTheaterMutationRejected   EventName = "theater.mutation.rejected"
+	EventTheaterResourceProcessing EventName = "theater.resource.processing"
+	EventTheaterResourceReady      EventName = "theater.resource.ready"
+	EventTheaterResourceFailed     EventName = "theater.resource.failed"
+	// Attachment Video Events
+	EventAttachmentVideoProcessing  EventName = "attachment.video.processing"
+	EventAttachmentVideoReady       EventName = "attachment.video.ready"
+	EventAttachmentVideoFailed      EventName = "attachment.video.failed"
 	EventTheaterPreloadRequested    EventName = "theater.preload.requested"
 	EventTheaterPointerTrace        EventName = "theater.pointer.trace"
 	EventTheaterEffectTriggered     EventName = "theater.effect.triggered"
 	EventTheaterSceneAudioTriggered EventName = "theater.scene.audio.triggered"
 	EventTheaterVisibilityTriggered EventName = "theater.visibility.triggered"
+	// Voice Events
+	EventVoiceSignal       EventName = "voice.signal"
+	EventVoiceStateUpdated EventName = "voice.state.updated"
 )
 
 type TheaterEventPayload struct {
@@ -531,8 +597,10 @@ type Event struct {
 	CharacterRemarkSnapshot     *CharacterRemarkSnapshotPayload     `json:"characterRemarkSnapshot,omitempty"`
 	QuickLoginRequested         *QuickLoginRequestedPayload         `json:"quickLoginRequested,omitempty"`
 	Theater                     *TheaterEventPayload                `json:"theater,omitempty"`
+	Voice                       *VoiceEventPayload                  `json:"voice,omitempty"`
 	MessageContext              *MessageContext                     `json:"messageContext,omitempty"`
 	MessageReaction             *MessageReactionEvent               `json:"messageReaction,omitempty"`
+	AttachmentVideo             *AttachmentVideoEventPayload        `json:"attachmentVideo,omitempty"`
 	IsInteractiveUpdate         bool                                `json:"is_interactive_update,omitempty"`
 }
 
@@ -0,0 +1,60 @@
+package protocol
+
+// 语音信令类型
+const (
+	VoiceSignalOffer  = "offer"
+	VoiceSignalAnswer = "answer"
+	VoiceSignalICE    = "ice"
+)
+
+// 语音拓扑：mesh 为成员两两直连，sfu 为经由转发服务器
+const (
+	VoiceTopologyMesh = "mesh"
+	VoiceTopologySFU  = "sfu"
+)
+
+type VoiceICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
+
+// VoiceParticipantState 语音成员状态，随频道在线列表一并下发
+type VoiceParticipantState struct {
+	SessionID   string `json:"sessionId"`
+	Muted       bool   `json:"muted"`
+	Deafened    bool   `json:"deafened"`
+	Speaking    bool   `json:"speaking"`
+	ServerMuted bool   `json:"serverMuted"`
+	JoinedAt    int64  `json:"joinedAt"`
+}
+
+type VoiceParticipant struct {
+	User *User `json:"user"`
+	VoiceParticipantState
+}
+
+// VoiceSignal 由服务端原样转发的 WebRTC 信令
+type VoiceSignal struct {
+	Kind          string          `json:"kind"`
+	FromSessionID string          `json:"fromSessionId"`
+	FromUserID    string          `json:"fromUserId"`
+	ToSessionID   string          `json:"toSessionId"`
+	SDP           string          `json:"sdp,omitempty"`
+	Candidate     *VoiceCandidate `json:"candidate,omitempty"`
+}
+
+type VoiceCandidate struct {
+	Candidate        string  `json:"candidate"`
+	SDPMid           *string `json:"sdpMid,omitempty"`
+	SDPMLineIndex    *int    `json:"sdpMLineIndex,omitempty"`
+	UsernameFragment *string `json:"usernameFragment,omitempty"`
+}
+
+type VoiceEventPayload struct {
+	ChannelID    string              `json:"channelId"`
+	Action       string              `json:"action,omitempty"` // join/leave/update/moderate
+	Topology     string              `json:"topology,omitempty"`
+	Participants []*VoiceParticipant `json:"participants,omitempty"`
+	Signal       *VoiceSignal        `json:"signal,omitempty"`
+}
@@ -23,6 +23,8 @@ type BilledRunInput struct {
 	Source     string
 	Now        time.Time
 	Runner     TaskRunner
+	// OnDelta 非空时以流式方式执行，取消或中断后按已产生的用量结算
+	OnDelta StreamDeltaFunc
 }
 
 type BilledRunOutput struct {
@@ -59,14 +61,35 @@ func RunTaskWithBilling(ctx context.Context, input BilledRunInput) (BilledRunOut
 		}
 	}()
 
-	result, err := input.Runner.Run(ctx, RunRequest{
+	runReq := RunRequest{
 		FeatureKey: strings.TrimSpace(input.FeatureKey),
 		UserID:     input.User.ID,
 		WorldID:    strings.TrimSpace(input.WorldID),
 		Input:      input.Input,
 		Source:     source,
-	})
+	}
+	var result RunResult
+	var err error
+	if streamer, ok := input.Runner.(StreamingTaskRunner); ok && input.OnDelta != nil {
+		result, err = streamer.RunStream(ctx, runReq, input.OnDelta)
+	} else {
+		result, err = input.Runner.Run(ctx, runReq)
+		if err == nil && input.OnDelta != nil {
+			_ = input.OnDelta(result.Result)
+		}
+	}
 	if err != nil {
+		// 流式中途取消仍已消耗上游 token，部分结果按实际用量结算
+		if reservation != nil && input.OnDelta != nil && result.ProviderID != "" && UsageAvailable(result.Usage) {
+			status := "failed"
+			if ctx.Err() != nil {
+				status = "canceled"
+			}
+			if settleErr := settlePlatformRun(input, reservation, result, status, now); settleErr == nil {
+				settled = true
+				return BilledRunOutput{Result: result, Billed: true}, err
+			}
+		}
 		return BilledRunOutput{}, err
 	}
 	if !strings.EqualFold(source, "platform") {
@@ -75,9 +98,20 @@ func RunTaskWithBilling(ctx context.Context, input BilledRunInput) (BilledRunOut
 	if !UsageAvailable(result.Usage) {
 		return BilledRunOutput{}, errors.New("ai usage unavailable")
 	}
+	if reservation == nil {
+		return BilledRunOutput{}, errors.New("ai quota reservation missing")
+	}
+	if err := settlePlatformRun(input, reservation, result, "success", now); err != nil {
+		return BilledRunOutput{}, err
+	}
+	settled = true
+	return BilledRunOutput{Result: result, Billed: true}, nil
+}
+
+func settlePlatformRun(input BilledRunInput, reservation *model.AIQuotaReservationModel, result RunResult, status string, now time.Time) error {
 	pricing, err := ResolvePricing(input.Config, result.ProviderID, result.Model)
 	if err != nil {
-		return BilledRunOutput{}, err
+		return err
 	}
 	cost := CalculateUsageCost(result.Usage, *pricing)
 	startedAt := result.StartedAt
@@ -97,7 +131,7 @@ func RunTaskWithBilling(ctx context.Context, input BilledRunInput) (BilledRunOut
 		ProviderID:           result.ProviderID,
 		Model:                result.Model,
 		Source:               "platform",
-		Status:               "success",
+		Status:               status,
 		PromptTokens:         result.Usage.PromptTokens,
 		CompletionTokens:     result.Usage.CompletionTokens,
 		CacheTokens:          result.Usage.CacheTokens,
@@ -126,12 +160,5 @@ func RunTaskWithBilling(ctx context.Context, input BilledRunInput) (BilledRunOut
 		TotalCost:         cost.TotalCost,
 		LogID:             logItem.ID,
 	}
-	if reservation == nil {
-		return BilledRunOutput{}, errors.New("ai quota reservation missing")
-	}
-	if err := SettleQuotaReservation(reservation.ID, ledgerItem, logItem); err != nil {
-		return BilledRunOutput{}, err
-	}
-	settled = true
-	return BilledRunOutput{Result: result, Billed: true}, nil
+	return SettleQuotaReservation(reservation.ID, ledgerItem, logItem)
 }
@@ -1,8 +1,9 @@
 package ai
 
 const (
-	FeaturePolish        = "polish"
-	FeatureBattleSummary = "battle_summary"
+	FeaturePolish          = "polish"
+	FeatureBattleSummary   = "battle_summary"
+	FeatureGlossaryExtract = "glossary_extract"
 )
 
 type FeatureDefinition struct {
@@ -23,5 +24,10 @@ func BuiltinFeatures() map[string]FeatureDefinition {
 			Label:         "战报总结",
 			InputMaxChars: 12000,
 		},
+		FeatureGlossaryExtract: {
+			Key:           FeatureGlossaryExtract,
+			Label:         "术语提取",
+			InputMaxChars: 12000,
+		},
 	}
 }
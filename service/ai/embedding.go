@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"sealchat/utils"
+)
+
+type EmbeddingRequest struct {
+	Model      string
+	Inputs     []string
+	Dimensions int
+}
+
+type EmbeddingResult struct {
+	Vectors [][]float32
+	Model   string
+	Usage   RunUsage
+}
+
+type EmbeddingClient interface {
+	Embed(ctx context.Context, req EmbeddingRequest) (EmbeddingResult, error)
+}
+
+type openAIEmbeddingClient struct {
+	client *openai.Client
+}
+
+func newOpenAIEmbeddingClient(provider utils.AIProviderConfig) EmbeddingClient {
+	config := openai.DefaultConfig(provider.APIKey)
+	config.BaseURL = provider.BaseURL
+	return &openAIEmbeddingClient{client: openai.NewClientWithConfig(config)}
+}
+
+func (c *openAIEmbeddingClient) Embed(ctx context.Context, req EmbeddingRequest) (EmbeddingResult, error) {
+	if len(req.Inputs) == 0 {
+		return EmbeddingResult{}, nil
+	}
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input:      req.Inputs,
+		Model:      openai.EmbeddingModel(req.Model),
+		Dimensions: req.Dimensions,
+	})
+	if err != nil {
+		return EmbeddingResult{}, err
+	}
+	if len(resp.Data) != len(req.Inputs) {
+		return EmbeddingResult{}, fmt.Errorf("embedding count mismatch: got %d, want %d", len(resp.Data), len(req.Inputs))
+	}
+	// 服务端不保证按输入顺序返回，按 index 归位
+	vectors := make([][]float32, len(req.Inputs))
+	for _, item := range resp.Data {
+		if item.Index < 0 || item.Index >= len(vectors) {
+			return EmbeddingResult{}, errors.New("embedding index out of range")
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return EmbeddingResult{
+		Vectors: vectors,
+		Model:   string(resp.Model),
+		Usage:   RunUsage{PromptTokens: int64(resp.Usage.PromptTokens)},
+	}, nil
+}
+
+// NewEmbeddingClient 按 Embedding 配置选出服务商并创建客户端，返回实际使用的模型名
+func NewEmbeddingClient(cfg utils.AIConfig) (EmbeddingClient, string, error) {
+	cfg = utils.NormalizeAIConfig(cfg)
+	if !cfg.Embedding.Enabled {
+		return nil, "", errors.New("embedding disabled")
+	}
+	selector := &ProviderSelector{}
+	for _, provider := range selector.EnabledProviders(cfg) {
+		if cfg.Embedding.ProviderID != "" && !strings.EqualFold(provider.ID, cfg.Embedding.ProviderID) {
+			continue
+		}
+		return newOpenAIEmbeddingClient(provider), cfg.Embedding.Model, nil
+	}
+	return nil, "", errors.New("no embedding provider available")
+}
@@ -105,26 +105,67 @@ func NewRunner(cfgProvider func() *utils.AppConfig, clientFactory func(provider
 	}
 }
 
-func (r *Runner) Run(ctx context.Context, req RunRequest) (RunResult, error) {
+// runPlan 为一次调用解析出的输入、功能配置与按轮询顺序排列的服务商
+type runPlan struct {
+	featureKey string
+	input      string
+	source     string
+	featureCfg utils.AIFeatureConfig
+	retry      utils.AIRetryConfig
+	providers  []utils.AIProviderConfig
+}
+
+func (p *runPlan) completionRequest(provider utils.AIProviderConfig) CompletionRequest {
+	model := p.featureCfg.DefaultModel
+	if p.source == "user" && strings.TrimSpace(provider.SelectedModel) != "" {
+		model = strings.TrimSpace(provider.SelectedModel)
+	}
+	if model == "" && len(provider.Models) > 0 {
+		model = provider.Models[0]
+	}
+	return CompletionRequest{
+		Model:        model,
+		SystemPrompt: p.featureCfg.DefaultPrompt,
+		UserInput:    p.input,
+		Params:       p.featureCfg.Params,
+	}
+}
+
+func (p *runPlan) runResult(provider utils.AIProviderConfig, model string, result CompletionResult) RunResult {
+	if result.Model == "" {
+		result.Model = model
+	}
+	return RunResult{
+		FeatureKey: p.featureKey,
+		Result:     result.Text,
+		Model:      result.Model,
+		ProviderID: provider.ID,
+		Usage:      result.Usage,
+		StartedAt:  result.StartedAt,
+		FinishedAt: result.FinishedAt,
+	}
+}
+
+func (r *Runner) prepare(req RunRequest) (*runPlan, error) {
 	if r == nil || r.configProvider == nil {
-		return RunResult{}, errors.New("ai runner unavailable")
+		return nil, errors.New("ai runner unavailable")
 	}
 	appCfg := r.configProvider()
 	if appCfg == nil {
-		return RunResult{}, errors.New("ai config unavailable")
+		return nil, errors.New("ai config unavailable")
 	}
 	aiCfg := utils.NormalizeAIConfig(appCfg.AI)
 	if !IsFeatureAvailable(aiCfg, req.FeatureKey, req.UserID, req.WorldID) {
-		return RunResult{}, errors.New("ai feature unavailable")
+		return nil, errors.New("ai feature unavailable")
 	}
 	definition, ok := BuiltinFeatures()[req.FeatureKey]
 	if !ok {
-		return RunResult{}, fmt.Errorf("unknown ai feature: %s", req.FeatureKey)
+		return nil, fmt.Errorf("unknown ai feature: %s", req.FeatureKey)
 	}
 	featureCfg := aiCfg.Features[req.FeatureKey]
 	source := strings.ToLower(strings.TrimSpace(req.Source))
 	if featureCfg.UserCustomOnly && source != "user" {
-		return RunResult{}, FormatUserCustomProviderRequiredError(req.FeatureKey)
+		return nil, FormatUserCustomProviderRequiredError(req.FeatureKey)
 	}
 	maxInputChars := featureCfg.Params.MaxInputChars
 	if maxInputChars <= 0 {
@@ -132,60 +173,53 @@ func (r *Runner) Run(ctx context.Context, req RunRequest) (RunResult, error) {
 	}
 	input := strings.TrimSpace(req.Input)
 	if input == "" {
-		return RunResult{}, errors.New("ai input required")
+		return nil, errors.New("ai input required")
 	}
 	currentChars := len([]rune(input))
 	if maxInputChars > 0 && currentChars > maxInputChars {
-		return RunResult{}, FormatInputTooLongError(req.FeatureKey, currentChars, maxInputChars)
+		return nil, FormatInputTooLongError(req.FeatureKey, currentChars, maxInputChars)
 	}
 	if source == "user" {
 		userProviders, err := loadUserProviders(req.UserID)
 		if err != nil {
-			return RunResult{}, err
+			return nil, err
 		}
 		if len(userProviders) == 0 {
-			return RunResult{}, errors.New("no ai provider available")
+			return nil, errors.New("no ai provider available")
 		}
 		aiCfg.Providers = userProviders
 	}
 	startOffset := r.nextProviderOffset(aiCfg)
 	providers := r.selector.OrderedProviders(aiCfg, startOffset)
 	if len(providers) == 0 {
-		return RunResult{}, errors.New("no ai provider available")
+		return nil, errors.New("no ai provider available")
+	}
+	return &runPlan{
+		featureKey: req.FeatureKey,
+		input:      input,
+		source:     source,
+		featureCfg: featureCfg,
+		retry:      aiCfg.Retry,
+		providers:  providers,
+	}, nil
+}
+
+func (r *Runner) Run(ctx context.Context, req RunRequest) (RunResult, error) {
+	plan, err := r.prepare(req)
+	if err != nil {
+		return RunResult{}, err
 	}
 	var lastErr error
-	for _, provider := range providers {
+	for _, provider := range plan.providers {
 		client := r.clientFactory(provider)
 		if client == nil {
 			lastErr = fmt.Errorf("provider %s client unavailable", provider.ID)
 			continue
 		}
-		model := featureCfg.DefaultModel
-		if source == "user" && strings.TrimSpace(provider.SelectedModel) != "" {
-			model = strings.TrimSpace(provider.SelectedModel)
-		}
-		if model == "" && len(provider.Models) > 0 {
-			model = provider.Models[0]
-		}
-		result, err := r.completeWithRetry(ctx, client, CompletionRequest{
-			Model:        model,
-			SystemPrompt: featureCfg.DefaultPrompt,
-			UserInput:    input,
-			Params:       featureCfg.Params,
-		}, aiCfg.Retry)
+		completionReq := plan.completionRequest(provider)
+		result, err := r.completeWithRetry(ctx, client, completionReq, plan.retry)
 		if err == nil {
-			if result.Model == "" {
-				result.Model = model
-			}
-			return RunResult{
-				FeatureKey: req.FeatureKey,
-				Result:     result.Text,
-				Model:      result.Model,
-				ProviderID: provider.ID,
-				Usage:      result.Usage,
-				StartedAt:  result.StartedAt,
-				FinishedAt: result.FinishedAt,
-			}, nil
+			return plan.runResult(provider, completionReq.Model, result), nil
 		}
 		lastErr = err
 	}
@@ -208,6 +242,14 @@ func (r *Runner) nextProviderOffset(cfg utils.AIConfig) int {
 }
 
 func (r *Runner) completeWithRetry(ctx context.Context, client ChatClient, req CompletionRequest, retry utils.AIRetryConfig) (CompletionResult, error) {
+	return retryCompletion(ctx, retry, func() (CompletionResult, bool, error) {
+		result, err := client.Complete(ctx, req)
+		return result, true, err
+	})
+}
+
+// retryCompletion 按退避重试调用；attempt 返回 retryable=false 时立即结束，例如流式输出已经推送了部分内容
+func retryCompletion(ctx context.Context, retry utils.AIRetryConfig, attempt func() (CompletionResult, bool, error)) (CompletionResult, error) {
 	attempts := retry.MaxAttempts
 	if attempts <= 0 {
 		attempts = 1
@@ -225,13 +267,16 @@ func (r *Runner) completeWithRetry(ctx context.Context, client ChatClient, req C
 	}
 
 	var lastErr error
-	for attempt := 1; attempt <= attempts; attempt++ {
-		result, err := client.Complete(ctx, req)
+	for current := 1; current <= attempts; current++ {
+		result, retryable, err := attempt()
 		if err == nil {
 			return result, nil
 		}
+		if !retryable {
+			return result, err
+		}
 		lastErr = err
-		if attempt == attempts {
+		if current == attempts {
 			break
 		}
 		waitMs := delay
@@ -270,8 +315,7 @@ func newOpenAIChatClient(provider utils.AIProviderConfig) ChatClient {
 	return &openAIChatClient{client: openai.NewClientWithConfig(config)}
 }
 
-func (c *openAIChatClient) Complete(ctx context.Context, req CompletionRequest) (CompletionResult, error) {
-	startedAt := time.Now()
+func buildOpenAIChatRequest(req CompletionRequest) openai.ChatCompletionRequest {
 	request := openai.ChatCompletionRequest{
 		Model: req.Model,
 		Messages: []openai.ChatCompletionMessage{
@@ -286,25 +330,34 @@ func (c *openAIChatClient) Complete(ctx context.Context, req CompletionRequest)
 	if req.Params.TopP != nil {
 		request.TopP = *req.Params.TopP
 	}
-	resp, err := c.client.CreateChatCompletion(ctx, request)
+	return request
+}
+
+func usageFromOpenAI(usage openai.Usage) RunUsage {
+	cacheTokens := int64(0)
+	if usage.PromptTokensDetails != nil {
+		cacheTokens = int64(usage.PromptTokensDetails.CachedTokens)
+	}
+	return RunUsage{
+		PromptTokens:     int64(usage.PromptTokens),
+		CompletionTokens: int64(usage.CompletionTokens),
+		CacheTokens:      cacheTokens,
+	}
+}
+
+func (c *openAIChatClient) Complete(ctx context.Context, req CompletionRequest) (CompletionResult, error) {
+	startedAt := time.Now()
+	resp, err := c.client.CreateChatCompletion(ctx, buildOpenAIChatRequest(req))
 	if err != nil {
 		return CompletionResult{}, err
 	}
 	if len(resp.Choices) == 0 {
 		return CompletionResult{}, errors.New("empty ai response")
 	}
-	cacheTokens := int64(0)
-	if resp.Usage.PromptTokensDetails != nil {
-		cacheTokens = int64(resp.Usage.PromptTokensDetails.CachedTokens)
-	}
 	return CompletionResult{
-		Text:  resp.Choices[0].Message.Content,
-		Model: resp.Model,
-		Usage: RunUsage{
-			PromptTokens:     int64(resp.Usage.PromptTokens),
-			CompletionTokens: int64(resp.Usage.CompletionTokens),
-			CacheTokens:      cacheTokens,
-		},
+		Text:       resp.Choices[0].Message.Content,
+		Model:      resp.Model,
+		Usage:      usageFromOpenAI(resp.Usage),
 		StartedAt:  startedAt,
 		FinishedAt: time.Now(),
 	}, nil
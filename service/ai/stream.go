@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// StreamDeltaFunc 接收增量文本；返回错误会中止上游请求
+type StreamDeltaFunc func(delta string) error
+
+// StreamingChatClient 支持 stream: true 的服务商客户端
+type StreamingChatClient interface {
+	ChatClient
+	CompleteStream(ctx context.Context, req CompletionRequest, onDelta StreamDeltaFunc) (CompletionResult, error)
+}
+
+// StreamingTaskRunner 可逐段推送结果的任务执行器
+type StreamingTaskRunner interface {
+	TaskRunner
+	RunStream(ctx context.Context, req RunRequest, onDelta StreamDeltaFunc) (RunResult, error)
+}
+
+// RunStream 与 Run 相同地选择服务商与重试，但在首段内容推送前才允许重试或切换服务商。
+// 中途失败或取消时返回已生成的部分结果；服务商未返回用量时 Usage 为按字符估算的消耗，便于调用方结算。
+func (r *Runner) RunStream(ctx context.Context, req RunRequest, onDelta StreamDeltaFunc) (RunResult, error) {
+	plan, err := r.prepare(req)
+	if err != nil {
+		return RunResult{}, err
+	}
+	if onDelta == nil {
+		onDelta = func(string) error { return nil }
+	}
+	var lastErr error
+	for _, provider := range plan.providers {
+		client := r.clientFactory(provider)
+		if client == nil {
+			lastErr = fmt.Errorf("provider %s client unavailable", provider.ID)
+			continue
+		}
+		completionReq := plan.completionRequest(provider)
+		emitted := false
+		result, err := retryCompletion(ctx, plan.retry, func() (CompletionResult, bool, error) {
+			streamer, ok := client.(StreamingChatClient)
+			if !ok {
+				result, err := client.Complete(ctx, completionReq)
+				if err != nil {
+					return result, true, err
+				}
+				emitted = true
+				return result, false, onDelta(result.Text)
+			}
+			result, err := streamer.CompleteStream(ctx, completionReq, func(delta string) error {
+				emitted = true
+				return onDelta(delta)
+			})
+			return result, !emitted, err
+		})
+		if err == nil {
+			// 不少兼容服务商会忽略 include_usage，完整结果同样按估算结算
+			if !UsageAvailable(result.Usage) {
+				result.Usage = estimateStreamUsage(completionReq, result.Text)
+			}
+			return plan.runResult(provider, completionReq.Model, result), nil
+		}
+		if emitted {
+			if !UsageAvailable(result.Usage) {
+				result.Usage = estimateStreamUsage(completionReq, result.Text)
+			}
+			if result.FinishedAt.IsZero() {
+				result.FinishedAt = time.Now()
+			}
+			return plan.runResult(provider, completionReq.Model, result), err
+		}
+		if ctx.Err() != nil {
+			return RunResult{}, ctx.Err()
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no ai provider available")
+	}
+	return RunResult{}, lastErr
+}
+
+// estimateStreamUsage 与 EstimatePlatformReservation 一致按字符数近似 token 数
+func estimateStreamUsage(req CompletionRequest, partial string) RunUsage {
+	prompt := int64(len([]rune(req.SystemPrompt)) + len([]rune(req.UserInput)))
+	if prompt <= 0 {
+		prompt = 1
+	}
+	return RunUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: int64(len([]rune(partial))),
+	}
+}
+
+func (c *openAIChatClient) CompleteStream(ctx context.Context, req CompletionRequest, onDelta StreamDeltaFunc) (CompletionResult, error) {
+	startedAt := time.Now()
+	request := buildOpenAIChatRequest(req)
+	request.Stream = true
+	request.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := c.client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return CompletionResult{}, err
+	}
+	defer stream.Close()
+
+	var text strings.Builder
+	result := CompletionResult{StartedAt: startedAt}
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			result.Text = text.String()
+			result.FinishedAt = time.Now()
+			return result, err
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.Usage = usageFromOpenAI(*chunk.Usage)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			text.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				result.Text = text.String()
+				result.FinishedAt = time.Now()
+				return result, err
+			}
+		}
+	}
+	result.Text = text.String()
+	result.FinishedAt = time.Now()
+	if result.Text == "" {
+		return result, errors.New("empty ai response")
+	}
+	return result, nil
+}
@@ -44,6 +44,7 @@ type AppNotificationMessageSource struct {
 	WhisperRecipientIDs []string
 	MentionDisplayNames map[string]string
 	CreatedAt           time.Time
+	IsGroupDM           bool
 }
 
 type AppNotificationDeviceCandidate struct {
@@ -117,6 +118,10 @@ func BuildAppNotificationEvent(source AppNotificationMessageSource, recipientID
 	base := strings.TrimRight(strings.TrimSpace(webURL), "/")
 	openPath := base + "/#/" + url.PathEscape(source.WorldID) + "/" + url.PathEscape(source.ChannelID) + "?msg=" + url.QueryEscape(source.MessageID)
 	fallbackPath := base + "/#/" + url.PathEscape(source.WorldID) + "/" + url.PathEscape(source.ChannelID)
+	if source.IsGroupDM {
+		fallbackPath = base + "/#/group-dm/" + url.PathEscape(source.ChannelID)
+		openPath = fallbackPath + "?msg=" + url.QueryEscape(source.MessageID)
+	}
 	return AppNotificationEvent{
 		SchemaVersion: "1.0",
 		EventID:       "evt_" + utils.NewID(),
@@ -144,7 +149,9 @@ func ShouldDeliverAppNotification(source AppNotificationMessageSource, candidate
 	if strings.TrimSpace(candidate.UserID) == "" || candidate.UserID == source.SenderUserID || !candidate.CanRead {
 		return false
 	}
-	if candidate.WorldWhitelistEnabled {
+	if source.IsGroupDM {
+		// 多人私聊不属于任何世界，不受活跃世界与白名单限制
+	} else if candidate.WorldWhitelistEnabled {
 		if _, ok := candidate.WorldWhitelistIDs[source.WorldID]; !ok {
 			return false
 		}
@@ -180,12 +187,20 @@ func EnqueueAppNotificationForMessageWithMeow(messageID, webURL, publicOrigin, f
 		return err
 	}
 	channel, err := model.ChannelGet(message.ChannelID)
-	if err != nil || channel == nil || strings.TrimSpace(channel.WorldID) == "" {
+	if err != nil || channel == nil {
 		return err
 	}
-	world, err := GetWorldByID(channel.WorldID)
-	if err != nil || world == nil {
-		return err
+	isGroupDM := channel.IsGroupDM()
+	worldName := ""
+	if !isGroupDM {
+		if strings.TrimSpace(channel.WorldID) == "" {
+			return nil
+		}
+		world, err := GetWorldByID(channel.WorldID)
+		if err != nil || world == nil {
+			return err
+		}
+		worldName = world.Name
 	}
 	instanceID, err := model.EnsureAppNotificationInstanceID()
 	if err != nil {
@@ -221,7 +236,7 @@ func EnqueueAppNotificationForMessageWithMeow(messageID, webURL, publicOrigin, f
 		}
 	}
 	source := AppNotificationMessageSource{
-		WorldID: channel.WorldID, WorldName: world.Name,
+		WorldID: channel.WorldID, WorldName: worldName, IsGroupDM: isGroupDM,
 		ChannelID: channel.ID, ChannelName: channel.Name,
 		MessageID: message.ID, Content: message.Content,
 		SenderUserID: message.UserID, SenderName: senderName, SenderAvatarURL: senderAvatar,
@@ -233,7 +248,7 @@ func EnqueueAppNotificationForMessageWithMeow(messageID, webURL, publicOrigin, f
 	for _, device := range devices {
 		canRead, known := canReadByUser[device.UserID]
 		if !known {
-			canRead = IsWorldMember(channel.WorldID, device.UserID) && CanReadChannelByUserId(device.UserID, channel.ID)
+			canRead = appNotificationCanRead(source, device.UserID)
 			canReadByUser[device.UserID] = canRead
 		}
 		preference := preferences[device.UserID]
@@ -259,6 +274,17 @@ func EnqueueAppNotificationForMessageWithMeow(messageID, webURL, publicOrigin, f
 	return errors.Join(serverChanErr, barkErr, meowErr)
 }
 
+// appNotificationCanRead 判断接收者能否看到该消息：多人私聊按成员关系，其余按世界成员与频道权限
+func appNotificationCanRead(source AppNotificationMessageSource, userID string) bool {
+	var canRead bool
+	if source.IsGroupDM {
+		canRead = IsGroupDMMember(source.ChannelID, userID)
+	} else {
+		canRead = IsWorldMember(source.WorldID, userID) && CanReadChannelByUserId(userID, source.ChannelID)
+	}
+	return canRead && !UserHasBlocked(userID, source.SenderUserID)
+}
+
 func sendServerChanAppNotifications(source AppNotificationMessageSource, webURL string, canReadByUser map[string]bool) error {
 	preferences, err := model.ListServerChanAppNotificationPreferences()
 	if err != nil {
@@ -267,7 +293,7 @@ func sendServerChanAppNotifications(source AppNotificationMessageSource, webURL
 	for _, preference := range preferences {
 		canRead, known := canReadByUser[preference.UserID]
 		if !known {
-			canRead = IsWorldMember(source.WorldID, preference.UserID) && CanReadChannelByUserId(preference.UserID, source.ChannelID)
+			canRead = appNotificationCanRead(source, preference.UserID)
 			canReadByUser[preference.UserID] = canRead
 		}
 		candidate := AppNotificationDeviceCandidate{
@@ -338,7 +364,7 @@ func sendBarkAppNotifications(source AppNotificationMessageSource, webURL, publi
 	for _, preference := range preferences {
 		canRead, known := canReadByUser[preference.UserID]
 		if !known {
-			canRead = IsWorldMember(source.WorldID, preference.UserID) && CanReadChannelByUserId(preference.UserID, source.ChannelID)
+			canRead = appNotificationCanRead(source, preference.UserID)
 			canReadByUser[preference.UserID] = canRead
 		}
 		candidate := AppNotificationDeviceCandidate{
@@ -429,7 +455,7 @@ func sendMeowAppNotifications(source AppNotificationMessageSource, webURL, publi
 	for _, preference := range preferences {
 		canRead, known := canReadByUser[preference.UserID]
 		if !known {
-			canRead = IsWorldMember(source.WorldID, preference.UserID) && CanReadChannelByUserId(preference.UserID, source.ChannelID)
+			canRead = appNotificationCanRead(source, preference.UserID)
 			canReadByUser[preference.UserID] = canRead
 		}
 		candidate := AppNotificationDeviceCandidate{
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+// AppNotificationNotice 面向指定用户的系统通知（非消息类），例如审核结果或所有权变更。
+type AppNotificationNotice struct {
+	EventType string
+	Title     string
+	Body      string
+	DedupeKey string
+	WorldID   string
+	WorldName string
+	// OpenPath 为前端 hash 路由（不含 "/#"），为空时打开世界首页
+	OpenPath string
+}
+
+func BuildAppNotificationNoticeEvent(notice AppNotificationNotice, sequence uint64, instanceID, webURL string) AppNotificationEvent {
+	createdAt := time.Now().UTC()
+	title := truncateAppNotificationRunes(strings.TrimSpace(notice.Title), appNotificationTitleRuneLimit)
+	if title == "" {
+		title = "SealChat"
+	}
+	base := strings.TrimRight(strings.TrimSpace(webURL), "/")
+	fallbackPath := base + "/#/"
+	if notice.WorldID != "" {
+		fallbackPath = base + "/#/" + url.PathEscape(notice.WorldID)
+	}
+	openPath := fallbackPath
+	if path := strings.TrimSpace(notice.OpenPath); path != "" {
+		openPath = base + "/#/" + strings.TrimPrefix(path, "/")
+	}
+	dedupeKey := strings.TrimSpace(notice.DedupeKey)
+	if dedupeKey == "" {
+		dedupeKey = "notice:" + utils.NewID()
+	}
+	return AppNotificationEvent{
+		SchemaVersion: "1.0",
+		EventID:       "evt_" + utils.NewID(),
+		Sequence:      sequence,
+		EventType:     notice.EventType,
+		InstanceID:    instanceID,
+		CreatedAt:     createdAt,
+		ExpiresAt:     createdAt.Add(defaultAppNotificationRetention),
+		DedupeKey:     dedupeKey,
+		Notification: AppNotificationDisplay{
+			Channel: "system", Title: title,
+			Body:        truncateAppNotificationRunes(strings.TrimSpace(notice.Body), appNotificationBodyRuneLimit),
+			CollapseKey: notice.EventType,
+		},
+		Context: AppNotificationEventContext{
+			World: AppNotificationEntity{ID: notice.WorldID, Name: notice.WorldName},
+		},
+		Navigation: AppNotificationEventNavigation{OpenPath: openPath, FallbackPath: fallbackPath},
+	}
+}
+
+// EnqueueAppNotificationNotice 将系统通知投递到目标用户的全部已授权设备
+func EnqueueAppNotificationNotice(userIDs []string, notice AppNotificationNotice, webURL string) error {
+	userIDs = uniqueAppNotificationStrings(userIDs)
+	if len(userIDs) == 0 {
+		return nil
+	}
+	devices, err := model.ListActiveAppNotificationDevicesByUsers(userIDs)
+	if err != nil || len(devices) == 0 {
+		return err
+	}
+	instanceID, err := model.EnsureAppNotificationInstanceID()
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		sequence, err := model.AdvanceAppNotificationSequence(device.ID)
+		if err != nil {
+			return err
+		}
+		DefaultAppNotificationHub.Enqueue(device.ID, BuildAppNotificationNoticeEvent(notice, sequence, instanceID, webURL))
+	}
+	return nil
+}
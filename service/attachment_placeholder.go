@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+const (
+	imagePlaceholderBackfillBatch    = 50
+	imagePlaceholderBackfillInterval = 2 * time.Second
+	// imagePlaceholderMaxFileSize 超过该大小的存量图片不在回填中解码
+	imagePlaceholderMaxFileSize = 32 * 1024 * 1024
+)
+
+// ImagePlaceholderBackfillStats 存量图片占位信息回填统计
+type ImagePlaceholderBackfillStats struct {
+	Scanned   int64 `json:"scanned"`
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"`
+	Skipped   int64 `json:"skipped"` // 超出像素上限或无法解码，只记录能读到的尺寸
+}
+
+var imagePlaceholderBackfillOnce sync.Once
+
+var errImagePlaceholderTooLarge = errors.New("图片文件过大，跳过占位信息计算")
+
+// ComputeImagePlaceholderData 为图片数据计算尺寸与 ThumbHash，非图片或无法解码时返回空值
+func ComputeImagePlaceholderData(data []byte, mimeType string) utils.ImagePlaceholder {
+	if len(data) == 0 || !strings.HasPrefix(strings.ToLower(mimeType), "image/") {
+		return utils.ImagePlaceholder{}
+	}
+	placeholder, err := utils.ComputeImagePlaceholder(data)
+	if err != nil {
+		return utils.ImagePlaceholder{}
+	}
+	return placeholder
+}
+
+// computeImagePlaceholderFile 读取本地文件计算占位信息
+func computeImagePlaceholderFile(path, mimeType string) utils.ImagePlaceholder {
+	if !strings.HasPrefix(strings.ToLower(mimeType), "image/") {
+		return utils.ImagePlaceholder{}
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > imagePlaceholderMaxFileSize {
+		return utils.ImagePlaceholder{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return utils.ImagePlaceholder{}
+	}
+	return ComputeImagePlaceholderData(data, mimeType)
+}
+
+// StartImagePlaceholderBackfillWorker 启动后台任务，为缺少尺寸与 ThumbHash 的存量图片补全占位信息
+func StartImagePlaceholderBackfillWorker(ctx context.Context) {
+	imagePlaceholderBackfillOnce.Do(func() {
+		go runImagePlaceholderBackfillWorker(ctx)
+	})
+}
+
+func runImagePlaceholderBackfillWorker(ctx context.Context) {
+	var total ImagePlaceholderBackfillStats
+	cursor := ""
+	for {
+		stats, next, err := BackfillImagePlaceholders(cursor, imagePlaceholderBackfillBatch)
+		if err != nil {
+			log.Printf("image-placeholder: 回填失败: %v", err)
+			return
+		}
+		total.Scanned += stats.Scanned
+		total.Completed += stats.Completed
+		total.Failed += stats.Failed
+		total.Skipped += stats.Skipped
+		if next == "" {
+			if total.Scanned > 0 {
+				log.Printf("image-placeholder: 回填完成，扫描 %d，成功 %d，跳过 %d，失败 %d", total.Scanned, total.Completed, total.Skipped, total.Failed)
+			}
+			return
+		}
+		cursor = next
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(imagePlaceholderBackfillInterval):
+		}
+	}
+}
+
+// readImagePlaceholderSource 读取附件内容：本地文件直接读取，S3 对象经存储管理器下载到临时文件。
+// permanent 为 true 表示文件缺失或过大，重试也不会成功
+func readImagePlaceholderSource(att *model.AttachmentModel, cfg *utils.AppConfig) (data []byte, permanent bool, err error) {
+	if att.Size > imagePlaceholderMaxFileSize {
+		return nil, true, errImagePlaceholderTooLarge
+	}
+	if att.StorageType != model.StorageS3 {
+		path, err := resolveAttachmentFilePath(att, cfg)
+		if err != nil {
+			return nil, true, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, true, err
+		}
+		if info.Size() > imagePlaceholderMaxFileSize {
+			return nil, true, errImagePlaceholderTooLarge
+		}
+		data, err := os.ReadFile(path)
+		return data, err != nil, err
+	}
+	// 对象存储可能只是暂时不可达，失败后留待下次启动重试
+	tempPath, err := MaterializeAttachmentToTempFile(att)
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.Remove(tempPath)
+	data, err = os.ReadFile(tempPath)
+	return data, false, err
+}
+
+// BackfillImagePlaceholders 按 ID 顺序处理一批缺少占位信息的图片（含 S3 存储）；返回下一批的游标，处理完毕时为空。
+// 处理过的图片都会标记，超出像素上限或无法解码的不会在下次启动时重复扫描。
+func BackfillImagePlaceholders(afterID string, batchSize int) (*ImagePlaceholderBackfillStats, string, error) {
+	if batchSize <= 0 {
+		batchSize = imagePlaceholderBackfillBatch
+	}
+	db := model.GetDB()
+	query := db.Model(&model.AttachmentModel{}).
+		Where("thumb_hash = '' OR thumb_hash IS NULL").
+		Where("placeholder_checked = ?", false).
+		Where("mime_type LIKE ?", "image/%")
+	if afterID != "" {
+		query = query.Where("id > ?", afterID)
+	}
+	var attachments []*model.AttachmentModel
+	if err := query.Order("id asc").Limit(batchSize).Find(&attachments).Error; err != nil {
+		return nil, "", err
+	}
+
+	stats := &ImagePlaceholderBackfillStats{Scanned: int64(len(attachments))}
+	cfg := utils.GetConfig()
+	for _, att := range attachments {
+		// 共用同一文件的附件记录一并更新
+		scope := db.Model(&model.AttachmentModel{}).Where("id = ?", att.ID)
+		if att.ObjectKey != "" {
+			scope = db.Model(&model.AttachmentModel{}).Where("object_key = ? AND (thumb_hash = '' OR thumb_hash IS NULL)", att.ObjectKey)
+		}
+		data, permanent, err := readImagePlaceholderSource(att, cfg)
+		if err != nil {
+			if permanent {
+				_ = scope.Update("placeholder_checked", true).Error
+			}
+			stats.Failed++
+			continue
+		}
+		placeholder := ComputeImagePlaceholderData(data, att.MimeType)
+		if err := scope.Updates(map[string]any{
+			"width":               placeholder.Width,
+			"height":              placeholder.Height,
+			"thumb_hash":          placeholder.ThumbHash,
+			"placeholder_checked": true,
+		}).Error; err != nil {
+			stats.Failed++
+			continue
+		}
+		if placeholder.ThumbHash == "" {
+			stats.Skipped++
+			continue
+		}
+		stats.Completed++
+	}
+	if len(attachments) < batchSize {
+		return stats, "", nil
+	}
+	return stats, attachments[len(attachments)-1].ID, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+func TestBackfillImagePlaceholdersFillsSharedAttachments(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	InitStorageManager(utils.StorageConfig{Mode: utils.StorageModeLocal, Local: utils.LocalStorageConfig{UploadDir: t.TempDir(), TempDir: t.TempDir()}})
+	createExternalGlossaryTestUser(t, "placeholder-user")
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 6, 9))); err != nil {
+		t.Fatalf("encode png failed: %v", err)
+	}
+	data := encoded.Bytes()
+	tempPath := filepath.Join(t.TempDir(), "old.png")
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		t.Fatalf("write temp file failed: %v", err)
+	}
+	hash := sha256.Sum256(data)
+	location, err := PersistAttachmentFile(hash[:], int64(len(data)), tempPath, "image/png")
+	if err != nil {
+		t.Fatalf("persist attachment failed: %v", err)
+	}
+	source := &model.AttachmentModel{
+		Hash: hash[:], Filename: "old.png", Size: int64(len(data)), MimeType: "image/png", UserID: "placeholder-user",
+		StorageType: location.StorageType, ObjectKey: location.ObjectKey,
+	}
+	if tx, _ := model.AttachmentCreate(source); tx.Error != nil {
+		t.Fatalf("create attachment failed: %v", tx.Error)
+	}
+	copied := *source
+	copied.ID = ""
+	if tx, _ := model.AttachmentCreate(&copied); tx.Error != nil {
+		t.Fatalf("create shared attachment failed: %v", tx.Error)
+	}
+	other := &model.AttachmentModel{Hash: []byte("doc"), Filename: "notes.txt", Size: 3, MimeType: "text/plain", UserID: "placeholder-user"}
+	if tx, _ := model.AttachmentCreate(other); tx.Error != nil {
+		t.Fatalf("create text attachment failed: %v", tx.Error)
+	}
+
+	stats, next, err := BackfillImagePlaceholders("", 10)
+	if err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+	if next != "" || stats.Completed < 1 || stats.Failed != 0 {
+		t.Fatalf("unexpected backfill result: %+v next=%q", stats, next)
+	}
+	placeholders, err := model.AttachmentImagePlaceholdersByIDs(model.GetDB(), []string{source.ID, copied.ID, other.ID})
+	if err != nil {
+		t.Fatalf("load placeholders failed: %v", err)
+	}
+	if len(placeholders) != 2 {
+		t.Fatalf("both image records should be filled, got %d", len(placeholders))
+	}
+	got := placeholders[copied.ID]
+	if got == nil || got.Width != 6 || got.Height != 9 || got.ThumbHash == "" {
+		t.Fatalf("unexpected placeholder: %+v", got)
+	}
+
+	stats, _, err = BackfillImagePlaceholders("", 10)
+	if err != nil || stats.Scanned != 0 {
+		t.Fatalf("filled attachments should not be rescanned: %+v %v", stats, err)
+	}
+}
+
+func TestBackfillImagePlaceholdersMarksUndecodableImages(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	InitStorageManager(utils.StorageConfig{Mode: utils.StorageModeLocal, Local: utils.LocalStorageConfig{UploadDir: t.TempDir(), TempDir: t.TempDir()}})
+	createExternalGlossaryTestUser(t, "placeholder-broken")
+
+	data := []byte("not really a png")
+	tempPath := filepath.Join(t.TempDir(), "broken.png")
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		t.Fatalf("write temp file failed: %v", err)
+	}
+	hash := sha256.Sum256(data)
+	location, err := PersistAttachmentFile(hash[:], int64(len(data)), tempPath, "image/png")
+	if err != nil {
+		t.Fatalf("persist attachment failed: %v", err)
+	}
+	broken := &model.AttachmentModel{
+		Hash: hash[:], Filename: "broken.png", Size: int64(len(data)), MimeType: "image/png", UserID: "placeholder-broken",
+		StorageType: location.StorageType, ObjectKey: location.ObjectKey,
+	}
+	if tx, _ := model.AttachmentCreate(broken); tx.Error != nil {
+		t.Fatalf("create attachment failed: %v", tx.Error)
+	}
+	missing := &model.AttachmentModel{
+		Hash: []byte("missing"), Filename: "missing.png", Size: 10, MimeType: "image/png", UserID: "placeholder-broken",
+		StorageType: model.StorageLocal, ObjectKey: "attachments/missing.png",
+	}
+	if tx, _ := model.AttachmentCreate(missing); tx.Error != nil {
+		t.Fatalf("create missing attachment failed: %v", tx.Error)
+	}
+
+	stats, _, err := BackfillImagePlaceholders("", 10)
+	if err != nil || stats.Skipped != 1 || stats.Failed != 1 || stats.Completed != 0 {
+		t.Fatalf("unexpected backfill result: %+v %v", stats, err)
+	}
+	stats, _, err = BackfillImagePlaceholders("", 10)
+	if err != nil || stats.Scanned != 0 {
+		t.Fatalf("processed images should not be rescanned: %+v %v", stats, err)
+	}
+}
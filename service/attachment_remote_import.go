@@ -71,6 +71,34 @@ func ImportAttachmentFromURL(input RemoteAttachmentImportInput) (*model.Attachme
 		return nil, ErrRemoteAttachmentTooLarge
 	}
 
+	if strings.TrimSpace(input.ContentType) == "" {
+		input.ContentType = strings.TrimSpace(resp.Header.Get("Content-Type"))
+	}
+	if strings.TrimSpace(input.Filename) == "" {
+		if base := path.Base(parsed.Path); base != "" && base != "." && base != "/" {
+			input.Filename = base
+		}
+	}
+	input.MaxSizeBytes = maxSize
+	return ImportAttachmentFromReader(resp.Body, input)
+}
+
+// ImportAttachmentFromReader 将数据流保存为附件，input 中的 URL 与 HTTPClient 不使用
+func ImportAttachmentFromReader(reader io.Reader, input RemoteAttachmentImportInput) (*model.AttachmentModel, error) {
+	if reader == nil {
+		return nil, errors.New("附件数据为空")
+	}
+	if strings.TrimSpace(input.UserID) == "" {
+		return nil, errors.New("缺少用户 ID")
+	}
+	if GetStorageManager() == nil {
+		return nil, errors.New("存储服务未初始化")
+	}
+	maxSize := input.MaxSizeBytes
+	if maxSize <= 0 {
+		maxSize = 20 * 1024 * 1024
+	}
+
 	tempFile, err := os.CreateTemp("", "sealchat-remote-attachment-*")
 	if err != nil {
 		return nil, fmt.Errorf("创建临时文件失败: %w", err)
@@ -82,7 +110,7 @@ func ImportAttachmentFromURL(input RemoteAttachmentImportInput) (*model.Attachme
 	}()
 
 	hasher := sha256.New()
-	limited := io.LimitReader(resp.Body, maxSize+1)
+	limited := io.LimitReader(reader, maxSize+1)
 	buffer := make([]byte, 32*1024)
 	headerBuf := make([]byte, 0, 512)
 	var total int64
@@ -123,9 +151,6 @@ func ImportAttachmentFromURL(input RemoteAttachmentImportInput) (*model.Attachme
 	}
 
 	contentType := strings.TrimSpace(input.ContentType)
-	if contentType == "" {
-		contentType = strings.TrimSpace(resp.Header.Get("Content-Type"))
-	}
 	if contentType == "" {
 		contentType = http.DetectContentType(headerBuf)
 	}
@@ -134,31 +159,39 @@ func ImportAttachmentFromURL(input RemoteAttachmentImportInput) (*model.Attachme
 	}
 
 	filename := strings.TrimSpace(input.Filename)
-	if filename == "" {
-		if base := path.Base(parsed.Path); base != "" && base != "." && base != "/" {
-			filename = base
-		}
-	}
 	if filename == "" {
 		filename = "remote-attachment"
 	}
 
 	hashBytes := hasher.Sum(nil)
+	sanitizedHash, sanitizedSize, metadataStripped, err := sanitizeImageFileInPlace(tempPath, contentType, sha256.New)
+	if err != nil {
+		return nil, err
+	}
+	if metadataStripped {
+		hashBytes, total = sanitizedHash, sanitizedSize
+	}
+	placeholder := computeImagePlaceholderFile(tempPath, contentType)
 	location, err := PersistAttachmentFile(hashBytes, total, tempPath, contentType)
 	if err != nil {
 		return nil, err
 	}
 	_, item := model.AttachmentCreate(&model.AttachmentModel{
-		Filename:    filename,
-		Size:        total,
-		Hash:        hashBytes,
-		MimeType:    contentType,
-		UserID:      strings.TrimSpace(input.UserID),
-		ChannelID:   strings.TrimSpace(input.ChannelID),
-		StorageType: location.StorageType,
-		ObjectKey:   location.ObjectKey,
-		ExternalURL: location.ExternalURL,
+		Filename:         filename,
+		Size:             total,
+		Hash:             hashBytes,
+		MimeType:         contentType,
+		UserID:           strings.TrimSpace(input.UserID),
+		ChannelID:        strings.TrimSpace(input.ChannelID),
+		StorageType:      location.StorageType,
+		ObjectKey:        location.ObjectKey,
+		ExternalURL:      location.ExternalURL,
+		MetadataStripped: metadataStripped,
+		Width:            placeholder.Width,
+		Height:           placeholder.Height,
+		ThumbHash:        placeholder.ThumbHash,
 	})
+	EnqueueAttachmentVideo(item)
 	return item, nil
 }
 
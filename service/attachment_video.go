@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+const attachmentVideoRootType = "attachment_video"
+
+// AttachmentVideoStateNotifier 视频处理状态变化后回调，由 api 层注入用于推送 attachment.video.* 事件
+var AttachmentVideoStateNotifier func(video *model.AttachmentVideoModel)
+
+type attachmentVideoService struct {
+	config    utils.AttachmentVideoConfig
+	toolchain MediaToolchain
+	runner    MediaCommandRunner
+	queue     chan string
+	ctx       context.Context
+	cancel    context.CancelFunc
+	once      sync.Once
+}
+
+var attachmentVideo = &attachmentVideoService{}
+
+// InitAttachmentVideoService 启动聊天视频附件的探测与转码队列；未启用或缺少 ffmpeg/ffprobe 时不处理视频
+func InitAttachmentVideoService(config utils.AttachmentVideoConfig, toolchain MediaToolchain) {
+	attachmentVideo.once.Do(func() {
+		attachmentVideo.config = normalizeAttachmentVideoConfig(config)
+		attachmentVideo.toolchain = toolchain
+		if !attachmentVideo.available() {
+			return
+		}
+		attachmentVideo.runner = execMediaCommandRunner{}
+		attachmentVideo.ctx, attachmentVideo.cancel = context.WithCancel(context.Background())
+		attachmentVideo.queue = make(chan string, 256)
+		// 上次退出时未完成的任务重新排队
+		_ = model.GetDB().Model(&model.AttachmentVideoModel{}).
+			Where("status IN ?", []string{model.AttachmentVideoStatusProbing, model.AttachmentVideoStatusTranscoding}).
+			Updates(map[string]any{"status": model.AttachmentVideoStatusPending, "progress": 0}).Error
+		for index := 0; index < attachmentVideo.config.WorkerConcurrency; index++ {
+			go attachmentVideoWorker(attachmentVideo.ctx, attachmentVideo)
+		}
+		go attachmentVideo.scanPendingJobs(attachmentVideo.ctx)
+	})
+}
+
+func normalizeAttachmentVideoConfig(config utils.AttachmentVideoConfig) utils.AttachmentVideoConfig {
+	if config.WorkerConcurrency <= 0 {
+		config.WorkerConcurrency = 1
+	}
+	if config.MaxDimension <= 0 {
+		config.MaxDimension = 1280
+	}
+	if config.MaxSourceSizeMB <= 0 {
+		config.MaxSourceSizeMB = 1024
+	}
+	if config.MaxDurationSeconds <= 0 {
+		config.MaxDurationSeconds = 1800
+	}
+	if config.MaxRenditionSizeMB <= 0 {
+		config.MaxRenditionSizeMB = 200
+	}
+	if config.VideoBitrateKbps <= 0 {
+		config.VideoBitrateKbps = 2500
+	}
+	if config.ProbeTimeoutSeconds <= 0 {
+		config.ProbeTimeoutSeconds = 30
+	}
+	if config.TranscodeTimeoutSeconds <= 0 {
+		config.TranscodeTimeoutSeconds = 1800
+	}
+	return config
+}
+
+func (service *attachmentVideoService) available() bool {
+	return service.config.Enabled && service.toolchain.FFprobeAvailable() && service.toolchain.FFmpegAvailable()
+}
+
+// EnqueueAttachmentVideo 为新上传的视频附件创建处理记录并排队；同一文件已处理过时直接复用结果
+func EnqueueAttachmentVideo(att *model.AttachmentModel) {
+	if att == nil || att.ID == "" || !strings.HasPrefix(strings.ToLower(att.MimeType), "video/") {
+		return
+	}
+	service := attachmentVideo
+	if service.queue == nil {
+		return
+	}
+	video := &model.AttachmentVideoModel{
+		AttachmentID: att.ID,
+		UserID:       att.UserID,
+		ChannelID:    att.ChannelID,
+		Status:       model.AttachmentVideoStatusPending,
+	}
+	if existing := findReadyAttachmentVideoByObjectKey(att); existing != nil {
+		video.Status = model.AttachmentVideoStatusReady
+		video.Progress = 1
+		video.DurationMS = existing.DurationMS
+		video.Width = existing.Width
+		video.Height = existing.Height
+		video.Container = existing.Container
+		video.VideoCodec = existing.VideoCodec
+		video.AudioCodec = existing.AudioCodec
+		video.PosterAttachmentID = existing.PosterAttachmentID
+		video.RenditionAttachmentID = existing.RenditionAttachmentID
+		video.RenditionMimeType = existing.RenditionMimeType
+		video.RenditionSize = existing.RenditionSize
+	}
+	video.ID = utils.NewID()
+	if err := model.GetDB().Create(video).Error; err != nil {
+		log.Printf("attachment-video: 创建处理记录失败 %s: %v", att.ID, err)
+		return
+	}
+	notifyAttachmentVideoState(video)
+	if video.Status == model.AttachmentVideoStatusPending {
+		service.enqueue(att.ID)
+	}
+}
+
+// RetryAttachmentVideo 将可重试的失败任务重新排队
+func RetryAttachmentVideo(attachmentID string) (*model.AttachmentVideoModel, error) {
+	video, err := model.AttachmentVideoGet(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if video == nil {
+		return nil, errors.New("视频处理记录不存在")
+	}
+	if video.Status != model.AttachmentVideoStatusFailed || !video.Retryable {
+		return nil, errors.New("当前状态不可重试")
+	}
+	if err := model.GetDB().Model(&model.AttachmentVideoModel{}).Where("id = ?", video.ID).
+		Updates(map[string]any{"status": model.AttachmentVideoStatusPending, "progress": 0, "failure_code": "", "failure_message": "", "retryable": false}).Error; err != nil {
+		return nil, err
+	}
+	video.Status = model.AttachmentVideoStatusPending
+	video.Progress = 0
+	video.FailureCode = ""
+	video.FailureMessage = ""
+	video.Retryable = false
+	notifyAttachmentVideoState(video)
+	attachmentVideo.enqueue(attachmentID)
+	return video, nil
+}
+
+func findReadyAttachmentVideoByObjectKey(att *model.AttachmentModel) *model.AttachmentVideoModel {
+	if strings.TrimSpace(att.ObjectKey) == "" {
+		return nil
+	}
+	var existing model.AttachmentVideoModel
+	err := model.GetDB().Model(&model.AttachmentVideoModel{}).
+		Joins("JOIN attachments ON attachments.id = attachment_videos.attachment_id").
+		Where("attachments.object_key = ? AND attachment_videos.status = ?", att.ObjectKey, model.AttachmentVideoStatusReady).
+		Order("attachment_videos.created_at ASC").
+		Limit(1).
+		Find(&existing).Error
+	if err != nil || existing.ID == "" {
+		return nil
+	}
+	return &existing
+}
+
+func (service *attachmentVideoService) enqueue(attachmentID string) {
+	if service == nil || service.queue == nil {
+		return
+	}
+	select {
+	case service.queue <- attachmentID:
+	default:
+	}
+}
+
+func (service *attachmentVideoService) scanPendingJobs(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for {
+		var ids []string
+		_ = model.GetDB().Model(&model.AttachmentVideoModel{}).Where("status = ?", model.AttachmentVideoStatusPending).Limit(100).Pluck("attachment_id", &ids).Error
+		for _, id := range ids {
+			service.enqueue(id)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func attachmentVideoWorker(ctx context.Context, service *attachmentVideoService) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case attachmentID := <-service.queue:
+			service.process(ctx, attachmentID)
+		}
+	}
+}
+
+func (service *attachmentVideoService) process(ctx context.Context, attachmentID string) {
+	claim := model.GetDB().Model(&model.AttachmentVideoModel{}).
+		Where("attachment_id = ? AND status = ?", attachmentID, model.AttachmentVideoStatusPending).
+		Updates(map[string]any{"status": model.AttachmentVideoStatusProbing, "progress": 0.1, "failure_code": "", "failure_message": "", "retryable": false})
+	if claim.Error != nil || claim.RowsAffected != 1 {
+		return
+	}
+	video, err := model.AttachmentVideoGet(attachmentID)
+	if err != nil || video == nil {
+		return
+	}
+	notifyAttachmentVideoState(video)
+
+	var att model.AttachmentModel
+	if err := model.GetDB().Where("id = ?", attachmentID).Limit(1).Find(&att).Error; err != nil || att.ID == "" {
+		attachmentVideoFailure(video, TheaterMediaErrorProbeFailed, errors.New("附件不存在"), false)
+		return
+	}
+	path, cleanup, err := resolveTheaterAttachmentPath(ctx, &att)
+	if err != nil {
+		attachmentVideoFailure(video, TheaterMediaErrorProbeFailed, err, true)
+		return
+	}
+	defer cleanup()
+
+	metadata, err := service.probe(ctx, path, att.MimeType)
+	if err != nil {
+		attachmentVideoFailure(video, TheaterMediaErrorProbeFailed, err, false)
+		return
+	}
+	video.DurationMS = metadata.DurationMS
+	video.Width = metadata.Width
+	video.Height = metadata.Height
+	video.Container = metadata.Container
+	video.VideoCodec = metadata.VideoCodec
+	video.AudioCodec = metadata.AudioCodec
+
+	tempDir, err := os.MkdirTemp("", "sealchat-attachment-video-*")
+	if err != nil {
+		attachmentVideoFailure(video, TheaterMediaErrorTranscodeFailed, err, true)
+		return
+	}
+	defer os.RemoveAll(tempDir)
+
+	poster, err := service.generatePoster(ctx, path, tempDir, &att, metadata)
+	if err != nil {
+		attachmentVideoFailure(video, TheaterMediaErrorTranscodeFailed, err, true)
+		return
+	}
+	video.PosterAttachmentID = poster.ID
+
+	if service.shouldTranscode(&att, metadata) {
+		video.Status = model.AttachmentVideoStatusTranscoding
+		video.Progress = 0.5
+		_ = model.GetDB().Model(&model.AttachmentVideoModel{}).Where("id = ?", video.ID).
+			Updates(map[string]any{"status": video.Status, "progress": video.Progress, "poster_attachment_id": video.PosterAttachmentID}).Error
+		notifyAttachmentVideoState(video)
+		rendition, err := service.transcodeRendition(ctx, path, tempDir, &att)
+		if err != nil {
+			attachmentVideoFailure(video, TheaterMediaErrorTranscodeFailed, err, true)
+			return
+		}
+		if rendition != nil {
+			video.RenditionAttachmentID = rendition.ID
+			video.RenditionMimeType = rendition.MimeType
+			video.RenditionSize = rendition.Size
+		}
+	}
+
+	video.Status = model.AttachmentVideoStatusReady
+	video.Progress = 1
+	if err := model.GetDB().Model(&model.AttachmentVideoModel{}).Where("id = ?", video.ID).Updates(map[string]any{
+		"status": video.Status, "progress": video.Progress, "duration_ms": video.DurationMS,
+		"width": video.Width, "height": video.Height, "container": video.Container,
+		"video_codec": video.VideoCodec, "audio_codec": video.AudioCodec,
+		"poster_attachment_id": video.PosterAttachmentID, "rendition_attachment_id": video.RenditionAttachmentID,
+		"rendition_mime_type": video.RenditionMimeType, "rendition_size": video.RenditionSize,
+		"failure_code": "", "failure_message": "", "retryable": false,
+	}).Error; err != nil {
+		attachmentVideoFailure(video, TheaterMediaErrorTranscodeFailed, err, true)
+		return
+	}
+	// 视频附件沿用图片的尺寸与 ThumbHash 字段，客户端可在封面加载前显示占位
+	_ = model.GetDB().Model(&model.AttachmentModel{}).Where("id = ?", att.ID).Updates(map[string]any{
+		"width": metadata.Width, "height": metadata.Height, "thumb_hash": poster.ThumbHash,
+	}).Error
+	notifyAttachmentVideoState(video)
+}
+
+func (service *attachmentVideoService) probe(ctx context.Context, path, mimeType string) (theaterMediaMetadata, error) {
+	probeCtx, cancel := context.WithTimeout(ctx, time.Duration(service.config.ProbeTimeoutSeconds)*time.Second)
+	defer cancel()
+	output, err := service.runner.Run(probeCtx, service.toolchain.FFprobePath, "-v", "error", "-show_format", "-show_streams", "-of", "json", path)
+	if err != nil {
+		return theaterMediaMetadata{}, fmt.Errorf("%s: %s: %w", TheaterMediaErrorProbeFailed, truncateTheaterBroadcastError(string(output)), err)
+	}
+	return parseFFprobeMetadata(output, mimeType)
+}
+
+// generatePoster 截取开头附近的一帧作为封面，保存为独立的 JPEG 附件
+func (service *attachmentVideoService) generatePoster(ctx context.Context, sourcePath, tempDir string, att *model.AttachmentModel, metadata theaterMediaMetadata) (*model.AttachmentModel, error) {
+	offset := int64(1000)
+	if metadata.DurationMS > 0 && metadata.DurationMS/2 < offset {
+		offset = metadata.DurationMS / 2
+	}
+	posterPath := filepath.Join(tempDir, "poster.jpg")
+	posterCtx, cancel := context.WithTimeout(ctx, time.Duration(service.config.ProbeTimeoutSeconds)*time.Second)
+	defer cancel()
+	output, err := service.runner.Run(posterCtx, service.toolchain.FFmpegPath, "-y",
+		"-ss", strconv.FormatFloat(float64(offset)/1000, 'f', 3, 64), "-i", sourcePath,
+		"-frames:v", "1", "-vf", attachmentVideoScaleFilter(service.config.MaxDimension), "-q:v", "3", posterPath)
+	if err != nil {
+		return nil, fmt.Errorf("poster: %s: %w", truncateTheaterBroadcastError(string(output)), err)
+	}
+	return persistAttachmentVideoDerived(att, "poster", posterPath, "image/jpeg", 0)
+}
+
+// shouldTranscode 编码不被浏览器普遍支持或分辨率超过上限时转码；超过源文件大小或时长上限的视频只生成封面
+func (service *attachmentVideoService) shouldTranscode(att *model.AttachmentModel, metadata theaterMediaMetadata) bool {
+	if !service.config.Transcode {
+		return false
+	}
+	if att.Size > service.config.MaxSourceSizeMB*1024*1024 {
+		return false
+	}
+	if metadata.DurationMS > service.config.MaxDurationSeconds*1000 {
+		return false
+	}
+	if max(metadata.Width, metadata.Height) > service.config.MaxDimension {
+		return true
+	}
+	return !isWebSafeVideo(att.MimeType, metadata)
+}
+
+func isWebSafeVideo(mimeType string, metadata theaterMediaMetadata) bool {
+	switch strings.ToLower(mimeType) {
+	case "video/mp4":
+		if metadata.VideoCodec != "h264" {
+			return false
+		}
+		return metadata.AudioCodec == "" || metadata.AudioCodec == "aac" || metadata.AudioCodec == "mp3"
+	case "video/webm":
+		if metadata.VideoCodec != "vp8" && metadata.VideoCodec != "vp9" && metadata.VideoCodec != "av1" {
+			return false
+		}
+		return metadata.AudioCodec == "" || metadata.AudioCodec == "opus" || metadata.AudioCodec == "vorbis"
+	default:
+		return false
+	}
+}
+
+// transcodeRendition 生成 H.264 mp4，失败时回退 VP9 webm；结果超过大小上限时放弃并返回 nil
+func (service *attachmentVideoService) transcodeRendition(ctx context.Context, sourcePath, tempDir string, att *model.AttachmentModel) (*model.AttachmentModel, error) {
+	transcodeCtx, cancel := context.WithTimeout(ctx, time.Duration(service.config.TranscodeTimeoutSeconds)*time.Second)
+	defer cancel()
+	scale := attachmentVideoScaleFilter(service.config.MaxDimension)
+	bitrate := fmt.Sprintf("%dk", service.config.VideoBitrateKbps)
+	bufsize := fmt.Sprintf("%dk", service.config.VideoBitrateKbps*2)
+	renditionPath := filepath.Join(tempDir, "rendition.mp4")
+	mimeType := "video/mp4"
+	output, err := service.runner.Run(transcodeCtx, service.toolchain.FFmpegPath, "-y", "-i", sourcePath, "-map", "0:v:0", "-map", "0:a:0?", "-vf", scale, "-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-maxrate", bitrate, "-bufsize", bufsize, "-pix_fmt", "yuv420p", "-c:a", "aac", "-b:a", "128k", "-movflags", "+faststart", renditionPath)
+	if err != nil {
+		renditionPath = filepath.Join(tempDir, "rendition.webm")
+		mimeType = "video/webm"
+		var fallbackErr error
+		output, fallbackErr = service.runner.Run(transcodeCtx, service.toolchain.FFmpegPath, "-y", "-i", sourcePath, "-map", "0:v:0", "-map", "0:a:0?", "-vf", scale, "-c:v", "libvpx-vp9", "-crf", "32", "-b:v", bitrate, "-c:a", "libopus", "-b:a", "96k", renditionPath)
+		if fallbackErr != nil {
+			return nil, fmt.Errorf("rendition: %s: %w", truncateTheaterBroadcastError(string(output)), fallbackErr)
+		}
+	}
+	info, err := os.Stat(renditionPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > service.config.MaxRenditionSizeMB*1024*1024 {
+		log.Printf("attachment-video: 转码结果过大，保留原文件播放 %s (%d bytes)", att.ID, info.Size())
+		return nil, nil
+	}
+	return persistAttachmentVideoDerived(att, "rendition", renditionPath, mimeType, info.Size())
+}
+
+func attachmentVideoScaleFilter(maxDimension int) string {
+	return fmt.Sprintf("scale=min(%d\\,iw):min(%d\\,ih):force_original_aspect_ratio=decrease:force_divisible_by=2", maxDimension, maxDimension)
+}
+
+func persistAttachmentVideoDerived(att *model.AttachmentModel, name, path, mimeType string, size int64) (*model.AttachmentModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		size = int64(len(data))
+	}
+	hash := sha256.Sum256(data)
+	placeholder := ComputeImagePlaceholderData(data, mimeType)
+	location, err := PersistAttachmentFile(hash[:], size, path, mimeType)
+	if err != nil {
+		return nil, err
+	}
+	derived := &model.AttachmentModel{
+		Hash: hash[:], Filename: name + filepath.Ext(path), Size: size, MimeType: mimeType,
+		UserID: att.UserID, ChannelID: att.ChannelID,
+		StorageType: location.StorageType, ObjectKey: location.ObjectKey, ExternalURL: location.ExternalURL,
+		RootID: att.ID, RootIDType: attachmentVideoRootType,
+		Width: placeholder.Width, Height: placeholder.Height, ThumbHash: placeholder.ThumbHash,
+	}
+	if tx, _ := model.AttachmentCreate(derived); tx.Error != nil {
+		return nil, tx.Error
+	}
+	return derived, nil
+}
+
+func attachmentVideoFailure(video *model.AttachmentVideoModel, code string, err error, retryable bool) {
+	message := ""
+	if err != nil {
+		message = err.Error()
+		log.Printf("attachment-video: 处理失败 %s: %v", video.AttachmentID, err)
+	}
+	video.Status = model.AttachmentVideoStatusFailed
+	video.Progress = 0
+	video.FailureCode = code
+	video.FailureMessage = message
+	video.Retryable = retryable
+	_ = model.GetDB().Model(&model.AttachmentVideoModel{}).Where("id = ?", video.ID).Updates(map[string]any{
+		"status": video.Status, "progress": 0, "failure_code": code, "failure_message": message, "retryable": retryable,
+	}).Error
+	notifyAttachmentVideoState(video)
+}
+
+func notifyAttachmentVideoState(video *model.AttachmentVideoModel) {
+	if AttachmentVideoStateNotifier != nil && video != nil {
+		AttachmentVideoStateNotifier(video)
+	}
+}
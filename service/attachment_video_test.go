@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+type fakeAttachmentVideoRunner struct {
+	probe  string
+	poster []byte
+	calls  []string
+}
+
+func (runner *fakeAttachmentVideoRunner) Run(_ context.Context, path string, args ...string) ([]byte, error) {
+	runner.calls = append(runner.calls, path+" "+strings.Join(args, " "))
+	if path == "ffprobe" {
+		return []byte(runner.probe), nil
+	}
+	output := args[len(args)-1]
+	if strings.HasSuffix(output, ".jpg") {
+		return nil, os.WriteFile(output, runner.poster, 0644)
+	}
+	return nil, os.WriteFile(output, []byte("rendition"), 0644)
+}
+
+func TestAttachmentVideoProcessGeneratesPosterAndRendition(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	InitStorageManager(utils.StorageConfig{Mode: utils.StorageModeLocal, Local: utils.LocalStorageConfig{UploadDir: t.TempDir(), TempDir: t.TempDir()}})
+	createExternalGlossaryTestUser(t, "video-user")
+
+	data := []byte("fake hevc video")
+	sourcePath := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(sourcePath, data, 0644); err != nil {
+		t.Fatalf("write source failed: %v", err)
+	}
+	hash := sha256.Sum256(data)
+	location, err := PersistAttachmentFile(hash[:], int64(len(data)), sourcePath, "video/mp4")
+	if err != nil {
+		t.Fatalf("persist attachment failed: %v", err)
+	}
+	att := &model.AttachmentModel{
+		Hash: hash[:], Filename: "clip.mp4", Size: int64(len(data)), MimeType: "video/mp4", UserID: "video-user", ChannelID: "ch-video",
+		StorageType: location.StorageType, ObjectKey: location.ObjectKey,
+	}
+	if tx, _ := model.AttachmentCreate(att); tx.Error != nil {
+		t.Fatalf("create attachment failed: %v", tx.Error)
+	}
+	video := &model.AttachmentVideoModel{AttachmentID: att.ID, UserID: att.UserID, ChannelID: att.ChannelID, Status: model.AttachmentVideoStatusPending}
+	video.ID = utils.NewID()
+	if err := model.GetDB().Create(video).Error; err != nil {
+		t.Fatalf("create video record failed: %v", err)
+	}
+
+	var poster bytes.Buffer
+	if err := jpeg.Encode(&poster, image.NewGray(image.Rect(0, 0, 16, 9)), nil); err != nil {
+		t.Fatalf("encode poster failed: %v", err)
+	}
+	runner := &fakeAttachmentVideoRunner{
+		probe:  `{"streams":[{"codec_type":"video","codec_name":"hevc","width":1920,"height":1080,"avg_frame_rate":"30/1"},{"codec_type":"audio","codec_name":"aac"}],"format":{"format_name":"mov,mp4,m4a,3gp,3g2,mj2","duration":"12.5"}}`,
+		poster: poster.Bytes(),
+	}
+	var states []string
+	prevNotifier := AttachmentVideoStateNotifier
+	AttachmentVideoStateNotifier = func(video *model.AttachmentVideoModel) {
+		states = append(states, video.Status)
+	}
+	defer func() { AttachmentVideoStateNotifier = prevNotifier }()
+
+	service := &attachmentVideoService{
+		config:    normalizeAttachmentVideoConfig(utils.AttachmentVideoConfig{Enabled: true, Transcode: true}),
+		toolchain: MediaToolchain{FFmpegPath: "ffmpeg", FFprobePath: "ffprobe"},
+		runner:    runner,
+	}
+	service.process(context.Background(), att.ID)
+
+	got, err := model.AttachmentVideoGet(att.ID)
+	if err != nil || got == nil {
+		t.Fatalf("load video record failed: %v", err)
+	}
+	if got.Status != model.AttachmentVideoStatusReady || got.DurationMS != 12500 || got.VideoCodec != "hevc" {
+		t.Fatalf("unexpected video record: %+v", got)
+	}
+	if got.PosterAttachmentID == "" || got.RenditionAttachmentID == "" || got.RenditionMimeType != "video/mp4" {
+		t.Fatalf("poster and rendition should be stored: %+v", got)
+	}
+	if strings.Join(states, ",") != "probing,transcoding,ready" {
+		t.Fatalf("unexpected notified states: %v", states)
+	}
+	var updated model.AttachmentModel
+	if err := model.GetDB().Where("id = ?", att.ID).First(&updated).Error; err != nil {
+		t.Fatalf("load attachment failed: %v", err)
+	}
+	if updated.Width != 1920 || updated.Height != 1080 || updated.ThumbHash == "" {
+		t.Fatalf("source attachment should carry video size and poster thumbhash: %+v", updated)
+	}
+	if len(runner.calls) != 3 || !strings.Contains(runner.calls[2], "libx264") {
+		t.Fatalf("unexpected media commands: %v", runner.calls)
+	}
+}
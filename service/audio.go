@@ -474,6 +474,7 @@ func (svc *audioService) newAssetRecord(originalName string, opts AudioUploadOpt
 	}
 	asset.Scope = scope
 	asset.WorldID = cloneStringPtr(opts.WorldID)
+	asset.AnalysisStatus = svc.initialAnalysisStatus()
 	return asset
 }
 
@@ -573,6 +574,7 @@ func (svc *audioService) transcodeAsset(assetID, sourceKey string) error {
 	if result.TranscodeStatus == model.AudioTranscodeReady {
 		svc.removeAssetObject(model.StorageLocal, sourceKey)
 	}
+	svc.scheduleAnalysis(assetID)
 	return nil
 }
 
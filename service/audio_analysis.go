@@ -0,0 +1,611 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/service/storage"
+	"sealchat/utils"
+)
+
+const (
+	audioAnalysisDefaultTargetLUFS  = -16.0
+	audioAnalysisDefaultMaxTruePeak = -1.0
+	audioAnalysisDefaultPoints      = 200
+	audioAnalysisMaxPoints          = 2000
+	audioAnalysisMinGainDB          = -24.0
+	audioAnalysisMaxGainDB          = 12.0
+	audioAnalysisTimeout            = 10 * time.Minute
+	// 波形解码采样率：只用于峰值包络，无需高采样率
+	audioWaveformSampleRate = 4000
+	// 每个原始峰值块覆盖的样本数（0.05 秒）
+	audioWaveformChunkSamples = 200
+	audioWaveformPeakScale    = 255
+)
+
+var (
+	ErrAudioAnalysisUnavailable = errors.New("ffmpeg 不可用，无法分析音频响度")
+	ErrAudioAnalysisBusy        = errors.New("已有响度分析任务正在运行")
+)
+
+var (
+	ebur128IntegratedPattern = regexp.MustCompile(`I:\s*(-?inf|-?[0-9]+(?:\.[0-9]+)?)\s*LUFS`)
+	ebur128RangePattern      = regexp.MustCompile(`LRA:\s*(-?inf|-?[0-9]+(?:\.[0-9]+)?)\s*LU\b`)
+	ebur128PeakPattern       = regexp.MustCompile(`Peak:\s*(-?inf|-?[0-9]+(?:\.[0-9]+)?)\s*dBFS`)
+)
+
+// AudioLoudnessAnalysis 为单个素材的响度分析结果。
+type AudioLoudnessAnalysis struct {
+	IntegratedLUFS   *float64 `json:"loudnessLufs"`
+	LoudnessRangeLU  *float64 `json:"loudnessRangeLu"`
+	TruePeakDBTP     *float64 `json:"truePeakDbtp"`
+	GainSuggestionDB *float64 `json:"gainSuggestionDb"`
+	WaveformPeaks    []int    `json:"waveformPeaks"`
+}
+
+// AudioAssetAnalysisView 为接口返回的分析摘要。
+type AudioAssetAnalysisView struct {
+	AssetID          string                    `json:"assetId"`
+	Status           model.AudioAnalysisStatus `json:"status"`
+	LoudnessLUFS     *float64                  `json:"loudnessLufs"`
+	LoudnessRangeLU  *float64                  `json:"loudnessRangeLu"`
+	TruePeakDBTP     *float64                  `json:"truePeakDbtp"`
+	GainSuggestionDB *float64                  `json:"gainSuggestionDb"`
+	TargetLUFS       float64                   `json:"targetLufs"`
+	WaveformPeaks    []int                     `json:"waveformPeaks"`
+	WaveformScale    int                       `json:"waveformScale"`
+	AnalyzedAt       *time.Time                `json:"analyzedAt"`
+	Error            string                    `json:"error,omitempty"`
+}
+
+type audioAnalysisSettings struct {
+	Enabled     bool
+	TargetLUFS  float64
+	MaxTruePeak float64
+	Points      int
+}
+
+var audioAnalysisSlots = make(chan struct{}, 2)
+
+func (svc *audioService) analysisSettings() audioAnalysisSettings {
+	settings := audioAnalysisSettings{
+		Enabled:     svc.cfg.EnableLoudnessAnalysis,
+		TargetLUFS:  svc.cfg.LoudnessTargetLUFS,
+		MaxTruePeak: svc.cfg.LoudnessMaxTruePeakDB,
+		Points:      svc.cfg.WaveformPoints,
+	}
+	if cfg := utils.GetConfig(); cfg != nil {
+		settings.Enabled = cfg.Audio.EnableLoudnessAnalysis
+		settings.TargetLUFS = cfg.Audio.LoudnessTargetLUFS
+		settings.MaxTruePeak = cfg.Audio.LoudnessMaxTruePeakDB
+		settings.Points = cfg.Audio.WaveformPoints
+	}
+	if settings.TargetLUFS >= 0 || settings.TargetLUFS < -70 {
+		settings.TargetLUFS = audioAnalysisDefaultTargetLUFS
+	}
+	if settings.MaxTruePeak > 0 || settings.MaxTruePeak < -20 {
+		settings.MaxTruePeak = audioAnalysisDefaultMaxTruePeak
+	}
+	if settings.Points <= 0 {
+		settings.Points = audioAnalysisDefaultPoints
+	}
+	if settings.Points > audioAnalysisMaxPoints {
+		settings.Points = audioAnalysisMaxPoints
+	}
+	return settings
+}
+
+func (svc *audioService) analysisAvailable() bool {
+	return svc != nil && svc.ffmpegPath != "" && svc.analysisSettings().Enabled
+}
+
+// initialAnalysisStatus 决定新素材入库时的分析状态。
+func (svc *audioService) initialAnalysisStatus() model.AudioAnalysisStatus {
+	if svc.analysisAvailable() {
+		return model.AudioAnalysisPending
+	}
+	return model.AudioAnalysisUnavailable
+}
+
+func (svc *audioService) scheduleAnalysis(assetID string) {
+	if !svc.analysisAvailable() || strings.TrimSpace(assetID) == "" {
+		return
+	}
+	go func() {
+		audioAnalysisSlots <- struct{}{}
+		defer func() { <-audioAnalysisSlots }()
+		if err := svc.analyzeAsset(assetID); err != nil {
+			log.Printf("[audio] 响度分析失败 %s: %v", assetID, err)
+		}
+	}()
+}
+
+func (svc *audioService) analyzeAsset(assetID string) error {
+	if svc == nil || svc.ffmpegPath == "" {
+		return ErrAudioAnalysisUnavailable
+	}
+	asset, err := AudioGetAsset(assetID)
+	if err != nil {
+		return err
+	}
+	sourcePath, cleanup, err := svc.resolveAnalysisSource(asset)
+	if err != nil {
+		svc.markAnalysisFailed(assetID, err)
+		return err
+	}
+	defer cleanup()
+
+	settings := svc.analysisSettings()
+	ctx, cancel := context.WithTimeout(context.Background(), audioAnalysisTimeout)
+	defer cancel()
+	result, err := svc.analyzeFile(ctx, sourcePath, settings)
+	if err != nil {
+		svc.markAnalysisFailed(assetID, err)
+		return err
+	}
+	now := time.Now()
+	updates := map[string]interface{}{
+		"analysis_status":    model.AudioAnalysisReady,
+		"loudness_lufs":      result.IntegratedLUFS,
+		"loudness_range_lu":  result.LoudnessRangeLU,
+		"true_peak_dbtp":     result.TruePeakDBTP,
+		"gain_suggestion_db": result.GainSuggestionDB,
+		"waveform_peaks":     model.JSONList[int](result.WaveformPeaks),
+		"analyzed_at":        &now,
+		"analysis_error":     "",
+	}
+	return model.GetDB().Model(&model.AudioAsset{}).Where("id = ?", assetID).Updates(updates).Error
+}
+
+func (svc *audioService) markAnalysisFailed(assetID string, cause error) {
+	message := ""
+	if cause != nil {
+		message = cause.Error()
+		if len(message) > 500 {
+			message = message[:500]
+		}
+	}
+	if err := model.GetDB().Model(&model.AudioAsset{}).Where("id = ?", assetID).Updates(map[string]interface{}{
+		"analysis_status": model.AudioAnalysisFailed,
+		"analysis_error":  message,
+	}).Error; err != nil {
+		log.Printf("[audio] 写入响度分析失败状态失败 %s: %v", assetID, err)
+	}
+}
+
+// resolveAnalysisSource 返回可供 ffmpeg 读取的本地文件路径；S3 素材会先下载到临时目录。
+func (svc *audioService) resolveAnalysisSource(asset *model.AudioAsset) (string, func(), error) {
+	noop := func() {}
+	if asset == nil || strings.TrimSpace(asset.ObjectKey) == "" {
+		return "", noop, errors.New("素材缺少存储路径")
+	}
+	if asset.StorageType == model.StorageS3 {
+		if svc.objectStore == nil {
+			return "", noop, errors.New("对象存储未配置")
+		}
+		tempPath := filepath.Join(svc.cfg.TempDir, fmt.Sprintf("analysis-%s%s", asset.ID, filepath.Ext(asset.ObjectKey)))
+		if err := svc.objectStore.DownloadToPath(context.Background(), storage.BackendS3, asset.ObjectKey, tempPath); err != nil {
+			return "", noop, err
+		}
+		return tempPath, func() { _ = os.Remove(tempPath) }, nil
+	}
+	full, err := svc.storage.fullPath(asset.ObjectKey)
+	if err != nil {
+		return "", noop, err
+	}
+	if !fileExists(full) {
+		return "", noop, fmt.Errorf("素材文件不存在: %s", asset.ObjectKey)
+	}
+	return full, noop, nil
+}
+
+func (svc *audioService) analyzeFile(ctx context.Context, path string, settings audioAnalysisSettings) (*AudioLoudnessAnalysis, error) {
+	result, err := svc.measureLoudness(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	result.GainSuggestionDB = computeLoudnessGainSuggestion(result.IntegratedLUFS, result.TruePeakDBTP, settings.TargetLUFS, settings.MaxTruePeak)
+	peaks, err := svc.extractWaveformPeaks(ctx, path, settings.Points)
+	if err != nil {
+		return nil, err
+	}
+	result.WaveformPeaks = peaks
+	return result, nil
+}
+
+func (svc *audioService) measureLoudness(ctx context.Context, path string) (*AudioLoudnessAnalysis, error) {
+	args := []string{"-hide_banner", "-nostats", "-i", path, "-vn", "-af", "ebur128=peak=true", "-f", "null", "-"}
+	cmd := exec.CommandContext(ctx, svc.ffmpegPath, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg ebur128 失败: %w", err)
+	}
+	result, ok := parseEBUR128Summary(string(output))
+	if !ok {
+		return nil, errors.New("未能从 ffmpeg 输出中解析响度摘要")
+	}
+	return result, nil
+}
+
+// parseEBUR128Summary 解析 ffmpeg ebur128 滤镜输出末尾的 Summary 段。
+func parseEBUR128Summary(output string) (*AudioLoudnessAnalysis, bool) {
+	idx := strings.LastIndex(output, "Summary:")
+	if idx < 0 {
+		return nil, false
+	}
+	summary := output[idx:]
+	result := &AudioLoudnessAnalysis{}
+	match := ebur128IntegratedPattern.FindStringSubmatch(summary)
+	if len(match) < 2 {
+		return nil, false
+	}
+	result.IntegratedLUFS = parseEBUR128Value(match[1])
+	if match := ebur128RangePattern.FindStringSubmatch(summary); len(match) >= 2 {
+		result.LoudnessRangeLU = parseEBUR128Value(match[1])
+	}
+	if match := ebur128PeakPattern.FindStringSubmatch(summary); len(match) >= 2 {
+		result.TruePeakDBTP = parseEBUR128Value(match[1])
+	}
+	return result, true
+}
+
+func parseEBUR128Value(raw string) *float64 {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" || strings.HasSuffix(trimmed, "inf") {
+		return nil
+	}
+	value, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return &value
+}
+
+// computeLoudnessGainSuggestion 计算达到目标响度所需的增益，并保证真峰值不超过上限。
+// 静音或无法测量的素材返回 nil。
+func computeLoudnessGainSuggestion(integrated, truePeak *float64, targetLUFS, maxTruePeak float64) *float64 {
+	if integrated == nil || *integrated <= -70 {
+		return nil
+	}
+	gain := targetLUFS - *integrated
+	if truePeak != nil && *truePeak+gain > maxTruePeak {
+		gain = maxTruePeak - *truePeak
+	}
+	if gain < audioAnalysisMinGainDB {
+		gain = audioAnalysisMinGainDB
+	}
+	if gain > audioAnalysisMaxGainDB {
+		gain = audioAnalysisMaxGainDB
+	}
+	gain = math.Round(gain*100) / 100
+	return &gain
+}
+
+func (svc *audioService) extractWaveformPeaks(ctx context.Context, path string, points int) ([]int, error) {
+	args := []string{"-v", "error", "-i", path, "-vn", "-ac", "1", "-ar", strconv.Itoa(audioWaveformSampleRate), "-f", "s16le", "-"}
+	cmd := exec.CommandContext(ctx, svc.ffmpegPath, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	chunks, readErr := readPCMChunkPeaks(bufio.NewReaderSize(stdout, 64*1024), audioWaveformChunkSamples)
+	waitErr := cmd.Wait()
+	if readErr != nil {
+		return nil, readErr
+	}
+	if waitErr != nil {
+		return nil, fmt.Errorf("ffmpeg 波形解码失败: %w", waitErr)
+	}
+	return downsampleWaveformPeaks(chunks, points), nil
+}
+
+// readPCMChunkPeaks 读取 s16le 单声道 PCM，按固定样本数输出每块的归一化峰值。
+func readPCMChunkPeaks(r io.Reader, chunkSamples int) ([]float64, error) {
+	if chunkSamples <= 0 {
+		chunkSamples = audioWaveformChunkSamples
+	}
+	buf := make([]byte, chunkSamples*2)
+	peaks := make([]float64, 0, 1024)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n >= 2 {
+			peak := 0.0
+			for i := 0; i+1 < n; i += 2 {
+				sample := float64(int16(binary.LittleEndian.Uint16(buf[i:]))) / 32768
+				if sample < 0 {
+					sample = -sample
+				}
+				if sample > peak {
+					peak = sample
+				}
+			}
+			peaks = append(peaks, peak)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return peaks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// downsampleWaveformPeaks 将原始峰值块按最大值合并到指定点数，并量化为 0-255。
+func downsampleWaveformPeaks(chunks []float64, points int) []int {
+	if points <= 0 {
+		points = audioAnalysisDefaultPoints
+	}
+	if len(chunks) == 0 {
+		return []int{}
+	}
+	if len(chunks) < points {
+		points = len(chunks)
+	}
+	result := make([]int, points)
+	for i := 0; i < points; i++ {
+		start := i * len(chunks) / points
+		end := (i + 1) * len(chunks) / points
+		if end <= start {
+			end = start + 1
+		}
+		peak := 0.0
+		for _, value := range chunks[start:end] {
+			if value > peak {
+				peak = value
+			}
+		}
+		if peak > 1 {
+			peak = 1
+		}
+		result[i] = int(math.Round(peak * audioWaveformPeakScale))
+	}
+	return result
+}
+
+// AudioGetAssetAnalysis 返回素材的响度分析与波形数据。
+func AudioGetAssetAnalysis(assetID string) (*AudioAssetAnalysisView, error) {
+	asset, err := AudioGetAsset(assetID)
+	if err != nil {
+		return nil, err
+	}
+	target := audioAnalysisDefaultTargetLUFS
+	if svc := GetAudioService(); svc != nil {
+		target = svc.analysisSettings().TargetLUFS
+	}
+	peaks := []int(asset.WaveformPeaks)
+	if peaks == nil {
+		peaks = []int{}
+	}
+	return &AudioAssetAnalysisView{
+		AssetID:          asset.ID,
+		Status:           asset.AnalysisStatus,
+		LoudnessLUFS:     asset.LoudnessLUFS,
+		LoudnessRangeLU:  asset.LoudnessRangeLU,
+		TruePeakDBTP:     asset.TruePeakDBTP,
+		GainSuggestionDB: asset.GainSuggestionDB,
+		TargetLUFS:       target,
+		WaveformPeaks:    peaks,
+		WaveformScale:    audioWaveformPeakScale,
+		AnalyzedAt:       asset.AnalyzedAt,
+		Error:            asset.AnalysisError,
+	}, nil
+}
+
+// AudioRequestAssetAnalysis 将单个素材重新加入分析队列。
+func AudioRequestAssetAnalysis(assetID string) (*model.AudioAsset, error) {
+	svc := GetAudioService()
+	if !svc.analysisAvailable() {
+		return nil, ErrAudioAnalysisUnavailable
+	}
+	asset, err := AudioGetAsset(assetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset.TranscodeStatus == model.AudioTranscodePending {
+		return nil, errors.New("素材仍在转码中，请稍后再试")
+	}
+	if err := model.GetDB().Model(&model.AudioAsset{}).Where("id = ?", asset.ID).Updates(map[string]interface{}{
+		"analysis_status": model.AudioAnalysisPending,
+		"analysis_error":  "",
+	}).Error; err != nil {
+		return nil, err
+	}
+	asset.AnalysisStatus = model.AudioAnalysisPending
+	asset.AnalysisError = ""
+	svc.scheduleAnalysis(asset.ID)
+	return asset, nil
+}
+
+// applyTrackLoudnessGain 按素材的增益建议填充轨道 GainDB；关闭标准化的轨道增益归零。
+func applyTrackLoudnessGain(tracks []AudioTrackState) []AudioTrackState {
+	if len(tracks) == 0 {
+		return tracks
+	}
+	assetIDs := make([]string, 0, len(tracks))
+	for i := range tracks {
+		tracks[i].GainDB = 0
+		if !trackLoudnessNormalizationEnabled(tracks[i]) {
+			continue
+		}
+		if id := trackEffectiveAssetID(tracks[i]); id != "" {
+			assetIDs = append(assetIDs, id)
+		}
+	}
+	if len(assetIDs) == 0 {
+		return tracks
+	}
+	var rows []struct {
+		ID               string
+		GainSuggestionDB *float64
+	}
+	if err := model.GetDB().Model(&model.AudioAsset{}).
+		Select("id, gain_suggestion_db").
+		Where("id IN ?", appendUniqueStrings(nil, assetIDs...)).
+		Scan(&rows).Error; err != nil {
+		log.Printf("[audio] 读取素材响度增益失败: %v", err)
+		return tracks
+	}
+	gains := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		if row.GainSuggestionDB != nil {
+			gains[row.ID] = *row.GainSuggestionDB
+		}
+	}
+	for i := range tracks {
+		if !trackLoudnessNormalizationEnabled(tracks[i]) {
+			continue
+		}
+		tracks[i].GainDB = gains[trackEffectiveAssetID(tracks[i])]
+	}
+	return tracks
+}
+
+func trackLoudnessNormalizationEnabled(track AudioTrackState) bool {
+	return track.NormalizeLoudness == nil || *track.NormalizeLoudness
+}
+
+func trackEffectiveAssetID(track AudioTrackState) string {
+	if track.AssetID != nil {
+		if trimmed := strings.TrimSpace(*track.AssetID); trimmed != "" {
+			return trimmed
+		}
+	}
+	if track.PlaylistIndex >= 0 && track.PlaylistIndex < len(track.PlaylistAssetIDs) {
+		return strings.TrimSpace(track.PlaylistAssetIDs[track.PlaylistIndex])
+	}
+	return ""
+}
+
+type AudioAnalysisBatchRequest struct {
+	// Force 为 true 时连已分析的素材也重新分析
+	Force     bool
+	BatchSize int
+	DryRun    bool
+}
+
+type AudioAnalysisBatchStatus struct {
+	Running    bool       `json:"running"`
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Succeeded  int        `json:"succeeded"`
+	Failed     int        `json:"failed"`
+	Pending    int64      `json:"pending"`
+	LastError  string     `json:"lastError,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+var audioAnalysisBatch = struct {
+	sync.Mutex
+	status AudioAnalysisBatchStatus
+}{}
+
+func audioAnalysisBatchQuery(force bool) *gorm.DB {
+	q := model.GetDB().Model(&model.AudioAsset{}).
+		Where("deleted_at IS NULL").
+		Where("transcode_status <> ?", model.AudioTranscodePending)
+	if !force {
+		q = q.Where("analysis_status IS NULL OR analysis_status IN ?", []string{
+			"",
+			string(model.AudioAnalysisPending),
+			string(model.AudioAnalysisFailed),
+			string(model.AudioAnalysisUnavailable),
+		})
+	}
+	return q
+}
+
+// GetAudioAnalysisBatchStatus 返回批量分析任务进度及待分析素材数。
+func GetAudioAnalysisBatchStatus() (*AudioAnalysisBatchStatus, error) {
+	var pending int64
+	if err := audioAnalysisBatchQuery(false).Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	audioAnalysisBatch.Lock()
+	status := audioAnalysisBatch.status
+	audioAnalysisBatch.Unlock()
+	status.Pending = pending
+	return &status, nil
+}
+
+// StartAudioAnalysisBatch 为现有音频库启动后台重新分析；DryRun 仅返回待处理数量。
+func StartAudioAnalysisBatch(req AudioAnalysisBatchRequest) (*AudioAnalysisBatchStatus, error) {
+	svc := GetAudioService()
+	if !svc.analysisAvailable() {
+		return nil, ErrAudioAnalysisUnavailable
+	}
+	if req.BatchSize <= 0 {
+		req.BatchSize = 500
+	}
+	var ids []string
+	if err := audioAnalysisBatchQuery(req.Force).
+		Order("created_at ASC").
+		Limit(req.BatchSize).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		status, err := GetAudioAnalysisBatchStatus()
+		if err != nil {
+			return nil, err
+		}
+		status.Total = len(ids)
+		return status, nil
+	}
+
+	audioAnalysisBatch.Lock()
+	if audioAnalysisBatch.status.Running {
+		audioAnalysisBatch.Unlock()
+		return nil, ErrAudioAnalysisBusy
+	}
+	now := time.Now()
+	audioAnalysisBatch.status = AudioAnalysisBatchStatus{
+		Running:   true,
+		Total:     len(ids),
+		StartedAt: &now,
+	}
+	snapshot := audioAnalysisBatch.status
+	audioAnalysisBatch.Unlock()
+
+	go func() {
+		for _, id := range ids {
+			err := svc.analyzeAsset(id)
+			audioAnalysisBatch.Lock()
+			audioAnalysisBatch.status.Processed++
+			if err != nil {
+				audioAnalysisBatch.status.Failed++
+				audioAnalysisBatch.status.LastError = fmt.Sprintf("%s: %v", id, err)
+			} else {
+				audioAnalysisBatch.status.Succeeded++
+			}
+			audioAnalysisBatch.Unlock()
+		}
+		finished := time.Now()
+		audioAnalysisBatch.Lock()
+		audioAnalysisBatch.status.Running = false
+		audioAnalysisBatch.status.FinishedAt = &finished
+		audioAnalysisBatch.Unlock()
+		log.Printf("[audio] 批量响度分析完成: total=%d", len(ids))
+	}()
+	return &snapshot, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestParseEBUR128Summary(t *testing.T) {
+	output := `[Parsed_ebur128_0 @ 0x0] t: 1.2  TARGET:-23 LUFS    M: -20.1 S:-120.7     I: -20.1 LUFS       LRA:   0.0 LU  FTPK: -3.2 dBFS  TPK: -3.2 dBFS
+[Parsed_ebur128_0 @ 0x0] Summary:
+
+  Integrated loudness:
+    I:         -18.4 LUFS
+    Threshold: -28.7 LUFS
+
+  Loudness range:
+    LRA:         6.3 LU
+    Threshold: -38.6 LUFS
+    LRA low:   -22.5 LUFS
+    LRA high:  -16.2 LUFS
+
+  True peak:
+    Peak:       -0.6 dBFS
+`
+	result, ok := parseEBUR128Summary(output)
+	if !ok {
+		t.Fatalf("expected summary to be parsed")
+	}
+	if result.IntegratedLUFS == nil || *result.IntegratedLUFS != -18.4 {
+		t.Fatalf("unexpected integrated loudness: %v", result.IntegratedLUFS)
+	}
+	if result.LoudnessRangeLU == nil || *result.LoudnessRangeLU != 6.3 {
+		t.Fatalf("unexpected loudness range: %v", result.LoudnessRangeLU)
+	}
+	if result.TruePeakDBTP == nil || *result.TruePeakDBTP != -0.6 {
+		t.Fatalf("unexpected true peak: %v", result.TruePeakDBTP)
+	}
+
+	silent, ok := parseEBUR128Summary("Summary:\n  I:         -inf LUFS\n  Peak:       -inf dBFS\n")
+	if !ok {
+		t.Fatalf("expected silent summary to be parsed")
+	}
+	if silent.IntegratedLUFS != nil || silent.TruePeakDBTP != nil {
+		t.Fatalf("expected -inf values to be nil")
+	}
+
+	if _, ok := parseEBUR128Summary("no summary here"); ok {
+		t.Fatalf("expected missing summary to fail")
+	}
+}
+
+func TestComputeLoudnessGainSuggestion(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	gain := computeLoudnessGainSuggestion(f(-20), f(-6), -16, -1)
+	if gain == nil || *gain != 4 {
+		t.Fatalf("expected +4 dB, got %v", gain)
+	}
+
+	// -30 LUFS 需 +14 dB，但真峰值 -3 dBTP 只允许 +2 dB
+	gain = computeLoudnessGainSuggestion(f(-30), f(-3), -16, -1)
+	if gain == nil || *gain != 2 {
+		t.Fatalf("expected true peak limited +2 dB, got %v", gain)
+	}
+
+	gain = computeLoudnessGainSuggestion(f(-60), nil, -16, -1)
+	if gain == nil || *gain != audioAnalysisMaxGainDB {
+		t.Fatalf("expected gain clamped to max, got %v", gain)
+	}
+
+	if gain := computeLoudnessGainSuggestion(nil, nil, -16, -1); gain != nil {
+		t.Fatalf("expected nil gain for unmeasured audio")
+	}
+	if gain := computeLoudnessGainSuggestion(f(-80), nil, -16, -1); gain != nil {
+		t.Fatalf("expected nil gain for silent audio")
+	}
+}
+
+func TestWaveformPeaksFromPCM(t *testing.T) {
+	var buf bytes.Buffer
+	samples := []int16{0, 1000, -16384, 200, 32767, -5, 100, 0, -32768}
+	for _, sample := range samples {
+		_ = binary.Write(&buf, binary.LittleEndian, sample)
+	}
+	chunks, err := readPCMChunkPeaks(&buf, 3)
+	if err != nil {
+		t.Fatalf("read pcm failed: %v", err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if chunks[0] != 0.5 || chunks[2] != 1 {
+		t.Fatalf("unexpected chunk peaks: %v", chunks)
+	}
+
+	peaks := downsampleWaveformPeaks(chunks, 200)
+	if len(peaks) != 3 {
+		t.Fatalf("expected points capped to chunk count, got %d", len(peaks))
+	}
+	if peaks[0] != 128 || peaks[2] != 255 {
+		t.Fatalf("unexpected quantized peaks: %v", peaks)
+	}
+
+	merged := downsampleWaveformPeaks([]float64{0.1, 0.9, 0.2, 0.3}, 2)
+	if len(merged) != 2 || merged[0] != 230 || merged[1] != 77 {
+		t.Fatalf("unexpected merged peaks: %v", merged)
+	}
+	if len(downsampleWaveformPeaks(nil, 10)) != 0 {
+		t.Fatalf("expected empty peaks for empty input")
+	}
+}
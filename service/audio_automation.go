@@ -0,0 +1,603 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+const (
+	audioAutomationTickInterval = time.Second
+	audioMaxCuePoints           = 64
+	audioMaxCrossfadeMs         = 60000
+	// 单次推进最多连续跳过的曲目数，避免长时间离线后追赶进度时陷入死循环
+	audioPlaylistMaxAdvanceSteps = 64
+
+	AudioTransitionCrossfade = "crossfade"
+
+	AudioSyncReasonSceneTransition    = "scene-transition"
+	AudioSyncReasonSceneAutomation    = "scene-automation"
+	AudioSyncReasonTransitionComplete = "transition-complete"
+	AudioSyncReasonPlaylistAdvance    = "playlist-advance"
+	AudioSyncReasonCueLoop            = "cue-loop"
+)
+
+var (
+	ErrAudioSceneWorldMismatch  = errors.New("场景不属于该频道所在世界")
+	ErrAudioSceneAutomationSelf = errors.New("场景自动化不能指向自身")
+)
+
+// AudioPlaybackEventPublisher 负责把服务端自动化产生的播放状态推送给客户端。
+type AudioPlaybackEventPublisher interface {
+	PublishAudioPlaybackState(context.Context, *AudioPlaybackStateSnapshot) error
+}
+
+var audioAutomationState = struct {
+	sync.RWMutex
+	publisher AudioPlaybackEventPublisher
+	startOnce sync.Once
+}{}
+
+func SetAudioPlaybackEventPublisher(publisher AudioPlaybackEventPublisher) {
+	audioAutomationState.Lock()
+	audioAutomationState.publisher = publisher
+	audioAutomationState.Unlock()
+}
+
+func audioPlaybackEventPublisher() AudioPlaybackEventPublisher {
+	audioAutomationState.RLock()
+	defer audioAutomationState.RUnlock()
+	return audioAutomationState.publisher
+}
+
+// normalizeAudioCuePoints 校验并整理素材提示点；duration 为 0 时不校验上限。
+func normalizeAudioCuePoints(points []model.AudioCuePoint, duration float64) ([]model.AudioCuePoint, error) {
+	if len(points) > audioMaxCuePoints {
+		return nil, errors.New("提示点数量过多")
+	}
+	result := make([]model.AudioCuePoint, 0, len(points))
+	seen := map[string]struct{}{}
+	for _, point := range points {
+		item := model.AudioCuePoint{
+			ID:    strings.TrimSpace(point.ID),
+			Name:  strings.TrimSpace(point.Name),
+			Kind:  strings.TrimSpace(point.Kind),
+			Start: point.Start,
+		}
+		if item.ID == "" {
+			item.ID = utils.NewID()
+		}
+		if _, ok := seen[item.ID]; ok {
+			return nil, errors.New("提示点ID重复")
+		}
+		seen[item.ID] = struct{}{}
+		switch item.Kind {
+		case model.AudioCueKindIntro, model.AudioCueKindLoop, model.AudioCueKindOutro, model.AudioCueKindMarker:
+		case "":
+			item.Kind = model.AudioCueKindMarker
+		default:
+			return nil, errors.New("提示点类型无效")
+		}
+		if math.IsNaN(item.Start) || math.IsInf(item.Start, 0) || item.Start < 0 {
+			return nil, errors.New("提示点起始时间无效")
+		}
+		if duration > 0 && item.Start > duration {
+			return nil, errors.New("提示点超出素材时长")
+		}
+		if point.End != nil {
+			end := *point.End
+			if math.IsNaN(end) || math.IsInf(end, 0) || end <= item.Start {
+				return nil, errors.New("提示点结束时间必须晚于起始时间")
+			}
+			if duration > 0 && end > duration {
+				end = duration
+			}
+			item.End = &end
+		}
+		if item.Kind == model.AudioCueKindLoop && !item.IsRegion() {
+			return nil, errors.New("循环提示点必须是区间")
+		}
+		if item.Name == "" {
+			item.Name = item.Kind
+		}
+		result = append(result, item)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start < result[j].Start
+	})
+	return result, nil
+}
+
+// normalizeSceneAutomation 整理场景自动化配置；NextSceneID 为空表示清除自动化。
+func normalizeSceneAutomation(input *model.AudioSceneAutomation, sceneID string) (*model.AudioSceneAutomation, error) {
+	if input == nil {
+		return nil, nil
+	}
+	nextSceneID := strings.TrimSpace(input.NextSceneID)
+	if nextSceneID == "" {
+		return nil, nil
+	}
+	if sceneID != "" && nextSceneID == sceneID {
+		return nil, ErrAudioSceneAutomationSelf
+	}
+	if _, err := getAudioScene(nextSceneID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("自动化目标场景不存在")
+		}
+		return nil, err
+	}
+	if math.IsNaN(input.AfterSeconds) || input.AfterSeconds <= 0 {
+		return nil, errors.New("自动化触发时间必须大于 0 秒")
+	}
+	crossfadeMs := input.CrossfadeMs
+	if crossfadeMs < 0 {
+		crossfadeMs = 0
+	}
+	if crossfadeMs > audioMaxCrossfadeMs {
+		crossfadeMs = audioMaxCrossfadeMs
+	}
+	return &model.AudioSceneAutomation{
+		NextSceneID:  nextSceneID,
+		AfterSeconds: input.AfterSeconds,
+		CrossfadeMs:  crossfadeMs,
+	}, nil
+}
+
+// resolveSceneScheduledAutomation 读取场景自动化配置，并以当前整体进度为起点计算触发位置。
+func resolveSceneScheduledAutomation(sceneID *string, basePosition float64) *model.AudioScheduledAutomation {
+	id := strings.TrimSpace(normalizeOptionalString(sceneID))
+	if id == "" {
+		return nil
+	}
+	scene, err := getAudioScene(id)
+	if err != nil || scene == nil || scene.Automation == nil {
+		return nil
+	}
+	return &model.AudioScheduledAutomation{
+		TargetSceneID: scene.Automation.NextSceneID,
+		AtPositionSec: basePosition + scene.Automation.AfterSeconds,
+		CrossfadeMs:   scene.Automation.CrossfadeMs,
+	}
+}
+
+// mergeScheduledAutomation 场景未变化时保留已排程的触发点，避免普通同步不断推迟自动化。
+func mergeScheduledAutomation(prevSceneID *string, prev *model.AudioScheduledAutomation, nextSceneID *string, next *model.AudioScheduledAutomation) *model.AudioScheduledAutomation {
+	if next == nil {
+		return nil
+	}
+	if prev != nil && normalizeOptionalString(prevSceneID) == normalizeOptionalString(nextSceneID) && prev.TargetSceneID == next.TargetSceneID {
+		return cloneAudioScheduledAutomation(prev)
+	}
+	return next
+}
+
+func audioTransitionActive(transition *model.AudioPlaybackTransition, sceneID *string, nowMs int64) bool {
+	if transition == nil {
+		return false
+	}
+	if normalizeOptionalString(transition.ToSceneID) != normalizeOptionalString(sceneID) {
+		return false
+	}
+	return nowMs < transition.StartedAtMs+int64(transition.DurationMs)
+}
+
+func cloneAudioPlaybackTransition(src *model.AudioPlaybackTransition) *model.AudioPlaybackTransition {
+	if src == nil {
+		return nil
+	}
+	value := *src
+	value.FromSceneID = cloneStringPtr(src.FromSceneID)
+	value.ToSceneID = cloneStringPtr(src.ToSceneID)
+	value.FromTracks = append([]AudioTrackState(nil), src.FromTracks...)
+	return &value
+}
+
+func cloneAudioScheduledAutomation(src *model.AudioScheduledAutomation) *model.AudioScheduledAutomation {
+	if src == nil {
+		return nil
+	}
+	value := *src
+	return &value
+}
+
+// sceneTracksToTrackStates 将场景轨道配置转换为从头开始播放的轨道状态。
+func sceneTracksToTrackStates(tracks []model.AudioSceneTrack) []AudioTrackState {
+	result := make([]AudioTrackState, 0, len(tracks))
+	for _, track := range tracks {
+		state := AudioTrackState{
+			Type:             track.Type,
+			AssetID:          cloneStringPtr(track.AssetID),
+			Volume:           track.Volume,
+			FadeIn:           track.FadeIn,
+			FadeOut:          track.FadeOut,
+			PlaybackRate:     1,
+			PlaylistFolderID: cloneStringPtr(track.PlaylistFolderID),
+			PlaylistMode:     cloneStringPtr(track.PlaylistMode),
+			PlaylistAssetIDs: append([]string(nil), track.PlaylistAssetIDs...),
+			PlaylistIndex:    track.PlaylistIndex,
+			LoopCueID:        cloneStringPtr(track.LoopCueID),
+		}
+		if track.LoopEnabled != nil {
+			state.LoopEnabled = *track.LoopEnabled
+		}
+		if track.PlaybackRate != nil && *track.PlaybackRate > 0 {
+			state.PlaybackRate = *track.PlaybackRate
+		}
+		if state.AssetID == nil && len(state.PlaylistAssetIDs) > 0 {
+			index := state.PlaylistIndex
+			if index < 0 || index >= len(state.PlaylistAssetIDs) {
+				index = 0
+			}
+			state.AssetID = cloneStringPtr(&state.PlaylistAssetIDs[index])
+		}
+		state.IsPlaying = state.AssetID != nil
+		result = append(result, state)
+	}
+	return result
+}
+
+type AudioSceneTransitionInput struct {
+	ChannelID            string
+	SceneID              string
+	CrossfadeMs          int
+	WorldPlaybackEnabled bool
+	BaseRevision         int64
+	ActorID              string
+	Persist              bool
+	SyncReason           string
+}
+
+// AudioTransitionToScene 切换到指定场景并从头播放，CrossfadeMs 大于 0 时附带交叉淡化信息。
+func AudioTransitionToScene(input AudioSceneTransitionInput) (*AudioPlaybackStateSnapshot, error) {
+	input.ChannelID = strings.TrimSpace(input.ChannelID)
+	input.SceneID = strings.TrimSpace(input.SceneID)
+	if input.ChannelID == "" {
+		return nil, errors.New("channelId 必填")
+	}
+	if input.SceneID == "" {
+		return nil, errors.New("sceneId 必填")
+	}
+	scene, err := getAudioScene(input.SceneID)
+	if err != nil {
+		return nil, err
+	}
+	if scene.Scope == model.AudioScopeWorld {
+		channel, chErr := model.ChannelGet(input.ChannelID)
+		if chErr != nil {
+			return nil, chErr
+		}
+		if channel == nil || strings.TrimSpace(channel.WorldID) != normalizeOptionalString(scene.WorldID) {
+			return nil, ErrAudioSceneWorldMismatch
+		}
+	}
+	crossfadeMs := input.CrossfadeMs
+	if crossfadeMs < 0 {
+		crossfadeMs = 0
+	}
+	if crossfadeMs > audioMaxCrossfadeMs {
+		crossfadeMs = audioMaxCrossfadeMs
+	}
+	reason := strings.TrimSpace(input.SyncReason)
+	if reason == "" {
+		reason = AudioSyncReasonSceneTransition
+	}
+	sceneID := scene.ID
+	update := AudioPlaybackUpdateInput{
+		ChannelID:            input.ChannelID,
+		SceneID:              &sceneID,
+		Tracks:               sceneTracksToTrackStates(scene.Tracks),
+		IsPlaying:            true,
+		Position:             0,
+		LoopEnabled:          false,
+		PlaybackRate:         1,
+		WorldPlaybackEnabled: input.WorldPlaybackEnabled,
+		BaseRevision:         input.BaseRevision,
+		ActorID:              input.ActorID,
+		Persist:              input.Persist,
+		SyncReason:           reason,
+	}
+	if crossfadeMs > 0 {
+		update.Transition = &model.AudioPlaybackTransition{
+			Type:       AudioTransitionCrossfade,
+			ToSceneID:  &sceneID,
+			DurationMs: crossfadeMs,
+		}
+	}
+	return AudioUpsertPlaybackState(update)
+}
+
+// StartAudioAutomationWorker 启动服务端播放自动化：播放列表推进、循环区间回绕与场景自动切换，
+// 使所有客户端在主持人离线时仍能保持一致的播放进度。
+func StartAudioAutomationWorker(ctx context.Context) {
+	audioAutomationState.startOnce.Do(func() {
+		go func() {
+			restoreAudioPlaybackRuntime()
+			ticker := time.NewTicker(audioAutomationTickInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case now := <-ticker.C:
+					audioAutomationTick(now)
+				}
+			}
+		}()
+	})
+}
+
+// restoreAudioPlaybackRuntime 重启后运行时状态为空，先载入仍在播放的持久化状态，
+// 否则这些频道要等有人打开才会恢复播放列表推进与场景自动化。
+func restoreAudioPlaybackRuntime() {
+	var channelIDs []string
+	if err := model.GetDB().Model(&model.AudioPlaybackState{}).
+		Where("is_playing = ?", true).
+		Pluck("channel_id", &channelIDs).Error; err != nil {
+		log.Printf("[audio] 载入播放状态失败: %v", err)
+		return
+	}
+	for _, channelID := range channelIDs {
+		if _, err := AudioGetPlaybackState(channelID); err != nil {
+			log.Printf("[audio] 恢复播放状态失败 channel=%s err=%v", channelID, err)
+		}
+	}
+}
+
+func audioAutomationTick(now time.Time) {
+	audioPlaybackRuntimeStore.RLock()
+	candidates := make([]*AudioPlaybackStateSnapshot, 0)
+	for _, runtime := range audioPlaybackRuntimeStore.states {
+		if runtime == nil {
+			continue
+		}
+		if !runtime.IsPlaying && runtime.Transition == nil {
+			continue
+		}
+		candidates = append(candidates, runtimeToSnapshot(runtime, now))
+	}
+	audioPlaybackRuntimeStore.RUnlock()
+	for _, snapshot := range candidates {
+		processAudioAutomation(snapshot, now)
+	}
+}
+
+func processAudioAutomation(snapshot *AudioPlaybackStateSnapshot, now time.Time) {
+	if snapshot == nil {
+		return
+	}
+	nowMs := now.UnixMilli()
+	if automation := snapshot.Automation; automation != nil && snapshot.IsPlaying && snapshot.Position >= automation.AtPositionSec {
+		next, err := AudioTransitionToScene(AudioSceneTransitionInput{
+			ChannelID:            snapshot.ChannelID,
+			SceneID:              automation.TargetSceneID,
+			CrossfadeMs:          automation.CrossfadeMs,
+			WorldPlaybackEnabled: snapshot.WorldPlaybackEnabled,
+			BaseRevision:         snapshot.Revision,
+			ActorID:              snapshot.UpdatedBy,
+			Persist:              true,
+			SyncReason:           AudioSyncReasonSceneAutomation,
+		})
+		if err == nil {
+			publishAudioAutomationSnapshot(next)
+			return
+		}
+		var conflictErr *AudioPlaybackRevisionConflictError
+		if errors.As(err, &conflictErr) {
+			return
+		}
+		log.Printf("[audio] 场景自动化切换失败 channel=%s scene=%s err=%v", snapshot.ChannelID, automation.TargetSceneID, err)
+		// 目标场景已失效时清除排程，避免每秒重复尝试
+		commitAudioAutomation(snapshot, now, "", func(runtime *audioPlaybackRuntimeState) {
+			runtime.Automation = nil
+		})
+		return
+	}
+
+	transitionDone := snapshot.Transition != nil && nowMs >= snapshot.Transition.StartedAtMs+int64(snapshot.Transition.DurationMs)
+	var tracks []AudioTrackState
+	reason := ""
+	if snapshot.IsPlaying {
+		assets := loadAudioAutomationAssets(snapshot.Tracks)
+		if len(assets) > 0 {
+			var trackReason string
+			tracks, trackReason = advanceAudioTracks(snapshot.Tracks, assets, rand.Intn)
+			reason = trackReason
+		}
+	}
+	if reason == "" && !transitionDone {
+		return
+	}
+	if reason != "" {
+		tracks = applyTrackLoudnessGain(tracks)
+	} else {
+		reason = AudioSyncReasonTransitionComplete
+	}
+	commitAudioAutomation(snapshot, now, reason, func(runtime *audioPlaybackRuntimeState) {
+		if tracks != nil {
+			runtime.Tracks = tracks
+			runtime.BasePositionSec = snapshot.Position
+			runtime.CapturedAtMs = nowMs
+		}
+		if transitionDone {
+			runtime.Transition = nil
+		}
+	})
+}
+
+// commitAudioAutomation 在版本未变化的前提下写回自动化结果，随后持久化并广播。
+func commitAudioAutomation(snapshot *AudioPlaybackStateSnapshot, now time.Time, reason string, mutate func(runtime *audioPlaybackRuntimeState)) {
+	key := playbackScopeKey(snapshot.ScopeType, snapshot.ScopeID)
+	audioPlaybackRuntimeStore.Lock()
+	runtime := audioPlaybackRuntimeStore.states[key]
+	if runtime == nil || runtime.Revision != snapshot.Revision {
+		audioPlaybackRuntimeStore.Unlock()
+		return
+	}
+	mutate(runtime)
+	runtime.Revision += 1
+	runtime.UpdatedAt = now
+	runtime.SyncReason = reason
+	next := runtimeToSnapshot(runtime, now)
+	audioPlaybackRuntimeStore.Unlock()
+	if err := persistPlaybackState(AudioPlaybackUpdateInput{ChannelID: next.ChannelID}, next); err != nil {
+		log.Printf("[audio] 保存自动化播放状态失败 scope=%s err=%v", key, err)
+	}
+	publishAudioAutomationSnapshot(next)
+}
+
+func publishAudioAutomationSnapshot(snapshot *AudioPlaybackStateSnapshot) {
+	publisher := audioPlaybackEventPublisher()
+	if publisher == nil || snapshot == nil {
+		return
+	}
+	if err := publisher.PublishAudioPlaybackState(context.Background(), snapshot); err != nil {
+		log.Printf("[audio] 广播自动化播放状态失败 channel=%s err=%v", snapshot.ChannelID, err)
+	}
+}
+
+type audioAutomationAsset struct {
+	Duration  float64
+	CuePoints []model.AudioCuePoint
+}
+
+// loadAudioAutomationAssets 只加载需要服务端推进的轨道（播放列表或循环区间）所引用的素材。
+func loadAudioAutomationAssets(tracks []AudioTrackState) map[string]audioAutomationAsset {
+	ids := make([]string, 0)
+	seen := map[string]struct{}{}
+	add := func(id string) {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return
+		}
+		if _, ok := seen[id]; ok {
+			return
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	for _, track := range tracks {
+		if !track.IsPlaying || track.Muted {
+			continue
+		}
+		if track.LoopCueID != nil {
+			add(trackEffectiveAssetID(track))
+			continue
+		}
+		if track.PlaylistMode != nil && len(track.PlaylistAssetIDs) > 0 {
+			for _, id := range track.PlaylistAssetIDs {
+				add(id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var rows []model.AudioAsset
+	if err := model.GetDB().Select("id", "duration", "cue_points").
+		Where("id IN ? AND deleted_at IS NULL", ids).
+		Find(&rows).Error; err != nil {
+		log.Printf("[audio] 读取自动化素材信息失败: %v", err)
+		return nil
+	}
+	result := make(map[string]audioAutomationAsset, len(rows))
+	for _, row := range rows {
+		result[row.ID] = audioAutomationAsset{
+			Duration:  row.DurationSeconds,
+			CuePoints: []model.AudioCuePoint(row.CuePoints),
+		}
+	}
+	return result
+}
+
+// advanceAudioTracks 根据已投影的轨道进度推进播放列表或回绕循环区间，返回新轨道及变化原因。
+func advanceAudioTracks(tracks []AudioTrackState, assets map[string]audioAutomationAsset, randIntn func(int) int) ([]AudioTrackState, string) {
+	result := append([]AudioTrackState(nil), tracks...)
+	reason := ""
+	for i := range result {
+		track := &result[i]
+		if !track.IsPlaying || track.Muted {
+			continue
+		}
+		if track.LoopCueID != nil {
+			asset, ok := assets[trackEffectiveAssetID(*track)]
+			if !ok {
+				continue
+			}
+			if cue := findAudioCuePoint(asset.CuePoints, *track.LoopCueID); cue != nil && cue.IsRegion() && track.Position >= *cue.End {
+				length := *cue.End - cue.Start
+				track.Position = cue.Start + math.Mod(track.Position-cue.Start, length)
+				if reason == "" {
+					reason = AudioSyncReasonCueLoop
+				}
+			}
+			continue
+		}
+		if track.PlaylistMode == nil || len(track.PlaylistAssetIDs) == 0 {
+			continue
+		}
+		if advancePlaylistTrack(track, assets, randIntn) {
+			reason = AudioSyncReasonPlaylistAdvance
+		}
+	}
+	if reason == "" {
+		return nil, ""
+	}
+	return result, reason
+}
+
+func advancePlaylistTrack(track *AudioTrackState, assets map[string]audioAutomationAsset, randIntn func(int) int) bool {
+	count := len(track.PlaylistAssetIDs)
+	if track.PlaylistIndex < 0 || track.PlaylistIndex >= count {
+		track.PlaylistIndex = 0
+	}
+	advanced := false
+	for step := 0; step < audioPlaylistMaxAdvanceSteps; step++ {
+		asset, ok := assets[trackEffectiveAssetID(*track)]
+		if !ok || asset.Duration <= 0 || track.Position < asset.Duration {
+			break
+		}
+		track.Position -= asset.Duration
+		advanced = true
+		switch *track.PlaylistMode {
+		case "single":
+			// 单曲循环：保持当前曲目，仅回绕进度
+		case "shuffle":
+			if count > 1 {
+				next := randIntn(count - 1)
+				if next >= track.PlaylistIndex {
+					next++
+				}
+				track.PlaylistIndex = next
+			}
+		default:
+			track.PlaylistIndex = (track.PlaylistIndex + 1) % count
+		}
+		assetID := track.PlaylistAssetIDs[track.PlaylistIndex]
+		track.AssetID = &assetID
+	}
+	if track.Position < 0 {
+		track.Position = 0
+	}
+	return advanced
+}
+
+func findAudioCuePoint(points []model.AudioCuePoint, id string) *model.AudioCuePoint {
+	id = strings.TrimSpace(id)
+	for i := range points {
+		if points[i].ID == id {
+			return &points[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"sealchat/model"
+)
+
+func TestAdvanceAudioTracksPlaylist(t *testing.T) {
+	mode := "sequential"
+	first := "asset-a"
+	tracks := []AudioTrackState{{
+		Type:             "music",
+		AssetID:          &first,
+		IsPlaying:        true,
+		Position:         31.5,
+		PlaylistMode:     &mode,
+		PlaylistAssetIDs: []string{"asset-a", "asset-b"},
+	}}
+	assets := map[string]audioAutomationAsset{
+		"asset-a": {Duration: 30},
+		"asset-b": {Duration: 60},
+	}
+	next, reason := advanceAudioTracks(tracks, assets, func(int) int { return 0 })
+	if reason != AudioSyncReasonPlaylistAdvance {
+		t.Fatalf("expected playlist advance, got %q", reason)
+	}
+	if next[0].PlaylistIndex != 1 || next[0].AssetID == nil || *next[0].AssetID != "asset-b" {
+		t.Fatalf("unexpected playlist position: index=%d asset=%v", next[0].PlaylistIndex, next[0].AssetID)
+	}
+	if next[0].Position != 1.5 {
+		t.Fatalf("expected overflow carried to next track, got %v", next[0].Position)
+	}
+	if tracks[0].PlaylistIndex != 0 {
+		t.Fatalf("input tracks should not be mutated")
+	}
+
+	// 长时间无人推进时应连续跳过多首并回到列表开头
+	tracks[0].Position = 95
+	next, _ = advanceAudioTracks(tracks, assets, func(int) int { return 0 })
+	if next[0].PlaylistIndex != 0 || next[0].Position != 5 {
+		t.Fatalf("expected wrap to first track at 5s, got index=%d pos=%v", next[0].PlaylistIndex, next[0].Position)
+	}
+
+	tracks[0].Position = 10
+	if _, reason := advanceAudioTracks(tracks, assets, func(int) int { return 0 }); reason != "" {
+		t.Fatalf("expected no change before track end, got %q", reason)
+	}
+}
+
+func TestAdvanceAudioTracksShuffleAndSingle(t *testing.T) {
+	shuffle := "shuffle"
+	single := "single"
+	assetID := "asset-b"
+	assets := map[string]audioAutomationAsset{
+		"asset-a": {Duration: 10},
+		"asset-b": {Duration: 10},
+		"asset-c": {Duration: 10},
+	}
+	tracks := []AudioTrackState{
+		{Type: "music", AssetID: &assetID, IsPlaying: true, Position: 10, PlaylistMode: &shuffle, PlaylistAssetIDs: []string{"asset-a", "asset-b", "asset-c"}, PlaylistIndex: 1},
+		{Type: "ambience", AssetID: &assetID, IsPlaying: true, Position: 12, PlaylistMode: &single, PlaylistAssetIDs: []string{"asset-a", "asset-b"}, PlaylistIndex: 1},
+	}
+	next, _ := advanceAudioTracks(tracks, assets, func(int) int { return 1 })
+	if next[0].PlaylistIndex == 1 {
+		t.Fatalf("shuffle should not repeat the current track")
+	}
+	if next[1].PlaylistIndex != 1 || next[1].Position != 2 {
+		t.Fatalf("single mode should restart current track, got index=%d pos=%v", next[1].PlaylistIndex, next[1].Position)
+	}
+}
+
+func TestAdvanceAudioTracksCueLoop(t *testing.T) {
+	assetID := "asset-loop"
+	cueID := "loop-1"
+	end := 20.0
+	assets := map[string]audioAutomationAsset{
+		assetID: {Duration: 40, CuePoints: []model.AudioCuePoint{{ID: cueID, Kind: model.AudioCueKindLoop, Start: 8, End: &end}}},
+	}
+	tracks := []AudioTrackState{{Type: "music", AssetID: &assetID, IsPlaying: true, Position: 23, LoopCueID: &cueID}}
+	next, reason := advanceAudioTracks(tracks, assets, nil)
+	if reason != AudioSyncReasonCueLoop {
+		t.Fatalf("expected cue loop, got %q", reason)
+	}
+	if next[0].Position != 11 {
+		t.Fatalf("expected position wrapped into loop region, got %v", next[0].Position)
+	}
+}
+
+func TestNormalizeAudioCuePoints(t *testing.T) {
+	end := 50.0
+	points, err := normalizeAudioCuePoints([]model.AudioCuePoint{
+		{ID: "outro", Kind: model.AudioCueKindOutro, Start: 30},
+		{ID: "loop", Kind: model.AudioCueKindLoop, Start: 5, End: &end},
+		{Name: " 标记 ", Start: 1},
+	}, 40)
+	if err != nil {
+		t.Fatalf("normalize cue points failed: %v", err)
+	}
+	if len(points) != 3 || points[0].Name != "标记" || points[0].Kind != model.AudioCueKindMarker || points[0].ID == "" {
+		t.Fatalf("unexpected normalized points: %+v", points)
+	}
+	if points[1].End == nil || *points[1].End != 40 {
+		t.Fatalf("expected loop end clamped to duration, got %v", points[1].End)
+	}
+
+	if _, err := normalizeAudioCuePoints([]model.AudioCuePoint{{Kind: model.AudioCueKindLoop, Start: 5}}, 0); err == nil {
+		t.Fatalf("expected loop cue without end to be rejected")
+	}
+	if _, err := normalizeAudioCuePoints([]model.AudioCuePoint{{Kind: "chorus", Start: 5}}, 0); err == nil {
+		t.Fatalf("expected unknown cue kind to be rejected")
+	}
+}
+
+func TestMergeScheduledAutomationKeepsAnchor(t *testing.T) {
+	sceneA := "scene-a"
+	prev := &model.AudioScheduledAutomation{TargetSceneID: "scene-b", AtPositionSec: 30}
+	next := &model.AudioScheduledAutomation{TargetSceneID: "scene-b", AtPositionSec: 55}
+	merged := mergeScheduledAutomation(&sceneA, prev, &sceneA, next)
+	if merged == nil || merged.AtPositionSec != 30 {
+		t.Fatalf("expected existing anchor kept for same scene, got %+v", merged)
+	}
+	sceneC := "scene-c"
+	merged = mergeScheduledAutomation(&sceneA, prev, &sceneC, next)
+	if merged == nil || merged.AtPositionSec != 55 {
+		t.Fatalf("expected new anchor after scene change, got %+v", merged)
+	}
+	if mergeScheduledAutomation(&sceneA, prev, &sceneA, nil) != nil {
+		t.Fatalf("expected automation cleared when scene has none")
+	}
+}
+
+func TestRestoreAudioPlaybackRuntimeLoadsPlayingStates(t *testing.T) {
+	initTestDB(t)
+	audioPlaybackRuntimeStore.Lock()
+	audioPlaybackRuntimeStore.states = map[string]*audioPlaybackRuntimeState{}
+	audioPlaybackRuntimeStore.Unlock()
+
+	now := time.Now()
+	states := []*model.AudioPlaybackState{
+		{ChannelID: "restore-playing", IsPlaying: true, PlaybackRate: 1, Revision: 3, CapturedAtMs: now.UnixMilli(), Automation: &model.AudioScheduledAutomation{TargetSceneID: "scene-next", AtPositionSec: 30}},
+		{ChannelID: "restore-paused", IsPlaying: false, PlaybackRate: 1},
+	}
+	for _, state := range states {
+		if err := model.GetDB().Create(state).Error; err != nil {
+			t.Fatalf("create playback state failed: %v", err)
+		}
+	}
+
+	restoreAudioPlaybackRuntime()
+
+	runtime := getRuntimeState(AudioPlaybackScopeChannel, "restore-playing")
+	if runtime == nil || !runtime.IsPlaying || runtime.Revision != 3 || runtime.Automation == nil || runtime.Automation.TargetSceneID != "scene-next" {
+		t.Fatalf("playing state should be restored with its automation: %+v", runtime)
+	}
+	if getRuntimeState(AudioPlaybackScopeChannel, "restore-paused") != nil {
+		t.Fatal("paused states do not need automation and should stay unloaded")
+	}
+}
@@ -48,6 +48,7 @@ type AudioAssetUpdateInput struct {
 	WorldID     *string
 	UpdatedBy   string
 	Variants    []model.AudioAssetVariant
+	CuePoints   *[]model.AudioCuePoint
 }
 
 type AudioAssetUsageSummary struct {
@@ -261,6 +262,7 @@ type AudioSceneInput struct {
 	ActorID      string
 	Scope        model.AudioAssetScope
 	WorldID      *string
+	Automation   *model.AudioSceneAutomation
 }
 
 type AudioSceneFilters struct {
@@ -286,6 +288,8 @@ type AudioPlaybackUpdateInput struct {
 	ActorID              string
 	Persist              bool
 	SyncReason           string
+	// Transition 仅由场景切换流程设置，普通状态同步不携带
+	Transition *model.AudioPlaybackTransition
 }
 
 type AudioPlaybackRevisionConflictError struct {
@@ -318,6 +322,9 @@ type AudioPlaybackStateSnapshot struct {
 	UpdatedAt            time.Time
 	ScopeType            string
 	ScopeID              string
+	Transition           *model.AudioPlaybackTransition
+	Automation           *model.AudioScheduledAutomation
+	SyncReason           string
 }
 
 type audioPlaybackRuntimeState struct {
@@ -335,6 +342,9 @@ type audioPlaybackRuntimeState struct {
 	UpdatedAt            time.Time
 	ScopeType            string
 	ScopeID              string
+	Transition           *model.AudioPlaybackTransition
+	Automation           *model.AudioScheduledAutomation
+	SyncReason           string
 }
 
 var audioPlaybackRuntimeStore = struct {
@@ -415,9 +425,11 @@ func AudioCreateAssetFromUpload(file *multipart.FileHeader, opts AudioUploadOpti
 		audioCleanupPersistedAsset(asset)
 		return nil, err
 	}
-	if asset.TranscodeStatus == model.AudioTranscodePending {
-		if svc := GetAudioService(); svc != nil {
+	if svc := GetAudioService(); svc != nil {
+		if asset.TranscodeStatus == model.AudioTranscodePending {
 			svc.scheduleTranscode(asset.ID, asset.ObjectKey)
+		} else {
+			svc.scheduleAnalysis(asset.ID)
 		}
 	}
 	return asset, nil
@@ -474,6 +486,8 @@ func AudioCreateAssetFromImport(filePath string, opts AudioUploadOptions) (*mode
 	}
 	if asset.TranscodeStatus == model.AudioTranscodePending {
 		svc.scheduleTranscode(asset.ID, asset.ObjectKey)
+	} else {
+		svc.scheduleAnalysis(asset.ID)
 	}
 	return asset, nil
 }
@@ -515,7 +529,8 @@ func AudioListAssets(filters AudioAssetFilters) ([]*model.AudioAsset, int64, err
 		sceneAssetIDs = ids
 	}
 	return utils.QueryPaginatedList(db, filters.Page, filters.PageSize, &model.AudioAsset{}, func(q *gorm.DB) *gorm.DB {
-		q = q.Where("deleted_at IS NULL")
+		// 波形数据较大，列表中省略，由分析详情接口单独返回
+		q = q.Omit("waveform_peaks").Where("deleted_at IS NULL")
 		if filters.HasSceneOnly {
 			q = q.Where("id IN ?", sceneAssetIDs)
 		}
@@ -1179,6 +1194,18 @@ func normalizeTrackStates(items []AudioTrackState) []AudioTrackState {
 			PlaybackRate:     item.PlaybackRate,
 			PlaylistAssetIDs: append([]string(nil), item.PlaylistAssetIDs...),
 			PlaylistIndex:    item.PlaylistIndex,
+			GainDB:           item.GainDB,
+		}
+		if item.NormalizeLoudness != nil {
+			val := *item.NormalizeLoudness
+			t.NormalizeLoudness = &val
+		}
+		if item.LoopCueID != nil {
+			trimmed := strings.TrimSpace(*item.LoopCueID)
+			if trimmed != "" {
+				val := trimmed
+				t.LoopCueID = &val
+			}
 		}
 		if t.PlaybackRate <= 0 {
 			t.PlaybackRate = 1
@@ -1327,6 +1354,9 @@ func runtimeToSnapshot(runtime *audioPlaybackRuntimeState, now time.Time) *Audio
 		UpdatedAt:            runtime.UpdatedAt,
 		ScopeType:            runtime.ScopeType,
 		ScopeID:              runtime.ScopeID,
+		Transition:           cloneAudioPlaybackTransition(runtime.Transition),
+		Automation:           cloneAudioScheduledAutomation(runtime.Automation),
+		SyncReason:           runtime.SyncReason,
 	}
 }
 
@@ -1356,6 +1386,8 @@ func modelToRuntimeState(state *model.AudioPlaybackState, scopeType, scopeID str
 		UpdatedAt:            state.UpdatedAt,
 		ScopeType:            scopeType,
 		ScopeID:              scopeID,
+		Transition:           cloneAudioPlaybackTransition(state.Transition),
+		Automation:           cloneAudioScheduledAutomation(state.Automation),
 	}
 }
 
@@ -1478,6 +1510,8 @@ func persistPlaybackState(input AudioPlaybackUpdateInput, snapshot *AudioPlaybac
 	state.LoopEnabled = snapshot.LoopEnabled
 	state.PlaybackRate = snapshot.PlaybackRate
 	state.WorldPlaybackEnabled = snapshot.WorldPlaybackEnabled
+	state.Transition = cloneAudioPlaybackTransition(snapshot.Transition)
+	state.Automation = cloneAudioScheduledAutomation(snapshot.Automation)
 	state.Revision = snapshot.Revision
 	state.UpdatedBy = snapshot.UpdatedBy
 	updatedAt := snapshot.UpdatedAt
@@ -1526,6 +1560,8 @@ func persistWorldScopeModeOff(channelID, actorID string, updatedAt time.Time, ca
 	state.LoopEnabled = false
 	state.PlaybackRate = 1
 	state.WorldPlaybackEnabled = false
+	state.Transition = nil
+	state.Automation = nil
 	if state.Revision < 0 {
 		state.Revision = 0
 	}
@@ -1613,6 +1649,8 @@ func AudioUpsertPlaybackState(input AudioPlaybackUpdateInput) (*AudioPlaybackSta
 			seededRuntime = modelToRuntimeState(persistedState, seedScopeType, seedScopeID)
 		}
 	}
+	tracks := applyTrackLoudnessGain(normalizeTrackStates(input.Tracks))
+	sceneAutomation := resolveSceneScheduledAutomation(input.SceneID, input.Position)
 	runtime := upsertRuntimeState(scopeType, scopeID, seededRuntime)
 	audioPlaybackRuntimeStore.Lock()
 	if input.BaseRevision > 0 && runtime.Revision > 0 && input.BaseRevision != runtime.Revision {
@@ -1625,9 +1663,21 @@ func AudioUpsertPlaybackState(input AudioPlaybackUpdateInput) (*AudioPlaybackSta
 	if capturedAtMs <= 0 {
 		capturedAtMs = now.UnixMilli()
 	}
+	nowMs := now.UnixMilli()
+	if input.Transition != nil {
+		transition := *input.Transition
+		transition.FromSceneID = cloneStringPtr(runtime.SceneID)
+		transition.FromTracks = projectTrackStates(runtime.Tracks, runtime.IsPlaying, runtime.CapturedAtMs, nowMs, runtime.PlaybackRate)
+		transition.StartedAtMs = nowMs
+		runtime.Transition = &transition
+	} else if !audioTransitionActive(runtime.Transition, input.SceneID, nowMs) {
+		runtime.Transition = nil
+	}
+	runtime.Automation = mergeScheduledAutomation(runtime.SceneID, runtime.Automation, input.SceneID, sceneAutomation)
+	runtime.SyncReason = strings.TrimSpace(input.SyncReason)
 	runtime.ChannelID = input.ChannelID
 	runtime.SceneID = cloneStringPtr(input.SceneID)
-	runtime.Tracks = normalizeTrackStates(input.Tracks)
+	runtime.Tracks = tracks
 	runtime.IsPlaying = input.IsPlaying
 	runtime.BasePositionSec = input.Position
 	runtime.CapturedAtMs = capturedAtMs
@@ -1656,6 +1706,8 @@ func AudioUpsertPlaybackState(input AudioPlaybackUpdateInput) (*AudioPlaybackSta
 		worldRuntime.LoopEnabled = false
 		worldRuntime.PlaybackRate = 1
 		worldRuntime.WorldPlaybackEnabled = false
+		worldRuntime.Transition = nil
+		worldRuntime.Automation = nil
 		if worldRuntime.Revision < targetRevision {
 			worldRuntime.Revision = targetRevision
 		}
@@ -1767,6 +1819,14 @@ func AudioUpdateAsset(id string, input AudioAssetUpdateInput) (*model.AudioAsset
 		updates["variants"] = model.JSONList[model.AudioAssetVariant](input.Variants)
 		asset.Variants = model.JSONList[model.AudioAssetVariant](input.Variants)
 	}
+	if input.CuePoints != nil {
+		cuePoints, err := normalizeAudioCuePoints(*input.CuePoints, asset.DurationSeconds)
+		if err != nil {
+			return nil, err
+		}
+		updates["cue_points"] = model.JSONList[model.AudioCuePoint](cuePoints)
+		asset.CuePoints = model.JSONList[model.AudioCuePoint](cuePoints)
+	}
 	if err := model.GetDB().Model(asset).Updates(updates).Error; err != nil {
 		return nil, err
 	}
@@ -3267,6 +3327,11 @@ func AudioCreateScene(input AudioSceneInput) (*model.AudioScene, error) {
 	scene.UpdatedBy = input.ActorID
 	scene.Scope = scope
 	scene.WorldID = cloneStringPtr(input.WorldID)
+	automation, err := normalizeSceneAutomation(input.Automation, "")
+	if err != nil {
+		return nil, err
+	}
+	scene.Automation = automation
 	if err := model.GetDB().Create(scene).Error; err != nil {
 		return nil, err
 	}
@@ -3302,6 +3367,17 @@ func AudioUpdateScene(id string, input AudioSceneInput) (*model.AudioScene, erro
 		updates["channel_scope"] = input.ChannelScope
 		scene.ChannelScope = input.ChannelScope
 	}
+	if input.Automation != nil {
+		automation, err := normalizeSceneAutomation(input.Automation, scene.ID)
+		if err != nil {
+			return nil, err
+		}
+		// 显式写入 nil 时 Updates(map) 不经过序列化器，这里统一走结构体字段
+		scene.Automation = automation
+		if err := model.GetDB().Model(scene).Select("automation").Updates(&model.AudioScene{Automation: automation}).Error; err != nil {
+			return nil, err
+		}
+	}
 	if err := model.GetDB().Model(scene).Updates(updates).Error; err != nil {
 		return nil, err
 	}
@@ -3359,6 +3435,10 @@ func normalizeSceneTracks(tracks []model.AudioSceneTrack) []model.AudioSceneTrac
 			}
 			item.PlaylistAssetIDs = ids
 		}
+		if track.LoopCueID != nil && strings.TrimSpace(*track.LoopCueID) != "" {
+			value := strings.TrimSpace(*track.LoopCueID)
+			item.LoopCueID = &value
+		}
 		if len(item.PlaylistAssetIDs) == 0 {
 			item.PlaylistIndex = 0
 		} else if track.PlaylistIndex < 0 {
@@ -24,6 +24,7 @@ type BattleReportInput struct {
 	AIProviderID       string
 	AIModel            string
 	AIFeatureKey       string
+	IncludeDiceStats   bool
 }
 
 func EnsureBattleReportChannelAccess(userID, channelID string) error {
@@ -118,6 +119,7 @@ func CreateBattleReport(channelID string, userID string, input BattleReportInput
 		PeriodStart:        input.PeriodStart,
 		PeriodEnd:          input.PeriodEnd,
 		ContextReportCount: input.ContextReportCount,
+		SourceChannelIDs:   input.SourceChannelIDs,
 		SortOrder:          sortOrder,
 		Status:             input.Status,
 		ErrorMessage:       input.ErrorMessage,
@@ -127,6 +129,7 @@ func CreateBattleReport(channelID string, userID string, input BattleReportInput
 		AIProviderID:       input.AIProviderID,
 		AIModel:            input.AIModel,
 		AIFeatureKey:       input.AIFeatureKey,
+		IncludeDiceStats:   input.IncludeDiceStats,
 	}
 	item.Normalize()
 	if err := model.GetDB().Create(item).Error; err != nil {
@@ -149,6 +152,7 @@ func UpdateBattleReport(reportID string, userID string, input BattleReportInput)
 	item.PeriodStart = input.PeriodStart
 	item.PeriodEnd = input.PeriodEnd
 	item.ContextReportCount = input.ContextReportCount
+	item.IncludeDiceStats = input.IncludeDiceStats
 	item.UpdaterID = strings.TrimSpace(userID)
 	if input.Status != "" {
 		item.Status = input.Status
@@ -251,6 +255,26 @@ func ReorderBattleReports(channelID string, userID string, ids []string) error {
 	return SyncBattleReportDisplayFromReports(channelID)
 }
 
+// BattleReportDiceStats 统计战报时段内指定频道的掷骰数据，不包含悄悄话
+func BattleReportDiceStats(report *model.BattleReportModel, channelIDs []string) (*DiceStatsReport, error) {
+	if report == nil || !report.IncludeDiceStats || len(channelIDs) == 0 {
+		return nil, nil
+	}
+	f := model.DiceStatsFilter{
+		WorldID:    report.WorldID,
+		ChannelIDs: channelIDs,
+	}
+	if !report.PeriodStart.IsZero() {
+		start := report.PeriodStart
+		f.StartTime = &start
+	}
+	if !report.PeriodEnd.IsZero() {
+		end := report.PeriodEnd
+		f.EndTime = &end
+	}
+	return ComputeDiceStats(f, DiceStatsOptions{GroupByUser: true, GroupByIdentity: true})
+}
+
 func loadBattleReport(reportID string) (*model.BattleReportModel, error) {
 	reportID = strings.TrimSpace(reportID)
 	if reportID == "" {
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -11,6 +12,9 @@ import (
 	"sealchat/utils"
 )
 
+// ErrBattleReportSummaryBusy 并发的继续生成请求中只有一个能接手失败的战报
+var ErrBattleReportSummaryBusy = errors.New("战报已在重新生成中")
+
 type BattleReportSummaryInput struct {
 	Title              string
 	PeriodStart        time.Time
@@ -56,7 +60,8 @@ func StartBattleReportSummary(ctx context.Context, channelID string, userID stri
 		PeriodEnd:          input.PeriodEnd,
 		ContextReportCount: input.ContextReportCount,
 	}
-	if err := ensureBattleReportSummaryInputWithinLimit(preflightReport, channels, input.AIConfig); err != nil {
+	estimate, err := preflightBattleReportSummary(preflightReport, channels, userID, input.Source, input.AIConfig)
+	if err != nil {
 		return nil, err
 	}
 	item, err := CreateBattleReport(channelID, userID, BattleReportInput{
@@ -64,6 +69,7 @@ func StartBattleReportSummary(ctx context.Context, channelID string, userID stri
 		PeriodStart:        input.PeriodStart,
 		PeriodEnd:          input.PeriodEnd,
 		ContextReportCount: input.ContextReportCount,
+		SourceChannelIDs:   sourceChannelIDs,
 		Status:             model.BattleReportStatusGenerating,
 		AISource:           input.Source,
 		AIFeatureKey:       aiService.FeatureBattleSummary,
@@ -71,6 +77,9 @@ func StartBattleReportSummary(ctx context.Context, channelID string, userID stri
 	if err != nil {
 		return nil, err
 	}
+	item.EstimatedCost = estimate.EstimatedCost
+	_ = model.GetDB().Model(&model.BattleReportModel{}).Where("id = ?", item.ID).
+		Update("estimated_cost", estimate.EstimatedCost).Error
 	user := model.UserGet(userID)
 	if user == nil {
 		user = &model.UserModel{StringPKBaseModel: model.StringPKBaseModel{ID: userID}}
@@ -89,6 +98,91 @@ func StartBattleReportSummary(ctx context.Context, channelID string, userID stri
 	return item, nil
 }
 
+// ResumeBattleReportSummary 重新执行失败的 AI 战报，已完成的分段总结会被复用
+func ResumeBattleReportSummary(ctx context.Context, reportID string, userID string, input BattleReportSummaryInput) (*model.BattleReportModel, error) {
+	report, err := GetBattleReport(reportID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != model.BattleReportStatusFailed || report.AIFeatureKey != aiService.FeatureBattleSummary {
+		return nil, fmt.Errorf("仅失败的 AI 战报可以继续生成")
+	}
+	source := strings.TrimSpace(input.Source)
+	if source == "" {
+		source = report.AISource
+	}
+	channels, err := resolveBattleReportSourceChannels(report.ChannelID, report.WorldID, report.SourceChannelIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	estimate, err := preflightBattleReportSummary(report, channels, userID, source, input.AIConfig)
+	if err != nil {
+		return nil, err
+	}
+	tx := model.GetDB().Model(&model.BattleReportModel{}).
+		Where("id = ? AND status = ?", report.ID, model.BattleReportStatusFailed).
+		Updates(map[string]interface{}{
+			"status":         model.BattleReportStatusGenerating,
+			"error_message":  "",
+			"ai_source":      source,
+			"estimated_cost": estimate.EstimatedCost,
+		})
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return nil, ErrBattleReportSummaryBusy
+	}
+	report.Status = model.BattleReportStatusGenerating
+	report.ErrorMessage = ""
+	report.AISource = source
+	report.EstimatedCost = estimate.EstimatedCost
+	user := model.UserGet(userID)
+	if user == nil {
+		user = &model.UserModel{StringPKBaseModel: model.StringPKBaseModel{ID: userID}}
+	}
+	go func() {
+		if err := runBattleReportSummaryTask(ctx, report.ID, BattleReportSummaryRunOptions{
+			User:             user,
+			Source:           source,
+			SourceChannelIDs: report.SourceChannelIDs,
+			AIConfig:         input.AIConfig,
+			Runner:           input.Runner,
+		}); err != nil {
+			_ = markBattleReportSummaryFailed(report.ID, err)
+		}
+	}()
+	return report, nil
+}
+
+// EstimateBattleReportSummary 预估分段数量、调用次数与平台额度消耗
+func EstimateBattleReportSummary(channelID string, userID string, source string, input BattleReportSummaryPromptInput) (*BattleReportSummaryEstimate, error) {
+	channels, err := resolveBattleReportSourceChannels(channelID, "", input.SourceChannelIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	report := &model.BattleReportModel{
+		ChannelID:          strings.TrimSpace(channelID),
+		WorldID:            channels[0].WorldID,
+		Title:              input.Title,
+		PeriodStart:        input.PeriodStart,
+		PeriodEnd:          input.PeriodEnd,
+		ContextReportCount: input.ContextReportCount,
+	}
+	messageGroups, err := loadBattleReportMessageGroups(channels, report.PeriodStart, report.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	if battleReportMessageGroupLen(messageGroups) == 0 {
+		return nil, fmt.Errorf("所选时间范围内没有可总结的消息")
+	}
+	contextReports, err := loadBattleReportContextReports(report)
+	if err != nil {
+		return nil, err
+	}
+	return estimateBattleReportSummary(report, contextReports, messageGroups, input.AIConfig, strings.EqualFold(strings.TrimSpace(source), "platform"))
+}
+
 func BuildBattleReportSummaryPrompt(channelID string, userID string, input BattleReportSummaryPromptInput) (string, error) {
 	channels, err := resolveBattleReportSourceChannels(channelID, "", input.SourceChannelIDs, userID)
 	if err != nil {
@@ -143,39 +237,77 @@ func runBattleReportSummaryTask(ctx context.Context, reportID string, opts Battl
 	if err != nil {
 		return err
 	}
-	prompt := buildBattleReportSummaryPromptWithGroups(report, contextReports, messageGroups)
-	if err := validateBattleReportSummaryPromptSize(prompt, opts.AIConfig); err != nil {
-		return markBattleReportSummaryFailed(report.ID, err)
-	}
-	output, err := aiService.RunTaskWithBilling(ctx, aiService.BilledRunInput{
-		Config:     opts.AIConfig,
-		User:       opts.User,
-		FeatureKey: aiService.FeatureBattleSummary,
-		WorldID:    report.WorldID,
-		Input:      prompt,
-		Source:     opts.Source,
-		Runner:     opts.Runner,
-	})
+	var lastOutput aiService.BilledRunOutput
+	summarize := func(stage string, level int, index int, prompt string) (string, error) {
+		hash := battleReportChunkHash(prompt)
+		cacheable := stage != BattleReportSummaryStageFinal
+		if cacheable {
+			if cached, err := model.BattleReportSummaryChunkGet(report.ID, level, index); err == nil && cached != nil && cached.InputHash == hash {
+				return cached.Summary, nil
+			}
+		}
+		output, err := aiService.RunTaskWithBilling(ctx, aiService.BilledRunInput{
+			Config:     opts.AIConfig,
+			User:       opts.User,
+			FeatureKey: aiService.FeatureBattleSummary,
+			WorldID:    report.WorldID,
+			Input:      prompt,
+			Source:     opts.Source,
+			Runner:     opts.Runner,
+		})
+		if err != nil {
+			return "", err
+		}
+		text := strings.TrimSpace(output.Result.Result)
+		if text == "" {
+			return "", fmt.Errorf("AI 返回空战报")
+		}
+		lastOutput = output
+		if cacheable {
+			if err := model.BattleReportSummaryChunkSave(&model.BattleReportSummaryChunkModel{
+				ReportID:   report.ID,
+				Level:      level,
+				ChunkIndex: index,
+				InputHash:  hash,
+				Summary:    text,
+				ProviderID: output.Result.ProviderID,
+				Model:      output.Result.Model,
+			}); err != nil {
+				return "", err
+			}
+		}
+		return text, nil
+	}
+	progress := func(stage string, level int, done int, total int) {
+		_ = model.GetDB().Model(&model.BattleReportModel{}).
+			Where("id = ? AND is_deleted = ?", report.ID, false).
+			Updates(map[string]interface{}{
+				"summary_stage": stage,
+				"summary_level": level,
+				"summary_done":  done,
+				"summary_total": total,
+			}).Error
+	}
+	result, err := runBattleReportMapReduce(report, contextReports, messageGroups, battleReportSummaryBudget(opts.AIConfig), summarize, progress)
 	if err != nil {
 		return markBattleReportSummaryFailed(report.ID, err)
 	}
-	result := strings.TrimSpace(output.Result.Result)
-	if result == "" {
-		return markBattleReportSummaryFailed(report.ID, fmt.Errorf("AI 返回空战报"))
-	}
 	updates := map[string]interface{}{
 		"content":         result,
 		"content_preview": model.BuildBattleReportPreview(result, 200),
 		"status":          model.BattleReportStatusReady,
 		"error_message":   "",
 		"ai_source":       strings.TrimSpace(opts.Source),
-		"ai_provider_id":  output.Result.ProviderID,
-		"ai_model":        output.Result.Model,
+		"ai_provider_id":  lastOutput.Result.ProviderID,
+		"ai_model":        lastOutput.Result.Model,
 		"ai_feature_key":  aiService.FeatureBattleSummary,
 	}
-	return model.GetDB().Model(&model.BattleReportModel{}).
+	if err := model.GetDB().Model(&model.BattleReportModel{}).
 		Where("id = ? AND is_deleted = ?", report.ID, false).
-		Updates(updates).Error
+		Updates(updates).Error; err != nil {
+		return err
+	}
+	return model.BattleReportSummaryChunkClear(report.ID)
 }
 
 func resolveBattleReportSourceChannels(primaryChannelID string, worldID string, sourceChannelIDs []string, userID string) ([]*model.ChannelModel, error) {
@@ -259,19 +391,30 @@ func battleReportSummaryMaxInputChars(cfg utils.AIConfig) int {
 	return normalized.Features[aiService.FeatureBattleSummary].Params.MaxInputChars
 }
 
-func ensureBattleReportSummaryInputWithinLimit(report *model.BattleReportModel, channels []*model.ChannelModel, cfg utils.AIConfig) error {
+// preflightBattleReportSummary 规划分段并预估成本；平台额度不足以覆盖全部调用时直接拒绝
+func preflightBattleReportSummary(report *model.BattleReportModel, channels []*model.ChannelModel, userID string, source string, cfg utils.AIConfig) (*BattleReportSummaryEstimate, error) {
 	messageGroups, err := loadBattleReportMessageGroups(channels, report.PeriodStart, report.PeriodEnd)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if battleReportMessageGroupLen(messageGroups) == 0 {
-		return fmt.Errorf("所选时间范围内没有可总结的消息")
+		return nil, fmt.Errorf("所选时间范围内没有可总结的消息")
 	}
 	contextReports, err := loadBattleReportContextReports(report)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	platform := strings.EqualFold(strings.TrimSpace(source), "platform")
+	estimate, err := estimateBattleReportSummary(report, contextReports, messageGroups, cfg, platform)
+	if err != nil {
+		return nil, err
+	}
+	if platform {
+		if err := aiService.EnsureQuotaAvailable(cfg, userID, estimate.EstimatedCost, time.Now()); err != nil {
+			return nil, err
+		}
 	}
-	return validateBattleReportSummaryPromptSize(buildBattleReportSummaryPromptWithGroups(report, contextReports, messageGroups), cfg)
+	return estimate, nil
 }
 
 func validateBattleReportSummaryPromptSize(prompt string, cfg utils.AIConfig) error {
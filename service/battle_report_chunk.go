@@ -0,0 +1,300 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"sealchat/model"
+	aiService "sealchat/service/ai"
+	"sealchat/utils"
+)
+
+const (
+	BattleReportSummaryStageMap    = "map"
+	BattleReportSummaryStageReduce = "reduce"
+	BattleReportSummaryStageFinal  = "final"
+)
+
+// battleReportSummarizeFunc 执行一次总结调用；level 0 为原始记录分段，之后每层为上一层的合并
+type battleReportSummarizeFunc func(stage string, level int, index int, prompt string) (string, error)
+
+type battleReportProgressFunc func(stage string, level int, done int, total int)
+
+type battleReportLineGroup struct {
+	Name  string
+	Lines []string
+}
+
+// BattleReportSummaryEstimate 分段总结的调用次数与平台额度预估
+type BattleReportSummaryEstimate struct {
+	Windows       int     `json:"windows"`
+	Calls         int     `json:"calls"`
+	EstimatedCost float64 `json:"estimatedCost"`
+	ProviderID    string  `json:"providerId,omitempty"`
+	Model         string  `json:"model,omitempty"`
+}
+
+func battleReportSummaryBudget(cfg utils.AIConfig) int {
+	if maxInputChars := battleReportSummaryMaxInputChars(cfg); maxInputChars > 0 {
+		return maxInputChars
+	}
+	return aiService.BuiltinFeatures()[aiService.FeatureBattleSummary].InputMaxChars
+}
+
+func battleReportLineGroups(groups []BattleReportMessageGroup) []battleReportLineGroup {
+	out := make([]battleReportLineGroup, 0, len(groups))
+	for _, group := range groups {
+		lines := make([]string, 0, len(group.Messages))
+		for _, msg := range group.Messages {
+			if line := formatBattleReportMessageLine(msg); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		name := strings.TrimSpace(group.ChannelName)
+		if name == "" {
+			name = strings.TrimSpace(group.ChannelID)
+		}
+		if name == "" {
+			name = "未命名频道"
+		}
+		out = append(out, battleReportLineGroup{Name: name, Lines: lines})
+	}
+	return out
+}
+
+// splitBattleReportWindows 按字符预算切分记录，跨频道时保留频道标题；单行超出预算时截断
+func splitBattleReportWindows(groups []battleReportLineGroup, capacity int) [][]battleReportLineGroup {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	withHeading := len(groups) > 1
+	var windows [][]battleReportLineGroup
+	var current []battleReportLineGroup
+	used := 0
+	flush := func() {
+		if len(current) > 0 {
+			windows = append(windows, current)
+		}
+		current = nil
+		used = 0
+	}
+	for _, group := range groups {
+		headingCost := 0
+		if withHeading {
+			headingCost = len([]rune(group.Name)) + 4
+		}
+		opened := false
+		for _, line := range group.Lines {
+			runes := []rune(line)
+			if limit := capacity - headingCost - 1; len(runes) > limit && limit > 0 {
+				line = string(runes[:limit])
+				runes = runes[:limit]
+			}
+			cost := len(runes) + 1
+			if !opened {
+				cost += headingCost
+			}
+			if used+cost > capacity && used > 0 {
+				flush()
+				opened = false
+				cost = len(runes) + 1 + headingCost
+			}
+			if !opened {
+				current = append(current, battleReportLineGroup{Name: group.Name})
+				opened = true
+			}
+			current[len(current)-1].Lines = append(current[len(current)-1].Lines, line)
+			used += cost
+		}
+	}
+	flush()
+	return windows
+}
+
+func buildBattleReportWindowPrompt(report *model.BattleReportModel, window []battleReportLineGroup, index int, total int) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("以下是一段较长跑团记录的第 %d/%d 段，请提炼本段的关键事件、人物行动与结果，供之后合并为完整战报。\n", index, total))
+	writeBattleReportPeriod(&builder, report)
+	builder.WriteString("\n本段记录：\n")
+	withHeading := len(window) > 1
+	for _, group := range window {
+		if withHeading {
+			builder.WriteString("## ")
+			builder.WriteString(group.Name)
+			builder.WriteString("\n")
+		}
+		for _, line := range group.Lines {
+			builder.WriteString(line)
+			builder.WriteString("\n")
+		}
+	}
+	builder.WriteString("\n要求：忠实原意，按事件顺序列出要点，不要编造未出现的信息。")
+	return strings.TrimSpace(builder.String())
+}
+
+func buildBattleReportMergePrompt(summaries []string) string {
+	var builder strings.Builder
+	builder.WriteString("以下是同一段跑团记录按时间顺序排列的分段总结，请合并为一份连贯的阶段总结，保留关键事件与因果，删去重复内容。\n")
+	writeBattleReportSummaries(&builder, summaries)
+	builder.WriteString("\n要求：忠实原意，按事件顺序整理，不要编造未出现的信息。")
+	return strings.TrimSpace(builder.String())
+}
+
+func buildBattleReportFinalPrompt(report *model.BattleReportModel, contextReports []*model.BattleReportModel, summaries []string) string {
+	var builder strings.Builder
+	builder.WriteString("请根据以下跑团记录的分段总结生成战报总结。\n")
+	writeBattleReportPeriod(&builder, report)
+	writeBattleReportContext(&builder, contextReports)
+	builder.WriteString("\n本次记录（分段总结）：\n")
+	writeBattleReportSummaries(&builder, summaries)
+	builder.WriteString("\n要求：忠实原意，按事件顺序整理，不要编造未出现的信息。")
+	return strings.TrimSpace(builder.String())
+}
+
+func writeBattleReportSummaries(builder *strings.Builder, summaries []string) {
+	for i, summary := range summaries {
+		builder.WriteString(fmt.Sprintf("\n## 第 %d 段\n", i+1))
+		builder.WriteString(strings.TrimSpace(summary))
+		builder.WriteString("\n")
+	}
+}
+
+// batchBattleReportSummaries 贪心地把相邻总结装入预算内的批次
+func batchBattleReportSummaries(summaries []string, budget int) [][]string {
+	var batches [][]string
+	var current []string
+	for _, summary := range summaries {
+		candidate := append(append([]string(nil), current...), summary)
+		if len(current) > 0 && countBattleReportInputChars(buildBattleReportMergePrompt(candidate)) > budget {
+			batches = append(batches, current)
+			current = []string{summary}
+			continue
+		}
+		current = candidate
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// runBattleReportMapReduce 记录能一次放入预算时直接总结；否则先分段总结，再逐层合并到最终提示词放得下为止
+func runBattleReportMapReduce(
+	report *model.BattleReportModel,
+	contextReports []*model.BattleReportModel,
+	messageGroups []BattleReportMessageGroup,
+	budget int,
+	summarize battleReportSummarizeFunc,
+	progress battleReportProgressFunc,
+) (string, error) {
+	if progress == nil {
+		progress = func(string, int, int, int) {}
+	}
+	direct := buildBattleReportSummaryPromptWithGroups(report, contextReports, messageGroups)
+	if countBattleReportInputChars(direct) <= budget {
+		progress(BattleReportSummaryStageFinal, 0, 0, 1)
+		result, err := summarize(BattleReportSummaryStageFinal, 0, 0, direct)
+		if err == nil {
+			progress(BattleReportSummaryStageFinal, 0, 1, 1)
+		}
+		return result, err
+	}
+
+	overhead := countBattleReportInputChars(buildBattleReportWindowPrompt(report, nil, 9999, 9999))
+	windows := splitBattleReportWindows(battleReportLineGroups(messageGroups), budget-overhead)
+	summaries := make([]string, 0, len(windows))
+	progress(BattleReportSummaryStageMap, 0, 0, len(windows))
+	for i, window := range windows {
+		result, err := summarize(BattleReportSummaryStageMap, 0, i, buildBattleReportWindowPrompt(report, window, i+1, len(windows)))
+		if err != nil {
+			return "", err
+		}
+		summaries = append(summaries, result)
+		progress(BattleReportSummaryStageMap, 0, i+1, len(windows))
+	}
+
+	for level := 1; ; level++ {
+		if len(summaries) <= 1 || countBattleReportInputChars(buildBattleReportFinalPrompt(report, contextReports, summaries)) <= budget {
+			break
+		}
+		batches := batchBattleReportSummaries(summaries, budget)
+		if len(batches) >= len(summaries) {
+			return "", fmt.Errorf("分段总结过长，无法继续合并，请调大输入上限或缩短时间范围")
+		}
+		merged := make([]string, 0, len(batches))
+		progress(BattleReportSummaryStageReduce, level, 0, len(batches))
+		for i, batch := range batches {
+			if len(batch) == 1 {
+				merged = append(merged, batch[0])
+			} else {
+				result, err := summarize(BattleReportSummaryStageReduce, level, i, buildBattleReportMergePrompt(batch))
+				if err != nil {
+					return "", err
+				}
+				merged = append(merged, result)
+			}
+			progress(BattleReportSummaryStageReduce, level, i+1, len(batches))
+		}
+		summaries = merged
+	}
+
+	final := buildBattleReportFinalPrompt(report, contextReports, summaries)
+	if countBattleReportInputChars(final) > budget {
+		return "", fmt.Errorf("战报前情提要过长，请减少引用的前情战报数量")
+	}
+	progress(BattleReportSummaryStageFinal, 0, 0, 1)
+	result, err := summarize(BattleReportSummaryStageFinal, 0, 0, final)
+	if err == nil {
+		progress(BattleReportSummaryStageFinal, 0, 1, 1)
+	}
+	return result, err
+}
+
+// estimateBattleReportSummary 用占位总结走一遍同样的切分与合并流程，逐次调用 EstimatePlatformReservation 累加成本
+func estimateBattleReportSummary(
+	report *model.BattleReportModel,
+	contextReports []*model.BattleReportModel,
+	messageGroups []BattleReportMessageGroup,
+	cfg utils.AIConfig,
+	withCost bool,
+) (*BattleReportSummaryEstimate, error) {
+	normalized := utils.NormalizeAIConfig(cfg)
+	completionChars := normalized.Features[aiService.FeatureBattleSummary].Params.MaxTokens
+	if completionChars <= 0 {
+		completionChars = 512
+	}
+	placeholder := strings.Repeat("事", completionChars)
+	estimate := &BattleReportSummaryEstimate{}
+	_, err := runBattleReportMapReduce(report, contextReports, messageGroups, battleReportSummaryBudget(cfg),
+		func(stage string, level int, index int, prompt string) (string, error) {
+			estimate.Calls++
+			if stage == BattleReportSummaryStageMap {
+				estimate.Windows++
+			}
+			if !withCost {
+				return placeholder, nil
+			}
+			providerID, modelName, cost, err := aiService.EstimatePlatformReservation(cfg, aiService.FeatureBattleSummary, prompt)
+			if err != nil {
+				return "", err
+			}
+			estimate.ProviderID = providerID
+			estimate.Model = modelName
+			estimate.EstimatedCost += cost
+			return placeholder, nil
+		}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return estimate, nil
+}
+
+func battleReportChunkHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+func buildBattleReportChunkTestGroups(count int) []BattleReportMessageGroup {
+	base := time.Date(2026, 3, 1, 20, 0, 0, 0, time.Local)
+	groups := []BattleReportMessageGroup{
+		{ChannelID: "ch-a", ChannelName: "主线"},
+		{ChannelID: "ch-b", ChannelName: "支线"},
+	}
+	for i := 0; i < count; i++ {
+		msg := &model.MessageModel{
+			Content:          fmt.Sprintf("第%03d句台词，调查员推开了吱呀作响的木门，屋里弥漫着潮湿的霉味。", i),
+			SenderMemberName: "调查员",
+		}
+		msg.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		groups[i%2].Messages = append(groups[i%2].Messages, msg)
+	}
+	return groups
+}
+
+func TestRunBattleReportMapReduceSplitsAndMergesWithinBudget(t *testing.T) {
+	report := &model.BattleReportModel{Title: "长团"}
+	groups := buildBattleReportChunkTestGroups(80)
+	budget := 800
+
+	var calls []string
+	var stages []string
+	summarize := func(stage string, level int, index int, prompt string) (string, error) {
+		if got := countBattleReportInputChars(prompt); got > budget {
+			t.Fatalf("%s prompt exceeds budget: %d > %d", stage, got, budget)
+		}
+		calls = append(calls, prompt)
+		stages = append(stages, stage)
+		return strings.Repeat("要", 150) + fmt.Sprintf("%s-%d-%d", stage, level, index), nil
+	}
+	var lastProgress [4]any
+	result, err := runBattleReportMapReduce(report, nil, groups, budget, summarize, func(stage string, level int, done int, total int) {
+		lastProgress = [4]any{stage, level, done, total}
+	})
+	if err != nil {
+		t.Fatalf("map reduce failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "final-0-0") {
+		t.Fatalf("result should come from the final stage, got %q", result)
+	}
+	if lastProgress != [4]any{BattleReportSummaryStageFinal, 0, 1, 1} {
+		t.Fatalf("unexpected final progress: %v", lastProgress)
+	}
+
+	mapCount, reduceCount := 0, 0
+	for _, stage := range stages {
+		switch stage {
+		case BattleReportSummaryStageMap:
+			mapCount++
+		case BattleReportSummaryStageReduce:
+			reduceCount++
+		}
+	}
+	if mapCount < 2 || reduceCount == 0 || stages[len(stages)-1] != BattleReportSummaryStageFinal {
+		t.Fatalf("expected map, reduce and final stages, got %v", stages)
+	}
+
+	// 所有原始台词都应出现在某个分段里，且顺序不变
+	joined := strings.Join(calls[:mapCount], "\n")
+	last := -1
+	for i := 0; i < 80; i += 2 {
+		pos := strings.Index(joined, fmt.Sprintf("第%03d句", i))
+		if pos < 0 || pos < last {
+			t.Fatalf("line %d missing or out of order", i)
+		}
+		last = pos
+	}
+
+	estimate, err := estimateBattleReportSummary(report, nil, groups, utils.AIConfig{
+		Features: map[string]utils.AIFeatureConfig{
+			"battle_summary": {Params: utils.AIModelParams{MaxInputChars: budget, MaxTokens: 150}},
+		},
+	}, false)
+	if err != nil {
+		t.Fatalf("estimate failed: %v", err)
+	}
+	if estimate.Windows != mapCount {
+		t.Fatalf("estimate windows = %d, want %d", estimate.Windows, mapCount)
+	}
+}
+
+func TestRunBattleReportMapReduceUsesSingleCallWhenInputFits(t *testing.T) {
+	groups := buildBattleReportChunkTestGroups(4)
+	calls := 0
+	_, err := runBattleReportMapReduce(&model.BattleReportModel{}, nil, groups, 30000,
+		func(stage string, level int, index int, prompt string) (string, error) {
+			calls++
+			if stage != BattleReportSummaryStageFinal || !strings.Contains(prompt, "本次记录：") {
+				t.Fatalf("small input should use the direct prompt, got stage %s", stage)
+			}
+			return "ok", nil
+		}, nil)
+	if err != nil || calls != 1 {
+		t.Fatalf("calls = %d err = %v, want single call", calls, err)
+	}
+}
+
+func TestBattleReportSummaryChunkSaveUpserts(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+
+	first := &model.BattleReportSummaryChunkModel{ReportID: "report-1", Level: 0, ChunkIndex: 2, InputHash: "h1", Summary: "旧"}
+	if err := model.BattleReportSummaryChunkSave(first); err != nil {
+		t.Fatalf("save chunk failed: %v", err)
+	}
+	if err := model.BattleReportSummaryChunkSave(&model.BattleReportSummaryChunkModel{ReportID: "report-1", Level: 0, ChunkIndex: 2, InputHash: "h2", Summary: "新"}); err != nil {
+		t.Fatalf("upsert chunk failed: %v", err)
+	}
+	got, err := model.BattleReportSummaryChunkGet("report-1", 0, 2)
+	if err != nil || got == nil || got.InputHash != "h2" || got.Summary != "新" {
+		t.Fatalf("unexpected chunk after upsert: %#v err=%v", got, err)
+	}
+	if err := model.BattleReportSummaryChunkClear("report-1"); err != nil {
+		t.Fatalf("clear chunks failed: %v", err)
+	}
+	if got, _ := model.BattleReportSummaryChunkGet("report-1", 0, 2); got != nil {
+		t.Fatal("chunks should be cleared")
+	}
+}
@@ -21,28 +21,8 @@ func buildBattleReportSummaryPrompt(report *model.BattleReportModel, contextRepo
 func buildBattleReportSummaryPromptWithGroups(report *model.BattleReportModel, contextReports []*model.BattleReportModel, messageGroups []BattleReportMessageGroup) string {
 	var builder strings.Builder
 	builder.WriteString("请根据以下跑团聊天记录生成战报总结。\n")
-	if report != nil {
-		if !report.PeriodStart.IsZero() || !report.PeriodEnd.IsZero() {
-			builder.WriteString("\n时间周期：")
-			builder.WriteString(formatBattleReportTime(report.PeriodStart))
-			builder.WriteString(" - ")
-			builder.WriteString(formatBattleReportTime(report.PeriodEnd))
-			builder.WriteString("\n")
-		}
-	}
-	if len(contextReports) > 0 {
-		builder.WriteString("\n前情提要：\n")
-		for _, item := range contextReports {
-			if item == nil {
-				continue
-			}
-			builder.WriteString("## ")
-			builder.WriteString(strings.TrimSpace(item.Title))
-			builder.WriteString("\n")
-			builder.WriteString(strings.TrimSpace(item.Content))
-			builder.WriteString("\n")
-		}
-	}
+	writeBattleReportPeriod(&builder, report)
+	writeBattleReportContext(&builder, contextReports)
 	builder.WriteString("\n本次记录：\n")
 	withChannelHeading := len(messageGroups) > 1
 	for _, group := range messageGroups {
@@ -74,6 +54,34 @@ func buildBattleReportSummaryPromptWithGroups(report *model.BattleReportModel, c
 	return strings.TrimSpace(builder.String())
 }
 
+func writeBattleReportPeriod(builder *strings.Builder, report *model.BattleReportModel) {
+	if report == nil || (report.PeriodStart.IsZero() && report.PeriodEnd.IsZero()) {
+		return
+	}
+	builder.WriteString("\n时间周期：")
+	builder.WriteString(formatBattleReportTime(report.PeriodStart))
+	builder.WriteString(" - ")
+	builder.WriteString(formatBattleReportTime(report.PeriodEnd))
+	builder.WriteString("\n")
+}
+
+func writeBattleReportContext(builder *strings.Builder, contextReports []*model.BattleReportModel) {
+	if len(contextReports) == 0 {
+		return
+	}
+	builder.WriteString("\n前情提要：\n")
+	for _, item := range contextReports {
+		if item == nil {
+			continue
+		}
+		builder.WriteString("## ")
+		builder.WriteString(strings.TrimSpace(item.Title))
+		builder.WriteString("\n")
+		builder.WriteString(strings.TrimSpace(item.Content))
+		builder.WriteString("\n")
+	}
+}
+
 func formatBattleReportMessageLine(msg *model.MessageModel) string {
 	if msg == nil {
 		return ""
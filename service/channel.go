@@ -360,6 +360,8 @@ func ChannelNew(channelID, channelType, channelName, worldID, creatorId, parentI
 			pm.PermFuncChannelTheaterResourceDelete,
 			pm.PermFuncChannelTheaterActionTrigger,
 			pm.PermFuncChannelTheaterAdminRestore,
+			pm.PermFuncChannelVoiceJoin,
+			pm.PermFuncChannelVoiceManage,
 		}
 	})
 
@@ -392,6 +394,8 @@ func ChannelNew(channelID, channelType, channelName, worldID, creatorId, parentI
 			pm.PermFuncChannelTheaterResourceDelete,
 			pm.PermFuncChannelTheaterActionTrigger,
 			pm.PermFuncChannelTheaterAdminRestore,
+			pm.PermFuncChannelVoiceJoin,
+			pm.PermFuncChannelVoiceManage,
 		}
 	})
 
@@ -427,6 +431,7 @@ func ChannelNew(channelID, channelType, channelName, worldID, creatorId, parentI
 			pm.PermFuncChannelTheaterView,
 			pm.PermFuncChannelTheaterObjectEditDelegated,
 			pm.PermFuncChannelTheaterActionTrigger,
+			pm.PermFuncChannelVoiceJoin,
 		}
 	})
 
@@ -148,6 +148,15 @@ func validateIdentityInput(input *ChannelIdentityInput) error {
 	if len([]rune(input.DisplayName)) > 32 {
 		return errors.New("频道昵称长度需在32个字符以内")
 	}
+	worldID := ""
+	if channel, _ := model.ChannelGet(input.ChannelID); channel != nil {
+		worldID = channel.WorldID
+	}
+	displayName, err := ContentFilterApplyText(worldID, model.ContentFilterScopeIdentity, input.DisplayName)
+	if err != nil {
+		return err
+	}
+	input.DisplayName = displayName
 	if input.Color != "" {
 		color := model.ChannelIdentityNormalizeColor(input.Color)
 		if color == "" {
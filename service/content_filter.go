@@ -0,0 +1,623 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/service/contentfilter"
+)
+
+const (
+	contentFilterImportMax  = 5000
+	contentFilterSnippetMax = 64
+)
+
+var (
+	ErrContentFilterBlocked         = errors.New("内容包含违禁词，无法提交")
+	ErrContentFilterNeedsApproval   = errors.New("内容需要管理员审核后才能发布")
+	ErrContentFilterPermission      = errors.New("无权管理内容过滤规则")
+	ErrContentFilterRuleNotFound    = errors.New("过滤规则不存在")
+	ErrContentFilterRuleInvalid     = errors.New("过滤规则无效")
+	ErrContentFilterPendingNotFound = errors.New("待审核内容不存在")
+	ErrContentFilterPendingReviewed = errors.New("该内容已审核")
+)
+
+var contentFilterTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+var contentFilterActionSeverity = map[string]int{
+	model.ContentFilterActionMask:    1,
+	model.ContentFilterActionFlag:    2,
+	model.ContentFilterActionApprove: 3,
+	model.ContentFilterActionBlock:   4,
+}
+
+var contentFilterAllScopes = []string{
+	model.ContentFilterScopeMessage,
+	model.ContentFilterScopeNickname,
+	model.ContentFilterScopeIdentity,
+	model.ContentFilterScopeWorld,
+}
+
+type ContentFilterRuleInput struct {
+	Pattern   string   `json:"pattern"`
+	MatchType string   `json:"matchType"`
+	Action    string   `json:"action"`
+	Scopes    []string `json:"scopes"`
+	Enabled   *bool    `json:"enabled"`
+	Note      string   `json:"note"`
+}
+
+type ContentFilterHit struct {
+	RuleID  string `json:"ruleId"`
+	Action  string `json:"action"`
+	Snippet string `json:"snippet"`
+}
+
+// ContentFilterResult 过滤结果，Action 为命中规则中最严重的动作
+type ContentFilterResult struct {
+	Action      string             `json:"action"`
+	Text        string             `json:"text"`
+	Hits        []ContentFilterHit `json:"hits"`
+	RuleIDs     []string           `json:"ruleIds"`
+	FlagRuleIDs []string           `json:"flagRuleIds,omitempty"`
+}
+
+func (r *ContentFilterResult) Blocked() bool {
+	return r != nil && r.Action == model.ContentFilterActionBlock
+}
+
+func (r *ContentFilterResult) NeedsApproval() bool {
+	return r != nil && r.Action == model.ContentFilterActionApprove
+}
+
+func (r *ContentFilterResult) Flagged() bool {
+	return r != nil && len(r.FlagRuleIDs) > 0
+}
+
+type contentFilterCache struct {
+	mu       sync.RWMutex
+	matchers map[string]*contentfilter.Matcher
+	// generation 每次失效递增，加载期间发生过失效的结果不写回缓存
+	generation uint64
+}
+
+var defaultContentFilterCache = &contentFilterCache{matchers: map[string]*contentfilter.Matcher{}}
+
+func (c *contentFilterCache) invalidate() {
+	c.mu.Lock()
+	c.matchers = map[string]*contentfilter.Matcher{}
+	c.generation++
+	c.mu.Unlock()
+}
+
+func (c *contentFilterCache) get(worldID, scope string) *contentfilter.Matcher {
+	key := worldID + "|" + scope
+	c.mu.RLock()
+	matcher, ok := c.matchers[key]
+	generation := c.generation
+	c.mu.RUnlock()
+	if ok {
+		return matcher
+	}
+	var rules []*model.ContentFilterRuleModel
+	if err := model.GetDB().Where("world_id = ? AND enabled = ?", worldID, true).Find(&rules).Error; err != nil {
+		return nil
+	}
+	compiled := make([]contentfilter.Rule, 0, len(rules))
+	for _, rule := range rules {
+		if !contentFilterRuleHasScope(rule, scope) {
+			continue
+		}
+		compiled = append(compiled, contentfilter.Rule{
+			ID:      rule.ID,
+			Pattern: rule.Pattern,
+			Regex:   rule.MatchType == model.ContentFilterMatchRegex,
+			Action:  rule.Action,
+		})
+	}
+	matcher, _ = contentfilter.Compile(compiled)
+	c.mu.Lock()
+	if c.generation == generation {
+		c.matchers[key] = matcher
+	}
+	c.mu.Unlock()
+	return matcher
+}
+
+func contentFilterRuleHasScope(rule *model.ContentFilterRuleModel, scope string) bool {
+	if strings.TrimSpace(rule.Scopes) == "" {
+		return true
+	}
+	for _, item := range strings.Split(rule.Scopes, ",") {
+		if strings.TrimSpace(item) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// transformContentText 只对正文文本部分调用 fn：TipTap JSON 处理 text 节点，其余格式跳过标签
+func transformContentText(content string, fn func(string) string) string {
+	if LooksLikeTipTapJSON(content) {
+		var doc any
+		if err := json.Unmarshal([]byte(content), &doc); err == nil {
+			changed := false
+			var walk func(node any)
+			walk = func(node any) {
+				switch value := node.(type) {
+				case map[string]any:
+					if text, ok := value["text"].(string); ok && value["type"] == "text" {
+						if replaced := fn(text); replaced != text {
+							value["text"] = replaced
+							changed = true
+						}
+					}
+					if children, ok := value["content"].([]any); ok {
+						for _, child := range children {
+							walk(child)
+						}
+					}
+				}
+			}
+			walk(doc)
+			if !changed {
+				return content
+			}
+			if data, err := json.Marshal(doc); err == nil {
+				return string(data)
+			}
+			return content
+		}
+	}
+	locs := contentFilterTagPattern.FindAllStringIndex(content, -1)
+	if len(locs) == 0 {
+		return fn(content)
+	}
+	var sb strings.Builder
+	last := 0
+	for _, loc := range locs {
+		if loc[0] > last {
+			sb.WriteString(fn(content[last:loc[0]]))
+		}
+		sb.WriteString(content[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	if last < len(content) {
+		sb.WriteString(fn(content[last:]))
+	}
+	return sb.String()
+}
+
+func truncateContentFilterSnippet(text string) string {
+	runes := []rune(text)
+	if len(runes) > contentFilterSnippetMax {
+		return string(runes[:contentFilterSnippetMax]) + "…"
+	}
+	return text
+}
+
+func runContentFilter(matchers []*contentfilter.Matcher, scope, content string) *ContentFilterResult {
+	result := &ContentFilterResult{Text: content}
+	active := make([]*contentfilter.Matcher, 0, len(matchers))
+	for _, matcher := range matchers {
+		if !matcher.Empty() {
+			active = append(active, matcher)
+		}
+	}
+	if len(active) == 0 || strings.TrimSpace(content) == "" {
+		return result
+	}
+	seenRules := map[string]struct{}{}
+	seenFlags := map[string]struct{}{}
+	inspect := func(text string) string {
+		var maskMatches []contentfilter.Match
+		runes := []rune(text)
+		for _, matcher := range active {
+			for _, match := range matcher.Find(text) {
+				result.Hits = append(result.Hits, ContentFilterHit{
+					RuleID: match.RuleID, Action: match.Action,
+					Snippet: truncateContentFilterSnippet(string(runes[match.Start:match.End])),
+				})
+				if _, ok := seenRules[match.RuleID]; !ok {
+					seenRules[match.RuleID] = struct{}{}
+					result.RuleIDs = append(result.RuleIDs, match.RuleID)
+				}
+				if match.Action == model.ContentFilterActionFlag {
+					if _, ok := seenFlags[match.RuleID]; !ok {
+						seenFlags[match.RuleID] = struct{}{}
+						result.FlagRuleIDs = append(result.FlagRuleIDs, match.RuleID)
+					}
+				}
+				if contentFilterActionSeverity[match.Action] > contentFilterActionSeverity[result.Action] {
+					result.Action = match.Action
+				}
+				if match.Action == model.ContentFilterActionMask {
+					maskMatches = append(maskMatches, match)
+				}
+			}
+		}
+		return contentfilter.Mask(text, maskMatches)
+	}
+	if scope == model.ContentFilterScopeMessage {
+		result.Text = transformContentText(content, inspect)
+	} else {
+		result.Text = inspect(content)
+	}
+	return result
+}
+
+func recordContentFilterHits(ruleIDs []string) {
+	if len(ruleIDs) == 0 {
+		return
+	}
+	_ = model.GetDB().Model(&model.ContentFilterRuleModel{}).Where("id IN ?", ruleIDs).
+		Updates(map[string]any{"hit_count": gorm.Expr("hit_count + ?", 1), "last_hit_at": time.Now()}).Error
+}
+
+// ContentFilterCheck 以全站规则和世界规则检查内容，并累计命中统计
+func ContentFilterCheck(worldID, scope, content string) *ContentFilterResult {
+	matchers := []*contentfilter.Matcher{defaultContentFilterCache.get("", scope)}
+	if worldID = strings.TrimSpace(worldID); worldID != "" {
+		matchers = append(matchers, defaultContentFilterCache.get(worldID, scope))
+	}
+	result := runContentFilter(matchers, scope, content)
+	if len(result.RuleIDs) > 0 {
+		go recordContentFilterHits(result.RuleIDs)
+	}
+	return result
+}
+
+// ContentFilterApplyText 用于昵称、角色名、世界简介等无法进入审核队列的字段：需审核视同拦截
+func ContentFilterApplyText(worldID, scope, text string) (string, error) {
+	result := ContentFilterCheck(worldID, scope, text)
+	if result.Blocked() || result.NeedsApproval() {
+		return text, ErrContentFilterBlocked
+	}
+	return result.Text, nil
+}
+
+func canManageContentFilter(worldID, actorID string) bool {
+	if isReportSystemAdmin(actorID) {
+		return true
+	}
+	return worldID != "" && IsWorldAdmin(worldID, actorID)
+}
+
+func normalizeContentFilterRuleInput(input ContentFilterRuleInput) (*model.ContentFilterRuleModel, error) {
+	rule := &model.ContentFilterRuleModel{
+		Pattern:   strings.TrimSpace(input.Pattern),
+		MatchType: strings.TrimSpace(input.MatchType),
+		Action:    strings.TrimSpace(input.Action),
+		Note:      strings.TrimSpace(input.Note),
+		Enabled:   true,
+	}
+	if rule.MatchType == "" {
+		rule.MatchType = model.ContentFilterMatchWord
+	}
+	if rule.MatchType != model.ContentFilterMatchWord && rule.MatchType != model.ContentFilterMatchRegex {
+		return nil, ErrContentFilterRuleInvalid
+	}
+	if rule.Action == "" {
+		rule.Action = model.ContentFilterActionMask
+	}
+	if _, ok := contentFilterActionSeverity[rule.Action]; !ok {
+		return nil, ErrContentFilterRuleInvalid
+	}
+	scopes := make([]string, 0, len(input.Scopes))
+	for _, scope := range input.Scopes {
+		scope = strings.TrimSpace(scope)
+		valid := false
+		for _, item := range contentFilterAllScopes {
+			if item == scope {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, ErrContentFilterRuleInvalid
+		}
+		scopes = append(scopes, scope)
+	}
+	rule.Scopes = strings.Join(scopes, ",")
+	if input.Enabled != nil {
+		rule.Enabled = *input.Enabled
+	}
+	if len([]rune(rule.Note)) > 255 {
+		return nil, ErrContentFilterRuleInvalid
+	}
+	if err := contentfilter.ValidateRule(contentfilter.Rule{Pattern: rule.Pattern, Regex: rule.MatchType == model.ContentFilterMatchRegex}); err != nil {
+		return nil, errors.Join(ErrContentFilterRuleInvalid, err)
+	}
+	return rule, nil
+}
+
+func ContentFilterRuleList(worldID, actorID string) ([]*model.ContentFilterRuleModel, error) {
+	worldID = strings.TrimSpace(worldID)
+	if !canManageContentFilter(worldID, actorID) {
+		return nil, ErrContentFilterPermission
+	}
+	var items []*model.ContentFilterRuleModel
+	err := model.GetDB().Where("world_id = ?", worldID).Order("created_at DESC").Find(&items).Error
+	return items, err
+}
+
+func ContentFilterRuleCreate(worldID, actorID string, input ContentFilterRuleInput) (*model.ContentFilterRuleModel, error) {
+	worldID = strings.TrimSpace(worldID)
+	if !canManageContentFilter(worldID, actorID) {
+		return nil, ErrContentFilterPermission
+	}
+	rule, err := normalizeContentFilterRuleInput(input)
+	if err != nil {
+		return nil, err
+	}
+	rule.WorldID = worldID
+	rule.CreatorID = actorID
+	if err := model.GetDB().Create(rule).Error; err != nil {
+		return nil, err
+	}
+	defaultContentFilterCache.invalidate()
+	return rule, nil
+}
+
+// ContentFilterRuleImport 批量导入词表，每行一个词，已存在的词跳过
+func ContentFilterRuleImport(worldID, actorID string, words []string, template ContentFilterRuleInput) (int, error) {
+	worldID = strings.TrimSpace(worldID)
+	if !canManageContentFilter(worldID, actorID) {
+		return 0, ErrContentFilterPermission
+	}
+	if len(words) > contentFilterImportMax {
+		return 0, ErrContentFilterRuleInvalid
+	}
+	var existing []string
+	if err := model.GetDB().Model(&model.ContentFilterRuleModel{}).
+		Where("world_id = ? AND match_type = ?", worldID, model.ContentFilterMatchWord).
+		Pluck("pattern", &existing).Error; err != nil {
+		return 0, err
+	}
+	seen := make(map[string]struct{}, len(existing))
+	for _, item := range existing {
+		seen[item] = struct{}{}
+	}
+	template.MatchType = model.ContentFilterMatchWord
+	rules := make([]*model.ContentFilterRuleModel, 0, len(words))
+	for _, word := range words {
+		template.Pattern = word
+		rule, err := normalizeContentFilterRuleInput(template)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[rule.Pattern]; ok {
+			continue
+		}
+		seen[rule.Pattern] = struct{}{}
+		rule.WorldID = worldID
+		rule.CreatorID = actorID
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return 0, nil
+	}
+	if err := model.GetDB().CreateInBatches(rules, 200).Error; err != nil {
+		return 0, err
+	}
+	defaultContentFilterCache.invalidate()
+	return len(rules), nil
+}
+
+func loadContentFilterRuleForManage(ruleID, actorID string) (*model.ContentFilterRuleModel, error) {
+	var rule model.ContentFilterRuleModel
+	if err := model.GetDB().Where("id = ?", strings.TrimSpace(ruleID)).Limit(1).Find(&rule).Error; err != nil {
+		return nil, err
+	}
+	if rule.ID == "" {
+		return nil, ErrContentFilterRuleNotFound
+	}
+	if !canManageContentFilter(rule.WorldID, actorID) {
+		return nil, ErrContentFilterPermission
+	}
+	return &rule, nil
+}
+
+func ContentFilterRuleUpdate(ruleID, actorID string, input ContentFilterRuleInput) (*model.ContentFilterRuleModel, error) {
+	rule, err := loadContentFilterRuleForManage(ruleID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeContentFilterRuleInput(input)
+	if err != nil {
+		return nil, err
+	}
+	updates := map[string]any{
+		"pattern":    normalized.Pattern,
+		"match_type": normalized.MatchType,
+		"action":     normalized.Action,
+		"scopes":     normalized.Scopes,
+		"enabled":    normalized.Enabled,
+		"note":       normalized.Note,
+		"updated_at": time.Now(),
+	}
+	if err := model.GetDB().Model(&model.ContentFilterRuleModel{}).Where("id = ?", rule.ID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	defaultContentFilterCache.invalidate()
+	rule.Pattern = normalized.Pattern
+	rule.MatchType = normalized.MatchType
+	rule.Action = normalized.Action
+	rule.Scopes = normalized.Scopes
+	rule.Enabled = normalized.Enabled
+	rule.Note = normalized.Note
+	return rule, nil
+}
+
+func ContentFilterRuleDelete(ruleID, actorID string) error {
+	rule, err := loadContentFilterRuleForManage(ruleID, actorID)
+	if err != nil {
+		return err
+	}
+	if err := model.GetDB().Where("id = ?", rule.ID).Delete(&model.ContentFilterRuleModel{}).Error; err != nil {
+		return err
+	}
+	defaultContentFilterCache.invalidate()
+	return nil
+}
+
+// ContentFilterTest 试运行规则，不计入命中统计；rule 不为空时只测试该规则
+func ContentFilterTest(worldID, actorID, scope, text string, rule *ContentFilterRuleInput) (*ContentFilterResult, error) {
+	worldID = strings.TrimSpace(worldID)
+	if !canManageContentFilter(worldID, actorID) {
+		return nil, ErrContentFilterPermission
+	}
+	if scope == "" {
+		scope = model.ContentFilterScopeMessage
+	}
+	if rule != nil {
+		normalized, err := normalizeContentFilterRuleInput(*rule)
+		if err != nil {
+			return nil, err
+		}
+		matcher, _ := contentfilter.Compile([]contentfilter.Rule{{
+			ID: "test", Pattern: normalized.Pattern,
+			Regex: normalized.MatchType == model.ContentFilterMatchRegex, Action: normalized.Action,
+		}})
+		return runContentFilter([]*contentfilter.Matcher{matcher}, scope, text), nil
+	}
+	matchers := []*contentfilter.Matcher{defaultContentFilterCache.get("", scope)}
+	if worldID != "" {
+		matchers = append(matchers, defaultContentFilterCache.get(worldID, scope))
+	}
+	return runContentFilter(matchers, scope, text), nil
+}
+
+type ContentFilterStats struct {
+	TotalHits    int64                           `json:"totalHits"`
+	RuleCount    int64                           `json:"ruleCount"`
+	PendingCount int64                           `json:"pendingCount"`
+	TopRules     []*model.ContentFilterRuleModel `json:"topRules"`
+}
+
+func ContentFilterStatsGet(worldID, actorID string) (*ContentFilterStats, error) {
+	worldID = strings.TrimSpace(worldID)
+	if !canManageContentFilter(worldID, actorID) {
+		return nil, ErrContentFilterPermission
+	}
+	db := model.GetDB()
+	stats := &ContentFilterStats{}
+	var sum struct{ Total int64 }
+	if err := db.Model(&model.ContentFilterRuleModel{}).Select("COALESCE(SUM(hit_count), 0) AS total").
+		Where("world_id = ?", worldID).Scan(&sum).Error; err != nil {
+		return nil, err
+	}
+	stats.TotalHits = sum.Total
+	db.Model(&model.ContentFilterRuleModel{}).Where("world_id = ?", worldID).Count(&stats.RuleCount)
+	db.Model(&model.ContentFilterPendingModel{}).Where("world_id = ? AND status = ?", worldID, model.ContentFilterPendingStatusPending).Count(&stats.PendingCount)
+	if err := db.Where("world_id = ? AND hit_count > 0", worldID).Order("hit_count DESC").Limit(20).Find(&stats.TopRules).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ContentFilterPendingCreate 暂存需审核的消息，payload 为原始发送参数
+func ContentFilterPendingCreate(worldID, channelID, userID, content string, ruleIDs []string, payload any) (*model.ContentFilterPendingModel, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	item := &model.ContentFilterPendingModel{
+		WorldID:     worldID,
+		ChannelID:   channelID,
+		UserID:      userID,
+		RuleIDs:     strings.Join(ruleIDs, ","),
+		Content:     content,
+		PayloadJSON: string(data),
+		Status:      model.ContentFilterPendingStatusPending,
+	}
+	if err := model.GetDB().Create(item).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func ContentFilterPendingList(worldID, actorID, status string) ([]*model.ContentFilterPendingModel, error) {
+	worldID = strings.TrimSpace(worldID)
+	if !canManageContentFilter(worldID, actorID) {
+		return nil, ErrContentFilterPermission
+	}
+	if status == "" {
+		status = model.ContentFilterPendingStatusPending
+	}
+	var items []*model.ContentFilterPendingModel
+	err := model.GetDB().Where("world_id = ? AND status = ?", worldID, status).Order("created_at ASC").Limit(200).Find(&items).Error
+	return items, err
+}
+
+// ContentFilterPendingReview 审核暂存消息，通过后由调用方按原参数发布并回写 MessageID
+func ContentFilterPendingReview(pendingID, actorID string, approve bool) (*model.ContentFilterPendingModel, error) {
+	var item model.ContentFilterPendingModel
+	if err := model.GetDB().Where("id = ?", strings.TrimSpace(pendingID)).Limit(1).Find(&item).Error; err != nil {
+		return nil, err
+	}
+	if item.ID == "" {
+		return nil, ErrContentFilterPendingNotFound
+	}
+	if !canManageContentFilter(item.WorldID, actorID) {
+		return nil, ErrContentFilterPermission
+	}
+	status := model.ContentFilterPendingStatusRejected
+	if approve {
+		status = model.ContentFilterPendingStatusApproved
+	}
+	now := time.Now()
+	res := model.GetDB().Model(&model.ContentFilterPendingModel{}).
+		Where("id = ? AND status = ?", item.ID, model.ContentFilterPendingStatusPending).
+		Updates(map[string]any{"status": status, "reviewer_id": actorID, "reviewed_at": now, "updated_at": now})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrContentFilterPendingReviewed
+	}
+	item.Status = status
+	item.ReviewerID = actorID
+	item.ReviewedAt = &now
+	return &item, nil
+}
+
+func ContentFilterPendingSetMessage(pendingID, messageID string) error {
+	return model.GetDB().Model(&model.ContentFilterPendingModel{}).Where("id = ?", pendingID).
+		Update("message_id", messageID).Error
+}
+
+// ContentFilterReviewerIDs 返回需要收到待审核提醒的世界管理员
+func ContentFilterReviewerIDs(worldID string) []string {
+	return ReportReviewerIDs(&model.ReportModel{WorldID: worldID})
+}
+
+// ContentFilterFlagMessage 命中“标记”规则的消息自动进入举报队列
+func ContentFilterFlagMessage(messageID string, ruleIDs []string) (*model.ReportModel, error) {
+	var msg model.MessageModel
+	if err := model.GetDB().Preload("User").Where("id = ?", messageID).Limit(1).Find(&msg).Error; err != nil {
+		return nil, err
+	}
+	if msg.ID == "" {
+		return nil, ErrReportTargetInvalid
+	}
+	report := snapshotMessageReport(&msg)
+	report.TargetType = model.ReportTargetMessage
+	report.TargetID = msg.ID
+	report.Category = model.ReportCategoryOther
+	sort.Strings(ruleIDs)
+	report.Reason = "内容过滤自动标记，命中规则：" + strings.Join(ruleIDs, ",")
+	report.Status = model.ReportStatusPending
+	if err := model.GetDB().Create(report).Error; err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"sealchat/model"
+	"sealchat/pm"
+)
+
+func TestContentFilterWorldRules(t *testing.T) {
+	initTestDB(t)
+	pm.Init()
+	ownerID := createWorldOwnershipTestUser(t, "filter-owner", false)
+	memberID := createWorldOwnershipTestUser(t, "filter-member", false)
+	worldID := createWorldOwnershipTestWorld(t, ownerID, memberID)
+
+	if _, err := ContentFilterRuleCreate(worldID, memberID, ContentFilterRuleInput{Pattern: "坏词"}); !errors.Is(err, ErrContentFilterPermission) {
+		t.Fatalf("member should not manage rules, got %v", err)
+	}
+	if _, err := ContentFilterRuleCreate(worldID, ownerID, ContentFilterRuleInput{Pattern: "(", MatchType: model.ContentFilterMatchRegex}); !errors.Is(err, ErrContentFilterRuleInvalid) {
+		t.Fatalf("expected invalid regex error, got %v", err)
+	}
+	count, err := ContentFilterRuleImport(worldID, ownerID, []string{"坏词", "坏词", " "}, ContentFilterRuleInput{Action: model.ContentFilterActionMask})
+	if err != nil || count != 1 {
+		t.Fatalf("import failed: count=%d err=%v", count, err)
+	}
+	blockRule, err := ContentFilterRuleCreate(worldID, ownerID, ContentFilterRuleInput{
+		Pattern: `加群\d{5,}`, MatchType: model.ContentFilterMatchRegex, Action: model.ContentFilterActionBlock,
+		Scopes: []string{model.ContentFilterScopeMessage},
+	})
+	if err != nil {
+		t.Fatalf("create block rule failed: %v", err)
+	}
+
+	result := ContentFilterCheck(worldID, model.ContentFilterScopeMessage, "这是坏 词")
+	if result.Blocked() || result.Text != "这是* *" {
+		t.Fatalf("unexpected mask result: %+v", result)
+	}
+	if !ContentFilterCheck(worldID, model.ContentFilterScopeMessage, "加群123456").Blocked() {
+		t.Fatalf("regex rule should block message")
+	}
+	if ContentFilterCheck(worldID, model.ContentFilterScopeNickname, "加群123456").Blocked() {
+		t.Fatalf("message-only rule should not apply to nickname")
+	}
+	if ContentFilterCheck("", model.ContentFilterScopeMessage, "坏词").Text != "坏词" {
+		t.Fatalf("world rule should not apply outside the world")
+	}
+
+	disabled := false
+	if _, err := ContentFilterRuleUpdate(blockRule.ID, ownerID, ContentFilterRuleInput{
+		Pattern: blockRule.Pattern, MatchType: blockRule.MatchType, Action: blockRule.Action, Enabled: &disabled,
+	}); err != nil {
+		t.Fatalf("update rule failed: %v", err)
+	}
+	if ContentFilterCheck(worldID, model.ContentFilterScopeMessage, "加群123456").Blocked() {
+		t.Fatalf("disabled rule should not block")
+	}
+	if err := ContentFilterRuleDelete(blockRule.ID, memberID); !errors.Is(err, ErrContentFilterPermission) {
+		t.Fatalf("member should not delete rule, got %v", err)
+	}
+}
+
+func TestContentFilterApproveAndFlag(t *testing.T) {
+	initTestDB(t)
+	pm.Init()
+	ownerID := createWorldOwnershipTestUser(t, "filter-approve-owner", false)
+	memberID := createWorldOwnershipTestUser(t, "filter-approve-member", false)
+	worldID := createWorldOwnershipTestWorld(t, ownerID, memberID)
+	channelID := createReportTestChannel(t, worldID, ownerID, memberID)
+
+	if _, err := ContentFilterRuleCreate(worldID, ownerID, ContentFilterRuleInput{Pattern: "链接", Action: model.ContentFilterActionApprove}); err != nil {
+		t.Fatalf("create approve rule failed: %v", err)
+	}
+	if _, err := ContentFilterRuleCreate(worldID, ownerID, ContentFilterRuleInput{Pattern: "可疑", Action: model.ContentFilterActionFlag}); err != nil {
+		t.Fatalf("create flag rule failed: %v", err)
+	}
+
+	if _, err := ContentFilterApplyText(worldID, model.ContentFilterScopeIdentity, "链接小号"); !errors.Is(err, ErrContentFilterBlocked) {
+		t.Fatalf("approve rule should block plain text fields, got %v", err)
+	}
+
+	result := ContentFilterCheck(worldID, model.ContentFilterScopeMessage, "发个链接")
+	if !result.NeedsApproval() {
+		t.Fatalf("expected approval required, got %+v", result)
+	}
+	pending, err := ContentFilterPendingCreate(worldID, channelID, memberID, "发个链接", result.RuleIDs, map[string]string{"content": "发个链接"})
+	if err != nil {
+		t.Fatalf("create pending failed: %v", err)
+	}
+	if _, err := ContentFilterPendingReview(pending.ID, memberID, true); !errors.Is(err, ErrContentFilterPermission) {
+		t.Fatalf("member should not review, got %v", err)
+	}
+	reviewed, err := ContentFilterPendingReview(pending.ID, ownerID, false)
+	if err != nil || reviewed.Status != model.ContentFilterPendingStatusRejected {
+		t.Fatalf("review failed: %+v %v", reviewed, err)
+	}
+	if _, err := ContentFilterPendingReview(pending.ID, ownerID, true); !errors.Is(err, ErrContentFilterPendingReviewed) {
+		t.Fatalf("expected already reviewed error, got %v", err)
+	}
+
+	flagged := ContentFilterCheck(worldID, model.ContentFilterScopeMessage, "有点可疑")
+	if !flagged.Flagged() || flagged.Blocked() {
+		t.Fatalf("expected flag only, got %+v", flagged)
+	}
+	messageID := createReportTestMessage(t, channelID, memberID, "有点可疑")
+	report, err := ContentFilterFlagMessage(messageID, flagged.FlagRuleIDs)
+	if err != nil {
+		t.Fatalf("flag message failed: %v", err)
+	}
+	if report.ReporterID != "" || report.WorldID != worldID || report.Status != model.ReportStatusPending {
+		t.Fatalf("unexpected flag report: %+v", report)
+	}
+}
+
+func TestContentFilterAppliesToWorldCreate(t *testing.T) {
+	initTestDB(t)
+	pm.Init()
+	adminID := createWorldOwnershipTestUser(t, "filter-admin", false)
+	if _, err := model.UserRoleLink([]string{"sys-admin"}, []string{adminID}); err != nil {
+		t.Fatalf("grant sys-admin failed: %v", err)
+	}
+	if _, err := ContentFilterRuleCreate("", adminID, ContentFilterRuleInput{Pattern: "坏词", Action: model.ContentFilterActionMask}); err != nil {
+		t.Fatalf("create mask rule failed: %v", err)
+	}
+	if _, err := ContentFilterRuleCreate("", adminID, ContentFilterRuleInput{Pattern: "禁词", Action: model.ContentFilterActionBlock}); err != nil {
+		t.Fatalf("create block rule failed: %v", err)
+	}
+
+	if _, _, err := WorldCreate(adminID, WorldCreateParams{Name: "禁词世界"}); !errors.Is(err, ErrContentFilterBlocked) {
+		t.Fatalf("blocked world name should be rejected, got %v", err)
+	}
+	world, _, err := WorldCreate(adminID, WorldCreateParams{Name: "新世界", Description: "含坏词的简介"})
+	if err != nil {
+		t.Fatalf("create world failed: %v", err)
+	}
+	if world.Description != "含**的简介" {
+		t.Fatalf("description should be masked, got %q", world.Description)
+	}
+}
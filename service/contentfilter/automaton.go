@@ -0,0 +1,74 @@
+package contentfilter
+
+// automaton Aho–Corasick 自动机，按 rune 建立转移
+type automaton struct {
+	next   []map[rune]int
+	fail   []int
+	output [][]int // 每个状态结束的模式下标（含经失败链继承的）
+}
+
+func buildAutomaton(patterns [][]rune) *automaton {
+	a := &automaton{
+		next:   []map[rune]int{{}},
+		fail:   []int{0},
+		output: [][]int{nil},
+	}
+	for idx, pattern := range patterns {
+		state := 0
+		for _, r := range pattern {
+			nextState, ok := a.next[state][r]
+			if !ok {
+				nextState = len(a.next)
+				a.next = append(a.next, map[rune]int{})
+				a.fail = append(a.fail, 0)
+				a.output = append(a.output, nil)
+				a.next[state][r] = nextState
+			}
+			state = nextState
+		}
+		a.output[state] = append(a.output[state], idx)
+	}
+
+	queue := make([]int, 0, len(a.next))
+	for _, child := range a.next[0] {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for r, child := range a.next[state] {
+			queue = append(queue, child)
+			f := a.fail[state]
+			for f != 0 {
+				if _, ok := a.next[f][r]; ok {
+					break
+				}
+				f = a.fail[f]
+			}
+			if target, ok := a.next[f][r]; ok && target != child {
+				a.fail[child] = target
+			}
+			a.output[child] = append(a.output[child], a.output[a.fail[child]]...)
+		}
+	}
+	return a
+}
+
+// search 扫描文本，每次命中回调模式下标与结束位置（含）
+func (a *automaton) search(text []rune, emit func(patternIdx, end int)) {
+	state := 0
+	for i, r := range text {
+		for state != 0 {
+			if _, ok := a.next[state][r]; ok {
+				break
+			}
+			state = a.fail[state]
+		}
+		if target, ok := a.next[state][r]; ok {
+			state = target
+		}
+		for _, idx := range a.output[state] {
+			emit(idx, i)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Package contentfilter 提供敏感词匹配引擎：词表使用 Aho–Corasick 自动机一次扫描完成匹配，
+// 正则规则单独执行。匹配前会折叠全角字符、统一大小写并跳过空白/标点/零宽字符，
+// 以应对“敏 感 词”“敏.感.词”这类插入分隔符的规避写法。
+package contentfilter
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxPatternLength = 256
+	MaskRune         = '*'
+)
+
+var (
+	ErrPatternEmpty   = errors.New("规则内容不能为空")
+	ErrPatternTooLong = errors.New("规则内容过长")
+)
+
+// Rule 待编译的规则，Regex 为 true 时 Pattern 按 RE2 正则解析
+type Rule struct {
+	ID      string
+	Pattern string
+	Regex   bool
+	Action  string
+}
+
+// Match 命中结果，Start/End 为原文中的 rune 下标，区间左闭右开
+type Match struct {
+	RuleID string
+	Action string
+	Start  int
+	End    int
+}
+
+type regexRule struct {
+	rule Rule
+	re   *regexp.Regexp
+}
+
+type wordPattern struct {
+	rule     Rule
+	length   int  // 规范化后的 rune 数
+	wordLike bool // 纯 ASCII 字母数字，需要单词边界
+}
+
+// Matcher 编译后的规则集合，可并发只读使用
+type Matcher struct {
+	ac      *automaton
+	words   []wordPattern
+	regexes []regexRule
+}
+
+// isNoise 判断规范化时需要跳过的字符
+func isNoise(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+		return true
+	}
+	return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.Is(unicode.Mn, r)
+}
+
+// foldRune 全角转半角并转小写
+func foldRune(r rune) rune {
+	switch {
+	case r == '\u3000':
+		r = ' '
+	case r >= '\uff01' && r <= '\uff5e':
+		r -= 0xfee0
+	}
+	return unicode.ToLower(r)
+}
+
+// normalize 返回规范化后的 rune 序列，以及每个规范化字符在原文中的 rune 下标
+func normalize(runes []rune) ([]rune, []int) {
+	out := make([]rune, 0, len(runes))
+	positions := make([]int, 0, len(runes))
+	for i, r := range runes {
+		r = foldRune(r)
+		if isNoise(r) {
+			continue
+		}
+		out = append(out, r)
+		positions = append(positions, i)
+	}
+	return out, positions
+}
+
+func isWordLike(runes []rune) bool {
+	for _, r := range runes {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return len(runes) > 0
+}
+
+func isWordRune(r rune) bool {
+	r = foldRune(r)
+	return r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// ValidateRule 校验单条规则是否可编译
+func ValidateRule(rule Rule) error {
+	pattern := strings.TrimSpace(rule.Pattern)
+	if pattern == "" {
+		return ErrPatternEmpty
+	}
+	if utf8.RuneCountInString(pattern) > MaxPatternLength {
+		return ErrPatternTooLong
+	}
+	if rule.Regex {
+		_, err := regexp.Compile(pattern)
+		return err
+	}
+	if normalized, _ := normalize([]rune(pattern)); len(normalized) == 0 {
+		return ErrPatternEmpty
+	}
+	return nil
+}
+
+// Compile 编译规则集，无效规则会被跳过并在返回的 errs 中按规则 ID 报告
+func Compile(rules []Rule) (*Matcher, map[string]error) {
+	m := &Matcher{}
+	errs := map[string]error{}
+	var patterns [][]rune
+	for _, rule := range rules {
+		if err := ValidateRule(rule); err != nil {
+			errs[rule.ID] = err
+			continue
+		}
+		pattern := strings.TrimSpace(rule.Pattern)
+		if rule.Regex {
+			m.regexes = append(m.regexes, regexRule{rule: rule, re: regexp.MustCompile(pattern)})
+			continue
+		}
+		normalized, _ := normalize([]rune(pattern))
+		patterns = append(patterns, normalized)
+		m.words = append(m.words, wordPattern{rule: rule, length: len(normalized), wordLike: isWordLike(normalized)})
+	}
+	if len(patterns) > 0 {
+		m.ac = buildAutomaton(patterns)
+	}
+	return m, errs
+}
+
+// Empty 规则集为空时无需扫描
+func (m *Matcher) Empty() bool {
+	return m == nil || (m.ac == nil && len(m.regexes) == 0)
+}
+
+// Find 返回全部命中，按起始位置排序
+func (m *Matcher) Find(text string) []Match {
+	if m.Empty() || text == "" {
+		return nil
+	}
+	runes := []rune(text)
+	var matches []Match
+	if m.ac != nil {
+		normalized, positions := normalize(runes)
+		m.ac.search(normalized, func(patternIdx, end int) {
+			word := m.words[patternIdx]
+			startOrig := positions[end-word.length+1]
+			endOrig := positions[end] + 1
+			if word.wordLike {
+				if startOrig > 0 && isWordRune(runes[startOrig-1]) {
+					return
+				}
+				if endOrig < len(runes) && isWordRune(runes[endOrig]) {
+					return
+				}
+			}
+			matches = append(matches, Match{RuleID: word.rule.ID, Action: word.rule.Action, Start: startOrig, End: endOrig})
+		})
+	}
+	if len(m.regexes) > 0 {
+		byteToRune := buildByteToRuneIndex(text)
+		for _, item := range m.regexes {
+			for _, loc := range item.re.FindAllStringIndex(text, -1) {
+				if loc[0] == loc[1] {
+					continue
+				}
+				matches = append(matches, Match{RuleID: item.rule.ID, Action: item.rule.Action, Start: byteToRune[loc[0]], End: byteToRune[loc[1]]})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Start != matches[j].Start {
+			return matches[i].Start < matches[j].Start
+		}
+		return matches[i].End > matches[j].End
+	})
+	return matches
+}
+
+func buildByteToRuneIndex(text string) []int {
+	index := make([]int, len(text)+1)
+	runeIdx := 0
+	for bytePos := range text {
+		index[bytePos] = runeIdx
+		runeIdx++
+	}
+	// 多字节字符中间的下标不会被正则返回，只需补齐末尾
+	index[len(text)] = runeIdx
+	return index
+}
+
+// Mask 将命中区间内的非空白字符替换为 MaskRune
+func Mask(text string, matches []Match) string {
+	if len(matches) == 0 {
+		return text
+	}
+	runes := []rune(text)
+	for _, match := range matches {
+		for i := match.Start; i < match.End && i < len(runes); i++ {
+			if !unicode.IsSpace(runes[i]) {
+				runes[i] = MaskRune
+			}
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,73 @@
+package contentfilter
+
+import "testing"
+
+func TestMatcherFindsCJKWithSeparators(t *testing.T) {
+	m, errs := Compile([]Rule{
+		{ID: "a", Pattern: "敏感词", Action: "mask"},
+		{ID: "b", Pattern: "感词汇", Action: "flag"},
+	})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected compile errors: %v", errs)
+	}
+	matches := m.Find("这是敏 感.词汇吗")
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %+v", matches)
+	}
+	if matches[0].RuleID != "a" || matches[0].Start != 2 || matches[0].End != 7 {
+		t.Fatalf("unexpected first match: %+v", matches[0])
+	}
+	if got := Mask("这是敏 感.词汇吗", matches[:1]); got != "这是* ***汇吗" {
+		t.Fatalf("unexpected mask result: %s", got)
+	}
+}
+
+func TestMatcherWordBoundaryAndWidthFolding(t *testing.T) {
+	m, _ := Compile([]Rule{{ID: "w", Pattern: "ass", Action: "mask"}})
+	if matches := m.Find("first class"); len(matches) != 0 {
+		t.Fatalf("ascii word should respect boundaries: %+v", matches)
+	}
+	if matches := m.Find("what an ＡＳＳ!"); len(matches) != 1 {
+		t.Fatalf("fullwidth word should match: %+v", matches)
+	}
+}
+
+func TestMatcherRegexAndInvalidRule(t *testing.T) {
+	m, errs := Compile([]Rule{
+		{ID: "r", Pattern: `\d{11}`, Regex: true, Action: "block"},
+		{ID: "bad", Pattern: `(`, Regex: true, Action: "block"},
+		{ID: "empty", Pattern: " ,. ", Action: "block"},
+	})
+	if len(errs) != 2 || errs["bad"] == nil || errs["empty"] == nil {
+		t.Fatalf("expected invalid rules to be reported: %v", errs)
+	}
+	matches := m.Find("电话13800001111")
+	if len(matches) != 1 || matches[0].Start != 2 || matches[0].End != 13 {
+		t.Fatalf("unexpected regex match: %+v", matches)
+	}
+}
+
+func TestAutomatonOverlappingPatterns(t *testing.T) {
+	m, _ := Compile([]Rule{
+		{ID: "he", Pattern: "he"},
+		{ID: "she", Pattern: "she"},
+		{ID: "hers", Pattern: "hers"},
+		{ID: "中文", Pattern: "中文"},
+	})
+	found := map[string]int{}
+	for _, match := range m.Find("ushers中文") {
+		found[match.RuleID]++
+	}
+	// he/she/hers 均为纯字母，需满足单词边界，只有 中文 命中
+	if len(found) != 1 || found["中文"] != 1 {
+		t.Fatalf("unexpected matches: %v", found)
+	}
+	m, _ = Compile([]Rule{{ID: "他", Pattern: "他"}, {ID: "他们", Pattern: "他们"}, {ID: "们好", Pattern: "们好"}})
+	found = map[string]int{}
+	for _, match := range m.Find("他们好") {
+		found[match.RuleID]++
+	}
+	if found["他"] != 1 || found["他们"] != 1 || found["们好"] != 1 {
+		t.Fatalf("overlapping CJK patterns should all match: %v", found)
+	}
+}
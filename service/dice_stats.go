@@ -0,0 +1,459 @@
+package service
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sealchat/model"
+)
+
+var (
+	// [2d6=3+4]、[d100=56]：骰子明细
+	diceStatsDetailPattern = regexp.MustCompile(`\[(\d*)d(\d+)=(\d+(?:\+\d+)*)\]`)
+	// 3[1d4]：单颗骰子结果写在方括号前
+	diceStatsSingleDetailPattern = regexp.MustCompile(`(\d+)\[1?d(\d+)\]`)
+	// d100<=50 / 1d100 <= 50：CoC 风格检定
+	diceStatsCoCFormulaPattern = regexp.MustCompile(`^\s*1?d100\s*<=\s*(\d+)\s*$`)
+	diceStatsCoCDetailPattern  = regexp.MustCompile(`^\s*(\d+)\s*<=`)
+)
+
+const (
+	// 面数超过该值的骰子只统计均值，不生成分布直方图
+	diceStatsMaxHistogramSides = 1000
+	// 卡方检验要求每个面期望频数不少于该值
+	diceStatsChiSquareMinExpected = 5.0
+)
+
+// CoC 检定成功等级
+const (
+	DiceCoCLevelFumble   = "fumble"
+	DiceCoCLevelFailure  = "failure"
+	DiceCoCLevelRegular  = "regular"
+	DiceCoCLevelHard     = "hard"
+	DiceCoCLevelExtreme  = "extreme"
+	DiceCoCLevelCritical = "critical"
+)
+
+type DiceChiSquareResult struct {
+	Statistic        float64 `json:"statistic"`
+	DegreesOfFreedom int     `json:"degreesOfFreedom"`
+	PValue           float64 `json:"pValue"`
+	Sufficient       bool    `json:"sufficient"` // 样本量是否足以得出结论
+}
+
+type DiceFaceStats struct {
+	Sides        int                  `json:"sides"`
+	Count        int64                `json:"count"`
+	Sum          int64                `json:"sum"`
+	Mean         float64              `json:"mean"`
+	ExpectedMean float64              `json:"expectedMean"`
+	Histogram    []int64              `json:"histogram,omitempty"` // 下标 i 对应点数 i+1
+	ChiSquare    *DiceChiSquareResult `json:"chiSquare,omitempty"`
+}
+
+type DiceCoCStats struct {
+	Checks               int64   `json:"checks"`
+	Critical             int64   `json:"critical"`
+	Extreme              int64   `json:"extreme"`
+	Hard                 int64   `json:"hard"`
+	Regular              int64   `json:"regular"`
+	Failure              int64   `json:"failure"`
+	Fumble               int64   `json:"fumble"`
+	SuccessRate          float64 `json:"successRate"`
+	LongestSuccessStreak int     `json:"longestSuccessStreak"`
+	LongestFailureStreak int     `json:"longestFailureStreak"`
+	CurrentStreak        int     `json:"currentStreak"` // 正数为连续成功，负数为连续失败
+}
+
+// DiceCriticalStats 裸骰的大成功/大失败计数：d20 出 20/1，d100 出 1/100
+type DiceCriticalStats struct {
+	D20Critical  int64 `json:"d20Critical"`
+	D20Fumble    int64 `json:"d20Fumble"`
+	D100Critical int64 `json:"d100Critical"`
+	D100Fumble   int64 `json:"d100Fumble"`
+}
+
+type DiceStatsResult struct {
+	TotalRolls  int64             `json:"totalRolls"` // 掷骰表达式次数
+	TotalDice   int64             `json:"totalDice"`  // 可识别的单颗骰子数
+	Dice        []*DiceFaceStats  `json:"dice"`
+	CoC         DiceCoCStats      `json:"coc"`
+	Criticals   DiceCriticalStats `json:"criticals"`
+	FirstRollAt int64             `json:"firstRollAt,omitempty"`
+	LastRollAt  int64             `json:"lastRollAt,omitempty"`
+}
+
+type DiceStatsGroup struct {
+	ID    string           `json:"id"`
+	Name  string           `json:"name"`
+	Stats *DiceStatsResult `json:"stats"`
+}
+
+type DiceStatsReport struct {
+	Overall    *DiceStatsResult  `json:"overall"`
+	ByUser     []*DiceStatsGroup `json:"byUser,omitempty"`
+	ByIdentity []*DiceStatsGroup `json:"byIdentity,omitempty"`
+	Truncated  bool              `json:"truncated"`
+}
+
+type DiceStatsOptions struct {
+	GroupByUser     bool
+	GroupByIdentity bool
+}
+
+// ComputeDiceStats 读取掷骰记录并汇总
+func ComputeDiceStats(f model.DiceStatsFilter, opts DiceStatsOptions) (*DiceStatsReport, error) {
+	rows, truncated, err := model.DiceStatsRows(f)
+	if err != nil {
+		return nil, err
+	}
+	report := &DiceStatsReport{
+		Overall:   aggregateDiceStats(rows),
+		Truncated: truncated,
+	}
+	if opts.GroupByUser {
+		report.ByUser = groupDiceStats(rows, func(row *model.DiceStatsRow) (string, string) {
+			return row.UserID, row.UserNickname
+		})
+	}
+	if opts.GroupByIdentity {
+		report.ByIdentity = groupDiceStats(rows, func(row *model.DiceStatsRow) (string, string) {
+			return row.IdentityID, row.IdentityName
+		})
+	}
+	return report, nil
+}
+
+func groupDiceStats(rows []model.DiceStatsRow, key func(row *model.DiceStatsRow) (string, string)) []*DiceStatsGroup {
+	grouped := map[string][]model.DiceStatsRow{}
+	names := map[string]string{}
+	order := []string{}
+	for i := range rows {
+		id, name := key(&rows[i])
+		if id == "" {
+			continue
+		}
+		if _, ok := grouped[id]; !ok {
+			order = append(order, id)
+		}
+		grouped[id] = append(grouped[id], rows[i])
+		if name != "" {
+			names[id] = name
+		}
+	}
+	groups := make([]*DiceStatsGroup, 0, len(order))
+	for _, id := range order {
+		groups = append(groups, &DiceStatsGroup{
+			ID:    id,
+			Name:  names[id],
+			Stats: aggregateDiceStats(grouped[id]),
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Stats.TotalRolls > groups[j].Stats.TotalRolls
+	})
+	return groups
+}
+
+// aggregateDiceStats 汇总按时间排序的掷骰记录
+func aggregateDiceStats(rows []model.DiceStatsRow) *DiceStatsResult {
+	result := &DiceStatsResult{Dice: []*DiceFaceStats{}}
+	faces := map[int]*DiceFaceStats{}
+	streak := 0
+
+	for i := range rows {
+		row := &rows[i]
+		result.TotalRolls++
+		if !row.CreatedAt.IsZero() {
+			ts := row.CreatedAt.UnixMilli()
+			if result.FirstRollAt == 0 || ts < result.FirstRollAt {
+				result.FirstRollAt = ts
+			}
+			if ts > result.LastRollAt {
+				result.LastRollAt = ts
+			}
+		}
+
+		level, cocRoll, isCoC := parseDiceStatsCoCCheck(row.Formula, row.ResultDetail)
+		groups := parseDiceStatsFaces(row.ResultDetail)
+		if isCoC && len(groups) == 0 {
+			// 检定明细形如 93<=50，不含方括号，单独补记这颗 d100
+			groups = []diceStatsFaceGroup{{Sides: 100, Values: []int{cocRoll}}}
+		}
+		for _, group := range groups {
+			stats := faces[group.Sides]
+			if stats == nil {
+				stats = &DiceFaceStats{Sides: group.Sides, ExpectedMean: float64(group.Sides+1) / 2}
+				if group.Sides <= diceStatsMaxHistogramSides {
+					stats.Histogram = make([]int64, group.Sides)
+				}
+				faces[group.Sides] = stats
+			}
+			for _, value := range group.Values {
+				stats.Count++
+				stats.Sum += int64(value)
+				result.TotalDice++
+				if stats.Histogram != nil {
+					stats.Histogram[value-1]++
+				}
+			}
+			// 裸骰（单颗）才计入大成功/大失败
+			if len(groups) == 1 && len(group.Values) == 1 {
+				switch group.Sides {
+				case 20:
+					if group.Values[0] == 20 {
+						result.Criticals.D20Critical++
+					} else if group.Values[0] == 1 {
+						result.Criticals.D20Fumble++
+					}
+				case 100:
+					if group.Values[0] == 1 {
+						result.Criticals.D100Critical++
+					} else if group.Values[0] == 100 {
+						result.Criticals.D100Fumble++
+					}
+				}
+			}
+		}
+
+		if isCoC {
+			coc := &result.CoC
+			coc.Checks++
+			switch level {
+			case DiceCoCLevelCritical:
+				coc.Critical++
+			case DiceCoCLevelExtreme:
+				coc.Extreme++
+			case DiceCoCLevelHard:
+				coc.Hard++
+			case DiceCoCLevelRegular:
+				coc.Regular++
+			case DiceCoCLevelFailure:
+				coc.Failure++
+			case DiceCoCLevelFumble:
+				coc.Fumble++
+			}
+			if diceCoCLevelSucceeded(level) {
+				if streak < 0 {
+					streak = 0
+				}
+				streak++
+				if streak > coc.LongestSuccessStreak {
+					coc.LongestSuccessStreak = streak
+				}
+			} else {
+				if streak > 0 {
+					streak = 0
+				}
+				streak--
+				if -streak > coc.LongestFailureStreak {
+					coc.LongestFailureStreak = -streak
+				}
+			}
+		}
+	}
+
+	result.CoC.CurrentStreak = streak
+	if result.CoC.Checks > 0 {
+		succeeded := result.CoC.Critical + result.CoC.Extreme + result.CoC.Hard + result.CoC.Regular
+		result.CoC.SuccessRate = roundDiceStat(float64(succeeded) / float64(result.CoC.Checks))
+	}
+
+	for _, stats := range faces {
+		if stats.Count > 0 {
+			stats.Mean = roundDiceStat(float64(stats.Sum) / float64(stats.Count))
+		}
+		if stats.Histogram != nil && stats.Sides > 1 {
+			stats.ChiSquare = diceChiSquare(stats.Histogram)
+		}
+		result.Dice = append(result.Dice, stats)
+	}
+	sort.Slice(result.Dice, func(i, j int) bool {
+		return result.Dice[i].Sides < result.Dice[j].Sides
+	})
+	return result
+}
+
+type diceStatsFaceGroup struct {
+	Sides  int
+	Values []int
+}
+
+// parseDiceStatsFaces 从掷骰明细中提取每颗骰子的点数，无法可靠识别的部分直接忽略
+func parseDiceStatsFaces(detail string) []diceStatsFaceGroup {
+	detail = strings.TrimSpace(detail)
+	if detail == "" {
+		return nil
+	}
+	var groups []diceStatsFaceGroup
+	for _, match := range diceStatsDetailPattern.FindAllStringSubmatch(detail, -1) {
+		count := 1
+		if match[1] != "" {
+			parsed, err := strconv.Atoi(match[1])
+			if err != nil {
+				continue
+			}
+			count = parsed
+		}
+		sides, err := strconv.Atoi(match[2])
+		if err != nil || sides <= 0 {
+			continue
+		}
+		parts := strings.Split(match[3], "+")
+		if len(parts) != count {
+			continue
+		}
+		values := make([]int, 0, len(parts))
+		valid := true
+		for _, part := range parts {
+			value, err := strconv.Atoi(part)
+			if err != nil || value < 1 || value > sides {
+				valid = false
+				break
+			}
+			values = append(values, value)
+		}
+		if valid {
+			groups = append(groups, diceStatsFaceGroup{Sides: sides, Values: values})
+		}
+	}
+	for _, match := range diceStatsSingleDetailPattern.FindAllStringSubmatch(detail, -1) {
+		value, err1 := strconv.Atoi(match[1])
+		sides, err2 := strconv.Atoi(match[2])
+		if err1 != nil || err2 != nil || sides <= 0 || value < 1 || value > sides {
+			continue
+		}
+		groups = append(groups, diceStatsFaceGroup{Sides: sides, Values: []int{value}})
+	}
+	return groups
+}
+
+// parseDiceStatsCoCCheck 识别 d100<=技能值 形式的检定，按 CoC7 规则判定成功等级
+func parseDiceStatsCoCCheck(formula, detail string) (level string, roll int, ok bool) {
+	formulaMatch := diceStatsCoCFormulaPattern.FindStringSubmatch(strings.ToLower(formula))
+	if formulaMatch == nil {
+		return "", 0, false
+	}
+	skill, err := strconv.Atoi(formulaMatch[1])
+	if err != nil {
+		return "", 0, false
+	}
+	detailMatch := diceStatsCoCDetailPattern.FindStringSubmatch(detail)
+	if detailMatch == nil {
+		return "", 0, false
+	}
+	roll, err = strconv.Atoi(detailMatch[1])
+	if err != nil || roll < 1 || roll > 100 {
+		return "", 0, false
+	}
+	return diceCoCSuccessLevel(roll, skill), roll, true
+}
+
+func diceCoCSuccessLevel(roll, skill int) string {
+	if roll == 1 {
+		return DiceCoCLevelCritical
+	}
+	if roll == 100 || (skill < 50 && roll >= 96) {
+		return DiceCoCLevelFumble
+	}
+	switch {
+	case roll <= skill/5:
+		return DiceCoCLevelExtreme
+	case roll <= skill/2:
+		return DiceCoCLevelHard
+	case roll <= skill:
+		return DiceCoCLevelRegular
+	}
+	return DiceCoCLevelFailure
+}
+
+func diceCoCLevelSucceeded(level string) bool {
+	switch level {
+	case DiceCoCLevelCritical, DiceCoCLevelExtreme, DiceCoCLevelHard, DiceCoCLevelRegular:
+		return true
+	}
+	return false
+}
+
+// diceChiSquare 以均匀分布为原假设做拟合优度检验
+func diceChiSquare(histogram []int64) *DiceChiSquareResult {
+	var total int64
+	for _, count := range histogram {
+		total += count
+	}
+	sides := len(histogram)
+	if total == 0 || sides < 2 {
+		return nil
+	}
+	expected := float64(total) / float64(sides)
+	statistic := 0.0
+	for _, count := range histogram {
+		diff := float64(count) - expected
+		statistic += diff * diff / expected
+	}
+	df := sides - 1
+	return &DiceChiSquareResult{
+		Statistic:        roundDiceStat(statistic),
+		DegreesOfFreedom: df,
+		PValue:           roundDiceStat(regularizedGammaQ(float64(df)/2, statistic/2)),
+		Sufficient:       expected >= diceStatsChiSquareMinExpected,
+	}
+}
+
+// regularizedGammaQ 上不完全伽马函数的正则化形式 Q(a, x)，用于卡方分布的右尾概率
+func regularizedGammaQ(a, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	lgammaA, _ := math.Lgamma(a)
+	if x < a+1 {
+		// 级数展开求 P，再取补
+		sum := 1 / a
+		term := sum
+		for n := 1; n < 1000; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-14 {
+				break
+			}
+		}
+		p := sum * math.Exp(-x+a*math.Log(x)-lgammaA)
+		return math.Max(0, math.Min(1, 1-p))
+	}
+	// 连分式（Lentz 算法）
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 1000; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-14 {
+			break
+		}
+	}
+	q := math.Exp(-x+a*math.Log(x)-lgammaA) * h
+	return math.Max(0, math.Min(1, q))
+}
+
+func roundDiceStat(value float64) float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	return math.Round(value*10000) / 10000
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"sealchat/model"
+)
+
+func TestParseDiceStatsFaces(t *testing.T) {
+	groups := parseDiceStatsFaces("3[2d6=2+1]+3[1d4]")
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	if groups[0].Sides != 6 || len(groups[0].Values) != 2 || groups[0].Values[1] != 1 {
+		t.Fatalf("unexpected 2d6 group: %+v", groups[0])
+	}
+	if groups[1].Sides != 4 || groups[1].Values[0] != 3 {
+		t.Fatalf("unexpected 1d4 group: %+v", groups[1])
+	}
+	if groups := parseDiceStatsFaces("[d100=56]"); len(groups) != 1 || groups[0].Values[0] != 56 {
+		t.Fatalf("unexpected d100 group: %+v", groups)
+	}
+	// 取高、奖励骰等无法对应单颗点数的明细应被忽略
+	if groups := parseDiceStatsFaces("12[4d6k3={6 3 3 | 2}]"); len(groups) != 0 {
+		t.Fatalf("expected keep-highest detail ignored, got %+v", groups)
+	}
+	if groups := parseDiceStatsFaces("[2d6=7+1]"); len(groups) != 0 {
+		t.Fatalf("expected out-of-range face ignored, got %+v", groups)
+	}
+}
+
+func TestDiceCoCSuccessLevel(t *testing.T) {
+	cases := []struct {
+		roll, skill int
+		want        string
+	}{
+		{1, 40, DiceCoCLevelCritical},
+		{8, 40, DiceCoCLevelExtreme},
+		{20, 40, DiceCoCLevelHard},
+		{40, 40, DiceCoCLevelRegular},
+		{41, 40, DiceCoCLevelFailure},
+		{97, 40, DiceCoCLevelFumble},
+		{97, 60, DiceCoCLevelFailure},
+		{100, 90, DiceCoCLevelFumble},
+	}
+	for _, tc := range cases {
+		if got := diceCoCSuccessLevel(tc.roll, tc.skill); got != tc.want {
+			t.Fatalf("roll=%d skill=%d expected %s, got %s", tc.roll, tc.skill, tc.want, got)
+		}
+	}
+}
+
+func TestAggregateDiceStatsStreaks(t *testing.T) {
+	rows := []model.DiceStatsRow{
+		{Formula: "d100<=50", ResultDetail: "20<=50"},
+		{Formula: "d100<=50", ResultDetail: "10<=50"},
+		{Formula: "1d100 <= 50", ResultDetail: "1<=50"},
+		{Formula: "d100<=50", ResultDetail: "70<=50"},
+		{Formula: "d100<=50", ResultDetail: "100<=50"},
+		{Formula: "d20", ResultDetail: "[d20=20]"},
+		{Formula: "d20", ResultDetail: "[d20=1]"},
+		{Formula: "2d20", ResultDetail: "[2d20=20+1]"},
+	}
+	stats := aggregateDiceStats(rows)
+	if stats.TotalRolls != 8 || stats.TotalDice != 9 {
+		t.Fatalf("unexpected totals: rolls=%d dice=%d", stats.TotalRolls, stats.TotalDice)
+	}
+	coc := stats.CoC
+	if coc.Checks != 5 || coc.Critical != 1 || coc.Hard != 1 || coc.Extreme != 1 || coc.Failure != 1 || coc.Fumble != 1 {
+		t.Fatalf("unexpected coc stats: %+v", coc)
+	}
+	if coc.SuccessRate != 0.6 || coc.LongestSuccessStreak != 3 || coc.LongestFailureStreak != 2 || coc.CurrentStreak != -2 {
+		t.Fatalf("unexpected streaks: %+v", coc)
+	}
+	if stats.Criticals.D20Critical != 1 || stats.Criticals.D20Fumble != 1 || stats.Criticals.D100Critical != 1 || stats.Criticals.D100Fumble != 1 {
+		t.Fatalf("unexpected criticals: %+v", stats.Criticals)
+	}
+	if len(stats.Dice) != 2 || stats.Dice[0].Sides != 20 || stats.Dice[0].Count != 4 || stats.Dice[1].Sides != 100 {
+		t.Fatalf("unexpected dice groups: %+v", stats.Dice)
+	}
+}
+
+func TestDiceChiSquare(t *testing.T) {
+	uniform := diceChiSquare([]int64{10, 10, 10, 10, 10, 10})
+	if uniform == nil || uniform.Statistic != 0 || uniform.PValue != 1 || !uniform.Sufficient {
+		t.Fatalf("unexpected uniform result: %+v", uniform)
+	}
+	skewed := diceChiSquare([]int64{30, 0, 0, 0, 0, 0})
+	if skewed == nil || skewed.DegreesOfFreedom != 5 || skewed.PValue > 0.001 {
+		t.Fatalf("expected skewed distribution rejected: %+v", skewed)
+	}
+	if small := diceChiSquare([]int64{1, 0, 0, 0, 0, 0}); small == nil || small.Sufficient {
+		t.Fatalf("expected small sample marked insufficient: %+v", small)
+	}
+	// 自由度 1 时 3.841 对应 p≈0.05
+	if p := regularizedGammaQ(0.5, 3.841/2); math.Abs(p-0.05) > 0.001 {
+		t.Fatalf("unexpected chi-square p-value: %v", p)
+	}
+	// 自由度 10 时 18.307 对应 p≈0.05
+	if p := regularizedGammaQ(5, 18.307/2); math.Abs(p-0.05) > 0.001 {
+		t.Fatalf("unexpected chi-square p-value: %v", p)
+	}
+}
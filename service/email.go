@@ -1,9 +1,15 @@
 package service
 
 import (
+	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
 	"strings"
 	"time"
 
@@ -29,6 +35,11 @@ func (s *EmailService) IsConfigured() bool {
 
 // SendEmail 发送邮件
 func (s *EmailService) SendEmail(to, subject, htmlBody string) error {
+	return s.SendEmailWithReplyTo(to, "", subject, htmlBody)
+}
+
+// SendEmailWithReplyTo 发送邮件并指定 Reply-To，replyTo 为空时不设置
+func (s *EmailService) SendEmailWithReplyTo(to, replyTo, subject, htmlBody string) error {
 	if !s.IsConfigured() {
 		return fmt.Errorf("SMTP 未配置")
 	}
@@ -48,6 +59,9 @@ func (s *EmailService) SendEmail(to, subject, htmlBody string) error {
 		"Content-Type": "text/html; charset=UTF-8",
 		"Date":         time.Now().Format(time.RFC1123Z),
 	}
+	if replyTo != "" {
+		headers["Reply-To"] = replyTo
+	}
 
 	var msg strings.Builder
 	for k, v := range headers {
@@ -56,13 +70,97 @@ func (s *EmailService) SendEmail(to, subject, htmlBody string) error {
 	msg.WriteString("\r\n")
 	msg.WriteString(htmlBody)
 
+	return s.send(to, msg.String())
+}
+
+// EmailAttachment 邮件附件
+type EmailAttachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// SendEmailWithAttachments 发送带附件的邮件（multipart/mixed）
+func (s *EmailService) SendEmailWithAttachments(to, subject, htmlBody string, attachments []EmailAttachment) error {
+	if !s.IsConfigured() {
+		return fmt.Errorf("SMTP 未配置")
+	}
+
+	fromName := s.cfg.FromName
+	if fromName == "" {
+		fromName = "SealChat"
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	htmlHeader := textproto.MIMEHeader{}
+	htmlHeader.Set("Content-Type", "text/html; charset=UTF-8")
+	htmlHeader.Set("Content-Transfer-Encoding", "base64")
+	part, err := writer.CreatePart(htmlHeader)
+	if err != nil {
+		return err
+	}
+	if err := writeBase64Lines(part, []byte(htmlBody)); err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		fileName := mime.BEncoding.Encode("UTF-8", attachment.FileName)
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", fmt.Sprintf("%s; name=\"%s\"", contentType, fileName))
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if err := writeBase64Lines(part, attachment.Data); err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("From: %s <%s>\r\n", fromName, s.cfg.FromAddress))
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", to))
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject)))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%s\r\n", writer.Boundary()))
+	msg.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return s.send(to, msg.String())
+}
+
+// writeBase64Lines 按 RFC 2045 每行 76 字符写出 base64
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
+
+func (s *EmailService) send(to, msg string) error {
 	addr := fmt.Sprintf("%s:%d", s.cfg.Host, s.cfg.Port)
 
 	// 根据配置选择 TLS 或普通连接
 	if s.cfg.UseTLS {
-		return s.sendWithTLS(addr, to, msg.String())
+		return s.sendWithTLS(addr, to, msg)
 	}
-	return s.sendPlain(addr, to, msg.String())
+	return s.sendPlain(addr, to, msg)
 }
 
 func (s *EmailService) sendPlain(addr, to, msg string) error {
@@ -130,8 +228,16 @@ type MessageSummary struct {
 	Time        time.Time
 }
 
+// emailReplyMarker 回复邮件中该行及以下的内容视为引用原文
+const emailReplyMarker = "##- 请在此行上方输入回复 -##"
+
 // BuildUnreadDigestHTML 构建未读消息摘要 HTML
 func BuildUnreadDigestHTML(channelName string, messages []MessageSummary, siteURL string, channelURL string) string {
+	return buildUnreadDigestHTML(channelName, messages, siteURL, channelURL, false)
+}
+
+// buildUnreadDigestHTML replyEnabled 为真时提示用户可直接回复邮件发言
+func buildUnreadDigestHTML(channelName string, messages []MessageSummary, siteURL string, channelURL string, replyEnabled bool) string {
 	var sb strings.Builder
 
 	sb.WriteString(`<!DOCTYPE html>
@@ -164,7 +270,13 @@ body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-
 	sb.WriteString(`</p>
 </div>
 <div class="content">
-<p style="color:#666;margin-bottom:16px;">以下是您尚未阅读的消息：</p>
+`)
+	if replyEnabled {
+		sb.WriteString(`<p style="color:#999;font-size:12px;">`)
+		sb.WriteString(emailReplyMarker)
+		sb.WriteString("</p>\n")
+	}
+	sb.WriteString(`<p style="color:#666;margin-bottom:16px;">以下是您尚未阅读的消息：</p>
 `)
 
 	for _, m := range messages {
@@ -198,8 +310,13 @@ body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-
 	sb.WriteString(`
 </div>
 <div class="footer">
-此邮件由 SealChat 自动发送，请勿直接回复。<br>
-如需取消订阅，请在 SealChat 中关闭邮件提醒功能。
+`)
+	if replyEnabled {
+		sb.WriteString("直接回复此邮件，回复内容将以您的身份发送到该频道。<br>\n")
+	} else {
+		sb.WriteString("此邮件由 SealChat 自动发送，请勿直接回复。<br>\n")
+	}
+	sb.WriteString(`如需取消订阅，请在 SealChat 中关闭邮件提醒功能。
 </div>
 </div>
 </body>
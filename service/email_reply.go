@@ -0,0 +1,358 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/encoding/htmlindex"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+var (
+	ErrEmailReplyAddressInvalid = errors.New("回复地址无效或已过期")
+	ErrEmailReplySenderMismatch = errors.New("发件人与通知邮箱不一致")
+	ErrEmailReplyEmpty          = errors.New("回复内容为空")
+	ErrEmailReplyDuplicate      = errors.New("重复投递的回复邮件")
+	ErrEmailReplyForbidden      = errors.New("无权在该频道发言")
+)
+
+// emailReplyMaxRunes 单封回复写入消息的最大字符数
+const emailReplyMaxRunes = 4000
+
+// EmailReplyMessageCreator 由 api 层注入，以用户身份走常规消息发送流程
+var EmailReplyMessageCreator func(userID, channelID, content string) error
+
+var emailReplyBase32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EmailReplyAvailable 回复投递已启用且签名密钥与域名均已配置
+func EmailReplyAvailable(cfg utils.EmailReplyConfig) bool {
+	return cfg.Enabled && strings.TrimSpace(cfg.Secret) != "" && strings.TrimSpace(cfg.Domain) != ""
+}
+
+// BuildEmailReplyAddress 为通知设置生成签名回复地址，令牌绑定设置 ID、过期时间与收件邮箱
+func BuildEmailReplyAddress(cfg utils.EmailReplyConfig, setting *model.EmailNotificationSettingsModel, now time.Time) string {
+	if !EmailReplyAvailable(cfg) || setting == nil || setting.ID == "" {
+		return ""
+	}
+	expires := strconv.FormatInt(now.Add(time.Duration(cfg.TokenTTLHours)*time.Hour).Unix(), 36)
+	signature := emailReplySignature(cfg.Secret, setting.ID, expires, setting.Email)
+	return fmt.Sprintf("%s+%s.%s.%s@%s", cfg.AddressPrefix, setting.ID, expires, signature, strings.TrimSpace(cfg.Domain))
+}
+
+// VerifyEmailReplyAddress 校验回复地址的签名与有效期，返回对应的通知设置
+func VerifyEmailReplyAddress(cfg utils.EmailReplyConfig, address string, now time.Time) (*model.EmailNotificationSettingsModel, error) {
+	if !EmailReplyAvailable(cfg) {
+		return nil, ErrEmailReplyAddressInvalid
+	}
+	address = strings.Trim(strings.TrimSpace(address), "<>")
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || !strings.EqualFold(address[at+1:], strings.TrimSpace(cfg.Domain)) {
+		return nil, ErrEmailReplyAddressInvalid
+	}
+	local := address[:at]
+	prefix := cfg.AddressPrefix + "+"
+	if len(local) <= len(prefix) || !strings.EqualFold(local[:len(prefix)], prefix) {
+		return nil, ErrEmailReplyAddressInvalid
+	}
+	parts := strings.Split(local[len(prefix):], ".")
+	if len(parts) != 3 {
+		return nil, ErrEmailReplyAddressInvalid
+	}
+	settingID, expires, signature := parts[0], parts[1], strings.ToLower(parts[2])
+	expiresAt, err := strconv.ParseInt(expires, 36, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return nil, ErrEmailReplyAddressInvalid
+	}
+	setting, err := model.EmailNotificationSettingsGetByID(settingID)
+	if err != nil {
+		return nil, err
+	}
+	// 关闭通知或更换邮箱后旧地址随之失效
+	if setting == nil || !setting.Enabled || strings.TrimSpace(setting.ChannelID) == "" {
+		return nil, ErrEmailReplyAddressInvalid
+	}
+	expected := emailReplySignature(cfg.Secret, setting.ID, expires, setting.Email)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrEmailReplyAddressInvalid
+	}
+	return setting, nil
+}
+
+func emailReplySignature(secret, settingID, expires, email string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("email-reply|" + settingID + "|" + expires + "|" + strings.ToLower(strings.TrimSpace(email))))
+	return strings.ToLower(emailReplyBase32.EncodeToString(mac.Sum(nil)[:10]))
+}
+
+// emailReplySeen 记录近期处理过的回复（设置 ID + Message-ID），避免 MTA 重投导致重复发言
+var emailReplySeen = struct {
+	sync.Mutex
+	items map[string]time.Time
+}{items: map[string]time.Time{}}
+
+func markEmailReplySeen(key string, now time.Time) bool {
+	if key == "" {
+		return true
+	}
+	emailReplySeen.Lock()
+	defer emailReplySeen.Unlock()
+	for key, seenAt := range emailReplySeen.items {
+		if now.Sub(seenAt) > 24*time.Hour {
+			delete(emailReplySeen.items, key)
+		}
+	}
+	if _, ok := emailReplySeen.items[key]; ok {
+		return false
+	}
+	emailReplySeen.items[key] = now
+	return true
+}
+
+func forgetEmailReplySeen(key string) {
+	if key == "" {
+		return
+	}
+	emailReplySeen.Lock()
+	delete(emailReplySeen.items, key)
+	emailReplySeen.Unlock()
+}
+
+// HandleInboundEmailReply 处理投递到回复地址的原始邮件，校验后以用户身份发送到通知所属频道
+func HandleInboundEmailReply(cfg utils.EmailReplyConfig, recipient string, raw []byte) error {
+	now := time.Now()
+	setting, err := VerifyEmailReplyAddress(cfg, recipient, now)
+	if err != nil {
+		return err
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("解析邮件失败: %w", err)
+	}
+	parser := mail.AddressParser{WordDecoder: emailWordDecoder()}
+	from, err := parser.Parse(msg.Header.Get("From"))
+	if err != nil || !strings.EqualFold(from.Address, strings.TrimSpace(setting.Email)) {
+		return ErrEmailReplySenderMismatch
+	}
+	text, err := extractEmailReplyText(textproto.MIMEHeader(msg.Header), msg.Body, 0)
+	if err != nil {
+		return fmt.Errorf("读取邮件正文失败: %w", err)
+	}
+	content := StripEmailReplyQuote(text)
+	if content == "" {
+		return ErrEmailReplyEmpty
+	}
+	if runes := []rune(content); len(runes) > emailReplyMaxRunes {
+		content = string(runes[:emailReplyMaxRunes])
+	}
+	if EmailReplyMessageCreator == nil {
+		return errors.New("消息发送未初始化")
+	}
+	seenKey := ""
+	if messageID := strings.TrimSpace(msg.Header.Get("Message-Id")); messageID != "" {
+		seenKey = setting.ID + "|" + messageID
+	}
+	if !markEmailReplySeen(seenKey, now) {
+		return ErrEmailReplyDuplicate
+	}
+	if err := EmailReplyMessageCreator(setting.UserID, setting.ChannelID, content); err != nil {
+		// 发送失败时允许 MTA 重投
+		forgetEmailReplySeen(seenKey)
+		return err
+	}
+	return nil
+}
+
+func emailWordDecoder() *mime.WordDecoder {
+	return &mime.WordDecoder{CharsetReader: emailCharsetReader}
+}
+
+func emailCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	charset = strings.ToLower(strings.TrimSpace(charset))
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" {
+		return input, nil
+	}
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// extractEmailReplyText 优先取 text/plain 正文，只有 HTML 时转为纯文本并去掉 blockquote 引用
+func extractEmailReplyText(header textproto.MIMEHeader, body io.Reader, depth int) (string, error) {
+	if depth > 5 {
+		return "", nil
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		htmlText := ""
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			if strings.HasPrefix(strings.ToLower(part.Header.Get("Content-Disposition")), "attachment") {
+				continue
+			}
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			text, err := extractEmailReplyText(part.Header, part, depth+1)
+			if err != nil {
+				return "", err
+			}
+			if text == "" {
+				continue
+			}
+			if partType == "text/html" {
+				if htmlText == "" {
+					htmlText = text
+				}
+				continue
+			}
+			return text, nil
+		}
+		return htmlText, nil
+	}
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", nil
+	}
+	var decoded io.Reader = body
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		decoded = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		decoded = quotedprintable.NewReader(body)
+	}
+	decoded, err = emailCharsetReader(params["charset"], decoded)
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(io.LimitReader(decoded, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if mediaType == "text/html" {
+		return emailHTMLToText(string(data)), nil
+	}
+	return string(data), nil
+}
+
+func emailHTMLToText(source string) string {
+	root, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch node.Data {
+			case "blockquote", "style", "script", "head":
+				return
+			case "br":
+				sb.WriteString("\n")
+				return
+			}
+		}
+		if node.Type == html.TextNode {
+			sb.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if node.Type == html.ElementNode {
+			switch node.Data {
+			case "p", "div", "li", "tr", "h1", "h2", "h3", "h4", "h5", "h6":
+				sb.WriteString("\n")
+			}
+		}
+	}
+	walk(root)
+	return sb.String()
+}
+
+var (
+	emailQuoteHeaderPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^on\s.+wrote:$`),
+		regexp.MustCompile(`^在.+写道[:：]$`),
+		regexp.MustCompile(`(?i)^-{2,}\s*(original message|原始邮件|原始信件)\s*-{2,}$`),
+		regexp.MustCompile(`(?i)^(from|发件人)[:：]\s*\S+`),
+		regexp.MustCompile(`^_{10,}$`),
+	}
+	emailSignaturePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^sent from my\s`),
+		regexp.MustCompile(`^(发自我的|来自我的)`),
+	}
+)
+
+// StripEmailReplyQuote 截取回复正文：遇到回复分隔线、引用块、"某某写道"等引用头或签名分隔时丢弃其后的内容
+func StripEmailReplyQuote(text string) string {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	for index, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.Contains(trimmed, emailReplyMarker) || strings.HasPrefix(trimmed, ">") {
+			break
+		}
+		if isEmailQuoteHeader(trimmed) {
+			break
+		}
+		// Gmail 等客户端会把过长的 "On ... wrote:" 折成两行
+		if index+1 < len(lines) && trimmed != "" && isEmailQuoteHeader(trimmed+" "+strings.TrimSpace(lines[index+1])) {
+			break
+		}
+		if isEmailSignature(line, trimmed) {
+			break
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+func isEmailQuoteHeader(line string) bool {
+	for _, pattern := range emailQuoteHeaderPatterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmailSignature(raw, trimmed string) bool {
+	// 签名分隔符按 RFC 3676 为独占一行的 "-- "
+	if strings.TrimRight(raw, "\r") == "-- " || trimmed == "--" {
+		return true
+	}
+	for _, pattern := range emailSignaturePatterns {
+		if pattern.MatchString(trimmed) {
+			return true
+		}
+	}
+	return false
+}
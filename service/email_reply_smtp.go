@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"sealchat/utils"
+)
+
+const (
+	emailReplySMTPIdleTimeout  = 5 * time.Minute
+	emailReplySMTPMaxRecipient = 10
+)
+
+var emailReplyListenerOnce sync.Once
+
+// StartEmailReplyListener 启动接收回复邮件的 SMTP 监听，只接受签名回复地址作为收件人。
+// 监听不提供 TLS 与认证，应放在内网由前置 MTA 转发回复域名的邮件。
+func StartEmailReplyListener(ctx context.Context, cfg utils.EmailReplyConfig) {
+	if !cfg.Enabled {
+		return
+	}
+	if !EmailReplyAvailable(cfg) {
+		log.Println("email-reply: 未配置 domain 或 secret，回复投递未启用")
+		return
+	}
+	emailReplyListenerOnce.Do(func() {
+		listener, err := net.Listen("tcp", cfg.ListenAddr)
+		if err != nil {
+			log.Printf("email-reply: 监听 %s 失败: %v", cfg.ListenAddr, err)
+			return
+		}
+		log.Printf("email-reply: SMTP 监听 %s", listener.Addr())
+		go serveEmailReplySMTP(ctx, listener, cfg)
+	})
+}
+
+func serveEmailReplySMTP(ctx context.Context, listener net.Listener, cfg utils.EmailReplyConfig) {
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("email-reply: 接受连接失败: %v", err)
+			continue
+		}
+		go handleEmailReplySMTPConn(conn, cfg)
+	}
+}
+
+func handleEmailReplySMTPConn(conn net.Conn, cfg utils.EmailReplyConfig) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	domain := strings.TrimSpace(cfg.Domain)
+	reply := func(code int, message string) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(emailReplySMTPIdleTimeout))
+		return text.PrintfLine("%d %s", code, message) == nil
+	}
+	if !reply(220, domain+" SealChat ESMTP") {
+		return
+	}
+	maxBytes := int64(cfg.MaxMessageKB) * 1024
+	var from string
+	var recipients []string
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(emailReplySMTPIdleTimeout))
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			reply(250, domain)
+		case "EHLO":
+			_ = text.PrintfLine("250-%s", domain)
+			_ = text.PrintfLine("250-SIZE %d", maxBytes)
+			reply(250, "8BITMIME")
+		case "MAIL":
+			address, ok := parseSMTPPathArg(arg, "FROM:")
+			if !ok {
+				reply(501, "5.5.4 语法错误")
+				continue
+			}
+			from, recipients = address, nil
+			reply(250, "2.1.0 OK")
+		case "RCPT":
+			address, ok := parseSMTPPathArg(arg, "TO:")
+			if !ok {
+				reply(501, "5.5.4 语法错误")
+				continue
+			}
+			if len(recipients) >= emailReplySMTPMaxRecipient {
+				reply(452, "4.5.3 收件人过多")
+				continue
+			}
+			if _, err := VerifyEmailReplyAddress(cfg, address, time.Now()); err != nil {
+				reply(550, "5.1.1 回复地址无效或已过期")
+				continue
+			}
+			recipients = append(recipients, address)
+			reply(250, "2.1.5 OK")
+		case "DATA":
+			if len(recipients) == 0 {
+				reply(503, "5.5.1 缺少收件人")
+				continue
+			}
+			if !reply(354, "请输入邮件内容，以单独一行的 . 结束") {
+				return
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(emailReplySMTPIdleTimeout))
+			dot := text.DotReader()
+			data, err := io.ReadAll(io.LimitReader(dot, maxBytes+1))
+			if err != nil {
+				return
+			}
+			if int64(len(data)) > maxBytes {
+				_, _ = io.Copy(io.Discard, dot)
+				reply(552, "5.3.4 邮件过大")
+				from, recipients = "", nil
+				continue
+			}
+			code, message := deliverEmailReply(cfg, from, recipients, data)
+			reply(code, message)
+			from, recipients = "", nil
+		case "RSET":
+			from, recipients = "", nil
+			reply(250, "2.0.0 OK")
+		case "NOOP":
+			reply(250, "2.0.0 OK")
+		case "VRFY":
+			reply(252, "2.5.0 不提供地址验证")
+		case "QUIT":
+			reply(221, "2.0.0 Bye")
+			return
+		default:
+			reply(502, "5.5.2 不支持的命令")
+		}
+	}
+}
+
+// deliverEmailReply 逐个收件人投递；校验类错误直接拒收，其他错误返回临时失败让 MTA 重试
+func deliverEmailReply(cfg utils.EmailReplyConfig, from string, recipients []string, data []byte) (int, string) {
+	var lastErr error
+	delivered := 0
+	for _, recipient := range recipients {
+		err := HandleInboundEmailReply(cfg, recipient, data)
+		switch {
+		case err == nil, errors.Is(err, ErrEmailReplyDuplicate):
+			delivered++
+		default:
+			log.Printf("email-reply: 投递失败 from=%s to=%s: %v", from, recipient, err)
+			lastErr = err
+		}
+	}
+	if lastErr == nil || delivered > 0 {
+		return 250, "2.0.0 OK"
+	}
+	if errors.Is(lastErr, ErrEmailReplyAddressInvalid) || errors.Is(lastErr, ErrEmailReplySenderMismatch) ||
+		errors.Is(lastErr, ErrEmailReplyEmpty) || errors.Is(lastErr, ErrEmailReplyForbidden) {
+		return 550, fmt.Sprintf("5.7.1 %s", lastErr.Error())
+	}
+	return 451, "4.3.0 暂时无法投递，请稍后重试"
+}
+
+// parseSMTPPathArg 解析 "FROM:<addr> ..." 形式的参数，忽略 SIZE 等扩展参数
+func parseSMTPPathArg(arg, prefix string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	rest := strings.TrimSpace(arg[len(prefix):])
+	if strings.HasPrefix(rest, "<") {
+		end := strings.Index(rest, ">")
+		if end < 0 {
+			return "", false
+		}
+		return rest[1:end], true
+	}
+	address, _, _ := strings.Cut(rest, " ")
+	return address, address != ""
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+func TestStripEmailReplyQuote(t *testing.T) {
+	cases := map[string]string{
+		"好的，晚上见\r\n\r\nOn Mon, Oct 19, 2026 at 9:00 PM SealChat <noreply@example.com> wrote:\r\n> 原文": "好的，晚上见",
+		"收到\n\n在 2026年10月19日 21:00，SealChat 写道：\n> 原文":                                                "收到",
+		"第一行\n第二行\n-- \n张三\n某某公司":                                                                     "第一行\n第二行",
+		"马上到\n\n发自我的iPhone":                                                                           "马上到",
+		"先回复\n\n##- 请在此行上方输入回复 -##\n以下是您尚未阅读的消息":                                                      "先回复",
+		"长一点的回复\nOn Mon, Oct 19, 2026 at 9:00 PM SealChat\n<noreply@example.com> wrote:\n> 原文":        "长一点的回复",
+	}
+	for input, want := range cases {
+		if got := StripEmailReplyQuote(input); got != want {
+			t.Errorf("StripEmailReplyQuote(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestEmailReplySMTPPostsVerifiedReply(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	createExternalGlossaryTestUser(t, "reply-user")
+	setting, err := model.EmailNotificationSettingsUpsert("reply-user", "reply-channel", model.EmailNotificationSettingsUpsertParams{
+		Email: "reader@example.com", DelayMinutes: 10, Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("create notification setting failed: %v", err)
+	}
+	cfg := utils.EmailReplyConfig{Enabled: true, Domain: "reply.example.com", AddressPrefix: "reply", Secret: "test-secret", TokenTTLHours: 24, MaxMessageKB: 64}
+
+	type posted struct{ userID, channelID, content string }
+	var mu sync.Mutex
+	var got []posted
+	prevCreator := EmailReplyMessageCreator
+	EmailReplyMessageCreator = func(userID, channelID, content string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, posted{userID, channelID, content})
+		return nil
+	}
+	defer func() { EmailReplyMessageCreator = prevCreator }()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go serveEmailReplySMTP(ctx, listener, cfg)
+
+	address := BuildEmailReplyAddress(cfg, setting, time.Now())
+	if !strings.HasPrefix(address, "reply+"+setting.ID+".") || !strings.HasSuffix(address, "@reply.example.com") {
+		t.Fatalf("unexpected reply address: %s", address)
+	}
+	body := strings.Join([]string{
+		"From: Reader <reader@example.com>",
+		"To: " + address,
+		"Subject: Re: =?UTF-8?B?44CQU2VhbENoYXTjgJE=?=",
+		"Message-ID: <reply-1@example.com>",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/alternative; boundary="b1"`,
+		"",
+		"--b1",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"=E6=99=9A=E4=B8=8A=E8=A7=81",
+		"",
+		"On Mon, Oct 19, 2026 at 9:00 PM SealChat <noreply@example.com> wrote:",
+		"> =E5=8E=9F=E6=96=87",
+		"--b1",
+		"Content-Type: text/html; charset=UTF-8",
+		"",
+		"<div>晚上见</div><blockquote>原文</blockquote>",
+		"--b1--",
+		"",
+	}, "\r\n")
+	if err := smtp.SendMail(listener.Addr().String(), nil, "reader@example.com", []string{address}, []byte(body)); err != nil {
+		t.Fatalf("send reply failed: %v", err)
+	}
+	if len(got) != 1 || got[0].userID != "reply-user" || got[0].channelID != "reply-channel" || got[0].content != "晚上见" {
+		t.Fatalf("unexpected posted replies: %+v", got)
+	}
+
+	// MTA 重投同一封邮件不会重复发言
+	if err := smtp.SendMail(listener.Addr().String(), nil, "reader@example.com", []string{address}, []byte(body)); err != nil {
+		t.Fatalf("redelivery should be accepted: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("redelivered reply should not post again: %+v", got)
+	}
+
+	tampered := strings.Replace(address, "reply+"+setting.ID, "reply+"+setting.ID+"x", 1)
+	if err := smtp.SendMail(listener.Addr().String(), nil, "reader@example.com", []string{tampered}, []byte(body)); err == nil {
+		t.Fatalf("tampered reply address should be rejected")
+	}
+	spoofed := strings.Replace(strings.Replace(body, "reader@example.com", "other@example.com", 1), "reply-1@", "reply-2@", 1)
+	if err := smtp.SendMail(listener.Addr().String(), nil, "other@example.com", []string{address}, []byte(spoofed)); err == nil {
+		t.Fatalf("reply from another sender should be rejected")
+	}
+	if len(got) != 1 {
+		t.Fatalf("rejected replies should not be posted: %+v", got)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"unicode/utf8"
 
 	"sealchat/model"
+	"sealchat/protocol"
 	"sealchat/utils"
 
 	htmlnode "golang.org/x/net/html"
@@ -52,6 +53,8 @@ type ExportMessage struct {
 	Content          string    `json:"content"`
 	ContentHTML      string    `json:"content_html,omitempty"` // HTML 渲染结果，用于 HTML 导出
 	WhisperTargets   []string  `json:"whisper_targets"`
+	// ImagePlaceholders 图片附件的尺寸与 ThumbHash，键为附件 ID
+	ImagePlaceholders map[string]*protocol.ImagePlaceholder `json:"image_placeholders,omitempty"`
 }
 
 type ExportPayload struct {
@@ -159,25 +162,29 @@ func buildExportPayload(job *model.MessageExportJobModel, channelName string, me
 		// 将 <at> 标签转换为带样式的 HTML
 		htmlContent = convertAtTagsToHTML(htmlContent)
 		htmlContent = imageLayoutResolver.enhanceHTML(htmlContent)
+		var imagePlaceholders map[string]*protocol.ImagePlaceholder
 		if !includeImages {
 			htmlContent = stripImageTagsFromHTML(htmlContent)
+		} else {
+			imagePlaceholders = imageLayoutResolver.placeholdersFor(model.ExtractMessageImageAttachmentIDs(originalContent))
 		}
 		exportMessages = append(exportMessages, ExportMessage{
-			ID:               msg.ID,
-			SenderID:         msg.UserID,
-			SenderIdentityID: strings.TrimSpace(msg.SenderIdentityID),
-			SenderName:       resolveSenderName(msg),
-			SenderColor:      msg.SenderIdentityColor,
-			SenderAvatar:     resolveSenderAvatar(msg),
-			IsMerged:         msg.MergedMessages > 1,
-			IcMode:           fallbackIcMode(msg.ICMode),
-			IsWhisper:        msg.IsWhisper,
-			IsArchived:       msg.IsArchived,
-			IsBot:            msg.User != nil && msg.User.IsBot,
-			CreatedAt:        msg.CreatedAt,
-			Content:          exportContent,
-			ContentHTML:      htmlContent,
-			WhisperTargets:   extractWhisperTargets(msg, job.ChannelID, identityResolver),
+			ID:                msg.ID,
+			SenderID:          msg.UserID,
+			SenderIdentityID:  strings.TrimSpace(msg.SenderIdentityID),
+			SenderName:        resolveSenderName(msg),
+			SenderColor:       msg.SenderIdentityColor,
+			SenderAvatar:      resolveSenderAvatar(msg),
+			IsMerged:          msg.MergedMessages > 1,
+			IcMode:            fallbackIcMode(msg.ICMode),
+			IsWhisper:         msg.IsWhisper,
+			IsArchived:        msg.IsArchived,
+			IsBot:             msg.User != nil && msg.User.IsBot,
+			CreatedAt:         msg.CreatedAt,
+			Content:           exportContent,
+			ContentHTML:       htmlContent,
+			WhisperTargets:    extractWhisperTargets(msg, job.ChannelID, identityResolver),
+			ImagePlaceholders: imagePlaceholders,
 		})
 	}
 
@@ -1005,8 +1012,9 @@ type exportImageLayout struct {
 }
 
 type exportImageLayoutResolver struct {
-	channelID string
-	cache     map[string]*exportImageLayout
+	channelID    string
+	cache        map[string]*exportImageLayout
+	placeholders map[string]*protocol.ImagePlaceholder
 }
 
 func newExportImageLayoutResolver(channelID string) *exportImageLayoutResolver {
@@ -1015,8 +1023,9 @@ func newExportImageLayoutResolver(channelID string) *exportImageLayoutResolver {
 		return nil
 	}
 	return &exportImageLayoutResolver{
-		channelID: channelID,
-		cache:     make(map[string]*exportImageLayout),
+		channelID:    channelID,
+		cache:        make(map[string]*exportImageLayout),
+		placeholders: make(map[string]*protocol.ImagePlaceholder),
 	}
 }
 
@@ -1096,6 +1105,11 @@ func (r *exportImageLayoutResolver) ensureLayouts(attachmentIDs []string) {
 	if len(missing) == 0 {
 		return
 	}
+	if placeholders, err := model.AttachmentImagePlaceholdersByIDs(model.GetDB(), missing); err == nil {
+		for attachmentID, placeholder := range placeholders {
+			r.placeholders[attachmentID] = placeholder
+		}
+	}
 	layouts, err := model.ChannelAttachmentImageLayoutBatchGet(r.channelID, missing)
 	if err != nil {
 		for _, attachmentID := range missing {
@@ -1161,6 +1175,9 @@ func (r *exportImageLayoutResolver) decorateImageNodes(node *htmlnode.Node) bool
 					changed = true
 				}
 			}
+			if r.decorateImagePlaceholder(node, attachmentID) {
+				changed = true
+			}
 		} else if srcIndex >= 0 {
 			_ = srcIndex
 		}
@@ -1173,6 +1190,57 @@ func (r *exportImageLayoutResolver) decorateImageNodes(node *htmlnode.Node) bool
 	return changed
 }
 
+// decorateImagePlaceholder 写入 ThumbHash 与原始尺寸，查看器据此在图片加载前占位
+func (r *exportImageLayoutResolver) decorateImagePlaceholder(node *htmlnode.Node, attachmentID string) bool {
+	placeholder := r.placeholders[attachmentID]
+	if placeholder == nil {
+		return false
+	}
+	attrs := make([]htmlnode.Attribute, 0, 3)
+	if placeholder.ThumbHash != "" {
+		attrs = append(attrs, htmlnode.Attribute{Key: "data-thumbhash", Val: placeholder.ThumbHash})
+	}
+	if placeholder.Width > 0 && placeholder.Height > 0 {
+		attrs = append(attrs,
+			htmlnode.Attribute{Key: "width", Val: strconv.Itoa(placeholder.Width)},
+			htmlnode.Attribute{Key: "height", Val: strconv.Itoa(placeholder.Height)},
+		)
+	}
+	changed := false
+	for _, attr := range attrs {
+		exists := false
+		for _, current := range node.Attr {
+			if strings.EqualFold(current.Key, attr.Key) {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			node.Attr = append(node.Attr, attr)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// placeholdersFor 返回已加载的图片占位信息，未命中缓存的附件会补查一次
+func (r *exportImageLayoutResolver) placeholdersFor(attachmentIDs []string) map[string]*protocol.ImagePlaceholder {
+	if r == nil || len(attachmentIDs) == 0 {
+		return nil
+	}
+	r.ensureLayouts(attachmentIDs)
+	var result map[string]*protocol.ImagePlaceholder
+	for _, attachmentID := range attachmentIDs {
+		if placeholder := r.placeholders[attachmentID]; placeholder != nil {
+			if result == nil {
+				result = make(map[string]*protocol.ImagePlaceholder, len(attachmentIDs))
+			}
+			result[attachmentID] = placeholder
+		}
+	}
+	return result
+}
+
 func mergeInlineStyle(existing string, additions string) string {
 	existing = strings.TrimSpace(existing)
 	additions = strings.TrimSpace(additions)
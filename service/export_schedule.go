@@ -0,0 +1,529 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sealchat/model"
+)
+
+const (
+	ExportDeliveryLocal     = "local"
+	ExportDeliveryS3        = "s3"
+	ExportDeliveryEmail     = "email"
+	ExportDeliveryLogUpload = "log_upload"
+
+	exportScheduleMaxTargets = 5
+	// exportScheduleMaxFailures 连续失败达到该次数后自动停用计划
+	exportScheduleMaxFailures = 5
+)
+
+// ExportDeliveryTarget 投递目标：local 的 Path 为导出目录下 scheduled/ 的子目录，s3 的 Path 为对象前缀，
+// email 的 To 为收件人，log_upload 的 Name 为上传时的日志名。
+type ExportDeliveryTarget struct {
+	Type string `json:"type"`
+	Path string `json:"path,omitempty"`
+	To   string `json:"to,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type ExportDeliveryResult struct {
+	Type     string `json:"type"`
+	OK       bool   `json:"ok"`
+	Location string `json:"location,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ExportScheduleInput 创建或更新导出计划的参数；更新时忽略作用域字段。
+type ExportScheduleInput struct {
+	ScopeType        string
+	ScopeID          string
+	Name             string
+	Format           string
+	DisplayName      string
+	IncludeOOC       bool
+	IncludeArchived  bool
+	IncludeImages    bool
+	WithoutTimestamp bool
+	MergeMessages    bool
+	Frequency        string
+	CronExpr         string
+	TimeOfDay        string
+	Weekday          int
+	Targets          []ExportDeliveryTarget
+	AlertEmail       string
+	Incremental      bool
+	Enabled          bool
+}
+
+// ResolveExportScheduleScope 校验用户能否管理该作用域的导出计划，返回所属世界 ID（私聊为空）。
+// 世界与世界内频道需要世界管理员，私聊频道需要是会话双方之一。
+func ResolveExportScheduleScope(userID, scopeType, scopeID string) (string, error) {
+	scopeID = strings.TrimSpace(scopeID)
+	if strings.TrimSpace(userID) == "" {
+		return "", fmt.Errorf("未认证")
+	}
+	if scopeID == "" {
+		return "", fmt.Errorf("作用域不能为空")
+	}
+	switch scopeType {
+	case model.MessageExportScheduleScopeWorld:
+		if !IsWorldAdmin(scopeID, userID) {
+			return "", fmt.Errorf("无权限管理该世界的导出计划")
+		}
+		return scopeID, nil
+	case model.MessageExportScheduleScopeChannel:
+		channel, err := model.ChannelGet(scopeID)
+		if err != nil {
+			return "", err
+		}
+		if channel != nil && channel.ID != "" {
+			worldID := strings.TrimSpace(channel.WorldID)
+			if worldID == "" || !IsWorldAdmin(worldID, userID) {
+				return "", fmt.Errorf("无权限管理该频道的导出计划")
+			}
+			return worldID, nil
+		}
+		fr, err := model.FriendRelationGetByID(scopeID)
+		if err != nil {
+			return "", err
+		}
+		if fr.ID == "" {
+			return "", fmt.Errorf("频道不存在")
+		}
+		if fr.UserID1 != userID && fr.UserID2 != userID {
+			return "", fmt.Errorf("无权限管理该频道的导出计划")
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf("不支持的作用域: %s", scopeType)
+	}
+}
+
+// CreateExportSchedule 创建导出计划并算出首次执行时间。
+func CreateExportSchedule(userID string, input ExportScheduleInput) (*model.MessageExportScheduleModel, error) {
+	worldID, err := ResolveExportScheduleScope(userID, input.ScopeType, input.ScopeID)
+	if err != nil {
+		return nil, err
+	}
+	schedule := &model.MessageExportScheduleModel{
+		UserID:    userID,
+		ScopeType: input.ScopeType,
+		ScopeID:   strings.TrimSpace(input.ScopeID),
+		WorldID:   worldID,
+	}
+	if err := applyExportScheduleInput(schedule, input, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := model.GetDB().Create(schedule).Error; err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// UpdateExportSchedule 更新计划配置；时间规则变化后重新计算下次执行时间。
+func UpdateExportSchedule(userID, scheduleID string, input ExportScheduleInput) (*model.MessageExportScheduleModel, error) {
+	schedule, err := GetExportScheduleForUser(userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	wasEnabled := schedule.Enabled
+	if err := applyExportScheduleInput(schedule, input, time.Now()); err != nil {
+		return nil, err
+	}
+	// 因连续失败被停用的计划重新启用时清零失败计数
+	if input.Enabled && !wasEnabled {
+		schedule.FailureCount = 0
+	}
+	if err := model.GetDB().Save(schedule).Error; err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteExportSchedule 删除计划及其执行记录，已生成的导出任务保留。
+func DeleteExportSchedule(userID, scheduleID string) error {
+	schedule, err := GetExportScheduleForUser(userID, scheduleID)
+	if err != nil {
+		return err
+	}
+	return model.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", schedule.ID).Delete(&model.MessageExportScheduleRunModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(schedule).Error
+	})
+}
+
+// GetExportScheduleForUser 读取计划并按当前作用域权限校验。
+func GetExportScheduleForUser(userID, scheduleID string) (*model.MessageExportScheduleModel, error) {
+	scheduleID = strings.TrimSpace(scheduleID)
+	if scheduleID == "" {
+		return nil, fmt.Errorf("导出计划不存在")
+	}
+	var schedule model.MessageExportScheduleModel
+	if err := model.GetDB().Where("id = ?", scheduleID).Limit(1).Find(&schedule).Error; err != nil {
+		return nil, err
+	}
+	if schedule.ID == "" {
+		return nil, fmt.Errorf("导出计划不存在")
+	}
+	if _, err := ResolveExportScheduleScope(userID, schedule.ScopeType, schedule.ScopeID); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ListExportSchedules 列出作用域下的全部计划。
+func ListExportSchedules(userID, scopeType, scopeID string) ([]*model.MessageExportScheduleModel, error) {
+	if _, err := ResolveExportScheduleScope(userID, scopeType, scopeID); err != nil {
+		return nil, err
+	}
+	var items []*model.MessageExportScheduleModel
+	err := model.GetDB().
+		Where("scope_type = ? AND scope_id = ?", scopeType, strings.TrimSpace(scopeID)).
+		Order("created_at asc").
+		Find(&items).Error
+	return items, err
+}
+
+// ListExportScheduleRuns 按时间倒序列出计划的执行记录。
+func ListExportScheduleRuns(userID, scheduleID string, limit int) ([]*model.MessageExportScheduleRunModel, error) {
+	schedule, err := GetExportScheduleForUser(userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	var runs []*model.MessageExportScheduleRunModel
+	err = model.GetDB().
+		Where("schedule_id = ?", schedule.ID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
+
+// TriggerExportSchedule 把下次执行时间提前到现在，由后台 Worker 在下一轮执行。
+func TriggerExportSchedule(userID, scheduleID string) (*model.MessageExportScheduleModel, error) {
+	schedule, err := GetExportScheduleForUser(userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := model.GetDB().Model(schedule).Update("next_run_at", now).Error; err != nil {
+		return nil, err
+	}
+	schedule.NextRunAt = &now
+	return schedule, nil
+}
+
+// ParseExportScheduleTargets 解析计划中保存的投递目标。
+func ParseExportScheduleTargets(raw string) []ExportDeliveryTarget {
+	var targets []ExportDeliveryTarget
+	if strings.TrimSpace(raw) == "" {
+		return targets
+	}
+	_ = json.Unmarshal([]byte(raw), &targets)
+	return targets
+}
+
+func applyExportScheduleInput(schedule *model.MessageExportScheduleModel, input ExportScheduleInput, now time.Time) error {
+	format, ok := normalizeExportFormat(input.Format)
+	if !ok {
+		return fmt.Errorf("不支持的导出格式: %s", input.Format)
+	}
+	targets, err := normalizeExportDeliveryTargets(input.Targets, format)
+	if err != nil {
+		return err
+	}
+	encodedTargets, err := json.Marshal(targets)
+	if err != nil {
+		return err
+	}
+	alertEmail := strings.TrimSpace(input.AlertEmail)
+	if alertEmail != "" {
+		if _, err := mail.ParseAddress(alertEmail); err != nil {
+			return fmt.Errorf("告警邮箱格式无效")
+		}
+	}
+
+	schedule.Name = normalizeExportDisplayName(input.Name)
+	schedule.Format = format
+	schedule.DisplayName = normalizeExportDisplayName(input.DisplayName)
+	schedule.IncludeOOC = input.IncludeOOC
+	schedule.IncludeArchived = input.IncludeArchived
+	schedule.IncludeImages = input.IncludeImages
+	schedule.WithoutTimestamp = input.WithoutTimestamp
+	schedule.MergeMessages = input.MergeMessages
+	schedule.Frequency = strings.ToLower(strings.TrimSpace(input.Frequency))
+	schedule.CronExpr = strings.Join(strings.Fields(input.CronExpr), " ")
+	schedule.TimeOfDay = strings.TrimSpace(input.TimeOfDay)
+	schedule.Weekday = input.Weekday
+	schedule.Targets = string(encodedTargets)
+	schedule.AlertEmail = alertEmail
+	schedule.Incremental = input.Incremental
+	schedule.Enabled = input.Enabled
+
+	next, err := ComputeExportScheduleNextRun(schedule, now)
+	if err != nil {
+		return err
+	}
+	schedule.NextRunAt = &next
+	return nil
+}
+
+func normalizeExportDeliveryTargets(targets []ExportDeliveryTarget, format string) ([]ExportDeliveryTarget, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("至少配置一个投递目标")
+	}
+	if len(targets) > exportScheduleMaxTargets {
+		return nil, fmt.Errorf("投递目标最多 %d 个", exportScheduleMaxTargets)
+	}
+	result := make([]ExportDeliveryTarget, 0, len(targets))
+	for _, target := range targets {
+		target.Type = strings.ToLower(strings.TrimSpace(target.Type))
+		target.Path = strings.TrimSpace(target.Path)
+		target.To = strings.TrimSpace(target.To)
+		target.Name = strings.TrimSpace(target.Name)
+		switch target.Type {
+		case ExportDeliveryLocal:
+			dir, err := normalizeExportScheduleSubdir(target.Path)
+			if err != nil {
+				return nil, err
+			}
+			target.Path = dir
+		case ExportDeliveryS3:
+			target.Path = strings.Trim(target.Path, "/")
+			if strings.Contains(target.Path, "..") {
+				return nil, fmt.Errorf("S3 前缀不能包含 ..")
+			}
+		case ExportDeliveryEmail:
+			if target.To == "" {
+				return nil, fmt.Errorf("邮件投递需要收件人")
+			}
+			if _, err := mail.ParseAddress(target.To); err != nil {
+				return nil, fmt.Errorf("收件人邮箱格式无效: %s", target.To)
+			}
+		case ExportDeliveryLogUpload:
+			if format != "json" {
+				return nil, fmt.Errorf("日志上传仅支持 json 格式")
+			}
+		default:
+			return nil, fmt.Errorf("不支持的投递方式: %s", target.Type)
+		}
+		result = append(result, target)
+	}
+	return result, nil
+}
+
+// normalizeExportScheduleSubdir 本地投递只允许写入导出目录下 scheduled/ 的相对子目录
+func normalizeExportScheduleSubdir(dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return "", nil
+	}
+	if filepath.IsAbs(dir) || strings.HasPrefix(dir, "/") || strings.HasPrefix(dir, "\\") {
+		return "", fmt.Errorf("本地目录必须是相对路径")
+	}
+	cleaned := filepath.ToSlash(filepath.Clean(dir))
+	for _, part := range strings.Split(cleaned, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("本地目录不能包含 ..")
+		}
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
+
+// ComputeExportScheduleNextRun 按计划的时间规则（服务器本地时区）计算 after 之后的下一次执行时间。
+func ComputeExportScheduleNextRun(schedule *model.MessageExportScheduleModel, after time.Time) (time.Time, error) {
+	expr, err := exportScheduleCronExpr(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	spec, err := parseExportScheduleCron(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next, ok := spec.next(after)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cron 表达式在一年内没有可执行时间: %s", expr)
+	}
+	return next, nil
+}
+
+// exportScheduleCronExpr 把 daily/weekly 折算成等价的 cron 表达式
+func exportScheduleCronExpr(schedule *model.MessageExportScheduleModel) (string, error) {
+	switch schedule.Frequency {
+	case model.MessageExportScheduleFrequencyCron:
+		if strings.TrimSpace(schedule.CronExpr) == "" {
+			return "", fmt.Errorf("cron 表达式不能为空")
+		}
+		return schedule.CronExpr, nil
+	case model.MessageExportScheduleFrequencyDaily, model.MessageExportScheduleFrequencyWeekly:
+		hour, minute, err := parseExportScheduleTimeOfDay(schedule.TimeOfDay)
+		if err != nil {
+			return "", err
+		}
+		if schedule.Frequency == model.MessageExportScheduleFrequencyDaily {
+			return fmt.Sprintf("%d %d * * *", minute, hour), nil
+		}
+		if schedule.Weekday < 0 || schedule.Weekday > 6 {
+			return "", fmt.Errorf("星期取值应为 0-6")
+		}
+		return fmt.Sprintf("%d %d * * %d", minute, hour, schedule.Weekday), nil
+	default:
+		return "", fmt.Errorf("不支持的执行频率: %s", schedule.Frequency)
+	}
+}
+
+func parseExportScheduleTimeOfDay(value string) (int, int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, 0, nil
+	}
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("执行时间格式应为 HH:MM")
+	}
+	return parsed.Hour(), parsed.Minute(), nil
+}
+
+// exportCronSpec 标准五段 cron（分 时 日 月 周），支持 *、列表、范围与步长
+type exportCronSpec struct {
+	minute [60]bool
+	hour   [24]bool
+	dom    [32]bool
+	month  [13]bool
+	dow    [7]bool
+	// 日与周都被限定时按 cron 惯例取并集
+	domAny bool
+	dowAny bool
+}
+
+func parseExportScheduleCron(expr string) (*exportCronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron 表达式应包含 5 段: %s", expr)
+	}
+	spec := &exportCronSpec{}
+	type fieldDef struct {
+		min, max int
+		set      func(int)
+		any      *bool
+	}
+	var ignored bool
+	defs := []fieldDef{
+		{0, 59, func(v int) { spec.minute[v] = true }, &ignored},
+		{0, 23, func(v int) { spec.hour[v] = true }, &ignored},
+		{1, 31, func(v int) { spec.dom[v] = true }, &spec.domAny},
+		{1, 12, func(v int) { spec.month[v] = true }, &ignored},
+		{0, 7, func(v int) { spec.dow[v%7] = true }, &spec.dowAny},
+	}
+	for i, field := range fields {
+		def := defs[i]
+		wildcard, err := parseExportCronField(field, def.min, def.max, def.set)
+		if err != nil {
+			return nil, fmt.Errorf("cron 表达式第 %d 段无效: %w", i+1, err)
+		}
+		*def.any = wildcard
+	}
+	return spec, nil
+}
+
+func parseExportCronField(field string, min, max int, set func(int)) (bool, error) {
+	wildcard := field == "*"
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, rawStep, ok := strings.Cut(part, "/"); ok {
+			value, err := strconv.Atoi(rawStep)
+			if err != nil || value <= 0 {
+				return false, fmt.Errorf("步长无效: %s", part)
+			}
+			step = value
+			part = base
+		}
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			rawLo, rawHi, _ := strings.Cut(part, "-")
+			var err error
+			if lo, err = strconv.Atoi(rawLo); err != nil {
+				return false, fmt.Errorf("范围无效: %s", part)
+			}
+			if hi, err = strconv.Atoi(rawHi); err != nil {
+				return false, fmt.Errorf("范围无效: %s", part)
+			}
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return false, fmt.Errorf("取值无效: %s", part)
+			}
+			lo, hi = value, value
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return false, fmt.Errorf("取值超出范围 %d-%d: %s", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			set(v)
+		}
+	}
+	return wildcard, nil
+}
+
+func (s *exportCronSpec) dayMatches(t time.Time) bool {
+	domOK := s.dom[t.Day()]
+	dowOK := s.dow[int(t.Weekday())]
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowOK
+	case s.dowAny:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
+
+// next 返回严格晚于 after 的第一个匹配分钟，一年内无匹配（如 2 月 30 日）时返回 false
+func (s *exportCronSpec) next(after time.Time) (time.Time, bool) {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(1, 0, 1)
+	for t.Before(limit) {
+		if !s.month[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.hour[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !s.minute[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+func TestComputeExportScheduleNextRun(t *testing.T) {
+	after := time.Date(2026, 3, 4, 10, 30, 0, 0, time.Local) // 周三
+	cases := []struct {
+		schedule model.MessageExportScheduleModel
+		want     time.Time
+	}{
+		{model.MessageExportScheduleModel{Frequency: "daily", TimeOfDay: "09:15"}, time.Date(2026, 3, 5, 9, 15, 0, 0, time.Local)},
+		{model.MessageExportScheduleModel{Frequency: "daily", TimeOfDay: "23:00"}, time.Date(2026, 3, 4, 23, 0, 0, 0, time.Local)},
+		{model.MessageExportScheduleModel{Frequency: "weekly", TimeOfDay: "08:00", Weekday: 1}, time.Date(2026, 3, 9, 8, 0, 0, 0, time.Local)},
+		{model.MessageExportScheduleModel{Frequency: "cron", CronExpr: "*/20 9-18 * * 1-5"}, time.Date(2026, 3, 4, 10, 40, 0, 0, time.Local)},
+		{model.MessageExportScheduleModel{Frequency: "cron", CronExpr: "0 0 1 */2 *"}, time.Date(2026, 5, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tc := range cases {
+		got, err := ComputeExportScheduleNextRun(&tc.schedule, after)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.schedule.Frequency, tc.schedule.CronExpr, err)
+		}
+		if !got.Equal(tc.want) {
+			t.Fatalf("%s %s%s: got %v, want %v", tc.schedule.Frequency, tc.schedule.CronExpr, tc.schedule.TimeOfDay, got, tc.want)
+		}
+	}
+	for _, expr := range []string{"* * *", "61 * * * *", "0 0 30 2 *"} {
+		if _, err := ComputeExportScheduleNextRun(&model.MessageExportScheduleModel{Frequency: "cron", CronExpr: expr}, after); err == nil {
+			t.Fatalf("cron %q should be rejected", expr)
+		}
+	}
+	if _, err := normalizeExportScheduleSubdir("../escape"); err == nil {
+		t.Fatal("local target must not escape the scheduled dir")
+	}
+}
+
+func TestExportScheduleIncrementalRunDeliversToLocalDir(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	originalConfig := exportScheduleConfig
+	exportScheduleConfig = func() *utils.AppConfig { return &utils.AppConfig{} }
+	t.Cleanup(func() { exportScheduleConfig = originalConfig })
+
+	owner := createExternalGlossaryTestUser(t, "export-schedule-owner")
+	createExternalGlossaryTestUser(t, "export-schedule-member")
+	createExternalGlossaryTestWorld(t, "export-schedule-world", owner.ID)
+	createExternalGlossaryTestWorldMember(t, "export-schedule-world", owner.ID, model.WorldRoleOwner)
+	createExternalGlossaryTestWorldMember(t, "export-schedule-world", "export-schedule-member", model.WorldRoleMember)
+	db := model.GetDB()
+	if err := db.Create(&model.ChannelModel{
+		StringPKBaseModel: model.StringPKBaseModel{ID: "export-schedule-ch"},
+		Name:              "Schedule Channel",
+		PermType:          "public",
+		Status:            model.ChannelStatusActive,
+		WorldID:           "export-schedule-world",
+	}).Error; err != nil {
+		t.Fatalf("create channel failed: %v", err)
+	}
+	createMessage := func(id string, at time.Time) {
+		t.Helper()
+		if err := db.Create(&model.MessageModel{
+			StringPKBaseModel: model.StringPKBaseModel{ID: id, CreatedAt: at, UpdatedAt: at},
+			ChannelID:         "export-schedule-ch",
+			UserID:            owner.ID,
+			Content:           "定期导出 " + id,
+			DisplayOrder:      float64(at.UnixMilli()),
+			ICMode:            "ic",
+		}).Error; err != nil {
+			t.Fatalf("create message failed: %v", err)
+		}
+	}
+	createMessage("export-schedule-msg-1", time.Now().Add(-time.Hour))
+
+	input := ExportScheduleInput{
+		ScopeType:   model.MessageExportScheduleScopeChannel,
+		ScopeID:     "export-schedule-ch",
+		Name:        "每日团录",
+		Format:      "txt",
+		Frequency:   model.MessageExportScheduleFrequencyDaily,
+		TimeOfDay:   "04:00",
+		Targets:     []ExportDeliveryTarget{{Type: ExportDeliveryLocal, Path: "daily"}},
+		Incremental: true,
+		Enabled:     true,
+	}
+	if _, err := CreateExportSchedule("export-schedule-member", input); err == nil {
+		t.Fatal("plain world member should not manage export schedules")
+	}
+	schedule, err := CreateExportSchedule(owner.ID, input)
+	if err != nil {
+		t.Fatalf("create schedule failed: %v", err)
+	}
+
+	storageDir := t.TempDir()
+	runAndExport := func() *model.MessageExportScheduleRunModel {
+		t.Helper()
+		if _, err := TriggerExportSchedule(owner.ID, schedule.ID); err != nil {
+			t.Fatalf("trigger failed: %v", err)
+		}
+		if err := RunExportSchedulesOnce(context.Background(), storageDir, time.Now()); err != nil {
+			t.Fatalf("run schedules failed: %v", err)
+		}
+		var run model.MessageExportScheduleRunModel
+		db.Where("schedule_id = ?", schedule.ID).Order("created_at desc").Limit(1).Find(&run)
+		if run.JobID != "" {
+			job, err := GetMessageExportJob(run.JobID)
+			if err != nil {
+				t.Fatalf("load job failed: %v", err)
+			}
+			if err := processExportJob(job, MessageExportWorkerConfig{StorageDir: storageDir}); err != nil {
+				t.Fatalf("process job failed: %v", err)
+			}
+			if err := RunExportSchedulesOnce(context.Background(), storageDir, time.Now()); err != nil {
+				t.Fatalf("advance runs failed: %v", err)
+			}
+		}
+		db.Where("id = ?", run.ID).Limit(1).Find(&run)
+		return &run
+	}
+
+	first := runAndExport()
+	if first.Status != model.MessageExportScheduleRunSuccess || first.RangeStart != nil {
+		t.Fatalf("first run should export full history: %#v", first)
+	}
+	files, _ := os.ReadDir(filepath.Join(storageDir, "scheduled", "daily"))
+	if len(files) != 1 {
+		t.Fatalf("expected one delivered file, got %d", len(files))
+	}
+
+	second := runAndExport()
+	if second.Status != model.MessageExportScheduleRunSkipped || second.JobID != "" {
+		t.Fatalf("run without new messages should be skipped: %#v", second)
+	}
+
+	createMessage("export-schedule-msg-2", time.Now())
+	third := runAndExport()
+	if third.Status != model.MessageExportScheduleRunSuccess || third.RangeStart == nil {
+		t.Fatalf("third run should be incremental: %#v", third)
+	}
+	job, _ := GetMessageExportJob(third.JobID)
+	data, err := os.ReadFile(job.FilePath)
+	if err != nil {
+		t.Fatalf("read export failed: %v", err)
+	}
+	if content := string(data); !strings.Contains(content, "定期导出 export-schedule-msg-2") || strings.Contains(content, "定期导出 export-schedule-msg-1") {
+		t.Fatalf("incremental export should only contain the new message:\n%s", content)
+	}
+
+	runs, err := ListExportScheduleRuns(owner.ID, schedule.ID, 10)
+	if err != nil || len(runs) != 3 {
+		t.Fatalf("runs = %d err = %v, want 3", len(runs), err)
+	}
+}
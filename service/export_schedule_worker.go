@@ -0,0 +1,483 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"sealchat/model"
+	"sealchat/service/storage"
+	"sealchat/utils"
+)
+
+const (
+	exportScheduleInterval = 30 * time.Second
+	// exportScheduleEmailMaxBytes 超过该大小的导出文件不再作为邮件附件投递
+	exportScheduleEmailMaxBytes = 20 << 20
+)
+
+var (
+	exportScheduleWorkerOnce sync.Once
+	exportScheduleRunMu      sync.Mutex
+
+	// 测试中可替换
+	exportScheduleConfig = utils.GetConfig
+)
+
+// StartExportScheduleWorker 启动周期导出调度：到期计划创建导出任务，任务完成后逐个目标投递。
+func StartExportScheduleWorker(ctx context.Context, storageDir string) {
+	if storageDir == "" {
+		storageDir = "./data/exports"
+	}
+	exportScheduleWorkerOnce.Do(func() {
+		go runExportScheduleWorker(ctx, storageDir)
+	})
+}
+
+func runExportScheduleWorker(ctx context.Context, storageDir string) {
+	ticker := time.NewTicker(exportScheduleInterval)
+	defer ticker.Stop()
+	for {
+		if err := RunExportSchedulesOnce(ctx, storageDir, time.Now()); err != nil {
+			log.Printf("export-schedule: 调度失败: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunExportSchedulesOnce 先推进等待中的执行记录，再为到期计划创建新的导出任务
+func RunExportSchedulesOnce(ctx context.Context, storageDir string, now time.Time) error {
+	exportScheduleRunMu.Lock()
+	defer exportScheduleRunMu.Unlock()
+
+	if err := advanceExportScheduleRuns(ctx, storageDir); err != nil {
+		return err
+	}
+	var due []*model.MessageExportScheduleModel
+	if err := model.GetDB().
+		Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at asc").
+		Find(&due).Error; err != nil {
+		return err
+	}
+	for _, schedule := range due {
+		if err := startExportScheduleRun(schedule, now); err != nil {
+			log.Printf("export-schedule: 计划 %s 启动失败: %v", schedule.ID, err)
+		}
+	}
+	return nil
+}
+
+func startExportScheduleRun(schedule *model.MessageExportScheduleModel, now time.Time) error {
+	db := model.GetDB()
+	var running int64
+	if err := db.Model(&model.MessageExportScheduleRunModel{}).
+		Where("schedule_id = ? AND status = ?", schedule.ID, model.MessageExportScheduleRunRunning).
+		Count(&running).Error; err != nil {
+		return err
+	}
+	// 上一次还没投递完时顺延，避免增量区间重叠
+	if running > 0 {
+		return nil
+	}
+
+	next, err := ComputeExportScheduleNextRun(schedule, now)
+	if err != nil {
+		return finishExportScheduleRun(schedule, &model.MessageExportScheduleRunModel{ScheduleID: schedule.ID}, nil, err, nil)
+	}
+	if err := db.Model(&model.MessageExportScheduleModel{}).
+		Where("id = ?", schedule.ID).
+		Updates(map[string]any{"next_run_at": next, "last_run_at": now}).Error; err != nil {
+		return err
+	}
+	schedule.NextRunAt = &next
+	schedule.LastRunAt = &now
+
+	rangeEnd := now
+	run := &model.MessageExportScheduleRunModel{
+		ScheduleID: schedule.ID,
+		RangeEnd:   &rangeEnd,
+		Status:     model.MessageExportScheduleRunRunning,
+	}
+	if schedule.Incremental && schedule.LastRangeEnd != nil {
+		// 上次区间的终点已导出过，起点后移避免重复
+		rangeStart := schedule.LastRangeEnd.Add(time.Nanosecond)
+		run.RangeStart = &rangeStart
+	}
+	if err := db.Create(run).Error; err != nil {
+		return err
+	}
+
+	if _, err := ResolveExportScheduleScope(schedule.UserID, schedule.ScopeType, schedule.ScopeID); err != nil {
+		return finishExportScheduleRun(schedule, run, nil, fmt.Errorf("计划创建者已无导出权限: %w", err), nil)
+	}
+	channelIDs, err := resolveExportScheduleChannels(schedule)
+	if err != nil {
+		return finishExportScheduleRun(schedule, run, nil, err, nil)
+	}
+	if run.RangeStart != nil {
+		var count int64
+		if err := db.Model(&model.MessageModel{}).
+			Where("channel_id IN ?", channelIDs).
+			Where("is_revoked = ? AND is_deleted = ?", false, false).
+			Where("created_at >= ? AND created_at <= ?", *run.RangeStart, *run.RangeEnd).
+			Count(&count).Error; err != nil {
+			return finishExportScheduleRun(schedule, run, nil, err, nil)
+		}
+		if count == 0 {
+			return finishExportScheduleRun(schedule, run, nil, nil, nil)
+		}
+	}
+
+	displayName := schedule.DisplayName
+	if displayName == "" {
+		displayName = schedule.Name
+	}
+	opts := &ExportJobOptions{
+		UserID:             schedule.UserID,
+		ChannelID:          channelIDs[0],
+		Format:             schedule.Format,
+		DisplayName:        displayName,
+		IncludeOOC:         schedule.IncludeOOC,
+		IncludeArchived:    schedule.IncludeArchived,
+		IncludeImages:      schedule.IncludeImages,
+		IncludeDiceCommand: true,
+		WithoutTimestamp:   schedule.WithoutTimestamp,
+		MergeMessages:      schedule.MergeMessages,
+		StartTime:          run.RangeStart,
+		EndTime:            run.RangeEnd,
+	}
+	var job *model.MessageExportJobModel
+	if schedule.ScopeType == model.MessageExportScheduleScopeWorld {
+		job, err = CreateBatchMessageExportJob(opts, channelIDs)
+	} else {
+		job, err = CreateMessageExportJob(opts)
+	}
+	if err != nil {
+		return finishExportScheduleRun(schedule, run, nil, err, nil)
+	}
+	run.JobID = job.ID
+	if err := db.Model(run).Update("job_id", job.ID).Error; err != nil {
+		return err
+	}
+	return db.Model(schedule).Updates(map[string]any{
+		"last_status": model.MessageExportScheduleRunRunning,
+	}).Error
+}
+
+// resolveExportScheduleChannels 世界作用域导出世界内全部公开的活跃频道
+func resolveExportScheduleChannels(schedule *model.MessageExportScheduleModel) ([]string, error) {
+	if schedule.ScopeType != model.MessageExportScheduleScopeWorld {
+		return []string{schedule.ScopeID}, nil
+	}
+	var channelIDs []string
+	if err := model.GetDB().Model(&model.ChannelModel{}).
+		Where("world_id = ? AND is_private = ? AND status = ?", schedule.ScopeID, false, model.ChannelStatusActive).
+		Order("created_at asc").
+		Pluck("id", &channelIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(channelIDs) == 0 {
+		return nil, fmt.Errorf("世界内没有可导出的频道")
+	}
+	return channelIDs, nil
+}
+
+func advanceExportScheduleRuns(ctx context.Context, storageDir string) error {
+	db := model.GetDB()
+	var runs []*model.MessageExportScheduleRunModel
+	if err := db.Where("status = ? AND job_id <> ''", model.MessageExportScheduleRunRunning).
+		Order("created_at asc").
+		Find(&runs).Error; err != nil {
+		return err
+	}
+	for _, run := range runs {
+		var schedule model.MessageExportScheduleModel
+		if err := db.Where("id = ?", run.ScheduleID).Limit(1).Find(&schedule).Error; err != nil {
+			return err
+		}
+		if schedule.ID == "" {
+			continue
+		}
+		job, err := GetMessageExportJob(run.JobID)
+		if err != nil {
+			_ = finishExportScheduleRun(&schedule, run, nil, fmt.Errorf("导出任务丢失: %w", err), nil)
+			continue
+		}
+		switch job.Status {
+		case model.MessageExportStatusDone:
+			results, deliverErr := deliverExportScheduleJob(ctx, storageDir, &schedule, job)
+			_ = finishExportScheduleRun(&schedule, run, job, deliverErr, results)
+		case model.MessageExportStatusFailed:
+			_ = finishExportScheduleRun(&schedule, run, job, fmt.Errorf("导出失败: %s", job.ErrorMsg), nil)
+		}
+	}
+	return nil
+}
+
+// deliverExportScheduleJob 逐个目标投递，单个目标失败不影响其余目标
+func deliverExportScheduleJob(ctx context.Context, storageDir string, schedule *model.MessageExportScheduleModel, job *model.MessageExportJobModel) ([]ExportDeliveryResult, error) {
+	targets := ParseExportScheduleTargets(schedule.Targets)
+	results := make([]ExportDeliveryResult, 0, len(targets))
+	var failed []string
+	for _, target := range targets {
+		location, err := deliverExportScheduleTarget(ctx, storageDir, schedule, job, target)
+		result := ExportDeliveryResult{Type: target.Type, OK: err == nil, Location: location}
+		if err != nil {
+			result.Error = err.Error()
+			failed = append(failed, fmt.Sprintf("%s: %v", target.Type, err))
+		}
+		results = append(results, result)
+	}
+	if len(failed) > 0 {
+		return results, fmt.Errorf("投递失败: %s", strings.Join(failed, "; "))
+	}
+	return results, nil
+}
+
+func deliverExportScheduleTarget(ctx context.Context, storageDir string, schedule *model.MessageExportScheduleModel, job *model.MessageExportJobModel, target ExportDeliveryTarget) (string, error) {
+	fileName := ResolveExportDownloadFileName(job)
+	switch target.Type {
+	case ExportDeliveryLocal:
+		subdir, err := normalizeExportScheduleSubdir(target.Path)
+		if err != nil {
+			return "", err
+		}
+		if subdir == "" {
+			subdir = schedule.ID
+		}
+		dir := filepath.Join(storageDir, "scheduled", filepath.FromSlash(subdir))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+		dest := filepath.Join(dir, fileName)
+		if err := copyExportScheduleFile(job.FilePath, dest); err != nil {
+			return "", err
+		}
+		return dest, nil
+	case ExportDeliveryS3:
+		manager := GetStorageManager()
+		if manager == nil {
+			return "", fmt.Errorf("存储服务未初始化")
+		}
+		prefix := strings.Trim(target.Path, "/")
+		if prefix == "" {
+			prefix = "exports/scheduled"
+		}
+		result, err := manager.UploadToS3(ctx, storage.UploadInput{
+			ObjectKey:   path.Join(prefix, schedule.ID, fileName),
+			LocalPath:   job.FilePath,
+			ContentType: mime.TypeByExtension(filepath.Ext(fileName)),
+		})
+		if err != nil {
+			return "", err
+		}
+		if result.PublicURL != "" {
+			return result.PublicURL, nil
+		}
+		return result.ObjectKey, nil
+	case ExportDeliveryEmail:
+		if job.FileSize > exportScheduleEmailMaxBytes {
+			return "", fmt.Errorf("导出文件过大，无法作为邮件附件发送")
+		}
+		data, err := os.ReadFile(job.FilePath)
+		if err != nil {
+			return "", err
+		}
+		subject := fmt.Sprintf("[SealChat] 定期导出：%s", exportScheduleTitle(schedule))
+		body := fmt.Sprintf("<p>导出计划 <b>%s</b> 已生成新的导出文件，见附件。</p>", escapeHTML(exportScheduleTitle(schedule)))
+		err = exportScheduleEmailService().SendEmailWithAttachments(target.To, subject, body, []EmailAttachment{{
+			FileName:    fileName,
+			ContentType: mime.TypeByExtension(filepath.Ext(fileName)),
+			Data:        data,
+		}})
+		if err != nil {
+			return "", err
+		}
+		return target.To, nil
+	case ExportDeliveryLogUpload:
+		cfg := exportScheduleConfig()
+		if cfg == nil {
+			return "", fmt.Errorf("配置未加载")
+		}
+		opts := LogUploadOptions{
+			Name:           target.Name,
+			Endpoint:       cfg.LogUpload.Endpoint,
+			Endpoints:      cfg.LogUpload.Endpoints,
+			Token:          cfg.LogUpload.Token,
+			UniformID:      cfg.LogUpload.UniformID,
+			Client:         cfg.LogUpload.Client,
+			Version:        cfg.LogUpload.Version,
+			TimeoutSeconds: cfg.LogUpload.TimeoutSeconds,
+		}
+		if strings.EqualFold(job.Format, "zip") {
+			results, err := UploadBatchExportLogs(job, opts)
+			if err != nil {
+				return "", err
+			}
+			urls := make([]string, 0, len(results))
+			for _, result := range results {
+				urls = append(urls, result.URL)
+			}
+			return strings.Join(urls, " "), nil
+		}
+		result, err := UploadExportLog(job, opts)
+		if err != nil {
+			return "", err
+		}
+		return result.URL, nil
+	default:
+		return "", fmt.Errorf("不支持的投递方式: %s", target.Type)
+	}
+}
+
+// finishExportScheduleRun 落定执行结果；只有成功或无新消息时才推进增量水位，失败则累计次数并告警
+func finishExportScheduleRun(schedule *model.MessageExportScheduleModel, run *model.MessageExportScheduleRunModel, job *model.MessageExportJobModel, runErr error, results []ExportDeliveryResult) error {
+	db := model.GetDB()
+	now := time.Now()
+	status := model.MessageExportScheduleRunSuccess
+	switch {
+	case runErr != nil:
+		status = model.MessageExportScheduleRunFailed
+	case job == nil:
+		status = model.MessageExportScheduleRunSkipped
+	}
+	errMsg := ""
+	if runErr != nil {
+		errMsg = runErr.Error()
+	}
+	deliveries := ""
+	if len(results) > 0 {
+		if encoded, err := json.Marshal(results); err == nil {
+			deliveries = string(encoded)
+		}
+	}
+
+	run.Status = status
+	run.ErrorMsg = errMsg
+	run.Deliveries = deliveries
+	run.FinishedAt = &now
+	if run.ID == "" {
+		if err := db.Create(run).Error; err != nil {
+			return err
+		}
+	} else if err := db.Model(run).Updates(map[string]any{
+		"status":      status,
+		"error_msg":   errMsg,
+		"deliveries":  deliveries,
+		"finished_at": now,
+	}).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]any{
+		"last_status": status,
+		"last_error":  errMsg,
+	}
+	if runErr == nil {
+		updates["failure_count"] = 0
+		if run.RangeEnd != nil {
+			updates["last_range_end"] = *run.RangeEnd
+			schedule.LastRangeEnd = run.RangeEnd
+		}
+		schedule.FailureCount = 0
+	} else {
+		schedule.FailureCount++
+		updates["failure_count"] = schedule.FailureCount
+		if schedule.FailureCount >= exportScheduleMaxFailures {
+			updates["enabled"] = false
+			schedule.Enabled = false
+		}
+	}
+	schedule.LastStatus = status
+	schedule.LastError = errMsg
+	if err := db.Model(&model.MessageExportScheduleModel{}).Where("id = ?", schedule.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+	if runErr != nil {
+		sendExportScheduleAlert(schedule, runErr)
+	}
+	return nil
+}
+
+func sendExportScheduleAlert(schedule *model.MessageExportScheduleModel, runErr error) {
+	to := strings.TrimSpace(schedule.AlertEmail)
+	if to == "" {
+		if user := model.UserGet(schedule.UserID); user != nil && user.Email != nil {
+			to = strings.TrimSpace(*user.Email)
+		}
+	}
+	if to == "" {
+		log.Printf("export-schedule: 计划 %s 执行失败且无告警邮箱: %v", schedule.ID, runErr)
+		return
+	}
+	svc := exportScheduleEmailService()
+	if !svc.IsConfigured() {
+		log.Printf("export-schedule: 计划 %s 执行失败，SMTP 未配置无法告警: %v", schedule.ID, runErr)
+		return
+	}
+	title := exportScheduleTitle(schedule)
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("<p>导出计划 <b>%s</b> 执行失败（连续第 %d 次）。</p>", escapeHTML(title), schedule.FailureCount))
+	body.WriteString(fmt.Sprintf("<p>错误信息：%s</p>", escapeHTML(truncateContent(runErr.Error(), 500))))
+	if !schedule.Enabled {
+		body.WriteString(fmt.Sprintf("<p>连续失败已达 %d 次，计划已自动停用，修复后请重新启用。</p>", exportScheduleMaxFailures))
+	}
+	if err := svc.SendEmail(to, fmt.Sprintf("[SealChat] 导出计划执行失败：%s", title), body.String()); err != nil {
+		log.Printf("export-schedule: 发送告警邮件失败: %v", err)
+	}
+}
+
+func exportScheduleEmailService() *EmailService {
+	cfg := exportScheduleConfig()
+	if cfg == nil {
+		return NewEmailService(utils.SMTPConfig{})
+	}
+	return NewEmailService(cfg.EmailNotification.SMTP)
+}
+
+func exportScheduleTitle(schedule *model.MessageExportScheduleModel) string {
+	if schedule.Name != "" {
+		return schedule.Name
+	}
+	if schedule.DisplayName != "" {
+		return schedule.DisplayName
+	}
+	return schedule.ID
+}
+
+func copyExportScheduleFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".export-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := io.Copy(tmp, in); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dest)
+}
@@ -283,6 +283,9 @@ func GalleryAddEmojiFavorite(userID, attachmentID, remark string) (*model.Galler
 		StorageType: srcAtt.StorageType,
 		ObjectKey:   srcAtt.ObjectKey,
 		ExternalURL: srcAtt.ExternalURL,
+		Width:       srcAtt.Width,
+		Height:      srcAtt.Height,
+		ThumbHash:   srcAtt.ThumbHash,
 		RootID:      col.ID,
 		RootIDType:  "gallery_collection",
 		IsTemp:      false,
@@ -344,6 +347,9 @@ func GalleryAddEmojiReaction(userID, attachmentID, remark string) (*model.Galler
 		StorageType: srcAtt.StorageType,
 		ObjectKey:   srcAtt.ObjectKey,
 		ExternalURL: srcAtt.ExternalURL,
+		Width:       srcAtt.Width,
+		Height:      srcAtt.Height,
+		ThumbHash:   srcAtt.ThumbHash,
 		RootID:      col.ID,
 		RootIDType:  "gallery_collection",
 		IsTemp:      false,
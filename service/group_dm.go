@@ -0,0 +1,423 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mikespook/gorbac"
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/pm"
+	"sealchat/utils"
+)
+
+const (
+	groupDMMinMembers   = 3 // 含创建者
+	groupDMMaxMembers   = 50
+	groupDMNameMaxRunes = 64
+	groupDMRoleOwner    = "owner"
+	groupDMRoleMember   = "member"
+)
+
+var (
+	ErrGroupDMNotFound       = errors.New("多人私聊不存在")
+	ErrGroupDMPermission     = errors.New("无权操作该多人私聊")
+	ErrGroupDMTooFewMembers  = errors.New("多人私聊至少需要3人")
+	ErrGroupDMTooManyMembers = errors.New("多人私聊成员已达上限")
+	ErrGroupDMMemberInvalid  = errors.New("成员不存在或不可加入")
+	ErrGroupDMNameTooLong    = errors.New("多人私聊名称过长")
+)
+
+// GroupDMMember 多人私聊成员信息
+type GroupDMMember struct {
+	UserID   string    `json:"userId"`
+	Username string    `json:"username"`
+	Nickname string    `json:"nickname"`
+	Avatar   string    `json:"avatar"`
+	IsOwner  bool      `json:"isOwner"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+// GroupDMGet 获取多人私聊频道，已解散的视为不存在
+func GroupDMGet(channelID string) (*model.ChannelModel, error) {
+	ch, err := model.ChannelGet(strings.TrimSpace(channelID))
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil || ch.ID == "" || !ch.IsGroupDM() || ch.Status == model.ChannelStatusDeleted {
+		return nil, ErrGroupDMNotFound
+	}
+	return ch, nil
+}
+
+func IsGroupDMMember(channelID, userID string) bool {
+	var count int64
+	model.GetDB().Model(&model.MemberModel{}).Where("channel_id = ? AND user_id = ?", channelID, userID).Count(&count)
+	return count > 0
+}
+
+func GroupDMMemberIDs(channelID string) []string {
+	var ids []string
+	_ = model.GetDB().Model(&model.MemberModel{}).Where("channel_id = ?", channelID).
+		Order("created_at ASC").Pluck("user_id", &ids).Error
+	return ids
+}
+
+func normalizeGroupDMName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if len([]rune(name)) > groupDMNameMaxRunes {
+		return "", ErrGroupDMNameTooLong
+	}
+	return name, nil
+}
+
+// validateGroupDMInvitees 去重并校验被邀请人：必须是有效的非机器人用户，且允许邀请人向其发起私聊
+func validateGroupDMInvitees(actorID string, userIDs []string, existing map[string]struct{}) ([]*model.UserModel, error) {
+	seen := map[string]struct{}{}
+	users := make([]*model.UserModel, 0, len(userIDs))
+	for _, id := range userIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || id == actorID {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		if _, ok := existing[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		user := model.UserGet(id)
+		if user == nil || user.ID == "" || user.IsBot || user.Disabled {
+			return nil, ErrGroupDMMemberInvalid
+		}
+		if err := CheckDirectMessageAllowed(actorID, id); err != nil {
+			return nil, fmt.Errorf("%s: %w", user.Username, err)
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func groupDMRolePerms() map[string][]gorbac.Permission {
+	memberPerms := []gorbac.Permission{
+		pm.PermFuncChannelRead,
+		pm.PermFuncChannelTextSend,
+		pm.PermFuncChannelFileSend,
+		pm.PermFuncChannelAudioSend,
+		pm.PermFuncChannelInvite,
+		pm.PermFuncChannelMessagePin,
+	}
+	return map[string][]gorbac.Permission{
+		groupDMRoleOwner:  append(append([]gorbac.Permission{}, memberPerms...), pm.PermFuncChannelManageInfo),
+		groupDMRoleMember: memberPerms,
+	}
+}
+
+// createGroupDMRolesTx 在事务中写入角色与权限，提交成功后再由调用方注册到内存权限表
+func createGroupDMRolesTx(tx *gorm.DB, channelID string) error {
+	names := map[string]string{groupDMRoleOwner: "群主", groupDMRoleMember: "成员"}
+	for key, perms := range groupDMRolePerms() {
+		roleID := fmt.Sprintf("ch-%s-%s", channelID, key)
+		if err := tx.Create(&model.ChannelRoleModel{
+			StringPKBaseModel: model.StringPKBaseModel{ID: roleID},
+			Name:              names[key],
+			ChannelID:         channelID,
+		}).Error; err != nil {
+			return err
+		}
+		permIDs := make([]string, len(perms))
+		for i, perm := range perms {
+			permIDs[i] = perm.ID()
+		}
+		if err := model.RolePermissionBatchCreateTx(tx, roleID, permIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func registerGroupDMRoles(channelID string) {
+	for key, perms := range groupDMRolePerms() {
+		pm.ChannelRoleSetWithoutDB(fmt.Sprintf("ch-%s-%s", channelID, key), perms)
+	}
+}
+
+func addGroupDMMembersTx(tx *gorm.DB, channelID string, users []*model.UserModel, roleKey string) error {
+	userIDs := make([]string, 0, len(users))
+	for _, user := range users {
+		member := model.MemberModel{}
+		if err := tx.Where(model.MemberModel{UserID: user.ID, ChannelID: channelID}).
+			Attrs(model.MemberModel{Nickname: user.Nickname}).FirstOrCreate(&member).Error; err != nil {
+			return err
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+	roleID := fmt.Sprintf("ch-%s-%s", channelID, roleKey)
+	if _, err := model.UserRoleLinkTx(tx, []string{roleID}, userIDs); err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		if err := MaterializeSharedChannelIdentitiesForUserTx(tx, userID); err != nil {
+			return err
+		}
+	}
+	return model.ChannelReadInitInBatchesTx(tx, channelID, userIDs)
+}
+
+func addGroupDMMembers(channelID string, users []*model.UserModel, roleKey string) error {
+	return model.GetDB().Transaction(func(tx *gorm.DB) error {
+		return addGroupDMMembersTx(tx, channelID, users, roleKey)
+	})
+}
+
+// GroupDMCreate 创建多人私聊，创建者为群主
+func GroupDMCreate(ownerID string, memberIDs []string, name string) (*model.ChannelModel, error) {
+	owner := model.UserGet(ownerID)
+	if owner == nil || owner.ID == "" {
+		return nil, ErrGroupDMMemberInvalid
+	}
+	name, err := normalizeGroupDMName(name)
+	if err != nil {
+		return nil, err
+	}
+	invitees, err := validateGroupDMInvitees(ownerID, memberIDs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(invitees)+1 < groupDMMinMembers {
+		return nil, ErrGroupDMTooFewMembers
+	}
+	if len(invitees)+1 > groupDMMaxMembers {
+		return nil, ErrGroupDMTooManyMembers
+	}
+	ch := &model.ChannelModel{
+		StringPKBaseModel:  model.StringPKBaseModel{ID: utils.NewID()},
+		UserID:             ownerID,
+		Name:               name,
+		PermType:           model.ChannelPermTypeGroupDM,
+		Status:             model.ChannelStatusActive,
+		DefaultDiceExpr:    "d20",
+		BuiltInDiceEnabled: true,
+	}
+	// 频道、角色与成员同时写入，中途失败不会留下无人可见的空频道
+	err = model.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ch).Error; err != nil {
+			return err
+		}
+		if err := createGroupDMRolesTx(tx, ch.ID); err != nil {
+			return err
+		}
+		if err := addGroupDMMembersTx(tx, ch.ID, []*model.UserModel{owner}, groupDMRoleOwner); err != nil {
+			return err
+		}
+		return addGroupDMMembersTx(tx, ch.ID, invitees, groupDMRoleMember)
+	})
+	if err != nil {
+		return nil, err
+	}
+	registerGroupDMRoles(ch.ID)
+	return ch, nil
+}
+
+// GroupDMAddMembers 任意成员均可邀请新成员，仍需符合被邀请人的隐私设置
+func GroupDMAddMembers(channelID, actorID string, userIDs []string) ([]string, error) {
+	if _, err := GroupDMGet(channelID); err != nil {
+		return nil, err
+	}
+	if !IsGroupDMMember(channelID, actorID) {
+		return nil, ErrGroupDMPermission
+	}
+	existingIDs := GroupDMMemberIDs(channelID)
+	existing := make(map[string]struct{}, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = struct{}{}
+	}
+	invitees, err := validateGroupDMInvitees(actorID, userIDs, existing)
+	if err != nil {
+		return nil, err
+	}
+	if len(existingIDs)+len(invitees) > groupDMMaxMembers {
+		return nil, ErrGroupDMTooManyMembers
+	}
+	if err := addGroupDMMembers(channelID, invitees, groupDMRoleMember); err != nil {
+		return nil, err
+	}
+	added := make([]string, 0, len(invitees))
+	for _, user := range invitees {
+		added = append(added, user.ID)
+	}
+	return added, nil
+}
+
+func removeGroupDMMember(channelID, userID string) error {
+	if err := model.GetDB().Where("channel_id = ? AND user_id = ?", channelID, userID).Delete(&model.MemberModel{}).Error; err != nil {
+		return err
+	}
+	if err := removeChannelRoleLink(userID, channelID, groupDMRoleOwner); err != nil {
+		return err
+	}
+	return removeChannelRoleLink(userID, channelID, groupDMRoleMember)
+}
+
+// GroupDMRemoveMember 群主移出成员
+func GroupDMRemoveMember(channelID, actorID, targetID string) error {
+	ch, err := GroupDMGet(channelID)
+	if err != nil {
+		return err
+	}
+	if ch.UserID != actorID {
+		return ErrGroupDMPermission
+	}
+	if targetID == actorID || !IsGroupDMMember(channelID, targetID) {
+		return ErrGroupDMMemberInvalid
+	}
+	return removeGroupDMMember(channelID, targetID)
+}
+
+// GroupDMLeave 退出多人私聊；群主退出时转给最早加入的成员，最后一人退出后频道解散
+func GroupDMLeave(channelID, userID string) (*model.ChannelModel, error) {
+	ch, err := GroupDMGet(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if !IsGroupDMMember(channelID, userID) {
+		return nil, ErrGroupDMPermission
+	}
+	if err := removeGroupDMMember(channelID, userID); err != nil {
+		return nil, err
+	}
+	remaining := GroupDMMemberIDs(channelID)
+	if len(remaining) == 0 {
+		ch.Status = model.ChannelStatusDeleted
+		err := model.GetDB().Model(&model.ChannelModel{}).Where("id = ?", channelID).
+			Updates(map[string]any{"status": model.ChannelStatusDeleted, "updated_at": time.Now()}).Error
+		return ch, err
+	}
+	if ch.UserID == userID {
+		if err := setGroupDMOwner(ch, remaining[0]); err != nil {
+			return nil, err
+		}
+	}
+	return ch, nil
+}
+
+func setGroupDMOwner(ch *model.ChannelModel, newOwnerID string) error {
+	previousOwnerID := ch.UserID
+	if err := model.GetDB().Model(&model.ChannelModel{}).Where("id = ?", ch.ID).
+		Updates(map[string]any{"user_id": newOwnerID, "updated_at": time.Now()}).Error; err != nil {
+		return err
+	}
+	if err := removeChannelRoleLink(newOwnerID, ch.ID, groupDMRoleMember); err != nil {
+		return err
+	}
+	if err := ensureChannelRoleLink(newOwnerID, ch.ID, groupDMRoleOwner); err != nil {
+		return err
+	}
+	if previousOwnerID != "" && IsGroupDMMember(ch.ID, previousOwnerID) {
+		if err := removeChannelRoleLink(previousOwnerID, ch.ID, groupDMRoleOwner); err != nil {
+			return err
+		}
+		if err := ensureChannelRoleLink(previousOwnerID, ch.ID, groupDMRoleMember); err != nil {
+			return err
+		}
+	}
+	ch.UserID = newOwnerID
+	return nil
+}
+
+// GroupDMTransferOwner 群主将群主身份转给其他成员
+func GroupDMTransferOwner(channelID, actorID, targetID string) (*model.ChannelModel, error) {
+	ch, err := GroupDMGet(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch.UserID != actorID {
+		return nil, ErrGroupDMPermission
+	}
+	if targetID == actorID || !IsGroupDMMember(channelID, targetID) {
+		return nil, ErrGroupDMMemberInvalid
+	}
+	if err := setGroupDMOwner(ch, targetID); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// GroupDMUpdate 群主修改名称与头像，nil 表示不修改
+func GroupDMUpdate(channelID, actorID string, name, avatar *string) (*model.ChannelModel, error) {
+	ch, err := GroupDMGet(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch.UserID != actorID {
+		return nil, ErrGroupDMPermission
+	}
+	updates := map[string]any{}
+	if name != nil {
+		normalized, err := normalizeGroupDMName(*name)
+		if err != nil {
+			return nil, err
+		}
+		updates["name"] = normalized
+		ch.Name = normalized
+	}
+	if avatar != nil {
+		updates["avatar"] = strings.TrimSpace(*avatar)
+		ch.Avatar = strings.TrimSpace(*avatar)
+	}
+	if len(updates) == 0 {
+		return ch, nil
+	}
+	updates["updated_at"] = time.Now()
+	if err := model.GetDB().Model(&model.ChannelModel{}).Where("id = ?", channelID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// GroupDMList 返回用户所在的多人私聊
+func GroupDMList(userID string) ([]*model.ChannelModel, error) {
+	var items []*model.ChannelModel
+	err := model.GetDB().Model(&model.ChannelModel{}).
+		Joins("JOIN members ON members.channel_id = channels.id").
+		Where("members.user_id = ? AND channels.perm_type = ? AND channels.status <> ?", userID, model.ChannelPermTypeGroupDM, model.ChannelStatusDeleted).
+		Order("channels.recent_sent_at DESC").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		var count int64
+		model.GetDB().Model(&model.MemberModel{}).Where("channel_id = ?", item.ID).Count(&count)
+		item.MembersCount = int(count)
+	}
+	return items, nil
+}
+
+func GroupDMMembers(channelID, actorID string) ([]*GroupDMMember, error) {
+	ch, err := GroupDMGet(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if !IsGroupDMMember(channelID, actorID) {
+		return nil, ErrGroupDMPermission
+	}
+	var rows []*GroupDMMember
+	err = model.GetDB().Table("members").
+		Select("members.user_id AS user_id, users.username AS username, users.nickname AS nickname, users.avatar AS avatar, members.created_at AS joined_at").
+		Joins("LEFT JOIN users ON users.id = members.user_id").
+		Where("members.channel_id = ?", channelID).
+		Order("members.created_at ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		row.IsOwner = row.UserID == ch.UserID
+	}
+	return rows, nil
+}
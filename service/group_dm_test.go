@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"sealchat/model"
+	"sealchat/pm"
+)
+
+func TestGroupDMLifecycle(t *testing.T) {
+	initTestDB(t)
+	pm.Init()
+	ownerID := createWorldOwnershipTestUser(t, "gdm-owner", false)
+	aliceID := createWorldOwnershipTestUser(t, "gdm-alice", false)
+	bobID := createWorldOwnershipTestUser(t, "gdm-bob", false)
+	carolID := createWorldOwnershipTestUser(t, "gdm-carol", false)
+
+	if _, err := GroupDMCreate(ownerID, []string{aliceID, aliceID}, "小队"); !errors.Is(err, ErrGroupDMTooFewMembers) {
+		t.Fatalf("expected too few members, got %v", err)
+	}
+
+	ch, err := GroupDMCreate(ownerID, []string{aliceID, bobID}, "小队")
+	if err != nil {
+		t.Fatalf("create group dm failed: %v", err)
+	}
+	if !ch.IsGroupDM() || ch.UserID != ownerID {
+		t.Fatalf("unexpected channel: %+v", ch)
+	}
+	edit := *ch
+	edit.PermType = "public"
+	if err := model.ChannelInfoEdit(ch.ID, &edit); err != nil {
+		t.Fatalf("edit group dm info failed: %v", err)
+	}
+	if reloaded, err := GroupDMGet(ch.ID); err != nil || !reloaded.IsGroupDM() {
+		t.Fatalf("group dm must not become public: %v %+v", err, reloaded)
+	}
+	if got := len(GroupDMMemberIDs(ch.ID)); got != 3 {
+		t.Fatalf("expected 3 members, got %d", got)
+	}
+	if !pm.CanWithChannelRole(aliceID, ch.ID, pm.PermFuncChannelTextSend) {
+		t.Fatalf("member should be able to send")
+	}
+	if pm.CanWithChannelRole(carolID, ch.ID, pm.PermFuncChannelRead) {
+		t.Fatalf("non-member should not read")
+	}
+
+	if _, err := GroupDMAddMembers(ch.ID, aliceID, []string{carolID}); err != nil {
+		t.Fatalf("add member failed: %v", err)
+	}
+	if !IsGroupDMMember(ch.ID, carolID) {
+		t.Fatalf("carol should be a member")
+	}
+	if err := GroupDMRemoveMember(ch.ID, aliceID, carolID); !errors.Is(err, ErrGroupDMPermission) {
+		t.Fatalf("expected permission error, got %v", err)
+	}
+	if err := GroupDMRemoveMember(ch.ID, ownerID, carolID); err != nil {
+		t.Fatalf("remove member failed: %v", err)
+	}
+	if IsGroupDMMember(ch.ID, carolID) || pm.CanWithChannelRole(carolID, ch.ID, pm.PermFuncChannelRead) {
+		t.Fatalf("carol should be removed")
+	}
+
+	name := "新名字"
+	if _, err := GroupDMUpdate(ch.ID, aliceID, &name, nil); !errors.Is(err, ErrGroupDMPermission) {
+		t.Fatalf("expected permission error, got %v", err)
+	}
+	if _, err := GroupDMTransferOwner(ch.ID, ownerID, aliceID); err != nil {
+		t.Fatalf("transfer owner failed: %v", err)
+	}
+	updated, err := GroupDMUpdate(ch.ID, aliceID, &name, nil)
+	if err != nil || updated.Name != name {
+		t.Fatalf("update by new owner failed: %v", err)
+	}
+	if !pm.CanWithChannelRole(aliceID, ch.ID, pm.PermFuncChannelManageInfo) {
+		t.Fatalf("new owner should manage info")
+	}
+
+	items, err := GroupDMList(bobID)
+	if err != nil || len(items) != 1 || items[0].MembersCount != 3 {
+		t.Fatalf("unexpected list result: %v %+v", err, items)
+	}
+
+	if _, err := GroupDMLeave(ch.ID, aliceID); err != nil {
+		t.Fatalf("leave failed: %v", err)
+	}
+	reloaded, err := GroupDMGet(ch.ID)
+	if err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if reloaded.UserID != ownerID && reloaded.UserID != bobID {
+		t.Fatalf("ownership should pass to a remaining member, got %s", reloaded.UserID)
+	}
+	if _, err := GroupDMLeave(ch.ID, ownerID); err != nil {
+		t.Fatalf("leave failed: %v", err)
+	}
+	if _, err := GroupDMLeave(ch.ID, bobID); err != nil {
+		t.Fatalf("leave failed: %v", err)
+	}
+	if _, err := GroupDMGet(ch.ID); !errors.Is(err, ErrGroupDMNotFound) {
+		t.Fatalf("group dm should be dissolved, got %v", err)
+	}
+}
+
+func TestGroupDMRespectsBlocks(t *testing.T) {
+	initTestDB(t)
+	pm.Init()
+	ownerID := createWorldOwnershipTestUser(t, "gdm-owner", false)
+	aliceID := createWorldOwnershipTestUser(t, "gdm-alice", false)
+	bobID := createWorldOwnershipTestUser(t, "gdm-bob", false)
+	if _, err := UserBlock(bobID, ownerID); err != nil {
+		t.Fatalf("block failed: %v", err)
+	}
+	if _, err := GroupDMCreate(ownerID, []string{aliceID, bobID}, ""); !errors.Is(err, ErrUserBlocked) {
+		t.Fatalf("expected blocked error, got %v", err)
+	}
+	var count int64
+	model.GetDB().Model(&model.ChannelModel{}).Where("perm_type = ?", model.ChannelPermTypeGroupDM).Count(&count)
+	if count != 0 {
+		t.Fatalf("no channel should be created, got %d", count)
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/samber/lo"
+	"golang.org/x/crypto/blake2s"
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/service/storage"
+	"sealchat/utils"
+)
+
+// ImageSanitizeStats 存量图片元数据清理的统计
+type ImageSanitizeStats struct {
+	Total     int64 `json:"total"`     // 本批扫描数量
+	Pending   int64 `json:"pending"`   // 尚未清理的图片
+	Abandoned int64 `json:"abandoned"` // 文件缺失或损坏而放弃清理的图片
+	Completed int64 `json:"completed"` // 清理成功
+	Failed    int64 `json:"failed"`    // 清理失败
+	Skipped   int64 `json:"skipped"`   // 无元数据或不支持的存储
+	Rotated   int64 `json:"rotated"`   // 按方向重新绘制的图片
+}
+
+// ImageSanitizeItemResult 单个附件的清理结果
+type ImageSanitizeItemResult struct {
+	ID           string `json:"id"`
+	Filename     string `json:"filename"`
+	OriginalSize int64  `json:"originalSize"`
+	NewSize      int64  `json:"newSize"`
+	Success      bool   `json:"success"`
+	Rotated      bool   `json:"rotated,omitempty"`
+	Shared       int64  `json:"shared,omitempty"` // 共用同一文件而一并更新的附件记录数
+	Error        string `json:"error,omitempty"`
+	Skipped      bool   `json:"skipped"`
+	SkipReason   string `json:"skipReason,omitempty"`
+}
+
+// ImageMetadataStripEnabled 管理后台是否开启了上传时清除元数据
+func ImageMetadataStripEnabled() bool {
+	cfg := utils.GetConfig()
+	return cfg != nil && cfg.ImageStripMetadata
+}
+
+// sanitizeImageFileInPlace 清除临时文件中的图片元数据并重写文件；内容有变化时返回新的哈希与大小
+func sanitizeImageFileInPlace(path, mimeType string, newHash func() hash.Hash) ([]byte, int64, bool, error) {
+	if !ImageMetadataStripEnabled() || !utils.IsMetadataSanitizableImage(mimeType) {
+		return nil, 0, false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	result, err := utils.SanitizeImageMetadata(data)
+	if err != nil || !result.Changed {
+		// 结构无法解析时保留原文件，不阻断上传
+		return nil, 0, false, nil
+	}
+	if err := os.WriteFile(path, result.Data, 0644); err != nil {
+		return nil, 0, false, err
+	}
+	hasher := newHash()
+	hasher.Write(result.Data)
+	return hasher.Sum(nil), int64(len(result.Data)), true, nil
+}
+
+func imageSanitizePendingQuery(db *gorm.DB) *gorm.DB {
+	return db.Model(&model.AttachmentModel{}).
+		Where("id NOT IN (?)", theaterAttachmentScope(db).Select("id")).
+		Where("metadata_stripped = ? AND metadata_strip_failed = ?", false, false).
+		Where("storage_type = ? OR storage_type = ?", "local", "").
+		Where("mime_type IN ? OR filename LIKE ? OR filename LIKE ? OR filename LIKE ? OR filename LIKE ? OR filename LIKE ?",
+			[]string{"image/jpeg", "image/jpg", "image/png", "image/webp", "image/heic", "image/heif"},
+			"%.jpg", "%.jpeg", "%.png", "%.webp", "%.heic")
+}
+
+// GetImageSanitizePreview 统计尚未清理元数据的存量图片
+func GetImageSanitizePreview() (*ImageSanitizeStats, error) {
+	var pending, abandoned int64
+	if err := imageSanitizePendingQuery(model.GetDB()).Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if err := model.GetDB().Model(&model.AttachmentModel{}).Where("metadata_strip_failed = ?", true).Count(&abandoned).Error; err != nil {
+		return nil, err
+	}
+	return &ImageSanitizeStats{Pending: pending, Abandoned: abandoned}, nil
+}
+
+// SanitizeExistingImages 清理一批存量图片的元数据
+// batchSize: 本批处理数量（0 使用默认值）
+// dryRun: 只检测不写入
+func SanitizeExistingImages(batchSize int, dryRun bool) (*ImageSanitizeStats, []ImageSanitizeItemResult, error) {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	db := model.GetDB()
+	var pending int64
+	if err := imageSanitizePendingQuery(db).Count(&pending).Error; err != nil {
+		return nil, nil, err
+	}
+	var attachments []*model.AttachmentModel
+	if err := imageSanitizePendingQuery(db).Order("created_at asc").Limit(batchSize).Find(&attachments).Error; err != nil {
+		return nil, nil, err
+	}
+
+	cfg := utils.GetConfig()
+	stats := &ImageSanitizeStats{Total: int64(len(attachments)), Pending: pending}
+	results := make([]ImageSanitizeItemResult, 0, len(attachments))
+	for _, att := range attachments {
+		result := sanitizeOneAttachment(att, dryRun, cfg)
+		results = append(results, result)
+		switch {
+		case result.Skipped:
+			stats.Skipped++
+		case result.Success:
+			stats.Completed++
+			if result.Rotated {
+				stats.Rotated++
+			}
+		default:
+			stats.Failed++
+		}
+	}
+	return stats, results, nil
+}
+
+// sanitizeOneAttachment 清理单个附件；共用同一文件的附件记录会一并更新，旧文件在无人引用后删除
+func sanitizeOneAttachment(att *model.AttachmentModel, dryRun bool, cfg *utils.AppConfig) ImageSanitizeItemResult {
+	result := ImageSanitizeItemResult{
+		ID:           att.ID,
+		Filename:     att.Filename,
+		OriginalSize: att.Size,
+	}
+	markClean := func() {
+		if !dryRun {
+			_ = model.GetDB().Model(&model.AttachmentModel{}).Where("id = ?", att.ID).Update("metadata_stripped", true).Error
+		}
+	}
+	// 文件缺失或损坏重试也不会成功，标记后移出待处理队列，避免按时间排序的批次一直卡在这些记录上
+	markFailed := func(message string) ImageSanitizeItemResult {
+		result.Error = message
+		if !dryRun {
+			_ = model.GetDB().Model(&model.AttachmentModel{}).Where("id = ?", att.ID).Update("metadata_strip_failed", true).Error
+		}
+		return result
+	}
+
+	if att.StorageType == model.StorageS3 {
+		result.Skipped = true
+		result.SkipReason = "S3 storage not supported"
+		return result
+	}
+	filePath, err := resolveAttachmentFilePath(att, cfg)
+	if err != nil {
+		return markFailed(fmt.Sprintf("Cannot resolve path: %v", err))
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return markFailed(fmt.Sprintf("Cannot read file: %v", err))
+	}
+	sanitized, err := utils.SanitizeImageMetadata(data)
+	if err != nil {
+		return markFailed(fmt.Sprintf("Cannot parse image: %v", err))
+	}
+	if !sanitized.Changed {
+		result.Skipped = true
+		result.SkipReason = "No metadata"
+		markClean()
+		return result
+	}
+	result.NewSize = int64(len(sanitized.Data))
+	result.Rotated = sanitized.Rotated
+	if dryRun {
+		result.Success = true
+		return result
+	}
+
+	// 与上传路径一致使用 blake2s，去重按清理后的内容进行
+	hasher := lo.Must(blake2s.New256(nil))
+	hasher.Write(sanitized.Data)
+	newHash := hasher.Sum(nil)
+
+	manager := GetStorageManager()
+	if manager == nil {
+		result.Error = "Storage manager not initialized"
+		return result
+	}
+	tempDir := "./data/temp"
+	if cfg != nil && cfg.Storage.Local.TempDir != "" {
+		tempDir = cfg.Storage.Local.TempDir
+	}
+	_ = os.MkdirAll(tempDir, 0755)
+	tempPath := filepath.Join(tempDir, fmt.Sprintf("sanitize_%s_%d", att.ID, time.Now().UnixNano()))
+	if err := os.WriteFile(tempPath, sanitized.Data, 0644); err != nil {
+		result.Error = fmt.Sprintf("Cannot write temp file: %v", err)
+		return result
+	}
+	defer os.Remove(tempPath)
+
+	newObjectKey := storage.BuildAttachmentObjectKey(hex.EncodeToString(newHash), result.NewSize, time.Now())
+	uploadResult, err := manager.UploadAttachment(context.Background(), storage.UploadInput{
+		ObjectKey:   newObjectKey,
+		LocalPath:   tempPath,
+		ContentType: sanitized.MimeType,
+	})
+	if err != nil {
+		result.Error = fmt.Sprintf("Cannot upload new file: %v", err)
+		return result
+	}
+
+	oldObjectKey := att.ObjectKey
+	updates := map[string]any{
+		"hash":              newHash,
+		"size":              result.NewSize,
+		"object_key":        uploadResult.ObjectKey,
+		"metadata_stripped": true,
+	}
+	db := model.GetDB()
+	scope := db.Model(&model.AttachmentModel{}).Where("id = ?", att.ID)
+	if oldObjectKey != "" {
+		scope = db.Model(&model.AttachmentModel{}).
+			Where("object_key = ? AND (storage_type = ? OR storage_type = ?)", oldObjectKey, "local", "").
+			Where("id NOT IN (?)", theaterAttachmentScope(db).Select("id"))
+	}
+	tx := scope.Updates(updates)
+	if tx.Error != nil {
+		result.Error = fmt.Sprintf("Cannot update database: %v", tx.Error)
+		return result
+	}
+	if tx.RowsAffected > 1 {
+		result.Shared = tx.RowsAffected - 1
+	}
+
+	if oldObjectKey != "" && oldObjectKey != uploadResult.ObjectKey {
+		var remaining int64
+		if err := db.Model(&model.AttachmentModel{}).Where("object_key = ?", oldObjectKey).Count(&remaining).Error; err == nil && remaining == 0 {
+			if oldPath, _ := manager.ResolveLocalPath(oldObjectKey); oldPath != "" {
+				_ = os.Remove(oldPath)
+			}
+		}
+	}
+	result.Success = true
+	return result
+}
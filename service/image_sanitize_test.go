@@ -0,0 +1,116 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+func TestSanitizeExistingImagesUpdatesSharedAttachments(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	InitStorageManager(utils.StorageConfig{Mode: utils.StorageModeLocal, Local: utils.LocalStorageConfig{UploadDir: t.TempDir(), TempDir: t.TempDir()}})
+	createExternalGlossaryTestUser(t, "sanitize-user")
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, image.NewGray(image.Rect(0, 0, 3, 2)), nil); err != nil {
+		t.Fatalf("encode jpeg failed: %v", err)
+	}
+	exif := []byte("Exif\x00\x00II*\x00\x08\x00\x00\x00\x00\x00GPS 31.2304N")
+	data := append([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, byte(len(exif) + 2)}, exif...)
+	data = append(data, encoded.Bytes()[2:]...)
+
+	// 直接落盘，绕过上传时的清理，模拟开关开启前的存量附件
+	tempPath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		t.Fatalf("write temp file failed: %v", err)
+	}
+	hash := sha256.Sum256(data)
+	location, err := PersistAttachmentFile(hash[:], int64(len(data)), tempPath, "image/jpeg")
+	if err != nil {
+		t.Fatalf("persist attachment failed: %v", err)
+	}
+	source := &model.AttachmentModel{
+		Hash: hash[:], Filename: "photo.jpg", Size: int64(len(data)), MimeType: "image/jpeg", UserID: "sanitize-user",
+		StorageType: location.StorageType, ObjectKey: location.ObjectKey,
+	}
+	if tx, _ := model.AttachmentCreate(source); tx.Error != nil {
+		t.Fatalf("create attachment failed: %v", tx.Error)
+	}
+	copied := *source
+	copied.ID = ""
+	if tx, _ := model.AttachmentCreate(&copied); tx.Error != nil {
+		t.Fatalf("create shared attachment failed: %v", tx.Error)
+	}
+
+	stats, _, err := SanitizeExistingImages(10, true)
+	if err != nil || stats.Completed != 2 {
+		t.Fatalf("dry run should report both attachments, got %+v %v", stats, err)
+	}
+	var unchanged model.AttachmentModel
+	model.GetDB().Where("id = ?", source.ID).First(&unchanged)
+	if unchanged.MetadataStripped || unchanged.Size != source.Size {
+		t.Fatalf("dry run must not modify attachments")
+	}
+
+	stats, results, err := SanitizeExistingImages(10, false)
+	if err != nil || stats.Completed != 1 || results[0].Shared != 1 {
+		t.Fatalf("expected one sanitize covering the shared record, got %+v %+v %v", stats, results, err)
+	}
+	var items []model.AttachmentModel
+	model.GetDB().Where("id IN ?", []string{source.ID, copied.ID}).Find(&items)
+	for _, item := range items {
+		if !item.MetadataStripped || bytes.Equal(item.Hash, source.Hash) || item.ObjectKey != items[0].ObjectKey {
+			t.Fatalf("attachment %s not updated: %+v", item.ID, item)
+		}
+	}
+	path, err := MaterializeAttachmentToTempFile(&items[0])
+	if err != nil {
+		t.Fatalf("materialize failed: %v", err)
+	}
+	defer os.Remove(path)
+	content, _ := os.ReadFile(path)
+	if bytes.Contains(content, []byte("GPS")) || int64(len(content)) != items[0].Size {
+		t.Fatalf("stored file still has metadata or wrong size")
+	}
+	if preview, _ := GetImageSanitizePreview(); preview.Pending != 0 {
+		t.Fatalf("no attachments should remain pending, got %d", preview.Pending)
+	}
+}
+
+func TestSanitizeExistingImagesSkipsPastBrokenFiles(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	InitStorageManager(utils.StorageConfig{Mode: utils.StorageModeLocal, Local: utils.LocalStorageConfig{UploadDir: t.TempDir(), TempDir: t.TempDir()}})
+	createExternalGlossaryTestUser(t, "sanitize-broken")
+
+	missing := &model.AttachmentModel{
+		Hash: []byte("missing"), Filename: "missing.jpg", Size: 10, MimeType: "image/jpeg", UserID: "sanitize-broken",
+		StorageType: model.StorageLocal, ObjectKey: "attachments/missing.jpg",
+	}
+	if tx, _ := model.AttachmentCreate(missing); tx.Error != nil {
+		t.Fatalf("create attachment failed: %v", tx.Error)
+	}
+
+	if stats, _, err := SanitizeExistingImages(1, true); err != nil || stats.Failed != 1 {
+		t.Fatalf("dry run should report the broken file, got %+v %v", stats, err)
+	}
+	if preview, _ := GetImageSanitizePreview(); preview.Pending != 1 {
+		t.Fatalf("dry run must not mark failures, got %+v", preview)
+	}
+	if stats, _, err := SanitizeExistingImages(1, false); err != nil || stats.Failed != 1 {
+		t.Fatalf("expected the broken file to fail, got %+v %v", stats, err)
+	}
+	preview, _ := GetImageSanitizePreview()
+	if preview.Pending != 0 || preview.Abandoned != 1 {
+		t.Fatalf("broken file should leave the pending queue, got %+v", preview)
+	}
+	if stats, _, err := SanitizeExistingImages(1, false); err != nil || stats.Total != 0 {
+		t.Fatalf("next batch should not revisit the broken file, got %+v %v", stats, err)
+	}
+}
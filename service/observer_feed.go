@@ -0,0 +1,394 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sealchat/model"
+)
+
+const (
+	ObserverFeedDefaultLimit = 50
+	ObserverFeedMaxLimit     = 200
+)
+
+var ErrObserverFeedCursorInvalid = errors.New("分页游标无效")
+
+// ObserverFeedQuery 订阅源查询参数，Before 为上一页最后一条消息的 ID
+type ObserverFeedQuery struct {
+	Limit  int
+	Before string
+}
+
+// ObserverFeedLinks 订阅源中使用的绝对地址，由接口层根据请求拼装
+type ObserverFeedLinks struct {
+	Self    string
+	Home    string
+	NextURL func(cursor string) string
+}
+
+type ObserverFeedItem struct {
+	ID          string
+	MessageID   string
+	ChannelID   string
+	ChannelName string
+	SenderName  string
+	ContentText string
+	ContentHTML string
+	Published   time.Time
+	Updated     time.Time
+}
+
+type ObserverFeed struct {
+	ID          string
+	Title       string
+	Description string
+	Links       ObserverFeedLinks
+	Updated     time.Time
+	NextCursor  string
+	Items       []ObserverFeedItem
+}
+
+// LoadObserverFeed 按时间倒序读取一页场内消息，过滤规则与 OB 打印页“仅场内、隐藏归档”一致。
+// channel 为空时汇总世界内所有可旁观的频道。
+func LoadObserverFeed(world *model.WorldModel, channel *model.ChannelModel, query ObserverFeedQuery) (*ObserverFeed, error) {
+	if world == nil || strings.TrimSpace(world.ID) == "" {
+		return nil, ErrWorldNotFound
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = ObserverFeedDefaultLimit
+	}
+	if limit > ObserverFeedMaxLimit {
+		limit = ObserverFeedMaxLimit
+	}
+
+	feed := &ObserverFeed{
+		ID:          "urn:sealchat:ob:world:" + world.ID,
+		Title:       strings.TrimSpace(world.Name),
+		Description: strings.TrimSpace(world.Description),
+	}
+	var channels []*model.ChannelModel
+	if channel != nil {
+		channels = []*model.ChannelModel{channel}
+		feed.ID = "urn:sealchat:ob:channel:" + channel.ID
+		feed.Title = fmt.Sprintf("%s / %s", feed.Title, strings.TrimSpace(channel.Name))
+	} else {
+		var err error
+		if channels, err = ChannelListByWorld(world.ID); err != nil {
+			return nil, err
+		}
+	}
+	channelNames := map[string]string{}
+	channelIDs := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		if ch == nil || strings.TrimSpace(ch.ID) == "" {
+			continue
+		}
+		channelIDs = append(channelIDs, ch.ID)
+		channelNames[ch.ID] = strings.TrimSpace(ch.Name)
+	}
+	if len(channelIDs) == 0 {
+		feed.Updated = world.UpdatedAt
+		return feed, nil
+	}
+
+	q := applyObserverMessageFilters(model.GetDB().Model(&model.MessageModel{}), ObserverPrintOptions{MessageScope: 2}).
+		Where("channel_id IN ?", channelIDs)
+	if before := strings.TrimSpace(query.Before); before != "" {
+		var cursor model.MessageModel
+		if err := model.GetDB().Select("id", "created_at").
+			Where("id = ? AND channel_id IN ?", before, channelIDs).
+			Limit(1).Find(&cursor).Error; err != nil {
+			return nil, err
+		}
+		if cursor.ID == "" {
+			return nil, ErrObserverFeedCursorInvalid
+		}
+		q = q.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	var messages []*model.MessageModel
+	if err := q.Preload("User").Preload("Member").
+		Order("created_at desc").Order("id desc").
+		Limit(limit + 1).
+		Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		feed.NextCursor = messages[limit-1].ID
+	}
+
+	feed.Items = buildObserverFeedItems(messages, channelNames)
+	for _, item := range feed.Items {
+		if item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+	}
+	if feed.Updated.IsZero() {
+		feed.Updated = world.UpdatedAt
+	}
+	return feed, nil
+}
+
+func buildObserverFeedItems(messages []*model.MessageModel, channelNames map[string]string) []ObserverFeedItem {
+	// 导出渲染依赖频道内的角色与贴纸信息，需按频道分组处理后再按原顺序合并
+	grouped := map[string][]*model.MessageModel{}
+	for _, msg := range messages {
+		grouped[msg.ChannelID] = append(grouped[msg.ChannelID], msg)
+	}
+	rendered := map[string]ExportMessage{}
+	for channelID, group := range grouped {
+		job := &model.MessageExportJobModel{ChannelID: channelID}
+		payload := buildExportPayload(job, channelNames[channelID], group, nil, &exportExtraOptions{
+			IncludeImages:      false,
+			IncludeDiceCommand: true,
+		})
+		for _, msg := range payload.Messages {
+			rendered[msg.ID] = msg
+		}
+	}
+
+	items := make([]ObserverFeedItem, 0, len(messages))
+	for _, src := range messages {
+		msg, ok := rendered[src.ID]
+		if !ok {
+			continue
+		}
+		items = append(items, ObserverFeedItem{
+			ID:          "urn:sealchat:message:" + src.ID,
+			MessageID:   src.ID,
+			ChannelID:   src.ChannelID,
+			ChannelName: channelNames[src.ChannelID],
+			SenderName:  strings.TrimSpace(msg.SenderName),
+			ContentText: buildContentBody(&msg, false),
+			ContentHTML: msg.ContentHTML,
+			Published:   src.CreatedAt,
+			Updated:     observerFeedItemUpdated(src),
+		})
+	}
+	return items
+}
+
+// observerFeedItemUpdated 仅在消息被编辑过时使用 updated_at，避免其他字段变动导致订阅端重复提醒
+func observerFeedItemUpdated(msg *model.MessageModel) time.Time {
+	if msg.IsEdited && msg.UpdatedAt.After(msg.CreatedAt) {
+		return msg.UpdatedAt
+	}
+	return msg.CreatedAt
+}
+
+// applyObserverMessageFilters 旁观可见消息的公共过滤条件
+func applyObserverMessageFilters(q *gorm.DB, opts ObserverPrintOptions) *gorm.DB {
+	q = q.Where("is_revoked = ?", false).
+		Where("is_deleted = ?", false).
+		Where("is_whisper = ?", false)
+
+	switch opts.MessageScope {
+	case 1:
+		q = q.Where("ic_mode = ?", "ooc")
+	case 2:
+		q = q.Where("COALESCE(ic_mode, 'ic') = ?", "ic")
+	}
+
+	if !opts.ShowArchived {
+		q = q.Where("is_archived = ?", false)
+	}
+	return q
+}
+
+// ObserverFeedETag 根据本页条目及其更新时间生成弱校验值
+func ObserverFeedETag(feed *ObserverFeed, variant string) string {
+	h := sha1.New()
+	h.Write([]byte(variant))
+	h.Write([]byte{0})
+	h.Write([]byte(feed.ID))
+	h.Write([]byte{0})
+	h.Write([]byte(feed.Title))
+	h.Write([]byte{0})
+	h.Write([]byte(feed.NextCursor))
+	for _, item := range feed.Items {
+		h.Write([]byte{0})
+		h.Write([]byte(item.MessageID))
+		h.Write([]byte(strconv.FormatInt(item.Updated.UnixNano(), 10)))
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+func observerFeedItemTitle(item ObserverFeedItem) string {
+	text := []rune(strings.Join(strings.Fields(item.ContentText), " "))
+	if len(text) > 40 {
+		text = append(text[:40], '…')
+	}
+	title := item.SenderName
+	if title == "" {
+		title = "未知成员"
+	}
+	if len(text) > 0 {
+		title += "：" + string(text)
+	}
+	return title
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string        `xml:"id"`
+	Title     string        `xml:"title"`
+	Published string        `xml:"published"`
+	Updated   string        `xml:"updated"`
+	Author    atomPerson    `xml:"author"`
+	Category  *atomCategory `xml:"category,omitempty"`
+	Links     []atomLink    `xml:"link,omitempty"`
+	Content   atomText      `xml:"content"`
+}
+
+// RenderObserverFeedAtom 输出 Atom 1.0 订阅源
+func RenderObserverFeedAtom(feed *ObserverFeed) ([]byte, error) {
+	if feed == nil {
+		return nil, fmt.Errorf("observer feed is nil")
+	}
+	doc := atomFeed{
+		Xmlns:    "http://www.w3.org/2005/Atom",
+		ID:       feed.ID,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  feed.Updated.UTC().Format(time.RFC3339),
+	}
+	if feed.Links.Self != "" {
+		doc.Links = append(doc.Links, atomLink{Rel: "self", Type: "application/atom+xml", Href: feed.Links.Self})
+	}
+	if feed.Links.Home != "" {
+		doc.Links = append(doc.Links, atomLink{Rel: "alternate", Type: "text/html", Href: feed.Links.Home})
+	}
+	if feed.NextCursor != "" && feed.Links.NextURL != nil {
+		doc.Links = append(doc.Links, atomLink{Rel: "next", Type: "application/atom+xml", Href: feed.Links.NextURL(feed.NextCursor)})
+	}
+	for _, item := range feed.Items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     observerFeedItemTitle(item),
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+			Author:    atomPerson{Name: item.SenderName},
+			Content:   atomText{Type: "html", Body: item.ContentHTML},
+		}
+		if entry.Content.Body == "" {
+			entry.Content = atomText{Type: "text", Body: item.ContentText}
+		}
+		if item.ChannelID != "" {
+			entry.Category = &atomCategory{Term: item.ChannelID, Label: item.ChannelName}
+		}
+		if feed.Links.Home != "" {
+			entry.Links = append(entry.Links, atomLink{Rel: "alternate", Type: "text/html", Href: feed.Links.Home})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	ContentText   string           `json:"content_text,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	NextURL     string         `json:"next_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// RenderObserverFeedJSON 输出 JSON Feed 1.1 订阅源
+func RenderObserverFeedJSON(feed *ObserverFeed) ([]byte, error) {
+	if feed == nil {
+		return nil, fmt.Errorf("observer feed is nil")
+	}
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		Description: feed.Description,
+		HomePageURL: feed.Links.Home,
+		FeedURL:     feed.Links.Self,
+		Items:       make([]jsonFeedItem, 0, len(feed.Items)),
+	}
+	if feed.NextCursor != "" && feed.Links.NextURL != nil {
+		doc.NextURL = feed.Links.NextURL(feed.NextCursor)
+	}
+	for _, item := range feed.Items {
+		entry := jsonFeedItem{
+			ID:            item.ID,
+			URL:           feed.Links.Home,
+			Title:         observerFeedItemTitle(item),
+			ContentHTML:   item.ContentHTML,
+			ContentText:   item.ContentText,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+			DateModified:  item.Updated.UTC().Format(time.RFC3339),
+		}
+		if item.SenderName != "" {
+			entry.Authors = []jsonFeedAuthor{{Name: item.SenderName}}
+		}
+		if item.ChannelName != "" {
+			entry.Tags = []string{item.ChannelName}
+		}
+		doc.Items = append(doc.Items, entry)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"sealchat/model"
+)
+
+func TestObserverFeedItemUpdated(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	msg := &model.MessageModel{}
+	msg.CreatedAt = created
+	msg.UpdatedAt = created.Add(time.Hour)
+	if got := observerFeedItemUpdated(msg); !got.Equal(created) {
+		t.Fatalf("unedited message should keep created time, got %v", got)
+	}
+	msg.IsEdited = true
+	if got := observerFeedItemUpdated(msg); !got.Equal(msg.UpdatedAt) {
+		t.Fatalf("edited message should use updated time, got %v", got)
+	}
+}
+
+func TestRenderObserverFeed(t *testing.T) {
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	feed := &ObserverFeed{
+		ID:         "urn:sealchat:ob:world:w1",
+		Title:      "世界",
+		Updated:    published.Add(time.Minute),
+		NextCursor: "m1",
+		Links: ObserverFeedLinks{
+			Self:    "https://example.com/api/v1/public/ob/demo/feed.atom",
+			Home:    "https://example.com/#/ob/demo",
+			NextURL: func(cursor string) string { return "https://example.com/next?before=" + cursor },
+		},
+		Items: []ObserverFeedItem{{
+			ID:          "urn:sealchat:message:m1",
+			MessageID:   "m1",
+			ChannelName: "主线",
+			SenderName:  "阿尔法",
+			ContentText: "推开了门",
+			ContentHTML: "<p>推开了门</p>",
+			Published:   published,
+			Updated:     published.Add(time.Minute),
+		}},
+	}
+
+	atom, err := RenderObserverFeedAtom(feed)
+	if err != nil {
+		t.Fatalf("render atom failed: %v", err)
+	}
+	var parsed atomFeed
+	if err := xml.Unmarshal(atom, &parsed); err != nil {
+		t.Fatalf("atom output is not valid xml: %v", err)
+	}
+	if len(parsed.Entries) != 1 || parsed.Entries[0].ID != "urn:sealchat:message:m1" || parsed.Entries[0].Updated != "2024-05-01T12:01:00Z" {
+		t.Fatalf("unexpected atom entries: %+v", parsed.Entries)
+	}
+	if !strings.Contains(string(atom), `rel="next"`) || !strings.Contains(string(atom), "&lt;p&gt;") {
+		t.Fatalf("expected next link and escaped html content:\n%s", atom)
+	}
+
+	raw, err := RenderObserverFeedJSON(feed)
+	if err != nil {
+		t.Fatalf("render json feed failed: %v", err)
+	}
+	var doc jsonFeed
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("json feed output invalid: %v", err)
+	}
+	if doc.NextURL != "https://example.com/next?before=m1" || len(doc.Items) != 1 || doc.Items[0].DateModified != "2024-05-01T12:01:00Z" {
+		t.Fatalf("unexpected json feed: %+v", doc)
+	}
+
+	if ObserverFeedETag(feed, "atom") == ObserverFeedETag(feed, "json") {
+		t.Fatalf("etag should differ between formats")
+	}
+	before := ObserverFeedETag(feed, "atom")
+	feed.Items[0].Updated = feed.Items[0].Updated.Add(time.Second)
+	if ObserverFeedETag(feed, "atom") == before {
+		t.Fatalf("etag should change after edit")
+	}
+}
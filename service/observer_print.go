@@ -52,22 +52,9 @@ func LoadObserverPrintableMessages(channelID string, opts ObserverPrintOptions)
 
 	q := model.GetDB().Model(&model.MessageModel{}).
 		Where("channel_id = ?", channelID).
-		Where("is_revoked = ?", false).
-		Where("is_deleted = ?", false).
-		Where("is_whisper = ?", false).
 		Preload("User").
 		Preload("Member")
-
-	switch opts.MessageScope {
-	case 1:
-		q = q.Where("ic_mode = ?", "ooc")
-	case 2:
-		q = q.Where("COALESCE(ic_mode, 'ic') = ?", "ic")
-	}
-
-	if !opts.ShowArchived {
-		q = q.Where("is_archived = ?", false)
-	}
+	q = applyObserverMessageFilters(q, opts)
 
 	q = q.Order("display_order asc").Order("created_at asc")
 
@@ -0,0 +1,509 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"sealchat/model"
+	"sealchat/pm"
+)
+
+const (
+	reportReasonMaxRunes   = 1000
+	reportFeedbackMaxRunes = 500
+	reportPendingPerUser   = 20
+	reportDefaultMuteMins  = 60
+)
+
+var (
+	ErrReportNotFound        = errors.New("举报不存在")
+	ErrReportPermission      = errors.New("无权处理该举报")
+	ErrReportTargetInvalid   = errors.New("举报对象不存在或不可见")
+	ErrReportSelf            = errors.New("不能举报自己")
+	ErrReportCategoryInvalid = errors.New("举报类型无效")
+	ErrReportReasonTooLong   = errors.New("举报说明过长")
+	ErrReportDuplicate       = errors.New("你已举报过该内容，请等待处理")
+	ErrReportTooMany         = errors.New("待处理的举报过多，请稍后再试")
+	ErrReportResolved        = errors.New("举报已处理")
+	ErrReportActionInvalid   = errors.New("处理方式无效")
+)
+
+// ReportMessageRemover 由 api 层注入，复用消息删除与广播流程
+var ReportMessageRemover = func(operatorID, channelID, messageID string) error {
+	return errors.New("消息删除未就绪")
+}
+
+type ReportCreateInput struct {
+	TargetType string `json:"targetType"`
+	TargetID   string `json:"targetId"`
+	Category   string `json:"category"`
+	Reason     string `json:"reason"`
+	WorldID    string `json:"worldId"` // 举报用户时可指定所在世界
+}
+
+type ReportResolveInput struct {
+	Action      string `json:"action"`
+	Note        string `json:"note"`
+	Feedback    string `json:"feedback"`
+	MuteMinutes int    `json:"muteMinutes"`
+}
+
+// ReportReporterView 举报人可见的处理进度，不包含处理人与内部备注
+type ReportReporterView struct {
+	ID         string     `json:"id"`
+	WorldID    string     `json:"worldId"`
+	TargetType string     `json:"targetType"`
+	TargetID   string     `json:"targetId"`
+	Category   string     `json:"category"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	Feedback   string     `json:"feedback"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	HandledAt  *time.Time `json:"handledAt,omitempty"`
+}
+
+func normalizeReportCategory(value string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case model.ReportCategorySpam:
+		return model.ReportCategorySpam, true
+	case model.ReportCategoryHarassment:
+		return model.ReportCategoryHarassment, true
+	case model.ReportCategoryNSFW:
+		return model.ReportCategoryNSFW, true
+	case model.ReportCategoryIllegal:
+		return model.ReportCategoryIllegal, true
+	case model.ReportCategoryImpersonation:
+		return model.ReportCategoryImpersonation, true
+	case "", model.ReportCategoryOther:
+		return model.ReportCategoryOther, true
+	}
+	return "", false
+}
+
+func canSeeMessageForReport(userID string, msg *model.MessageModel) bool {
+	if !CanReadChannelByUserId(userID, msg.ChannelID) {
+		return false
+	}
+	if !msg.IsWhisper || msg.UserID == userID || msg.WhisperTo == userID {
+		return true
+	}
+	for _, id := range model.GetWhisperRecipientIDs(msg.ID) {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func buildMessageReportEvidence(reporterID, messageID string) (*model.ReportModel, error) {
+	var msg model.MessageModel
+	if err := model.GetDB().Preload("User").Where("id = ?", messageID).Limit(1).Find(&msg).Error; err != nil {
+		return nil, err
+	}
+	if msg.ID == "" || msg.IsDeleted || msg.IsRevoked || !canSeeMessageForReport(reporterID, &msg) {
+		return nil, ErrReportTargetInvalid
+	}
+	if msg.UserID == reporterID {
+		return nil, ErrReportSelf
+	}
+	return snapshotMessageReport(&msg), nil
+}
+
+// snapshotMessageReport 以消息当前内容生成带证据快照的举报记录
+func snapshotMessageReport(msg *model.MessageModel) *model.ReportModel {
+	evidence := &model.ReportEvidence{
+		MessageID:        msg.ID,
+		ChannelID:        msg.ChannelID,
+		Content:          msg.Content,
+		ICMode:           msg.ICMode,
+		IsWhisper:        msg.IsWhisper,
+		SenderUserID:     msg.UserID,
+		SenderMemberName: msg.SenderMemberName,
+		IdentityID:       msg.SenderIdentityID,
+		IdentityName:     msg.SenderIdentityName,
+		IdentityAvatarID: msg.SenderIdentityAvatarID,
+		CreatedAt:        msg.CreatedAt,
+		CapturedAt:       time.Now(),
+	}
+	if msg.User != nil {
+		evidence.SenderUsername = msg.User.Username
+		evidence.SenderNickname = msg.User.Nickname
+	}
+	report := &model.ReportModel{TargetUserID: msg.UserID}
+	if ch, err := model.ChannelGet(msg.ChannelID); err == nil && ch != nil {
+		evidence.ChannelName = ch.Name
+		report.WorldID = ch.WorldID
+	}
+	report.SetEvidence(evidence)
+	return report
+}
+
+func buildIdentityReportEvidence(reporterID, identityID string) (*model.ReportModel, error) {
+	identity, err := model.ChannelIdentityGetByID(identityID)
+	if err != nil || identity == nil || identity.ID == "" || !CanReadChannelByUserId(reporterID, identity.ChannelID) {
+		return nil, ErrReportTargetInvalid
+	}
+	if identity.UserID == reporterID {
+		return nil, ErrReportSelf
+	}
+	evidence := &model.ReportEvidence{
+		ChannelID:        identity.ChannelID,
+		SenderUserID:     identity.UserID,
+		IdentityID:       identity.ID,
+		IdentityName:     identity.DisplayName,
+		IdentityAvatarID: identity.AvatarAttachmentID,
+		CreatedAt:        identity.CreatedAt,
+		CapturedAt:       time.Now(),
+	}
+	if user := model.UserGet(identity.UserID); user != nil {
+		evidence.SenderUsername = user.Username
+		evidence.SenderNickname = user.Nickname
+	}
+	report := &model.ReportModel{TargetUserID: identity.UserID}
+	if ch, err := model.ChannelGet(identity.ChannelID); err == nil && ch != nil {
+		evidence.ChannelName = ch.Name
+		report.WorldID = ch.WorldID
+	}
+	report.SetEvidence(evidence)
+	return report, nil
+}
+
+func buildUserReportEvidence(reporterID, userID, worldID string) (*model.ReportModel, error) {
+	if userID == reporterID {
+		return nil, ErrReportSelf
+	}
+	user := model.UserGet(userID)
+	if user == nil || user.ID == "" {
+		return nil, ErrReportTargetInvalid
+	}
+	report := &model.ReportModel{TargetUserID: user.ID}
+	worldID = strings.TrimSpace(worldID)
+	if worldID != "" && IsWorldMember(worldID, reporterID) && IsWorldMember(worldID, user.ID) {
+		report.WorldID = worldID
+	}
+	report.SetEvidence(&model.ReportEvidence{
+		SenderUserID:   user.ID,
+		SenderUsername: user.Username,
+		SenderNickname: user.Nickname,
+		UserAvatar:     user.Avatar,
+		UserBrief:      user.Brief,
+		CapturedAt:     time.Now(),
+	})
+	return report, nil
+}
+
+// ReportCreate 提交举报并保存内容快照
+func ReportCreate(reporterID string, input ReportCreateInput) (*model.ReportModel, error) {
+	category, ok := normalizeReportCategory(input.Category)
+	if !ok {
+		return nil, ErrReportCategoryInvalid
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if len([]rune(reason)) > reportReasonMaxRunes {
+		return nil, ErrReportReasonTooLong
+	}
+	targetID := strings.TrimSpace(input.TargetID)
+	if targetID == "" {
+		return nil, ErrReportTargetInvalid
+	}
+	targetType := strings.TrimSpace(input.TargetType)
+	var report *model.ReportModel
+	var err error
+	switch targetType {
+	case model.ReportTargetMessage:
+		report, err = buildMessageReportEvidence(reporterID, targetID)
+	case model.ReportTargetIdentity:
+		report, err = buildIdentityReportEvidence(reporterID, targetID)
+	case model.ReportTargetUser:
+		report, err = buildUserReportEvidence(reporterID, targetID, input.WorldID)
+	default:
+		return nil, ErrReportTargetInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	db := model.GetDB()
+	var count int64
+	if err := db.Model(&model.ReportModel{}).
+		Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status = ?", reporterID, targetType, targetID, model.ReportStatusPending).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrReportDuplicate
+	}
+	if err := db.Model(&model.ReportModel{}).
+		Where("reporter_id = ? AND status = ?", reporterID, model.ReportStatusPending).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= reportPendingPerUser {
+		return nil, ErrReportTooMany
+	}
+
+	report.ReporterID = reporterID
+	report.TargetType = targetType
+	report.TargetID = targetID
+	report.Category = category
+	report.Reason = reason
+	report.Status = model.ReportStatusPending
+	if err := db.Create(report).Error; err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func isReportSystemAdmin(userID string) bool {
+	return pm.CanWithSystemRole(userID, pm.PermModAdmin)
+}
+
+// ReportCanHandle 平台管理员可处理全部举报，世界管理员仅能处理本世界举报
+func ReportCanHandle(report *model.ReportModel, userID string) bool {
+	if report == nil {
+		return false
+	}
+	if isReportSystemAdmin(userID) {
+		return true
+	}
+	return report.WorldID != "" && IsWorldAdmin(report.WorldID, userID)
+}
+
+func ReportGet(reportID, actorID string) (*model.ReportModel, error) {
+	var report model.ReportModel
+	if err := model.GetDB().Where("id = ?", strings.TrimSpace(reportID)).Limit(1).Find(&report).Error; err != nil {
+		return nil, err
+	}
+	if report.ID == "" {
+		return nil, ErrReportNotFound
+	}
+	if !ReportCanHandle(&report, actorID) {
+		return nil, ErrReportPermission
+	}
+	return &report, nil
+}
+
+func normalizeReportStatusFilter(status string) string {
+	switch strings.TrimSpace(status) {
+	case model.ReportStatusPending, model.ReportStatusResolved, model.ReportStatusDismissed:
+		return strings.TrimSpace(status)
+	case "all":
+		return ""
+	}
+	return model.ReportStatusPending
+}
+
+func listReports(worldID string, global bool, status string, page, pageSize int) ([]*model.ReportModel, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	query := model.GetDB().Model(&model.ReportModel{})
+	if !global || worldID != "" {
+		query = query.Where("world_id = ?", worldID)
+	}
+	if status = normalizeReportStatusFilter(status); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var items []*model.ReportModel
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// ReportListByWorld 世界管理员的举报队列
+func ReportListByWorld(worldID, actorID, status string, page, pageSize int) ([]*model.ReportModel, int64, error) {
+	worldID = strings.TrimSpace(worldID)
+	if worldID == "" {
+		return nil, 0, ErrWorldNotFound
+	}
+	if !IsWorldAdmin(worldID, actorID) && !isReportSystemAdmin(actorID) {
+		return nil, 0, ErrReportPermission
+	}
+	return listReports(worldID, false, status, page, pageSize)
+}
+
+// ReportListGlobal 平台管理员的全局队列，worldID 为空时包含全部举报
+func ReportListGlobal(actorID, worldID, status string, page, pageSize int) ([]*model.ReportModel, int64, error) {
+	if !isReportSystemAdmin(actorID) {
+		return nil, 0, ErrReportPermission
+	}
+	return listReports(strings.TrimSpace(worldID), true, status, page, pageSize)
+}
+
+func ReportListMine(reporterID string) ([]*ReportReporterView, error) {
+	var items []*model.ReportModel
+	if err := model.GetDB().Where("reporter_id = ?", reporterID).Order("created_at DESC").Limit(100).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*ReportReporterView, 0, len(items))
+	for _, item := range items {
+		result = append(result, &ReportReporterView{
+			ID:         item.ID,
+			WorldID:    item.WorldID,
+			TargetType: item.TargetType,
+			TargetID:   item.TargetID,
+			Category:   item.Category,
+			Reason:     item.Reason,
+			Status:     item.Status,
+			Feedback:   item.Feedback,
+			CreatedAt:  item.CreatedAt,
+			HandledAt:  item.HandledAt,
+		})
+	}
+	return result, nil
+}
+
+func applyReportAction(report *model.ReportModel, actorID string, input ReportResolveInput) error {
+	switch input.Action {
+	case model.ReportActionDismiss:
+		return nil
+	case model.ReportActionDeleteMessage:
+		if report.TargetType != model.ReportTargetMessage {
+			return ErrReportActionInvalid
+		}
+		evidence := report.GetEvidence()
+		if evidence == nil || evidence.ChannelID == "" {
+			return ErrReportTargetInvalid
+		}
+		return ReportMessageRemover(actorID, evidence.ChannelID, report.TargetID)
+	case model.ReportActionMute:
+		if report.WorldID == "" || report.TargetUserID == "" {
+			return ErrReportActionInvalid
+		}
+		minutes := input.MuteMinutes
+		if minutes <= 0 {
+			minutes = reportDefaultMuteMins
+		}
+		_, err := WorldMuteMember(report.WorldID, actorID, report.TargetUserID, time.Duration(minutes)*time.Minute)
+		if errors.Is(err, ErrWorldPermission) && isReportSystemAdmin(actorID) {
+			// 平台管理员不一定是世界成员，以世界拥有者身份执行
+			if world, getErr := GetWorldByID(report.WorldID); getErr == nil && world != nil {
+				_, err = WorldMuteMember(report.WorldID, world.OwnerID, report.TargetUserID, time.Duration(minutes)*time.Minute)
+			}
+		}
+		return err
+	case model.ReportActionRemoveMember:
+		if report.WorldID == "" || report.TargetUserID == "" {
+			return ErrReportActionInvalid
+		}
+		err := WorldRemoveMember(report.WorldID, actorID, report.TargetUserID)
+		if errors.Is(err, ErrWorldPermission) && isReportSystemAdmin(actorID) {
+			if world, getErr := GetWorldByID(report.WorldID); getErr == nil && world != nil {
+				err = WorldRemoveMember(report.WorldID, world.OwnerID, report.TargetUserID)
+			}
+		}
+		return err
+	case model.ReportActionDisableUser:
+		if !isReportSystemAdmin(actorID) || report.TargetUserID == "" {
+			return ErrReportPermission
+		}
+		return model.UserSetDisable(report.TargetUserID, true)
+	}
+	return ErrReportActionInvalid
+}
+
+// ReportResolve 先以待处理状态为条件认领同一目标的全部待处理举报，认领成功后再执行处理动作，
+// 避免多名管理员同时处理时重复禁言或删除；动作失败时退回待处理
+func ReportResolve(reportID, actorID string, input ReportResolveInput) ([]*model.ReportModel, error) {
+	report, err := ReportGet(reportID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != model.ReportStatusPending {
+		return nil, ErrReportResolved
+	}
+	input.Action = strings.TrimSpace(input.Action)
+	input.Feedback = strings.TrimSpace(input.Feedback)
+	input.Note = strings.TrimSpace(input.Note)
+	if len([]rune(input.Feedback)) > reportFeedbackMaxRunes || len([]rune(input.Note)) > reportReasonMaxRunes {
+		return nil, ErrReportReasonTooLong
+	}
+	status := model.ReportStatusResolved
+	if input.Action == model.ReportActionDismiss {
+		status = model.ReportStatusDismissed
+	}
+	now := time.Now()
+	db := model.GetDB()
+	var related []*model.ReportModel
+	if err := db.Where("target_type = ? AND target_id = ? AND world_id = ? AND status = ?",
+		report.TargetType, report.TargetID, report.WorldID, model.ReportStatusPending).Find(&related).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(related))
+	includesReport := false
+	for _, item := range related {
+		ids = append(ids, item.ID)
+		includesReport = includesReport || item.ID == report.ID
+		item.Status = status
+		item.Action = input.Action
+		item.HandlerID = actorID
+		item.HandlerNote = input.Note
+		item.Feedback = input.Feedback
+		item.HandledAt = &now
+	}
+	if !includesReport {
+		return nil, ErrReportResolved
+	}
+	claim := db.Model(&model.ReportModel{}).Where("id IN ? AND status = ?", ids, model.ReportStatusPending).Updates(map[string]any{
+		"status":       status,
+		"action":       input.Action,
+		"handler_id":   actorID,
+		"handler_note": input.Note,
+		"feedback":     input.Feedback,
+		"handled_at":   now,
+		"updated_at":   now,
+	})
+	if claim.Error != nil {
+		return nil, claim.Error
+	}
+	release := func() error {
+		return db.Model(&model.ReportModel{}).Where("id IN ? AND status = ? AND handler_id = ?", ids, status, actorID).Updates(map[string]any{
+			"status":       model.ReportStatusPending,
+			"action":       "",
+			"handler_id":   "",
+			"handler_note": "",
+			"feedback":     "",
+			"handled_at":   nil,
+		}).Error
+	}
+	if claim.RowsAffected != int64(len(ids)) {
+		// 部分举报已被其他管理员认领，交还本次认领的部分
+		if claim.RowsAffected > 0 {
+			_ = release()
+		}
+		return nil, ErrReportResolved
+	}
+	if err := applyReportAction(report, actorID, input); err != nil {
+		if releaseErr := release(); releaseErr != nil {
+			return nil, releaseErr
+		}
+		return nil, err
+	}
+	return related, nil
+}
+
+// ReportReviewerIDs 返回需要收到新举报提醒的管理员；不属于世界的举报提醒平台管理员
+func ReportReviewerIDs(report *model.ReportModel) []string {
+	if report == nil {
+		return nil
+	}
+	var ids []string
+	if report.WorldID == "" {
+		// 私聊与多人私聊不属于任何世界，交由平台管理员处理
+		ids, _ = model.UserRoleMappingUserIdListByRoleId("sys-admin")
+		return ids
+	}
+	_ = model.GetDB().Model(&model.WorldMemberModel{}).
+		Where("world_id = ? AND role IN ?", report.WorldID, []string{model.WorldRoleOwner, model.WorldRoleAdmin}).
+		Pluck("user_id", &ids).Error
+	return ids
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mikespook/gorbac"
+
+	"sealchat/model"
+	"sealchat/pm"
+	"sealchat/utils"
+)
+
+func createReportTestChannel(t *testing.T, worldID string, memberIDs ...string) string {
+	t.Helper()
+	channelID := utils.NewID()
+	model.ChannelPublicNew(channelID, &model.ChannelModel{WorldID: worldID, Name: "举报测试", PermType: "public", Status: model.ChannelStatusActive}, memberIDs[0])
+	roleCreate(channelID, "member", "成员", func(roleId string) []gorbac.Permission {
+		return []gorbac.Permission{pm.PermFuncChannelRead, pm.PermFuncChannelTextSend}
+	})
+	for _, userID := range memberIDs {
+		if err := ensureChannelRoleLink(userID, channelID, "member"); err != nil {
+			t.Fatalf("link role failed: %v", err)
+		}
+	}
+	return channelID
+}
+
+func createReportTestMessage(t *testing.T, channelID, userID, content string) string {
+	t.Helper()
+	msg := &model.MessageModel{ChannelID: channelID, UserID: userID, Content: content}
+	msg.ID = utils.NewID()
+	if err := model.GetDB().Create(msg).Error; err != nil {
+		t.Fatalf("create message failed: %v", err)
+	}
+	return msg.ID
+}
+
+func TestReportMessageKeepsEvidence(t *testing.T) {
+	initTestDB(t)
+	pm.Init()
+	ownerID := createWorldOwnershipTestUser(t, "report-owner", false)
+	reporterID := createWorldOwnershipTestUser(t, "report-reporter", false)
+	offenderID := createWorldOwnershipTestUser(t, "report-offender", false)
+	outsiderID := createWorldOwnershipTestUser(t, "report-outsider", false)
+	worldID := createWorldOwnershipTestWorld(t, ownerID, reporterID, offenderID)
+	channelID := createReportTestChannel(t, worldID, ownerID, reporterID, offenderID)
+	messageID := createReportTestMessage(t, channelID, offenderID, "违规内容")
+
+	if _, err := ReportCreate(outsiderID, ReportCreateInput{TargetType: model.ReportTargetMessage, TargetID: messageID}); !errors.Is(err, ErrReportTargetInvalid) {
+		t.Fatalf("outsider should not report invisible message, got %v", err)
+	}
+	if _, err := ReportCreate(offenderID, ReportCreateInput{TargetType: model.ReportTargetMessage, TargetID: messageID}); !errors.Is(err, ErrReportSelf) {
+		t.Fatalf("expected self report error, got %v", err)
+	}
+	report, err := ReportCreate(reporterID, ReportCreateInput{
+		TargetType: model.ReportTargetMessage, TargetID: messageID, Category: model.ReportCategoryHarassment, Reason: "骚扰",
+	})
+	if err != nil {
+		t.Fatalf("create report failed: %v", err)
+	}
+	if report.WorldID != worldID || report.TargetUserID != offenderID {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if _, err := ReportCreate(reporterID, ReportCreateInput{TargetType: model.ReportTargetMessage, TargetID: messageID}); !errors.Is(err, ErrReportDuplicate) {
+		t.Fatalf("expected duplicate error, got %v", err)
+	}
+
+	model.GetDB().Model(&model.MessageModel{}).Where("id = ?", messageID).Update("content", "已编辑")
+	loaded, err := ReportGet(report.ID, ownerID)
+	if err != nil {
+		t.Fatalf("owner should see report: %v", err)
+	}
+	if evidence := loaded.GetEvidence(); evidence == nil || evidence.Content != "违规内容" {
+		t.Fatalf("evidence should keep original content: %+v", evidence)
+	}
+	if _, err := ReportGet(report.ID, offenderID); !errors.Is(err, ErrReportPermission) {
+		t.Fatalf("member should not see report, got %v", err)
+	}
+
+	items, total, err := ReportListByWorld(worldID, ownerID, "", 1, 20)
+	if err != nil || total != 1 || len(items) != 1 {
+		t.Fatalf("unexpected queue: %v %d", err, total)
+	}
+
+	var removed string
+	prevRemover := ReportMessageRemover
+	ReportMessageRemover = func(operatorID, channelID, messageID string) error {
+		removed = messageID
+		return nil
+	}
+	defer func() { ReportMessageRemover = prevRemover }()
+	resolved, err := ReportResolve(report.ID, ownerID, ReportResolveInput{Action: model.ReportActionDeleteMessage, Feedback: "已删除"})
+	if err != nil || len(resolved) != 1 {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if removed != messageID {
+		t.Fatalf("message remover not called")
+	}
+	mine, err := ReportListMine(reporterID)
+	if err != nil || len(mine) != 1 || mine[0].Status != model.ReportStatusResolved || mine[0].Feedback != "已删除" {
+		t.Fatalf("unexpected reporter view: %v %+v", err, mine)
+	}
+	if _, err := ReportResolve(report.ID, ownerID, ReportResolveInput{Action: model.ReportActionDismiss}); !errors.Is(err, ErrReportResolved) {
+		t.Fatalf("expected resolved error, got %v", err)
+	}
+}
+
+func TestReportMuteAction(t *testing.T) {
+	initTestDB(t)
+	pm.Init()
+	ownerID := createWorldOwnershipTestUser(t, "report-owner", false)
+	reporterID := createWorldOwnershipTestUser(t, "report-reporter", false)
+	offenderID := createWorldOwnershipTestUser(t, "report-offender", false)
+	worldID := createWorldOwnershipTestWorld(t, ownerID, reporterID, offenderID)
+	channelID := createReportTestChannel(t, worldID, ownerID, reporterID, offenderID)
+
+	report, err := ReportCreate(reporterID, ReportCreateInput{TargetType: model.ReportTargetUser, TargetID: offenderID, WorldID: worldID})
+	if err != nil {
+		t.Fatalf("create report failed: %v", err)
+	}
+	if _, err := ReportResolve(report.ID, ownerID, ReportResolveInput{Action: model.ReportActionDisableUser}); !errors.Is(err, ErrReportPermission) {
+		t.Fatalf("world owner should not disable users, got %v", err)
+	}
+	if _, err := ReportResolve(report.ID, ownerID, ReportResolveInput{Action: model.ReportActionMute, MuteMinutes: 30}); err != nil {
+		t.Fatalf("mute failed: %v", err)
+	}
+	if err := CheckChannelSendMuted(channelID, offenderID); !errors.Is(err, ErrWorldMemberMuted) {
+		t.Fatalf("offender should be muted, got %v", err)
+	}
+	if err := CheckChannelSendMuted(channelID, reporterID); err != nil {
+		t.Fatalf("reporter should not be muted, got %v", err)
+	}
+	if _, err := WorldMuteMember(worldID, ownerID, offenderID, 0); err != nil {
+		t.Fatalf("unmute failed: %v", err)
+	}
+	if err := CheckChannelSendMuted(channelID, offenderID); err != nil {
+		t.Fatalf("offender should be unmuted, got %v", err)
+	}
+}
+
+func TestReportResolveAppliesActionOnce(t *testing.T) {
+	initTestDB(t)
+	pm.Init()
+	ownerID := createWorldOwnershipTestUser(t, "report-owner", false)
+	reporterID := createWorldOwnershipTestUser(t, "report-reporter", false)
+	offenderID := createWorldOwnershipTestUser(t, "report-offender", false)
+	worldID := createWorldOwnershipTestWorld(t, ownerID, reporterID, offenderID)
+	channelID := createReportTestChannel(t, worldID, ownerID, reporterID, offenderID)
+	messageID := createReportTestMessage(t, channelID, offenderID, "违规内容")
+	report, err := ReportCreate(reporterID, ReportCreateInput{TargetType: model.ReportTargetMessage, TargetID: messageID})
+	if err != nil {
+		t.Fatalf("create report failed: %v", err)
+	}
+
+	var calls atomic.Int32
+	prevRemover := ReportMessageRemover
+	ReportMessageRemover = func(operatorID, channelID, messageID string) error {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+	defer func() { ReportMessageRemover = prevRemover }()
+
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ReportResolve(report.ID, ownerID, ReportResolveInput{Action: model.ReportActionDeleteMessage}); err == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 || succeeded.Load() != 1 {
+		t.Fatalf("action should run once, got calls=%d succeeded=%d", calls.Load(), succeeded.Load())
+	}
+}
+
+func TestReportReviewerIDsRoutesWorldlessReportsToSystemAdmins(t *testing.T) {
+	initTestDB(t)
+	pm.Init()
+	adminID := createWorldOwnershipTestUser(t, "report-sysadmin", false)
+	if _, err := model.UserRoleLink([]string{"sys-admin"}, []string{adminID}); err != nil {
+		t.Fatalf("grant sys-admin failed: %v", err)
+	}
+	ids := ReportReviewerIDs(&model.ReportModel{TargetType: model.ReportTargetMessage})
+	if len(ids) != 1 || ids[0] != adminID {
+		t.Fatalf("worldless report should notify system admins, got %v", ids)
+	}
+}
@@ -264,6 +264,7 @@ func processDataURLToAttachment(dataURL, userID, channelID string, cfg SatoriAtt
 
 	// Create attachment record
 	filename := generateFilename(hash, size, finalMime)
+	placeholder := ComputeImagePlaceholderData(finalData, finalMime)
 	_, newItem := model.AttachmentCreate(&model.AttachmentModel{
 		Filename:    filename,
 		Size:        size,
@@ -275,6 +276,9 @@ func processDataURLToAttachment(dataURL, userID, channelID string, cfg SatoriAtt
 		StorageType: location.StorageType,
 		ObjectKey:   location.ObjectKey,
 		ExternalURL: location.ExternalURL,
+		Width:       placeholder.Width,
+		Height:      placeholder.Height,
+		ThumbHash:   placeholder.ThumbHash,
 	})
 
 	return newItem.ID, nil
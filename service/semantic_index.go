@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"sealchat/model"
+	aiService "sealchat/service/ai"
+	"sealchat/service/vectorindex"
+	"sealchat/utils"
+)
+
+const (
+	semanticIndexStateFile   = "state.json"
+	semanticIndexMaxRounds   = 20
+	semanticIndexMaxTextRune = 2000
+)
+
+var ErrSemanticSearchUnavailable = errors.New("语义搜索未开启")
+
+// semanticIndexState 增量索引的进度；模型或维度变化时整库重建
+type semanticIndexState struct {
+	Model       string    `json:"model"`
+	Dimensions  int       `json:"dimensions"`
+	WatermarkAt time.Time `json:"watermarkAt"`
+	WatermarkID string    `json:"watermarkId"`
+}
+
+type SemanticIndexStatus struct {
+	Enabled     bool      `json:"enabled"`
+	Model       string    `json:"model"`
+	WatermarkAt time.Time `json:"watermarkAt"`
+	LastRunAt   time.Time `json:"lastRunAt"`
+	LastError   string    `json:"lastError"`
+	Backfilling []string  `json:"backfilling"`
+}
+
+var semanticIndex = struct {
+	mu          sync.Mutex
+	store       *vectorindex.Store
+	state       semanticIndexState
+	lastRunAt   time.Time
+	lastError   string
+	backfilling map[string]struct{}
+}{backfilling: map[string]struct{}{}}
+
+// semanticIndexRunMu 串行化增量索引与频道回填，二者都会改写分片
+var semanticIndexRunMu sync.Mutex
+
+var semanticIndexWorkerOnce sync.Once
+
+var (
+	semanticIndexConfig            = utils.GetConfig
+	semanticEmbeddingClientFactory = aiService.NewEmbeddingClient
+)
+
+func StartSemanticIndexWorker(ctx context.Context) {
+	semanticIndexWorkerOnce.Do(func() {
+		go runSemanticIndexWorker(ctx)
+	})
+}
+
+func runSemanticIndexWorker(ctx context.Context) {
+	for {
+		interval := 30 * time.Second
+		if cfg := semanticIndexConfig(); cfg != nil {
+			embedding := utils.NormalizeAIConfig(cfg.AI).Embedding
+			interval = time.Duration(embedding.IntervalSec) * time.Second
+			if embedding.Enabled {
+				if _, err := RunSemanticIndexOnce(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					log.Printf("semantic-index: 增量索引失败: %v", err)
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// RunSemanticIndexOnce 按 updated_at 水位线拉取变更消息并写入向量索引；首次运行即为全量回填
+func RunSemanticIndexOnce(ctx context.Context) (int, error) {
+	semanticIndexRunMu.Lock()
+	defer semanticIndexRunMu.Unlock()
+
+	cfg, client, store, err := prepareSemanticIndex()
+	if err != nil {
+		return 0, err
+	}
+	state := currentSemanticIndexState()
+	indexed := 0
+	for round := 0; round < semanticIndexMaxRounds; round++ {
+		var messages []*model.MessageModel
+		query := model.GetDB().Model(&model.MessageModel{}).
+			Select("id, channel_id, content, is_deleted, is_revoked, updated_at, sender_identity_name, sender_member_name")
+		if !state.WatermarkAt.IsZero() {
+			query = query.Where("updated_at > ? OR (updated_at = ? AND id > ?)", state.WatermarkAt, state.WatermarkAt, state.WatermarkID)
+		}
+		if err := query.Order("updated_at asc").Order("id asc").Limit(cfg.Embedding.BatchSize).Find(&messages).Error; err != nil {
+			return indexed, recordSemanticIndexRun(err)
+		}
+		if len(messages) == 0 {
+			break
+		}
+		if err := embedSemanticIndexMessages(ctx, cfg, client, store, messages); err != nil {
+			return indexed, recordSemanticIndexRun(err)
+		}
+		last := messages[len(messages)-1]
+		state.WatermarkAt = last.UpdatedAt
+		state.WatermarkID = last.ID
+		if err := store.Flush(); err != nil {
+			return indexed, recordSemanticIndexRun(err)
+		}
+		if err := saveSemanticIndexState(store.Dir(), state); err != nil {
+			return indexed, recordSemanticIndexRun(err)
+		}
+		indexed += len(messages)
+		if len(messages) < cfg.Embedding.BatchSize {
+			break
+		}
+	}
+	return indexed, recordSemanticIndexRun(nil)
+}
+
+// SemanticIndexBackfillChannel 清空频道分片后按消息顺序重新生成向量，用于修复或补建单个频道
+func SemanticIndexBackfillChannel(ctx context.Context, channelID string) error {
+	channelID = strings.TrimSpace(channelID)
+	semanticIndex.mu.Lock()
+	if _, running := semanticIndex.backfilling[channelID]; running {
+		semanticIndex.mu.Unlock()
+		return errors.New("该频道正在回填")
+	}
+	semanticIndex.backfilling[channelID] = struct{}{}
+	semanticIndex.mu.Unlock()
+	defer func() {
+		semanticIndex.mu.Lock()
+		delete(semanticIndex.backfilling, channelID)
+		semanticIndex.mu.Unlock()
+	}()
+
+	semanticIndexRunMu.Lock()
+	defer semanticIndexRunMu.Unlock()
+	cfg, client, store, err := prepareSemanticIndex()
+	if err != nil {
+		return err
+	}
+	if err := store.Reset(channelID); err != nil {
+		return err
+	}
+	lastID := ""
+	for {
+		var messages []*model.MessageModel
+		err := model.GetDB().Model(&model.MessageModel{}).
+			Select("id, channel_id, content, is_deleted, is_revoked, updated_at, sender_identity_name, sender_member_name").
+			Where("channel_id = ? AND id > ?", channelID, lastID).
+			Where("is_deleted = ? AND is_revoked = ?", false, false).
+			Order("id asc").
+			Limit(cfg.Embedding.BatchSize).
+			Find(&messages).Error
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			break
+		}
+		if err := embedSemanticIndexMessages(ctx, cfg, client, store, messages); err != nil {
+			return err
+		}
+		lastID = messages[len(messages)-1].ID
+		if len(messages) < cfg.Embedding.BatchSize {
+			break
+		}
+	}
+	return store.Flush()
+}
+
+// SemanticSearchChannel 把查询文本向量化后在频道分片内检索；可见性过滤由调用方完成
+func SemanticSearchChannel(ctx context.Context, channelID string, text string, limit int) ([]vectorindex.Hit, error) {
+	cfg, client, store, err := prepareSemanticIndex()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.Embed(ctx, aiService.EmbeddingRequest{
+		Model:      cfg.Embedding.Model,
+		Inputs:     []string{strings.TrimSpace(text)},
+		Dimensions: cfg.Embedding.Dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Vectors) == 0 {
+		return nil, errors.New("empty embedding")
+	}
+	return store.Search(channelID, result.Vectors[0], limit)
+}
+
+func SemanticSearchEnabled() bool {
+	cfg := semanticIndexConfig()
+	return cfg != nil && cfg.AI.Embedding.Enabled
+}
+
+func GetSemanticIndexStatus() SemanticIndexStatus {
+	status := SemanticIndexStatus{Enabled: SemanticSearchEnabled()}
+	semanticIndex.mu.Lock()
+	defer semanticIndex.mu.Unlock()
+	status.Model = semanticIndex.state.Model
+	status.WatermarkAt = semanticIndex.state.WatermarkAt
+	status.LastRunAt = semanticIndex.lastRunAt
+	status.LastError = semanticIndex.lastError
+	status.Backfilling = make([]string, 0, len(semanticIndex.backfilling))
+	for channelID := range semanticIndex.backfilling {
+		status.Backfilling = append(status.Backfilling, channelID)
+	}
+	return status
+}
+
+func embedSemanticIndexMessages(ctx context.Context, cfg utils.AIConfig, client aiService.EmbeddingClient, store *vectorindex.Store, messages []*model.MessageModel) error {
+	pending := make([]*model.MessageModel, 0, len(messages))
+	inputs := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		if strings.TrimSpace(msg.ChannelID) == "" {
+			continue
+		}
+		text := ""
+		if !msg.IsDeleted && !msg.IsRevoked {
+			text = semanticIndexMessageText(msg)
+		}
+		if text == "" {
+			if err := store.Delete(msg.ChannelID, msg.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		pending = append(pending, msg)
+		inputs = append(inputs, text)
+	}
+	if len(inputs) == 0 {
+		return nil
+	}
+	result, err := client.Embed(ctx, aiService.EmbeddingRequest{
+		Model:      cfg.Embedding.Model,
+		Inputs:     inputs,
+		Dimensions: cfg.Embedding.Dimensions,
+	})
+	if err != nil {
+		return err
+	}
+	if len(result.Vectors) != len(pending) {
+		return errors.New("embedding count mismatch")
+	}
+	for i, msg := range pending {
+		if err := store.Upsert(msg.ChannelID, msg.ID, result.Vectors[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func semanticIndexMessageText(msg *model.MessageModel) string {
+	content := strings.TrimSpace(buildFilteredPlainContent(msg.Content, false))
+	if content == "" {
+		return ""
+	}
+	if runes := []rune(content); len(runes) > semanticIndexMaxTextRune {
+		content = string(runes[:semanticIndexMaxTextRune])
+	}
+	name := strings.TrimSpace(msg.SenderIdentityName)
+	if name == "" {
+		name = strings.TrimSpace(msg.SenderMemberName)
+	}
+	if name == "" {
+		return content
+	}
+	return name + "：" + content
+}
+
+// prepareSemanticIndex 按当前配置打开索引目录；模型或维度与已有索引不一致时清空重建
+func prepareSemanticIndex() (utils.AIConfig, aiService.EmbeddingClient, *vectorindex.Store, error) {
+	appCfg := semanticIndexConfig()
+	if appCfg == nil || !appCfg.AI.Embedding.Enabled {
+		return utils.AIConfig{}, nil, nil, ErrSemanticSearchUnavailable
+	}
+	cfg := utils.NormalizeAIConfig(appCfg.AI)
+	client, _, err := semanticEmbeddingClientFactory(cfg)
+	if err != nil {
+		return cfg, nil, nil, err
+	}
+
+	semanticIndex.mu.Lock()
+	defer semanticIndex.mu.Unlock()
+	dir := filepath.Clean(cfg.Embedding.IndexDir)
+	if semanticIndex.store == nil || semanticIndex.store.Dir() != dir {
+		store, err := vectorindex.Open(dir)
+		if err != nil {
+			return cfg, nil, nil, err
+		}
+		semanticIndex.store = store
+		semanticIndex.state = loadSemanticIndexState(dir)
+	}
+	state := semanticIndex.state
+	if state.Model != cfg.Embedding.Model || state.Dimensions != cfg.Embedding.Dimensions {
+		if err := semanticIndex.store.ResetAll(); err != nil {
+			return cfg, nil, nil, err
+		}
+		state = semanticIndexState{Model: cfg.Embedding.Model, Dimensions: cfg.Embedding.Dimensions}
+		if err := writeSemanticIndexState(dir, state); err != nil {
+			return cfg, nil, nil, err
+		}
+		semanticIndex.state = state
+	}
+	return cfg, client, semanticIndex.store, nil
+}
+
+func currentSemanticIndexState() semanticIndexState {
+	semanticIndex.mu.Lock()
+	defer semanticIndex.mu.Unlock()
+	return semanticIndex.state
+}
+
+func recordSemanticIndexRun(err error) error {
+	semanticIndex.mu.Lock()
+	defer semanticIndex.mu.Unlock()
+	semanticIndex.lastRunAt = time.Now()
+	semanticIndex.lastError = ""
+	if err != nil {
+		semanticIndex.lastError = err.Error()
+	}
+	return err
+}
+
+func loadSemanticIndexState(dir string) semanticIndexState {
+	var state semanticIndexState
+	data, err := os.ReadFile(filepath.Join(dir, semanticIndexStateFile))
+	if err != nil {
+		return state
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return semanticIndexState{}
+	}
+	return state
+}
+
+func saveSemanticIndexState(dir string, state semanticIndexState) error {
+	if err := writeSemanticIndexState(dir, state); err != nil {
+		return err
+	}
+	semanticIndex.mu.Lock()
+	semanticIndex.state = state
+	semanticIndex.mu.Unlock()
+	return nil
+}
+
+func writeSemanticIndexState(dir string, state semanticIndexState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, semanticIndexStateFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, semanticIndexStateFile))
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"sealchat/model"
+	aiService "sealchat/service/ai"
+	"sealchat/utils"
+)
+
+type semanticIndexTestClient struct {
+	calls int
+}
+
+// Embed 以是否包含几个关键字生成向量，足以区分测试消息的语义
+func (c *semanticIndexTestClient) Embed(_ context.Context, req aiService.EmbeddingRequest) (aiService.EmbeddingResult, error) {
+	c.calls++
+	vectors := make([][]float32, 0, len(req.Inputs))
+	for _, input := range req.Inputs {
+		vector := []float32{0.01, 0.01, 0.01}
+		if strings.Contains(input, "酒馆") || strings.Contains(input, "商人") {
+			vector[0] = 1
+		}
+		if strings.Contains(input, "墓地") {
+			vector[1] = 1
+		}
+		if strings.Contains(input, "船") {
+			vector[2] = 1
+		}
+		vectors = append(vectors, vector)
+	}
+	return aiService.EmbeddingResult{Vectors: vectors}, nil
+}
+
+func TestSemanticIndexIncrementalUpdatesAndSearch(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	client := &semanticIndexTestClient{}
+	cfg := &utils.AppConfig{AI: utils.AIConfig{Embedding: utils.AIEmbeddingConfig{
+		Enabled:   true,
+		Model:     "test-embedding",
+		BatchSize: 2,
+		IndexDir:  t.TempDir(),
+	}}}
+	originalConfig, originalFactory := semanticIndexConfig, semanticEmbeddingClientFactory
+	semanticIndexConfig = func() *utils.AppConfig { return cfg }
+	semanticEmbeddingClientFactory = func(utils.AIConfig) (aiService.EmbeddingClient, string, error) {
+		return client, "test-embedding", nil
+	}
+	t.Cleanup(func() {
+		semanticIndexConfig, semanticEmbeddingClientFactory = originalConfig, originalFactory
+	})
+
+	base := time.Now().Add(-time.Hour)
+	contents := []string{"我们在酒馆遇见了一位商人", "夜里去墓地调查", "登上了前往北方的船", ""}
+	for i, content := range contents {
+		msg := &model.MessageModel{
+			StringPKBaseModel: model.StringPKBaseModel{ID: "semantic-msg-" + string(rune('a'+i)), CreatedAt: base, UpdatedAt: base.Add(time.Duration(i) * time.Second)},
+			ChannelID:         "semantic-ch",
+			Content:           content,
+		}
+		if err := model.GetDB().Create(msg).Error; err != nil {
+			t.Fatalf("create message failed: %v", err)
+		}
+	}
+
+	indexed, err := RunSemanticIndexOnce(context.Background())
+	if err != nil || indexed != 4 {
+		t.Fatalf("indexed = %d err = %v, want 4 messages scanned", indexed, err)
+	}
+	hits, err := SemanticSearchChannel(context.Background(), "semantic-ch", "和商人见面的那场酒馆戏", 3)
+	if err != nil {
+		t.Fatalf("semantic search failed: %v", err)
+	}
+	if len(hits) != 3 || hits[0].ID != "semantic-msg-a" {
+		t.Fatalf("empty message should be skipped and tavern message ranked first: %#v", hits)
+	}
+
+	if err := model.GetDB().Model(&model.MessageModel{}).Where("id = ?", "semantic-msg-a").
+		Updates(map[string]any{"is_deleted": true, "updated_at": time.Now()}).Error; err != nil {
+		t.Fatalf("delete message failed: %v", err)
+	}
+	calls := client.calls
+	if indexed, err := RunSemanticIndexOnce(context.Background()); err != nil || indexed != 1 {
+		t.Fatalf("incremental run indexed = %d err = %v, want 1", indexed, err)
+	}
+	if client.calls != calls {
+		t.Fatal("deleted message should be removed without calling the embedding api")
+	}
+	hits, _ = SemanticSearchChannel(context.Background(), "semantic-ch", "酒馆", 3)
+	for _, hit := range hits {
+		if hit.ID == "semantic-msg-a" {
+			t.Fatalf("deleted message still indexed: %#v", hits)
+		}
+	}
+}
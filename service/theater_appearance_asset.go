@@ -182,6 +182,16 @@ func CreateTheaterAppearanceAssetUpload(ctx context.Context, operatorUserID, cha
 		return nil, newTheaterError(TheaterMediaErrorUnsupported, "不支持演出资源格式", 415, nil)
 	}
 	hashBytes := hasher.Sum(nil)
+	metadataStripped := false
+	if kind == "static_image" {
+		sanitizedHash, sanitizedSize, changed, err := sanitizeImageFileInPlace(tempPath, mimeType, sha256.New)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			hashBytes, written, metadataStripped = sanitizedHash, sanitizedSize, true
+		}
+	}
 	location, err := PersistTheaterAttachmentFile(hashBytes, written, tempPath, mimeType)
 	if err != nil {
 		return nil, err
@@ -192,7 +202,7 @@ func CreateTheaterAppearanceAssetUpload(ctx context.Context, operatorUserID, cha
 		Hash:              hashBytes, Filename: sanitizeTheaterFilename(input.Filename), Size: written, MimeType: mimeType,
 		IsAnimated: kind == "animated_image", UserID: actor.TargetUserID, ChannelID: channelID,
 		StorageType: location.StorageType, ObjectKey: location.ObjectKey, ExternalURL: location.ExternalURL,
-		RootID: assetID, RootIDType: theaterAttachmentRootAppearance, IsTemp: false,
+		RootID: assetID, RootIDType: theaterAttachmentRootAppearance, IsTemp: false, MetadataStripped: metadataStripped,
 	}
 	asset := model.TheaterAppearanceAssetModel{
 		StringPKBaseModel: model.StringPKBaseModel{ID: assetID},
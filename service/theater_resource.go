@@ -193,6 +193,16 @@ func CreateTheaterResourceUpload(ctx context.Context, actorID, worldID, channelI
 		return nil, newTheaterError(TheaterErrorResourceLimitExceeded, "资源文件大小超限", 413, map[string]any{"limitBytes": limit})
 	}
 	hashBytes := hasher.Sum(nil)
+	metadataStripped := false
+	if kind == "static_image" {
+		sanitizedHash, sanitizedSize, changed, err := sanitizeImageFileInPlace(tempPath, mimeType, sha256.New)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			hashBytes, written, metadataStripped = sanitizedHash, sanitizedSize, true
+		}
+	}
 	hashHex := hex.EncodeToString(hashBytes)
 	clientID := strings.TrimSpace(input.ClientResourceID)
 	if clientID != "" {
@@ -226,7 +236,7 @@ func CreateTheaterResourceUpload(ctx context.Context, actorID, worldID, channelI
 	if err != nil {
 		return nil, err
 	}
-	attachment := &model.AttachmentModel{Hash: hashBytes, Filename: sanitizeTheaterFilename(input.Filename), Size: written, MimeType: mimeType, IsAnimated: kind == "animated_image", UserID: actorID, ChannelID: channelID, StorageType: location.StorageType, ObjectKey: location.ObjectKey, ExternalURL: location.ExternalURL, RootID: room.ID, RootIDType: theaterAttachmentRootResource, IsTemp: false}
+	attachment := &model.AttachmentModel{Hash: hashBytes, Filename: sanitizeTheaterFilename(input.Filename), Size: written, MimeType: mimeType, IsAnimated: kind == "animated_image", UserID: actorID, ChannelID: channelID, StorageType: location.StorageType, ObjectKey: location.ObjectKey, ExternalURL: location.ExternalURL, RootID: room.ID, RootIDType: theaterAttachmentRootResource, IsTemp: false, MetadataStripped: metadataStripped}
 	if tx, _ := model.AttachmentCreate(attachment); tx.Error != nil {
 		return nil, tx.Error
 	}
@@ -18,6 +18,7 @@ type UnreadNotificationWorkerConfig struct {
 	CheckIntervalSec int
 	MaxPerHour       int
 	SiteURL          string
+	Reply            utils.EmailReplyConfig // 启用后邮件附带签名回复地址
 }
 
 var (
@@ -135,7 +136,8 @@ func processUserChannelNotification(setting *model.EmailNotificationSettingsMode
 	channelURL := resolveChannelURLForEmail(channelID, cfg.SiteURL)
 
 	// 7. 构建并发送邮件
-	htmlBody := BuildUnreadDigestHTML(channelName, unreadMessages, normalizeSiteURL(cfg.SiteURL), channelURL)
+	replyTo := BuildEmailReplyAddress(cfg.Reply, setting, time.Now())
+	htmlBody := buildUnreadDigestHTML(channelName, unreadMessages, normalizeSiteURL(cfg.SiteURL), channelURL, replyTo != "")
 	subject := "【SealChat】您有 " + formatMessageCount(len(unreadMessages)) + " 条未读消息"
 
 	// 选择 SMTP 配置：用户自定义或全局
@@ -158,7 +160,7 @@ func processUserChannelNotification(setting *model.EmailNotificationSettingsMode
 		}
 	}
 
-	if err := svc.SendEmail(setting.Email, subject, htmlBody); err != nil {
+	if err := svc.SendEmailWithReplyTo(setting.Email, replyTo, subject, htmlBody); err != nil {
 		log.Printf("email-notification: 发送邮件失败 user=%s email=%s: %v", userID, setting.Email, err)
 		return
 	}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm/clause"
+
+	"sealchat/model"
+)
+
+const (
+	UserPrivacyPolicyEveryone     = "everyone"
+	UserPrivacyPolicyFriends      = "friends"
+	UserPrivacyPolicyWorldMembers = "world_members"
+	UserPrivacyPolicyNobody       = "nobody"
+
+	userPrivacyPrefKeyDM            = "privacy.dm"
+	userPrivacyPrefKeyFriendRequest = "privacy.friend_request"
+	userBlockListMax                = 1000
+)
+
+var (
+	ErrUserBlockSelf          = errors.New("不能屏蔽自己")
+	ErrUserBlockTargetInvalid = errors.New("用户不存在")
+	ErrUserBlockLimit         = errors.New("屏蔽列表已满")
+	ErrUserBlocked            = errors.New("你们之间存在屏蔽关系")
+	ErrUserPrivacyInvalid     = errors.New("隐私设置无效")
+	ErrUserDMNotAllowed       = errors.New("对方不接受你的私聊")
+	ErrUserFriendReqForbidden = errors.New("对方不接受你的好友申请")
+)
+
+// UserPrivacySettings 私聊与好友申请的接收范围
+type UserPrivacySettings struct {
+	DirectMessage string `json:"directMessage"`
+	FriendRequest string `json:"friendRequest"`
+}
+
+func normalizeUserPrivacyPolicy(value string, allowFriends bool) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", UserPrivacyPolicyEveryone:
+		return UserPrivacyPolicyEveryone, true
+	case UserPrivacyPolicyFriends:
+		return UserPrivacyPolicyFriends, allowFriends
+	case UserPrivacyPolicyWorldMembers:
+		return UserPrivacyPolicyWorldMembers, true
+	case UserPrivacyPolicyNobody:
+		return UserPrivacyPolicyNobody, true
+	}
+	return "", false
+}
+
+func GetUserPrivacySettings(userID string) UserPrivacySettings {
+	settings := UserPrivacySettings{DirectMessage: UserPrivacyPolicyEveryone, FriendRequest: UserPrivacyPolicyEveryone}
+	items, err := model.UserPreferenceListByPrefix(userID, "privacy.")
+	if err != nil {
+		return settings
+	}
+	for _, item := range items {
+		switch item.PrefKey {
+		case userPrivacyPrefKeyDM:
+			if policy, ok := normalizeUserPrivacyPolicy(item.PrefValue, true); ok {
+				settings.DirectMessage = policy
+			}
+		case userPrivacyPrefKeyFriendRequest:
+			if policy, ok := normalizeUserPrivacyPolicy(item.PrefValue, false); ok {
+				settings.FriendRequest = policy
+			}
+		}
+	}
+	return settings
+}
+
+func UpdateUserPrivacySettings(userID string, input UserPrivacySettings) (UserPrivacySettings, error) {
+	dmPolicy, ok := normalizeUserPrivacyPolicy(input.DirectMessage, true)
+	if !ok {
+		return UserPrivacySettings{}, ErrUserPrivacyInvalid
+	}
+	friendPolicy, ok := normalizeUserPrivacyPolicy(input.FriendRequest, false)
+	if !ok {
+		return UserPrivacySettings{}, ErrUserPrivacyInvalid
+	}
+	if _, err := model.UserPreferenceUpsert(userID, userPrivacyPrefKeyDM, dmPolicy); err != nil {
+		return UserPrivacySettings{}, err
+	}
+	if _, err := model.UserPreferenceUpsert(userID, userPrivacyPrefKeyFriendRequest, friendPolicy); err != nil {
+		return UserPrivacySettings{}, err
+	}
+	return UserPrivacySettings{DirectMessage: dmPolicy, FriendRequest: friendPolicy}, nil
+}
+
+// UserBlock 屏蔽用户，重复屏蔽视为成功
+func UserBlock(userID, targetID string) (*model.UserBlockModel, error) {
+	targetID = strings.TrimSpace(targetID)
+	if targetID == "" || targetID == userID {
+		return nil, ErrUserBlockSelf
+	}
+	if target := model.UserGet(targetID); target == nil || target.ID == "" {
+		return nil, ErrUserBlockTargetInvalid
+	}
+	db := model.GetDB()
+	var count int64
+	if err := db.Model(&model.UserBlockModel{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= userBlockListMax {
+		return nil, ErrUserBlockLimit
+	}
+	item := &model.UserBlockModel{UserID: userID, BlockedUserID: targetID}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(item).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func UserUnblock(userID, targetID string) error {
+	return model.GetDB().Where("user_id = ? AND blocked_user_id = ?", userID, strings.TrimSpace(targetID)).
+		Delete(&model.UserBlockModel{}).Error
+}
+
+func UserBlockList(userID string) ([]*model.UserBlockModel, error) {
+	var items []*model.UserBlockModel
+	if err := model.GetDB().Where("user_id = ?", userID).Order("created_at DESC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return items, nil
+	}
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.BlockedUserID)
+	}
+	var users []*model.UserModel
+	if err := model.GetDB().Select("id, username, nickname, avatar, is_bot").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*model.UserModel, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+	for _, item := range items {
+		item.BlockedUser = byID[item.BlockedUserID]
+	}
+	return items, nil
+}
+
+// UserHasBlocked 判断 userID 是否屏蔽了 targetID
+func UserHasBlocked(userID, targetID string) bool {
+	if userID == "" || targetID == "" || userID == targetID {
+		return false
+	}
+	var count int64
+	model.GetDB().Model(&model.UserBlockModel{}).
+		Where("user_id = ? AND blocked_user_id = ?", userID, targetID).Count(&count)
+	return count > 0
+}
+
+// UsersBlockedEitherWay 任意一方屏蔽另一方即返回 true，用于私聊与好友申请
+func UsersBlockedEitherWay(userA, userB string) bool {
+	if userA == "" || userB == "" || userA == userB {
+		return false
+	}
+	var count int64
+	model.GetDB().Model(&model.UserBlockModel{}).
+		Where("(user_id = ? AND blocked_user_id = ?) OR (user_id = ? AND blocked_user_id = ?)", userA, userB, userB, userA).
+		Count(&count)
+	return count > 0
+}
+
+// UserBlockedIDSet 返回 userID 屏蔽的用户集合
+func UserBlockedIDSet(userID string) map[string]struct{} {
+	result := map[string]struct{}{}
+	if strings.TrimSpace(userID) == "" {
+		return result
+	}
+	var ids []string
+	_ = model.GetDB().Model(&model.UserBlockModel{}).Where("user_id = ?", userID).Pluck("blocked_user_id", &ids).Error
+	for _, id := range ids {
+		result[id] = struct{}{}
+	}
+	return result
+}
+
+// UserBlockerIDSet 返回屏蔽了 userID 的用户集合
+func UserBlockerIDSet(userID string) map[string]struct{} {
+	result := map[string]struct{}{}
+	if strings.TrimSpace(userID) == "" {
+		return result
+	}
+	var ids []string
+	_ = model.GetDB().Model(&model.UserBlockModel{}).Where("blocked_user_id = ?", userID).Pluck("user_id", &ids).Error
+	for _, id := range ids {
+		result[id] = struct{}{}
+	}
+	return result
+}
+
+// UserIDsBlockingSender 返回 candidates 中屏蔽了 senderID 的用户
+func UserIDsBlockingSender(senderID string, candidates []string) map[string]struct{} {
+	result := map[string]struct{}{}
+	if senderID == "" || len(candidates) == 0 {
+		return result
+	}
+	var ids []string
+	_ = model.GetDB().Model(&model.UserBlockModel{}).
+		Where("blocked_user_id = ? AND user_id IN ?", senderID, candidates).
+		Pluck("user_id", &ids).Error
+	for _, id := range ids {
+		result[id] = struct{}{}
+	}
+	return result
+}
+
+// usersShareWorld 注册时所有人都会加入系统默认世界，因此不计入共同世界
+func usersShareWorld(userA, userB string) bool {
+	var count int64
+	model.GetDB().Table("world_members AS a").
+		Joins("JOIN world_members AS b ON a.world_id = b.world_id").
+		Joins("JOIN worlds ON worlds.id = a.world_id AND worlds.status = ? AND worlds.is_system_default = ?", "active", false).
+		Where("a.user_id = ? AND b.user_id = ?", userA, userB).
+		Count(&count)
+	return count > 0
+}
+
+func checkUserPrivacyPolicy(policy, senderID, receiverID string) bool {
+	switch policy {
+	case UserPrivacyPolicyNobody:
+		return false
+	case UserPrivacyPolicyFriends:
+		return model.FriendRelationGet(senderID, receiverID).IsFriend
+	case UserPrivacyPolicyWorldMembers:
+		return model.FriendRelationGet(senderID, receiverID).IsFriend || usersShareWorld(senderID, receiverID)
+	}
+	return true
+}
+
+// CheckDirectMessageAllowed 校验 senderID 能否向 receiverID 发起私聊
+func CheckDirectMessageAllowed(senderID, receiverID string) error {
+	if UsersBlockedEitherWay(senderID, receiverID) {
+		return ErrUserBlocked
+	}
+	if !checkUserPrivacyPolicy(GetUserPrivacySettings(receiverID).DirectMessage, senderID, receiverID) {
+		return ErrUserDMNotAllowed
+	}
+	return nil
+}
+
+// CheckFriendRequestAllowed 校验 senderID 能否向 receiverID 发送好友申请
+func CheckFriendRequestAllowed(senderID, receiverID string) error {
+	if UsersBlockedEitherWay(senderID, receiverID) {
+		return ErrUserBlocked
+	}
+	if !checkUserPrivacyPolicy(GetUserPrivacySettings(receiverID).FriendRequest, senderID, receiverID) {
+		return ErrUserFriendReqForbidden
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"sealchat/model"
+)
+
+func TestCheckDirectMessageAllowedPrivacyPolicies(t *testing.T) {
+	initTestDB(t)
+	receiverID := createWorldOwnershipTestUser(t, "receiver", false)
+	friendID := createWorldOwnershipTestUser(t, "friend", false)
+	coMemberID := createWorldOwnershipTestUser(t, "co-member", false)
+	strangerID := createWorldOwnershipTestUser(t, "stranger", false)
+	createWorldOwnershipTestWorld(t, receiverID, coMemberID)
+	if err := model.FriendRelationCreate(receiverID, friendID, true); err != nil {
+		t.Fatalf("create friend failed: %v", err)
+	}
+
+	if err := CheckDirectMessageAllowed(strangerID, receiverID); err != nil {
+		t.Fatalf("default policy should allow everyone, got %v", err)
+	}
+	if _, err := UpdateUserPrivacySettings(receiverID, UserPrivacySettings{DirectMessage: "world_members"}); err != nil {
+		t.Fatalf("update privacy failed: %v", err)
+	}
+	if err := CheckDirectMessageAllowed(coMemberID, receiverID); err != nil {
+		t.Fatalf("co-member should be allowed, got %v", err)
+	}
+	if err := CheckDirectMessageAllowed(friendID, receiverID); err != nil {
+		t.Fatalf("friend should be allowed, got %v", err)
+	}
+	if err := CheckDirectMessageAllowed(strangerID, receiverID); !errors.Is(err, ErrUserDMNotAllowed) {
+		t.Fatalf("stranger should be rejected, got %v", err)
+	}
+	if _, err := UpdateUserPrivacySettings(receiverID, UserPrivacySettings{FriendRequest: "friends"}); !errors.Is(err, ErrUserPrivacyInvalid) {
+		t.Fatalf("friends-only friend request policy should be invalid, got %v", err)
+	}
+	if _, err := UpdateUserPrivacySettings(receiverID, UserPrivacySettings{DirectMessage: "nobody", FriendRequest: "nobody"}); err != nil {
+		t.Fatalf("update privacy failed: %v", err)
+	}
+	if err := CheckDirectMessageAllowed(friendID, receiverID); !errors.Is(err, ErrUserDMNotAllowed) {
+		t.Fatalf("nobody policy should reject friends, got %v", err)
+	}
+	if err := CheckFriendRequestAllowed(strangerID, receiverID); !errors.Is(err, ErrUserFriendReqForbidden) {
+		t.Fatalf("friend request should be rejected, got %v", err)
+	}
+}
+
+func TestWorldMembersPolicyIgnoresDefaultWorld(t *testing.T) {
+	initTestDB(t)
+	receiverID := createWorldOwnershipTestUser(t, "default-receiver", false)
+	senderID := createWorldOwnershipTestUser(t, "default-sender", false)
+	defaultWorld, err := GetOrCreateDefaultWorld()
+	if err != nil {
+		t.Fatalf("create default world failed: %v", err)
+	}
+	for _, userID := range []string{receiverID, senderID} {
+		if _, err := WorldJoin(defaultWorld.ID, userID, model.WorldRoleMember); err != nil {
+			t.Fatalf("join default world failed: %v", err)
+		}
+	}
+	if _, err := UpdateUserPrivacySettings(receiverID, UserPrivacySettings{DirectMessage: "world_members"}); err != nil {
+		t.Fatalf("update privacy failed: %v", err)
+	}
+	if err := CheckDirectMessageAllowed(senderID, receiverID); !errors.Is(err, ErrUserDMNotAllowed) {
+		t.Fatalf("sharing only the default world should not count, got %v", err)
+	}
+}
+
+func TestUserBlockPreventsContactBothWays(t *testing.T) {
+	initTestDB(t)
+	userID := createWorldOwnershipTestUser(t, "blocker", false)
+	targetID := createWorldOwnershipTestUser(t, "blocked", false)
+
+	if _, err := UserBlock(userID, userID); !errors.Is(err, ErrUserBlockSelf) {
+		t.Fatalf("expected self block rejected, got %v", err)
+	}
+	if _, err := UserBlock(userID, targetID); err != nil {
+		t.Fatalf("block failed: %v", err)
+	}
+	if _, err := UserBlock(userID, targetID); err != nil {
+		t.Fatalf("repeated block should succeed, got %v", err)
+	}
+	if !UserHasBlocked(userID, targetID) || UserHasBlocked(targetID, userID) {
+		t.Fatalf("block direction incorrect")
+	}
+	if err := CheckDirectMessageAllowed(targetID, userID); !errors.Is(err, ErrUserBlocked) {
+		t.Fatalf("blocked user should not DM, got %v", err)
+	}
+	if err := CheckFriendRequestAllowed(userID, targetID); !errors.Is(err, ErrUserBlocked) {
+		t.Fatalf("blocker should not send friend request either, got %v", err)
+	}
+	if blocking := UserIDsBlockingSender(targetID, []string{userID}); len(blocking) != 1 {
+		t.Fatalf("expected blocker in mention filter, got %v", blocking)
+	}
+	items, err := UserBlockList(userID)
+	if err != nil || len(items) != 1 || items[0].BlockedUser == nil {
+		t.Fatalf("unexpected block list: %+v err=%v", items, err)
+	}
+	if err := UserUnblock(userID, targetID); err != nil {
+		t.Fatalf("unblock failed: %v", err)
+	}
+	if UsersBlockedEitherWay(userID, targetID) {
+		t.Fatalf("block should be removed")
+	}
+}
@@ -0,0 +1,270 @@
+// Package vectorindex 提供按频道分片的本地向量存储：向量入库时归一化，检索时以点积计算余弦相似度并全量扫描。
+// 单频道消息量在十万级以内时扁平扫描足够快，且无需维护图结构；每个分片以 gob 编码写入独立文件，
+// 写入先落临时文件再原子替换，进程中断不会留下半截索引。
+package vectorindex
+
+import (
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrDimensionMismatch = errors.New("vector dimension mismatch")
+
+type Hit struct {
+	ID    string
+	Score float32
+}
+
+// shard 单个频道的向量；vectors 为按 ids 顺序平铺的 dim 维向量
+type shard struct {
+	Dim     int
+	IDs     []string
+	Vectors []float32
+
+	index map[string]int
+	dirty bool
+}
+
+type Store struct {
+	dir    string
+	mu     sync.Mutex
+	shards map[string]*shard
+}
+
+func Open(dir string) (*Store, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, errors.New("vector index dir required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir, shards: map[string]*shard{}}, nil
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Upsert 写入或替换一条向量；同一分片内维度必须一致
+func (s *Store) Upsert(channelID string, id string, vector []float32) error {
+	if len(vector) == 0 {
+		return errors.New("empty vector")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh, err := s.loadLocked(channelID)
+	if err != nil {
+		return err
+	}
+	if sh.Dim == 0 {
+		sh.Dim = len(vector)
+	}
+	if sh.Dim != len(vector) {
+		return fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(vector), sh.Dim)
+	}
+	normalized := normalize(vector)
+	if pos, ok := sh.index[id]; ok {
+		copy(sh.Vectors[pos*sh.Dim:(pos+1)*sh.Dim], normalized)
+	} else {
+		sh.index[id] = len(sh.IDs)
+		sh.IDs = append(sh.IDs, id)
+		sh.Vectors = append(sh.Vectors, normalized...)
+	}
+	sh.dirty = true
+	return nil
+}
+
+// Delete 移除一条向量，末尾元素移到空位以保持平铺数组紧凑
+func (s *Store) Delete(channelID string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh, err := s.loadLocked(channelID)
+	if err != nil {
+		return err
+	}
+	pos, ok := sh.index[id]
+	if !ok {
+		return nil
+	}
+	last := len(sh.IDs) - 1
+	if pos != last {
+		sh.IDs[pos] = sh.IDs[last]
+		copy(sh.Vectors[pos*sh.Dim:(pos+1)*sh.Dim], sh.Vectors[last*sh.Dim:(last+1)*sh.Dim])
+		sh.index[sh.IDs[pos]] = pos
+	}
+	sh.IDs = sh.IDs[:last]
+	sh.Vectors = sh.Vectors[:last*sh.Dim]
+	delete(sh.index, id)
+	sh.dirty = true
+	return nil
+}
+
+// Search 返回与查询向量最相近的 limit 条结果，按相似度降序
+func (s *Store) Search(channelID string, query []float32, limit int) ([]Hit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh, err := s.loadLocked(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sh.IDs) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	if sh.Dim != len(query) {
+		return nil, fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(query), sh.Dim)
+	}
+	q := normalize(query)
+	hits := make([]Hit, len(sh.IDs))
+	for i, id := range sh.IDs {
+		vector := sh.Vectors[i*sh.Dim : (i+1)*sh.Dim]
+		var score float32
+		for j, value := range vector {
+			score += value * q[j]
+		}
+		hits[i] = Hit{ID: id, Score: score}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score == hits[j].Score {
+			return hits[i].ID < hits[j].ID
+		}
+		return hits[i].Score > hits[j].Score
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func (s *Store) Count(channelID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh, err := s.loadLocked(channelID)
+	if err != nil {
+		return 0, err
+	}
+	return len(sh.IDs), nil
+}
+
+// Reset 清空频道分片并删除文件，用于重新回填
+func (s *Store) Reset(channelID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.shards, channelID)
+	if err := os.Remove(s.shardPath(channelID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ResetAll 清空全部分片，向量模型或维度变更后使用
+func (s *Store) ResetAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shards = map[string]*shard{}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".vec" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush 把有改动的分片写回磁盘
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for channelID, sh := range s.shards {
+		if !sh.dirty {
+			continue
+		}
+		if err := s.writeShardLocked(channelID, sh); err != nil {
+			return err
+		}
+		sh.dirty = false
+	}
+	return nil
+}
+
+func (s *Store) loadLocked(channelID string) (*shard, error) {
+	channelID = strings.TrimSpace(channelID)
+	if channelID == "" {
+		return nil, errors.New("invalid channel id")
+	}
+	if sh, ok := s.shards[channelID]; ok {
+		return sh, nil
+	}
+	sh := &shard{}
+	file, err := os.Open(s.shardPath(channelID))
+	switch {
+	case err == nil:
+		decodeErr := gob.NewDecoder(file).Decode(sh)
+		_ = file.Close()
+		if decodeErr != nil {
+			return nil, fmt.Errorf("读取向量分片失败: %w", decodeErr)
+		}
+	case os.IsNotExist(err):
+	default:
+		return nil, err
+	}
+	sh.index = make(map[string]int, len(sh.IDs))
+	for i, id := range sh.IDs {
+		sh.index[id] = i
+	}
+	s.shards[channelID] = sh
+	return sh, nil
+}
+
+func (s *Store) writeShardLocked(channelID string, sh *shard) error {
+	tmp, err := os.CreateTemp(s.dir, "shard-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if err := gob.NewEncoder(tmp).Encode(sh); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, s.shardPath(channelID))
+}
+
+// shardPath 频道 ID 可能含冒号等不适合作文件名的字符，统一编码
+func (s *Store) shardPath(channelID string) string {
+	return filepath.Join(s.dir, base64.RawURLEncoding.EncodeToString([]byte(channelID))+".vec")
+}
+
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, value := range vector {
+		sum += float64(value) * float64(value)
+	}
+	out := make([]float32, len(vector))
+	if sum == 0 {
+		return out
+	}
+	inv := float32(1 / math.Sqrt(sum))
+	for i, value := range vector {
+		out[i] = value * inv
+	}
+	return out
+}
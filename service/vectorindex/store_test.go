@@ -0,0 +1,58 @@
+package vectorindex
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStoreSearchPersistAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatalf("open store failed: %v", err)
+	}
+	for id, vector := range map[string][]float32{
+		"m1": {1, 0, 0},
+		"m2": {0.9, 0.1, 0},
+		"m3": {0, 0, 5},
+	} {
+		if err := store.Upsert("ch-1", id, vector); err != nil {
+			t.Fatalf("upsert %s failed: %v", id, err)
+		}
+	}
+	if err := store.Upsert("ch-1", "bad", []float32{1, 0}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch, got %v", err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	hits, err := reopened.Search("ch-1", []float32{2, 0, 0}, 2)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(hits) != 2 || hits[0].ID != "m1" || hits[1].ID != "m2" || hits[0].Score < 0.999 {
+		t.Fatalf("unexpected hits: %#v", hits)
+	}
+
+	if err := reopened.Delete("ch-1", "m1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	hits, _ = reopened.Search("ch-1", []float32{0, 0, 1}, 5)
+	if len(hits) != 2 || hits[0].ID != "m3" {
+		t.Fatalf("unexpected hits after delete: %#v", hits)
+	}
+	if other, _ := reopened.Search("ch-2", []float32{1, 0, 0}, 5); len(other) != 0 {
+		t.Fatalf("shards must be isolated per channel: %#v", other)
+	}
+	if err := reopened.Upsert("user-a:user-b", "dm", []float32{1, 1}); err != nil {
+		t.Fatalf("channel ids with separators should be stored: %v", err)
+	}
+	if _, err := reopened.Count(" "); err == nil {
+		t.Fatal("empty channel id should be rejected")
+	}
+}
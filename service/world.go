@@ -83,6 +83,7 @@ type WorldUpdateParams struct {
 	CharacterCardBadgeTemplate            *string
 	CursorTheme                           *utils.CursorThemeConfig
 	StickyNoteDefaultAppearance           *protocol.StickyNoteAppearance
+	JoinQuestions                         *[]model.WorldJoinQuestion
 }
 
 type WorldChannelDefaultDiceConfig struct {
@@ -589,6 +590,15 @@ func WorldCreate(ownerID string, params WorldCreateParams) (*model.WorldModel, *
 	if err != nil {
 		return nil, nil, err
 	}
+	// 新世界还没有自己的规则，按平台全局规则过滤
+	if name, err = ContentFilterApplyText("", model.ContentFilterScopeWorld, name); err != nil {
+		return nil, nil, err
+	}
+	if description != "" {
+		if description, err = ContentFilterApplyText("", model.ContentFilterScopeWorld, description); err != nil {
+			return nil, nil, err
+		}
+	}
 	visibility := params.Visibility
 	if visibility == "" {
 		visibility = model.WorldVisibilityPublic
@@ -667,6 +677,10 @@ func WorldUpdate(worldID, actorID string, params WorldUpdateParams) (*model.Worl
 	}
 	updates := map[string]interface{}{}
 	if name := strings.TrimSpace(params.Name); name != "" {
+		name, err := ContentFilterApplyText(worldID, model.ContentFilterScopeWorld, name)
+		if err != nil {
+			return nil, err
+		}
 		updates["name"] = name
 	}
 	if params.Description != "" {
@@ -674,6 +688,9 @@ func WorldUpdate(worldID, actorID string, params WorldUpdateParams) (*model.Worl
 		if err != nil {
 			return nil, err
 		}
+		if description, err = ContentFilterApplyText(worldID, model.ContentFilterScopeWorld, description); err != nil {
+			return nil, err
+		}
 		updates["description"] = description
 	}
 	if params.Avatar != "" {
@@ -686,6 +703,17 @@ func WorldUpdate(worldID, actorID string, params WorldUpdateParams) (*model.Worl
 	if params.EnforceMembership != nil {
 		updates["enforce_membership"] = *params.EnforceMembership
 	}
+	if params.JoinQuestions != nil {
+		questions, err := normalizeWorldJoinQuestions(*params.JoinQuestions)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(questions)
+		if err != nil {
+			return nil, err
+		}
+		updates["join_questions_json"] = string(raw)
+	}
 	if params.AllowAdminEditMessages != nil {
 		updates["allow_admin_edit_messages"] = *params.AllowAdminEditMessages
 	}
@@ -868,15 +896,29 @@ func WorldJoin(worldID, userID, role string) (*model.WorldMemberModel, error) {
 	if _, err := ensureWorldMemberChannelState(worldID, userID, role); err != nil {
 		return member, err
 	}
+	notifyWorldMemberChange(worldID, userID, userID, true)
 	return member, nil
 }
 
+// WorldMemberChangeNotifier 世界成员加入或离开后回调，由 api 层注入用于推送机器人通知
+var WorldMemberChangeNotifier func(worldID, userID, operatorID string, joined bool)
+
+func notifyWorldMemberChange(worldID, userID, operatorID string, joined bool) {
+	if WorldMemberChangeNotifier != nil {
+		WorldMemberChangeNotifier(worldID, userID, operatorID, joined)
+	}
+}
+
 func WorldLeave(worldID, userID string) error {
+	return worldLeave(worldID, userID, userID)
+}
+
+func worldLeave(worldID, userID, operatorID string) error {
 	if IsWorldOwner(worldID, userID) {
 		return errors.New("世界拥有者无法退出，请先转移所有权或删除世界")
 	}
 	db := model.GetDB()
-	return db.Transaction(func(tx *gorm.DB) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("world_id = ? AND user_id = ?", worldID, userID).Delete(&model.WorldMemberModel{}).Error; err != nil {
 			return err
 		}
@@ -891,6 +933,11 @@ func WorldLeave(worldID, userID string) error {
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	notifyWorldMemberChange(worldID, userID, operatorID, false)
+	return nil
 }
 
 func IsWorldOwner(worldID, userID string) bool {
@@ -1133,7 +1180,7 @@ func WorldRemoveMember(worldID, actorID, targetUserID string) error {
 	if IsWorldOwner(worldID, targetUserID) {
 		return ErrWorldOwnerImmutable
 	}
-	return WorldLeave(worldID, targetUserID)
+	return worldLeave(worldID, targetUserID, actorID)
 }
 
 func WorldUpdateMemberRole(worldID, actorID, targetUserID, role string) error {
@@ -1406,7 +1453,7 @@ func removeChannelRoleLink(userID, channelID, roleKey string) error {
 	return err
 }
 
-func WorldInviteCreate(worldID, creatorID string, ttlMinutes int, maxUse int, memo string, role string) (*model.WorldInviteModel, error) {
+func WorldInviteCreate(worldID, creatorID string, ttlMinutes int, maxUse int, memo string, role string, joinMode string) (*model.WorldInviteModel, error) {
 	if !IsWorldAdmin(worldID, creatorID) {
 		return nil, ErrWorldPermission
 	}
@@ -1414,6 +1461,10 @@ func WorldInviteCreate(worldID, creatorID string, ttlMinutes int, maxUse int, me
 	if role != model.WorldRoleMember && role != model.WorldRoleSpectator {
 		return nil, ErrWorldMemberInvalid
 	}
+	joinMode = strings.ToLower(strings.TrimSpace(joinMode))
+	if joinMode != model.WorldInviteJoinModeApply {
+		joinMode = model.WorldInviteJoinModeAuto
+	}
 	// 合法化参数：负数一律视为无限
 	if ttlMinutes < 0 {
 		ttlMinutes = 0
@@ -1434,6 +1485,7 @@ func WorldInviteCreate(worldID, creatorID string, ttlMinutes int, maxUse int, me
 		MaxUse:    maxUse,
 		Memo:      memo,
 		Status:    "active",
+		JoinMode:  joinMode,
 	}
 	if ttlMinutes > 0 {
 		expire := time.Now().Add(time.Duration(ttlMinutes) * time.Minute)
@@ -1445,18 +1497,27 @@ func WorldInviteCreate(worldID, creatorID string, ttlMinutes int, maxUse int, me
 	return invite, nil
 }
 
-func WorldInviteConsume(slug, userID string) (*model.WorldInviteModel, *model.WorldModel, *model.WorldMemberModel, bool, error) {
+type WorldInviteConsumeResult struct {
+	Invite        *model.WorldInviteModel
+	World         *model.WorldModel
+	Member        *model.WorldMemberModel
+	AlreadyJoined bool
+	// Request 非空时表示邀请为申请模式，用户尚未加入，需等待审核
+	Request *model.WorldJoinRequestModel
+}
+
+func WorldInviteConsume(slug, userID string, application WorldJoinApplicationInput) (*WorldInviteConsumeResult, error) {
 	slug = strings.TrimSpace(slug)
 	if slug == "" {
-		return nil, nil, nil, false, ErrWorldInviteInvalid
+		return nil, ErrWorldInviteInvalid
 	}
 	db := model.GetDB()
 	var invite model.WorldInviteModel
 	if err := db.Where("slug = ? AND status = ?", slug, "active").Limit(1).Find(&invite).Error; err != nil {
-		return nil, nil, nil, false, err
+		return nil, err
 	}
 	if invite.ID == "" {
-		return nil, nil, nil, false, ErrWorldInviteInvalid
+		return nil, ErrWorldInviteInvalid
 	}
 	now := time.Now()
 	markInviteArchived := func() {
@@ -1466,30 +1527,40 @@ func WorldInviteConsume(slug, userID string) (*model.WorldInviteModel, *model.Wo
 	}
 	if invite.ExpireAt != nil && invite.ExpireAt.Before(now) {
 		markInviteArchived()
-		return nil, nil, nil, false, ErrWorldInviteInvalid
+		return nil, ErrWorldInviteInvalid
 	}
 	if invite.MaxUse > 0 && invite.UsedCount >= invite.MaxUse {
 		markInviteArchived()
-		return nil, nil, nil, false, ErrWorldInviteInvalid
+		return nil, ErrWorldInviteInvalid
 	}
 	world, err := GetWorldByID(invite.WorldID)
 	if err != nil {
-		return nil, nil, nil, false, err
+		return nil, err
 	}
 	existingMember := &model.WorldMemberModel{}
 	_ = db.Where("world_id = ? AND user_id = ?", invite.WorldID, userID).Limit(1).Find(existingMember).Error
 	wasMember := existingMember.ID != ""
 	role := normalizeWorldRole(invite.Role)
+	invite.Role = role
+	if !wasMember && IsWorldBanned(invite.WorldID, userID) {
+		return nil, ErrWorldJoinBanned
+	}
+	if !wasMember && invite.JoinMode == model.WorldInviteJoinModeApply {
+		// 申请模式下名额在审核通过时才计入 used_count
+		request, err := submitWorldJoinRequest(world, userID, invite.ID, role, application)
+		if err != nil {
+			return nil, err
+		}
+		return &WorldInviteConsumeResult{Invite: &invite, World: world, Request: request}, nil
+	}
 	member, err := WorldJoin(invite.WorldID, userID, role)
 	if err != nil {
-		return nil, nil, nil, false, err
+		return nil, err
 	}
-	alreadyJoined := wasMember
 	if !wasMember {
 		_ = db.Model(&model.WorldInviteModel{}).
 			Where("id = ?", invite.ID).
 			Updates(map[string]any{"used_count": gorm.Expr("used_count + 1"), "updated_at": time.Now()}).Error
 	}
-	invite.Role = role
-	return &invite, world, member, alreadyJoined, nil
+	return &WorldInviteConsumeResult{Invite: &invite, World: world, Member: member, AlreadyJoined: wasMember}, nil
 }
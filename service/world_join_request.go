@@ -0,0 +1,379 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sealchat/model"
+	"sealchat/pm"
+	"sealchat/utils"
+)
+
+const (
+	worldJoinQuestionMaxCount       = 10
+	worldJoinQuestionMaxRunes       = 200
+	worldJoinAnswerMaxRunes         = 1000
+	worldJoinMessageMaxRunes        = 500
+	worldJoinReviewNoteMaxRunes     = 500
+	WorldJoinRequestDecisionApprove = "approve"
+	WorldJoinRequestDecisionReject  = "reject"
+	WorldJoinRequestDecisionBan     = "ban"
+)
+
+var (
+	ErrWorldJoinRequestNotFound  = errors.New("加入申请不存在")
+	ErrWorldJoinRequestReviewed  = errors.New("加入申请已处理")
+	ErrWorldJoinBanned           = errors.New("你已被该世界封禁")
+	ErrWorldJoinAlreadyMember    = errors.New("已是世界成员")
+	ErrWorldJoinAnswerInvalid    = errors.New("加入申请回答无效")
+	ErrWorldJoinQuestionsInvalid = errors.New("加入申请问题无效")
+	ErrWorldJoinDecisionInvalid  = errors.New("审核操作无效")
+	ErrWorldJoinNotApplicable    = errors.New("该世界无需申请即可加入")
+)
+
+type WorldJoinApplicationInput struct {
+	Answers []model.WorldJoinAnswer `json:"answers"`
+	Message string                  `json:"message"`
+}
+
+type WorldJoinRequestView struct {
+	*model.WorldJoinRequestModel
+	Username  string `json:"username"`
+	Nickname  string `json:"nickname"`
+	Avatar    string `json:"avatar"`
+	WorldName string `json:"worldName,omitempty"`
+}
+
+func (v *WorldJoinRequestView) MarshalJSON() ([]byte, error) {
+	raw, err := json.Marshal(v.WorldJoinRequestModel)
+	if err != nil {
+		return nil, err
+	}
+	var merged map[string]any
+	if err := json.Unmarshal(raw, &merged); err != nil {
+		return nil, err
+	}
+	merged["username"] = v.Username
+	merged["nickname"] = v.Nickname
+	merged["avatar"] = v.Avatar
+	if v.WorldName != "" {
+		merged["worldName"] = v.WorldName
+	}
+	return json.Marshal(merged)
+}
+
+// normalizeWorldJoinQuestions 校验并补全问题 ID，空问题会被丢弃
+func normalizeWorldJoinQuestions(questions []model.WorldJoinQuestion) ([]model.WorldJoinQuestion, error) {
+	result := make([]model.WorldJoinQuestion, 0, len(questions))
+	seen := map[string]struct{}{}
+	for _, question := range questions {
+		prompt := strings.TrimSpace(question.Prompt)
+		if prompt == "" {
+			continue
+		}
+		if len([]rune(prompt)) > worldJoinQuestionMaxRunes {
+			return nil, fmt.Errorf("%w: 问题不能超过%d字", ErrWorldJoinQuestionsInvalid, worldJoinQuestionMaxRunes)
+		}
+		id := strings.TrimSpace(question.ID)
+		if _, exists := seen[id]; id == "" || exists || len(id) > 32 {
+			id = utils.NewIDWithLength(8)
+		}
+		seen[id] = struct{}{}
+		result = append(result, model.WorldJoinQuestion{ID: id, Prompt: prompt, Required: question.Required})
+	}
+	if len(result) > worldJoinQuestionMaxCount {
+		return nil, fmt.Errorf("%w: 最多%d个问题", ErrWorldJoinQuestionsInvalid, worldJoinQuestionMaxCount)
+	}
+	return result, nil
+}
+
+// normalizeWorldJoinAnswers 按世界当前问题整理回答，未知问题的回答会被忽略
+func normalizeWorldJoinAnswers(questions []model.WorldJoinQuestion, answers []model.WorldJoinAnswer) ([]model.WorldJoinAnswer, error) {
+	byID := make(map[string]string, len(answers))
+	for _, answer := range answers {
+		byID[strings.TrimSpace(answer.QuestionID)] = strings.TrimSpace(answer.Answer)
+	}
+	result := make([]model.WorldJoinAnswer, 0, len(questions))
+	for _, question := range questions {
+		value := byID[question.ID]
+		if value == "" && question.Required {
+			return nil, fmt.Errorf("%w: 请回答「%s」", ErrWorldJoinAnswerInvalid, question.Prompt)
+		}
+		if len([]rune(value)) > worldJoinAnswerMaxRunes {
+			return nil, fmt.Errorf("%w: 回答不能超过%d字", ErrWorldJoinAnswerInvalid, worldJoinAnswerMaxRunes)
+		}
+		result = append(result, model.WorldJoinAnswer{QuestionID: question.ID, Prompt: question.Prompt, Answer: value})
+	}
+	return result, nil
+}
+
+func IsWorldBanned(worldID, userID string) bool {
+	var count int64
+	model.GetDB().Model(&model.WorldBanModel{}).Where("world_id = ? AND user_id = ?", worldID, userID).Count(&count)
+	return count > 0
+}
+
+// WorldJoinRequestSubmit 提交加入申请；已有待审核申请时更新其内容
+func WorldJoinRequestSubmit(worldID, userID string, input WorldJoinApplicationInput) (*model.WorldJoinRequestModel, error) {
+	world, err := GetWorldByID(worldID)
+	if err != nil {
+		return nil, err
+	}
+	if world.Status != "active" {
+		return nil, ErrWorldNotFound
+	}
+	if !world.RequiresJoinApproval() {
+		return nil, ErrWorldJoinNotApplicable
+	}
+	return submitWorldJoinRequest(world, userID, "", model.WorldRoleMember, input)
+}
+
+func submitWorldJoinRequest(world *model.WorldModel, userID, inviteID, role string, input WorldJoinApplicationInput) (*model.WorldJoinRequestModel, error) {
+	if IsWorldBanned(world.ID, userID) {
+		return nil, ErrWorldJoinBanned
+	}
+	if IsWorldMember(world.ID, userID) {
+		return nil, ErrWorldJoinAlreadyMember
+	}
+	answers, err := normalizeWorldJoinAnswers(world.GetJoinQuestions(), input.Answers)
+	if err != nil {
+		return nil, err
+	}
+	message := strings.TrimSpace(input.Message)
+	if len([]rune(message)) > worldJoinMessageMaxRunes {
+		message = string([]rune(message)[:worldJoinMessageMaxRunes])
+	}
+	rawAnswers, err := json.Marshal(answers)
+	if err != nil {
+		return nil, err
+	}
+	db := model.GetDB()
+	var request model.WorldJoinRequestModel
+	if err := db.Where("world_id = ? AND user_id = ? AND status = ?", world.ID, userID, model.WorldJoinRequestStatusPending).
+		Limit(1).Find(&request).Error; err != nil {
+		return nil, err
+	}
+	if request.ID != "" {
+		updates := map[string]any{"answers_json": string(rawAnswers), "message": message, "updated_at": time.Now()}
+		if inviteID != "" {
+			updates["invite_id"] = inviteID
+			updates["role"] = role
+			request.InviteID = inviteID
+			request.Role = role
+		}
+		if err := db.Model(&request).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+		request.AnswersJSON = string(rawAnswers)
+		request.Message = message
+		return &request, nil
+	}
+	request = model.WorldJoinRequestModel{
+		WorldID: world.ID, UserID: userID, Status: model.WorldJoinRequestStatusPending,
+		Role: normalizeWorldRole(role), InviteID: inviteID, Message: message, AnswersJSON: string(rawAnswers),
+	}
+	if err := db.Create(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func WorldJoinRequestWithdraw(userID, requestID string) error {
+	result := model.GetDB().Model(&model.WorldJoinRequestModel{}).
+		Where("id = ? AND user_id = ? AND status = ?", requestID, userID, model.WorldJoinRequestStatusPending).
+		Updates(map[string]any{"status": model.WorldJoinRequestStatusWithdrawn, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWorldJoinRequestNotFound
+	}
+	return nil
+}
+
+func canReviewWorldJoinRequests(worldID, actorID string) bool {
+	return IsWorldAdmin(worldID, actorID) || pm.CanWithSystemRole(actorID, pm.PermModAdmin)
+}
+
+// WorldJoinRequestList 返回世界的申请队列，status 为空时返回全部
+func WorldJoinRequestList(worldID, actorID, status string, page, pageSize int) ([]*WorldJoinRequestView, int64, error) {
+	if !canReviewWorldJoinRequests(worldID, actorID) {
+		return nil, 0, ErrWorldPermission
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	query := model.GetDB().Model(&model.WorldJoinRequestModel{}).Where("world_id = ?", worldID)
+	if status = strings.TrimSpace(status); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []*model.WorldJoinRequestModel
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	views, err := buildWorldJoinRequestViews(rows, false)
+	return views, total, err
+}
+
+// WorldJoinRequestListMine 返回当前用户提交过的申请
+func WorldJoinRequestListMine(userID string) ([]*WorldJoinRequestView, error) {
+	var rows []*model.WorldJoinRequestModel
+	if err := model.GetDB().Where("user_id = ?", userID).Order("created_at DESC").Limit(100).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return buildWorldJoinRequestViews(rows, true)
+}
+
+func buildWorldJoinRequestViews(rows []*model.WorldJoinRequestModel, includeWorld bool) ([]*WorldJoinRequestView, error) {
+	userIDs := make([]string, 0, len(rows))
+	worldIDs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		userIDs = append(userIDs, row.UserID)
+		worldIDs = append(worldIDs, row.WorldID)
+	}
+	users := map[string]model.UserModel{}
+	if len(userIDs) > 0 {
+		var list []model.UserModel
+		if err := model.GetDB().Select("id", "username", "nickname", "avatar").Where("id IN ?", userIDs).Find(&list).Error; err != nil {
+			return nil, err
+		}
+		for _, user := range list {
+			users[user.ID] = user
+		}
+	}
+	worldNames := map[string]string{}
+	if includeWorld && len(worldIDs) > 0 {
+		var worlds []model.WorldModel
+		if err := model.GetDB().Select("id", "name").Where("id IN ?", worldIDs).Find(&worlds).Error; err != nil {
+			return nil, err
+		}
+		for _, world := range worlds {
+			worldNames[world.ID] = world.Name
+		}
+	}
+	views := make([]*WorldJoinRequestView, 0, len(rows))
+	for _, row := range rows {
+		user := users[row.UserID]
+		views = append(views, &WorldJoinRequestView{
+			WorldJoinRequestModel: row, Username: user.Username, Nickname: user.Nickname, Avatar: user.Avatar,
+			WorldName: worldNames[row.WorldID],
+		})
+	}
+	return views, nil
+}
+
+func GetWorldJoinRequest(requestID string) (*model.WorldJoinRequestModel, error) {
+	var request model.WorldJoinRequestModel
+	if err := model.GetDB().Where("id = ?", strings.TrimSpace(requestID)).Limit(1).Find(&request).Error; err != nil {
+		return nil, err
+	}
+	if request.ID == "" {
+		return nil, ErrWorldJoinRequestNotFound
+	}
+	return &request, nil
+}
+
+// WorldJoinRequestReview 审核申请：approve 加入世界，reject 拒绝，ban 拒绝并封禁该用户
+func WorldJoinRequestReview(worldID, requestID, actorID, decision, note string) (*model.WorldJoinRequestModel, error) {
+	if !canReviewWorldJoinRequests(worldID, actorID) {
+		return nil, ErrWorldPermission
+	}
+	request, err := GetWorldJoinRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.WorldID != worldID {
+		return nil, ErrWorldJoinRequestNotFound
+	}
+	if request.Status != model.WorldJoinRequestStatusPending {
+		return nil, ErrWorldJoinRequestReviewed
+	}
+	note = strings.TrimSpace(note)
+	if len([]rune(note)) > worldJoinReviewNoteMaxRunes {
+		note = string([]rune(note)[:worldJoinReviewNoteMaxRunes])
+	}
+	var status string
+	switch decision {
+	case WorldJoinRequestDecisionApprove:
+		status = model.WorldJoinRequestStatusApproved
+	case WorldJoinRequestDecisionReject:
+		status = model.WorldJoinRequestStatusRejected
+	case WorldJoinRequestDecisionBan:
+		status = model.WorldJoinRequestStatusBanned
+	default:
+		return nil, ErrWorldJoinDecisionInvalid
+	}
+	now := time.Now()
+	err = model.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.WorldJoinRequestModel{}).
+			Where("id = ? AND status = ?", request.ID, model.WorldJoinRequestStatusPending).
+			Updates(map[string]any{"status": status, "reviewer_id": actorID, "review_note": note, "reviewed_at": &now, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrWorldJoinRequestReviewed
+		}
+		if status == model.WorldJoinRequestStatusBanned {
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.WorldBanModel{
+				WorldID: worldID, UserID: request.UserID, Reason: note, CreatedBy: actorID,
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if status == model.WorldJoinRequestStatusApproved {
+		if _, err := WorldJoin(worldID, request.UserID, request.Role); err != nil {
+			return nil, err
+		}
+		if request.InviteID != "" {
+			_ = model.GetDB().Model(&model.WorldInviteModel{}).Where("id = ?", request.InviteID).
+				Updates(map[string]any{"used_count": gorm.Expr("used_count + 1"), "updated_at": now}).Error
+		}
+	}
+	request.Status = status
+	request.ReviewerID = actorID
+	request.ReviewNote = note
+	request.ReviewedAt = &now
+	return request, nil
+}
+
+func WorldBanList(worldID, actorID string) ([]*model.WorldBanModel, error) {
+	if !canReviewWorldJoinRequests(worldID, actorID) {
+		return nil, ErrWorldPermission
+	}
+	var items []*model.WorldBanModel
+	err := model.GetDB().Where("world_id = ?", worldID).Order("created_at DESC").Find(&items).Error
+	return items, err
+}
+
+func WorldUnban(worldID, userID, actorID string) error {
+	if !canReviewWorldJoinRequests(worldID, actorID) {
+		return ErrWorldPermission
+	}
+	return model.GetDB().Where("world_id = ? AND user_id = ?", worldID, userID).Delete(&model.WorldBanModel{}).Error
+}
+
+// WorldJoinReviewerIDs 返回可以审核申请的世界管理员
+func WorldJoinReviewerIDs(worldID string) []string {
+	var ids []string
+	_ = model.GetDB().Model(&model.WorldMemberModel{}).
+		Where("world_id = ? AND role IN ?", worldID, []string{model.WorldRoleOwner, model.WorldRoleAdmin}).
+		Pluck("user_id", &ids).Error
+	return ids
+}
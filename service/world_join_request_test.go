@@ -0,0 +1,61 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"sealchat/model"
+)
+
+func TestNormalizeWorldJoinQuestions(t *testing.T) {
+	questions, err := normalizeWorldJoinQuestions([]model.WorldJoinQuestion{
+		{ID: "q1", Prompt: "  为什么想加入？ ", Required: true},
+		{ID: "q1", Prompt: "重复的 ID"},
+		{Prompt: "   "},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(questions) != 2 {
+		t.Fatalf("expected blank question dropped, got %d", len(questions))
+	}
+	if questions[0].ID != "q1" || questions[0].Prompt != "为什么想加入？" {
+		t.Fatalf("unexpected first question: %+v", questions[0])
+	}
+	if questions[1].ID == "" || questions[1].ID == "q1" {
+		t.Fatalf("duplicate id should be regenerated: %+v", questions[1])
+	}
+
+	tooMany := make([]model.WorldJoinQuestion, worldJoinQuestionMaxCount+1)
+	for i := range tooMany {
+		tooMany[i].Prompt = "问题"
+	}
+	if _, err := normalizeWorldJoinQuestions(tooMany); !errors.Is(err, ErrWorldJoinQuestionsInvalid) {
+		t.Fatalf("expected too many questions rejected, got %v", err)
+	}
+}
+
+func TestNormalizeWorldJoinAnswers(t *testing.T) {
+	questions := []model.WorldJoinQuestion{
+		{ID: "q1", Prompt: "角色名", Required: true},
+		{ID: "q2", Prompt: "备注"},
+	}
+	if _, err := normalizeWorldJoinAnswers(questions, nil); !errors.Is(err, ErrWorldJoinAnswerInvalid) {
+		t.Fatalf("expected required answer error, got %v", err)
+	}
+	answers, err := normalizeWorldJoinAnswers(questions, []model.WorldJoinAnswer{
+		{QuestionID: "q1", Answer: " 艾琳 "},
+		{QuestionID: "unknown", Answer: "ignored"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(answers) != 2 || answers[0].Answer != "艾琳" || answers[0].Prompt != "角色名" || answers[1].Answer != "" {
+		t.Fatalf("unexpected answers: %+v", answers)
+	}
+	long := []model.WorldJoinAnswer{{QuestionID: "q1", Answer: strings.Repeat("长", worldJoinAnswerMaxRunes+1)}}
+	if _, err := normalizeWorldJoinAnswers(questions, long); !errors.Is(err, ErrWorldJoinAnswerInvalid) {
+		t.Fatalf("expected long answer rejected, got %v", err)
+	}
+}
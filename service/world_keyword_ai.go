@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sealchat/model"
+	aiService "sealchat/service/ai"
+	"sealchat/utils"
+)
+
+const (
+	WorldKeywordConflictKeyword = "keyword"
+	WorldKeywordConflictAlias   = "alias"
+
+	// worldKeywordExtractMaxWindows 限制单次提取的调用次数，避免误选超长时间范围
+	worldKeywordExtractMaxWindows = 8
+)
+
+var ErrWorldKeywordExtractUnavailable = errors.New("术语提取功能未开启或无权使用")
+
+type WorldKeywordExtractInput struct {
+	ChannelIDs  []string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Source      string
+	AIConfig    utils.AIConfig
+	Runner      aiService.TaskRunner
+}
+
+// WorldKeywordSuggestion AI 提议的词条；与已有词条重名或命中别名时带上冲突信息供管理员取舍
+type WorldKeywordSuggestion struct {
+	Keyword             string   `json:"keyword"`
+	Category            string   `json:"category"`
+	Aliases             []string `json:"aliases"`
+	Description         string   `json:"description"`
+	Conflict            string   `json:"conflict,omitempty"`
+	ExistingID          string   `json:"existingId,omitempty"`
+	ExistingKeyword     string   `json:"existingKeyword,omitempty"`
+	ExistingDescription string   `json:"existingDescription,omitempty"`
+}
+
+type WorldKeywordExtractResult struct {
+	Suggestions []WorldKeywordSuggestion `json:"suggestions"`
+	Windows     int                      `json:"windows"`
+	ProviderID  string                   `json:"providerId,omitempty"`
+	Model       string                   `json:"model,omitempty"`
+	// 失败或无法解析而被跳过的分段，其余分段的结果照常返回
+	FailedWindows int      `json:"failedWindows,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+type worldKeywordExtractItem struct {
+	Keyword     string   `json:"keyword"`
+	Term        string   `json:"term"`
+	Name        string   `json:"name"`
+	Category    string   `json:"category"`
+	Aliases     []string `json:"aliases"`
+	Description string   `json:"description"`
+}
+
+// WorldKeywordExtract 扫描频道记录，由 AI 提议新词条并标记与现有词条的冲突；结果不落库，确认后经 WorldKeywordImport 导入
+func WorldKeywordExtract(ctx context.Context, worldID string, user *model.UserModel, input WorldKeywordExtractInput) (*WorldKeywordExtractResult, error) {
+	worldID = strings.TrimSpace(worldID)
+	if user == nil {
+		return nil, fmt.Errorf("缺少用户信息")
+	}
+	if err := ensureWorldKeywordPermission(worldID, user.ID, true); err != nil {
+		return nil, err
+	}
+	if !aiService.IsFeatureAvailable(utils.NormalizeAIConfig(input.AIConfig), aiService.FeatureGlossaryExtract, user.ID, worldID) {
+		return nil, ErrWorldKeywordExtractUnavailable
+	}
+	if len(input.ChannelIDs) == 0 {
+		return nil, fmt.Errorf("请选择要扫描的频道")
+	}
+	channels, err := resolveBattleReportSourceChannels(input.ChannelIDs[0], worldID, input.ChannelIDs, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	messageGroups, err := loadBattleReportMessageGroups(channels, input.PeriodStart, input.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	if battleReportMessageGroupLen(messageGroups) == 0 {
+		return nil, fmt.Errorf("所选时间范围内没有可提取的消息")
+	}
+
+	categories, err := WorldKeywordListCategories(worldID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	prompts := buildWorldKeywordExtractPrompts(messageGroups, categories, worldKeywordExtractBudget(input.AIConfig))
+	if len(prompts) > worldKeywordExtractMaxWindows {
+		return nil, fmt.Errorf("所选范围过长（需分 %d 段，最多 %d 段），请缩短时间范围", len(prompts), worldKeywordExtractMaxWindows)
+	}
+	if strings.EqualFold(strings.TrimSpace(input.Source), "platform") {
+		total := 0.0
+		for _, prompt := range prompts {
+			_, _, cost, err := aiService.EstimatePlatformReservation(input.AIConfig, aiService.FeatureGlossaryExtract, prompt)
+			if err != nil {
+				return nil, err
+			}
+			total += cost
+		}
+		if err := aiService.EnsureQuotaAvailable(input.AIConfig, user.ID, total, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &WorldKeywordExtractResult{Windows: len(prompts)}
+	items, err := runWorldKeywordExtractWindows(ctx, prompts, result, func(prompt string) (aiService.BilledRunOutput, error) {
+		return aiService.RunTaskWithBilling(ctx, aiService.BilledRunInput{
+			Config:     input.AIConfig,
+			User:       user,
+			FeatureKey: aiService.FeatureGlossaryExtract,
+			WorldID:    worldID,
+			Input:      prompt,
+			Source:     input.Source,
+			Runner:     input.Runner,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []*model.WorldKeywordModel
+	if err := model.GetDB().Where("world_id = ?", worldID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	result.Suggestions = mergeWorldKeywordSuggestions(items, existing)
+	return result, nil
+}
+
+// runWorldKeywordExtractWindows 逐段调用 AI。每段都已单独计费，某段失败或输出无法解析时跳过并记入警告，
+// 保留其余段的结果；只有全部失败时才返回错误
+func runWorldKeywordExtractWindows(ctx context.Context, prompts []string, result *WorldKeywordExtractResult, run func(prompt string) (aiService.BilledRunOutput, error)) ([]worldKeywordExtractItem, error) {
+	var (
+		items     []worldKeywordExtractItem
+		lastErr   error
+		succeeded int
+	)
+	for index, prompt := range prompts {
+		if ctx.Err() != nil {
+			lastErr = ctx.Err()
+			result.FailedWindows += len(prompts) - index
+			result.Warnings = append(result.Warnings, fmt.Sprintf("第 %d 段起已取消", index+1))
+			break
+		}
+		output, err := run(prompt)
+		if err != nil {
+			lastErr = err
+			result.FailedWindows++
+			result.Warnings = append(result.Warnings, fmt.Sprintf("第 %d 段提取失败：%v", index+1, err))
+			continue
+		}
+		parsed, err := parseWorldKeywordExtractOutput(output.Result.Result)
+		if err != nil {
+			lastErr = err
+			result.FailedWindows++
+			result.Warnings = append(result.Warnings, fmt.Sprintf("第 %d 段输出无法解析：%v", index+1, err))
+			continue
+		}
+		succeeded++
+		items = append(items, parsed...)
+		result.ProviderID = output.Result.ProviderID
+		result.Model = output.Result.Model
+	}
+	if succeeded == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return items, nil
+}
+
+// WorldKeywordAcceptSuggestions 批量采纳提议；replace 为 true 时覆盖同名词条，否则冲突项计为跳过
+func WorldKeywordAcceptSuggestions(worldID, actorID string, suggestions []WorldKeywordSuggestion, replace bool) (*WorldKeywordImportStats, error) {
+	entries := make([]WorldKeywordInput, 0, len(suggestions))
+	for _, item := range suggestions {
+		entries = append(entries, WorldKeywordInput{
+			Keyword:     item.Keyword,
+			Category:    item.Category,
+			Aliases:     item.Aliases,
+			Description: item.Description,
+		})
+	}
+	return WorldKeywordImport(worldID, actorID, entries, replace)
+}
+
+func worldKeywordExtractBudget(cfg utils.AIConfig) int {
+	normalized := utils.NormalizeAIConfig(cfg)
+	if maxInputChars := normalized.Features[aiService.FeatureGlossaryExtract].Params.MaxInputChars; maxInputChars > 0 {
+		return maxInputChars
+	}
+	return aiService.BuiltinFeatures()[aiService.FeatureGlossaryExtract].InputMaxChars
+}
+
+func buildWorldKeywordExtractPrompts(messageGroups []BattleReportMessageGroup, categories []string, budget int) []string {
+	header := buildWorldKeywordExtractHeader(categories)
+	overhead := countBattleReportInputChars(buildWorldKeywordExtractPrompt(header, nil))
+	windows := splitBattleReportWindows(battleReportLineGroups(messageGroups), budget-overhead)
+	prompts := make([]string, 0, len(windows))
+	for _, window := range windows {
+		prompts = append(prompts, buildWorldKeywordExtractPrompt(header, window))
+	}
+	return prompts
+}
+
+func buildWorldKeywordExtractHeader(categories []string) string {
+	var builder strings.Builder
+	builder.WriteString("请从以下跑团记录中提取世界观词条（人物、地点、组织、物品等），按约定的 JSON 格式输出。\n")
+	if len(categories) > 0 {
+		builder.WriteString("世界已有分类（优先沿用）：")
+		builder.WriteString(strings.Join(categories, "、"))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func buildWorldKeywordExtractPrompt(header string, window []battleReportLineGroup) string {
+	var builder strings.Builder
+	builder.WriteString(header)
+	builder.WriteString("\n记录：\n")
+	withHeading := len(window) > 1
+	for _, group := range window {
+		if withHeading {
+			builder.WriteString("## ")
+			builder.WriteString(group.Name)
+			builder.WriteString("\n")
+		}
+		for _, line := range group.Lines {
+			builder.WriteString(line)
+			builder.WriteString("\n")
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// parseWorldKeywordExtractOutput 兼容代码块包裹与 {"items": [...]} 形式的输出
+func parseWorldKeywordExtractOutput(raw string) ([]worldKeywordExtractItem, error) {
+	text := strings.TrimSpace(raw)
+	if start := strings.Index(text, "```"); start >= 0 {
+		text = text[start+3:]
+		if newline := strings.Index(text, "\n"); newline >= 0 {
+			text = text[newline+1:]
+		}
+		if end := strings.Index(text, "```"); end >= 0 {
+			text = text[:end]
+		}
+		text = strings.TrimSpace(text)
+	}
+	if text == "" {
+		return nil, nil
+	}
+	var items []worldKeywordExtractItem
+	if strings.HasPrefix(text, "{") {
+		var wrapped map[string][]worldKeywordExtractItem
+		if err := json.Unmarshal([]byte(text), &wrapped); err == nil {
+			for _, list := range wrapped {
+				items = append(items, list...)
+			}
+			return items, nil
+		}
+	}
+	start := strings.Index(text, "[")
+	end := strings.LastIndex(text, "]")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("AI 返回的术语格式无法解析")
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &items); err != nil {
+		return nil, fmt.Errorf("AI 返回的术语格式无法解析: %w", err)
+	}
+	return items, nil
+}
+
+// mergeWorldKeywordSuggestions 按词条名去重合并各分段结果，并与现有词条的名称、别名比对
+func mergeWorldKeywordSuggestions(items []worldKeywordExtractItem, existing []*model.WorldKeywordModel) []WorldKeywordSuggestion {
+	byKeyword := map[string]*model.WorldKeywordModel{}
+	byAlias := map[string]*model.WorldKeywordModel{}
+	for _, item := range existing {
+		byKeyword[strings.ToLower(strings.TrimSpace(item.Keyword))] = item
+		for _, alias := range item.Aliases {
+			if key := strings.ToLower(strings.TrimSpace(alias)); key != "" {
+				byAlias[key] = item
+			}
+		}
+	}
+
+	out := make([]WorldKeywordSuggestion, 0, len(items))
+	index := map[string]int{}
+	for _, item := range items {
+		keyword := strings.TrimSpace(firstNonEmptyWorldKeywordValue(item.Keyword, item.Term, item.Name))
+		if keyword == "" {
+			continue
+		}
+		key := strings.ToLower(keyword)
+		if pos, ok := index[key]; ok {
+			merged := &out[pos]
+			merged.Aliases = normalizeWorldKeywordSuggestionAliases(merged.Keyword, append(merged.Aliases, item.Aliases...))
+			if merged.Description == "" {
+				merged.Description = strings.TrimSpace(item.Description)
+			}
+			if merged.Category == "" {
+				merged.Category = strings.TrimSpace(item.Category)
+			}
+			continue
+		}
+		suggestion := WorldKeywordSuggestion{
+			Keyword:     keyword,
+			Category:    strings.TrimSpace(item.Category),
+			Aliases:     normalizeWorldKeywordSuggestionAliases(keyword, item.Aliases),
+			Description: strings.TrimSpace(item.Description),
+		}
+		index[key] = len(out)
+		out = append(out, suggestion)
+	}
+
+	for i := range out {
+		suggestion := &out[i]
+		key := strings.ToLower(suggestion.Keyword)
+		var hit *model.WorldKeywordModel
+		if match, ok := byKeyword[key]; ok {
+			hit = match
+			suggestion.Conflict = WorldKeywordConflictKeyword
+		} else if match, ok := byAlias[key]; ok {
+			hit = match
+			suggestion.Conflict = WorldKeywordConflictAlias
+		} else {
+			for _, alias := range suggestion.Aliases {
+				aliasKey := strings.ToLower(alias)
+				if match, ok := byKeyword[aliasKey]; ok {
+					hit = match
+				} else if match, ok := byAlias[aliasKey]; ok {
+					hit = match
+				}
+				if hit != nil {
+					suggestion.Conflict = WorldKeywordConflictAlias
+					break
+				}
+			}
+		}
+		if hit != nil {
+			suggestion.ExistingID = hit.ID
+			suggestion.ExistingKeyword = hit.Keyword
+			suggestion.ExistingDescription = hit.Description
+		}
+	}
+	return out
+}
+
+func normalizeWorldKeywordSuggestionAliases(keyword string, aliases []string) []string {
+	out := make([]string, 0, len(aliases))
+	seen := map[string]struct{}{strings.ToLower(keyword): {}}
+	for _, raw := range aliases {
+		trimmed := strings.TrimSpace(raw)
+		lower := strings.ToLower(trimmed)
+		if trimmed == "" {
+			continue
+		}
+		if _, exists := seen[lower]; exists {
+			continue
+		}
+		seen[lower] = struct{}{}
+		out = append(out, trimmed)
+	}
+	return out
+}
+
+func firstNonEmptyWorldKeywordValue(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
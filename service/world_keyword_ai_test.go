@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sealchat/model"
+	aiService "sealchat/service/ai"
+	"sealchat/utils"
+)
+
+func TestMergeWorldKeywordSuggestionsFlagsConflicts(t *testing.T) {
+	items, err := parseWorldKeywordExtractOutput("以下是提取结果：\n```json\n" + `[
+		{"keyword": "黑塔", "category": "地点", "description": "城北的废弃高塔"},
+		{"term": "艾琳", "category": "人物", "aliases": ["小艾"], "description": "侦探"},
+		{"keyword": "银钥匙", "category": "物品", "aliases": ["钥匙", "银钥匙"]},
+		{"keyword": "黑塔", "aliases": ["北塔"]},
+		{"keyword": "旧港", "aliases": ["港口"]}
+	]` + "\n```")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	existing := []*model.WorldKeywordModel{
+		{StringPKBaseModel: model.StringPKBaseModel{ID: "kw-1"}, Keyword: "艾琳", Description: "旧描述"},
+		{StringPKBaseModel: model.StringPKBaseModel{ID: "kw-2"}, Keyword: "塔楼", Aliases: model.JSONList[string]{"黑塔"}},
+		{StringPKBaseModel: model.StringPKBaseModel{ID: "kw-3"}, Keyword: "港口"},
+	}
+	got := mergeWorldKeywordSuggestions(items, existing)
+	if len(got) != 4 {
+		t.Fatalf("expected 4 merged suggestions, got %#v", got)
+	}
+	byKeyword := map[string]WorldKeywordSuggestion{}
+	for _, item := range got {
+		byKeyword[item.Keyword] = item
+	}
+	if tower := byKeyword["黑塔"]; tower.Conflict != WorldKeywordConflictAlias || tower.ExistingID != "kw-2" || len(tower.Aliases) != 1 || tower.Aliases[0] != "北塔" {
+		t.Fatalf("unexpected merged tower suggestion: %#v", tower)
+	}
+	if irene := byKeyword["艾琳"]; irene.Conflict != WorldKeywordConflictKeyword || irene.ExistingDescription != "旧描述" {
+		t.Fatalf("term field should map to keyword and conflict with existing: %#v", irene)
+	}
+	if key := byKeyword["银钥匙"]; key.Conflict != "" || len(key.Aliases) != 1 {
+		t.Fatalf("new keyword should have no conflict and drop self alias: %#v", key)
+	}
+	if port := byKeyword["旧港"]; port.Conflict != WorldKeywordConflictAlias || port.ExistingID != "kw-3" {
+		t.Fatalf("alias hitting existing keyword should be flagged: %#v", port)
+	}
+}
+
+func TestWorldKeywordExtractRespectsFeatureAccess(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	owner := createExternalGlossaryTestUser(t, "glossary-owner")
+	createExternalGlossaryTestWorld(t, "glossary-world", owner.ID)
+	createExternalGlossaryTestWorldMember(t, "glossary-world", owner.ID, model.WorldRoleOwner)
+
+	_, err := WorldKeywordExtract(context.Background(), "glossary-world", owner, WorldKeywordExtractInput{
+		ChannelIDs: []string{"missing"},
+		AIConfig:   utils.AIConfig{},
+	})
+	if !errors.Is(err, ErrWorldKeywordExtractUnavailable) {
+		t.Fatalf("disabled feature should be rejected, got %v", err)
+	}
+
+	stats, err := WorldKeywordAcceptSuggestions("glossary-world", owner.ID, []WorldKeywordSuggestion{
+		{Keyword: "黑塔", Category: "地点", Aliases: []string{"北塔"}, Description: "城北的废弃高塔"},
+		{Keyword: " "},
+	}, false)
+	if err != nil {
+		t.Fatalf("accept suggestions failed: %v", err)
+	}
+	if stats.Created != 1 || stats.Skipped != 1 {
+		t.Fatalf("unexpected import stats: %#v", stats)
+	}
+}
+
+func TestWorldKeywordExtractKeepsPaidWindowsOnFailure(t *testing.T) {
+	outputs := map[string]string{
+		"w1": `[{"keyword":"黑塔","category":"地点"}]`,
+		"w2": "抱歉，我无法完成",
+		"w4": `[{"keyword":"银钥匙","category":"物品"}]`,
+	}
+	result := &WorldKeywordExtractResult{Windows: 4}
+	items, err := runWorldKeywordExtractWindows(context.Background(), []string{"w1", "w2", "w3", "w4"}, result, func(prompt string) (aiService.BilledRunOutput, error) {
+		if prompt == "w3" {
+			return aiService.BilledRunOutput{}, errors.New("upstream timeout")
+		}
+		return aiService.BilledRunOutput{Result: aiService.RunResult{Result: outputs[prompt], Model: "m"}}, nil
+	})
+	if err != nil {
+		t.Fatalf("partial failure should not fail the request: %v", err)
+	}
+	if len(items) != 2 || result.FailedWindows != 2 || len(result.Warnings) != 2 {
+		t.Fatalf("unexpected partial result: items=%#v result=%#v", items, result)
+	}
+
+	result = &WorldKeywordExtractResult{Windows: 1}
+	if _, err := runWorldKeywordExtractWindows(context.Background(), []string{"w2"}, result, func(prompt string) (aiService.BilledRunOutput, error) {
+		return aiService.BilledRunOutput{Result: aiService.RunResult{Result: outputs[prompt]}}, nil
+	}); err == nil {
+		t.Fatalf("all windows failing should return an error")
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sealchat/model"
+)
+
+const worldMuteMaxDuration = 30 * 24 * time.Hour
+
+var (
+	ErrWorldMemberMuted       = errors.New("你已在该世界被禁言")
+	ErrWorldMuteDurationRange = errors.New("禁言时长无效")
+)
+
+// WorldMuteMember 禁言世界成员，duration 为 0 时解除禁言；管理员之间仅拥有者可操作
+func WorldMuteMember(worldID, actorID, targetUserID string, duration time.Duration) (*time.Time, error) {
+	targetUserID = strings.TrimSpace(targetUserID)
+	if targetUserID == "" || targetUserID == actorID {
+		return nil, ErrWorldMemberInvalid
+	}
+	if duration < 0 || duration > worldMuteMaxDuration {
+		return nil, ErrWorldMuteDurationRange
+	}
+	if !IsWorldAdmin(worldID, actorID) {
+		return nil, ErrWorldPermission
+	}
+	if IsWorldOwner(worldID, targetUserID) {
+		return nil, ErrWorldOwnerImmutable
+	}
+	if IsWorldAdmin(worldID, targetUserID) && !IsWorldOwner(worldID, actorID) {
+		return nil, ErrWorldPermission
+	}
+	var until *time.Time
+	if duration > 0 {
+		value := time.Now().Add(duration)
+		until = &value
+	}
+	res := model.GetDB().Model(&model.WorldMemberModel{}).
+		Where("world_id = ? AND user_id = ?", worldID, targetUserID).
+		Updates(map[string]any{"muted_until": until, "updated_at": time.Now()})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrWorldMemberInvalid
+	}
+	return until, nil
+}
+
+// WorldMemberMutedUntil 返回成员当前的禁言截止时间，未禁言返回 nil
+func WorldMemberMutedUntil(worldID, userID string) *time.Time {
+	if strings.TrimSpace(worldID) == "" || strings.TrimSpace(userID) == "" {
+		return nil
+	}
+	var member model.WorldMemberModel
+	if err := model.GetDB().Select("muted_until").
+		Where("world_id = ? AND user_id = ? AND muted_until > ?", worldID, userID, time.Now()).
+		Limit(1).Find(&member).Error; err != nil {
+		return nil
+	}
+	return member.MutedUntil
+}
+
+// CheckChannelSendMuted 校验用户在频道所属世界中是否处于禁言状态
+func CheckChannelSendMuted(channelID, userID string) error {
+	ch, err := model.ChannelGet(channelID)
+	if err != nil || ch == nil || strings.TrimSpace(ch.WorldID) == "" {
+		return nil
+	}
+	if until := WorldMemberMutedUntil(ch.WorldID, userID); until != nil {
+		return fmt.Errorf("%w，解除时间：%s", ErrWorldMemberMuted, until.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/pm"
+)
+
+const (
+	worldOwnershipTransferTTL          = 7 * 24 * time.Hour
+	worldOwnershipTransferNoteMaxRunes = 500
+)
+
+var (
+	ErrWorldOwnershipTransferNotFound = errors.New("所有权转移请求不存在")
+	ErrWorldOwnershipTransferResolved = errors.New("所有权转移请求已处理或已过期")
+	ErrWorldOwnershipTargetInvalid    = errors.New("接收人必须是有效的非机器人用户")
+	ErrWorldOwnershipTargetNotMember  = errors.New("接收人必须是世界成员")
+	ErrWorldOwnerStillActive          = errors.New("世界拥有者账号仍然有效，无法强制转移")
+)
+
+// WorldOwnerAbandoned 判断世界拥有者账号是否已停用、删除或不存在
+func WorldOwnerAbandoned(world *model.WorldModel) bool {
+	if world == nil {
+		return false
+	}
+	ownerID := strings.TrimSpace(world.OwnerID)
+	if ownerID == "" {
+		return true
+	}
+	var owner model.UserModel
+	if err := model.GetDB().Where("id = ?", ownerID).Limit(1).Find(&owner).Error; err != nil {
+		return false
+	}
+	return owner.ID == "" || owner.Disabled || owner.DeletedAt != nil
+}
+
+func loadWorldOwnershipTarget(userID string) (*model.UserModel, error) {
+	var user model.UserModel
+	if err := model.GetDB().Where("id = ?", strings.TrimSpace(userID)).Limit(1).Find(&user).Error; err != nil {
+		return nil, err
+	}
+	if user.ID == "" || user.IsBot || user.Disabled || user.DeletedAt != nil {
+		return nil, ErrWorldOwnershipTargetInvalid
+	}
+	return &user, nil
+}
+
+func trimWorldOwnershipNote(note string) string {
+	note = strings.TrimSpace(note)
+	if len([]rune(note)) > worldOwnershipTransferNoteMaxRunes {
+		note = string([]rune(note)[:worldOwnershipTransferNoteMaxRunes])
+	}
+	return note
+}
+
+// WorldOwnershipTransferRequest 拥有者发起转移，需接收人确认后生效；同一世界仅保留一个待处理请求
+func WorldOwnershipTransferRequest(worldID, actorID, targetUserID, note string) (*model.WorldOwnershipTransferModel, error) {
+	world, err := GetWorldByID(worldID)
+	if err != nil {
+		return nil, err
+	}
+	if world.Status != "active" {
+		return nil, ErrWorldNotFound
+	}
+	if world.OwnerID != actorID || !IsWorldOwner(worldID, actorID) {
+		return nil, ErrWorldPermission
+	}
+	if targetUserID == actorID {
+		return nil, ErrWorldOwnershipTargetInvalid
+	}
+	if _, err := loadWorldOwnershipTarget(targetUserID); err != nil {
+		return nil, err
+	}
+	if !IsWorldMember(worldID, targetUserID) {
+		return nil, ErrWorldOwnershipTargetNotMember
+	}
+	now := time.Now()
+	expiresAt := now.Add(worldOwnershipTransferTTL)
+	transfer := &model.WorldOwnershipTransferModel{
+		WorldID:    worldID,
+		FromUserID: actorID,
+		ToUserID:   targetUserID,
+		ActorID:    actorID,
+		Status:     model.WorldOwnershipTransferStatusPending,
+		Note:       trimWorldOwnershipNote(note),
+		ExpiresAt:  &expiresAt,
+	}
+	err = model.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.WorldOwnershipTransferModel{}).
+			Where("world_id = ? AND status = ?", worldID, model.WorldOwnershipTransferStatusPending).
+			Updates(map[string]any{"status": model.WorldOwnershipTransferStatusCancelled, "resolved_at": &now, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.Create(transfer).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func getPendingWorldOwnershipTransfer(transferID string) (*model.WorldOwnershipTransferModel, error) {
+	var transfer model.WorldOwnershipTransferModel
+	if err := model.GetDB().Where("id = ?", strings.TrimSpace(transferID)).Limit(1).Find(&transfer).Error; err != nil {
+		return nil, err
+	}
+	if transfer.ID == "" {
+		return nil, ErrWorldOwnershipTransferNotFound
+	}
+	if transfer.Status != model.WorldOwnershipTransferStatusPending {
+		return nil, ErrWorldOwnershipTransferResolved
+	}
+	if transfer.ExpiresAt != nil && transfer.ExpiresAt.Before(time.Now()) {
+		_ = resolveWorldOwnershipTransfer(model.GetDB(), &transfer, model.WorldOwnershipTransferStatusExpired)
+		return nil, ErrWorldOwnershipTransferResolved
+	}
+	return &transfer, nil
+}
+
+func resolveWorldOwnershipTransfer(tx *gorm.DB, transfer *model.WorldOwnershipTransferModel, status string) error {
+	now := time.Now()
+	result := tx.Model(&model.WorldOwnershipTransferModel{}).
+		Where("id = ? AND status = ?", transfer.ID, model.WorldOwnershipTransferStatusPending).
+		Updates(map[string]any{"status": status, "resolved_at": &now, "updated_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWorldOwnershipTransferResolved
+	}
+	transfer.Status = status
+	transfer.ResolvedAt = &now
+	return nil
+}
+
+// WorldOwnershipTransferAccept 接收人确认转移，原拥有者降为管理员
+func WorldOwnershipTransferAccept(transferID, userID string) (*model.WorldOwnershipTransferModel, *model.WorldModel, error) {
+	transfer, err := getPendingWorldOwnershipTransfer(transferID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if transfer.ToUserID != userID {
+		return nil, nil, ErrWorldOwnershipTransferNotFound
+	}
+	world, err := GetWorldByID(transfer.WorldID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 请求发出后拥有者已变更（例如被强制转移）时请求作废
+	if world.Status != "active" || world.OwnerID != transfer.FromUserID {
+		_ = resolveWorldOwnershipTransfer(model.GetDB(), transfer, model.WorldOwnershipTransferStatusCancelled)
+		return nil, nil, ErrWorldOwnershipTransferResolved
+	}
+	if !IsWorldMember(world.ID, userID) {
+		return nil, nil, ErrWorldOwnershipTargetNotMember
+	}
+	err = model.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := resolveWorldOwnershipTransfer(tx, transfer, model.WorldOwnershipTransferStatusAccepted); err != nil {
+			return err
+		}
+		return applyWorldOwnerChange(tx, world.ID, transfer.FromUserID, transfer.ToUserID, model.WorldRoleAdmin)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	world, err = finishWorldOwnerChange(world.ID, transfer.FromUserID, transfer.ToUserID)
+	return transfer, world, err
+}
+
+func WorldOwnershipTransferDecline(transferID, userID string) (*model.WorldOwnershipTransferModel, error) {
+	transfer, err := getPendingWorldOwnershipTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.ToUserID != userID {
+		return nil, ErrWorldOwnershipTransferNotFound
+	}
+	if err := resolveWorldOwnershipTransfer(model.GetDB(), transfer, model.WorldOwnershipTransferStatusDeclined); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func WorldOwnershipTransferCancel(transferID, userID string) (*model.WorldOwnershipTransferModel, error) {
+	transfer, err := getPendingWorldOwnershipTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.FromUserID != userID {
+		return nil, ErrWorldOwnershipTransferNotFound
+	}
+	if err := resolveWorldOwnershipTransfer(model.GetDB(), transfer, model.WorldOwnershipTransferStatusCancelled); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// WorldOwnershipTransferList 返回世界的转移记录，仅世界管理员与平台管理员可见
+func WorldOwnershipTransferList(worldID, actorID string) ([]*model.WorldOwnershipTransferModel, error) {
+	if !IsWorldAdmin(worldID, actorID) && !pm.CanWithSystemRole(actorID, pm.PermModAdmin) {
+		return nil, ErrWorldPermission
+	}
+	var items []*model.WorldOwnershipTransferModel
+	err := model.GetDB().Where("world_id = ?", worldID).Order("created_at DESC").Limit(100).Find(&items).Error
+	return items, err
+}
+
+// WorldOwnershipTransferListIncoming 返回等待当前用户确认的转移请求
+func WorldOwnershipTransferListIncoming(userID string) ([]*model.WorldOwnershipTransferModel, error) {
+	var items []*model.WorldOwnershipTransferModel
+	err := model.GetDB().
+		Where("to_user_id = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)", userID, model.WorldOwnershipTransferStatusPending, time.Now()).
+		Order("created_at DESC").Find(&items).Error
+	return items, err
+}
+
+// AdminListAbandonedWorlds 列出拥有者账号已停用或删除的活跃世界
+func AdminListAbandonedWorlds() ([]*model.WorldModel, error) {
+	var worlds []*model.WorldModel
+	err := model.GetDB().
+		Where("status = ?", "active").
+		Where("owner_id = '' OR owner_id IS NULL OR owner_id NOT IN (?)",
+			model.GetDB().Model(&model.UserModel{}).Select("id").Where("disabled = ? AND deleted_at IS NULL", false)).
+		Order("created_at ASC").
+		Find(&worlds).Error
+	return worlds, err
+}
+
+// AdminWorldForceTransfer 平台管理员将无人管理的世界转移给指定用户，接收人不是成员时会自动加入
+func AdminWorldForceTransfer(worldID, operatorID, targetUserID, note string) (*model.WorldOwnershipTransferModel, *model.WorldModel, error) {
+	world, err := GetWorldByID(worldID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if world.Status != "active" {
+		return nil, nil, ErrWorldNotFound
+	}
+	if !WorldOwnerAbandoned(world) {
+		return nil, nil, ErrWorldOwnerStillActive
+	}
+	if _, err := loadWorldOwnershipTarget(targetUserID); err != nil {
+		return nil, nil, err
+	}
+	if _, err := WorldJoin(worldID, targetUserID, model.WorldRoleMember); err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	transfer := &model.WorldOwnershipTransferModel{
+		WorldID:    worldID,
+		FromUserID: world.OwnerID,
+		ToUserID:   targetUserID,
+		ActorID:    operatorID,
+		Status:     model.WorldOwnershipTransferStatusForced,
+		Note:       trimWorldOwnershipNote(note),
+		ResolvedAt: &now,
+	}
+	err = model.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.WorldOwnershipTransferModel{}).
+			Where("world_id = ? AND status = ?", worldID, model.WorldOwnershipTransferStatusPending).
+			Updates(map[string]any{"status": model.WorldOwnershipTransferStatusCancelled, "resolved_at": &now, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+		return applyWorldOwnerChange(tx, worldID, world.OwnerID, targetUserID, model.WorldRoleMember)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	world, err = finishWorldOwnerChange(worldID, transfer.FromUserID, targetUserID)
+	return transfer, world, err
+}
+
+// applyWorldOwnerChange 更新 OwnerID 与成员角色，previousRole 为原拥有者的新角色
+func applyWorldOwnerChange(tx *gorm.DB, worldID, fromUserID, toUserID, previousRole string) error {
+	now := time.Now()
+	if err := tx.Model(&model.WorldModel{}).Where("id = ?", worldID).
+		Updates(map[string]any{"owner_id": toUserID, "updated_at": now}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.WorldMemberModel{}).
+		Where("world_id = ? AND user_id = ?", worldID, toUserID).
+		Updates(map[string]any{"role": model.WorldRoleOwner, "updated_at": now}).Error; err != nil {
+		return err
+	}
+	if strings.TrimSpace(fromUserID) == "" {
+		return nil
+	}
+	return tx.Model(&model.WorldMemberModel{}).
+		Where("world_id = ? AND user_id = ?", worldID, fromUserID).
+		Updates(map[string]any{"role": previousRole, "updated_at": now}).Error
+}
+
+// finishWorldOwnerChange 事务提交后重写双方的频道角色
+func finishWorldOwnerChange(worldID, fromUserID, toUserID string) (*model.WorldModel, error) {
+	if err := syncWorldChannelRoles(worldID, toUserID, model.WorldRoleOwner); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(fromUserID) != "" {
+		var member model.WorldMemberModel
+		if err := model.GetDB().Where("world_id = ? AND user_id = ?", worldID, fromUserID).Limit(1).Find(&member).Error; err != nil {
+			return nil, err
+		}
+		if member.ID != "" {
+			if err := syncWorldChannelRoles(worldID, fromUserID, member.Role); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return GetWorldByID(worldID)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+func createWorldOwnershipTestUser(t *testing.T, prefix string, disabled bool) string {
+	t.Helper()
+	userID := prefix + "-" + utils.NewID()
+	if err := model.GetDB().Create(&model.UserModel{
+		StringPKBaseModel: model.StringPKBaseModel{ID: userID},
+		Username:          userID,
+		Password:          "pw",
+		Salt:              "salt",
+		Disabled:          disabled,
+	}).Error; err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+	return userID
+}
+
+func createWorldOwnershipTestWorld(t *testing.T, ownerID string, memberIDs ...string) string {
+	t.Helper()
+	db := model.GetDB()
+	worldID := "world-ownership-" + utils.NewID()
+	if err := db.Create(&model.WorldModel{
+		StringPKBaseModel: model.StringPKBaseModel{ID: worldID},
+		Name:              "Ownership World",
+		Visibility:        model.WorldVisibilityPublic,
+		Status:            "active",
+		OwnerID:           ownerID,
+	}).Error; err != nil {
+		t.Fatalf("create world failed: %v", err)
+	}
+	roles := map[string]string{ownerID: model.WorldRoleOwner}
+	for _, memberID := range memberIDs {
+		roles[memberID] = model.WorldRoleMember
+	}
+	for userID, role := range roles {
+		if err := db.Create(&model.WorldMemberModel{
+			WorldID:  worldID,
+			UserID:   userID,
+			Role:     role,
+			JoinedAt: time.Now(),
+		}).Error; err != nil {
+			t.Fatalf("create world member failed: %v", err)
+		}
+	}
+	return worldID
+}
+
+func TestWorldOwnershipTransferAccept(t *testing.T) {
+	initTestDB(t)
+	ownerID := createWorldOwnershipTestUser(t, "owner", false)
+	targetID := createWorldOwnershipTestUser(t, "target", false)
+	outsiderID := createWorldOwnershipTestUser(t, "outsider", false)
+	worldID := createWorldOwnershipTestWorld(t, ownerID, targetID)
+
+	if _, err := WorldOwnershipTransferRequest(worldID, targetID, ownerID, ""); !errors.Is(err, ErrWorldPermission) {
+		t.Fatalf("expected non-owner request rejected, got %v", err)
+	}
+	if _, err := WorldOwnershipTransferRequest(worldID, ownerID, outsiderID, ""); !errors.Is(err, ErrWorldOwnershipTargetNotMember) {
+		t.Fatalf("expected non-member target rejected, got %v", err)
+	}
+	transfer, err := WorldOwnershipTransferRequest(worldID, ownerID, targetID, "交给你了")
+	if err != nil {
+		t.Fatalf("request transfer failed: %v", err)
+	}
+	if _, _, err := WorldOwnershipTransferAccept(transfer.ID, outsiderID); !errors.Is(err, ErrWorldOwnershipTransferNotFound) {
+		t.Fatalf("expected other user accept rejected, got %v", err)
+	}
+	accepted, world, err := WorldOwnershipTransferAccept(transfer.ID, targetID)
+	if err != nil {
+		t.Fatalf("accept transfer failed: %v", err)
+	}
+	if accepted.Status != model.WorldOwnershipTransferStatusAccepted || world.OwnerID != targetID {
+		t.Fatalf("unexpected transfer result: status=%s owner=%s", accepted.Status, world.OwnerID)
+	}
+	if !IsWorldOwner(worldID, targetID) || !worldRoleEquals(worldID, ownerID, model.WorldRoleAdmin) {
+		t.Fatalf("member roles not swapped after transfer")
+	}
+	if _, _, err := WorldOwnershipTransferAccept(transfer.ID, targetID); !errors.Is(err, ErrWorldOwnershipTransferResolved) {
+		t.Fatalf("expected resolved transfer rejected, got %v", err)
+	}
+}
+
+func TestAdminWorldForceTransferRequiresAbandonedOwner(t *testing.T) {
+	initTestDB(t)
+	activeOwnerID := createWorldOwnershipTestUser(t, "active-owner", false)
+	disabledOwnerID := createWorldOwnershipTestUser(t, "disabled-owner", true)
+	targetID := createWorldOwnershipTestUser(t, "target", false)
+	activeWorldID := createWorldOwnershipTestWorld(t, activeOwnerID)
+	abandonedWorldID := createWorldOwnershipTestWorld(t, disabledOwnerID)
+
+	if _, _, err := AdminWorldForceTransfer(activeWorldID, "admin", targetID, ""); !errors.Is(err, ErrWorldOwnerStillActive) {
+		t.Fatalf("expected active owner protected, got %v", err)
+	}
+	abandoned, err := AdminListAbandonedWorlds()
+	if err != nil {
+		t.Fatalf("list abandoned worlds failed: %v", err)
+	}
+	found := false
+	for _, world := range abandoned {
+		if world.ID == activeWorldID {
+			t.Fatalf("active world listed as abandoned")
+		}
+		found = found || world.ID == abandonedWorldID
+	}
+	if !found {
+		t.Fatalf("abandoned world not listed")
+	}
+	transfer, world, err := AdminWorldForceTransfer(abandonedWorldID, "admin", targetID, "原拥有者已停用")
+	if err != nil {
+		t.Fatalf("force transfer failed: %v", err)
+	}
+	if transfer.Status != model.WorldOwnershipTransferStatusForced || transfer.FromUserID != disabledOwnerID || world.OwnerID != targetID {
+		t.Fatalf("unexpected forced transfer: %+v owner=%s", transfer, world.OwnerID)
+	}
+	if !IsWorldOwner(abandonedWorldID, targetID) || !worldRoleEquals(abandonedWorldID, disabledOwnerID, model.WorldRoleMember) {
+		t.Fatalf("member roles not updated after forced transfer")
+	}
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"sealchat/model"
+	"sealchat/pm"
+	"sealchat/utils"
+)
+
+const (
+	worldPackageVersion         = 1
+	worldPackageKind            = "world"
+	worldPackageMaxArchiveBytes = int64(2 << 30)
+	worldPackageRetention       = 7 * 24 * time.Hour
+)
+
+var (
+	ErrWorldPackageJobNotFound = errors.New("世界模板包任务不存在")
+	ErrWorldPackageJobBusy     = errors.New("任务进行中，无法删除")
+	ErrWorldPackageInvalid     = errors.New("世界模板包无效")
+	ErrWorldPackageTooLarge    = errors.New("世界模板包大小无效")
+)
+
+func WorldPackageRequestBodyLimit() int {
+	return int(worldPackageMaxArchiveBytes + 16<<20)
+}
+
+// WorldPackageSettings 世界本身的设置，不含任何与服务器或用户绑定的 ID
+type WorldPackageSettings struct {
+	Name                                  string `json:"name"`
+	Description                           string `json:"description"`
+	Avatar                                string `json:"avatar,omitempty"`
+	Visibility                            string `json:"visibility"`
+	EnforceMembership                     bool   `json:"enforceMembership"`
+	AllowAdminEditMessages                bool   `json:"allowAdminEditMessages"`
+	AllowManageOtherUserChannelIdentities bool   `json:"allowManageOtherUserChannelIdentities"`
+	AllowMemberEditKeywords               bool   `json:"allowMemberEditKeywords"`
+	StrictWhisperPrivacy                  bool   `json:"strictWhisperPrivacy"`
+	ChannelDefaultDiceMode                string `json:"channelDefaultDiceMode"`
+	CharacterCardBadgeTemplate            string `json:"characterCardBadgeTemplate,omitempty"`
+	CursorThemeJSON                       string `json:"cursorThemeJson,omitempty"`
+	TheaterPresentationTemplateJSON       string `json:"theaterPresentationTemplateJson,omitempty"`
+	StickyNoteDefaultAppearanceJSON       string `json:"stickyNoteDefaultAppearanceJson,omitempty"`
+	Dice3DConfigJSON                      string `json:"dice3dConfigJson,omitempty"`
+	TheaterActivated                      bool   `json:"theaterActivated"`
+	DefaultChannelID                      string `json:"defaultChannelId,omitempty"`
+}
+
+// WorldPackageRole 频道角色按 key 保存（ch-{channelId}-{key}），导入时按新频道 ID 重建
+type WorldPackageRole struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Desc        string   `json:"desc,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+type WorldPackageStickyNoteFolder struct {
+	ID         string `json:"id"`
+	ParentID   string `json:"parentId,omitempty"`
+	Name       string `json:"name"`
+	Color      string `json:"color,omitempty"`
+	OrderIndex int    `json:"orderIndex"`
+}
+
+type WorldPackageStickyNote struct {
+	ID             string `json:"id"`
+	FolderID       string `json:"folderId,omitempty"`
+	Title          string `json:"title"`
+	Content        string `json:"content"`
+	ContentText    string `json:"contentText,omitempty"`
+	Color          string `json:"color,omitempty"`
+	AppearanceJSON string `json:"appearanceJson,omitempty"`
+	IsPublic       bool   `json:"isPublic"`
+	IsPinned       bool   `json:"isPinned"`
+	OrderIndex     int    `json:"orderIndex"`
+	NoteType       string `json:"noteType,omitempty"`
+	TypeData       string `json:"typeData,omitempty"`
+	Visibility     string `json:"visibility,omitempty"`
+	DefaultX       int    `json:"defaultX"`
+	DefaultY       int    `json:"defaultY"`
+	DefaultW       int    `json:"defaultW"`
+	DefaultH       int    `json:"defaultH"`
+}
+
+type WorldPackageDiceMacro struct {
+	Digits   string `json:"digits"`
+	Label    string `json:"label"`
+	Expr     string `json:"expr"`
+	Note     string `json:"note,omitempty"`
+	Favorite bool   `json:"favorite"`
+}
+
+type WorldPackageChannel struct {
+	ID                     string                         `json:"id"`
+	ParentID               string                         `json:"parentId,omitempty"`
+	Name                   string                         `json:"name"`
+	Note                   string                         `json:"note,omitempty"`
+	PermType               string                         `json:"permType"`
+	SortOrder              int                            `json:"sortOrder"`
+	DefaultDiceExpr        string                         `json:"defaultDiceExpr"`
+	BuiltInDiceEnabled     bool                           `json:"builtInDiceEnabled"`
+	BotFeatureEnabled      bool                           `json:"botFeatureEnabled"`
+	VoiceEnabled           bool                           `json:"voiceEnabled"`
+	BackgroundAttachmentID string                         `json:"backgroundAttachmentId,omitempty"`
+	BackgroundSettings     string                         `json:"backgroundSettings,omitempty"`
+	Roles                  []WorldPackageRole             `json:"roles"`
+	IForms                 []model.ChannelIFormModel      `json:"iforms"`
+	DiceMacros             []WorldPackageDiceMacro        `json:"diceMacros"`
+	StickyNoteFolders      []WorldPackageStickyNoteFolder `json:"stickyNoteFolders"`
+	StickyNotes            []WorldPackageStickyNote       `json:"stickyNotes"`
+}
+
+type WorldPackageCardTemplate struct {
+	ID                   string `json:"id"`
+	Name                 string `json:"name"`
+	SheetType            string `json:"sheetType"`
+	Content              string `json:"content"`
+	DefaultBadgeTemplate string `json:"defaultBadgeTemplate,omitempty"`
+}
+
+type WorldPackageDocument struct {
+	World             WorldPackageSettings              `json:"world"`
+	Channels          []WorldPackageChannel             `json:"channels"`
+	KeywordCategories []model.WorldKeywordCategoryModel `json:"keywordCategories"`
+	Keywords          []model.WorldKeywordModel         `json:"keywords"`
+	CardTemplates     []WorldPackageCardTemplate        `json:"cardTemplates"`
+	SharedIFormIDs    []string                          `json:"sharedIFormIds,omitempty"`
+	Announcements     []model.AnnouncementModel         `json:"announcements"`
+}
+
+// WorldPackageMessage 历史消息的可移植形式，发送者仅保留展示信息
+type WorldPackageMessage struct {
+	ID             string    `json:"id"`
+	QuoteID        string    `json:"quoteId,omitempty"`
+	Content        string    `json:"content"`
+	WidgetData     string    `json:"widgetData,omitempty"`
+	ICMode         string    `json:"icMode"`
+	IsArchived     bool      `json:"isArchived,omitempty"`
+	IsPinned       bool      `json:"isPinned,omitempty"`
+	DisplayOrder   float64   `json:"displayOrder"`
+	CreatedAt      time.Time `json:"createdAt"`
+	SenderName     string    `json:"senderName"`
+	SenderColor    string    `json:"senderColor,omitempty"`
+	SenderAvatarID string    `json:"senderAvatarId,omitempty"`
+}
+
+type WorldPackageAsset struct {
+	ID   string             `json:"id"`
+	File TheaterPackageFile `json:"file"`
+}
+
+type WorldPackageHistory struct {
+	ChannelID string             `json:"channelId"`
+	Messages  int                `json:"messages"`
+	File      TheaterPackageFile `json:"file"`
+}
+
+type WorldPackageManifest struct {
+	PackageVersion  int                   `json:"packageVersion"`
+	PackageKind     string                `json:"packageKind"`
+	PackageID       string                `json:"packageId"`
+	CreatedAt       time.Time             `json:"createdAt"`
+	SourceWorldID   string                `json:"sourceWorldId"`
+	SourceWorldName string                `json:"sourceWorldName"`
+	Document        TheaterPackageFile    `json:"document"`
+	Assets          []WorldPackageAsset   `json:"assets"`
+	Theater         *TheaterPackageFile   `json:"theater,omitempty"`
+	History         []WorldPackageHistory `json:"history,omitempty"`
+}
+
+type WorldPackageSummary struct {
+	WorldID           string            `json:"worldId,omitempty"`
+	WorldName         string            `json:"worldName"`
+	DryRun            bool              `json:"dryRun,omitempty"`
+	Channels          int               `json:"channels"`
+	Roles             int               `json:"roles"`
+	IForms            int               `json:"iforms"`
+	DiceMacros        int               `json:"diceMacros"`
+	StickyNotes       int               `json:"stickyNotes"`
+	StickyNoteFolders int               `json:"stickyNoteFolders"`
+	KeywordCategories int               `json:"keywordCategories"`
+	Keywords          int               `json:"keywords"`
+	CardTemplates     int               `json:"cardTemplates"`
+	Announcements     int               `json:"announcements"`
+	Assets            int               `json:"assets"`
+	Messages          int               `json:"messages"`
+	Theater           bool              `json:"theater"`
+	ChannelIDMap      map[string]string `json:"channelIdMap,omitempty"`
+	Warnings          []string          `json:"warnings,omitempty"`
+}
+
+type worldPackageWorkerConfig struct {
+	StorageDir string
+}
+
+var worldPackageWorkerState = struct {
+	sync.RWMutex
+	startOnce sync.Once
+	config    worldPackageWorkerConfig
+}{config: worldPackageWorkerConfig{StorageDir: "./data/exports/world-packages"}}
+
+func StartWorldPackageWorker(ctx context.Context, storageDir string) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	storageDir = strings.TrimSpace(storageDir)
+	if storageDir == "" {
+		storageDir = "./data/exports"
+	}
+	storageDir = filepath.Join(storageDir, "world-packages")
+	worldPackageWorkerState.Lock()
+	worldPackageWorkerState.config.StorageDir = storageDir
+	worldPackageWorkerState.Unlock()
+	worldPackageWorkerState.startOnce.Do(func() {
+		if err := os.MkdirAll(storageDir, 0o755); err != nil {
+			log.Printf("world package: 创建任务目录失败: %v", err)
+		}
+		_ = model.GetDB().Model(&model.WorldPackageJobModel{}).
+			Where("status = ?", model.WorldPackageJobStatusRunning).
+			Updates(map[string]any{"status": model.WorldPackageJobStatusPending, "started_at": nil}).Error
+		go runWorldPackageWorker(ctx)
+	})
+}
+
+func worldPackageStorageDir() string {
+	worldPackageWorkerState.RLock()
+	defer worldPackageWorkerState.RUnlock()
+	return worldPackageWorkerState.config.StorageDir
+}
+
+func runWorldPackageWorker(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	cleanupTicker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	defer cleanupTicker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cleanupTicker.C:
+			_ = cleanupExpiredWorldPackageJobs()
+		case <-ticker.C:
+			job, err := acquireNextWorldPackageJob()
+			if err != nil {
+				log.Printf("world package: 获取任务失败: %v", err)
+				continue
+			}
+			if job == nil {
+				continue
+			}
+			if err := processWorldPackageJob(ctx, job); err != nil {
+				log.Printf("world package: 任务 %s 失败: %v", job.ID, err)
+			}
+		}
+	}
+}
+
+func acquireNextWorldPackageJob() (*model.WorldPackageJobModel, error) {
+	var job model.WorldPackageJobModel
+	if err := model.GetDB().Where("status = ?", model.WorldPackageJobStatusPending).
+		Order("created_at ASC").Limit(1).Find(&job).Error; err != nil {
+		return nil, err
+	}
+	if job.ID == "" {
+		return nil, nil
+	}
+	now := time.Now()
+	result := model.GetDB().Model(&model.WorldPackageJobModel{}).
+		Where("id = ? AND status = ?", job.ID, model.WorldPackageJobStatusPending).
+		Updates(map[string]any{"status": model.WorldPackageJobStatusRunning, "started_at": &now, "progress": 0.01, "error_code": "", "error_message": ""})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	job.Status = model.WorldPackageJobStatusRunning
+	job.StartedAt = &now
+	return &job, nil
+}
+
+func processWorldPackageJob(ctx context.Context, job *model.WorldPackageJobModel) error {
+	var summary WorldPackageSummary
+	var err error
+	switch job.Type {
+	case model.WorldPackageJobTypeExport:
+		summary, err = exportWorldPackage(ctx, job)
+	case model.WorldPackageJobTypeImport:
+		summary, err = importWorldPackage(ctx, job)
+	default:
+		err = fmt.Errorf("未知世界模板包任务类型: %s", job.Type)
+	}
+	if err != nil {
+		_ = failWorldPackageJob(job.ID, "WORLD_PACKAGE_FAILED", err)
+		return err
+	}
+	raw, _ := json.Marshal(summary)
+	now := time.Now()
+	expiresAt := now.Add(worldPackageRetention)
+	updates := map[string]any{
+		"status": model.WorldPackageJobStatusDone, "progress": 1, "summary_json": string(raw),
+		"finished_at": &now, "expires_at": &expiresAt, "error_code": "", "error_message": "",
+	}
+	if summary.WorldID != "" && job.Type == model.WorldPackageJobTypeImport {
+		updates["target_world_id"] = summary.WorldID
+	}
+	return model.GetDB().Model(&model.WorldPackageJobModel{}).Where("id = ?", job.ID).Updates(updates).Error
+}
+
+func updateWorldPackageProgress(jobID string, progress float64) {
+	if progress < 0 {
+		progress = 0
+	}
+	if progress > 0.99 {
+		progress = 0.99
+	}
+	_ = model.GetDB().Model(&model.WorldPackageJobModel{}).Where("id = ? AND status = ?", jobID, model.WorldPackageJobStatusRunning).Update("progress", progress).Error
+}
+
+func failWorldPackageJob(jobID, code string, cause error) error {
+	now := time.Now()
+	expiresAt := now.Add(worldPackageRetention)
+	message := ""
+	if cause != nil {
+		message = cause.Error()
+	}
+	return model.GetDB().Model(&model.WorldPackageJobModel{}).Where("id = ?", jobID).Updates(map[string]any{
+		"status": model.WorldPackageJobStatusFailed, "error_code": code, "error_message": message,
+		"finished_at": &now, "expires_at": &expiresAt,
+	}).Error
+}
+
+func canManageWorldPackage(worldID, actorID string) bool {
+	return IsWorldAdmin(worldID, actorID) || pm.CanWithSystemRole(actorID, pm.PermModAdmin)
+}
+
+// CreateWorldPackageExportJob 创建世界模板包导出任务，includeHistory 为真时附带非悄悄话的历史消息
+func CreateWorldPackageExportJob(actorID, worldID string, includeHistory bool) (*model.WorldPackageJobModel, error) {
+	world, err := GetWorldByID(worldID)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageWorldPackage(world.ID, actorID) {
+		return nil, ErrWorldPermission
+	}
+	job := &model.WorldPackageJobModel{
+		Type: model.WorldPackageJobTypeExport, Status: model.WorldPackageJobStatusPending,
+		ActorUserID: actorID, SourceWorldID: world.ID, IncludeHistory: includeHistory,
+	}
+	return job, model.GetDB().Create(job).Error
+}
+
+type WorldPackageImportParams struct {
+	Name           string
+	IncludeHistory bool
+	DryRun         bool
+}
+
+// CreateWorldPackageImportJob 保存上传的模板包并创建导入任务；导入总是创建一个新世界
+func CreateWorldPackageImportJob(actorID, filename string, reader io.Reader, size int64, params WorldPackageImportParams) (*model.WorldPackageJobModel, error) {
+	if !params.DryRun {
+		if config := utils.GetConfig(); config != nil && !config.Audio.AllowNonAdminCreateWorld {
+			if !pm.CanWithSystemRole(actorID, pm.PermModAdmin) {
+				return nil, ErrWorldCreateForbidden
+			}
+		}
+	}
+	if reader == nil || size <= 0 || size > worldPackageMaxArchiveBytes {
+		return nil, ErrWorldPackageTooLarge
+	}
+	name := strings.TrimSpace(params.Name)
+	if len([]rune(name)) > 100 {
+		name = string([]rune(name)[:100])
+	}
+	job := &model.WorldPackageJobModel{
+		StringPKBaseModel: model.StringPKBaseModel{ID: utils.NewID()},
+		Type:              model.WorldPackageJobTypeImport, Status: model.WorldPackageJobStatusPending,
+		ActorUserID: actorID, TargetName: name, IncludeHistory: params.IncludeHistory, DryRun: params.DryRun,
+		OriginalName: sanitizeWorldPackageFilename(filename),
+	}
+	incomingDir := filepath.Join(worldPackageStorageDir(), "incoming")
+	if err := os.MkdirAll(incomingDir, 0o755); err != nil {
+		return nil, err
+	}
+	inputPath := filepath.Join(incomingDir, job.ID+".zip")
+	output, err := os.OpenFile(inputPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	written, copyErr := io.Copy(output, io.LimitReader(reader, worldPackageMaxArchiveBytes+1))
+	closeErr := output.Close()
+	if copyErr != nil || closeErr != nil || written != size || written > worldPackageMaxArchiveBytes {
+		_ = os.Remove(inputPath)
+		if copyErr != nil {
+			return nil, copyErr
+		}
+		if closeErr != nil {
+			return nil, closeErr
+		}
+		return nil, errors.New("世界模板包上传不完整")
+	}
+	job.InputFilePath = inputPath
+	if err := model.GetDB().Create(job).Error; err != nil {
+		_ = os.Remove(inputPath)
+		return nil, err
+	}
+	return job, nil
+}
+
+func GetWorldPackageJob(actorID, jobID string) (*model.WorldPackageJobModel, error) {
+	var job model.WorldPackageJobModel
+	if err := model.GetDB().Where("id = ?", strings.TrimSpace(jobID)).Limit(1).Find(&job).Error; err != nil {
+		return nil, err
+	}
+	if job.ID == "" {
+		return nil, ErrWorldPackageJobNotFound
+	}
+	if job.ActorUserID != actorID && !pm.CanWithSystemRole(actorID, pm.PermModAdmin) {
+		return nil, ErrWorldPackageJobNotFound
+	}
+	return &job, nil
+}
+
+func DeleteWorldPackageJob(actorID, jobID string) error {
+	job, err := GetWorldPackageJob(actorID, jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status == model.WorldPackageJobStatusPending || job.Status == model.WorldPackageJobStatusRunning {
+		return ErrWorldPackageJobBusy
+	}
+	for _, path := range []string{job.InputFilePath, job.OutputFilePath} {
+		if strings.TrimSpace(path) != "" {
+			_ = os.Remove(path)
+		}
+	}
+	return model.GetDB().Delete(&model.WorldPackageJobModel{}, "id = ?", job.ID).Error
+}
+
+func cleanupExpiredWorldPackageJobs() error {
+	var jobs []model.WorldPackageJobModel
+	if err := model.GetDB().Where("expires_at IS NOT NULL AND expires_at < ?", time.Now()).Limit(200).Find(&jobs).Error; err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		for _, path := range []string{job.InputFilePath, job.OutputFilePath} {
+			if strings.TrimSpace(path) != "" {
+				_ = os.Remove(path)
+			}
+		}
+		_ = model.GetDB().Delete(&model.WorldPackageJobModel{}, "id = ?", job.ID).Error
+	}
+	return nil
+}
+
+func sanitizeWorldPackageFilename(value string) string {
+	name := filepath.Base(strings.TrimSpace(value))
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "\r", ""), "\n", "")
+	if name == "." || name == "" {
+		return "world-package.zip"
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
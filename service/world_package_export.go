@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+var worldPackageAttachmentRefPattern = regexp.MustCompile(`id:([a-zA-Z0-9_-]+)`)
+
+// collectWorldPackageAttachmentRefs 收集文本中的 id:xxx 附件引用
+func collectWorldPackageAttachmentRefs(target map[string]struct{}, values ...string) {
+	for _, value := range values {
+		for _, match := range worldPackageAttachmentRefPattern.FindAllStringSubmatch(value, -1) {
+			if len(match) > 1 && match[1] != "" {
+				target[match[1]] = struct{}{}
+			}
+		}
+	}
+}
+
+// collectWorldPackageAttachmentValue 处理直接保存附件 ID 的字段（头像、频道背景），兼容带 id: 前缀的写法
+func collectWorldPackageAttachmentValue(target map[string]struct{}, value string) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "id:")
+	if value != "" && attachmentTokenPattern.MatchString(value) {
+		target[value] = struct{}{}
+	}
+}
+
+func remapWorldPackageAttachmentValue(value string, attachmentMap map[string]string) string {
+	trimmed := strings.TrimSpace(value)
+	if next := attachmentMap[strings.TrimPrefix(trimmed, "id:")]; next != "" {
+		if strings.HasPrefix(trimmed, "id:") {
+			return "id:" + next
+		}
+		return next
+	}
+	return value
+}
+
+// remapWorldPackageAttachmentRefs 将文本中的附件引用替换为导入后的新 ID，未知引用保持原样
+func remapWorldPackageAttachmentRefs(value string, attachmentMap map[string]string) string {
+	if value == "" || len(attachmentMap) == 0 {
+		return value
+	}
+	return worldPackageAttachmentRefPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if next := attachmentMap[strings.TrimPrefix(match, "id:")]; next != "" {
+			return "id:" + next
+		}
+		return match
+	})
+}
+
+func exportWorldPackage(ctx context.Context, job *model.WorldPackageJobModel) (WorldPackageSummary, error) {
+	var summary WorldPackageSummary
+	if job == nil {
+		return summary, fmt.Errorf("世界模板包任务不存在")
+	}
+	world, err := GetWorldByID(job.SourceWorldID)
+	if err != nil {
+		return summary, err
+	}
+	if !canManageWorldPackage(world.ID, job.ActorUserID) {
+		return summary, ErrWorldPermission
+	}
+
+	stagingDir, err := os.MkdirTemp(worldPackageStorageDir(), "export-"+job.ID+"-")
+	if err != nil {
+		return summary, err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	document, refs, err := buildWorldPackageDocument(world, job.ActorUserID)
+	if err != nil {
+		return summary, err
+	}
+	updateWorldPackageProgress(job.ID, 0.2)
+
+	manifest := WorldPackageManifest{
+		PackageVersion:  worldPackageVersion,
+		PackageKind:     worldPackageKind,
+		PackageID:       utils.NewID(),
+		CreatedAt:       time.Now().UTC(),
+		SourceWorldID:   world.ID,
+		SourceWorldName: world.Name,
+		Assets:          []WorldPackageAsset{},
+	}
+	summary = summarizeWorldPackageDocument(document)
+	summary.WorldName = world.Name
+
+	if job.IncludeHistory {
+		for _, channel := range document.Channels {
+			if err := ctx.Err(); err != nil {
+				return summary, err
+			}
+			messages, err := exportWorldPackageChannelHistory(channel.ID, refs)
+			if err != nil {
+				return summary, err
+			}
+			if len(messages) == 0 {
+				continue
+			}
+			relative := "history/" + channel.ID + ".json"
+			file, err := writeJSONFile(filepath.Join(stagingDir, filepath.FromSlash(relative)), messages)
+			if err != nil {
+				return summary, err
+			}
+			file.Path = relative
+			manifest.History = append(manifest.History, WorldPackageHistory{ChannelID: channel.ID, Messages: len(messages), File: file})
+			summary.Messages += len(messages)
+		}
+	}
+	updateWorldPackageProgress(job.ID, 0.4)
+
+	assetIDs := mapKeys(refs)
+	sort.Strings(assetIDs)
+	for index, attachmentID := range assetIDs {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		file, err := exportAttachmentToPackage(stagingDir, "assets/"+attachmentID, attachmentID)
+		if err != nil {
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("附件 %s 导出失败: %v", attachmentID, err))
+			continue
+		}
+		manifest.Assets = append(manifest.Assets, WorldPackageAsset{ID: attachmentID, File: file})
+		updateWorldPackageProgress(job.ID, 0.4+0.3*float64(index+1)/float64(len(assetIDs)))
+	}
+	summary.Assets = len(manifest.Assets)
+
+	if world.TheaterActivated {
+		theaterFile, err := exportWorldPackageTheater(ctx, stagingDir, job)
+		if err != nil {
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("舞台导出失败: %v", err))
+		} else {
+			manifest.Theater = &theaterFile
+			summary.Theater = true
+		}
+	}
+	updateWorldPackageProgress(job.ID, 0.8)
+
+	documentFile, err := writeJSONFile(filepath.Join(stagingDir, "world", "document.json"), document)
+	if err != nil {
+		return summary, err
+	}
+	documentFile.Path = "world/document.json"
+	manifest.Document = documentFile
+	if _, err := writeJSONFile(filepath.Join(stagingDir, "manifest.json"), manifest); err != nil {
+		return summary, err
+	}
+
+	completedDir := filepath.Join(worldPackageStorageDir(), "completed")
+	if err := os.MkdirAll(completedDir, 0o755); err != nil {
+		return summary, err
+	}
+	temporaryZIP := filepath.Join(completedDir, job.ID+".tmp")
+	outputZIP := filepath.Join(completedDir, job.ID+".zip")
+	if err := zipDirectory(stagingDir, temporaryZIP); err != nil {
+		_ = os.Remove(temporaryZIP)
+		return summary, err
+	}
+	info, err := os.Stat(temporaryZIP)
+	if err != nil {
+		return summary, err
+	}
+	if info.Size() > worldPackageMaxArchiveBytes {
+		_ = os.Remove(temporaryZIP)
+		return summary, ErrWorldPackageTooLarge
+	}
+	fileInfo, err := theaterPackageFile(temporaryZIP, "application/zip", "")
+	if err != nil {
+		return summary, err
+	}
+	_ = os.Remove(outputZIP)
+	if err := os.Rename(temporaryZIP, outputZIP); err != nil {
+		return summary, err
+	}
+	outputName := sanitizeWorldPackageFilename(fmt.Sprintf("%s-世界模板-%s.zip", world.Name, time.Now().Format("20060102-150405")))
+	if err := model.GetDB().Model(&model.WorldPackageJobModel{}).Where("id = ?", job.ID).Updates(map[string]any{
+		"output_file_path": outputZIP, "output_file_name": outputName, "output_file_size": info.Size(),
+		"package_hash": fileInfo.SHA256, "progress": 0.99,
+	}).Error; err != nil {
+		return summary, err
+	}
+	summary.WorldID = world.ID
+	return summary, nil
+}
+
+// buildWorldPackageDocument 读取世界结构数据；私聊频道、成员、身份与机器人绑定不会被导出
+func buildWorldPackageDocument(world *model.WorldModel, actorID string) (WorldPackageDocument, map[string]struct{}, error) {
+	db := model.GetDB()
+	refs := map[string]struct{}{}
+	document := WorldPackageDocument{
+		World: WorldPackageSettings{
+			Name:                                  world.Name,
+			Description:                           world.Description,
+			Avatar:                                world.Avatar,
+			Visibility:                            world.Visibility,
+			EnforceMembership:                     world.EnforceMembership,
+			AllowAdminEditMessages:                world.AllowAdminEditMessages,
+			AllowManageOtherUserChannelIdentities: world.AllowManageOtherUserChannelIdentities,
+			AllowMemberEditKeywords:               world.AllowMemberEditKeywords,
+			StrictWhisperPrivacy:                  world.StrictWhisperPrivacy,
+			ChannelDefaultDiceMode:                world.ChannelDefaultDiceMode,
+			CharacterCardBadgeTemplate:            world.CharacterCardBadgeTemplate,
+			CursorThemeJSON:                       world.CursorThemeJSON,
+			TheaterPresentationTemplateJSON:       world.TheaterPresentationTemplateJSON,
+			StickyNoteDefaultAppearanceJSON:       world.StickyNoteDefaultAppearanceJSON,
+			Dice3DConfigJSON:                      world.Dice3DConfigJSON,
+			TheaterActivated:                      world.TheaterActivated,
+			DefaultChannelID:                      world.DefaultChannelID,
+		},
+		Channels:          []WorldPackageChannel{},
+		KeywordCategories: []model.WorldKeywordCategoryModel{},
+		Keywords:          []model.WorldKeywordModel{},
+		CardTemplates:     []WorldPackageCardTemplate{},
+		Announcements:     []model.AnnouncementModel{},
+	}
+	collectWorldPackageAttachmentValue(refs, world.Avatar)
+
+	var channels []model.ChannelModel
+	if err := db.Where("world_id = ? AND status = ? AND is_private = ?", world.ID, "active", false).
+		Order("sort_order DESC").Order("created_at ASC").Find(&channels).Error; err != nil {
+		return document, nil, err
+	}
+	for _, channel := range channels {
+		item := WorldPackageChannel{
+			ID:                     channel.ID,
+			ParentID:               channel.ParentID,
+			Name:                   channel.Name,
+			Note:                   channel.Note,
+			PermType:               channel.PermType,
+			SortOrder:              channel.SortOrder,
+			DefaultDiceExpr:        channel.DefaultDiceExpr,
+			BuiltInDiceEnabled:     channel.BuiltInDiceEnabled,
+			BotFeatureEnabled:      channel.BotFeatureEnabled,
+			VoiceEnabled:           channel.VoiceEnabled,
+			BackgroundAttachmentID: channel.BackgroundAttachmentId,
+			BackgroundSettings:     channel.BackgroundSettings,
+			Roles:                  []WorldPackageRole{},
+			IForms:                 []model.ChannelIFormModel{},
+			DiceMacros:             []WorldPackageDiceMacro{},
+			StickyNoteFolders:      []WorldPackageStickyNoteFolder{},
+			StickyNotes:            []WorldPackageStickyNote{},
+		}
+		collectWorldPackageAttachmentValue(refs, channel.BackgroundAttachmentId)
+
+		var roles []model.ChannelRoleModel
+		if err := db.Where("channel_id = ?", channel.ID).Order("id ASC").Find(&roles).Error; err != nil {
+			return document, nil, err
+		}
+		for _, role := range roles {
+			key, ok := extractRoleKey(role.ID, channel.ID)
+			if !ok {
+				continue
+			}
+			var permissions []string
+			if err := db.Model(&model.RolePermissionModel{}).Where("role_id = ?", role.ID).Order("permission_id ASC").Pluck("permission_id", &permissions).Error; err != nil {
+				return document, nil, err
+			}
+			item.Roles = append(item.Roles, WorldPackageRole{Key: key, Name: role.Name, Desc: role.Desc, Permissions: permissions})
+		}
+
+		if err := db.Where("channel_id = ?", channel.ID).Order("order_index ASC").Find(&item.IForms).Error; err != nil {
+			return document, nil, err
+		}
+		for i := range item.IForms {
+			item.IForms[i].CreatedBy = ""
+			item.IForms[i].UpdatedBy = ""
+		}
+
+		var macros []model.DiceMacroModel
+		if err := db.Where("channel_id = ? AND user_id = ?", channel.ID, actorID).Order("digits ASC").Find(&macros).Error; err != nil {
+			return document, nil, err
+		}
+		for _, macro := range macros {
+			item.DiceMacros = append(item.DiceMacros, WorldPackageDiceMacro{
+				Digits: macro.Digits, Label: macro.Label, Expr: macro.Expr, Note: macro.Note, Favorite: macro.Favorite,
+			})
+		}
+
+		var folders []model.StickyNoteFolderModel
+		if err := db.Where("channel_id = ? AND is_deleted = ?", channel.ID, false).Order("order_index ASC").Find(&folders).Error; err != nil {
+			return document, nil, err
+		}
+		for _, folder := range folders {
+			item.StickyNoteFolders = append(item.StickyNoteFolders, WorldPackageStickyNoteFolder{
+				ID: folder.ID, ParentID: folder.ParentID, Name: folder.Name, Color: folder.Color, OrderIndex: folder.OrderIndex,
+			})
+		}
+		var notes []model.StickyNoteModel
+		if err := db.Where("channel_id = ? AND is_deleted = ?", channel.ID, false).Order("order_index ASC").Find(&notes).Error; err != nil {
+			return document, nil, err
+		}
+		for _, note := range notes {
+			collectWorldPackageAttachmentRefs(refs, note.Content, note.AppearanceJSON)
+			item.StickyNotes = append(item.StickyNotes, WorldPackageStickyNote{
+				ID: note.ID, FolderID: note.FolderID, Title: note.Title, Content: note.Content, ContentText: note.ContentText,
+				Color: note.Color, AppearanceJSON: note.AppearanceJSON, IsPublic: note.IsPublic, IsPinned: note.IsPinned,
+				OrderIndex: note.OrderIndex, NoteType: string(note.NoteType), TypeData: note.TypeData, Visibility: string(note.Visibility),
+				DefaultX: note.DefaultX, DefaultY: note.DefaultY, DefaultW: note.DefaultW, DefaultH: note.DefaultH,
+			})
+		}
+		collectWorldPackageAttachmentRefs(refs, channel.BackgroundSettings)
+		document.Channels = append(document.Channels, item)
+	}
+
+	if err := db.Where("world_id = ?", world.ID).Order("priority DESC").Find(&document.KeywordCategories).Error; err != nil {
+		return document, nil, err
+	}
+	if err := db.Where("world_id = ?", world.ID).Order("sort_order DESC").Order("created_at ASC").Find(&document.Keywords).Error; err != nil {
+		return document, nil, err
+	}
+	for i := range document.KeywordCategories {
+		document.KeywordCategories[i].CreatedBy = ""
+		document.KeywordCategories[i].UpdatedBy = ""
+	}
+	for i := range document.Keywords {
+		document.Keywords[i].CreatedBy = ""
+		document.Keywords[i].UpdatedBy = ""
+		collectWorldPackageAttachmentRefs(refs, document.Keywords[i].Description)
+	}
+
+	var templateIDs []string
+	if err := db.Model(&model.WorldCharacterCardTemplateBindingModel{}).Where("world_id = ?", world.ID).Pluck("template_id", &templateIDs).Error; err != nil {
+		return document, nil, err
+	}
+	if len(templateIDs) > 0 {
+		var templates []model.CharacterCardTemplateModel
+		if err := db.Where("id IN ?", templateIDs).Order("created_at ASC").Find(&templates).Error; err != nil {
+			return document, nil, err
+		}
+		for _, template := range templates {
+			document.CardTemplates = append(document.CardTemplates, WorldPackageCardTemplate{
+				ID: template.ID, Name: template.Name, SheetType: template.SheetType, Content: template.Content,
+				DefaultBadgeTemplate: template.DefaultBadgeTemplate,
+			})
+		}
+	}
+
+	exportedForms := map[string]struct{}{}
+	for _, channel := range document.Channels {
+		for _, form := range channel.IForms {
+			exportedForms[form.ID] = struct{}{}
+		}
+	}
+	var formIDs []string
+	if err := db.Model(&model.WorldIFormBindingModel{}).Where("world_id = ?", world.ID).Order("form_id ASC").Pluck("form_id", &formIDs).Error; err != nil {
+		return document, nil, err
+	}
+	for _, formID := range formIDs {
+		if _, ok := exportedForms[formID]; ok {
+			document.SharedIFormIDs = append(document.SharedIFormIDs, formID)
+		}
+	}
+
+	if err := db.Where("scope_type = ? AND scope_id = ? AND status <> ?", model.AnnouncementScopeWorld, world.ID, model.AnnouncementStatusArchived).
+		Order("created_at ASC").Find(&document.Announcements).Error; err != nil {
+		return document, nil, err
+	}
+	for i := range document.Announcements {
+		document.Announcements[i].CreatedBy = ""
+		document.Announcements[i].UpdatedBy = ""
+		collectWorldPackageAttachmentRefs(refs, document.Announcements[i].Content)
+	}
+	return document, refs, nil
+}
+
+// exportWorldPackageChannelHistory 导出频道历史；悄悄话、已删除和已撤回的消息不会进入模板包
+func exportWorldPackageChannelHistory(channelID string, refs map[string]struct{}) ([]WorldPackageMessage, error) {
+	var rows []model.MessageModel
+	if err := model.GetDB().Where("channel_id = ? AND is_whisper = ? AND is_deleted = ? AND (is_revoked = ? OR is_revoked IS NULL)", channelID, false, false, false).
+		Order("display_order ASC").Order("created_at ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	messages := make([]WorldPackageMessage, 0, len(rows))
+	for _, row := range rows {
+		name := strings.TrimSpace(row.SenderIdentityName)
+		if name == "" {
+			name = strings.TrimSpace(row.SenderMemberName)
+		}
+		collectWorldPackageAttachmentRefs(refs, row.Content)
+		collectWorldPackageAttachmentValue(refs, row.SenderIdentityAvatarID)
+		messages = append(messages, WorldPackageMessage{
+			ID: row.ID, QuoteID: row.QuoteID, Content: row.Content, WidgetData: row.WidgetData, ICMode: row.ICMode,
+			IsArchived: row.IsArchived, IsPinned: row.IsPinned, DisplayOrder: row.DisplayOrder, CreatedAt: row.CreatedAt,
+			SenderName: name, SenderColor: row.SenderIdentityColor, SenderAvatarID: row.SenderIdentityAvatarID,
+		})
+	}
+	return messages, nil
+}
+
+// exportWorldPackageTheater 复用舞台包导出，将生成的舞台包原样嵌入世界模板包
+func exportWorldPackageTheater(ctx context.Context, stagingDir string, job *model.WorldPackageJobModel) (TheaterPackageFile, error) {
+	theaterJob := &model.TheaterPackageJobModel{
+		StringPKBaseModel: model.StringPKBaseModel{ID: "world-" + job.ID},
+		Type:              model.TheaterPackageJobTypeExport,
+		ActorUserID:       job.ActorUserID,
+		SourceWorldID:     job.SourceWorldID,
+	}
+	if _, err := exportTheaterPackage(ctx, theaterJob); err != nil {
+		return TheaterPackageFile{}, err
+	}
+	source := filepath.Join(theaterPackageStorageDir(), "completed", theaterJob.ID+".zip")
+	defer os.Remove(source)
+	relative := "theater/package.zip"
+	target := filepath.Join(stagingDir, filepath.FromSlash(relative))
+	if err := copyTheaterPackageFile(source, target); err != nil {
+		return TheaterPackageFile{}, err
+	}
+	file, err := theaterPackageFile(target, "application/zip", "theater.zip")
+	if err != nil {
+		return TheaterPackageFile{}, err
+	}
+	file.Path = relative
+	return file, nil
+}
+
+func summarizeWorldPackageDocument(document WorldPackageDocument) WorldPackageSummary {
+	summary := WorldPackageSummary{
+		WorldName:         document.World.Name,
+		Channels:          len(document.Channels),
+		KeywordCategories: len(document.KeywordCategories),
+		Keywords:          len(document.Keywords),
+		CardTemplates:     len(document.CardTemplates),
+		Announcements:     len(document.Announcements),
+	}
+	for _, channel := range document.Channels {
+		summary.Roles += len(channel.Roles)
+		summary.IForms += len(channel.IForms)
+		summary.DiceMacros += len(channel.DiceMacros)
+		summary.StickyNotes += len(channel.StickyNotes)
+		summary.StickyNoteFolders += len(channel.StickyNoteFolders)
+	}
+	return summary
+}
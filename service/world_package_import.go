@@ -0,0 +1,585 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/pm"
+	"sealchat/pm/gen"
+	"sealchat/utils"
+)
+
+type worldPackageRemap struct {
+	channels    map[string]string
+	attachments map[string]string
+	folders     map[string]string
+	iforms      map[string]string
+	messages    map[string]string
+}
+
+func importWorldPackage(ctx context.Context, job *model.WorldPackageJobModel) (WorldPackageSummary, error) {
+	var summary WorldPackageSummary
+	if job == nil || strings.TrimSpace(job.InputFilePath) == "" {
+		return summary, fmt.Errorf("世界模板包任务或文件不存在")
+	}
+	extractDir, err := os.MkdirTemp(worldPackageStorageDir(), "import-"+job.ID+"-")
+	if err != nil {
+		return summary, err
+	}
+	defer os.RemoveAll(extractDir)
+	if err := extractTheaterPackageZIP(job.InputFilePath, extractDir); err != nil {
+		return summary, err
+	}
+	manifest, document, err := loadAndValidateWorldPackage(extractDir)
+	if err != nil {
+		return summary, err
+	}
+	updateWorldPackageProgress(job.ID, 0.1)
+
+	channels, dropped := orderWorldPackageChannels(document.Channels)
+	document.Channels = channels
+	summary = summarizeWorldPackageDocument(document)
+	summary.DryRun = job.DryRun
+	summary.Assets = len(manifest.Assets)
+	summary.Theater = manifest.Theater != nil
+	if name := strings.TrimSpace(job.TargetName); name != "" {
+		document.World.Name = name
+	}
+	summary.WorldName = document.World.Name
+	if dropped > 0 {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("%d 个子频道的父频道缺失，已作为顶层频道导入", dropped))
+	}
+	if job.IncludeHistory {
+		for _, history := range manifest.History {
+			summary.Messages += history.Messages
+		}
+	} else if len(manifest.History) > 0 {
+		summary.Warnings = append(summary.Warnings, "模板包包含历史消息，本次导入未选择导入历史")
+	}
+	if job.DryRun {
+		return summary, nil
+	}
+
+	world, err := createWorldFromPackage(job.ActorUserID, document.World)
+	if err != nil {
+		return summary, err
+	}
+	remap := worldPackageRemap{
+		channels: map[string]string{}, attachments: map[string]string{}, folders: map[string]string{},
+		iforms: map[string]string{}, messages: map[string]string{},
+	}
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		for _, newID := range remap.channels {
+			cleanupClonedChannel(newID)
+		}
+		_ = WorldDelete(world.ID, job.ActorUserID)
+	}()
+
+	for index, asset := range manifest.Assets {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		newID, err := importWorldPackageAsset(extractDir, asset, world.ID, job.ActorUserID)
+		if err != nil {
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("附件 %s 导入失败: %v", asset.ID, err))
+			continue
+		}
+		remap.attachments[asset.ID] = newID
+		updateWorldPackageProgress(job.ID, 0.1+0.3*float64(index+1)/float64(len(manifest.Assets)))
+	}
+	if avatar := remapWorldPackageAttachmentValue(document.World.Avatar, remap.attachments); avatar != world.Avatar {
+		if err := model.GetDB().Model(&model.WorldModel{}).Where("id = ?", world.ID).Update("avatar", avatar).Error; err != nil {
+			return summary, err
+		}
+	}
+
+	for _, channel := range document.Channels {
+		newID := utils.NewID()
+		remap.channels[channel.ID] = newID
+		permType := channel.PermType
+		if permType != "public" && permType != "non-public" {
+			permType = "public"
+		}
+		if ChannelNew(newID, permType, channel.Name, world.ID, job.ActorUserID, remap.channels[channel.ParentID]) == nil {
+			return summary, fmt.Errorf("创建频道失败: %s", channel.Name)
+		}
+		dice := strings.TrimSpace(channel.DefaultDiceExpr)
+		if dice == "" {
+			dice = "d20"
+		}
+		if err := model.GetDB().Model(&model.ChannelModel{}).Where("id = ?", newID).Updates(map[string]any{
+			"note":                     channel.Note,
+			"sort_order":               channel.SortOrder,
+			"default_dice_expr":        dice,
+			"built_in_dice_enabled":    channel.BuiltInDiceEnabled,
+			"bot_feature_enabled":      channel.BotFeatureEnabled,
+			"voice_enabled":            channel.VoiceEnabled,
+			"background_attachment_id": remapWorldPackageAttachmentValue(channel.BackgroundAttachmentID, remap.attachments),
+			"background_settings":      remapWorldPackageAttachmentRefs(channel.BackgroundSettings, remap.attachments),
+		}).Error; err != nil {
+			return summary, err
+		}
+	}
+	updateWorldPackageProgress(job.ID, 0.5)
+
+	rolePerms := map[string][]string{}
+	err = model.GetDB().Transaction(func(tx *gorm.DB) error {
+		for _, channel := range document.Channels {
+			if err := importWorldPackageChannelContent(tx, channel, world.ID, job.ActorUserID, remap, rolePerms); err != nil {
+				return err
+			}
+		}
+		if err := importWorldPackageWorldContent(tx, document, world.ID, job.ActorUserID, remap); err != nil {
+			return err
+		}
+		if job.IncludeHistory {
+			for _, history := range manifest.History {
+				channelID := remap.channels[history.ChannelID]
+				if channelID == "" {
+					continue
+				}
+				var messages []WorldPackageMessage
+				if err := decodeStrictJSONFile(theaterPackageAbsolutePath(extractDir, history.File.Path), &messages); err != nil {
+					return fmt.Errorf("历史消息文件无效: %w", err)
+				}
+				if err := importWorldPackageMessages(tx, channelID, job, messages, remap); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return summary, err
+	}
+	applyRolePermsToMemory(rolePerms)
+	updateWorldPackageProgress(job.ID, 0.8)
+
+	defaultChannelID := remap.channels[document.World.DefaultChannelID]
+	if defaultChannelID == "" && len(document.Channels) > 0 {
+		defaultChannelID = remap.channels[document.Channels[0].ID]
+	}
+	if defaultChannelID != "" {
+		if err := model.GetDB().Model(&model.WorldModel{}).Where("id = ?", world.ID).Update("default_channel_id", defaultChannelID).Error; err != nil {
+			return summary, err
+		}
+		world.DefaultChannelID = defaultChannelID
+	} else if err := ensureWorldDefaultChannel(world, job.ActorUserID); err != nil {
+		return summary, err
+	}
+	committed = true
+
+	if manifest.Theater != nil {
+		theaterJob := &model.TheaterPackageJobModel{
+			StringPKBaseModel: model.StringPKBaseModel{ID: "world-" + job.ID},
+			Type:              model.TheaterPackageJobTypeImport,
+			ActorUserID:       job.ActorUserID,
+			TargetWorldID:     world.ID,
+			InputChannelID:    world.DefaultChannelID,
+			InputFilePath:     theaterPackageAbsolutePath(extractDir, manifest.Theater.Path),
+		}
+		if _, err := importTheaterPackage(ctx, theaterJob); err != nil {
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("舞台导入失败: %v", err))
+			summary.Theater = false
+		}
+	}
+
+	summary.WorldID = world.ID
+	summary.ChannelIDMap = remap.channels
+	return summary, nil
+}
+
+func loadAndValidateWorldPackage(root string) (WorldPackageManifest, WorldPackageDocument, error) {
+	var manifest WorldPackageManifest
+	var document WorldPackageDocument
+	if err := decodeStrictJSONFile(filepath.Join(root, "manifest.json"), &manifest); err != nil {
+		return manifest, document, fmt.Errorf("%w: manifest.json 无效: %v", ErrWorldPackageInvalid, err)
+	}
+	if manifest.PackageKind != worldPackageKind || manifest.PackageVersion < 1 || manifest.PackageVersion > worldPackageVersion {
+		return manifest, document, fmt.Errorf("%w: 模板包版本或类型不受支持", ErrWorldPackageInvalid)
+	}
+	files := []TheaterPackageFile{manifest.Document}
+	assetIDs := map[string]struct{}{}
+	for _, asset := range manifest.Assets {
+		if strings.TrimSpace(asset.ID) == "" {
+			return manifest, document, fmt.Errorf("%w: 附件 ID 缺失", ErrWorldPackageInvalid)
+		}
+		if _, exists := assetIDs[asset.ID]; exists {
+			return manifest, document, fmt.Errorf("%w: 附件 ID 重复: %s", ErrWorldPackageInvalid, asset.ID)
+		}
+		assetIDs[asset.ID] = struct{}{}
+		files = append(files, asset.File)
+	}
+	if manifest.Theater != nil {
+		files = append(files, *manifest.Theater)
+	}
+	for _, history := range manifest.History {
+		files = append(files, history.File)
+	}
+	seen := map[string]struct{}{}
+	for _, item := range files {
+		if !validTheaterPackageRelativePath(item.Path) || item.Size < 0 || len(item.SHA256) != 64 {
+			return manifest, document, fmt.Errorf("%w: manifest 文件声明无效", ErrWorldPackageInvalid)
+		}
+		if _, exists := seen[item.Path]; exists {
+			return manifest, document, fmt.Errorf("%w: manifest 文件路径重复: %s", ErrWorldPackageInvalid, item.Path)
+		}
+		seen[item.Path] = struct{}{}
+		actual, err := theaterPackageFile(theaterPackageAbsolutePath(root, item.Path), item.MimeType, item.Filename)
+		if err != nil {
+			return manifest, document, err
+		}
+		if actual.Size != item.Size || !strings.EqualFold(actual.SHA256, item.SHA256) {
+			return manifest, document, fmt.Errorf("%w: 文件校验失败: %s", ErrWorldPackageInvalid, item.Path)
+		}
+	}
+	if err := decodeStrictJSONFile(theaterPackageAbsolutePath(root, manifest.Document.Path), &document); err != nil {
+		return manifest, document, fmt.Errorf("%w: 世界文档无效: %v", ErrWorldPackageInvalid, err)
+	}
+	if strings.TrimSpace(document.World.Name) == "" {
+		return manifest, document, fmt.Errorf("%w: 世界名称缺失", ErrWorldPackageInvalid)
+	}
+	channelIDs := map[string]struct{}{}
+	for _, channel := range document.Channels {
+		if strings.TrimSpace(channel.ID) == "" {
+			return manifest, document, fmt.Errorf("%w: 频道 ID 缺失", ErrWorldPackageInvalid)
+		}
+		if _, exists := channelIDs[channel.ID]; exists {
+			return manifest, document, fmt.Errorf("%w: 频道 ID 重复: %s", ErrWorldPackageInvalid, channel.ID)
+		}
+		channelIDs[channel.ID] = struct{}{}
+	}
+	return manifest, document, nil
+}
+
+// orderWorldPackageChannels 按父频道优先排序，父频道缺失或成环的频道降级为顶层频道
+func orderWorldPackageChannels(channels []WorldPackageChannel) ([]WorldPackageChannel, int) {
+	byID := make(map[string]int, len(channels))
+	for index, channel := range channels {
+		byID[channel.ID] = index
+	}
+	result := make([]WorldPackageChannel, 0, len(channels))
+	placed := make(map[string]bool, len(channels))
+	dropped := 0
+	var place func(index int, visiting map[string]bool)
+	place = func(index int, visiting map[string]bool) {
+		channel := channels[index]
+		if placed[channel.ID] {
+			return
+		}
+		visiting[channel.ID] = true
+		if channel.ParentID != "" {
+			parentIndex, ok := byID[channel.ParentID]
+			if !ok || visiting[channel.ParentID] {
+				channel.ParentID = ""
+				dropped++
+			} else {
+				place(parentIndex, visiting)
+			}
+		}
+		delete(visiting, channel.ID)
+		if !placed[channel.ID] {
+			placed[channel.ID] = true
+			result = append(result, channel)
+		}
+	}
+	for index := range channels {
+		place(index, map[string]bool{})
+	}
+	return result, dropped
+}
+
+func createWorldFromPackage(actorID string, settings WorldPackageSettings) (*model.WorldModel, error) {
+	config := utils.GetConfig()
+	if config != nil && !config.Audio.AllowNonAdminCreateWorld {
+		if !pm.CanWithSystemRole(actorID, pm.PermModAdmin) {
+			return nil, ErrWorldCreateForbidden
+		}
+	}
+	description, err := normalizeWorldDescription(settings.Description)
+	if err != nil {
+		return nil, err
+	}
+	visibility := settings.Visibility
+	if visibility != model.WorldVisibilityPublic && visibility != model.WorldVisibilityPrivate && visibility != model.WorldVisibilityUnlisted {
+		visibility = model.WorldVisibilityPrivate
+	}
+	world := &model.WorldModel{
+		Name:                                  strings.TrimSpace(settings.Name),
+		Description:                           description,
+		Visibility:                            visibility,
+		OwnerID:                               actorID,
+		EnforceMembership:                     settings.EnforceMembership,
+		AllowAdminEditMessages:                settings.AllowAdminEditMessages,
+		AllowManageOtherUserChannelIdentities: settings.AllowManageOtherUserChannelIdentities,
+		AllowMemberEditKeywords:               settings.AllowMemberEditKeywords,
+		StrictWhisperPrivacy:                  settings.StrictWhisperPrivacy,
+		ChannelDefaultDiceMode:                model.WorldChannelDefaultDiceModeBuiltin, // 机器人绑定不随模板包迁移
+		CharacterCardBadgeTemplate:            settings.CharacterCardBadgeTemplate,
+		CursorThemeJSON:                       settings.CursorThemeJSON,
+		TheaterPresentationTemplateJSON:       settings.TheaterPresentationTemplateJSON,
+		TheaterActivated:                      settings.TheaterActivated,
+		StickyNoteDefaultAppearanceJSON:       settings.StickyNoteDefaultAppearanceJSON,
+		Dice3DConfigJSON:                      settings.Dice3DConfigJSON,
+		Status:                                "active",
+	}
+	err = model.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(world).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.WorldMemberModel{
+			WorldID: world.ID, UserID: actorID, Role: model.WorldRoleOwner, JoinedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return world, nil
+}
+
+func importWorldPackageAsset(root string, asset WorldPackageAsset, worldID, actorID string) (string, error) {
+	hash, err := hex.DecodeString(asset.File.SHA256)
+	if err != nil {
+		return "", err
+	}
+	location, err := PersistAttachmentFile(hash, asset.File.Size, theaterPackageAbsolutePath(root, asset.File.Path), asset.File.MimeType)
+	if err != nil {
+		return "", err
+	}
+	attachment := model.AttachmentModel{
+		Hash: model.ByteArray(hash), Filename: asset.File.Filename, Size: asset.File.Size, MimeType: asset.File.MimeType,
+		UserID: actorID, StorageType: location.StorageType, ObjectKey: location.ObjectKey, ExternalURL: location.ExternalURL,
+		RootID: worldID, RootIDType: "world", IsTemp: false,
+	}
+	if err := model.GetDB().Create(&attachment).Error; err != nil {
+		return "", err
+	}
+	return attachment.ID, nil
+}
+
+// importWorldPackageChannelContent 写入频道角色权限、iForm、骰子宏与便签；骰子宏归属于导入者
+func importWorldPackageChannelContent(tx *gorm.DB, channel WorldPackageChannel, worldID, actorID string, remap worldPackageRemap, rolePerms map[string][]string) error {
+	channelID := remap.channels[channel.ID]
+	for _, role := range channel.Roles {
+		key := strings.TrimSpace(role.Key)
+		if key == "" || strings.ContainsAny(key, " %") {
+			continue
+		}
+		roleID := fmt.Sprintf("ch-%s-%s", channelID, key)
+		var existing model.ChannelRoleModel
+		if err := tx.Where("id = ?", roleID).Limit(1).Find(&existing).Error; err != nil {
+			return err
+		}
+		if existing.ID == "" {
+			if err := tx.Create(&model.ChannelRoleModel{
+				StringPKBaseModel: model.StringPKBaseModel{ID: roleID}, Name: role.Name, Desc: role.Desc, ChannelID: channelID,
+			}).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(&model.ChannelRoleModel{}).Where("id = ?", roleID).
+			Updates(map[string]any{"name": role.Name, "desc": role.Desc}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", roleID).Delete(&model.RolePermissionModel{}).Error; err != nil {
+			return err
+		}
+		perms := make([]model.RolePermissionModel, 0, len(role.Permissions))
+		seen := map[string]struct{}{}
+		for _, permID := range role.Permissions {
+			if _, ok := gen.PermChannelMap[permID]; !ok {
+				continue
+			}
+			if _, ok := seen[permID]; ok {
+				continue
+			}
+			seen[permID] = struct{}{}
+			perms = append(perms, model.RolePermissionModel{
+				StringPKBaseModel: model.StringPKBaseModel{ID: utils.NewID()}, RoleID: roleID, PermissionID: permID,
+			})
+		}
+		rolePerms[roleID] = []string{}
+		if len(perms) > 0 {
+			if err := tx.Create(&perms).Error; err != nil {
+				return err
+			}
+			for _, perm := range perms {
+				rolePerms[roleID] = append(rolePerms[roleID], perm.PermissionID)
+			}
+		}
+	}
+
+	for _, form := range channel.IForms {
+		newID := utils.NewID()
+		remap.iforms[form.ID] = newID
+		form.StringPKBaseModel = model.StringPKBaseModel{ID: newID}
+		form.ChannelID = channelID
+		form.CreatedBy = actorID
+		form.UpdatedBy = actorID
+		if err := tx.Create(&form).Error; err != nil {
+			return err
+		}
+	}
+	for _, macro := range channel.DiceMacros {
+		item := model.DiceMacroModel{
+			UserID: actorID, ChannelID: channelID, Digits: macro.Digits, Label: macro.Label,
+			Expr: macro.Expr, Note: macro.Note, Favorite: macro.Favorite,
+		}
+		item.Init()
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, folder := range channel.StickyNoteFolders {
+		remap.folders[folder.ID] = utils.NewID()
+	}
+	for _, folder := range channel.StickyNoteFolders {
+		item := model.StickyNoteFolderModel{
+			StringPKBaseModel: model.StringPKBaseModel{ID: remap.folders[folder.ID]},
+			ChannelID:         channelID, WorldID: worldID, ParentID: remap.folders[folder.ParentID],
+			Name: folder.Name, Color: folder.Color, OrderIndex: folder.OrderIndex, CreatorID: actorID,
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+	for _, note := range channel.StickyNotes {
+		item := model.StickyNoteModel{
+			StringPKBaseModel: model.StringPKBaseModel{ID: utils.NewID()},
+			ChannelID:         channelID, WorldID: worldID, FolderID: remap.folders[note.FolderID],
+			Title: note.Title, Content: remapWorldPackageAttachmentRefs(note.Content, remap.attachments), ContentText: note.ContentText,
+			Color: note.Color, AppearanceJSON: remapWorldPackageAttachmentRefs(note.AppearanceJSON, remap.attachments),
+			CreatorID: actorID, IsPublic: note.IsPublic, IsPinned: note.IsPinned, OrderIndex: note.OrderIndex,
+			NoteType: model.StickyNoteType(note.NoteType), TypeData: note.TypeData, Visibility: model.StickyNoteVisibility(note.Visibility),
+			DefaultX: note.DefaultX, DefaultY: note.DefaultY, DefaultW: note.DefaultW, DefaultH: note.DefaultH,
+		}
+		if item.NoteType == "" {
+			item.NoteType = model.StickyNoteTypeText
+		}
+		if item.Visibility == "" {
+			item.Visibility = model.StickyNoteVisibilityAll
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importWorldPackageWorldContent(tx *gorm.DB, document WorldPackageDocument, worldID, actorID string, remap worldPackageRemap) error {
+	for _, formID := range document.SharedIFormIDs {
+		newID := remap.iforms[formID]
+		if newID == "" {
+			continue
+		}
+		if err := tx.Create(&model.WorldIFormBindingModel{
+			StringPKBaseModel: model.StringPKBaseModel{ID: utils.NewID()}, WorldID: worldID, FormID: newID, CreatedBy: actorID, UpdatedBy: actorID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	for _, category := range document.KeywordCategories {
+		category.StringPKBaseModel = model.StringPKBaseModel{ID: utils.NewID()}
+		category.WorldID = worldID
+		category.CreatedBy = actorID
+		category.UpdatedBy = actorID
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+	}
+	for _, keyword := range document.Keywords {
+		keyword.StringPKBaseModel = model.StringPKBaseModel{ID: utils.NewID()}
+		keyword.WorldID = worldID
+		keyword.Description = remapWorldPackageAttachmentRefs(keyword.Description, remap.attachments)
+		keyword.CreatedBy = actorID
+		keyword.UpdatedBy = actorID
+		keyword.MatchedVia = ""
+		if err := tx.Create(&keyword).Error; err != nil {
+			return err
+		}
+	}
+	for _, template := range document.CardTemplates {
+		item := model.CharacterCardTemplateModel{
+			StringPKBaseModel: model.StringPKBaseModel{ID: utils.NewID()},
+			UserID:            actorID, Name: template.Name, SheetType: template.SheetType, Content: template.Content,
+			DefaultBadgeTemplate: template.DefaultBadgeTemplate,
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.WorldCharacterCardTemplateBindingModel{
+			StringPKBaseModel: model.StringPKBaseModel{ID: utils.NewID()}, WorldID: worldID, TemplateID: item.ID, CreatedBy: actorID, UpdatedBy: actorID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	for _, announcement := range document.Announcements {
+		announcement.StringPKBaseModel = model.StringPKBaseModel{ID: utils.NewID()}
+		announcement.ScopeType = model.AnnouncementScopeWorld
+		announcement.ScopeID = worldID
+		announcement.Content = remapWorldPackageAttachmentRefs(announcement.Content, remap.attachments)
+		announcement.CreatedBy = actorID
+		announcement.UpdatedBy = actorID
+		if err := tx.Create(&announcement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importWorldPackageMessages 以导入消息写入历史，发送者统一记为导入者并保留原展示名
+func importWorldPackageMessages(tx *gorm.DB, channelID string, job *model.WorldPackageJobModel, messages []WorldPackageMessage, remap worldPackageRemap) error {
+	for _, item := range messages {
+		remap.messages[item.ID] = utils.NewID()
+	}
+	rows := make([]*model.MessageModel, 0, len(messages))
+	for _, item := range messages {
+		icMode := item.ICMode
+		if icMode != "ooc" {
+			icMode = "ic"
+		}
+		content := remapWorldPackageAttachmentRefs(item.Content, remap.attachments)
+		rows = append(rows, &model.MessageModel{
+			StringPKBaseModel:      model.StringPKBaseModel{ID: remap.messages[item.ID], CreatedAt: item.CreatedAt, UpdatedAt: item.CreatedAt},
+			Content:                content,
+			WidgetData:             item.WidgetData,
+			ChannelID:              channelID,
+			UserID:                 job.ActorUserID,
+			QuoteID:                remap.messages[item.QuoteID],
+			DisplayOrder:           item.DisplayOrder,
+			ICMode:                 icMode,
+			IsArchived:             item.IsArchived,
+			IsPinned:               item.IsPinned,
+			IsImported:             true,
+			ImportJobID:            job.ID,
+			SenderMemberName:       item.SenderName,
+			SenderIdentityName:     item.SenderName,
+			SenderIdentityColor:    item.SenderColor,
+			SenderIdentityAvatarID: remapWorldPackageAttachmentValue(item.SenderAvatarID, remap.attachments),
+		})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := tx.CreateInBatches(rows, 100).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := model.ReplaceMessageImageAttachments(tx, row.ID, row.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestOrderWorldPackageChannelsParentsFirst(t *testing.T) {
+	channels := []WorldPackageChannel{
+		{ID: "child", ParentID: "root"},
+		{ID: "orphan", ParentID: "missing"},
+		{ID: "root"},
+		{ID: "loop-a", ParentID: "loop-b"},
+		{ID: "loop-b", ParentID: "loop-a"},
+	}
+	ordered, dropped := orderWorldPackageChannels(channels)
+	if len(ordered) != len(channels) {
+		t.Fatalf("expected %d channels, got %d", len(channels), len(ordered))
+	}
+	position := map[string]int{}
+	for index, channel := range ordered {
+		position[channel.ID] = index
+		if channel.ParentID != "" {
+			if _, ok := position[channel.ParentID]; !ok {
+				t.Fatalf("channel %s placed before parent %s", channel.ID, channel.ParentID)
+			}
+		}
+	}
+	if dropped != 2 {
+		t.Fatalf("expected orphan and one loop member to be detached, got %d", dropped)
+	}
+}
+
+func TestRemapWorldPackageAttachmentRefs(t *testing.T) {
+	refs := map[string]struct{}{}
+	content := `<img src="id:old1"> 以及 id:old2 和 id:unknown`
+	collectWorldPackageAttachmentRefs(refs, content)
+	if len(refs) != 3 {
+		t.Fatalf("expected 3 refs, got %v", refs)
+	}
+	mapping := map[string]string{"old1": "new1", "old2": "new2"}
+	got := remapWorldPackageAttachmentRefs(content, mapping)
+	want := `<img src="id:new1"> 以及 id:new2 和 id:unknown`
+	if got != want {
+		t.Fatalf("unexpected remap result: %s", got)
+	}
+	if got := remapWorldPackageAttachmentValue("old1", mapping); got != "new1" {
+		t.Fatalf("raw attachment value not remapped: %s", got)
+	}
+	if got := remapWorldPackageAttachmentValue("id:old2", mapping); got != "id:new2" {
+		t.Fatalf("prefixed attachment value not remapped: %s", got)
+	}
+	collectWorldPackageAttachmentValue(refs, "https://example.com/a.png")
+	if len(refs) != 3 {
+		t.Fatalf("url should not be treated as attachment id: %v", refs)
+	}
+}
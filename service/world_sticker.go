@@ -0,0 +1,1050 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"sealchat/model"
+	"sealchat/pm"
+)
+
+var (
+	ErrWorldStickerPackNotFound      = errors.New("world sticker pack not found")
+	ErrWorldStickerNotFound          = errors.New("world sticker not found")
+	ErrWorldStickerShortcodeInvalid  = errors.New("表情短码需以文字开头，只能包含文字、数字、下划线或连字符，最长 32 个字符")
+	ErrWorldStickerShortcodeConflict = errors.New("表情短码已被本世界的其他表情占用")
+	ErrWorldStickerLimitExceeded     = errors.New("表情数量超过上限")
+)
+
+const (
+	worldStickerPackLimit   = 50
+	worldStickerPerPackMax  = 100
+	worldStickerMaxFileSize = 2 * 1024 * 1024
+	worldStickerManifest    = "manifest.json"
+)
+
+var (
+	worldStickerShortcodePattern = regexp.MustCompile(`^\p{L}[\p{L}\p{N}_-]{0,31}$`)
+	worldStickerTokenPattern     = regexp.MustCompile(`:(\p{L}[\p{L}\p{N}_-]{0,31}):`)
+	worldStickerHTMLTagPattern   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// WorldStickerPackInput 用于创建或更新表情包。
+type WorldStickerPackInput struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	CoverID      string   `json:"coverId"`
+	AllowedRoles []string `json:"allowedRoles"`
+	SortOrder    *int     `json:"sortOrder"`
+	Enabled      *bool    `json:"isEnabled"`
+}
+
+// WorldStickerInput 用于添加或更新单个表情；AttachmentID 仅在添加时使用。
+type WorldStickerInput struct {
+	Shortcode    string   `json:"shortcode"`
+	Aliases      []string `json:"aliases"`
+	AttachmentID string   `json:"attachmentId"`
+	SortOrder    *int     `json:"sortOrder"`
+}
+
+// WorldStickerPackView 表情包及其表情。
+type WorldStickerPackView struct {
+	*model.WorldStickerPackModel
+	Stickers []*model.WorldStickerModel `json:"stickers"`
+}
+
+// worldStickerPackFile 导出 ZIP 中 manifest.json 的结构
+type worldStickerPackFile struct {
+	Version      int                    `json:"version"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description,omitempty"`
+	AllowedRoles []string               `json:"allowedRoles,omitempty"`
+	Stickers     []worldStickerFileItem `json:"stickers"`
+}
+
+type worldStickerFileItem struct {
+	Shortcode string   `json:"shortcode"`
+	Aliases   []string `json:"aliases,omitempty"`
+	File      string   `json:"file"`
+}
+
+func ensureWorldStickerManage(worldID, userID string) error {
+	if strings.TrimSpace(worldID) == "" || strings.TrimSpace(userID) == "" {
+		return ErrWorldPermission
+	}
+	if pm.CanWithSystemRole(userID, pm.PermModAdmin) || IsWorldAdmin(worldID, userID) {
+		return nil
+	}
+	return ErrWorldPermission
+}
+
+// worldStickerMemberRole 返回用户在世界中的角色；系统管理员视作世界管理员
+func worldStickerMemberRole(worldID, userID string) (string, error) {
+	if strings.TrimSpace(worldID) == "" || strings.TrimSpace(userID) == "" {
+		return "", ErrWorldPermission
+	}
+	var member model.WorldMemberModel
+	if err := model.GetDB().Where("world_id = ? AND user_id = ?", worldID, userID).Limit(1).Find(&member).Error; err != nil {
+		return "", err
+	}
+	if member.ID != "" {
+		return member.Role, nil
+	}
+	if pm.CanWithSystemRole(userID, pm.PermModAdmin) {
+		return model.WorldRoleAdmin, nil
+	}
+	return "", ErrWorldPermission
+}
+
+func worldStickerPackUsable(pack *model.WorldStickerPackModel, role string) bool {
+	if pack == nil || !pack.IsEnabled {
+		return false
+	}
+	if role == model.WorldRoleOwner || role == model.WorldRoleAdmin || len(pack.AllowedRoles) == 0 {
+		return true
+	}
+	for _, allowed := range pack.AllowedRoles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeWorldStickerRoles(roles []string) ([]string, error) {
+	result := make([]string, 0, len(roles))
+	seen := map[string]struct{}{}
+	for _, role := range roles {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if role == "" {
+			continue
+		}
+		switch role {
+		case model.WorldRoleOwner, model.WorldRoleAdmin, model.WorldRoleMember, model.WorldRoleSpectator:
+		default:
+			return nil, fmt.Errorf("未知的世界角色: %s", role)
+		}
+		if _, ok := seen[role]; ok {
+			continue
+		}
+		seen[role] = struct{}{}
+		result = append(result, role)
+	}
+	return result, nil
+}
+
+// normalizeWorldStickerShortcode 去掉首尾冒号并统一小写
+func normalizeWorldStickerShortcode(code string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(code), ":"))
+}
+
+func normalizeWorldStickerCodes(shortcode string, aliases []string) (string, []string, error) {
+	shortcode = normalizeWorldStickerShortcode(shortcode)
+	if !worldStickerShortcodePattern.MatchString(shortcode) {
+		return "", nil, ErrWorldStickerShortcodeInvalid
+	}
+	seen := map[string]struct{}{shortcode: {}}
+	result := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		alias = normalizeWorldStickerShortcode(alias)
+		if alias == "" {
+			continue
+		}
+		if !worldStickerShortcodePattern.MatchString(alias) {
+			return "", nil, ErrWorldStickerShortcodeInvalid
+		}
+		if _, ok := seen[alias]; ok {
+			continue
+		}
+		seen[alias] = struct{}{}
+		result = append(result, alias)
+	}
+	return shortcode, result, nil
+}
+
+// checkWorldStickerCodeConflict 短码与别名在同一世界内不得重复
+func checkWorldStickerCodeConflict(tx *gorm.DB, worldID, excludeID string, codes []string) error {
+	var stickers []*model.WorldStickerModel
+	if err := tx.Where("world_id = ?", worldID).Find(&stickers).Error; err != nil {
+		return err
+	}
+	taken := map[string]struct{}{}
+	for _, sticker := range stickers {
+		if sticker.ID == excludeID {
+			continue
+		}
+		taken[sticker.Shortcode] = struct{}{}
+		for _, alias := range sticker.Aliases {
+			taken[alias] = struct{}{}
+		}
+	}
+	for _, code := range codes {
+		if _, ok := taken[code]; ok {
+			return fmt.Errorf("%w: %s", ErrWorldStickerShortcodeConflict, code)
+		}
+	}
+	return nil
+}
+
+func getWorldStickerPack(worldID, packID string) (*model.WorldStickerPackModel, error) {
+	var pack model.WorldStickerPackModel
+	if err := model.GetDB().Where("id = ? AND world_id = ?", packID, worldID).Limit(1).Find(&pack).Error; err != nil {
+		return nil, err
+	}
+	if pack.ID == "" {
+		return nil, ErrWorldStickerPackNotFound
+	}
+	return &pack, nil
+}
+
+func listWorldStickersByPack(packIDs []string) (map[string][]*model.WorldStickerModel, error) {
+	result := map[string][]*model.WorldStickerModel{}
+	if len(packIDs) == 0 {
+		return result, nil
+	}
+	var stickers []*model.WorldStickerModel
+	if err := model.GetDB().Where("pack_id IN ?", packIDs).
+		Order("sort_order asc").Order("created_at asc").
+		Find(&stickers).Error; err != nil {
+		return nil, err
+	}
+	for _, sticker := range stickers {
+		result[sticker.PackID] = append(result[sticker.PackID], sticker)
+	}
+	return result, nil
+}
+
+// worldStickerPacksForWorld 本世界的表情包在前、共享进来的在后，各自按排序值排列
+func worldStickerPacksForWorld(worldID string) ([]*model.WorldStickerPackModel, error) {
+	db := model.GetDB()
+	var own []*model.WorldStickerPackModel
+	if err := db.Where("world_id = ?", worldID).
+		Order("sort_order asc").Order("created_at asc").
+		Find(&own).Error; err != nil {
+		return nil, err
+	}
+	var shared []*model.WorldStickerPackModel
+	if err := db.Where("id IN (?)", db.Model(&model.WorldStickerPackShareModel{}).Select("pack_id").Where("target_world_id = ?", worldID)).
+		Order("sort_order asc").Order("created_at asc").
+		Find(&shared).Error; err != nil {
+		return nil, err
+	}
+	for _, pack := range shared {
+		pack.SharedFrom = pack.WorldID
+	}
+	return append(own, shared...), nil
+}
+
+// WorldStickerPackList 返回当前用户可见的表情包；管理员能看到本世界全部表情包（含停用的），成员只看到可用的。
+func WorldStickerPackList(worldID, userID string) ([]*WorldStickerPackView, error) {
+	role, err := worldStickerMemberRole(worldID, userID)
+	if err != nil {
+		return nil, err
+	}
+	canManage := ensureWorldStickerManage(worldID, userID) == nil
+	packs, err := worldStickerPacksForWorld(worldID)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]*model.WorldStickerPackModel, 0, len(packs))
+	for _, pack := range packs {
+		if (canManage && pack.SharedFrom == "") || worldStickerPackUsable(pack, role) {
+			visible = append(visible, pack)
+		}
+	}
+	packIDs := make([]string, 0, len(visible))
+	for _, pack := range visible {
+		packIDs = append(packIDs, pack.ID)
+	}
+	stickers, err := listWorldStickersByPack(packIDs)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]*WorldStickerPackView, 0, len(visible))
+	for _, pack := range visible {
+		items := stickers[pack.ID]
+		if items == nil {
+			items = []*model.WorldStickerModel{}
+		}
+		pack.StickerCount = len(items)
+		views = append(views, &WorldStickerPackView{WorldStickerPackModel: pack, Stickers: items})
+	}
+	return views, nil
+}
+
+func applyWorldStickerPackInput(pack *model.WorldStickerPackModel, input WorldStickerPackInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return errors.New("表情包名称不能为空")
+	}
+	if len([]rune(name)) > 100 {
+		return errors.New("表情包名称过长")
+	}
+	roles, err := normalizeWorldStickerRoles(input.AllowedRoles)
+	if err != nil {
+		return err
+	}
+	pack.Name = name
+	pack.Description = strings.TrimSpace(input.Description)
+	pack.CoverID = strings.TrimSpace(input.CoverID)
+	pack.AllowedRoles = roles
+	if input.SortOrder != nil {
+		pack.SortOrder = *input.SortOrder
+	}
+	if input.Enabled != nil {
+		pack.IsEnabled = *input.Enabled
+	}
+	return nil
+}
+
+// WorldStickerPackCreate 创建表情包，默认排在最后。
+func WorldStickerPackCreate(worldID, actorID string, input WorldStickerPackInput) (*model.WorldStickerPackModel, error) {
+	if err := ensureWorldStickerManage(worldID, actorID); err != nil {
+		return nil, err
+	}
+	db := model.GetDB()
+	var count int64
+	if err := db.Model(&model.WorldStickerPackModel{}).Where("world_id = ?", worldID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= worldStickerPackLimit {
+		return nil, fmt.Errorf("%w: 每个世界最多 %d 个表情包", ErrWorldStickerLimitExceeded, worldStickerPackLimit)
+	}
+	pack := &model.WorldStickerPackModel{
+		WorldID:   worldID,
+		SortOrder: int(count),
+		IsEnabled: true,
+		CreatedBy: actorID,
+		UpdatedBy: actorID,
+	}
+	if err := applyWorldStickerPackInput(pack, input); err != nil {
+		return nil, err
+	}
+	if err := db.Create(pack).Error; err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// WorldStickerPackUpdate 更新表情包信息与可用角色。
+func WorldStickerPackUpdate(worldID, packID, actorID string, input WorldStickerPackInput) (*model.WorldStickerPackModel, error) {
+	if err := ensureWorldStickerManage(worldID, actorID); err != nil {
+		return nil, err
+	}
+	pack, err := getWorldStickerPack(worldID, packID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWorldStickerPackInput(pack, input); err != nil {
+		return nil, err
+	}
+	pack.UpdatedBy = actorID
+	if err := model.GetDB().Select("name", "description", "cover_id", "allowed_roles", "sort_order", "is_enabled", "updated_by").Updates(pack).Error; err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// WorldStickerPackDelete 删除表情包及其表情与共享记录；已发送消息中的图片不受影响。
+func WorldStickerPackDelete(worldID, packID, actorID string) error {
+	if err := ensureWorldStickerManage(worldID, actorID); err != nil {
+		return err
+	}
+	pack, err := getWorldStickerPack(worldID, packID)
+	if err != nil {
+		return err
+	}
+	return model.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pack_id = ?", pack.ID).Delete(&model.WorldStickerModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("pack_id = ?", pack.ID).Delete(&model.WorldStickerPackShareModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(pack).Error
+	})
+}
+
+// WorldStickerPackReorder 按传入顺序重排本世界的表情包，未列出的保持原有相对顺序排在后面。
+func WorldStickerPackReorder(worldID, actorID string, packIDs []string) error {
+	if err := ensureWorldStickerManage(worldID, actorID); err != nil {
+		return err
+	}
+	var packs []*model.WorldStickerPackModel
+	if err := model.GetDB().Where("world_id = ?", worldID).Order("sort_order asc").Order("created_at asc").Find(&packs).Error; err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(packs))
+	for _, pack := range packs {
+		ids = append(ids, pack.ID)
+	}
+	return model.GetDB().Transaction(func(tx *gorm.DB) error {
+		for index, id := range reorderWorldStickerIDs(ids, packIDs) {
+			if err := tx.Model(&model.WorldStickerPackModel{}).Where("id = ?", id).Update("sort_order", index).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// reorderWorldStickerIDs 指定的 ID 依次排前，忽略不属于 current 的 ID
+func reorderWorldStickerIDs(current, ordered []string) []string {
+	known := map[string]struct{}{}
+	for _, id := range current {
+		known[id] = struct{}{}
+	}
+	result := make([]string, 0, len(current))
+	placed := map[string]struct{}{}
+	for _, id := range ordered {
+		if _, ok := known[id]; !ok {
+			continue
+		}
+		if _, ok := placed[id]; ok {
+			continue
+		}
+		placed[id] = struct{}{}
+		result = append(result, id)
+	}
+	for _, id := range current {
+		if _, ok := placed[id]; !ok {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// WorldStickerAdd 把已上传的图片加入表情包；附件记录会复制一份归属到表情包，避免原附件被清理。
+func WorldStickerAdd(worldID, packID, actorID string, input WorldStickerInput) (*model.WorldStickerModel, error) {
+	if err := ensureWorldStickerManage(worldID, actorID); err != nil {
+		return nil, err
+	}
+	pack, err := getWorldStickerPack(worldID, packID)
+	if err != nil {
+		return nil, err
+	}
+	shortcode, aliases, err := normalizeWorldStickerCodes(input.Shortcode, input.Aliases)
+	if err != nil {
+		return nil, err
+	}
+	var source model.AttachmentModel
+	if err := model.GetDB().Where("id = ?", strings.TrimPrefix(strings.TrimSpace(input.AttachmentID), "id:")).Limit(1).Find(&source).Error; err != nil {
+		return nil, err
+	}
+	if source.ID == "" {
+		return nil, errors.New("附件不存在或已删除")
+	}
+	if !strings.HasPrefix(source.MimeType, "image/") {
+		return nil, errors.New("表情只能使用图片")
+	}
+	if source.Size > worldStickerMaxFileSize {
+		return nil, fmt.Errorf("表情图片不能超过 %d KB", worldStickerMaxFileSize/1024)
+	}
+
+	var sticker *model.WorldStickerModel
+	err = model.GetDB().Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.WorldStickerModel{}).Where("pack_id = ?", pack.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= worldStickerPerPackMax {
+			return fmt.Errorf("%w: 每个表情包最多 %d 个表情", ErrWorldStickerLimitExceeded, worldStickerPerPackMax)
+		}
+		if err := checkWorldStickerCodeConflict(tx, worldID, "", append([]string{shortcode}, aliases...)); err != nil {
+			return err
+		}
+		copied := model.AttachmentModel{
+			Hash:        source.Hash,
+			Filename:    source.Filename,
+			Size:        source.Size,
+			MimeType:    source.MimeType,
+			IsAnimated:  source.IsAnimated,
+			UserID:      actorID,
+			StorageType: source.StorageType,
+			ObjectKey:   source.ObjectKey,
+			ExternalURL: source.ExternalURL,
+			Width:       source.Width,
+			Height:      source.Height,
+			ThumbHash:   source.ThumbHash,
+			RootID:      pack.ID,
+			RootIDType:  "world_sticker_pack",
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+		sortOrder := int(count)
+		if input.SortOrder != nil {
+			sortOrder = *input.SortOrder
+		}
+		sticker = &model.WorldStickerModel{
+			PackID:       pack.ID,
+			WorldID:      worldID,
+			Shortcode:    shortcode,
+			Aliases:      aliases,
+			AttachmentID: copied.ID,
+			SortOrder:    sortOrder,
+			CreatedBy:    actorID,
+		}
+		return tx.Create(sticker).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sticker, nil
+}
+
+func getWorldSticker(worldID, stickerID string) (*model.WorldStickerModel, error) {
+	var sticker model.WorldStickerModel
+	if err := model.GetDB().Where("id = ? AND world_id = ?", stickerID, worldID).Limit(1).Find(&sticker).Error; err != nil {
+		return nil, err
+	}
+	if sticker.ID == "" {
+		return nil, ErrWorldStickerNotFound
+	}
+	return &sticker, nil
+}
+
+// WorldStickerUpdate 修改表情的短码、别名与排序。
+func WorldStickerUpdate(worldID, stickerID, actorID string, input WorldStickerInput) (*model.WorldStickerModel, error) {
+	if err := ensureWorldStickerManage(worldID, actorID); err != nil {
+		return nil, err
+	}
+	sticker, err := getWorldSticker(worldID, stickerID)
+	if err != nil {
+		return nil, err
+	}
+	shortcode, aliases, err := normalizeWorldStickerCodes(input.Shortcode, input.Aliases)
+	if err != nil {
+		return nil, err
+	}
+	err = model.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := checkWorldStickerCodeConflict(tx, worldID, sticker.ID, append([]string{shortcode}, aliases...)); err != nil {
+			return err
+		}
+		sticker.Shortcode = shortcode
+		sticker.Aliases = aliases
+		if input.SortOrder != nil {
+			sticker.SortOrder = *input.SortOrder
+		}
+		return tx.Select("shortcode", "aliases", "sort_order").Updates(sticker).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sticker, nil
+}
+
+// WorldStickerDelete 从表情包中移除表情。
+func WorldStickerDelete(worldID, stickerID, actorID string) error {
+	if err := ensureWorldStickerManage(worldID, actorID); err != nil {
+		return err
+	}
+	sticker, err := getWorldSticker(worldID, stickerID)
+	if err != nil {
+		return err
+	}
+	return model.GetDB().Delete(sticker).Error
+}
+
+// WorldStickerReorder 重排表情包内的表情。
+func WorldStickerReorder(worldID, packID, actorID string, stickerIDs []string) error {
+	if err := ensureWorldStickerManage(worldID, actorID); err != nil {
+		return err
+	}
+	pack, err := getWorldStickerPack(worldID, packID)
+	if err != nil {
+		return err
+	}
+	grouped, err := listWorldStickersByPack([]string{pack.ID})
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(grouped[pack.ID]))
+	for _, sticker := range grouped[pack.ID] {
+		ids = append(ids, sticker.ID)
+	}
+	return model.GetDB().Transaction(func(tx *gorm.DB) error {
+		for index, id := range reorderWorldStickerIDs(ids, stickerIDs) {
+			if err := tx.Model(&model.WorldStickerModel{}).Where("id = ?", id).Update("sort_order", index).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// worldStickerLookup 以短码与别名索引用户可用的表情；本世界表情包优先，同一短码取排序靠前者
+func worldStickerLookup(worldID, userID string) (map[string]*model.WorldStickerModel, error) {
+	role, err := worldStickerMemberRole(worldID, userID)
+	if err != nil {
+		return nil, err
+	}
+	packs, err := worldStickerPacksForWorld(worldID)
+	if err != nil {
+		return nil, err
+	}
+	usable := make([]string, 0, len(packs))
+	for _, pack := range packs {
+		if worldStickerPackUsable(pack, role) {
+			usable = append(usable, pack.ID)
+		}
+	}
+	grouped, err := listWorldStickersByPack(usable)
+	if err != nil {
+		return nil, err
+	}
+	lookup := map[string]*model.WorldStickerModel{}
+	for _, packID := range usable {
+		for _, sticker := range grouped[packID] {
+			for _, code := range append([]string{sticker.Shortcode}, sticker.Aliases...) {
+				if _, exists := lookup[code]; !exists {
+					lookup[code] = sticker
+				}
+			}
+		}
+	}
+	return lookup, nil
+}
+
+// ResolveWorldStickerReactionEmoji 把 :shortcode: 形式的表情反应换成 id:附件ID，其余输入原样返回。
+func ResolveWorldStickerReactionEmoji(worldID, userID, emoji string) (string, error) {
+	trimmed := strings.TrimSpace(emoji)
+	if strings.TrimSpace(worldID) == "" || len(trimmed) < 3 || !strings.HasPrefix(trimmed, ":") || !strings.HasSuffix(trimmed, ":") {
+		return emoji, nil
+	}
+	lookup, err := worldStickerLookup(worldID, userID)
+	if err != nil {
+		if errors.Is(err, ErrWorldPermission) {
+			return emoji, nil
+		}
+		return "", err
+	}
+	if sticker := lookup[normalizeWorldStickerShortcode(trimmed)]; sticker != nil {
+		return "id:" + sticker.AttachmentID, nil
+	}
+	return emoji, nil
+}
+
+// ExpandWorldStickerShortcodes 把消息中的 :shortcode: 展开为图片；TipTap JSON 按文本节点拆分，
+// 其余内容只替换标签之外的文本，代码块与行内代码保持原样。
+func ExpandWorldStickerShortcodes(worldID, userID, content string) string {
+	if strings.TrimSpace(worldID) == "" || strings.Count(content, ":") < 2 || !worldStickerTokenPattern.MatchString(content) {
+		return content
+	}
+	lookup, err := worldStickerLookup(worldID, userID)
+	if err != nil || len(lookup) == 0 {
+		return content
+	}
+	if LooksLikeTipTapJSON(content) {
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(content), &doc); err != nil {
+			return content
+		}
+		if !expandWorldStickerTipTapNode(doc, lookup) {
+			return content
+		}
+		encoded, err := json.Marshal(doc)
+		if err != nil {
+			return content
+		}
+		return string(encoded)
+	}
+	return expandWorldStickerMarkup(content, lookup)
+}
+
+func expandWorldStickerMarkup(content string, lookup map[string]*model.WorldStickerModel) string {
+	var buf strings.Builder
+	last := 0
+	inCode := 0
+	for _, loc := range worldStickerHTMLTagPattern.FindAllStringIndex(content, -1) {
+		segment := content[last:loc[0]]
+		if inCode > 0 {
+			buf.WriteString(segment)
+		} else {
+			buf.WriteString(replaceWorldStickerTokens(segment, lookup))
+		}
+		tag := strings.ToLower(content[loc[0]:loc[1]])
+		switch {
+		case strings.HasPrefix(tag, "<code"), strings.HasPrefix(tag, "<pre"):
+			inCode++
+		case strings.HasPrefix(tag, "</code"), strings.HasPrefix(tag, "</pre"):
+			if inCode > 0 {
+				inCode--
+			}
+		}
+		buf.WriteString(content[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	if inCode > 0 {
+		buf.WriteString(content[last:])
+	} else {
+		buf.WriteString(replaceWorldStickerTokens(content[last:], lookup))
+	}
+	return buf.String()
+}
+
+func replaceWorldStickerTokens(text string, lookup map[string]*model.WorldStickerModel) string {
+	return worldStickerTokenPattern.ReplaceAllStringFunc(text, func(token string) string {
+		sticker := lookup[normalizeWorldStickerShortcode(token)]
+		if sticker == nil {
+			return token
+		}
+		return fmt.Sprintf(`<img src="id:%s" alt="%s" />`, sticker.AttachmentID, escapeHTML(token))
+	})
+}
+
+// expandWorldStickerTipTapNode 原地改写节点树，返回是否有改动
+func expandWorldStickerTipTapNode(node map[string]any, lookup map[string]*model.WorldStickerModel) bool {
+	nodeType, _ := node["type"].(string)
+	if nodeType == "codeBlock" {
+		return false
+	}
+	children, ok := node["content"].([]any)
+	if !ok {
+		return false
+	}
+	changed := false
+	expanded := make([]any, 0, len(children))
+	for _, raw := range children {
+		child, ok := raw.(map[string]any)
+		if !ok {
+			expanded = append(expanded, raw)
+			continue
+		}
+		if childType, _ := child["type"].(string); childType == "text" && !worldStickerTipTapHasCodeMark(child) {
+			parts := splitWorldStickerTipTapText(child, lookup)
+			if parts != nil {
+				expanded = append(expanded, parts...)
+				changed = true
+				continue
+			}
+		} else if expandWorldStickerTipTapNode(child, lookup) {
+			changed = true
+		}
+		expanded = append(expanded, child)
+	}
+	if changed {
+		node["content"] = expanded
+	}
+	return changed
+}
+
+func worldStickerTipTapHasCodeMark(node map[string]any) bool {
+	marks, _ := node["marks"].([]any)
+	for _, raw := range marks {
+		if mark, ok := raw.(map[string]any); ok && mark["type"] == "code" {
+			return true
+		}
+	}
+	return false
+}
+
+// splitWorldStickerTipTapText 把文本节点按短码拆成文本与图片节点，没有命中时返回 nil
+func splitWorldStickerTipTapText(node map[string]any, lookup map[string]*model.WorldStickerModel) []any {
+	text, _ := node["text"].(string)
+	locs := worldStickerTokenPattern.FindAllStringIndex(text, -1)
+	var parts []any
+	last := 0
+	for _, loc := range locs {
+		token := text[loc[0]:loc[1]]
+		sticker := lookup[normalizeWorldStickerShortcode(token)]
+		if sticker == nil {
+			continue
+		}
+		if loc[0] > last {
+			parts = append(parts, cloneWorldStickerTipTapText(node, text[last:loc[0]]))
+		}
+		parts = append(parts, map[string]any{
+			"type":  "image",
+			"attrs": map[string]any{"src": "id:" + sticker.AttachmentID, "alt": token, "title": token},
+		})
+		last = loc[1]
+	}
+	if parts == nil {
+		return nil
+	}
+	if last < len(text) {
+		parts = append(parts, cloneWorldStickerTipTapText(node, text[last:]))
+	}
+	return parts
+}
+
+func cloneWorldStickerTipTapText(node map[string]any, text string) map[string]any {
+	clone := make(map[string]any, len(node))
+	for key, value := range node {
+		clone[key] = value
+	}
+	clone["text"] = text
+	return clone
+}
+
+// WorldStickerPackExport 导出表情包为 ZIP：manifest.json 记录短码与别名，图片放在 stickers/ 下。
+func WorldStickerPackExport(worldID, packID, actorID string) (string, []byte, error) {
+	if err := ensureWorldStickerManage(worldID, actorID); err != nil {
+		return "", nil, err
+	}
+	pack, err := getWorldStickerPack(worldID, packID)
+	if err != nil {
+		return "", nil, err
+	}
+	grouped, err := listWorldStickersByPack([]string{pack.ID})
+	if err != nil {
+		return "", nil, err
+	}
+	manifest := worldStickerPackFile{
+		Version:      1,
+		Name:         pack.Name,
+		Description:  pack.Description,
+		AllowedRoles: pack.AllowedRoles,
+		Stickers:     []worldStickerFileItem{},
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, sticker := range grouped[pack.ID] {
+		var attachment model.AttachmentModel
+		if err := model.GetDB().Where("id = ?", sticker.AttachmentID).Limit(1).Find(&attachment).Error; err != nil {
+			return "", nil, err
+		}
+		if attachment.ID == "" {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(attachment.Filename))
+		if ext == "" {
+			ext = ".png"
+		}
+		name := path.Join("stickers", sticker.Shortcode+ext)
+		if err := writeWorldStickerZipEntry(archive, name, &attachment); err != nil {
+			return "", nil, fmt.Errorf("导出表情 %s 失败: %w", sticker.Shortcode, err)
+		}
+		manifest.Stickers = append(manifest.Stickers, worldStickerFileItem{
+			Shortcode: sticker.Shortcode,
+			Aliases:   sticker.Aliases,
+			File:      name,
+		})
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", nil, err
+	}
+	writer, err := archive.Create(worldStickerManifest)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := writer.Write(manifestData); err != nil {
+		return "", nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return "", nil, err
+	}
+	fileName := sanitizeFileName(pack.Name)
+	if fileName == "" {
+		fileName = "stickers"
+	}
+	return fileName + ".zip", buf.Bytes(), nil
+}
+
+func writeWorldStickerZipEntry(archive *zip.Writer, name string, attachment *model.AttachmentModel) error {
+	temporary, err := MaterializeAttachmentToTempFile(attachment)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary)
+	source, err := os.Open(temporary)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, source)
+	return err
+}
+
+// WorldStickerPackImport 从 ZIP 导入为新的表情包；与本世界已有短码冲突的表情会被跳过并在结果中列出。
+func WorldStickerPackImport(worldID, actorID string, reader io.ReaderAt, size int64) (*WorldStickerPackView, []string, error) {
+	if err := ensureWorldStickerManage(worldID, actorID); err != nil {
+		return nil, nil, err
+	}
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, nil, errors.New("无法读取 ZIP 文件")
+	}
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[path.Clean(file.Name)] = file
+	}
+	manifestFile := files[worldStickerManifest]
+	if manifestFile == nil {
+		return nil, nil, errors.New("ZIP 中缺少 manifest.json")
+	}
+	var manifest worldStickerPackFile
+	if err := readWorldStickerZipJSON(manifestFile, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("manifest.json 解析失败: %w", err)
+	}
+	if len(manifest.Stickers) > worldStickerPerPackMax {
+		return nil, nil, fmt.Errorf("%w: 每个表情包最多 %d 个表情", ErrWorldStickerLimitExceeded, worldStickerPerPackMax)
+	}
+
+	pack, err := WorldStickerPackCreate(worldID, actorID, WorldStickerPackInput{
+		Name:         manifest.Name,
+		Description:  manifest.Description,
+		AllowedRoles: manifest.AllowedRoles,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var skipped []string
+	for index, item := range manifest.Stickers {
+		shortcode, aliases, err := normalizeWorldStickerCodes(item.Shortcode, item.Aliases)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", item.Shortcode, err))
+			continue
+		}
+		if err := checkWorldStickerCodeConflict(model.GetDB(), worldID, "", append([]string{shortcode}, aliases...)); err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", shortcode, err))
+			continue
+		}
+		file := files[path.Clean(item.File)]
+		if file == nil {
+			skipped = append(skipped, fmt.Sprintf("%s: 缺少图片文件 %s", shortcode, item.File))
+			continue
+		}
+		attachmentID, err := importWorldStickerZipImage(file, pack.ID, actorID)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", shortcode, err))
+			continue
+		}
+		if err := model.GetDB().Create(&model.WorldStickerModel{
+			PackID:       pack.ID,
+			WorldID:      worldID,
+			Shortcode:    shortcode,
+			Aliases:      aliases,
+			AttachmentID: attachmentID,
+			SortOrder:    index,
+			CreatedBy:    actorID,
+		}).Error; err != nil {
+			return nil, skipped, err
+		}
+	}
+	grouped, err := listWorldStickersByPack([]string{pack.ID})
+	if err != nil {
+		return nil, skipped, err
+	}
+	stickers := grouped[pack.ID]
+	if stickers == nil {
+		stickers = []*model.WorldStickerModel{}
+	}
+	pack.StickerCount = len(stickers)
+	return &WorldStickerPackView{WorldStickerPackModel: pack, Stickers: stickers}, skipped, nil
+}
+
+func readWorldStickerZipJSON(file *zip.File, target any) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return json.NewDecoder(io.LimitReader(reader, 1<<20)).Decode(target)
+}
+
+func importWorldStickerZipImage(file *zip.File, packID, actorID string) (string, error) {
+	if file.UncompressedSize64 > worldStickerMaxFileSize {
+		return "", fmt.Errorf("图片超过 %d KB", worldStickerMaxFileSize/1024)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	attachment, err := ImportAttachmentFromReader(reader, RemoteAttachmentImportInput{
+		Filename:     path.Base(file.Name),
+		UserID:       actorID,
+		MaxSizeBytes: worldStickerMaxFileSize,
+	})
+	if err != nil {
+		return "", err
+	}
+	if attachment == nil {
+		return "", errors.New("创建附件记录失败")
+	}
+	if !strings.HasPrefix(attachment.MimeType, "image/") {
+		return "", errors.New("不是图片文件")
+	}
+	if err := model.GetDB().Model(attachment).Updates(map[string]any{
+		"root_id":      packID,
+		"root_id_type": "world_sticker_pack",
+	}).Error; err != nil {
+		return "", err
+	}
+	return attachment.ID, nil
+}
+
+// WorldStickerPackShare 系统管理员把表情包共享给另一个世界，目标世界成员按表情包的角色设置使用。
+func WorldStickerPackShare(packID, targetWorldID, actorID string) (*model.WorldStickerPackShareModel, error) {
+	if !pm.CanWithSystemRole(actorID, pm.PermModAdmin) {
+		return nil, ErrWorldPermission
+	}
+	var pack model.WorldStickerPackModel
+	if err := model.GetDB().Where("id = ?", packID).Limit(1).Find(&pack).Error; err != nil {
+		return nil, err
+	}
+	if pack.ID == "" {
+		return nil, ErrWorldStickerPackNotFound
+	}
+	targetWorldID = strings.TrimSpace(targetWorldID)
+	if targetWorldID == "" || targetWorldID == pack.WorldID {
+		return nil, errors.New("目标世界无效")
+	}
+	world, err := GetWorldByID(targetWorldID)
+	if err != nil || world == nil {
+		return nil, ErrWorldNotFound
+	}
+	var share model.WorldStickerPackShareModel
+	if err := model.GetDB().Where("pack_id = ? AND target_world_id = ?", pack.ID, targetWorldID).Limit(1).Find(&share).Error; err != nil {
+		return nil, err
+	}
+	if share.ID != "" {
+		return &share, nil
+	}
+	share = model.WorldStickerPackShareModel{PackID: pack.ID, TargetWorldID: targetWorldID, CreatedBy: actorID}
+	if err := model.GetDB().Create(&share).Error; err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// WorldStickerPackUnshare 取消共享。
+func WorldStickerPackUnshare(packID, targetWorldID, actorID string) error {
+	if !pm.CanWithSystemRole(actorID, pm.PermModAdmin) {
+		return ErrWorldPermission
+	}
+	return model.GetDB().Where("pack_id = ? AND target_world_id = ?", packID, strings.TrimSpace(targetWorldID)).
+		Delete(&model.WorldStickerPackShareModel{}).Error
+}
+
+// WorldStickerPackShares 列出表情包共享到的世界。
+func WorldStickerPackShares(packID, actorID string) ([]*model.WorldStickerPackShareModel, error) {
+	if !pm.CanWithSystemRole(actorID, pm.PermModAdmin) {
+		return nil, ErrWorldPermission
+	}
+	var shares []*model.WorldStickerPackShareModel
+	if err := model.GetDB().Where("pack_id = ?", packID).Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].CreatedAt.Before(shares[j].CreatedAt) })
+	return shares, nil
+}
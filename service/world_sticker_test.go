@@ -0,0 +1,148 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"sealchat/model"
+	"sealchat/utils"
+)
+
+func createWorldStickerTestAttachment(t *testing.T, userID string) string {
+	t.Helper()
+	attachment := &model.AttachmentModel{
+		Hash:     model.ByteArray([]byte("sticker-" + utils.NewID())),
+		Filename: "sticker.png",
+		Size:     128,
+		MimeType: "image/png",
+		UserID:   userID,
+	}
+	if err := model.GetDB().Create(attachment).Error; err != nil {
+		t.Fatalf("create attachment failed: %v", err)
+	}
+	return attachment.ID
+}
+
+func TestWorldStickerShortcodesRoleGatingAndSharing(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	createExternalGlossaryTestUser(t, "sticker-owner")
+	createExternalGlossaryTestUser(t, "sticker-member")
+	createExternalGlossaryTestUser(t, "sticker-spectator")
+	createExternalGlossaryTestUser(t, "sticker-sysadmin")
+	createExternalGlossaryTestUser(t, "sticker-other")
+	grantExternalGlossarySystemAdmin(t, "sticker-sysadmin")
+	createExternalGlossaryTestWorld(t, "sticker-world", "sticker-owner")
+	createExternalGlossaryTestWorld(t, "sticker-world-2", "sticker-other")
+	createExternalGlossaryTestWorldMember(t, "sticker-world", "sticker-owner", model.WorldRoleOwner)
+	createExternalGlossaryTestWorldMember(t, "sticker-world", "sticker-member", model.WorldRoleMember)
+	createExternalGlossaryTestWorldMember(t, "sticker-world", "sticker-spectator", model.WorldRoleSpectator)
+	createExternalGlossaryTestWorldMember(t, "sticker-world-2", "sticker-other", model.WorldRoleMember)
+
+	if _, err := WorldStickerPackCreate("sticker-world", "sticker-member", WorldStickerPackInput{Name: "x"}); !errors.Is(err, ErrWorldPermission) {
+		t.Fatalf("member should not manage packs, got %v", err)
+	}
+	pack, err := WorldStickerPackCreate("sticker-world", "sticker-owner", WorldStickerPackInput{
+		Name:         "猫猫",
+		AllowedRoles: []string{model.WorldRoleMember},
+	})
+	if err != nil {
+		t.Fatalf("create pack failed: %v", err)
+	}
+	sticker, err := WorldStickerAdd("sticker-world", pack.ID, "sticker-owner", WorldStickerInput{
+		Shortcode:    ":Cat:",
+		Aliases:      []string{"喵"},
+		AttachmentID: createWorldStickerTestAttachment(t, "sticker-owner"),
+	})
+	if err != nil {
+		t.Fatalf("add sticker failed: %v", err)
+	}
+	if sticker.Shortcode != "cat" {
+		t.Fatalf("shortcode should be normalized, got %q", sticker.Shortcode)
+	}
+	if _, err := WorldStickerAdd("sticker-world", pack.ID, "sticker-owner", WorldStickerInput{
+		Shortcode:    "kitty",
+		Aliases:      []string{"喵"},
+		AttachmentID: createWorldStickerTestAttachment(t, "sticker-owner"),
+	}); !errors.Is(err, ErrWorldStickerShortcodeConflict) {
+		t.Fatalf("expected alias conflict, got %v", err)
+	}
+
+	emoji, err := ResolveWorldStickerReactionEmoji("sticker-world", "sticker-member", ":喵:")
+	if err != nil || emoji != "id:"+sticker.AttachmentID {
+		t.Fatalf("member reaction should resolve to sticker, got %q %v", emoji, err)
+	}
+	if emoji, _ := ResolveWorldStickerReactionEmoji("sticker-world", "sticker-spectator", ":cat:"); emoji != ":cat:" {
+		t.Fatalf("spectator should not use member-only pack, got %q", emoji)
+	}
+	if packs, err := WorldStickerPackList("sticker-world", "sticker-spectator"); err != nil || len(packs) != 0 {
+		t.Fatalf("spectator should see no packs, got %d %v", len(packs), err)
+	}
+
+	html := ExpandWorldStickerShortcodes("sticker-world", "sticker-member", `你好 :cat: <code>:cat:</code>`)
+	if !strings.Contains(html, `<img src="id:`+sticker.AttachmentID+`"`) || !strings.Contains(html, "<code>:cat:</code>") {
+		t.Fatalf("unexpected expanded html: %s", html)
+	}
+	tiptap := `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"a :cat: b"}]}]}`
+	expanded := ExpandWorldStickerShortcodes("sticker-world", "sticker-member", tiptap)
+	if !strings.Contains(expanded, `"type":"image"`) || !strings.Contains(expanded, `"text":" b"`) {
+		t.Fatalf("unexpected expanded tiptap: %s", expanded)
+	}
+
+	if _, err := WorldStickerPackShare(pack.ID, "sticker-world-2", "sticker-owner"); !errors.Is(err, ErrWorldPermission) {
+		t.Fatalf("world owner should not share packs, got %v", err)
+	}
+	if emoji, _ := ResolveWorldStickerReactionEmoji("sticker-world-2", "sticker-other", ":cat:"); emoji != ":cat:" {
+		t.Fatalf("unshared pack should not resolve in other world, got %q", emoji)
+	}
+	if _, err := WorldStickerPackShare(pack.ID, "sticker-world-2", "sticker-sysadmin"); err != nil {
+		t.Fatalf("share failed: %v", err)
+	}
+	packs, err := WorldStickerPackList("sticker-world-2", "sticker-other")
+	if err != nil || len(packs) != 1 || packs[0].SharedFrom != "sticker-world" || len(packs[0].Stickers) != 1 {
+		t.Fatalf("shared pack should be visible in target world, got %+v %v", packs, err)
+	}
+}
+
+func TestWorldStickerPackExportImportRoundTrip(t *testing.T) {
+	initExternalGlossaryTestDB(t)
+	InitStorageManager(utils.StorageConfig{Mode: utils.StorageModeLocal, Local: utils.LocalStorageConfig{UploadDir: t.TempDir(), TempDir: t.TempDir()}})
+	createExternalGlossaryTestUser(t, "sticker-owner")
+	createExternalGlossaryTestWorld(t, "sticker-world", "sticker-owner")
+	createExternalGlossaryTestWorld(t, "sticker-world-2", "sticker-owner")
+	createExternalGlossaryTestWorldMember(t, "sticker-world", "sticker-owner", model.WorldRoleOwner)
+	createExternalGlossaryTestWorldMember(t, "sticker-world-2", "sticker-owner", model.WorldRoleOwner)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+	source, err := ImportAttachmentFromReader(bytes.NewReader(png), RemoteAttachmentImportInput{Filename: "wave.png", UserID: "sticker-owner"})
+	if err != nil {
+		t.Fatalf("import source attachment failed: %v", err)
+	}
+	pack, err := WorldStickerPackCreate("sticker-world", "sticker-owner", WorldStickerPackInput{Name: "招呼"})
+	if err != nil {
+		t.Fatalf("create pack failed: %v", err)
+	}
+	if _, err := WorldStickerAdd("sticker-world", pack.ID, "sticker-owner", WorldStickerInput{Shortcode: "wave", Aliases: []string{"hi"}, AttachmentID: source.ID}); err != nil {
+		t.Fatalf("add sticker failed: %v", err)
+	}
+	name, data, err := WorldStickerPackExport("sticker-world", pack.ID, "sticker-owner")
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if name != "招呼.zip" {
+		t.Fatalf("unexpected export name %q", name)
+	}
+
+	imported, skipped, err := WorldStickerPackImport("sticker-world-2", "sticker-owner", bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if len(skipped) != 0 || len(imported.Stickers) != 1 {
+		t.Fatalf("unexpected import result: %+v skipped=%v", imported, skipped)
+	}
+	got := imported.Stickers[0]
+	if got.Shortcode != "wave" || len(got.Aliases) != 1 || got.Aliases[0] != "hi" || got.WorldID != "sticker-world-2" {
+		t.Fatalf("unexpected imported sticker: %+v", got)
+	}
+}
@@ -35,6 +35,8 @@ export interface ChannelRolePermSheet {
   func_channel_theater_resource_delete: PermResult; // 频道 - 小剧场 - 删除资源
   func_channel_theater_action_trigger: PermResult; // 频道 - 小剧场 - 触发动作
   func_channel_theater_admin_restore: PermResult; // 频道 - 小剧场 - 管理恢复
+  func_channel_voice_join: PermResult; // 频道 - 语音 - 加入语音
+  func_channel_voice_manage: PermResult; // 频道 - 语音 - 管理语音成员
   func_channel_read_all: PermResult; // 频道 - 特殊 - 查看所有子频道
   func_channel_text_send_all: PermResult; // 频道 - 特殊 - 在所有子频道发送文本
 }
@@ -668,6 +668,7 @@ export interface ServerConfig {
   imageSizeLimit: number;
   imageCompress: boolean;
   imageCompressQuality: number;
+  imageStripMetadata?: boolean;
   keywordMaxLength?: number;
   builtInSealBotEnable: boolean;
   botIncomingParenAsOoc?: boolean;
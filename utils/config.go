@@ -57,6 +57,11 @@ type AudioConfig struct {
 	FFmpegPath               string   `json:"ffmpegPath" yaml:"ffmpegPath"`
 	AllowWorldAudioWorkbench bool     `json:"allowWorldAudioWorkbench" yaml:"allowWorldAudioWorkbench"`
 	AllowNonAdminCreateWorld bool     `json:"allowNonAdminCreateWorld" yaml:"allowNonAdminCreateWorld"`
+	// 响度分析：上传/转码完成后测量 EBU R128 响度并生成波形
+	EnableLoudnessAnalysis bool    `json:"enableLoudnessAnalysis" yaml:"enableLoudnessAnalysis"`
+	LoudnessTargetLUFS     float64 `json:"loudnessTargetLufs" yaml:"loudnessTargetLufs"`
+	LoudnessMaxTruePeakDB  float64 `json:"loudnessMaxTruePeakDb" yaml:"loudnessMaxTruePeakDb"`
+	WaveformPoints         int     `json:"waveformPoints" yaml:"waveformPoints"`
 }
 
 type TheaterMediaConfig struct {
@@ -78,6 +83,20 @@ type TheaterMediaConfig struct {
 	KeepOriginal            bool  `json:"keepOriginal" yaml:"keepOriginal"`
 }
 
+// AttachmentVideoConfig 聊天视频附件的探测、封面与转码配置
+type AttachmentVideoConfig struct {
+	Enabled                 bool  `json:"enabled" yaml:"enabled"`
+	WorkerConcurrency       int   `json:"workerConcurrency" yaml:"workerConcurrency"`
+	Transcode               bool  `json:"transcode" yaml:"transcode"`                   // 编码不适合浏览器播放或尺寸过大时转码为 H.264（失败回退 VP9）
+	MaxDimension            int   `json:"maxDimension" yaml:"maxDimension"`             // 转码输出的最长边
+	MaxSourceSizeMB         int64 `json:"maxSourceSizeMB" yaml:"maxSourceSizeMB"`       // 超过该大小的源文件只生成封面
+	MaxDurationSeconds      int64 `json:"maxDurationSeconds" yaml:"maxDurationSeconds"` // 超过该时长的视频不转码
+	MaxRenditionSizeMB      int64 `json:"maxRenditionSizeMB" yaml:"maxRenditionSizeMB"` // 转码结果超过该大小时丢弃
+	VideoBitrateKbps        int   `json:"videoBitrateKbps" yaml:"videoBitrateKbps"`     // 转码码率上限
+	ProbeTimeoutSeconds     int   `json:"probeTimeoutSeconds" yaml:"probeTimeoutSeconds"`
+	TranscodeTimeoutSeconds int   `json:"transcodeTimeoutSeconds" yaml:"transcodeTimeoutSeconds"`
+}
+
 type StorageMode string
 
 const (
@@ -216,12 +235,27 @@ type SMTPConfig struct {
 
 // EmailNotificationConfig 邮件通知功能配置
 type EmailNotificationConfig struct {
-	Enabled          bool       `json:"enabled" yaml:"enabled"`
-	CheckIntervalSec int        `json:"-" yaml:"checkIntervalSec"` // 禁止前端获取
-	MaxPerHour       int        `json:"-" yaml:"maxPerHour"`       // 禁止前端获取
-	MinDelayMinutes  int        `json:"minDelayMinutes" yaml:"minDelayMinutes"`
-	MaxDelayMinutes  int        `json:"maxDelayMinutes" yaml:"maxDelayMinutes"`
-	SMTP             SMTPConfig `json:"-" yaml:"smtp"` // 禁止前端获取
+	Enabled          bool             `json:"enabled" yaml:"enabled"`
+	CheckIntervalSec int              `json:"-" yaml:"checkIntervalSec"` // 禁止前端获取
+	MaxPerHour       int              `json:"-" yaml:"maxPerHour"`       // 禁止前端获取
+	MinDelayMinutes  int              `json:"minDelayMinutes" yaml:"minDelayMinutes"`
+	MaxDelayMinutes  int              `json:"maxDelayMinutes" yaml:"maxDelayMinutes"`
+	SMTP             SMTPConfig       `json:"-" yaml:"smtp"` // 禁止前端获取
+	Reply            EmailReplyConfig `json:"reply" yaml:"reply"`
+
+	// 后台定时发送未读消息提醒邮件，默认关闭，避免已有部署升级后开始自动发信
+	DigestWorkerEnabled bool `json:"-" yaml:"digestWorkerEnabled"`
+}
+
+// EmailReplyConfig 通知邮件的回复投递配置，回复地址为 <addressPrefix>+<签名令牌>@<domain>
+type EmailReplyConfig struct {
+	Enabled       bool   `json:"enabled" yaml:"enabled"`
+	ListenAddr    string `json:"-" yaml:"listenAddr"`    // 入站 SMTP 监听地址，通常由前置 MTA 转发
+	Domain        string `json:"-" yaml:"domain"`        // 回复地址的域名
+	AddressPrefix string `json:"-" yaml:"addressPrefix"` // 回复地址本地部分的前缀
+	Secret        string `json:"-" yaml:"secret"`        // 回复令牌签名密钥，为空时不启用
+	TokenTTLHours int    `json:"-" yaml:"tokenTTLHours"`
+	MaxMessageKB  int    `json:"-" yaml:"maxMessageKB"`
 }
 
 // UpdateCheckConfig 更新检测配置
@@ -412,6 +446,18 @@ type AIConfig struct {
 	Pricing          []AIModelPricingConfig     `json:"pricing" yaml:"pricing"`
 	LogRetentionDays int                        `json:"logRetentionDays" yaml:"logRetentionDays"`
 	QuotaDefault     AIQuotaPolicyConfig        `json:"quotaDefault" yaml:"quotaDefault"`
+	Embedding        AIEmbeddingConfig          `json:"embedding" yaml:"embedding"`
+}
+
+// AIEmbeddingConfig 语义搜索的向量模型；ProviderID 为空时使用第一个启用的服务商
+type AIEmbeddingConfig struct {
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+	ProviderID  string `json:"providerId" yaml:"providerId"`
+	Model       string `json:"model" yaml:"model"`
+	Dimensions  int    `json:"dimensions" yaml:"dimensions"`
+	BatchSize   int    `json:"batchSize" yaml:"batchSize"`
+	IntervalSec int    `json:"intervalSec" yaml:"intervalSec"`
+	IndexDir    string `json:"indexDir" yaml:"indexDir"`
 }
 
 type PerformanceProfilerConfig struct {
@@ -440,7 +486,8 @@ type AppConfig struct {
 	ImageSizeLimit            int64                     `json:"imageSizeLimit" yaml:"imageSizeLimit"` // in kb
 	ImageCompress             bool                      `json:"imageCompress" yaml:"imageCompress"`
 	ImageCompressQuality      int                       `json:"imageCompressQuality" yaml:"imageCompressQuality"`
-	KeywordMaxLength          int64                     `json:"keywordMaxLength" yaml:"keywordMaxLength"` // 术语最大字数
+	ImageStripMetadata        bool                      `json:"imageStripMetadata" yaml:"imageStripMetadata"` // 上传时清除 EXIF 等元数据
+	KeywordMaxLength          int64                     `json:"keywordMaxLength" yaml:"keywordMaxLength"`     // 术语最大字数
 	DSN                       string                    `json:"-" yaml:"dbUrl" koanf:"dbUrl"`
 	BuiltInSealBotEnable      bool                      `json:"builtInSealBotEnable" yaml:"builtInSealBotEnable"` // 内置小海豹启用
 	BotIncomingParenAsOOC     bool                      `json:"botIncomingParenAsOoc" yaml:"botIncomingParenAsOoc"`
@@ -449,7 +496,10 @@ type AppConfig struct {
 	GalleryQuotaMB            int64                     `json:"galleryQuotaMB" yaml:"galleryQuotaMB"`
 	LogUpload                 LogUploadConfig           `json:"logUpload" yaml:"logUpload"`
 	Audio                     AudioConfig               `json:"audio" yaml:"audio"`
+	Voice                     VoiceConfig               `json:"voice" yaml:"voice"`
 	TheaterMedia              TheaterMediaConfig        `json:"theaterMedia" yaml:"theaterMedia"`
+	AttachmentVideo           AttachmentVideoConfig     `json:"attachmentVideo" yaml:"attachmentVideo"`
+	QQBridge                  QQBridgeConfig            `json:"-" yaml:"qqBridge"`
 	Export                    ExportConfig              `json:"export" yaml:"export"`
 	Storage                   StorageConfig             `json:"storage" yaml:"storage"`
 	SQLite                    SQLiteConfig              `json:"sqlite" yaml:"sqlite"`
@@ -470,6 +520,39 @@ type AppConfig struct {
 	PerformanceProfiler       PerformanceProfilerConfig `json:"performanceProfiler" yaml:"performanceProfiler"`
 }
 
+// QQBridgeConfig QQ 群桥接的连接限制
+type QQBridgeConfig struct {
+	// 允许指向本机或内网地址的主机名/IP，例如与 OneBot 实现同机部署时填写 127.0.0.1
+	AllowedPrivateHosts []string `json:"-" yaml:"allowedPrivateHosts"`
+}
+
+// VoiceConfig 语音频道配置
+type VoiceConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// 不超过该人数时成员两两直连（mesh），超过后需要 SFU
+	MeshMaxParticipants int `json:"meshMaxParticipants" yaml:"meshMaxParticipants"`
+	// 单个频道语音人数上限，0 表示不限制
+	MaxParticipants int                    `json:"maxParticipants" yaml:"maxParticipants"`
+	ICEServers      []VoiceICEServerConfig `json:"iceServers" yaml:"iceServers"`
+	// TURN REST 共享密钥（coturn use-auth-secret），填写后为 turn: 地址签发临时凭据
+	TURNSecret        string         `json:"-" yaml:"turnSecret"`
+	TURNCredentialTTL int            `json:"turnCredentialTtl" yaml:"turnCredentialTtl"` // 秒
+	SFU               VoiceSFUConfig `json:"sfu" yaml:"sfu"`
+}
+
+type VoiceICEServerConfig struct {
+	URLs       []string `json:"urls" yaml:"urls"`
+	Username   string   `json:"username" yaml:"username"`
+	Credential string   `json:"-" yaml:"credential"`
+}
+
+// VoiceSFUConfig 外部 SFU 接入，服务端仅签发接入令牌，不负责媒体转发
+type VoiceSFUConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	URL     string `json:"url" yaml:"url"`
+	Secret  string `json:"-" yaml:"secret"`
+}
+
 type ExportConfig struct {
 	StorageDir            string   `json:"storageDir" yaml:"storageDir"`
 	DownloadBandwidthKBps int      `json:"downloadBandwidthKBps" yaml:"downloadBandwidthKBps"`
@@ -525,6 +608,7 @@ func ReadConfig() *AppConfig {
 		ImageSizeLimit:            8192,
 		ImageCompress:             true,
 		ImageCompressQuality:      85,
+		ImageStripMetadata:        true,
 		KeywordMaxLength:          2000,
 		DSN:                       "./data/chat.db",
 		BuiltInSealBotEnable:      true,
@@ -553,6 +637,19 @@ func ReadConfig() *AppConfig {
 			FFmpegPath:               "",
 			AllowWorldAudioWorkbench: false,
 			AllowNonAdminCreateWorld: true,
+			EnableLoudnessAnalysis:   true,
+			LoudnessTargetLUFS:       -16,
+			LoudnessMaxTruePeakDB:    -1,
+			WaveformPoints:           200,
+		},
+		Voice: VoiceConfig{
+			Enabled:             true,
+			MeshMaxParticipants: 6,
+			MaxParticipants:     0,
+			ICEServers: []VoiceICEServerConfig{
+				{URLs: []string{"stun:stun.l.google.com:19302"}},
+			},
+			TURNCredentialTTL: 86400,
 		},
 		TheaterMedia: TheaterMediaConfig{
 			Enabled:                 true,
@@ -572,6 +669,18 @@ func ReadConfig() *AppConfig {
 			TranscodeTimeoutSeconds: 900,
 			KeepOriginal:            true,
 		},
+		AttachmentVideo: AttachmentVideoConfig{
+			Enabled:                 true,
+			WorkerConcurrency:       1,
+			Transcode:               true,
+			MaxDimension:            1280,
+			MaxSourceSizeMB:         1024,
+			MaxDurationSeconds:      1800,
+			MaxRenditionSizeMB:      200,
+			VideoBitrateKbps:        2500,
+			ProbeTimeoutSeconds:     30,
+			TranscodeTimeoutSeconds: 1800,
+		},
 		Export: ExportConfig{
 			StorageDir:            defaultExportStorageDir,
 			DownloadBandwidthKBps: 0,
@@ -625,6 +734,12 @@ func ReadConfig() *AppConfig {
 				UseTLS:   true,
 				FromName: "SealChat",
 			},
+			Reply: EmailReplyConfig{
+				ListenAddr:    "127.0.0.1:2525",
+				AddressPrefix: "reply",
+				TokenTTLHours: 7 * 24,
+				MaxMessageKB:  1024,
+			},
 		},
 		EmailAuth: EmailAuthConfig{
 			Enabled:        false,
@@ -765,6 +880,7 @@ func ReadConfig() *AppConfig {
 	config.Captcha.normalize()
 	applyEmailNotificationDefaults(&config.EmailNotification)
 	applyEmailAuthDefaults(&config.EmailAuth)
+	applyVoiceDefaults(&config.Voice)
 	applyUpdateCheckDefaults(&config.UpdateCheck)
 	applyBackupDefaults(&config.Backup)
 	applyAuthSessionDefaults(&config.AuthSession)
@@ -898,6 +1014,11 @@ const defaultAIBattleSummaryPrompt = `你是一位资深 TRPG 编年史官。你
 
 const legacyAIPolishPrompt = "你是中文文本润色助手。保持原意，修正病句，提升流畅度，不要增加无关信息。"
 
+const defaultAIGlossaryPrompt = `你是 TRPG 世界观整理助手。阅读用户提供的跑团记录，找出其中反复出现或对剧情重要的专有名词（人物、地点、组织、物品、事件等）。
+只输出 JSON 数组，不要附加任何解释，每项格式为：
+{"keyword": "词条名", "category": "分类", "aliases": ["别名"], "description": "一两句话的释义"}
+要求：只收录记录中真实出现的词条，释义忠实原文，不要编造；普通词汇与玩家场外发言不要收录。`
+
 const defaultAIPolishPrompt = "你是中文文本润色助手，润色以下TRPG文本，仅修正语病、不通顺处及不当用词，不改变原意。"
 
 func normalizeAIFeaturePrompt(featureKey string, prompt string) string {
@@ -907,6 +1028,10 @@ func normalizeAIFeaturePrompt(featureKey string, prompt string) string {
 		if trimmed == "" || trimmed == legacyAIBattleSummaryPrompt {
 			return defaultAIBattleSummaryPrompt
 		}
+	case "glossary_extract":
+		if trimmed == "" {
+			return defaultAIGlossaryPrompt
+		}
 	case "polish":
 		if trimmed == "" || trimmed == legacyAIPolishPrompt {
 			return defaultAIPolishPrompt
@@ -930,6 +1055,19 @@ func defaultAIFeatureConfig(featureKey string) AIFeatureConfig {
 				Mode: AIFeatureAccessAll,
 			},
 		}
+	case "glossary_extract":
+		return AIFeatureConfig{
+			Enabled:        false,
+			UserCustomOnly: false,
+			DefaultPrompt:  defaultAIGlossaryPrompt,
+			DefaultModel:   "deepseek-v4-flash",
+			Params: AIModelParams{
+				MaxInputChars: 12000,
+			},
+			Access: AIFeatureAccessConfig{
+				Mode: AIFeatureAccessAll,
+			},
+		}
 	default:
 		return AIFeatureConfig{
 			Enabled:        false,
@@ -984,6 +1122,7 @@ func NormalizeAIConfig(cfg AIConfig) AIConfig {
 		Pricing:          make([]AIModelPricingConfig, 0, len(cfg.Pricing)),
 		LogRetentionDays: cfg.LogRetentionDays,
 		QuotaDefault:     cfg.QuotaDefault,
+		Embedding:        cfg.Embedding,
 	}
 	if result.Routing.Mode == "" {
 		result.Routing.Mode = AIRoutingModeRoundRobin
@@ -1000,6 +1139,20 @@ func NormalizeAIConfig(cfg AIConfig) AIConfig {
 	if result.LogRetentionDays <= 0 {
 		result.LogRetentionDays = 30
 	}
+	result.Embedding.ProviderID = strings.TrimSpace(result.Embedding.ProviderID)
+	result.Embedding.Model = strings.TrimSpace(result.Embedding.Model)
+	if result.Embedding.Model == "" {
+		result.Embedding.Model = "text-embedding-3-small"
+	}
+	if result.Embedding.BatchSize <= 0 {
+		result.Embedding.BatchSize = 32
+	}
+	if result.Embedding.IntervalSec <= 0 {
+		result.Embedding.IntervalSec = 30
+	}
+	if strings.TrimSpace(result.Embedding.IndexDir) == "" {
+		result.Embedding.IndexDir = "./data/vector-index"
+	}
 
 	sourceProviders := cfg.Providers
 	if len(sourceProviders) == 0 {
@@ -1041,7 +1194,7 @@ func NormalizeAIConfig(cfg AIConfig) AIConfig {
 		})
 	}
 
-	for _, featureKey := range []string{"polish", "battle_summary"} {
+	for _, featureKey := range []string{"polish", "battle_summary", "glossary_extract"} {
 		feature := defaultAIFeatureConfig(featureKey)
 		if raw, ok := cfg.Features[featureKey]; ok {
 			feature.Enabled = raw.Enabled
@@ -1057,7 +1210,7 @@ func NormalizeAIConfig(cfg AIConfig) AIConfig {
 			feature.Access.UserIDs = normalizeAIIdentifierList(raw.Access.UserIDs)
 			feature.Access.WorldIDs = normalizeAIIdentifierList(raw.Access.WorldIDs)
 		}
-		if featureKey == "battle_summary" && feature.Params.MaxInputChars <= 0 {
+		if featureKey != "polish" && feature.Params.MaxInputChars <= 0 {
 			feature.Params.MaxInputChars = defaultAIFeatureConfig(featureKey).Params.MaxInputChars
 		}
 		if feature.Access.Mode == "" {
@@ -1494,6 +1647,56 @@ func applyEmailNotificationDefaults(cfg *EmailNotificationConfig) {
 	if pw := strings.TrimSpace(os.Getenv("SEALCHAT_SMTP_PASSWORD")); pw != "" {
 		cfg.SMTP.Password = pw
 	}
+	if strings.TrimSpace(cfg.Reply.ListenAddr) == "" {
+		cfg.Reply.ListenAddr = "127.0.0.1:2525"
+	}
+	if strings.TrimSpace(cfg.Reply.AddressPrefix) == "" {
+		cfg.Reply.AddressPrefix = "reply"
+	}
+	if cfg.Reply.TokenTTLHours <= 0 {
+		cfg.Reply.TokenTTLHours = 7 * 24
+	}
+	if cfg.Reply.MaxMessageKB <= 0 {
+		cfg.Reply.MaxMessageKB = 1024
+	}
+	if secret := strings.TrimSpace(os.Getenv("SEALCHAT_EMAIL_REPLY_SECRET")); secret != "" {
+		cfg.Reply.Secret = secret
+	}
+}
+
+func applyVoiceDefaults(cfg *VoiceConfig) {
+	if cfg == nil {
+		return
+	}
+	if cfg.MeshMaxParticipants <= 0 {
+		cfg.MeshMaxParticipants = 6
+	}
+	if cfg.MaxParticipants < 0 {
+		cfg.MaxParticipants = 0
+	}
+	if cfg.TURNCredentialTTL <= 0 {
+		cfg.TURNCredentialTTL = 86400
+	}
+	cfg.TURNSecret = strings.TrimSpace(cfg.TURNSecret)
+	cfg.SFU.URL = strings.TrimSpace(cfg.SFU.URL)
+	if cfg.SFU.URL == "" {
+		cfg.SFU.Enabled = false
+	}
+	servers := make([]VoiceICEServerConfig, 0, len(cfg.ICEServers))
+	for _, item := range cfg.ICEServers {
+		urls := make([]string, 0, len(item.URLs))
+		for _, u := range item.URLs {
+			if u = strings.TrimSpace(u); u != "" {
+				urls = append(urls, u)
+			}
+		}
+		if len(urls) == 0 {
+			continue
+		}
+		item.URLs = urls
+		servers = append(servers, item)
+	}
+	cfg.ICEServers = servers
 }
 
 func applyEmailAuthDefaults(cfg *EmailAuthConfig) {
@@ -1732,6 +1935,7 @@ func WriteConfig(config *AppConfig) {
 		_ = k.Set("imageSizeLimit", config.ImageSizeLimit)
 		_ = k.Set("imageCompress", config.ImageCompress)
 		_ = k.Set("imageCompressQuality", config.ImageCompressQuality)
+		_ = k.Set("imageStripMetadata", config.ImageStripMetadata)
 		_ = k.Set("keywordMaxLength", config.KeywordMaxLength)
 		_ = k.Set("builtInSealBotEnable", config.BuiltInSealBotEnable)
 		_ = k.Set("botIncomingParenAsOoc", config.BotIncomingParenAsOOC)
@@ -1774,6 +1978,7 @@ func WriteConfig(config *AppConfig) {
 		_ = k.Set("ai.pricing", config.AI.Pricing)
 		_ = k.Set("ai.logRetentionDays", config.AI.LogRetentionDays)
 		_ = k.Set("ai.quotaDefault", config.AI.QuotaDefault)
+		_ = k.Set("ai.embedding", config.AI.Embedding)
 		_ = k.Set("performanceProfiler.enabled", config.PerformanceProfiler.Enabled)
 		_ = k.Set("performanceProfiler.outputDir", config.PerformanceProfiler.OutputDir)
 		_ = k.Set("performanceProfiler.lightSampleIntervalSec", config.PerformanceProfiler.LightSampleIntervalSec)
@@ -1792,6 +1997,19 @@ func WriteConfig(config *AppConfig) {
 		_ = k.Set("audio.ffmpegPath", config.Audio.FFmpegPath)
 		_ = k.Set("audio.allowWorldAudioWorkbench", config.Audio.AllowWorldAudioWorkbench)
 		_ = k.Set("audio.allowNonAdminCreateWorld", config.Audio.AllowNonAdminCreateWorld)
+		_ = k.Set("audio.enableLoudnessAnalysis", config.Audio.EnableLoudnessAnalysis)
+		_ = k.Set("audio.loudnessTargetLufs", config.Audio.LoudnessTargetLUFS)
+		_ = k.Set("audio.loudnessMaxTruePeakDb", config.Audio.LoudnessMaxTruePeakDB)
+		_ = k.Set("audio.waveformPoints", config.Audio.WaveformPoints)
+		_ = k.Set("voice.enabled", config.Voice.Enabled)
+		_ = k.Set("voice.meshMaxParticipants", config.Voice.MeshMaxParticipants)
+		_ = k.Set("voice.maxParticipants", config.Voice.MaxParticipants)
+		_ = k.Set("voice.iceServers", config.Voice.ICEServers)
+		_ = k.Set("voice.turnSecret", config.Voice.TURNSecret)
+		_ = k.Set("voice.turnCredentialTtl", config.Voice.TURNCredentialTTL)
+		_ = k.Set("voice.sfu.enabled", config.Voice.SFU.Enabled)
+		_ = k.Set("voice.sfu.url", config.Voice.SFU.URL)
+		_ = k.Set("voice.sfu.secret", config.Voice.SFU.Secret)
 		_ = k.Set("sqlite.wal", config.SQLite.EnableWAL)
 		_ = k.Set("sqlite.busyTimeout", config.SQLite.BusyTimeoutMS)
 		_ = k.Set("sqlite.cacheSizeKB", config.SQLite.CacheSizeKB)
@@ -0,0 +1,360 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+
+	_ "golang.org/x/image/webp"
+)
+
+// ImageSanitizeResult 图片元数据清理结果；Changed 为 false 时 Data 即原始数据
+type ImageSanitizeResult struct {
+	Data     []byte
+	MimeType string
+	Changed  bool
+	Rotated  bool // 按 EXIF 方向重新绘制了像素
+}
+
+var errImageMetadataMalformed = errors.New("图片结构无法解析")
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// IsMetadataSanitizableImage 判断 MIME 类型是否属于会清理元数据的图片格式
+func IsMetadataSanitizableImage(mimeType string) bool {
+	switch strings.ToLower(strings.TrimSpace(mimeType)) {
+	case "image/jpeg", "image/jpg", "image/png", "image/webp", "image/heic", "image/heif", "image/avif":
+		return true
+	}
+	return false
+}
+
+// SanitizeImageMetadata 清除 JPEG/PNG/WebP/HEIC 中的 EXIF、XMP、IPTC 与文本元数据，
+// 带方向信息的图片会先把方向烘焙进像素，避免去掉 EXIF 后显示歪斜。ICC 色彩配置会保留。
+// 无法识别的格式原样返回。
+func SanitizeImageMetadata(data []byte) (ImageSanitizeResult, error) {
+	result := ImageSanitizeResult{Data: data, MimeType: http.DetectContentType(data)}
+	var (
+		out     []byte
+		rotated bool
+		err     error
+	)
+	switch {
+	case len(data) > 3 && data[0] == 0xFF && data[1] == 0xD8:
+		result.MimeType = "image/jpeg"
+		out, rotated, err = sanitizeJPEGMetadata(data)
+	case bytes.HasPrefix(data, pngSignature):
+		result.MimeType = "image/png"
+		out, rotated, err = sanitizePNGMetadata(data)
+	case len(data) > 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		result.MimeType = "image/webp"
+		out, rotated, err = sanitizeWebPMetadata(data)
+	case isHEIFData(data):
+		result.MimeType = "image/heic"
+		out, err = sanitizeHEIFMetadata(data)
+	default:
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	if out != nil && !bytes.Equal(out, data) {
+		result.Data = out
+		result.Changed = true
+		result.Rotated = rotated
+	}
+	return result, nil
+}
+
+// sanitizeJPEGMetadata 丢弃 APP1(EXIF/XMP)、APP13(IPTC)、COM 等段，保留 JFIF、ICC 与 Adobe 段
+func sanitizeJPEGMetadata(data []byte) ([]byte, bool, error) {
+	var (
+		kept        bytes.Buffer
+		iccSegments [][]byte
+		orientation = 1
+	)
+	kept.Write(data[:2])
+	pos := 2
+	for pos < len(data) {
+		if data[pos] != 0xFF {
+			return nil, false, errImageMetadataMalformed
+		}
+		for pos < len(data) && data[pos] == 0xFF {
+			pos++
+		}
+		if pos >= len(data) {
+			return nil, false, errImageMetadataMalformed
+		}
+		marker := data[pos]
+		pos++
+		if marker == 0xD9 {
+			// EOI 之后的 MPF 副图、动态照片视频等尾随数据可能带有独立的 EXIF，一律丢弃
+			kept.Write([]byte{0xFF, marker})
+			break
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			kept.Write([]byte{0xFF, marker})
+			continue
+		}
+		if pos+2 > len(data) {
+			return nil, false, errImageMetadataMalformed
+		}
+		length := int(binary.BigEndian.Uint16(data[pos : pos+2]))
+		if length < 2 || pos+length > len(data) {
+			return nil, false, errImageMetadataMalformed
+		}
+		segment := data[pos-2 : pos+length]
+		payload := data[pos+2 : pos+length]
+		pos += length
+		if marker == 0xDA {
+			// 熵编码数据原样保留，遇到下一个标记（渐进式的后续段或 EOI）后继续解析
+			end := jpegScanEnd(data, pos)
+			kept.Write(segment)
+			kept.Write(data[pos:end])
+			pos = end
+			continue
+		}
+		switch {
+		case marker == 0xE1:
+			if bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+				if value, ok := readEXIFOrientation(payload[6:]); ok {
+					orientation = value
+				}
+			}
+			continue
+		case marker == 0xE2:
+			if !bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00")) {
+				continue
+			}
+			iccSegments = append(iccSegments, segment)
+		case marker == 0xE0, marker == 0xEE:
+		case marker >= 0xE3 && marker <= 0xEF, marker == 0xFE:
+			continue
+		}
+		kept.Write(segment)
+	}
+	if orientation <= 1 || orientation > 8 {
+		return kept.Bytes(), false, nil
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		// 像素无法解码时只去除元数据
+		return kept.Bytes(), false, nil
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, ApplyImageOrientation(img, orientation), &jpeg.Options{Quality: 92}); err != nil {
+		return nil, false, err
+	}
+	out := encoded.Bytes()
+	if len(iccSegments) == 0 {
+		return out, true, nil
+	}
+	var withICC bytes.Buffer
+	withICC.Write(out[:2])
+	for _, segment := range iccSegments {
+		withICC.Write(segment)
+	}
+	withICC.Write(out[2:])
+	return withICC.Bytes(), true, nil
+}
+
+// jpegScanEnd 返回从 start 开始的熵编码数据之后第一个标记的位置，跳过填充字节与 RST 标记
+func jpegScanEnd(data []byte, start int) int {
+	for i := start; i+1 < len(data); i++ {
+		if data[i] != 0xFF {
+			continue
+		}
+		next := data[i+1]
+		if next == 0x00 || next == 0xFF || (next >= 0xD0 && next <= 0xD7) {
+			continue
+		}
+		return i
+	}
+	return len(data)
+}
+
+// sanitizePNGMetadata 丢弃 eXIf 与文本/时间块
+func sanitizePNGMetadata(data []byte) ([]byte, bool, error) {
+	var kept bytes.Buffer
+	kept.Write(pngSignature)
+	orientation := 1
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, false, errImageMetadataMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, false, errImageMetadataMalformed
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		switch chunkType {
+		case "eXIf":
+			if value, ok := readEXIFOrientation(data[pos+8 : pos+8+length]); ok {
+				orientation = value
+			}
+		case "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			kept.Write(data[pos:end])
+		}
+		pos = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	if orientation <= 1 || orientation > 8 {
+		return kept.Bytes(), false, nil
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return kept.Bytes(), false, nil
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, ApplyImageOrientation(img, orientation)); err != nil {
+		return nil, false, err
+	}
+	return encoded.Bytes(), true, nil
+}
+
+// sanitizeWebPMetadata 移除 EXIF 与 XMP 块并清掉 VP8X 中对应的标志位
+func sanitizeWebPMetadata(data []byte) ([]byte, bool, error) {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	orientation := 1
+	animated := false
+	vp8xFlags := -1
+	pos := 12
+	for pos+8 <= len(data) {
+		fourCC := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + size + size%2
+		if pos+8+size > len(data) {
+			return nil, false, errImageMetadataMalformed
+		}
+		if end > len(data) {
+			end = len(data)
+		}
+		switch fourCC {
+		case "EXIF":
+			if value, ok := readEXIFOrientation(bytes.TrimPrefix(data[pos+8:pos+8+size], []byte("Exif\x00\x00"))); ok {
+				orientation = value
+			}
+		case "XMP ":
+		default:
+			if fourCC == "VP8X" && size > 0 {
+				vp8xFlags = body.Len() + 8
+				animated = data[pos+8]&0x02 != 0
+			}
+			body.Write(data[pos:end])
+		}
+		pos = end
+	}
+	out := body.Bytes()
+	if vp8xFlags >= 0 {
+		out[vp8xFlags] &^= 0x08 | 0x04
+	}
+	riff := make([]byte, 8, 8+len(out))
+	copy(riff, "RIFF")
+	binary.LittleEndian.PutUint32(riff[4:], uint32(len(out)))
+	riff = append(riff, out...)
+	if orientation <= 1 || orientation > 8 || animated {
+		return riff, false, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return riff, false, nil
+	}
+	encoded, err := EncodeImageToWebPWithCWebP(ApplyImageOrientation(img, orientation), 90)
+	if err != nil || len(encoded) == 0 {
+		// 没有 cwebp 时退回为仅去除元数据
+		return riff, false, nil
+	}
+	return encoded, true, nil
+}
+
+// readEXIFOrientation 从 TIFF 结构的 IFD0 中读取方向标签 0x0112
+func readEXIFOrientation(tiff []byte) (int, bool) {
+	if len(tiff) < 8 {
+		return 0, false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, false
+	}
+	if order.Uint16(tiff[2:4]) != 42 {
+		return 0, false
+	}
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0, false
+	}
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0, false
+		}
+		if order.Uint16(tiff[entry:entry+2]) != 0x0112 {
+			continue
+		}
+		value := int(order.Uint16(tiff[entry+8 : entry+10]))
+		if value < 1 || value > 8 {
+			return 0, false
+		}
+		return value, true
+	}
+	return 0, false
+}
+
+// ApplyImageOrientation 按 EXIF 方向值（1-8）旋转或翻转图片
+func ApplyImageOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	w, h := bounds.Dx(), bounds.Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			si := y*src.Stride + x*4
+			di := dy*dst.Stride + dx*4
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
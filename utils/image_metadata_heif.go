@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// HEIF/HEIC 的方向由 irot/imir 属性描述，不依赖 EXIF；这里不重排容器，
+// 只把 Exif 与 XMP 条目的数据区清零，偏移量保持不变，解码器仍能正常读取。
+
+type heifBox struct {
+	boxType string
+	start   int // 内容起始位置（不含头）
+	end     int
+}
+
+func isHEIFData(data []byte) bool {
+	if len(data) < 12 || string(data[4:8]) != "ftyp" {
+		return false
+	}
+	size := int(binary.BigEndian.Uint32(data[0:4]))
+	if size < 16 || size > len(data) {
+		return false
+	}
+	for pos := 8; pos+4 <= size; pos += 4 {
+		if pos == 12 {
+			continue // minor_version
+		}
+		switch string(data[pos : pos+4]) {
+		case "heic", "heix", "heim", "heis", "hevc", "hevx", "mif1", "msf1", "avif":
+			return true
+		}
+	}
+	return false
+}
+
+func readHEIFBoxes(data []byte, start, end int) ([]heifBox, error) {
+	var boxes []heifBox
+	pos := start
+	for pos+8 <= end {
+		size := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		boxType := string(data[pos+4 : pos+8])
+		header := 8
+		switch size {
+		case 0:
+			size = end - pos
+		case 1:
+			if pos+16 > end {
+				return nil, errImageMetadataMalformed
+			}
+			size = int(binary.BigEndian.Uint64(data[pos+8 : pos+16]))
+			header = 16
+		}
+		if size < header || pos+size > end {
+			return nil, errImageMetadataMalformed
+		}
+		boxes = append(boxes, heifBox{boxType: boxType, start: pos + header, end: pos + size})
+		pos += size
+	}
+	return boxes, nil
+}
+
+func findHEIFBox(boxes []heifBox, boxType string) (heifBox, bool) {
+	for _, box := range boxes {
+		if box.boxType == boxType {
+			return box, true
+		}
+	}
+	return heifBox{}, false
+}
+
+func sanitizeHEIFMetadata(data []byte) ([]byte, error) {
+	top, err := readHEIFBoxes(data, 0, len(data))
+	if err != nil {
+		return nil, err
+	}
+	meta, ok := findHEIFBox(top, "meta")
+	if !ok || meta.start+4 > meta.end {
+		return data, nil
+	}
+	children, err := readHEIFBoxes(data, meta.start+4, meta.end)
+	if err != nil {
+		return nil, err
+	}
+	iinf, ok := findHEIFBox(children, "iinf")
+	if !ok {
+		return data, nil
+	}
+	targets, err := heifMetadataItemIDs(data, iinf)
+	if err != nil || len(targets) == 0 {
+		return data, err
+	}
+	iloc, ok := findHEIFBox(children, "iloc")
+	if !ok {
+		return data, nil
+	}
+	extents, err := heifItemExtents(data, iloc, targets)
+	if err != nil {
+		return nil, err
+	}
+	if len(extents) == 0 {
+		return data, nil
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	for _, extent := range extents {
+		clear(out[extent[0]:extent[1]])
+	}
+	return out, nil
+}
+
+// heifMetadataItemIDs 返回 Exif 条目与 XMP（mime 类型为 application/rdf+xml）条目的 ID
+func heifMetadataItemIDs(data []byte, iinf heifBox) (map[uint32]struct{}, error) {
+	if iinf.start+4 > iinf.end {
+		return nil, errImageMetadataMalformed
+	}
+	pos := iinf.start + 4
+	if data[iinf.start] == 0 {
+		pos += 2
+	} else {
+		pos += 4
+	}
+	entries, err := readHEIFBoxes(data, pos, iinf.end)
+	if err != nil {
+		return nil, err
+	}
+	targets := map[uint32]struct{}{}
+	for _, entry := range entries {
+		if entry.boxType != "infe" || entry.start+4 > entry.end {
+			continue
+		}
+		version := data[entry.start]
+		if version < 2 {
+			continue
+		}
+		p := entry.start + 4
+		var itemID uint32
+		if version == 2 {
+			if p+2 > entry.end {
+				continue
+			}
+			itemID = uint32(binary.BigEndian.Uint16(data[p : p+2]))
+			p += 2
+		} else {
+			if p+4 > entry.end {
+				continue
+			}
+			itemID = binary.BigEndian.Uint32(data[p : p+4])
+			p += 4
+		}
+		p += 2 // item_protection_index
+		if p+4 > entry.end {
+			continue
+		}
+		itemType := string(data[p : p+4])
+		p += 4
+		switch itemType {
+		case "Exif":
+			targets[itemID] = struct{}{}
+		case "mime":
+			rest := strings.Split(string(data[p:entry.end]), "\x00")
+			if len(rest) > 1 && strings.Contains(rest[1], "rdf+xml") {
+				targets[itemID] = struct{}{}
+			}
+		}
+	}
+	return targets, nil
+}
+
+// heifItemExtents 读取 iloc 中目标条目在文件内的数据区间，只处理按文件偏移存放（construction_method 0）的条目
+func heifItemExtents(data []byte, iloc heifBox, targets map[uint32]struct{}) ([][2]int, error) {
+	p := iloc.start
+	if p+6 > iloc.end {
+		return nil, errImageMetadataMalformed
+	}
+	version := data[p]
+	p += 4
+	offsetSize := int(data[p] >> 4)
+	lengthSize := int(data[p] & 0x0F)
+	baseOffsetSize := int(data[p+1] >> 4)
+	indexSize := 0
+	if version == 1 || version == 2 {
+		indexSize = int(data[p+1] & 0x0F)
+	}
+	p += 2
+	readUint := func(size int) (int, bool) {
+		if p+size > iloc.end {
+			return 0, false
+		}
+		var value uint64
+		for i := 0; i < size; i++ {
+			value = value<<8 | uint64(data[p+i])
+		}
+		p += size
+		return int(value), true
+	}
+	itemCountSize := 2
+	if version == 2 {
+		itemCountSize = 4
+	}
+	itemCount, ok := readUint(itemCountSize)
+	if !ok {
+		return nil, errImageMetadataMalformed
+	}
+	var extents [][2]int
+	for i := 0; i < itemCount; i++ {
+		idSize := 2
+		if version == 2 {
+			idSize = 4
+		}
+		itemID, ok := readUint(idSize)
+		if !ok {
+			return nil, errImageMetadataMalformed
+		}
+		method := 0
+		if version == 1 || version == 2 {
+			value, ok := readUint(2)
+			if !ok {
+				return nil, errImageMetadataMalformed
+			}
+			method = value & 0x0F
+		}
+		if _, ok := readUint(2); !ok { // data_reference_index
+			return nil, errImageMetadataMalformed
+		}
+		baseOffset, ok := readUint(baseOffsetSize)
+		if !ok {
+			return nil, errImageMetadataMalformed
+		}
+		extentCount, ok := readUint(2)
+		if !ok {
+			return nil, errImageMetadataMalformed
+		}
+		_, wanted := targets[uint32(itemID)]
+		for j := 0; j < extentCount; j++ {
+			if _, ok := readUint(indexSize); !ok {
+				return nil, errImageMetadataMalformed
+			}
+			offset, ok := readUint(offsetSize)
+			if !ok {
+				return nil, errImageMetadataMalformed
+			}
+			length, ok := readUint(lengthSize)
+			if !ok {
+				return nil, errImageMetadataMalformed
+			}
+			if !wanted || method != 0 {
+				continue
+			}
+			start := baseOffset + offset
+			if start < 0 || length <= 0 || start+length > len(data) {
+				continue
+			}
+			extents = append(extents, [2]int{start, start + length})
+		}
+	}
+	return extents, nil
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func buildTestEXIF(orientation uint16) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	// 附带一段伪造的 GPS 文本，便于确认已被移除
+	return append(tiff, []byte("GPS 31.2304N 121.4737E")...)
+}
+
+func buildTestJPEG(t *testing.T, orientation uint16) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, nil); err != nil {
+		t.Fatalf("encode jpeg failed: %v", err)
+	}
+	payload := append([]byte("Exif\x00\x00"), buildTestEXIF(orientation)...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+	data := append([]byte{}, encoded.Bytes()[:2]...)
+	data = append(data, segment...)
+	return append(data, encoded.Bytes()[2:]...)
+}
+
+func TestSanitizeImageMetadataJPEGBakesOrientation(t *testing.T) {
+	result, err := SanitizeImageMetadata(buildTestJPEG(t, 6))
+	if err != nil {
+		t.Fatalf("sanitize failed: %v", err)
+	}
+	if !result.Changed || !result.Rotated || result.MimeType != "image/jpeg" {
+		t.Fatalf("unexpected result flags: %+v", result)
+	}
+	if bytes.Contains(result.Data, []byte("Exif")) || bytes.Contains(result.Data, []byte("GPS")) {
+		t.Fatalf("exif should be removed")
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatalf("decode sanitized jpeg failed: %v", err)
+	}
+	if cfg.Width != 2 || cfg.Height != 4 {
+		t.Fatalf("orientation 6 should swap dimensions, got %dx%d", cfg.Width, cfg.Height)
+	}
+
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	src.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	rotated := ApplyImageOrientation(src, 6)
+	if r, _, _, _ := rotated.At(1, 0).RGBA(); r == 0 {
+		t.Fatalf("orientation 6 should move top-left pixel to top-right")
+	}
+
+	plain, err := SanitizeImageMetadata(buildTestJPEG(t, 1))
+	if err != nil || !plain.Changed || plain.Rotated || bytes.Contains(plain.Data, []byte("GPS")) {
+		t.Fatalf("upright jpeg should only drop metadata: %+v %v", plain.Rotated, err)
+	}
+	again, err := SanitizeImageMetadata(plain.Data)
+	if err != nil || again.Changed {
+		t.Fatalf("sanitizing clean jpeg should be a no-op: changed=%v err=%v", again.Changed, err)
+	}
+}
+
+func TestSanitizeImageMetadataJPEGDropsTrailingImage(t *testing.T) {
+	// 模拟 MPF 副图：主图 EOI 之后紧跟一张带 EXIF 的 JPEG
+	primary := buildTestJPEG(t, 1)
+	data := append(append([]byte{}, primary...), buildTestJPEG(t, 1)...)
+	result, err := SanitizeImageMetadata(data)
+	if err != nil {
+		t.Fatalf("sanitize failed: %v", err)
+	}
+	if !result.Changed || result.Rotated {
+		t.Fatalf("unexpected result flags: %+v", result)
+	}
+	if bytes.Contains(result.Data, []byte("Exif")) || bytes.Contains(result.Data, []byte("GPS")) {
+		t.Fatalf("trailing image metadata should be removed")
+	}
+	if !bytes.HasSuffix(result.Data, []byte{0xFF, 0xD9}) {
+		t.Fatalf("output should end at the primary image EOI")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(result.Data)); err != nil {
+		t.Fatalf("decode sanitized jpeg failed: %v", err)
+	}
+}
+
+func TestSanitizeImageMetadataPNGAndWebPChunks(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatalf("encode png failed: %v", err)
+	}
+	chunk := func(kind string, body []byte) []byte {
+		out := binary.BigEndian.AppendUint32(nil, uint32(len(body)))
+		out = append(out, kind...)
+		out = append(out, body...)
+		return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(append([]byte(kind), body...)))
+	}
+	raw := encoded.Bytes()
+	withText := append(append([]byte{}, raw[:33]...), chunk("tEXt", []byte("Comment\x00home address"))...)
+	withText = append(withText, raw[33:]...)
+	result, err := SanitizeImageMetadata(withText)
+	if err != nil || !result.Changed || bytes.Contains(result.Data, []byte("home address")) {
+		t.Fatalf("png text chunk should be removed: changed=%v err=%v", result.Changed, err)
+	}
+	if _, err := png.Decode(bytes.NewReader(result.Data)); err != nil {
+		t.Fatalf("sanitized png should decode: %v", err)
+	}
+
+	riffChunk := func(kind string, body []byte) []byte {
+		out := append([]byte(kind), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+		out = append(out, body...)
+		if len(body)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	body := []byte("WEBP")
+	body = append(body, riffChunk("VP8X", []byte{0x08 | 0x04, 0, 0, 0, 1, 0, 0, 1, 0, 0})...)
+	body = append(body, riffChunk("VP8L", []byte{0x2f, 0, 0, 0, 0})...)
+	body = append(body, riffChunk("EXIF", buildTestEXIF(1))...)
+	body = append(body, riffChunk("XMP ", []byte("<x:xmpmeta/>"))...)
+	webp := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	webp = append(webp, body...)
+	result, err = SanitizeImageMetadata(webp)
+	if err != nil || !result.Changed {
+		t.Fatalf("webp metadata should be removed: changed=%v err=%v", result.Changed, err)
+	}
+	if bytes.Contains(result.Data, []byte("EXIF")) || bytes.Contains(result.Data, []byte("xmpmeta")) {
+		t.Fatalf("webp still contains metadata chunks")
+	}
+	if result.Data[20]&(0x08|0x04) != 0 {
+		t.Fatalf("vp8x metadata flags should be cleared, got %#x", result.Data[20])
+	}
+	if size := binary.LittleEndian.Uint32(result.Data[4:8]); int(size) != len(result.Data)-8 {
+		t.Fatalf("riff size not updated: %d vs %d", size, len(result.Data)-8)
+	}
+}
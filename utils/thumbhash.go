@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// thumbHashMaxSide ThumbHash 要求输入不超过 100x100
+	thumbHashMaxSide = 100
+	// imagePlaceholderMaxPixels 超过该像素数只记录尺寸，避免解码超大图片占用过多内存
+	imagePlaceholderMaxPixels = 64 * 1024 * 1024
+)
+
+// ImagePlaceholder 图片的原始尺寸与 base64 编码的 ThumbHash
+type ImagePlaceholder struct {
+	Width     int
+	Height    int
+	ThumbHash string
+}
+
+// ComputeImagePlaceholder 读取图片尺寸并生成 ThumbHash；无法解码像素时仍返回尺寸
+func ComputeImagePlaceholder(data []byte) (ImagePlaceholder, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ImagePlaceholder{}, err
+	}
+	result := ImagePlaceholder{Width: cfg.Width, Height: cfg.Height}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > imagePlaceholderMaxPixels {
+		return result, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return result, nil
+	}
+	result.ThumbHash = base64.StdEncoding.EncodeToString(EncodeThumbHash(img))
+	return result, nil
+}
+
+// EncodeThumbHash 按 ThumbHash 规范编码图片，输入会先缩放到 100px 以内
+func EncodeThumbHash(img image.Image) []byte {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	if w > thumbHashMaxSide || h > thumbHashMaxSide {
+		if w >= h {
+			h = max(1, int(math.Round(float64(h)*thumbHashMaxSide/float64(w))))
+			w = thumbHashMaxSide
+		} else {
+			w = max(1, int(math.Round(float64(w)*thumbHashMaxSide/float64(h))))
+			h = thumbHashMaxSide
+		}
+	}
+	small := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, bounds, draw.Src, nil)
+	return rgbaToThumbHash(w, h, small.Pix)
+}
+
+// jsRound 与参考实现中的 Math.round 保持一致
+func jsRound(v float64) int {
+	return int(math.Floor(v + 0.5))
+}
+
+func rgbaToThumbHash(w, h int, rgba []byte) []byte {
+	var avgR, avgG, avgB, avgA float64
+	for i, j := 0, 0; i < w*h; i, j = i+1, j+4 {
+		alpha := float64(rgba[j+3]) / 255
+		avgR += alpha / 255 * float64(rgba[j])
+		avgG += alpha / 255 * float64(rgba[j+1])
+		avgB += alpha / 255 * float64(rgba[j+2])
+		avgA += alpha
+	}
+	if avgA > 0 {
+		avgR /= avgA
+		avgG /= avgA
+		avgB /= avgA
+	}
+
+	hasAlpha := avgA < float64(w*h)
+	lLimit := 7
+	if hasAlpha {
+		// 有透明通道时亮度少用一些位
+		lLimit = 5
+	}
+	maxSide := float64(max(w, h))
+	lx := max(1, jsRound(float64(lLimit*w)/maxSide))
+	ly := max(1, jsRound(float64(lLimit*h)/maxSide))
+
+	// 转换到 LPQA，并以平均色为底合成透明像素
+	l := make([]float64, w*h)
+	p := make([]float64, w*h)
+	q := make([]float64, w*h)
+	a := make([]float64, w*h)
+	for i, j := 0, 0; i < w*h; i, j = i+1, j+4 {
+		alpha := float64(rgba[j+3]) / 255
+		r := avgR*(1-alpha) + alpha/255*float64(rgba[j])
+		g := avgG*(1-alpha) + alpha/255*float64(rgba[j+1])
+		b := avgB*(1-alpha) + alpha/255*float64(rgba[j+2])
+		l[i] = (r + g + b) / 3
+		p[i] = (r+g)/2 - b
+		q[i] = r - g
+		a[i] = alpha
+	}
+
+	encodeChannel := func(channel []float64, nx, ny int) (float64, []float64, float64) {
+		var dc, scale float64
+		var ac []float64
+		fx := make([]float64, w)
+		for cy := 0; cy < ny; cy++ {
+			for cx := 0; cx*ny < nx*(ny-cy); cx++ {
+				for x := 0; x < w; x++ {
+					fx[x] = math.Cos(math.Pi / float64(w) * float64(cx) * (float64(x) + 0.5))
+				}
+				var f float64
+				for y := 0; y < h; y++ {
+					fy := math.Cos(math.Pi / float64(h) * float64(cy) * (float64(y) + 0.5))
+					for x := 0; x < w; x++ {
+						f += channel[x+y*w] * fx[x] * fy
+					}
+				}
+				f /= float64(w * h)
+				if cx > 0 || cy > 0 {
+					ac = append(ac, f)
+					scale = math.Max(scale, math.Abs(f))
+				} else {
+					dc = f
+				}
+			}
+		}
+		if scale > 0 {
+			for i := range ac {
+				ac[i] = 0.5 + 0.5/scale*ac[i]
+			}
+		}
+		return dc, ac, scale
+	}
+	lDC, lAC, lScale := encodeChannel(l, max(3, lx), max(3, ly))
+	pDC, pAC, pScale := encodeChannel(p, 3, 3)
+	qDC, qAC, qScale := encodeChannel(q, 3, 3)
+	var aDC, aScale float64
+	var aAC []float64
+	if hasAlpha {
+		aDC, aAC, aScale = encodeChannel(a, 5, 5)
+	}
+
+	isLandscape := w > h
+	header24 := jsRound(63*lDC) | jsRound(31.5+31.5*pDC)<<6 | jsRound(31.5+31.5*qDC)<<12 | jsRound(31*lScale)<<18
+	if hasAlpha {
+		header24 |= 1 << 23
+	}
+	header16 := lx
+	if isLandscape {
+		header16 = ly
+	}
+	header16 |= jsRound(63*pScale)<<3 | jsRound(63*qScale)<<9
+	if isLandscape {
+		header16 |= 1 << 15
+	}
+
+	channels := [][]float64{lAC, pAC, qAC}
+	acStart := 5
+	if hasAlpha {
+		channels = append(channels, aAC)
+		acStart = 6
+	}
+	acCount := 0
+	for _, ac := range channels {
+		acCount += len(ac)
+	}
+	hash := make([]byte, acStart+(acCount+1)/2)
+	hash[0] = byte(header24)
+	hash[1] = byte(header24 >> 8)
+	hash[2] = byte(header24 >> 16)
+	hash[3] = byte(header16)
+	hash[4] = byte(header16 >> 8)
+	if hasAlpha {
+		hash[5] = byte(jsRound(15*aDC) | jsRound(15*aScale)<<4)
+	}
+	index := 0
+	for _, ac := range channels {
+		for _, f := range ac {
+			hash[acStart+index>>1] |= byte(jsRound(15*f) << ((index & 1) << 2))
+			index++
+		}
+	}
+	return hash
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestComputeImagePlaceholder(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 320, 160))
+	for y := 0; y < 160; y++ {
+		for x := 0; x < 320; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 255 / 320), G: 80, B: uint8(y * 255 / 160), A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		t.Fatalf("encode png failed: %v", err)
+	}
+	placeholder, err := ComputeImagePlaceholder(encoded.Bytes())
+	if err != nil {
+		t.Fatalf("compute placeholder failed: %v", err)
+	}
+	if placeholder.Width != 320 || placeholder.Height != 160 {
+		t.Fatalf("unexpected size %dx%d", placeholder.Width, placeholder.Height)
+	}
+	hash, err := base64.StdEncoding.DecodeString(placeholder.ThumbHash)
+	if err != nil || len(hash) < 5 || len(hash) > 25 {
+		t.Fatalf("unexpected thumbhash %q: %v", placeholder.ThumbHash, err)
+	}
+	if hash[4]&0x80 == 0 {
+		t.Fatalf("landscape flag should be set")
+	}
+	if hash[2]&0x80 != 0 {
+		t.Fatalf("opaque image should not set alpha flag")
+	}
+
+	// 与参考实现的输出对照
+	pixels := make([]byte, 0, 8*5*4)
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 8; x++ {
+			pixels = append(pixels, byte((x*37+y*11)%256), byte((x*5+y*53)%256), byte((x*y*7)%256), 255)
+		}
+	}
+	if got := base64.StdEncoding.EncodeToString(rgbaToThumbHash(8, 5, pixels)); got != "GOoNJJhTaXiAiIeHd3cLFVbydw==" {
+		t.Fatalf("thumbhash mismatch with reference: %s", got)
+	}
+
+	if _, err := ComputeImagePlaceholder([]byte("not an image")); err == nil {
+		t.Fatalf("non-image data should fail")
+	}
+}